@@ -19,7 +19,9 @@ import (
 
 	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers"
+	"github.com/aws/karpenter-provider-aws/pkg/debug"
 	"github.com/aws/karpenter-provider-aws/pkg/operator"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/webhooks"
 
 	"sigs.k8s.io/karpenter/pkg/cloudprovider/metrics"
@@ -38,9 +40,22 @@ func main() {
 		op.GetClient(),
 		op.AMIProvider,
 		op.SecurityGroupProvider,
+		op.AlertTracker,
+		op.UnavailableOfferingsCache,
 	)
 	lo.Must0(op.AddHealthzCheck("cloud-provider", awsCloudProvider.LivenessProbe))
 	cloudProvider := metrics.Decorate(awsCloudProvider)
+	if port := options.FromContext(ctx).DebugEndpointsPort; port != 0 {
+		lo.Must0(op.Manager.Add(debug.NewServer(options.FromContext(ctx).DebugEndpointsAddress, port).
+			With("/debug/explain", debug.NewExplainHandler(op.GetClient(), awsCloudProvider, op.UnavailableOfferingsCache)).
+			With("/debug/launch-templates", debug.NewLaunchTemplateHandler(op.GetClient(), awsCloudProvider, op.LaunchTemplateProvider)).
+			With("/debug/caches/amis", debug.NewAMICacheHandler(op.AMICache)).
+			With("/debug/caches/launch-templates", debug.NewLaunchTemplateCacheHandler(op.LaunchTemplateCache)).
+			With("/debug/caches/unavailable-offerings", debug.NewUnavailableOfferingsHandler(op.UnavailableOfferingsCache)).
+			With("/debug/caches/spot-interruptions", debug.NewSpotInterruptionHistoryHandler(op.SpotInterruptionHistory)).
+			With("/debug/pricing", debug.NewPricingHandler(op.PricingProvider, op.Clock)),
+		))
+	}
 
 	op.
 		WithControllers(ctx, corecontrollers.NewControllers(
@@ -59,15 +74,20 @@ func main() {
 			op.GetClient(),
 			op.EventRecorder,
 			op.UnavailableOfferingsCache,
+			op.ImpairedZonesCache,
+			op.SpotInterruptionHistory,
+			op.InterruptionWindows,
 			cloudProvider,
 			op.SubnetProvider,
 			op.SecurityGroupProvider,
 			op.InstanceProfileProvider,
 			op.InstanceProvider,
+			op.LaunchJournal,
 			op.PricingProvider,
 			op.AMIProvider,
 			op.LaunchTemplateProvider,
 			op.InstanceTypesProvider,
+			op.AlertTracker,
 		)...).
 		WithWebhooks(ctx, webhooks.NewWebhooks()...).
 		Start(ctx, cloudProvider)
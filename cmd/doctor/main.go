@@ -0,0 +1,76 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	"k8s.io/client-go/kubernetes/scheme"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/doctor"
+	"github.com/aws/karpenter-provider-aws/pkg/operator"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+)
+
+// main checks the prerequisites of Karpenter in the cluster of the current kubeconfig, and exits with a non-zero code
+// when any check fails. It's configured with the same flags and environment variables as the controller, and checks
+// the permissions of its own credentials, so it should run with the credentials of the controller.
+func main() {
+	ctx := injection.WithOptionsOrDie(context.Background(), coreoptions.Injectables...)
+	sess := session.Must(session.NewSession(operator.WithEndpoints(ctx, &aws.Config{
+		STSRegionalEndpoint: endpoints.RegionalSTSEndpoint,
+		HTTPClient:          lo.Must(operator.NewHTTPClient(ctx)),
+	})))
+	if aws.StringValue(sess.Config.Region) == "" {
+		sess.Config.Region = aws.String(lo.Must(ec2metadata.New(sess).Region()))
+	}
+	kubeClient := lo.Must(client.New(controllerruntime.GetConfigOrDie(), client.Options{Scheme: scheme.Scheme}))
+	ec2api := ec2.New(sess)
+	results := doctor.New(
+		kubeClient,
+		sts.New(sess),
+		iam.New(sess),
+		sqs.New(sess),
+		eks.New(sess),
+		eventbridge.New(sess),
+		subnet.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AvailableIPAddressTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AssociatePublicIPAddressTTL, awscache.DefaultCleanupInterval)),
+		securitygroup.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)),
+		operator.NewServiceChecks(ctx, sess),
+		aws.StringValue(sess.Config.Region),
+	).Run(ctx)
+	doctor.Print(os.Stdout, results)
+	if doctor.Failed(results) {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/samber/lo"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/importer"
+)
+
+type Options struct {
+	launchTemplate        string
+	launchTemplateVersion string
+	autoScalingGroup      string
+	name                  string
+	clusterName           string
+	region                string
+}
+
+func NewOptions() *Options {
+	o := &Options{}
+	flag.StringVar(&o.launchTemplate, "launch-template", "", "The ID or name of the launch template to import.")
+	flag.StringVar(&o.launchTemplateVersion, "launch-template-version", "$Default", "The version of the launch template to import, a version number, $Latest or $Default.")
+	flag.StringVar(&o.autoScalingGroup, "auto-scaling-group", "", "The name of the auto scaling group to import, instead of a launch template.")
+	flag.StringVar(&o.name, "name", "", "The name of the EC2NodeClass and the NodePool. Defaults to the name of the launch template or auto scaling group.")
+	flag.StringVar(&o.clusterName, "cluster-name", "", "Selects the subnets and security groups tagged with karpenter.sh/discovery=<cluster-name> when the launch template doesn't specify them.")
+	flag.StringVar(&o.region, "region", "", "The region of the launch template or auto scaling group. Defaults to the region of the AWS configuration.")
+	flag.Parse()
+	if (o.launchTemplate == "") == (o.autoScalingGroup == "") {
+		log.Fatal("exactly one of --launch-template or --auto-scaling-group must be set")
+	}
+	if o.name == "" {
+		o.name = objectName(lo.Ternary(o.autoScalingGroup != "", o.autoScalingGroup, o.launchTemplate))
+	}
+	return o
+}
+
+var invalidNameCharacters = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// objectName converts the name of a launch template or auto scaling group to the name of a Kubernetes object
+func objectName(name string) string {
+	return strings.Trim(invalidNameCharacters.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// main prints an EC2NodeClass and a NodePool that launch nodes equivalent to the nodes of a launch template or auto
+// scaling group. The output should be reviewed before it's applied, since settings without an equivalent, like custom
+// kubelet flags, aren't carried over.
+func main() {
+	opts := NewOptions()
+	ctx := context.Background()
+	sess := session.Must(session.NewSessionWithOptions(session.Options{
+		Config:            aws.Config{Region: lo.EmptyableToPtr(opts.region)},
+		SharedConfigState: session.SharedConfigEnable,
+	}))
+	i := importer.New(ec2.New(sess), autoscaling.New(sess), opts.clusterName)
+
+	var nodeClass *v1.EC2NodeClass
+	var nodePool *karpv1.NodePool
+	var err error
+	if opts.autoScalingGroup != "" {
+		nodeClass, nodePool, err = i.FromAutoScalingGroup(ctx, opts.autoScalingGroup, opts.name)
+	} else {
+		nodeClass, nodePool, err = i.FromLaunchTemplate(ctx, opts.launchTemplate, opts.launchTemplateVersion, opts.name)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	out := lo.Must(importer.Marshal(nodeClass, nodePool))
+	lo.Must(os.Stdout.Write(out))
+}
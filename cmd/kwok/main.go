@@ -0,0 +1,47 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"github.com/samber/lo"
+	"k8s.io/utils/env"
+	kwok "sigs.k8s.io/karpenter/kwok/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/metrics"
+	"sigs.k8s.io/karpenter/pkg/controllers"
+	"sigs.k8s.io/karpenter/pkg/operator"
+	"sigs.k8s.io/karpenter/pkg/webhooks"
+
+	awskwok "github.com/aws/karpenter-provider-aws/pkg/kwok"
+	// The options of the AWS cloud provider are parsed for the instance types, like vm-memory-overhead-percent
+	_ "github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+// main runs the kwok cloud provider with the instance types of the AWS cloud provider. KWOK_RECORDING is the path of
+// a recording of a region, which is captured with "make recording", and KWOK_REGION is the region whose static price
+// list is used when there's no recording.
+func main() {
+	ctx, op := operator.NewOperator()
+	instanceTypes := lo.Must(awskwok.ConstructInstanceTypes(ctx, env.GetString("KWOK_REGION", "us-west-2"), env.GetString("KWOK_RECORDING", "")))
+	cloudProvider := metrics.Decorate(kwok.NewCloudProvider(ctx, awskwok.NewClient(op.GetClient(), instanceTypes), instanceTypes.InstanceTypes))
+	op.
+		WithWebhooks(ctx, webhooks.NewWebhooks()...).
+		WithControllers(ctx, controllers.NewControllers(
+			op.Manager,
+			op.Clock,
+			op.GetClient(),
+			op.EventRecorder,
+			cloudProvider,
+		)...).Start(ctx, cloudProvider)
+}
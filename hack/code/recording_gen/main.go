@@ -0,0 +1,114 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
+	ssmp "github.com/aws/karpenter-provider-aws/pkg/providers/ssm"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/version"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+)
+
+type Options struct {
+	region      string
+	output      string
+	clusterName string
+}
+
+func NewOptions() *Options {
+	o := &Options{}
+	flag.StringVar(&o.region, "region", "us-west-2", "The region to record the responses of.")
+	flag.StringVar(&o.output, "output", "", "The destination for the recording. Defaults to pkg/fake/testdata/<region>.json.gz.")
+	flag.StringVar(&o.clusterName, "cluster-name", "", "Records the subnets and security groups tagged with karpenter.sh/discovery=<cluster-name>, when set.")
+	flag.Parse()
+	if o.output == "" {
+		o.output = fmt.Sprintf("pkg/fake/testdata/%s.json.gz", o.region)
+	}
+	return o
+}
+
+// The recording is captured by running the providers against the real APIs through the recording APIs, so that it has
+// the responses to the same calls that the providers make in a cluster
+func main() {
+	opts := NewOptions()
+	ctx := options.ToContext(context.Background(), test.Options())
+	sess := session.Must(session.NewSessionWithOptions(session.Options{
+		Config:            aws.Config{Region: aws.String(opts.region)},
+		SharedConfigState: session.SharedConfigEnable,
+	}))
+	recording := fake.NewRecording(opts.region)
+	ec2api := fake.NewRecordingEC2API(ec2.New(sess), recording)
+	ssmapi := fake.NewRecordingSSMAPI(ssm.New(sess), recording)
+	pricingapi := fake.NewRecordingPricingAPI(pricing.NewAPI(sess, opts.region), recording)
+
+	log.Println("recording prices for", opts.region)
+	pricingProvider := pricing.NewDefaultProvider(ctx, pricingapi, ec2api, opts.region)
+	lo.Must0(pricingProvider.UpdateOnDemandPricing(ctx))
+	lo.Must0(pricingProvider.UpdateSpotPricing(ctx))
+
+	log.Println("recording instance types for", opts.region)
+	subnetProvider := subnet.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AvailableIPAddressTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AssociatePublicIPAddressTTL, awscache.DefaultCleanupInterval))
+	instanceTypeProvider := instancetype.NewDefaultProvider(opts.region, cache.New(awscache.InstanceTypesAndZonesTTL, awscache.DefaultCleanupInterval), ec2api, subnetProvider, awscache.NewUnavailableOfferings(), pricingProvider)
+	lo.Must0(instanceTypeProvider.UpdateInstanceTypes(ctx))
+	lo.Must0(instanceTypeProvider.UpdateInstanceTypeOfferings(ctx))
+
+	log.Println("recording AMI parameters for", opts.region)
+	ssmProvider := ssmp.NewDefaultProvider(ssmapi, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+	for _, k8sVersion := range version.SupportedK8sVersions() {
+		lo.Must(ssmProvider.List(ctx, fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2023", k8sVersion)))
+		lo.Must(ssmProvider.List(ctx, fmt.Sprintf("/aws/service/bottlerocket/aws-k8s-%s", k8sVersion)))
+	}
+
+	if opts.clusterName != "" {
+		log.Println("recording subnets and security groups for", opts.clusterName)
+		nodeClass := test.EC2NodeClass(v1.EC2NodeClass{
+			Spec: v1.EC2NodeClassSpec{
+				SubnetSelectorTerms:        []v1.SubnetSelectorTerm{{Tags: map[string]string{"karpenter.sh/discovery": opts.clusterName}}},
+				SecurityGroupSelectorTerms: []v1.SecurityGroupSelectorTerm{{Tags: map[string]string{"karpenter.sh/discovery": opts.clusterName}}},
+			},
+		})
+		lo.Must(subnetProvider.List(ctx, nodeClass))
+		lo.Must(securitygroup.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)).List(ctx, nodeClass))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(opts.output), 0755); err != nil {
+		log.Fatalf("creating output directory, %s", err)
+	}
+	if err := recording.Save(opts.output); err != nil {
+		log.Fatalf("saving recording, %s", err)
+	}
+	log.Printf("recorded %d instance types, %d offerings and %d parameters to %s", len(recording.InstanceTypes), len(recording.InstanceTypeOfferings), len(recording.Parameters), opts.output)
+}
@@ -339,6 +339,10 @@ func getIdentMapping(identName string) (string, error) {
 		"disruptionSubsystem":     "disruption",
 		"consistencySubsystem":    "consistency",
 		"batcherSubsystem":        "cloudprovider_batcher",
+		"awsAPISubsystem":         "cloudprovider_aws_api",
+		"launchSubsystem":         "cloudprovider_launch",
+		"subnetSubsystem":         "cloudprovider_subnet",
+		"iamSubsystem":            "cloudprovider_iam",
 		"cloudProviderSubsystem":  "cloudprovider",
 		"stateSubsystem":          "cluster_state",
 	}
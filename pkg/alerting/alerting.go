@@ -0,0 +1,191 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alerting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/samber/lo"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+)
+
+const (
+	ReasonLaunchFailures    = "LaunchFailures"
+	ReasonUnregisteredNodes = "UnregisteredNodes"
+
+	// notifyTimeout bounds the time spent delivering an alert to each notifier
+	notifyTimeout = 10 * time.Second
+	// maxErrorLength bounds the length of the errors that are aggregated for an alert when they aren't AWS errors
+	maxErrorLength = 200
+)
+
+// Alert is sent to the configured notifiers when a NodePool reaches the threshold of consecutive launch failures or
+// consecutive nodes that failed to register
+type Alert struct {
+	Reason    string    `json:"reason"`
+	NodePool  string    `json:"nodePool"`
+	Count     int       `json:"count"`
+	Threshold int       `json:"threshold"`
+	Since     time.Time `json:"since"`
+	// Errors is the number of failures in the streak aggregated by error code or, for unregistered nodes, by the
+	// instance type and zone of the node
+	Errors map[string]int `json:"errors"`
+}
+
+func (a Alert) Subject() string {
+	switch a.Reason {
+	case ReasonUnregisteredNodes:
+		return fmt.Sprintf("Karpenter: %d consecutive nodes failed to register for NodePool %s", a.Count, a.NodePool)
+	default:
+		return fmt.Sprintf("Karpenter: %d consecutive launch failures for NodePool %s", a.Count, a.NodePool)
+	}
+}
+
+// Notifier delivers alerts to an external system
+type Notifier interface {
+	Notify(context.Context, Alert) error
+}
+
+type streakKey struct {
+	reason   string
+	nodePool string
+}
+
+type streak struct {
+	count  int
+	since  time.Time
+	errors map[string]int
+}
+
+// Tracker counts consecutive launch failures and unregistered nodes per NodePool and notifies once each time a streak
+// reaches the threshold. A streak is reset by the next successful launch or registration for the NodePool.
+type Tracker struct {
+	mu        sync.Mutex
+	clock     clock.Clock
+	threshold int
+	notifiers []Notifier
+	streaks   map[streakKey]*streak
+}
+
+func NewTracker(clk clock.Clock, threshold int, notifiers ...Notifier) *Tracker {
+	return &Tracker{
+		clock:     clk,
+		threshold: threshold,
+		notifiers: notifiers,
+		streaks:   map[streakKey]*streak{},
+	}
+}
+
+// RecordLaunchFailure records a failed launch for the NodePool, aggregated by the AWS error code if there is one
+func (t *Tracker) RecordLaunchFailure(ctx context.Context, nodePool string, err error) {
+	t.record(ctx, streakKey{reason: ReasonLaunchFailures, nodePool: nodePool}, errorKey(err))
+}
+
+// RecordLaunch resets the launch failure streak of the NodePool
+func (t *Tracker) RecordLaunch(nodePool string) {
+	t.reset(streakKey{reason: ReasonLaunchFailures, nodePool: nodePool})
+}
+
+// RecordUnregistered records a node that didn't register for the NodePool, aggregated by the passed key
+func (t *Tracker) RecordUnregistered(ctx context.Context, nodePool string, key string) {
+	t.record(ctx, streakKey{reason: ReasonUnregisteredNodes, nodePool: nodePool}, key)
+}
+
+// RecordRegistered resets the unregistered node streak of the NodePool
+func (t *Tracker) RecordRegistered(nodePool string) {
+	t.reset(streakKey{reason: ReasonUnregisteredNodes, nodePool: nodePool})
+}
+
+// Reset clears all streaks
+func (t *Tracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.streaks = map[streakKey]*streak{}
+}
+
+func (t *Tracker) record(ctx context.Context, key streakKey, errKey string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.streaks[key]
+	if !ok {
+		s = &streak{since: t.clock.Now(), errors: map[string]int{}}
+		t.streaks[key] = s
+	}
+	s.count++
+	s.errors[errKey]++
+	// Only notify once per streak so that a NodePool that keeps failing doesn't page on every attempt
+	if s.count != t.threshold || len(t.notifiers) == 0 {
+		return
+	}
+	alert := Alert{
+		Reason:    key.reason,
+		NodePool:  key.nodePool,
+		Count:     s.count,
+		Threshold: t.threshold,
+		Since:     s.since,
+		Errors:    lo.Assign(s.errors),
+	}
+	log.FromContext(ctx).WithValues("NodePool", alert.NodePool, "reason", alert.Reason, "count", alert.Count, "errors", alert.Errors).Info("sending alert")
+	// Delivery happens in the background so that an unresponsive notifier doesn't delay the launch path
+	go t.notify(log.IntoContext(context.Background(), log.FromContext(ctx)), alert)
+}
+
+func (t *Tracker) notify(ctx context.Context, alert Alert) {
+	for _, notifier := range t.notifiers {
+		notifyCtx, cancel := context.WithTimeout(ctx, notifyTimeout)
+		if err := notifier.Notify(notifyCtx, alert); err != nil {
+			log.FromContext(ctx).WithValues("NodePool", alert.NodePool, "reason", alert.Reason).Error(err, "failed sending alert")
+		}
+		cancel()
+	}
+}
+
+func (t *Tracker) reset(key streakKey) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.streaks, key)
+}
+
+func errorKey(err error) string {
+	var aerr awserr.Error
+	if errors.As(err, &aerr) {
+		return aerr.Code()
+	}
+	if cloudprovider.IsInsufficientCapacityError(err) {
+		return "InsufficientCapacity"
+	}
+	return truncate(err.Error(), maxErrorLength)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
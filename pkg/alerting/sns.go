@@ -0,0 +1,51 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+)
+
+// SNSNotifier publishes alerts as JSON to an SNS topic
+type SNSNotifier struct {
+	snsapi   snsiface.SNSAPI
+	topicARN string
+}
+
+func NewSNSNotifier(snsapi snsiface.SNSAPI, topicARN string) *SNSNotifier {
+	return &SNSNotifier{snsapi: snsapi, topicARN: topicARN}
+}
+
+func (s *SNSNotifier) Notify(ctx context.Context, alert Alert) error {
+	message, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("marshaling alert, %w", err)
+	}
+	if _, err = s.snsapi.PublishWithContext(ctx, &sns.PublishInput{
+		TopicArn: aws.String(s.topicARN),
+		// SNS rejects subjects that are longer than 100 characters
+		Subject: aws.String(truncate(alert.Subject(), 100)),
+		Message: aws.String(string(message)),
+	}); err != nil {
+		return fmt.Errorf("publishing alert to sns, %w", err)
+	}
+	return nil
+}
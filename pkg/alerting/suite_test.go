@@ -0,0 +1,190 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alerting_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sns"
+	clock "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	"github.com/aws/karpenter-provider-aws/pkg/alerting"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var ctx context.Context
+
+func TestAlerting(t *testing.T) {
+	ctx = context.Background()
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Alerting")
+}
+
+type notifier struct {
+	mu     sync.Mutex
+	alerts []alerting.Alert
+}
+
+func (n *notifier) Notify(_ context.Context, alert alerting.Alert) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+func (n *notifier) Alerts() []alerting.Alert {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]alerting.Alert{}, n.alerts...)
+}
+
+var _ = Describe("Alerting", func() {
+	Context("Tracker", func() {
+		var fakeClock *clock.FakeClock
+		var n *notifier
+		var tracker *alerting.Tracker
+		BeforeEach(func() {
+			fakeClock = clock.NewFakeClock(time.Now())
+			n = &notifier{}
+			tracker = alerting.NewTracker(fakeClock, 3, n)
+		})
+		It("should alert once the threshold of consecutive launch failures is reached", func() {
+			since := fakeClock.Now()
+			tracker.RecordLaunchFailure(ctx, "default", awserr.New("InvalidParameterValue", "invalid", nil))
+			fakeClock.Step(time.Minute)
+			tracker.RecordLaunchFailure(ctx, "default", cloudprovider.NewInsufficientCapacityError(fmt.Errorf("all requested instance types were unavailable during launch")))
+			Consistently(n.Alerts).Should(BeEmpty())
+
+			tracker.RecordLaunchFailure(ctx, "default", fmt.Errorf("creating instance, %w", awserr.New("InvalidParameterValue", "invalid", nil)))
+			Eventually(n.Alerts).Should(HaveLen(1))
+			alert := n.Alerts()[0]
+			Expect(alert.Reason).To(Equal(alerting.ReasonLaunchFailures))
+			Expect(alert.NodePool).To(Equal("default"))
+			Expect(alert.Count).To(Equal(3))
+			Expect(alert.Threshold).To(Equal(3))
+			Expect(alert.Since).To(BeTemporally("==", since))
+			Expect(alert.Errors).To(Equal(map[string]int{"InvalidParameterValue": 2, "InsufficientCapacity": 1}))
+		})
+		It("should only alert once per streak", func() {
+			for range 6 {
+				tracker.RecordLaunchFailure(ctx, "default", errors.New("failed"))
+			}
+			Eventually(n.Alerts).Should(HaveLen(1))
+			Consistently(n.Alerts).Should(HaveLen(1))
+		})
+		It("should reset the streak on a successful launch", func() {
+			tracker.RecordLaunchFailure(ctx, "default", errors.New("failed"))
+			tracker.RecordLaunchFailure(ctx, "default", errors.New("failed"))
+			tracker.RecordLaunch("default")
+			tracker.RecordLaunchFailure(ctx, "default", errors.New("failed"))
+			tracker.RecordLaunchFailure(ctx, "default", errors.New("failed"))
+			Consistently(n.Alerts).Should(BeEmpty())
+
+			tracker.RecordLaunchFailure(ctx, "default", errors.New("failed"))
+			Eventually(n.Alerts).Should(HaveLen(1))
+			Expect(n.Alerts()[0].Errors).To(Equal(map[string]int{"failed": 3}))
+		})
+		It("should track NodePools and reasons independently", func() {
+			tracker.RecordLaunchFailure(ctx, "default", errors.New("failed"))
+			tracker.RecordLaunchFailure(ctx, "other", errors.New("failed"))
+			tracker.RecordUnregistered(ctx, "default", "m5.large/test-zone-1a")
+			tracker.RecordLaunchFailure(ctx, "default", errors.New("failed"))
+			tracker.RecordUnregistered(ctx, "default", "m5.large/test-zone-1a")
+			Consistently(n.Alerts).Should(BeEmpty())
+
+			tracker.RecordUnregistered(ctx, "default", "m5.xlarge/test-zone-1b")
+			Eventually(n.Alerts).Should(HaveLen(1))
+			alert := n.Alerts()[0]
+			Expect(alert.Reason).To(Equal(alerting.ReasonUnregisteredNodes))
+			Expect(alert.Errors).To(Equal(map[string]int{"m5.large/test-zone-1a": 2, "m5.xlarge/test-zone-1b": 1}))
+		})
+		It("should reset the unregistered node streak on a registration", func() {
+			tracker.RecordUnregistered(ctx, "default", "m5.large/test-zone-1a")
+			tracker.RecordUnregistered(ctx, "default", "m5.large/test-zone-1a")
+			tracker.RecordRegistered("default")
+			tracker.RecordUnregistered(ctx, "default", "m5.large/test-zone-1a")
+			Consistently(n.Alerts).Should(BeEmpty())
+		})
+	})
+	Context("Webhook", func() {
+		It("should post the alert as JSON", func() {
+			var body []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Method).To(Equal(http.MethodPost))
+				Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+
+			alert := alerting.Alert{Reason: alerting.ReasonLaunchFailures, NodePool: "default", Count: 3, Threshold: 3, Errors: map[string]int{"InvalidParameterValue": 3}}
+			Expect(alerting.NewWebhookNotifier(server.URL, server.Client()).Notify(ctx, alert)).To(Succeed())
+			received := alerting.Alert{}
+			Expect(json.Unmarshal(body, &received)).To(Succeed())
+			Expect(received.NodePool).To(Equal("default"))
+			Expect(received.Errors).To(Equal(map[string]int{"InvalidParameterValue": 3}))
+		})
+		It("should fail when the webhook doesn't respond with success", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			defer server.Close()
+
+			Expect(alerting.NewWebhookNotifier(server.URL, server.Client()).Notify(ctx, alerting.Alert{})).ToNot(Succeed())
+		})
+	})
+	Context("SNS", func() {
+		It("should publish the alert to the topic", func() {
+			snsapi := &fake.SNSAPI{}
+			alert := alerting.Alert{Reason: alerting.ReasonUnregisteredNodes, NodePool: "default", Count: 3, Threshold: 3, Errors: map[string]int{"m5.large/test-zone-1a": 3}}
+			Expect(alerting.NewSNSNotifier(snsapi, fake.DefaultAlertTopicARN).Notify(ctx, alert)).To(Succeed())
+
+			Expect(snsapi.PublishBehavior.CalledWithInput.Len()).To(Equal(1))
+			input := snsapi.PublishBehavior.CalledWithInput.Pop()
+			Expect(aws.StringValue(input.TopicArn)).To(Equal(fake.DefaultAlertTopicARN))
+			Expect(aws.StringValue(input.Subject)).To(Equal("Karpenter: 3 consecutive nodes failed to register for NodePool default"))
+			received := alerting.Alert{}
+			Expect(json.Unmarshal([]byte(aws.StringValue(input.Message)), &received)).To(Succeed())
+			Expect(received.Errors).To(Equal(map[string]int{"m5.large/test-zone-1a": 3}))
+		})
+		It("should truncate subjects to the SNS limit", func() {
+			snsapi := &fake.SNSAPI{}
+			alert := alerting.Alert{Reason: alerting.ReasonLaunchFailures, NodePool: string(make([]byte, 100)), Count: 3}
+			Expect(alerting.NewSNSNotifier(snsapi, fake.DefaultAlertTopicARN).Notify(ctx, alert)).To(Succeed())
+			Expect(aws.StringValue(snsapi.PublishBehavior.CalledWithInput.Pop().Subject)).To(HaveLen(100))
+		})
+		It("should fail when publishing fails", func() {
+			snsapi := &fake.SNSAPI{}
+			snsapi.PublishBehavior.Error.Set(awserr.New(sns.ErrCodeAuthorizationErrorException, "not authorized", nil))
+			Expect(alerting.NewSNSNotifier(snsapi, fake.DefaultAlertTopicARN).Notify(ctx, alerting.Alert{})).ToNot(Succeed())
+		})
+	})
+})
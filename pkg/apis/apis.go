@@ -30,7 +30,13 @@ var (
 	CompatibilityGroup = "compatibility." + Group
 	//go:embed crds/karpenter.k8s.aws_ec2nodeclasses.yaml
 	EC2NodeClassCRD []byte
-	CRDs            = append(apis.CRDs,
+	//go:embed crds/karpenter.k8s.aws_nodegroupmigrations.yaml
+	NodeGroupMigrationCRD []byte
+	//go:embed crds/karpenter.k8s.aws_capacityschedules.yaml
+	CapacityScheduleCRD []byte
+	CRDs                = append(apis.CRDs,
 		object.Unmarshal[apiextensionsv1.CustomResourceDefinition](EC2NodeClassCRD),
+		object.Unmarshal[apiextensionsv1.CustomResourceDefinition](NodeGroupMigrationCRD),
+		object.Unmarshal[apiextensionsv1.CustomResourceDefinition](CapacityScheduleCRD),
 	)
 )
@@ -0,0 +1,103 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CapacityScheduleSpec is the specification of the nodes that are launched ahead of the windows of a schedule.
+type CapacityScheduleSpec struct {
+	// NodePool is the name of the NodePool that launches the nodes.
+	// +kubebuilder:validation:MinLength:=1
+	// +required
+	NodePool string `json:"nodePool"`
+	// Schedule is when the windows start, following the upstream cronjob syntax, like "0 9 * * 1-5".
+	// +kubebuilder:validation:Pattern:=`^(@(annually|yearly|monthly|weekly|daily|midnight|hourly))|((.+)\s(.+)\s(.+)\s(.+)\s(.+))$`
+	// +required
+	Schedule string `json:"schedule"`
+	// TimeZone is the IANA time zone of the schedule, like "America/New_York". The schedule is in UTC if it's omitted.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+	// Duration is how long the nodes are kept once a window starts. Only minutes and hours are accepted.
+	// +kubebuilder:validation:Pattern=`^((([0-9]+(h|m))|([0-9]+h[0-9]+m))(0s)?)$`
+	// +kubebuilder:validation:Type="string"
+	// +required
+	Duration metav1.Duration `json:"duration"`
+	// LeadTime is how long before a window starts its nodes are launched. The launches are spread across the lead
+	// time, rather than made at once.
+	// +kubebuilder:validation:Pattern=`^((([0-9]+(h|m))|([0-9]+h[0-9]+m))(0s)?)$`
+	// +kubebuilder:validation:Type="string"
+	// +kubebuilder:default:="15m"
+	// +optional
+	LeadTime *metav1.Duration `json:"leadTime,omitempty"`
+	// Nodes is the number of nodes that are launched for each window.
+	// +kubebuilder:validation:Minimum:=1
+	// +required
+	Nodes int32 `json:"nodes"`
+	// Requirements constrain the nodes in addition to the requirements of the NodePool, like their instance types.
+	// The cheapest instance types that satisfy the requirements are launched.
+	// +kubebuilder:validation:MaxItems:=30
+	// +optional
+	Requirements []corev1.NodeSelectorRequirement `json:"requirements,omitempty"`
+}
+
+// CapacityScheduleStatus is the state of the window of a CapacitySchedule
+type CapacityScheduleStatus struct {
+	// Active is true from the lead time before a window starts until the window ends
+	// +optional
+	Active bool `json:"active,omitempty"`
+	// WindowStart is when the active window starts, or when the next window starts if none is active
+	// +optional
+	WindowStart *metav1.Time `json:"windowStart,omitempty"`
+	// WindowEnd is when the active window ends, or when the next window ends if none is active
+	// +optional
+	WindowEnd *metav1.Time `json:"windowEnd,omitempty"`
+	// Nodes is the number of nodes that are launched for the active window
+	// +optional
+	Nodes int32 `json:"nodes,omitempty"`
+	// Message explains why nodes aren't launched, like an invalid schedule or a NodePool that isn't ready
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// CapacitySchedule launches nodes of a NodePool ahead of known windows of traffic, like business hours, so that the
+// capacity is ready before the pods that need it are created. The nodes aren't disrupted until their window ends, and
+// are then consolidated like any other node of the NodePool.
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="NodePool",type="string",JSONPath=".spec.nodePool",description=""
+// +kubebuilder:printcolumn:name="Schedule",type="string",JSONPath=".spec.schedule",description=""
+// +kubebuilder:printcolumn:name="Nodes",type="integer",JSONPath=".spec.nodes",description=""
+// +kubebuilder:printcolumn:name="Launched",type="integer",JSONPath=".status.nodes",description=""
+// +kubebuilder:printcolumn:name="Active",type="boolean",JSONPath=".status.active",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+// +kubebuilder:resource:path=capacityschedules,scope=Cluster,categories=karpenter,shortName={capsched,capscheds}
+// +kubebuilder:subresource:status
+type CapacitySchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CapacityScheduleSpec   `json:"spec"`
+	Status CapacityScheduleStatus `json:"status,omitempty"`
+}
+
+// CapacityScheduleList contains a list of CapacitySchedule
+// +kubebuilder:object:root=true
+type CapacityScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CapacitySchedule `json:"items"`
+}
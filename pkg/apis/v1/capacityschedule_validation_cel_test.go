@@ -0,0 +1,73 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1_test
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/karpenter/pkg/test"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CEL/Validation/CapacitySchedule", func() {
+	var schedule *v1.CapacitySchedule
+
+	BeforeEach(func() {
+		if env.Version.Minor() < 25 {
+			Skip("CEL Validation is for 1.25>")
+		}
+		schedule = &v1.CapacitySchedule{
+			ObjectMeta: test.ObjectMeta(metav1.ObjectMeta{}),
+			Spec: v1.CapacityScheduleSpec{
+				NodePool: "default",
+				Schedule: "0 9 * * 1-5",
+				Duration: metav1.Duration{Duration: 8 * time.Hour},
+				Nodes:    3,
+			},
+		}
+	})
+	AfterEach(func() {
+		Expect(env.Client.DeleteAllOf(ctx, &v1.CapacitySchedule{})).To(Succeed())
+	})
+	It("should succeed with a default lead time", func() {
+		Expect(env.Client.Create(ctx, schedule)).To(Succeed())
+		Expect(schedule.Spec.LeadTime.Duration).To(Equal(15 * time.Minute))
+	})
+	It("should succeed with a special schedule", func() {
+		schedule.Spec.Schedule = "@daily"
+		Expect(env.Client.Create(ctx, schedule)).To(Succeed())
+	})
+	It("should fail with a schedule that doesn't have 5 fields", func() {
+		schedule.Spec.Schedule = "0 9 * *"
+		Expect(env.Client.Create(ctx, schedule)).ToNot(Succeed())
+	})
+	It("should fail with a duration in seconds", func() {
+		schedule.Spec.Duration = metav1.Duration{Duration: 30 * time.Second}
+		Expect(env.Client.Create(ctx, schedule)).ToNot(Succeed())
+	})
+	It("should fail without nodes", func() {
+		schedule.Spec.Nodes = 0
+		Expect(env.Client.Create(ctx, schedule)).ToNot(Succeed())
+	})
+	It("should fail without a nodepool", func() {
+		schedule.Spec.NodePool = ""
+		Expect(env.Client.Create(ctx, schedule)).ToNot(Succeed())
+	})
+})
@@ -32,5 +32,9 @@ func init() {
 	scheme.Scheme.AddKnownTypes(gv,
 		&EC2NodeClass{},
 		&EC2NodeClassList{},
+		&CapacitySchedule{},
+		&CapacityScheduleList{},
+		&NodeGroupMigration{},
+		&NodeGroupMigrationList{},
 	)
 }
@@ -40,13 +40,15 @@ func (in *EC2NodeClassSpec) convertTo(v1beta1enc *v1beta1.EC2NodeClassSpec) {
 		return v1beta1.SubnetSelectorTerm{
 			ID:   subnet.ID,
 			Tags: subnet.Tags,
+			CIDR: subnet.CIDR,
 		}
 	})
 	v1beta1enc.SecurityGroupSelectorTerms = lo.Map(in.SecurityGroupSelectorTerms, func(sg SecurityGroupSelectorTerm, _ int) v1beta1.SecurityGroupSelectorTerm {
 		return v1beta1.SecurityGroupSelectorTerm{
-			ID:   sg.ID,
-			Name: sg.Name,
-			Tags: sg.Tags,
+			ID:    sg.ID,
+			Name:  sg.Name,
+			Tags:  sg.Tags,
+			VPCID: sg.VPCID,
 		}
 	})
 	v1beta1enc.AMISelectorTerms = lo.Map(in.AMISelectorTerms, func(ami AMISelectorTerm, _ int) v1beta1.AMISelectorTerm {
@@ -58,29 +60,116 @@ func (in *EC2NodeClassSpec) convertTo(v1beta1enc *v1beta1.EC2NodeClassSpec) {
 		}
 	})
 	v1beta1enc.AssociatePublicIPAddress = in.AssociatePublicIPAddress
+	if in.PrivateDNSNameOptions != nil {
+		v1beta1enc.PrivateDNSNameOptions = &v1beta1.PrivateDNSNameOptions{
+			HostnameType:                    (*v1beta1.HostnameType)(in.PrivateDNSNameOptions.HostnameType),
+			EnableResourceNameDNSARecord:    in.PrivateDNSNameOptions.EnableResourceNameDNSARecord,
+			EnableResourceNameDNSAAAARecord: in.PrivateDNSNameOptions.EnableResourceNameDNSAAAARecord,
+		}
+	}
+	v1beta1enc.NameTag = in.NameTag
 	v1beta1enc.Context = in.Context
+	v1beta1enc.AssumeRoleARN = in.AssumeRoleARN
+	v1beta1enc.AMIVerification = (*v1beta1.AMIVerification)(in.AMIVerification)
 	v1beta1enc.DetailedMonitoring = in.DetailedMonitoring
+	v1beta1enc.EBSOptimized = in.EBSOptimized
+	v1beta1enc.KeyName = in.KeyName
+	v1beta1enc.CreditSpecification = (*v1beta1.CreditSpecification)(in.CreditSpecification)
+	v1beta1enc.PTPHardwareClock = in.PTPHardwareClock
+	v1beta1enc.DCGMExporter = (*v1beta1.DCGMExporter)(in.DCGMExporter)
+	v1beta1enc.Neuron = (*v1beta1.NeuronConfiguration)(in.Neuron)
+	v1beta1enc.VPCCNI = (*v1beta1.VPCCNIConfiguration)(in.VPCCNI)
+	if in.PrimaryNetworkInterface != nil {
+		v1beta1enc.PrimaryNetworkInterface = &v1beta1.PrimaryNetworkInterface{
+			InterfaceType:   (*v1beta1.NetworkInterfaceType)(in.PrimaryNetworkInterface.InterfaceType),
+			SourceDestCheck: in.PrimaryNetworkInterface.SourceDestCheck,
+			Description:     in.PrimaryNetworkInterface.Description,
+			Tags:            in.PrimaryNetworkInterface.Tags,
+		}
+	}
+	v1beta1enc.MaintenanceWindow = (*v1beta1.MaintenanceWindow)(in.MaintenanceWindow)
+	v1beta1enc.CloudWatchAgent = (*v1beta1.CloudWatchAgent)(in.CloudWatchAgent)
+	if in.SSMAgent != nil {
+		v1beta1enc.SSMAgent = &v1beta1.SSMAgent{
+			Enabled:          in.SSMAgent.Enabled,
+			HybridActivation: (*v1beta1.SSMHybridActivation)(in.SSMAgent.HybridActivation),
+			SessionManager:   in.SSMAgent.SessionManager,
+		}
+	}
+	v1beta1enc.SubnetSelectionPolicy = (*v1beta1.SubnetSelectionPolicy)(in.SubnetSelectionPolicy)
 	v1beta1enc.Role = in.Role
 	v1beta1enc.InstanceProfile = in.InstanceProfile
 	v1beta1enc.InstanceStorePolicy = (*v1beta1.InstanceStorePolicy)(in.InstanceStorePolicy)
 	v1beta1enc.Tags = in.Tags
 	v1beta1enc.UserData = in.UserData
+	v1beta1enc.ContainerRegistries = lo.Map(in.ContainerRegistries, func(r ContainerRegistry, _ int) v1beta1.ContainerRegistry {
+		return v1beta1.ContainerRegistry{
+			Host:        r.Host,
+			Mirrors:     r.Mirrors,
+			Credentials: (*v1beta1.ContainerRegistryCredentials)(r.Credentials),
+		}
+	})
+	v1beta1enc.ContainerSnapshotter = (*v1beta1.ContainerSnapshotter)(in.ContainerSnapshotter)
+	if in.Swap != nil {
+		v1beta1enc.Swap = &v1beta1.Swap{
+			Location:     (*v1beta1.SwapLocation)(in.Swap.Location),
+			Size:         in.Swap.Size,
+			Swappiness:   in.Swap.Swappiness,
+			SwapBehavior: in.Swap.SwapBehavior,
+		}
+	}
+	v1beta1enc.Windows = (*v1beta1.WindowsConfiguration)(in.Windows)
+	if in.Bottlerocket != nil {
+		v1beta1enc.Bottlerocket = &v1beta1.BottlerocketConfiguration{
+			UpdateStrategy: (*v1beta1.BottlerocketUpdateStrategy)(in.Bottlerocket.UpdateStrategy),
+		}
+	}
+	v1beta1enc.Hugepages = lo.Map(in.Hugepages, func(h Hugepages, _ int) v1beta1.Hugepages { return v1beta1.Hugepages(h) })
+	v1beta1enc.KernelParameters = (*v1beta1.KernelParameters)(in.KernelParameters)
+	v1beta1enc.Sysctls = in.Sysctls
+	v1beta1enc.KernelModules = lo.Map(in.KernelModules, func(m KernelModule, _ int) v1beta1.KernelModule { return v1beta1.KernelModule(m) })
 	v1beta1enc.MetadataOptions = (*v1beta1.MetadataOptions)(in.MetadataOptions)
+	v1beta1enc.PodMetadataAccess = (*v1beta1.PodMetadataAccess)(in.PodMetadataAccess)
 	v1beta1enc.BlockDeviceMappings = lo.Map(in.BlockDeviceMappings, func(bdm *BlockDeviceMapping, _ int) *v1beta1.BlockDeviceMapping {
 		return &v1beta1.BlockDeviceMapping{
-			DeviceName: bdm.DeviceName,
-			RootVolume: bdm.RootVolume,
-			EBS:        (*v1beta1.BlockDevice)(bdm.EBS),
+			DeviceName:    bdm.DeviceName,
+			RootVolume:    bdm.RootVolume,
+			ImageFSVolume: bdm.ImageFSVolume,
+			EBS:           bdm.EBS.convertTo(),
 		}
 	})
 }
 
+func (in *BlockDevice) convertTo() *v1beta1.BlockDevice {
+	if in == nil {
+		return nil
+	}
+	return &v1beta1.BlockDevice{
+		DeleteOnTermination: in.DeleteOnTermination,
+		Encrypted:           in.Encrypted,
+		IOPS:                in.IOPS,
+		KMSKeyID:            in.KMSKeyID,
+		SnapshotID:          in.SnapshotID,
+		SnapshotSelectorTerms: lo.Map(in.SnapshotSelectorTerms, func(term SnapshotSelectorTerm, _ int) v1beta1.SnapshotSelectorTerm {
+			return v1beta1.SnapshotSelectorTerm{
+				Tags:  term.Tags,
+				Owner: term.Owner,
+			}
+		}),
+		Throughput: in.Throughput,
+		VolumeSize: in.VolumeSize,
+		VolumeType: in.VolumeType,
+	}
+}
+
 func (in *EC2NodeClassStatus) convertTo(v1beta1enc *v1beta1.EC2NodeClassStatus) {
 	v1beta1enc.Subnets = lo.Map(in.Subnets, func(subnet Subnet, _ int) v1beta1.Subnet {
 		return v1beta1.Subnet{
-			ID:     subnet.ID,
-			Zone:   subnet.Zone,
-			ZoneID: subnet.ZoneID,
+			ID:       subnet.ID,
+			Zone:     subnet.Zone,
+			ZoneID:   subnet.ZoneID,
+			CIDR:     subnet.CIDR,
+			Priority: subnet.Priority,
 		}
 	})
 	v1beta1enc.SecurityGroups = lo.Map(in.SecurityGroups, func(sg SecurityGroup, _ int) v1beta1.SecurityGroup {
@@ -135,13 +224,15 @@ func (in *EC2NodeClassSpec) convertFrom(v1beta1enc *v1beta1.EC2NodeClassSpec) {
 		return SubnetSelectorTerm{
 			ID:   subnet.ID,
 			Tags: subnet.Tags,
+			CIDR: subnet.CIDR,
 		}
 	})
 	in.SecurityGroupSelectorTerms = lo.Map(v1beta1enc.SecurityGroupSelectorTerms, func(sg v1beta1.SecurityGroupSelectorTerm, _ int) SecurityGroupSelectorTerm {
 		return SecurityGroupSelectorTerm{
-			ID:   sg.ID,
-			Name: sg.Name,
-			Tags: sg.Tags,
+			ID:    sg.ID,
+			Name:  sg.Name,
+			Tags:  sg.Tags,
+			VPCID: sg.VPCID,
 		}
 	})
 	in.AMISelectorTerms = append(in.AMISelectorTerms, lo.Map(v1beta1enc.AMISelectorTerms, func(ami v1beta1.AMISelectorTerm, _ int) AMISelectorTerm {
@@ -153,29 +244,116 @@ func (in *EC2NodeClassSpec) convertFrom(v1beta1enc *v1beta1.EC2NodeClassSpec) {
 		}
 	})...)
 	in.AssociatePublicIPAddress = v1beta1enc.AssociatePublicIPAddress
+	if v1beta1enc.PrivateDNSNameOptions != nil {
+		in.PrivateDNSNameOptions = &PrivateDNSNameOptions{
+			HostnameType:                    (*HostnameType)(v1beta1enc.PrivateDNSNameOptions.HostnameType),
+			EnableResourceNameDNSARecord:    v1beta1enc.PrivateDNSNameOptions.EnableResourceNameDNSARecord,
+			EnableResourceNameDNSAAAARecord: v1beta1enc.PrivateDNSNameOptions.EnableResourceNameDNSAAAARecord,
+		}
+	}
+	in.NameTag = v1beta1enc.NameTag
 	in.Context = v1beta1enc.Context
+	in.AssumeRoleARN = v1beta1enc.AssumeRoleARN
+	in.AMIVerification = (*AMIVerification)(v1beta1enc.AMIVerification)
 	in.DetailedMonitoring = v1beta1enc.DetailedMonitoring
+	in.EBSOptimized = v1beta1enc.EBSOptimized
+	in.KeyName = v1beta1enc.KeyName
+	in.CreditSpecification = (*CreditSpecification)(v1beta1enc.CreditSpecification)
+	in.PTPHardwareClock = v1beta1enc.PTPHardwareClock
+	in.DCGMExporter = (*DCGMExporter)(v1beta1enc.DCGMExporter)
+	in.Neuron = (*NeuronConfiguration)(v1beta1enc.Neuron)
+	in.VPCCNI = (*VPCCNIConfiguration)(v1beta1enc.VPCCNI)
+	if v1beta1enc.PrimaryNetworkInterface != nil {
+		in.PrimaryNetworkInterface = &PrimaryNetworkInterface{
+			InterfaceType:   (*NetworkInterfaceType)(v1beta1enc.PrimaryNetworkInterface.InterfaceType),
+			SourceDestCheck: v1beta1enc.PrimaryNetworkInterface.SourceDestCheck,
+			Description:     v1beta1enc.PrimaryNetworkInterface.Description,
+			Tags:            v1beta1enc.PrimaryNetworkInterface.Tags,
+		}
+	}
+	in.MaintenanceWindow = (*MaintenanceWindow)(v1beta1enc.MaintenanceWindow)
+	in.CloudWatchAgent = (*CloudWatchAgent)(v1beta1enc.CloudWatchAgent)
+	if v1beta1enc.SSMAgent != nil {
+		in.SSMAgent = &SSMAgent{
+			Enabled:          v1beta1enc.SSMAgent.Enabled,
+			HybridActivation: (*SSMHybridActivation)(v1beta1enc.SSMAgent.HybridActivation),
+			SessionManager:   v1beta1enc.SSMAgent.SessionManager,
+		}
+	}
+	in.SubnetSelectionPolicy = (*SubnetSelectionPolicy)(v1beta1enc.SubnetSelectionPolicy)
 	in.Role = v1beta1enc.Role
 	in.InstanceProfile = v1beta1enc.InstanceProfile
 	in.InstanceStorePolicy = (*InstanceStorePolicy)(v1beta1enc.InstanceStorePolicy)
 	in.Tags = v1beta1enc.Tags
 	in.UserData = v1beta1enc.UserData
+	in.ContainerRegistries = lo.Map(v1beta1enc.ContainerRegistries, func(r v1beta1.ContainerRegistry, _ int) ContainerRegistry {
+		return ContainerRegistry{
+			Host:        r.Host,
+			Mirrors:     r.Mirrors,
+			Credentials: (*ContainerRegistryCredentials)(r.Credentials),
+		}
+	})
+	in.ContainerSnapshotter = (*ContainerSnapshotter)(v1beta1enc.ContainerSnapshotter)
+	if v1beta1enc.Swap != nil {
+		in.Swap = &Swap{
+			Location:     (*SwapLocation)(v1beta1enc.Swap.Location),
+			Size:         v1beta1enc.Swap.Size,
+			Swappiness:   v1beta1enc.Swap.Swappiness,
+			SwapBehavior: v1beta1enc.Swap.SwapBehavior,
+		}
+	}
+	in.Windows = (*WindowsConfiguration)(v1beta1enc.Windows)
+	if v1beta1enc.Bottlerocket != nil {
+		in.Bottlerocket = &BottlerocketConfiguration{
+			UpdateStrategy: (*BottlerocketUpdateStrategy)(v1beta1enc.Bottlerocket.UpdateStrategy),
+		}
+	}
+	in.Hugepages = lo.Map(v1beta1enc.Hugepages, func(h v1beta1.Hugepages, _ int) Hugepages { return Hugepages(h) })
+	in.KernelParameters = (*KernelParameters)(v1beta1enc.KernelParameters)
+	in.Sysctls = v1beta1enc.Sysctls
+	in.KernelModules = lo.Map(v1beta1enc.KernelModules, func(m v1beta1.KernelModule, _ int) KernelModule { return KernelModule(m) })
 	in.MetadataOptions = (*MetadataOptions)(v1beta1enc.MetadataOptions)
+	in.PodMetadataAccess = (*PodMetadataAccess)(v1beta1enc.PodMetadataAccess)
 	in.BlockDeviceMappings = lo.Map(v1beta1enc.BlockDeviceMappings, func(bdm *v1beta1.BlockDeviceMapping, _ int) *BlockDeviceMapping {
 		return &BlockDeviceMapping{
-			DeviceName: bdm.DeviceName,
-			RootVolume: bdm.RootVolume,
-			EBS:        (*BlockDevice)(bdm.EBS),
+			DeviceName:    bdm.DeviceName,
+			RootVolume:    bdm.RootVolume,
+			ImageFSVolume: bdm.ImageFSVolume,
+			EBS:           convertBlockDeviceFrom(bdm.EBS),
 		}
 	})
 }
 
+func convertBlockDeviceFrom(v1beta1bd *v1beta1.BlockDevice) *BlockDevice {
+	if v1beta1bd == nil {
+		return nil
+	}
+	return &BlockDevice{
+		DeleteOnTermination: v1beta1bd.DeleteOnTermination,
+		Encrypted:           v1beta1bd.Encrypted,
+		IOPS:                v1beta1bd.IOPS,
+		KMSKeyID:            v1beta1bd.KMSKeyID,
+		SnapshotID:          v1beta1bd.SnapshotID,
+		SnapshotSelectorTerms: lo.Map(v1beta1bd.SnapshotSelectorTerms, func(term v1beta1.SnapshotSelectorTerm, _ int) SnapshotSelectorTerm {
+			return SnapshotSelectorTerm{
+				Tags:  term.Tags,
+				Owner: term.Owner,
+			}
+		}),
+		Throughput: v1beta1bd.Throughput,
+		VolumeSize: v1beta1bd.VolumeSize,
+		VolumeType: v1beta1bd.VolumeType,
+	}
+}
+
 func (in *EC2NodeClassStatus) convertFrom(v1beta1enc *v1beta1.EC2NodeClassStatus) {
 	in.Subnets = lo.Map(v1beta1enc.Subnets, func(subnet v1beta1.Subnet, _ int) Subnet {
 		return Subnet{
-			ID:     subnet.ID,
-			Zone:   subnet.Zone,
-			ZoneID: subnet.ZoneID,
+			ID:       subnet.ID,
+			Zone:     subnet.Zone,
+			ZoneID:   subnet.ZoneID,
+			CIDR:     subnet.CIDR,
+			Priority: subnet.Priority,
 		}
 	})
 	in.SecurityGroups = lo.Map(v1beta1enc.SecurityGroups, func(sg v1beta1.SecurityGroup, _ int) SecurityGroup {
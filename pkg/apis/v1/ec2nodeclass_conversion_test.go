@@ -15,6 +15,8 @@ limitations under the License.
 package v1_test
 
 import (
+	"time"
+
 	"github.com/awslabs/operatorpkg/status"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -72,9 +74,10 @@ var _ = Describe("Convert v1 to v1beta1 EC2NodeClass API", func() {
 					Name: "test-name-1",
 				},
 				{
-					Tags: map[string]string{"test-key-2": "test-value-2"},
-					ID:   "test-id-2",
-					Name: "test-name-2",
+					Tags:  map[string]string{"test-key-2": "test-value-2"},
+					ID:    "test-id-2",
+					Name:  "test-name-2",
+					VPCID: "test-vpc-2",
 				},
 			}
 			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
@@ -82,6 +85,7 @@ var _ = Describe("Convert v1 to v1beta1 EC2NodeClass API", func() {
 				Expect(v1beta1ec2nodeclass.Spec.SecurityGroupSelectorTerms[i].Tags).To(Equal(v1ec2nodeclass.Spec.SecurityGroupSelectorTerms[i].Tags))
 				Expect(v1beta1ec2nodeclass.Spec.SecurityGroupSelectorTerms[i].ID).To(Equal(v1ec2nodeclass.Spec.SecurityGroupSelectorTerms[i].ID))
 				Expect(v1beta1ec2nodeclass.Spec.SecurityGroupSelectorTerms[i].Name).To(Equal(v1ec2nodeclass.Spec.SecurityGroupSelectorTerms[i].Name))
+				Expect(v1beta1ec2nodeclass.Spec.SecurityGroupSelectorTerms[i].VPCID).To(Equal(v1ec2nodeclass.Spec.SecurityGroupSelectorTerms[i].VPCID))
 			}
 		})
 		It("should convert v1 ec2nodeclass ami selector terms", func() {
@@ -129,6 +133,132 @@ var _ = Describe("Convert v1 to v1beta1 EC2NodeClass API", func() {
 			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
 			Expect(lo.FromPtr(v1beta1ec2nodeclass.Spec.UserData)).To(Equal(lo.FromPtr(v1ec2nodeclass.Spec.UserData)))
 		})
+		It("should convert v1 ec2nodeclass container registries", func() {
+			v1ec2nodeclass.Spec.ContainerRegistries = []ContainerRegistry{
+				{Host: "docker.io", Mirrors: []string{"https://mirror.internal"}},
+				{Host: "mirror.internal", Credentials: &ContainerRegistryCredentials{Username: "test-username", Password: "test-password"}},
+			}
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(v1beta1ec2nodeclass.Spec.ContainerRegistries).To(Equal([]v1beta1.ContainerRegistry{
+				{Host: "docker.io", Mirrors: []string{"https://mirror.internal"}},
+				{Host: "mirror.internal", Credentials: &v1beta1.ContainerRegistryCredentials{Username: "test-username", Password: "test-password"}},
+			}))
+		})
+		It("should convert v1 ec2nodeclass container snapshotter", func() {
+			v1ec2nodeclass.Spec.ContainerSnapshotter = lo.ToPtr(ContainerSnapshotterSOCI)
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(string(lo.FromPtr(v1beta1ec2nodeclass.Spec.ContainerSnapshotter))).To(Equal(string(lo.FromPtr(v1ec2nodeclass.Spec.ContainerSnapshotter))))
+		})
+		It("should convert v1 ec2nodeclass swap", func() {
+			v1ec2nodeclass.Spec.Swap = &Swap{
+				Location:     lo.ToPtr(SwapLocationRootVolume),
+				Size:         lo.ToPtr(resource.MustParse("4Gi")),
+				Swappiness:   lo.ToPtr[int32](10),
+				SwapBehavior: lo.ToPtr("LimitedSwap"),
+			}
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(v1beta1ec2nodeclass.Spec.Swap).To(Equal(&v1beta1.Swap{
+				Location:     lo.ToPtr(v1beta1.SwapLocationRootVolume),
+				Size:         lo.ToPtr(resource.MustParse("4Gi")),
+				Swappiness:   lo.ToPtr[int32](10),
+				SwapBehavior: lo.ToPtr("LimitedSwap"),
+			}))
+		})
+		It("should convert v1 ec2nodeclass private dns name options and name tag", func() {
+			v1ec2nodeclass.Spec.PrivateDNSNameOptions = &PrivateDNSNameOptions{HostnameType: lo.ToPtr(HostnameTypeResourceName), EnableResourceNameDNSAAAARecord: lo.ToPtr(true)}
+			v1ec2nodeclass.Spec.NameTag = lo.ToPtr("{{ .NodePool }}-{{ .InstanceID }}")
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(v1beta1ec2nodeclass.Spec.PrivateDNSNameOptions).To(Equal(&v1beta1.PrivateDNSNameOptions{HostnameType: lo.ToPtr(v1beta1.HostnameTypeResourceName), EnableResourceNameDNSAAAARecord: lo.ToPtr(true)}))
+			Expect(v1beta1ec2nodeclass.Spec.NameTag).To(Equal(lo.ToPtr("{{ .NodePool }}-{{ .InstanceID }}")))
+		})
+		It("should convert v1 ec2nodeclass windows configuration", func() {
+			v1ec2nodeclass.Spec.Windows = &WindowsConfiguration{CSIProxy: lo.ToPtr(true), HostProcessContainers: lo.ToPtr(true), GMSA: lo.ToPtr(false)}
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(v1beta1ec2nodeclass.Spec.Windows).To(Equal(&v1beta1.WindowsConfiguration{CSIProxy: lo.ToPtr(true), HostProcessContainers: lo.ToPtr(true), GMSA: lo.ToPtr(false)}))
+		})
+		It("should convert v1 ec2nodeclass bottlerocket configuration", func() {
+			v1ec2nodeclass.Spec.Bottlerocket = &BottlerocketConfiguration{UpdateStrategy: lo.ToPtr(BottlerocketUpdateStrategyInPlace)}
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(v1beta1ec2nodeclass.Spec.Bottlerocket).To(Equal(&v1beta1.BottlerocketConfiguration{UpdateStrategy: lo.ToPtr(v1beta1.BottlerocketUpdateStrategyInPlace)}))
+		})
+		It("should convert v1 ec2nodeclass hugepages and kernel parameters", func() {
+			v1ec2nodeclass.Spec.Hugepages = []Hugepages{{Size: "2Mi", Count: 512}}
+			v1ec2nodeclass.Spec.KernelParameters = &KernelParameters{NUMABalancing: lo.ToPtr(false), IsolatedCPUs: lo.ToPtr("2-3")}
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(v1beta1ec2nodeclass.Spec.Hugepages).To(Equal([]v1beta1.Hugepages{{Size: "2Mi", Count: 512}}))
+			Expect(v1beta1ec2nodeclass.Spec.KernelParameters).To(Equal(&v1beta1.KernelParameters{NUMABalancing: lo.ToPtr(false), IsolatedCPUs: lo.ToPtr("2-3")}))
+		})
+		It("should convert v1 ec2nodeclass sysctls and kernel modules", func() {
+			v1ec2nodeclass.Spec.Sysctls = map[string]string{"net.netfilter.nf_conntrack_max": "1048576"}
+			v1ec2nodeclass.Spec.KernelModules = []KernelModule{"nf_conntrack"}
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(v1beta1ec2nodeclass.Spec.Sysctls).To(Equal(v1ec2nodeclass.Spec.Sysctls))
+			Expect(v1beta1ec2nodeclass.Spec.KernelModules).To(Equal([]v1beta1.KernelModule{"nf_conntrack"}))
+		})
+		It("should convert v1 ec2nodeclass ptp hardware clock", func() {
+			v1ec2nodeclass.Spec.PTPHardwareClock = lo.ToPtr(true)
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(lo.FromPtr(v1beta1ec2nodeclass.Spec.PTPHardwareClock)).To(BeTrue())
+		})
+		It("should convert v1 ec2nodeclass dcgm exporter", func() {
+			v1ec2nodeclass.Spec.DCGMExporter = &DCGMExporter{Enabled: lo.ToPtr(true), Image: lo.ToPtr("public.ecr.aws/test/dcgm-exporter:latest")}
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(lo.FromPtr(v1beta1ec2nodeclass.Spec.DCGMExporter.Enabled)).To(BeTrue())
+			Expect(lo.FromPtr(v1beta1ec2nodeclass.Spec.DCGMExporter.Image)).To(Equal("public.ecr.aws/test/dcgm-exporter:latest"))
+		})
+		It("should convert v1 ec2nodeclass neuron", func() {
+			v1ec2nodeclass.Spec.Neuron = &NeuronConfiguration{LogicalNeuronCoreConfig: lo.ToPtr[int32](2)}
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(lo.FromPtr(v1beta1ec2nodeclass.Spec.Neuron.LogicalNeuronCoreConfig)).To(BeNumerically("==", 2))
+		})
+		It("should convert v1 ec2nodeclass vpc cni", func() {
+			v1ec2nodeclass.Spec.VPCCNI = &VPCCNIConfiguration{ReservedENIs: lo.ToPtr[int32](1), ReservedIPsPerENI: lo.ToPtr[int32](2), CustomNetworking: lo.ToPtr(true), PrefixDelegation: lo.ToPtr(true)}
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(lo.FromPtr(v1beta1ec2nodeclass.Spec.VPCCNI.ReservedENIs)).To(BeNumerically("==", 1))
+			Expect(lo.FromPtr(v1beta1ec2nodeclass.Spec.VPCCNI.ReservedIPsPerENI)).To(BeNumerically("==", 2))
+			Expect(lo.FromPtr(v1beta1ec2nodeclass.Spec.VPCCNI.CustomNetworking)).To(BeTrue())
+			Expect(lo.FromPtr(v1beta1ec2nodeclass.Spec.VPCCNI.PrefixDelegation)).To(BeTrue())
+		})
+		It("should convert v1 ec2nodeclass primary network interface", func() {
+			v1ec2nodeclass.Spec.PrimaryNetworkInterface = &PrimaryNetworkInterface{
+				InterfaceType:   lo.ToPtr(NetworkInterfaceTypeEFA),
+				SourceDestCheck: lo.ToPtr(false),
+				Description:     lo.ToPtr("router"),
+				Tags:            map[string]string{"team": "network"},
+			}
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(lo.FromPtr(v1beta1ec2nodeclass.Spec.PrimaryNetworkInterface.InterfaceType)).To(Equal(v1beta1.NetworkInterfaceTypeEFA))
+			Expect(lo.FromPtr(v1beta1ec2nodeclass.Spec.PrimaryNetworkInterface.SourceDestCheck)).To(BeFalse())
+			Expect(lo.FromPtr(v1beta1ec2nodeclass.Spec.PrimaryNetworkInterface.Description)).To(Equal("router"))
+			Expect(v1beta1ec2nodeclass.Spec.PrimaryNetworkInterface.Tags).To(Equal(map[string]string{"team": "network"}))
+		})
+		It("should convert v1 ec2nodeclass maintenance window", func() {
+			v1ec2nodeclass.Spec.MaintenanceWindow = &MaintenanceWindow{
+				Schedule: "0 2 * * 6",
+				TimeZone: "Europe/Berlin",
+				Duration: metav1.Duration{Duration: 4 * time.Hour},
+			}
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(v1beta1ec2nodeclass.Spec.MaintenanceWindow.Schedule).To(Equal("0 2 * * 6"))
+			Expect(v1beta1ec2nodeclass.Spec.MaintenanceWindow.TimeZone).To(Equal("Europe/Berlin"))
+			Expect(v1beta1ec2nodeclass.Spec.MaintenanceWindow.Duration.Duration).To(Equal(4 * time.Hour))
+		})
+		It("should convert v1 ec2nodeclass ssm agent", func() {
+			v1ec2nodeclass.Spec.SSMAgent = &SSMAgent{
+				Enabled:          lo.ToPtr(true),
+				HybridActivation: &SSMHybridActivation{ID: "e488f2f6-e686-4afb-8a04-ef6dfEXAMPLE", Code: "o1PsFFt8NRyEMBRKHqaP", Region: "us-west-2"},
+				SessionManager:   lo.ToPtr(true),
+			}
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(lo.FromPtr(v1beta1ec2nodeclass.Spec.SSMAgent.Enabled)).To(BeTrue())
+			Expect(v1beta1ec2nodeclass.Spec.SSMAgent.SessionManager).To(HaveValue(BeTrue()))
+			Expect(*v1beta1ec2nodeclass.Spec.SSMAgent.HybridActivation).To(Equal(v1beta1.SSMHybridActivation{ID: "e488f2f6-e686-4afb-8a04-ef6dfEXAMPLE", Code: "o1PsFFt8NRyEMBRKHqaP", Region: "us-west-2"}))
+		})
+		It("should convert v1 ec2nodeclass cloudwatch agent", func() {
+			v1ec2nodeclass.Spec.CloudWatchAgent = &CloudWatchAgent{Enabled: lo.ToPtr(true), LogGroupName: lo.ToPtr("/test/nodes"), Metrics: lo.ToPtr(false)}
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(*v1beta1ec2nodeclass.Spec.CloudWatchAgent).To(Equal(v1beta1.CloudWatchAgent{Enabled: lo.ToPtr(true), LogGroupName: lo.ToPtr("/test/nodes"), Metrics: lo.ToPtr(false)}))
+		})
 		It("should convert v1 ec2nodeclass role", func() {
 			v1ec2nodeclass.Spec.Role = "test-role"
 			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
@@ -178,6 +308,21 @@ var _ = Describe("Convert v1 to v1beta1 EC2NodeClass API", func() {
 				Expect(v1beta1ec2nodeclass.Spec.BlockDeviceMappings[i].EBS.VolumeType).To(Equal(v1ec2nodeclass.Spec.BlockDeviceMappings[i].EBS.VolumeType))
 			}
 		})
+		It("should convert v1 ec2nodeclass block device mapping snapshot selector terms", func() {
+			v1ec2nodeclass.Spec.BlockDeviceMappings = []*BlockDeviceMapping{
+				{
+					EBS: &BlockDevice{
+						SnapshotSelectorTerms: []SnapshotSelectorTerm{{Tags: map[string]string{"test-key": "test-value"}, Owner: "self"}},
+					},
+					DeviceName:    lo.ToPtr("test-device"),
+					ImageFSVolume: true,
+				},
+			}
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(v1beta1ec2nodeclass.Spec.BlockDeviceMappings[0].EBS.SnapshotSelectorTerms).To(Equal([]v1beta1.SnapshotSelectorTerm{
+				{Tags: map[string]string{"test-key": "test-value"}, Owner: "self"},
+			}))
+		})
 		It("should convert v1 ec2nodeclass instance store policy", func() {
 			v1ec2nodeclass.Spec.InstanceStorePolicy = lo.ToPtr(InstanceStorePolicyRAID0)
 			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
@@ -188,6 +333,31 @@ var _ = Describe("Convert v1 to v1beta1 EC2NodeClass API", func() {
 			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
 			Expect(lo.FromPtr(v1beta1ec2nodeclass.Spec.DetailedMonitoring)).To(Equal(lo.FromPtr(v1ec2nodeclass.Spec.DetailedMonitoring)))
 		})
+		It("should convert v1 ec2nodeclass ebs optimized", func() {
+			v1ec2nodeclass.Spec.EBSOptimized = lo.ToPtr(false)
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(v1beta1ec2nodeclass.Spec.EBSOptimized).To(Equal(v1ec2nodeclass.Spec.EBSOptimized))
+		})
+		It("should convert v1 ec2nodeclass key name", func() {
+			v1ec2nodeclass.Spec.KeyName = lo.ToPtr("incident-response")
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(v1beta1ec2nodeclass.Spec.KeyName).To(Equal(v1ec2nodeclass.Spec.KeyName))
+		})
+		It("should convert v1 ec2nodeclass credit specification", func() {
+			v1ec2nodeclass.Spec.CreditSpecification = lo.ToPtr(CreditSpecificationStandard)
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(string(lo.FromPtr(v1beta1ec2nodeclass.Spec.CreditSpecification))).To(Equal(string(lo.FromPtr(v1ec2nodeclass.Spec.CreditSpecification))))
+		})
+		It("should convert v1 ec2nodeclass pod metadata access", func() {
+			v1ec2nodeclass.Spec.PodMetadataAccess = lo.ToPtr(PodMetadataAccessBlocked)
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(string(lo.FromPtr(v1beta1ec2nodeclass.Spec.PodMetadataAccess))).To(Equal(string(lo.FromPtr(v1ec2nodeclass.Spec.PodMetadataAccess))))
+		})
+		It("should convert v1 ec2nodeclass subnet selection policy", func() {
+			v1ec2nodeclass.Spec.SubnetSelectionPolicy = lo.ToPtr(SubnetSelectionPolicyRoundRobin)
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(string(lo.FromPtr(v1beta1ec2nodeclass.Spec.SubnetSelectionPolicy))).To(Equal(string(lo.FromPtr(v1ec2nodeclass.Spec.SubnetSelectionPolicy))))
+		})
 		It("should convert v1 ec2nodeclass metadata options", func() {
 			v1ec2nodeclass.Spec.MetadataOptions = &MetadataOptions{
 				HTTPEndpoint:            lo.ToPtr("test-endpoint"),
@@ -206,6 +376,16 @@ var _ = Describe("Convert v1 to v1beta1 EC2NodeClass API", func() {
 			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
 			Expect(lo.FromPtr(v1beta1ec2nodeclass.Spec.Context)).To(Equal(lo.FromPtr(v1ec2nodeclass.Spec.Context)))
 		})
+		It("should convert v1 ec2nodeclass assumeRoleARN", func() {
+			v1ec2nodeclass.Spec.AssumeRoleARN = lo.ToPtr("arn:aws:iam::123456789012:role/team-a")
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(lo.FromPtr(v1beta1ec2nodeclass.Spec.AssumeRoleARN)).To(Equal(lo.FromPtr(v1ec2nodeclass.Spec.AssumeRoleARN)))
+		})
+		It("should convert v1 ec2nodeclass amiVerification", func() {
+			v1ec2nodeclass.Spec.AMIVerification = &AMIVerification{KMSKeyID: lo.ToPtr("alias/ami-signing")}
+			Expect(v1ec2nodeclass.ConvertTo(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(lo.FromPtr(v1beta1ec2nodeclass.Spec.AMIVerification.KMSKeyID)).To(Equal("alias/ami-signing"))
+		})
 	})
 	Context("EC2NodeClass Status", func() {
 		It("should convert v1 ec2nodeclass subnet", func() {
@@ -385,6 +565,122 @@ var _ = Describe("Convert v1beta1 to v1 EC2NodeClass API", func() {
 			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
 			Expect(lo.FromPtr(v1ec2nodeclass.Spec.UserData)).To(Equal(lo.FromPtr(v1beta1ec2nodeclass.Spec.UserData)))
 		})
+		It("should convert v1beta1 ec2nodeclass container registries", func() {
+			v1beta1ec2nodeclass.Spec.ContainerRegistries = []v1beta1.ContainerRegistry{
+				{Host: "docker.io", Mirrors: []string{"https://mirror.internal"}},
+				{Host: "mirror.internal", Credentials: &v1beta1.ContainerRegistryCredentials{Username: "test-username", Password: "test-password"}},
+			}
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(v1ec2nodeclass.Spec.ContainerRegistries).To(Equal([]ContainerRegistry{
+				{Host: "docker.io", Mirrors: []string{"https://mirror.internal"}},
+				{Host: "mirror.internal", Credentials: &ContainerRegistryCredentials{Username: "test-username", Password: "test-password"}},
+			}))
+		})
+		It("should convert v1beta1 ec2nodeclass container snapshotter", func() {
+			v1beta1ec2nodeclass.Spec.ContainerSnapshotter = lo.ToPtr(v1beta1.ContainerSnapshotterSOCI)
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(string(lo.FromPtr(v1ec2nodeclass.Spec.ContainerSnapshotter))).To(Equal(string(lo.FromPtr(v1beta1ec2nodeclass.Spec.ContainerSnapshotter))))
+		})
+		It("should convert v1beta1 ec2nodeclass swap", func() {
+			v1beta1ec2nodeclass.Spec.Swap = &v1beta1.Swap{
+				Location:   lo.ToPtr(v1beta1.SwapLocationInstanceStore),
+				Swappiness: lo.ToPtr[int32](60),
+			}
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(v1ec2nodeclass.Spec.Swap).To(Equal(&Swap{
+				Location:   lo.ToPtr(SwapLocationInstanceStore),
+				Swappiness: lo.ToPtr[int32](60),
+			}))
+		})
+		It("should convert v1beta1 ec2nodeclass private dns name options and name tag", func() {
+			v1beta1ec2nodeclass.Spec.PrivateDNSNameOptions = &v1beta1.PrivateDNSNameOptions{HostnameType: lo.ToPtr(v1beta1.HostnameTypeIPName)}
+			v1beta1ec2nodeclass.Spec.NameTag = lo.ToPtr("web-{{ .NodeName }}")
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(v1ec2nodeclass.Spec.PrivateDNSNameOptions).To(Equal(&PrivateDNSNameOptions{HostnameType: lo.ToPtr(HostnameTypeIPName)}))
+			Expect(v1ec2nodeclass.Spec.NameTag).To(Equal(lo.ToPtr("web-{{ .NodeName }}")))
+		})
+		It("should convert v1beta1 ec2nodeclass windows configuration", func() {
+			v1beta1ec2nodeclass.Spec.Windows = &v1beta1.WindowsConfiguration{CSIProxy: lo.ToPtr(true), GMSA: lo.ToPtr(true)}
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(v1ec2nodeclass.Spec.Windows).To(Equal(&WindowsConfiguration{CSIProxy: lo.ToPtr(true), GMSA: lo.ToPtr(true)}))
+		})
+		It("should convert v1beta1 ec2nodeclass bottlerocket configuration", func() {
+			v1beta1ec2nodeclass.Spec.Bottlerocket = &v1beta1.BottlerocketConfiguration{UpdateStrategy: lo.ToPtr(v1beta1.BottlerocketUpdateStrategyReplace)}
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(v1ec2nodeclass.Spec.Bottlerocket).To(Equal(&BottlerocketConfiguration{UpdateStrategy: lo.ToPtr(BottlerocketUpdateStrategyReplace)}))
+		})
+		It("should convert v1beta1 ec2nodeclass hugepages and kernel parameters", func() {
+			v1beta1ec2nodeclass.Spec.Hugepages = []v1beta1.Hugepages{{Size: "1Gi", Count: 2}}
+			v1beta1ec2nodeclass.Spec.KernelParameters = &v1beta1.KernelParameters{NUMABalancing: lo.ToPtr(true)}
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(v1ec2nodeclass.Spec.Hugepages).To(Equal([]Hugepages{{Size: "1Gi", Count: 2}}))
+			Expect(v1ec2nodeclass.Spec.KernelParameters).To(Equal(&KernelParameters{NUMABalancing: lo.ToPtr(true)}))
+		})
+		It("should convert v1beta1 ec2nodeclass sysctls and kernel modules", func() {
+			v1beta1ec2nodeclass.Spec.Sysctls = map[string]string{"net.ipv4.conf.all.rp_filter": "2"}
+			v1beta1ec2nodeclass.Spec.KernelModules = []v1beta1.KernelModule{"br_netfilter"}
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(v1ec2nodeclass.Spec.Sysctls).To(Equal(v1beta1ec2nodeclass.Spec.Sysctls))
+			Expect(v1ec2nodeclass.Spec.KernelModules).To(Equal([]KernelModule{"br_netfilter"}))
+		})
+		It("should convert v1beta1 ec2nodeclass ptp hardware clock", func() {
+			v1beta1ec2nodeclass.Spec.PTPHardwareClock = lo.ToPtr(true)
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(lo.FromPtr(v1ec2nodeclass.Spec.PTPHardwareClock)).To(BeTrue())
+		})
+		It("should convert v1beta1 ec2nodeclass dcgm exporter", func() {
+			v1beta1ec2nodeclass.Spec.DCGMExporter = &v1beta1.DCGMExporter{Enabled: lo.ToPtr(true), Image: lo.ToPtr("public.ecr.aws/test/dcgm-exporter:latest")}
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(lo.FromPtr(v1ec2nodeclass.Spec.DCGMExporter.Enabled)).To(BeTrue())
+			Expect(lo.FromPtr(v1ec2nodeclass.Spec.DCGMExporter.Image)).To(Equal("public.ecr.aws/test/dcgm-exporter:latest"))
+		})
+		It("should convert v1beta1 ec2nodeclass neuron", func() {
+			v1beta1ec2nodeclass.Spec.Neuron = &v1beta1.NeuronConfiguration{LogicalNeuronCoreConfig: lo.ToPtr[int32](2)}
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(lo.FromPtr(v1ec2nodeclass.Spec.Neuron.LogicalNeuronCoreConfig)).To(BeNumerically("==", 2))
+		})
+		It("should convert v1beta1 ec2nodeclass vpc cni", func() {
+			v1beta1ec2nodeclass.Spec.VPCCNI = &v1beta1.VPCCNIConfiguration{ReservedENIs: lo.ToPtr[int32](1), ReservedIPsPerENI: lo.ToPtr[int32](2), CustomNetworking: lo.ToPtr(true)}
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(lo.FromPtr(v1ec2nodeclass.Spec.VPCCNI.ReservedENIs)).To(BeNumerically("==", 1))
+			Expect(lo.FromPtr(v1ec2nodeclass.Spec.VPCCNI.ReservedIPsPerENI)).To(BeNumerically("==", 2))
+			Expect(lo.FromPtr(v1ec2nodeclass.Spec.VPCCNI.CustomNetworking)).To(BeTrue())
+		})
+		It("should convert v1beta1 ec2nodeclass primary network interface", func() {
+			v1beta1ec2nodeclass.Spec.PrimaryNetworkInterface = &v1beta1.PrimaryNetworkInterface{
+				InterfaceType:   lo.ToPtr(v1beta1.NetworkInterfaceTypeENA),
+				SourceDestCheck: lo.ToPtr(false),
+				Description:     lo.ToPtr("router"),
+				Tags:            map[string]string{"team": "network"},
+			}
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(lo.FromPtr(v1ec2nodeclass.Spec.PrimaryNetworkInterface.InterfaceType)).To(Equal(NetworkInterfaceTypeENA))
+			Expect(lo.FromPtr(v1ec2nodeclass.Spec.PrimaryNetworkInterface.SourceDestCheck)).To(BeFalse())
+			Expect(lo.FromPtr(v1ec2nodeclass.Spec.PrimaryNetworkInterface.Description)).To(Equal("router"))
+			Expect(v1ec2nodeclass.Spec.PrimaryNetworkInterface.Tags).To(Equal(map[string]string{"team": "network"}))
+		})
+		It("should convert v1beta1 ec2nodeclass maintenance window", func() {
+			v1beta1ec2nodeclass.Spec.MaintenanceWindow = &v1beta1.MaintenanceWindow{
+				Schedule: "0 2 * * 6",
+				Duration: metav1.Duration{Duration: 90 * time.Minute},
+			}
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(v1ec2nodeclass.Spec.MaintenanceWindow.Schedule).To(Equal("0 2 * * 6"))
+			Expect(v1ec2nodeclass.Spec.MaintenanceWindow.TimeZone).To(BeEmpty())
+			Expect(v1ec2nodeclass.Spec.MaintenanceWindow.Duration.Duration).To(Equal(90 * time.Minute))
+		})
+		It("should convert v1beta1 ec2nodeclass ssm agent", func() {
+			v1beta1ec2nodeclass.Spec.SSMAgent = &v1beta1.SSMAgent{Enabled: lo.ToPtr(false)}
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(v1ec2nodeclass.Spec.SSMAgent.Enabled).To(HaveValue(BeFalse()))
+			Expect(v1ec2nodeclass.Spec.SSMAgent.HybridActivation).To(BeNil())
+			Expect(v1ec2nodeclass.Spec.SSMAgent.SessionManager).To(BeNil())
+		})
+		It("should convert v1beta1 ec2nodeclass cloudwatch agent", func() {
+			v1beta1ec2nodeclass.Spec.CloudWatchAgent = &v1beta1.CloudWatchAgent{Enabled: lo.ToPtr(true), LogGroupName: lo.ToPtr("/test/nodes"), Metrics: lo.ToPtr(false)}
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(*v1ec2nodeclass.Spec.CloudWatchAgent).To(Equal(CloudWatchAgent{Enabled: lo.ToPtr(true), LogGroupName: lo.ToPtr("/test/nodes"), Metrics: lo.ToPtr(false)}))
+		})
 		It("should convert v1beta1 ec2nodeclass role", func() {
 			v1beta1ec2nodeclass.Spec.Role = "test-role"
 			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
@@ -434,6 +730,21 @@ var _ = Describe("Convert v1beta1 to v1 EC2NodeClass API", func() {
 				Expect(v1ec2nodeclass.Spec.BlockDeviceMappings[i].EBS.VolumeType).To(Equal(v1beta1ec2nodeclass.Spec.BlockDeviceMappings[i].EBS.VolumeType))
 			}
 		})
+		It("should convert v1beta1 ec2nodeclass block device mapping snapshot selector terms", func() {
+			v1beta1ec2nodeclass.Spec.BlockDeviceMappings = []*v1beta1.BlockDeviceMapping{
+				{
+					EBS: &v1beta1.BlockDevice{
+						SnapshotSelectorTerms: []v1beta1.SnapshotSelectorTerm{{Tags: map[string]string{"test-key": "test-value"}, Owner: "self"}},
+					},
+					DeviceName:    lo.ToPtr("test-device"),
+					ImageFSVolume: true,
+				},
+			}
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(v1ec2nodeclass.Spec.BlockDeviceMappings[0].EBS.SnapshotSelectorTerms).To(Equal([]SnapshotSelectorTerm{
+				{Tags: map[string]string{"test-key": "test-value"}, Owner: "self"},
+			}))
+		})
 		It("should convert v1beta1 ec2nodeclass instance store policy", func() {
 			v1beta1ec2nodeclass.Spec.InstanceStorePolicy = lo.ToPtr(v1beta1.InstanceStorePolicyRAID0)
 			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
@@ -444,6 +755,31 @@ var _ = Describe("Convert v1beta1 to v1 EC2NodeClass API", func() {
 			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
 			Expect(lo.FromPtr(v1ec2nodeclass.Spec.DetailedMonitoring)).To(Equal(lo.FromPtr(v1beta1ec2nodeclass.Spec.DetailedMonitoring)))
 		})
+		It("should convert v1beta1 ec2nodeclass ebs optimized", func() {
+			v1beta1ec2nodeclass.Spec.EBSOptimized = lo.ToPtr(true)
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(v1ec2nodeclass.Spec.EBSOptimized).To(Equal(v1beta1ec2nodeclass.Spec.EBSOptimized))
+		})
+		It("should convert v1beta1 ec2nodeclass key name", func() {
+			v1beta1ec2nodeclass.Spec.KeyName = lo.ToPtr("incident-response")
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(v1ec2nodeclass.Spec.KeyName).To(Equal(v1beta1ec2nodeclass.Spec.KeyName))
+		})
+		It("should convert v1beta1 ec2nodeclass credit specification", func() {
+			v1beta1ec2nodeclass.Spec.CreditSpecification = lo.ToPtr(v1beta1.CreditSpecificationUnlimited)
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(string(lo.FromPtr(v1ec2nodeclass.Spec.CreditSpecification))).To(Equal(string(lo.FromPtr(v1beta1ec2nodeclass.Spec.CreditSpecification))))
+		})
+		It("should convert v1beta1 ec2nodeclass pod metadata access", func() {
+			v1beta1ec2nodeclass.Spec.PodMetadataAccess = lo.ToPtr(v1beta1.PodMetadataAccessAllowed)
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(string(lo.FromPtr(v1ec2nodeclass.Spec.PodMetadataAccess))).To(Equal(string(lo.FromPtr(v1beta1ec2nodeclass.Spec.PodMetadataAccess))))
+		})
+		It("should convert v1beta1 ec2nodeclass subnet selection policy", func() {
+			v1beta1ec2nodeclass.Spec.SubnetSelectionPolicy = lo.ToPtr(v1beta1.SubnetSelectionPolicyPriority)
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(string(lo.FromPtr(v1ec2nodeclass.Spec.SubnetSelectionPolicy))).To(Equal(string(lo.FromPtr(v1beta1ec2nodeclass.Spec.SubnetSelectionPolicy))))
+		})
 		It("should convert v1beta1 ec2nodeclass metadata options", func() {
 			v1beta1ec2nodeclass.Spec.MetadataOptions = &v1beta1.MetadataOptions{
 				HTTPEndpoint:            lo.ToPtr("test-endpoint"),
@@ -462,6 +798,16 @@ var _ = Describe("Convert v1beta1 to v1 EC2NodeClass API", func() {
 			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
 			Expect(lo.FromPtr(v1ec2nodeclass.Spec.Context)).To(Equal(lo.FromPtr(v1beta1ec2nodeclass.Spec.Context)))
 		})
+		It("should convert v1beta1 ec2nodeclass assumeRoleARN", func() {
+			v1beta1ec2nodeclass.Spec.AssumeRoleARN = lo.ToPtr("arn:aws:iam::123456789012:role/team-a")
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(lo.FromPtr(v1ec2nodeclass.Spec.AssumeRoleARN)).To(Equal(lo.FromPtr(v1beta1ec2nodeclass.Spec.AssumeRoleARN)))
+		})
+		It("should convert v1beta1 ec2nodeclass amiVerification", func() {
+			v1beta1ec2nodeclass.Spec.AMIVerification = &v1beta1.AMIVerification{KMSKeyID: lo.ToPtr("alias/ami-signing")}
+			Expect(v1ec2nodeclass.ConvertFrom(ctx, v1beta1ec2nodeclass)).To(Succeed())
+			Expect(lo.FromPtr(v1ec2nodeclass.Spec.AMIVerification.KMSKeyID)).To(Equal("alias/ami-signing"))
+		})
 	})
 	Context("EC2NodeClass Status", func() {
 		It("should convert v1beta1 ec2nodeclass subnet", func() {
@@ -135,8 +135,15 @@ var _ = Describe("Hash", func() {
 		Entry("Tags", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{Tags: map[string]string{"keyTag-test-3": "valueTag-test-3"}}}),
 		Entry("Context", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{Context: aws.String("context-2")}}),
 		Entry("DetailedMonitoring", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{DetailedMonitoring: aws.Bool(true)}}),
+		Entry("EBSOptimized", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{EBSOptimized: aws.Bool(true)}}),
+		Entry("KeyName", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{KeyName: aws.String("incident-response")}}),
+		Entry("SSMAgent SessionManager", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{SSMAgent: &v1.SSMAgent{SessionManager: aws.Bool(true)}}}),
+		Entry("CreditSpecification", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{CreditSpecification: lo.ToPtr(v1.CreditSpecificationStandard)}}),
+		Entry("PodMetadataAccess", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{PodMetadataAccess: lo.ToPtr(v1.PodMetadataAccessBlocked)}}),
 		Entry("InstanceStorePolicy", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{InstanceStorePolicy: lo.ToPtr(v1.InstanceStorePolicyRAID0)}}),
 		Entry("AssociatePublicIPAddress", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{AssociatePublicIPAddress: lo.ToPtr(true)}}),
+		Entry("PrivateDNSNameOptions HostnameType", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{PrivateDNSNameOptions: &v1.PrivateDNSNameOptions{HostnameType: lo.ToPtr(v1.HostnameTypeResourceName)}}}),
+		Entry("PrivateDNSNameOptions EnableResourceNameDNSAAAARecord", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{PrivateDNSNameOptions: &v1.PrivateDNSNameOptions{EnableResourceNameDNSAAAARecord: lo.ToPtr(true)}}}),
 		Entry("MetadataOptions HTTPEndpoint", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{MetadataOptions: &v1.MetadataOptions{HTTPEndpoint: lo.ToPtr("enabled")}}}),
 		Entry("MetadataOptions HTTPProtocolIPv6", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{MetadataOptions: &v1.MetadataOptions{HTTPProtocolIPv6: lo.ToPtr("enabled")}}}),
 		Entry("MetadataOptions HTTPPutResponseHopLimit", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{MetadataOptions: &v1.MetadataOptions{HTTPPutResponseHopLimit: lo.ToPtr(int64(10))}}}),
@@ -198,6 +205,8 @@ var _ = Describe("Hash", func() {
 				Tags: map[string]string{"ami-test-key": "ami-test-value"},
 			},
 		}
+		nodeClass.Spec.SubnetSelectionPolicy = lo.ToPtr(v1.SubnetSelectionPolicyRoundRobin)
+		nodeClass.Spec.NameTag = lo.ToPtr("{{ .NodePool }}-{{ .InstanceID }}")
 		updatedHash := nodeClass.Hash()
 		Expect(hash).To(Equal(updatedHash))
 	})
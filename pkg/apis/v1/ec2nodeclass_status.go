@@ -24,6 +24,29 @@ const (
 	ConditionTypeSecurityGroupsReady  = "SecurityGroupsReady"
 	ConditionTypeAMIsReady            = "AMIsReady"
 	ConditionTypeInstanceProfileReady = "InstanceProfileReady"
+	ConditionTypeKeyPairReady         = "KeyPairReady"
+	// ConditionTypeZonesImpaired is set while one or more of the EC2NodeClass's zones is avoided for new launches. It
+	// isn't a dependent of the Ready condition since launches fall back to impaired zones when there are no others.
+	ConditionTypeZonesImpaired = "ZonesImpaired"
+	// ConditionTypePublicIPExposed is set while instances of the EC2NodeClass would be assigned public IP addresses by
+	// subnets that route to an internet gateway, without associatePublicIPAddress being set on the EC2NodeClass.
+	ConditionTypePublicIPExposed = "PublicIPExposed"
+	// ConditionTypeEBSEncryptionPolicyViolated is set while launches of the EC2NodeClass would create EBS volumes that
+	// don't comply with the ebs-encryption-policy, taking the EBS encryption defaults of the account into account.
+	ConditionTypeEBSEncryptionPolicyViolated = "EBSEncryptionPolicyViolated"
+	// ConditionTypeQuotaHeadroomLow is set while less than the quota-headroom-percent of an account quota that launches
+	// of the EC2NodeClass count against is unused, so that launch failures are anticipated before the quota is reached.
+	ConditionTypeQuotaHeadroomLow = "QuotaHeadroomLow"
+	// ConditionTypePodMetadataAccessMismatch is set while the metadata options of the EC2NodeClass don't give pods the
+	// access to the instance metadata service that podMetadataAccess intends, either because the hop limit is adjusted
+	// at launch or because the metadata endpoint is disabled.
+	ConditionTypePodMetadataAccessMismatch = "PodMetadataAccessMismatch"
+	// ConditionTypeClusterConfigDrifted is set while the values of the cluster that nodes are bootstrapped with don't
+	// match the values that EKS reports for the cluster, e.g. because the configured cluster endpoint is stale.
+	ConditionTypeClusterConfigDrifted = "ClusterConfigDrifted"
+	// ConditionTypeKeyPairInUse is set while instances of the EC2NodeClass are launched with a key pair, recommending
+	// Session Manager instead. It isn't a dependent of the Ready condition since key pairs are still supported.
+	ConditionTypeKeyPairInUse = "KeyPairInUse"
 )
 
 // Subnet contains resolved Subnet selector values utilized for node launch
@@ -37,6 +60,12 @@ type Subnet struct {
 	// The associated availability zone ID
 	// +optional
 	ZoneID string `json:"zoneID,omitempty"`
+	// The IPv4 CIDR block of the subnet
+	// +optional
+	CIDR string `json:"cidr,omitempty"`
+	// The priority of the subnet from its karpenter.k8s.aws/subnet-priority tag
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
 }
 
 // SecurityGroup contains resolved SecurityGroup selector values utilized for node launch
@@ -62,6 +91,22 @@ type AMI struct {
 	Requirements []corev1.NodeSelectorRequirement `json:"requirements"`
 }
 
+// Cluster contains the values of the cluster that nodes are bootstrapped with
+type Cluster struct {
+	// Endpoint is the endpoint of the API server that nodes connect to
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+	// CertificateAuthorityHash is the SHA-256 hash of the CA bundle that nodes trust the API server with
+	// +optional
+	CertificateAuthorityHash string `json:"certificateAuthorityHash,omitempty"`
+	// ServiceCIDR is the CIDR of the services of the cluster
+	// +optional
+	ServiceCIDR string `json:"serviceCIDR,omitempty"`
+	// IPFamily is the IP family of the pods and services of the cluster
+	// +optional
+	IPFamily string `json:"ipFamily,omitempty"`
+}
+
 // EC2NodeClassStatus contains the resolved state of the EC2NodeClass
 type EC2NodeClassStatus struct {
 	// Subnets contains the current Subnet values that are available to the
@@ -79,6 +124,10 @@ type EC2NodeClassStatus struct {
 	// InstanceProfile contains the resolved instance profile for the role
 	// +optional
 	InstanceProfile string `json:"instanceProfile,omitempty"`
+	// Cluster contains the values of the cluster that nodes are bootstrapped with, which are discovered
+	// with the EKS DescribeCluster API unless they're configured
+	// +optional
+	Cluster *Cluster `json:"cluster,omitempty"`
 	// Conditions contains signals for health and readiness
 	// +optional
 	Conditions []status.Condition `json:"conditions,omitempty"`
@@ -90,6 +139,7 @@ func (in *EC2NodeClass) StatusConditions() status.ConditionSet {
 		ConditionTypeSubnetsReady,
 		ConditionTypeSecurityGroupsReady,
 		ConditionTypeInstanceProfileReady,
+		ConditionTypeKeyPairReady,
 	).For(in)
 }
 
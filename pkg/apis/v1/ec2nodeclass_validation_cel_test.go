@@ -83,6 +83,201 @@ var _ = Describe("CEL/Validation", func() {
 			Expect(env.Client.Create(ctx, nc)).To(Succeed())
 		})
 	})
+	Context("ContainerRegistries", func() {
+		It("should succeed with mirrors and credentials", func() {
+			nc.Spec.ContainerRegistries = []v1.ContainerRegistry{
+				{Host: "docker.io", Mirrors: []string{"https://111122223333.dkr.ecr.us-west-2.amazonaws.com/v2/docker-hub"}},
+				{Host: "registry.internal:5000", Credentials: &v1.ContainerRegistryCredentials{Username: "{{resolve:ssm:/registry/username}}", Password: "{{resolve:secretsmanager:registry}}"}},
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when the credentials aren't references", func() {
+			nc.Spec.ContainerRegistries = []v1.ContainerRegistry{
+				{Host: "registry.internal:5000", Credentials: &v1.ContainerRegistryCredentials{Username: "{{resolve:ssm:/registry/username}}", Password: "password"}},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+			nc.Spec.ContainerRegistries[0].Credentials = &v1.ContainerRegistryCredentials{Username: "username", Password: "{{resolve:secretsmanager:registry}}"}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when the password references a plain SSM parameter", func() {
+			nc.Spec.ContainerRegistries = []v1.ContainerRegistry{
+				{Host: "registry.internal:5000", Credentials: &v1.ContainerRegistryCredentials{Username: "{{resolve:ssm:/registry/username}}", Password: "{{resolve:ssm:/registry/password}}"}},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when a registry has neither mirrors nor credentials", func() {
+			nc.Spec.ContainerRegistries = []v1.ContainerRegistry{{Host: "docker.io"}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when a host is specified more than once", func() {
+			nc.Spec.ContainerRegistries = []v1.ContainerRegistry{
+				{Host: "docker.io", Mirrors: []string{"https://mirror.internal"}},
+				{Host: "docker.io", Credentials: &v1.ContainerRegistryCredentials{Username: "{{resolve:ssm:/registry/username}}", Password: "{{resolve:secretsmanager:registry}}"}},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when a host is a URL", func() {
+			nc.Spec.ContainerRegistries = []v1.ContainerRegistry{{Host: "https://docker.io", Mirrors: []string{"https://mirror.internal"}}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when a mirror isn't a URL", func() {
+			nc.Spec.ContainerRegistries = []v1.ContainerRegistry{{Host: "docker.io", Mirrors: []string{"mirror.internal"}}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("Swap", func() {
+		It("should succeed with a swap file on the root volume", func() {
+			nc.Spec.Swap = &v1.Swap{Size: lo.ToPtr(resource.MustParse("4Gi")), Swappiness: lo.ToPtr[int32](10)}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should succeed with swap on the instance store without a size", func() {
+			nc.Spec.Swap = &v1.Swap{Location: lo.ToPtr(v1.SwapLocationInstanceStore)}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when the size of a swap file isn't set", func() {
+			nc.Spec.Swap = &v1.Swap{Location: lo.ToPtr(v1.SwapLocationRootVolume)}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when swappiness is greater than 100", func() {
+			nc.Spec.Swap = &v1.Swap{Size: lo.ToPtr(resource.MustParse("4Gi")), Swappiness: lo.ToPtr[int32](101)}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when the swap behavior isn't supported", func() {
+			nc.Spec.Swap = &v1.Swap{Size: lo.ToPtr(resource.MustParse("4Gi")), SwapBehavior: lo.ToPtr("UnlimitedSwap")}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when swap uses the instance store and instanceStorePolicy is set", func() {
+			nc.Spec.Swap = &v1.Swap{Location: lo.ToPtr(v1.SwapLocationInstanceStore)}
+			nc.Spec.InstanceStorePolicy = lo.ToPtr(v1.InstanceStorePolicyRAID0)
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("Hugepages", func() {
+		It("should succeed with hugepages of each size", func() {
+			nc.Spec.Hugepages = []v1.Hugepages{{Size: "2Mi", Count: 512}, {Size: "1Gi", Count: 2}}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when a size isn't supported", func() {
+			nc.Spec.Hugepages = []v1.Hugepages{{Size: "1Mi", Count: 512}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when a size is specified more than once", func() {
+			nc.Spec.Hugepages = []v1.Hugepages{{Size: "2Mi", Count: 512}, {Size: "2Mi", Count: 256}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when the count is zero", func() {
+			nc.Spec.Hugepages = []v1.Hugepages{{Size: "2Mi"}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("KernelParameters", func() {
+		It("should succeed with a list of isolated CPUs", func() {
+			nc.Spec.KernelParameters = &v1.KernelParameters{IsolatedCPUs: lo.ToPtr("2-7,10,12-13"), NUMABalancing: lo.ToPtr(false)}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when the isolated CPUs aren't a CPU list", func() {
+			nc.Spec.KernelParameters = &v1.KernelParameters{IsolatedCPUs: lo.ToPtr("2-")}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("Sysctls", func() {
+		It("should succeed with network sysctls", func() {
+			nc.Spec.Sysctls = map[string]string{
+				"net.netfilter.nf_conntrack_max":     "1048576",
+				"net.ipv4.conf.eth0.rp_filter":       "2",
+				"net.ipv4.ip_local_port_range":       "1024 65535",
+				"net.core.somaxconn":                 "4096",
+				"fs.inotify.max_user_watches":        "524288",
+				"net.ipv4.tcp_keepalive_time":        "600",
+				"net.bridge.bridge-nf-call-iptables": "1",
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when a sysctl name isn't dot separated", func() {
+			nc.Spec.Sysctls = map[string]string{"somaxconn": "4096"}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when a sysctl value spans multiple lines", func() {
+			nc.Spec.Sysctls = map[string]string{"net.core.somaxconn": "4096\nkernel.panic = 1"}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when a sysctl value is empty", func() {
+			nc.Spec.Sysctls = map[string]string{"net.core.somaxconn": ""}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("KernelModules", func() {
+		It("should succeed with kernel modules", func() {
+			nc.Spec.KernelModules = []v1.KernelModule{"nf_conntrack", "br_netfilter"}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when a kernel module name has a path", func() {
+			nc.Spec.KernelModules = []v1.KernelModule{"/lib/modules/nf_conntrack.ko"}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("DCGMExporter", func() {
+		It("should succeed with a custom image", func() {
+			nc.Spec.DCGMExporter = &v1.DCGMExporter{Enabled: lo.ToPtr(true), Image: lo.ToPtr("public.ecr.aws/test/dcgm-exporter@sha256:0123456789abcdef")}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when the image has shell characters", func() {
+			nc.Spec.DCGMExporter = &v1.DCGMExporter{Enabled: lo.ToPtr(true), Image: lo.ToPtr("public.ecr.aws/test/dcgm-exporter; reboot")}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("Neuron", func() {
+		It("should succeed with a logical NeuronCore config of 2", func() {
+			nc.Spec.Neuron = &v1.NeuronConfiguration{LogicalNeuronCoreConfig: lo.ToPtr[int32](2)}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail with a logical NeuronCore config of 4", func() {
+			nc.Spec.Neuron = &v1.NeuronConfiguration{LogicalNeuronCoreConfig: lo.ToPtr[int32](4)}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("SSMAgent", func() {
+		It("should succeed with a hybrid activation", func() {
+			nc.Spec.SSMAgent = &v1.SSMAgent{HybridActivation: &v1.SSMHybridActivation{ID: "e488f2f6-e686-4afb-8a04-ef6dfe4f2a3b", Code: "{{resolve:ssm-secure:/karpenter/activation-code}}", Region: "us-west-2"}}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when a hybrid activation is set for a disabled SSM agent", func() {
+			nc.Spec.SSMAgent = &v1.SSMAgent{Enabled: lo.ToPtr(false), HybridActivation: &v1.SSMHybridActivation{ID: "e488f2f6-e686-4afb-8a04-ef6dfe4f2a3b", Code: "{{resolve:ssm-secure:/karpenter/activation-code}}", Region: "us-west-2"}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when the activation code isn't a reference", func() {
+			nc.Spec.SSMAgent = &v1.SSMAgent{HybridActivation: &v1.SSMHybridActivation{ID: "e488f2f6-e686-4afb-8a04-ef6dfe4f2a3b", Code: "o1PsFFt8NRyEMBRKHqaP", Region: "us-west-2"}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should succeed with session manager", func() {
+			nc.Spec.SSMAgent = &v1.SSMAgent{SessionManager: lo.ToPtr(true)}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when session manager is enabled for a disabled SSM agent", func() {
+			nc.Spec.SSMAgent = &v1.SSMAgent{Enabled: lo.ToPtr(false), SessionManager: lo.ToPtr(true)}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("KeyName", func() {
+		It("should succeed with a key name", func() {
+			nc.Spec.KeyName = lo.ToPtr("incident-response")
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail with an empty key name", func() {
+			nc.Spec.KeyName = lo.ToPtr("")
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("CloudWatchAgent", func() {
+		It("should succeed with a log group name", func() {
+			nc.Spec.CloudWatchAgent = &v1.CloudWatchAgent{Enabled: lo.ToPtr(true), LogGroupName: lo.ToPtr("/aws/karpenter/my-cluster_1/nodes#eks")}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when the log group name has a space", func() {
+			nc.Spec.CloudWatchAgent = &v1.CloudWatchAgent{Enabled: lo.ToPtr(true), LogGroupName: lo.ToPtr("/aws/karpenter/my cluster")}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
 	Context("Tags", func() {
 		It("should succeed when tags are empty", func() {
 			nc.Spec.Tags = map[string]string{}
@@ -138,6 +333,42 @@ var _ = Describe("CEL/Validation", func() {
 			}
 			Expect(env.Client.Create(ctx, nc)).To(Succeed())
 		})
+		It("should succeed with a valid subnet selector on cidr", func() {
+			nc.Spec.SubnetSelectorTerms = []v1.SubnetSelectorTerm{
+				{
+					CIDR: "10.0.128.0/17",
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should succeed with a valid subnet selector on cidr and tags", func() {
+			nc.Spec.SubnetSelectorTerms = []v1.SubnetSelectorTerm{
+				{
+					CIDR: "10.0.128.0/17",
+					Tags: map[string]string{
+						"test": "testvalue",
+					},
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when a subnet selector term has an invalid cidr", func() {
+			nc.Spec.SubnetSelectorTerms = []v1.SubnetSelectorTerm{
+				{
+					CIDR: "10.0.128.0",
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when a subnet selector term has an id and a cidr", func() {
+			nc.Spec.SubnetSelectorTerms = []v1.SubnetSelectorTerm{
+				{
+					ID:   "subnet-12345749",
+					CIDR: "10.0.128.0/17",
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
 		It("should fail when subnet selector terms is set to nil", func() {
 			nc.Spec.SubnetSelectorTerms = nil
 			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
@@ -244,6 +475,32 @@ var _ = Describe("CEL/Validation", func() {
 			}
 			Expect(env.Client.Create(ctx, nc)).To(Succeed())
 		})
+		It("should succeed with a valid security group selector scoped to a vpc", func() {
+			nc.Spec.SecurityGroupSelectorTerms = []v1.SecurityGroupSelectorTerm{
+				{
+					Name:  "testname-*",
+					VPCID: "vpc-12345749",
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when a security group selector term has an invalid vpc id", func() {
+			nc.Spec.SecurityGroupSelectorTerms = []v1.SecurityGroupSelectorTerm{
+				{
+					Name:  "testname",
+					VPCID: "invalid",
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when a security group selector term only has a vpc id", func() {
+			nc.Spec.SecurityGroupSelectorTerms = []v1.SecurityGroupSelectorTerm{
+				{
+					VPCID: "vpc-12345749",
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
 		It("should fail when security group selector terms is set to nil", func() {
 			nc.Spec.SecurityGroupSelectorTerms = nil
 			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
@@ -512,6 +769,24 @@ var _ = Describe("CEL/Validation", func() {
 			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
 		})
 	})
+	Context("AMIVerification", func() {
+		It("should succeed with a kms key", func() {
+			nc.Spec.AMIVerification = &v1.AMIVerification{KMSKeyID: aws.String("alias/ami-signing")}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should succeed with a public key", func() {
+			nc.Spec.AMIVerification = &v1.AMIVerification{PublicKey: aws.String("-----BEGIN PUBLIC KEY-----")}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail with both a kms key and a public key", func() {
+			nc.Spec.AMIVerification = &v1.AMIVerification{KMSKeyID: aws.String("alias/ami-signing"), PublicKey: aws.String("-----BEGIN PUBLIC KEY-----")}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail without a key", func() {
+			nc.Spec.AMIVerification = &v1.AMIVerification{}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
 	Context("Kubelet", func() {
 		It("should fail on kubeReserved with invalid keys", func() {
 			nc.Spec.Kubelet = &v1.KubeletConfiguration{
@@ -851,6 +1126,137 @@ var _ = Describe("CEL/Validation", func() {
 			}
 			Expect(env.Client.Create(ctx, nodeClass)).To(Not(Succeed()))
 		})
+		It("should fail if more than one imageFSVolume is specified", func() {
+			nodeClass := &v1.EC2NodeClass{
+				ObjectMeta: test.ObjectMeta(metav1.ObjectMeta{}),
+				Spec: v1.EC2NodeClassSpec{
+					AMISelectorTerms:           nc.Spec.AMISelectorTerms,
+					SubnetSelectorTerms:        nc.Spec.SubnetSelectorTerms,
+					SecurityGroupSelectorTerms: nc.Spec.SecurityGroupSelectorTerms,
+					Role:                       nc.Spec.Role,
+					BlockDeviceMappings: []*v1.BlockDeviceMapping{
+						{
+							DeviceName: aws.String("map-device-1"),
+							EBS: &v1.BlockDevice{
+								VolumeSize: resource.NewScaledQuantity(50, resource.Giga),
+							},
+							ImageFSVolume: true,
+						},
+						{
+							DeviceName: aws.String("map-device-2"),
+							EBS: &v1.BlockDevice{
+								VolumeSize: resource.NewScaledQuantity(50, resource.Giga),
+							},
+							ImageFSVolume: true,
+						},
+					},
+				},
+			}
+			Expect(env.Client.Create(ctx, nodeClass)).To(Not(Succeed()))
+		})
+		It("should fail if a blockDeviceMapping is both the rootVolume and the imageFSVolume", func() {
+			nodeClass := &v1.EC2NodeClass{
+				ObjectMeta: test.ObjectMeta(metav1.ObjectMeta{}),
+				Spec: v1.EC2NodeClassSpec{
+					AMISelectorTerms:           nc.Spec.AMISelectorTerms,
+					SubnetSelectorTerms:        nc.Spec.SubnetSelectorTerms,
+					SecurityGroupSelectorTerms: nc.Spec.SecurityGroupSelectorTerms,
+					Role:                       nc.Spec.Role,
+					BlockDeviceMappings: []*v1.BlockDeviceMapping{
+						{
+							DeviceName: aws.String("map-device-1"),
+							EBS: &v1.BlockDevice{
+								VolumeSize: resource.NewScaledQuantity(50, resource.Giga),
+							},
+							RootVolume:    true,
+							ImageFSVolume: true,
+						},
+					},
+				},
+			}
+			Expect(env.Client.Create(ctx, nodeClass)).To(Not(Succeed()))
+		})
+		It("should succeed if a blockDeviceMapping selects its snapshot", func() {
+			nodeClass := &v1.EC2NodeClass{
+				ObjectMeta: test.ObjectMeta(metav1.ObjectMeta{}),
+				Spec: v1.EC2NodeClassSpec{
+					AMISelectorTerms:           nc.Spec.AMISelectorTerms,
+					SubnetSelectorTerms:        nc.Spec.SubnetSelectorTerms,
+					SecurityGroupSelectorTerms: nc.Spec.SecurityGroupSelectorTerms,
+					Role:                       nc.Spec.Role,
+					BlockDeviceMappings: []*v1.BlockDeviceMapping{
+						{
+							DeviceName: aws.String("map-device-1"),
+							EBS: &v1.BlockDevice{
+								SnapshotSelectorTerms: []v1.SnapshotSelectorTerm{{Tags: map[string]string{"image-cache": "ml"}, Owner: "self"}},
+							},
+						},
+					},
+				},
+			}
+			Expect(env.Client.Create(ctx, nodeClass)).To(Succeed())
+		})
+		It("should fail if a blockDeviceMapping has both a snapshotID and snapshotSelectorTerms", func() {
+			nodeClass := &v1.EC2NodeClass{
+				ObjectMeta: test.ObjectMeta(metav1.ObjectMeta{}),
+				Spec: v1.EC2NodeClassSpec{
+					AMISelectorTerms:           nc.Spec.AMISelectorTerms,
+					SubnetSelectorTerms:        nc.Spec.SubnetSelectorTerms,
+					SecurityGroupSelectorTerms: nc.Spec.SecurityGroupSelectorTerms,
+					Role:                       nc.Spec.Role,
+					BlockDeviceMappings: []*v1.BlockDeviceMapping{
+						{
+							DeviceName: aws.String("map-device-1"),
+							EBS: &v1.BlockDevice{
+								SnapshotID:            aws.String("snap-0123456789abcdef0"),
+								SnapshotSelectorTerms: []v1.SnapshotSelectorTerm{{Tags: map[string]string{"image-cache": "ml"}}},
+							},
+						},
+					},
+				},
+			}
+			Expect(env.Client.Create(ctx, nodeClass)).To(Not(Succeed()))
+		})
+		It("should fail if a snapshotSelectorTerm has no tags", func() {
+			nodeClass := &v1.EC2NodeClass{
+				ObjectMeta: test.ObjectMeta(metav1.ObjectMeta{}),
+				Spec: v1.EC2NodeClassSpec{
+					AMISelectorTerms:           nc.Spec.AMISelectorTerms,
+					SubnetSelectorTerms:        nc.Spec.SubnetSelectorTerms,
+					SecurityGroupSelectorTerms: nc.Spec.SecurityGroupSelectorTerms,
+					Role:                       nc.Spec.Role,
+					BlockDeviceMappings: []*v1.BlockDeviceMapping{
+						{
+							DeviceName: aws.String("map-device-1"),
+							EBS: &v1.BlockDevice{
+								SnapshotSelectorTerms: []v1.SnapshotSelectorTerm{{Owner: "self"}},
+							},
+						},
+					},
+				},
+			}
+			Expect(env.Client.Create(ctx, nodeClass)).To(Not(Succeed()))
+		})
+		It("should fail if a snapshotSelectorTerm has an invalid owner", func() {
+			nodeClass := &v1.EC2NodeClass{
+				ObjectMeta: test.ObjectMeta(metav1.ObjectMeta{}),
+				Spec: v1.EC2NodeClassSpec{
+					AMISelectorTerms:           nc.Spec.AMISelectorTerms,
+					SubnetSelectorTerms:        nc.Spec.SubnetSelectorTerms,
+					SecurityGroupSelectorTerms: nc.Spec.SecurityGroupSelectorTerms,
+					Role:                       nc.Spec.Role,
+					BlockDeviceMappings: []*v1.BlockDeviceMapping{
+						{
+							DeviceName: aws.String("map-device-1"),
+							EBS: &v1.BlockDevice{
+								SnapshotSelectorTerms: []v1.SnapshotSelectorTerm{{Tags: map[string]string{"image-cache": "ml"}, Owner: "amazon"}},
+							},
+						},
+					},
+				},
+			}
+			Expect(env.Client.Create(ctx, nodeClass)).To(Not(Succeed()))
+		})
 		It("should fail VolumeSize is less then 1Gi/1G", func() {
 			nodeClass := &v1.EC2NodeClass{
 				ObjectMeta: test.ObjectMeta(metav1.ObjectMeta{}),
@@ -31,6 +31,11 @@ func init() {
 	karpv1.WellKnownLabels = karpv1.WellKnownLabels.Insert(
 		LabelInstanceHypervisor,
 		LabelInstanceEncryptionInTransitSupported,
+		LabelInstancePTPSupported,
+		LabelInstanceTrunkingCompatible,
+		LabelInstanceNitro,
+		LabelInstanceEBSNVMe,
+		LabelInstanceENASupport,
 		LabelInstanceCategory,
 		LabelInstanceFamily,
 		LabelInstanceGeneration,
@@ -38,6 +43,8 @@ func init() {
 		LabelInstanceLocalNVME,
 		LabelInstanceCPU,
 		LabelInstanceCPUManufacturer,
+		LabelInstanceCPUBaseline,
+		LabelInstanceBurstable,
 		LabelInstanceMemory,
 		LabelInstanceEBSBandwidth,
 		LabelInstanceNetworkBandwidth,
@@ -90,17 +97,30 @@ var (
 	ResourceNVIDIAGPU          corev1.ResourceName = "nvidia.com/gpu"
 	ResourceAMDGPU             corev1.ResourceName = "amd.com/gpu"
 	ResourceAWSNeuron          corev1.ResourceName = "aws.amazon.com/neuron"
+	ResourceAWSNeuronCore      corev1.ResourceName = "aws.amazon.com/neuroncore"
 	ResourceHabanaGaudi        corev1.ResourceName = "habana.ai/gaudi"
 	ResourceAWSPodENI          corev1.ResourceName = "vpc.amazonaws.com/pod-eni"
 	ResourcePrivateIPv4Address corev1.ResourceName = "vpc.amazonaws.com/PrivateIPv4Address"
 	ResourceEFA                corev1.ResourceName = "vpc.amazonaws.com/efa"
 
 	LabelNodeClass = apis.Group + "/ec2nodeclass"
+	// LabelDCGMExporter is set on the nodes that run the DCGM exporter, so that it can be discovered by Prometheus
+	LabelDCGMExporter = apis.Group + "/dcgm-exporter"
+	// LabelNeuronLogicalCoreConfig is the logical NeuronCore config of the Neuron runtime of nodes with Neuron devices
+	LabelNeuronLogicalCoreConfig = apis.Group + "/neuron-logical-nc-config"
+	// LabelBottlerocketUpdaterInterfaceVersion selects the nodes that the Bottlerocket update operator updates in place
+	LabelBottlerocketUpdaterInterfaceVersion = "bottlerocket.aws/updater-interface-version"
+	BottlerocketUpdaterInterfaceVersion      = "2.0.0"
 
 	LabelTopologyZoneID = "topology.k8s.aws/zone-id"
 
 	LabelInstanceHypervisor                   = apis.Group + "/instance-hypervisor"
 	LabelInstanceEncryptionInTransitSupported = apis.Group + "/instance-encryption-in-transit-supported"
+	LabelInstancePTPSupported                 = apis.Group + "/instance-ptp-supported"
+	LabelInstanceTrunkingCompatible           = apis.Group + "/instance-trunking-compatible"
+	LabelInstanceNitro                        = apis.Group + "/instance-nitro"
+	LabelInstanceEBSNVMe                      = apis.Group + "/instance-ebs-nvme"
+	LabelInstanceENASupport                   = apis.Group + "/instance-ena-support"
 	LabelInstanceCategory                     = apis.Group + "/instance-category"
 	LabelInstanceFamily                       = apis.Group + "/instance-family"
 	LabelInstanceGeneration                   = apis.Group + "/instance-generation"
@@ -108,6 +128,8 @@ var (
 	LabelInstanceSize                         = apis.Group + "/instance-size"
 	LabelInstanceCPU                          = apis.Group + "/instance-cpu"
 	LabelInstanceCPUManufacturer              = apis.Group + "/instance-cpu-manufacturer"
+	LabelInstanceCPUBaseline                  = apis.Group + "/instance-cpu-baseline"
+	LabelInstanceBurstable                    = apis.Group + "/instance-burstable"
 	LabelInstanceMemory                       = apis.Group + "/instance-memory"
 	LabelInstanceEBSBandwidth                 = apis.Group + "/instance-ebs-bandwidth"
 	LabelInstanceNetworkBandwidth             = apis.Group + "/instance-network-bandwidth"
@@ -123,8 +145,46 @@ var (
 	AnnotationEC2NodeClassHashVersion         = apis.Group + "/ec2nodeclass-hash-version"
 	AnnotationInstanceTagged                  = apis.Group + "/tagged"
 	AnnotationAMIFamilyCompatibility          = apis.CompatibilityGroup + "/v1beta1-ami-family-conversion"
+	AnnotationLaunchSpotPrice                 = apis.Group + "/launch-spot-price"
+	AnnotationLaunchOnDemandPrice             = apis.Group + "/launch-on-demand-price"
+	AnnotationSubnetID                        = apis.Group + "/subnet-id"
+	AnnotationAppliedSettings                 = apis.Group + "/applied-settings"
+	AnnotationSettingsError                   = apis.Group + "/settings-error"
+	AnnotationNodeGroupMigration              = apis.Group + "/nodegroup-migration"
+	AnnotationSpotInterruptionThreshold       = apis.Group + "/spot-interruption-threshold"
+	AnnotationSpotReturnAfter                 = apis.Group + "/spot-return-after"
+	AnnotationSpotFallback                    = apis.Group + "/spot-fallback"
+	AnnotationSpotPercentage                  = apis.Group + "/spot-percentage"
+	AnnotationMaxNodesPerZone                 = apis.Group + "/max-nodes-per-zone"
+	AnnotationArchitecturePreference          = apis.Group + "/architecture-preference"
+	AnnotationCapacityFloor                   = apis.Group + "/capacity-floor"
+	AnnotationCapacitySchedule                = apis.Group + "/capacity-schedule"
+	AnnotationAdoptedInstance                 = apis.Group + "/adopted-instance"
+	AnnotationLaunchCapacityType              = apis.Group + "/launch-capacity-type"
+	AnnotationLaunchZone                      = apis.Group + "/launch-zone"
+	AnnotationLaunchInstanceFamily            = apis.Group + "/launch-instance-family"
+	AnnotationMaintenanceWindow               = apis.Group + "/maintenance-window"
+	AnnotationMaintenanceWindowBudget         = apis.Group + "/maintenance-window-budget"
+	AnnotationInterruptionAwareDisruption     = apis.Group + "/interruption-aware-disruption"
+	AnnotationTerminationProtection           = apis.Group + "/termination-protection"
+	AnnotationTerminationProtected            = apis.Group + "/termination-protected"
+	AnnotationExternallyProtected             = apis.Group + "/externally-protected"
+	AnnotationInstanceMetadata                = apis.Group + "/instance-metadata"
+	AnnotationUsageOperation                  = apis.Group + "/usage-operation"
+	AnnotationPlacementGroup                  = apis.Group + "/placement-group"
+	AnnotationHostID                          = apis.Group + "/host-id"
+	AnnotationCapacityReservationID           = apis.Group + "/capacity-reservation-id"
+	AnnotationAMIName                         = apis.Group + "/ami-name"
+
+	// TaintIdentityNotReady is a startup taint that is removed once the identity agents on the node are ready
+	TaintIdentityNotReady = apis.Group + "/identity-not-ready"
 
 	TagNodeClaim             = coreapis.Group + "/nodeclaim"
 	TagManagedLaunchTemplate = apis.Group + "/cluster"
 	TagName                  = "Name"
+	TagAMISignature          = apis.Group + "/ami-signature"
+	TagAMIRecipe             = apis.Group + "/ami-recipe"
+	TagSubnetPriority        = apis.Group + "/subnet-priority"
+	TagAdoptNodePool         = apis.Group + "/adopt-nodepool"
+	TagSessionManager        = apis.Group + "/session-manager"
 )
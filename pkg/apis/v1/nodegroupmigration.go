@@ -0,0 +1,154 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"github.com/awslabs/operatorpkg/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NodeGroupMigrationSpec is the specification of the migration of an auto scaling group or an EKS managed node group
+// to the capacity of a NodePool.
+type NodeGroupMigrationSpec struct {
+	// AutoScalingGroupName is the name of the auto scaling group whose nodes are migrated.
+	// +kubebuilder:validation:XValidation:message="autoScalingGroupName is immutable",rule="self == oldSelf"
+	// +optional
+	AutoScalingGroupName string `json:"autoScalingGroupName,omitempty"`
+	// NodeGroupName is the name of the EKS managed node group, in the cluster of the controller, whose nodes are migrated.
+	// +kubebuilder:validation:XValidation:message="nodeGroupName is immutable",rule="self == oldSelf"
+	// +optional
+	NodeGroupName string `json:"nodeGroupName,omitempty"`
+	// NodePool is the name of the NodePool that launches the capacity which replaces the node group. The labels and
+	// taints of the node group must be set on the NodePool, so that its pods schedule to the new capacity.
+	// +kubebuilder:validation:MinLength:=1
+	// +required
+	NodePool string `json:"nodePool"`
+	// BatchSize is the number of nodes of the node group that are drained at a time.
+	// +kubebuilder:default:=1
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	BatchSize int32 `json:"batchSize,omitempty"`
+	// DrainTimeout is how long a batch of nodes is drained before the migration stops evicting their pods, like the
+	// pods whose eviction violates a PDB. The drain is retried once the migration is changed. A drainTimeout of 0m
+	// doesn't time out.
+	// +kubebuilder:validation:Pattern=`^((([0-9]+(h|m))|([0-9]+h[0-9]+m))(0s)?)$`
+	// +kubebuilder:validation:Type="string"
+	// +kubebuilder:default:="15m"
+	// +optional
+	DrainTimeout *metav1.Duration `json:"drainTimeout,omitempty"`
+	// Rollback stops the migration, uncordons the remaining nodes of the node group and restores its capacity.
+	// +optional
+	Rollback bool `json:"rollback,omitempty"`
+}
+
+// NodeGroupMigrationPhase is the phase of a NodeGroupMigration
+type NodeGroupMigrationPhase string
+
+const (
+	// NodeGroupMigrationPhasePending is the phase of a migration whose node group or NodePool isn't ready to migrate
+	NodeGroupMigrationPhasePending NodeGroupMigrationPhase = "Pending"
+	// NodeGroupMigrationPhaseMigrating is the phase of a migration that is draining the nodes of its node group
+	NodeGroupMigrationPhaseMigrating NodeGroupMigrationPhase = "Migrating"
+	// NodeGroupMigrationPhaseCompleted is the phase of a migration whose node group doesn't have nodes anymore
+	NodeGroupMigrationPhaseCompleted NodeGroupMigrationPhase = "Completed"
+	// NodeGroupMigrationPhaseRolledBack is the phase of a migration whose node group was restored
+	NodeGroupMigrationPhaseRolledBack NodeGroupMigrationPhase = "RolledBack"
+)
+
+const (
+	// ConditionTypeDrained is false while the draining nodes of a migration couldn't be drained within its drainTimeout
+	ConditionTypeDrained = "Drained"
+)
+
+// NodeGroupCapacity is the size of a node group
+type NodeGroupCapacity struct {
+	MinSize     int64 `json:"minSize"`
+	MaxSize     int64 `json:"maxSize"`
+	DesiredSize int64 `json:"desiredSize"`
+}
+
+// NodeGroupMigrationStatus is the progress of a NodeGroupMigration
+type NodeGroupMigrationStatus struct {
+	// Phase is the phase of the migration
+	// +optional
+	Phase NodeGroupMigrationPhase `json:"phase,omitempty"`
+	// Message explains the phase of the migration, like what a migration is waiting for
+	// +optional
+	Message string `json:"message,omitempty"`
+	// AutoScalingGroupName is the name of the auto scaling group of the node group
+	// +optional
+	AutoScalingGroupName string `json:"autoScalingGroupName,omitempty"`
+	// OriginalCapacity is the capacity of the node group before the migration, which is restored on rollback
+	// +optional
+	OriginalCapacity *NodeGroupCapacity `json:"originalCapacity,omitempty"`
+	// DrainingNodes are the nodes of the node group that are being drained
+	// +optional
+	DrainingNodes []string `json:"drainingNodes,omitempty"`
+	// DrainStartTime is when the draining nodes started draining
+	// +optional
+	DrainStartTime *metav1.Time `json:"drainStartTime,omitempty"`
+	// EvictedPodOwners are the UIDs of the controllers of the pods that were evicted from the draining nodes, whose
+	// pods must be scheduled before the next batch of nodes is drained
+	// +optional
+	EvictedPodOwners []string `json:"evictedPodOwners,omitempty"`
+	// RemainingNodes is the number of nodes of the node group that aren't migrated yet
+	// +optional
+	RemainingNodes int32 `json:"remainingNodes,omitempty"`
+	// MigratedNodes is the number of nodes of the node group that were drained and terminated
+	// +optional
+	MigratedNodes int32 `json:"migratedNodes,omitempty"`
+	// Conditions contains signals for the progress of the migration
+	// +optional
+	Conditions []status.Condition `json:"conditions,omitempty"`
+}
+
+// NodeGroupMigration cordons and drains the nodes of an auto scaling group or an EKS managed node group in batches, as
+// the capacity of a NodePool replaces them, and scales the node group in as its nodes are terminated.
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description=""
+// +kubebuilder:printcolumn:name="NodePool",type="string",JSONPath=".spec.nodePool",description=""
+// +kubebuilder:printcolumn:name="Migrated",type="integer",JSONPath=".status.migratedNodes",description=""
+// +kubebuilder:printcolumn:name="Remaining",type="integer",JSONPath=".status.remainingNodes",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+// +kubebuilder:resource:path=nodegroupmigrations,scope=Cluster,categories=karpenter,shortName={ngm,ngms}
+// +kubebuilder:subresource:status
+type NodeGroupMigration struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +kubebuilder:validation:XValidation:message="must specify exactly one of ['autoScalingGroupName', 'nodeGroupName']",rule="has(self.autoScalingGroupName) != has(self.nodeGroupName)"
+	Spec   NodeGroupMigrationSpec   `json:"spec"`
+	Status NodeGroupMigrationStatus `json:"status,omitempty"`
+}
+
+func (in *NodeGroupMigration) StatusConditions() status.ConditionSet {
+	return status.NewReadyConditions(ConditionTypeDrained).For(in)
+}
+
+func (in *NodeGroupMigration) GetConditions() []status.Condition {
+	return in.Status.Conditions
+}
+
+func (in *NodeGroupMigration) SetConditions(conditions []status.Condition) {
+	in.Status.Conditions = conditions
+}
+
+// NodeGroupMigrationList contains a list of NodeGroupMigration
+// +kubebuilder:object:root=true
+type NodeGroupMigrationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NodeGroupMigration `json:"items"`
+}
@@ -0,0 +1,79 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1_test
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/karpenter/pkg/test"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CEL/Validation/NodeGroupMigration", func() {
+	var migration *v1.NodeGroupMigration
+
+	BeforeEach(func() {
+		if env.Version.Minor() < 25 {
+			Skip("CEL Validation is for 1.25>")
+		}
+		migration = &v1.NodeGroupMigration{
+			ObjectMeta: test.ObjectMeta(metav1.ObjectMeta{}),
+			Spec:       v1.NodeGroupMigrationSpec{AutoScalingGroupName: "workers", NodePool: "default"},
+		}
+	})
+	AfterEach(func() {
+		Expect(env.Client.DeleteAllOf(ctx, &v1.NodeGroupMigration{})).To(Succeed())
+	})
+	It("should succeed with an auto scaling group", func() {
+		Expect(env.Client.Create(ctx, migration)).To(Succeed())
+		Expect(migration.Spec.BatchSize).To(BeNumerically("==", 1))
+		Expect(migration.Spec.DrainTimeout.Duration).To(Equal(15 * time.Minute))
+	})
+	It("should succeed with a managed node group", func() {
+		migration.Spec.AutoScalingGroupName = ""
+		migration.Spec.NodeGroupName = "workers"
+		Expect(env.Client.Create(ctx, migration)).To(Succeed())
+	})
+	It("should fail without a node group", func() {
+		migration.Spec.AutoScalingGroupName = ""
+		Expect(env.Client.Create(ctx, migration)).ToNot(Succeed())
+	})
+	It("should fail with both an auto scaling group and a managed node group", func() {
+		migration.Spec.NodeGroupName = "workers"
+		Expect(env.Client.Create(ctx, migration)).ToNot(Succeed())
+	})
+	It("should fail without a nodepool", func() {
+		migration.Spec.NodePool = ""
+		Expect(env.Client.Create(ctx, migration)).ToNot(Succeed())
+	})
+	It("should fail with a negative batch size", func() {
+		migration.Spec.BatchSize = -1
+		Expect(env.Client.Create(ctx, migration)).ToNot(Succeed())
+	})
+	It("should fail with a drain timeout in seconds", func() {
+		migration.Spec.DrainTimeout = &metav1.Duration{Duration: 30 * time.Second}
+		Expect(env.Client.Create(ctx, migration)).ToNot(Succeed())
+	})
+	It("should fail to change the auto scaling group", func() {
+		Expect(env.Client.Create(ctx, migration)).To(Succeed())
+		migration.Spec.AutoScalingGroupName = "other-workers"
+		Expect(env.Client.Update(ctx, migration)).ToNot(Succeed())
+	})
+})
@@ -29,8 +29,8 @@ import (
 type EC2NodeClassSpec struct {
 	// SubnetSelectorTerms is a list of or subnet selector terms. The terms are ORed.
 	// +kubebuilder:validation:XValidation:message="subnetSelectorTerms cannot be empty",rule="self.size() != 0"
-	// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['tags', 'id']",rule="self.all(x, has(x.tags) || has(x.id))"
-	// +kubebuilder:validation:XValidation:message="'id' is mutually exclusive, cannot be set with a combination of other fields in subnetSelectorTerms",rule="!self.all(x, has(x.id) && has(x.tags))"
+	// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['tags', 'id', 'cidr']",rule="self.all(x, has(x.tags) || has(x.id) || has(x.cidr))"
+	// +kubebuilder:validation:XValidation:message="'id' is mutually exclusive, cannot be set with a combination of other fields in subnetSelectorTerms",rule="!self.all(x, has(x.id) && (has(x.tags) || has(x.cidr)))"
 	// +kubebuilder:validation:MaxItems:=30
 	// +required
 	SubnetSelectorTerms []SubnetSelectorTerm `json:"subnetSelectorTerms" hash:"ignore"`
@@ -45,12 +45,26 @@ type EC2NodeClassSpec struct {
 	// AssociatePublicIPAddress controls if public IP addresses are assigned to instances that are launched with the nodeclass.
 	// +optional
 	AssociatePublicIPAddress *bool `json:"associatePublicIPAddress,omitempty"`
+	// PrivateDNSNameOptions configures the private DNS hostnames of the instances, which the nodes are named after, and
+	// their DNS records. Defaults to the private DNS hostname options of the subnet.
+	// +optional
+	PrivateDNSNameOptions *PrivateDNSNameOptions `json:"privateDnsNameOptions,omitempty"`
+	// NameTag is the Go template of the Name tag of the instances, which defaults to the name of the node. The template
+	// has the .NodeName, .InstanceID, .NodePool, .Zone, .InstanceType and .CapacityType fields, like
+	// "{{ .NodePool }}-{{ .Zone }}-{{ .InstanceID }}". The Name tag is set once the node registers.
+	// +kubebuilder:validation:MaxLength:=255
+	// +optional
+	NameTag *string `json:"nameTag,omitempty" hash:"ignore"`
 	// AMISelectorTerms is a list of or ami selector terms. The terms are ORed.
 	// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['tags', 'id', 'name']",rule="self.all(x, has(x.tags) || has(x.id) || has(x.name))"
 	// +kubebuilder:validation:XValidation:message="'id' is mutually exclusive, cannot be set with a combination of other fields in amiSelectorTerms",rule="!self.all(x, has(x.id) && (has(x.tags) || has(x.name) || has(x.owner)))"
 	// +kubebuilder:validation:MaxItems:=30
 	// +optional
 	AMISelectorTerms []AMISelectorTerm `json:"amiSelectorTerms,omitempty" hash:"ignore"`
+	// AMIVerification verifies the signatures that are attached to the discovered AMIs as tags before they are used.
+	// AMIs without a valid signature are rejected.
+	// +optional
+	AMIVerification *AMIVerification `json:"amiVerification,omitempty" hash:"ignore"`
 	// AMIFamily is the AMI family that instances use.
 	// +kubebuilder:validation:Enum:={AL2,AL2023,Bottlerocket,Ubuntu,Custom,Windows2019,Windows2022}
 	// +required
@@ -60,6 +74,54 @@ type EC2NodeClassSpec struct {
 	// this UserData to ensure nodes are being provisioned with the correct configuration.
 	// +optional
 	UserData *string `json:"userData,omitempty"`
+	// ContainerRegistries configures the mirrors and credentials that containerd uses to pull images from container
+	// registries. They are rendered into the containerd configuration of the AL2, AL2023, Ubuntu and Bottlerocket
+	// AMIFamilies.
+	// +kubebuilder:validation:XValidation:message="container registry hosts must be unique",rule="self.all(x, self.exists_one(y, x.host == y.host))"
+	// +kubebuilder:validation:MaxItems:=30
+	// +optional
+	ContainerRegistries []ContainerRegistry `json:"containerRegistries,omitempty"`
+	// ContainerSnapshotter is the snapshotter that containerd unpacks images with. SOCI lazily loads the layers of
+	// images that have a SOCI index, so that containers start before their images are fully pulled. It's configured
+	// for the AL2023 and Bottlerocket AMIFamilies, and the AMI defaults to OverlayFS when it isn't set.
+	// +optional
+	ContainerSnapshotter *ContainerSnapshotter `json:"containerSnapshotter,omitempty"`
+	// Swap configures swap space on the nodes and lets kubelet start with it. It's provisioned by the AL2, AL2023
+	// and Ubuntu AMIFamilies, and requires the NodeSwap feature of kubelet, which is enabled by default since
+	// Kubernetes 1.30.
+	// +optional
+	Swap *Swap `json:"swap,omitempty"`
+	// Windows configures the components that the Windows2019 and Windows2022 AMIFamilies set up before the node
+	// joins the cluster. It's ignored by the other AMIFamilies.
+	// +optional
+	Windows *WindowsConfiguration `json:"windows,omitempty"`
+	// Bottlerocket configures how the nodes of the Bottlerocket AMIFamily are updated to newer AMIs. It's ignored by
+	// the other AMIFamilies.
+	// +optional
+	Bottlerocket *BottlerocketConfiguration `json:"bottlerocket,omitempty" hash:"ignore"`
+	// Hugepages are pre-allocated on the nodes before kubelet starts, and are advertised as the hugepages-<size>
+	// resource of the instance types, with their memory taken out of the memory capacity. They're allocated by the
+	// AL2, AL2023, Ubuntu and Bottlerocket AMIFamilies, and Bottlerocket only allocates 1Gi hugepages when both sizes
+	// are set.
+	// +kubebuilder:validation:XValidation:message="hugepage sizes must be unique",rule="self.all(x, self.exists_one(y, x.size == y.size))"
+	// +kubebuilder:validation:MaxItems:=2
+	// +optional
+	Hugepages []Hugepages `json:"hugepages,omitempty"`
+	// KernelParameters configures the NUMA balancing and CPU isolation of the kernel on the nodes.
+	// +optional
+	KernelParameters *KernelParameters `json:"kernelParameters,omitempty"`
+	// Sysctls are set on the nodes before kubelet starts, like net.netfilter.nf_conntrack_max or
+	// net.ipv4.conf.all.rp_filter. They're set by all AMIFamilies except for Windows and Custom.
+	// +kubebuilder:validation:XValidation:message="sysctl names must be made of dot separated words",rule="self.all(k, k.matches('^[a-z0-9_]+([.][a-zA-Z0-9_-]+)+$'))"
+	// +kubebuilder:validation:XValidation:message="sysctl values must be printable characters on a single line",rule="self.all(k, self[k].matches('^[ -~]+$'))"
+	// +kubebuilder:validation:MaxProperties:=100
+	// +optional
+	Sysctls map[string]string `json:"sysctls,omitempty"`
+	// KernelModules are loaded on the nodes before the sysctls are set and kubelet starts, so that the sysctls of the
+	// modules, like the ones of nf_conntrack, can be set. They're loaded by all AMIFamilies except for Windows and Custom.
+	// +kubebuilder:validation:MaxItems:=30
+	// +optional
+	KernelModules []KernelModule `json:"kernelModules,omitempty"`
 	// Role is the AWS identity that nodes use. This field is immutable.
 	// This field is mutually exclusive from instanceProfile.
 	// Marking this field as immutable avoids concerns around terminating managed instance profiles from running instances.
@@ -87,15 +149,78 @@ type EC2NodeClassSpec struct {
 	Tags map[string]string `json:"tags,omitempty"`
 	// BlockDeviceMappings to be applied to provisioned nodes.
 	// +kubebuilder:validation:XValidation:message="must have only one blockDeviceMappings with rootVolume",rule="self.filter(x, has(x.rootVolume)?x.rootVolume==true:false).size() <= 1"
+	// +kubebuilder:validation:XValidation:message="must have only one blockDeviceMappings with imageFSVolume",rule="self.filter(x, has(x.imageFSVolume)?x.imageFSVolume==true:false).size() <= 1"
 	// +kubebuilder:validation:MaxItems:=50
 	// +optional
 	BlockDeviceMappings []*BlockDeviceMapping `json:"blockDeviceMappings,omitempty"`
 	// InstanceStorePolicy specifies how to handle instance-store disks.
 	// +optional
 	InstanceStorePolicy *InstanceStorePolicy `json:"instanceStorePolicy,omitempty"`
+	// SubnetSelectionPolicy decides which subnet instances are launched in when multiple subnets are selected in an
+	// availability zone. MostAvailableIPs chooses the subnet with the most available IP addresses, Priority chooses
+	// the subnet with the highest karpenter.k8s.aws/subnet-priority tag value and RoundRobin rotates through the
+	// subnets. Defaults to MostAvailableIPs.
+	// +optional
+	SubnetSelectionPolicy *SubnetSelectionPolicy `json:"subnetSelectionPolicy,omitempty" hash:"ignore"`
 	// DetailedMonitoring controls if detailed monitoring is enabled for instances that are launched
 	// +optional
 	DetailedMonitoring *bool `json:"detailedMonitoring,omitempty"`
+	// EBSOptimized controls if instances that are launched are EBS-optimized, which gives their EBS volumes dedicated
+	// bandwidth. Instance types that can't be launched with the setting are excluded: true excludes the instance types
+	// that don't support EBS optimization, and false excludes the instance types that are always EBS-optimized.
+	// Defaults to the setting of the instance type, which is EBS-optimized for the instance types that support it by
+	// default.
+	// +optional
+	EBSOptimized *bool `json:"ebsOptimized,omitempty"`
+	// KeyName is the name of the EC2 key pair that instances are launched with, for SSH access to the nodes. The key
+	// pair has to exist in the region of the cluster. Session Manager gives shell access to the nodes without key pairs,
+	// see ssmAgent.sessionManager.
+	// +kubebuilder:validation:MinLength:=1
+	// +kubebuilder:validation:MaxLength:=255
+	// +optional
+	KeyName *string `json:"keyName,omitempty"`
+	// CreditSpecification is the credit option for the CPU usage of the burstable performance instance types, such as
+	// the T family, that are launched. Standard instances are throttled to their baseline once their CPU credits are
+	// spent, while unlimited instances keep bursting and are billed for the surplus credits. Instance types that aren't
+	// burstable ignore it. Defaults to the credit option of the instance family, which is unlimited for T3, T3a and T4g
+	// and standard for T2.
+	// +optional
+	CreditSpecification *CreditSpecification `json:"creditSpecification,omitempty"`
+	// PTPHardwareClock configures chrony on the nodes to use the PTP hardware clock of the ENA device as its preferred
+	// time source, on instance types that expose one. It's configured by the AL2, AL2023 and Ubuntu AMIFamilies.
+	// +optional
+	PTPHardwareClock *bool `json:"ptpHardwareClock,omitempty"`
+	// DCGMExporter runs the NVIDIA DCGM exporter on the nodes that are launched with NVIDIA GPUs, so that the telemetry
+	// of their GPUs is available without a DaemonSet. It's configured by the AL2, AL2023, Ubuntu and Bottlerocket
+	// AMIFamilies.
+	// +optional
+	DCGMExporter *DCGMExporter `json:"dcgmExporter,omitempty"`
+	// Neuron configures the Neuron runtime of the nodes that are launched with AWS Inferentia or Trainium devices. The
+	// runtime is configured by the AL2 and AL2023 AMIFamilies.
+	// +optional
+	Neuron *NeuronConfiguration `json:"neuron,omitempty"`
+	// VPCCNI describes the configuration of the VPC CNI on the nodes, which the pod density of the instance types is
+	// calculated from when the kubelet doesn't set maxPods. It overrides the reserved-enis setting of the controller, so
+	// that NodeClasses with different VPC CNI configurations can share a cluster.
+	// +optional
+	VPCCNI *VPCCNIConfiguration `json:"vpcCNI,omitempty"`
+	// PrimaryNetworkInterface configures the network interface that instances are launched with at device index 0, so
+	// that its type, source/destination check, description and tags don't need to be changed after the launch.
+	// +optional
+	PrimaryNetworkInterface *PrimaryNetworkInterface `json:"primaryNetworkInterface,omitempty"`
+	// MaintenanceWindow is the recurring window that the nodes of the NodePools of the EC2NodeClass can be voluntarily
+	// disrupted in. Consolidation and drift are blocked by a disruption budget of the NodePools outside of the window.
+	// NodePools that are annotated with an SSM maintenance window use it instead.
+	// +optional
+	MaintenanceWindow *MaintenanceWindow `json:"maintenanceWindow,omitempty" hash:"ignore"`
+	// SSMAgent configures the AWS Systems Manager agent of the nodes. The defaults of the AMI are kept when it isn't
+	// set. It's configured by the AL2, AL2023, Ubuntu, Bottlerocket and Windows AMIFamilies.
+	// +optional
+	SSMAgent *SSMAgent `json:"ssmAgent,omitempty"`
+	// CloudWatchAgent installs the Amazon CloudWatch agent on the nodes, which ships their system logs and metrics to
+	// CloudWatch from the time they boot. It's configured by the AL2, AL2023 and Ubuntu AMIFamilies.
+	// +optional
+	CloudWatchAgent *CloudWatchAgent `json:"cloudWatchAgent,omitempty"`
 	// MetadataOptions for the generated launch template of provisioned nodes.
 	//
 	// This specifies the exposure of the Instance Metadata Service to
@@ -113,10 +238,23 @@ type EC2NodeClassSpec struct {
 	// +kubebuilder:default={"httpEndpoint":"enabled","httpProtocolIPv6":"disabled","httpPutResponseHopLimit":1,"httpTokens":"required"}
 	// +optional
 	MetadataOptions *MetadataOptions `json:"metadataOptions,omitempty"`
+	// PodMetadataAccess is whether the pods of the nodes that don't use the host network are meant to reach the
+	// instance metadata service, e.g. for the credentials of the node role when they don't use IRSA or EKS Pod
+	// Identity. Their IMDSv2 responses take one more hop than the ones of the node, so the httpPutResponseHopLimit of
+	// the instances is raised to at least 2 when it's Allowed, and lowered to 1 when it's Blocked. The
+	// httpPutResponseHopLimit of metadataOptions is used as is when it isn't set.
+	// +optional
+	PodMetadataAccess *PodMetadataAccess `json:"podMetadataAccess,omitempty"`
 	// Context is a Reserved field in EC2 APIs
 	// https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_CreateFleet.html
 	// +optional
 	Context *string `json:"context,omitempty"`
+	// AssumeRoleARN is the IAM role that Karpenter assumes for the AWS calls it makes on behalf of this EC2NodeClass,
+	// such as resolving subnets, security groups and AMIs, managing launch templates and launching instances.
+	// If omitted, the calls are made with the credentials of the Karpenter controller.
+	// +kubebuilder:validation:Pattern:="^arn:aws[a-z-]*:iam::[0-9]{12}:role/.+$"
+	// +optional
+	AssumeRoleARN *string `json:"assumeRoleARN,omitempty"`
 }
 
 // SubnetSelectorTerm defines selection logic for a subnet used by Karpenter to launch nodes.
@@ -132,6 +270,11 @@ type SubnetSelectorTerm struct {
 	// +kubebuilder:validation:Pattern="subnet-[0-9a-z]+"
 	// +optional
 	ID string `json:"id,omitempty"`
+	// CIDR is an IPv4 CIDR range, e.g. 10.0.128.0/17. Only subnets with a CIDR block that is contained in the range are
+	// selected.
+	// +kubebuilder:validation:Pattern:="^([0-9]{1,3}\\.){3}[0-9]{1,3}/[0-9]{1,2}$"
+	// +optional
+	CIDR string `json:"cidr,omitempty"`
 }
 
 // SecurityGroupSelectorTerm defines selection logic for a security group used by Karpenter to launch nodes.
@@ -149,7 +292,12 @@ type SecurityGroupSelectorTerm struct {
 	ID string `json:"id,omitempty"`
 	// Name is the security group name in EC2.
 	// This value is the name field, which is different from the name tag.
+	// The name can contain '*' and '?' wildcards, e.g. "karpenter-*".
 	Name string `json:"name,omitempty"`
+	// VPCID is the id of the VPC that the security groups selected by the term must belong to.
+	// +kubebuilder:validation:Pattern:="vpc-[0-9a-z]+"
+	// +optional
+	VPCID string `json:"vpcID,omitempty"`
 }
 
 // AMISelectorTerm defines selection logic for an ami used by Karpenter to launch nodes.
@@ -175,6 +323,21 @@ type AMISelectorTerm struct {
 	Owner string `json:"owner,omitempty"`
 }
 
+// AMIVerification defines the key that the signatures of AMIs are verified with.
+// The signature is read from the karpenter.k8s.aws/ami-signature tag of the AMI, and is a base64 encoded ECDSA signature
+// over the AMI ID. If the AMI has a karpenter.k8s.aws/ami-recipe tag, the signature is over the AMI ID and the recipe,
+// separated by a newline.
+// +kubebuilder:validation:XValidation:message="expected exactly one of ['kmsKeyID', 'publicKey']",rule="has(self.kmsKeyID) != has(self.publicKey)"
+type AMIVerification struct {
+	// KMSKeyID is the ID, ARN or alias of the asymmetric ECC_NIST KMS key that AMIs are signed with.
+	// Karpenter retrieves the public key of the KMS key, which requires the kms:GetPublicKey permission.
+	// +optional
+	KMSKeyID *string `json:"kmsKeyID,omitempty"`
+	// PublicKey is the PEM encoded ECDSA public key that AMIs are signed with, such as a cosign public key.
+	// +optional
+	PublicKey *string `json:"publicKey,omitempty"`
+}
+
 // MetadataOptions contains parameters for specifying the exposure of the
 // Instance Metadata Service to provisioned EC2 nodes.
 type MetadataOptions struct {
@@ -225,17 +388,23 @@ type MetadataOptions struct {
 	HTTPTokens *string `json:"httpTokens,omitempty"`
 }
 
+// +kubebuilder:validation:XValidation:message="rootVolume and imageFSVolume are mutually exclusive",rule="!(has(self.rootVolume) && self.rootVolume && has(self.imageFSVolume) && self.imageFSVolume)"
 type BlockDeviceMapping struct {
 	// The device name (for example, /dev/sdh or xvdh).
 	// +required
 	DeviceName *string `json:"deviceName,omitempty"`
 	// EBS contains parameters used to automatically set up EBS volumes when an instance is launched.
-	// +kubebuilder:validation:XValidation:message="snapshotID or volumeSize must be defined",rule="has(self.snapshotID) || has(self.volumeSize)"
+	// +kubebuilder:validation:XValidation:message="snapshotID, snapshotSelectorTerms or volumeSize must be defined",rule="has(self.snapshotID) || has(self.snapshotSelectorTerms) || has(self.volumeSize)"
+	// +kubebuilder:validation:XValidation:message="snapshotID and snapshotSelectorTerms are mutually exclusive",rule="!(has(self.snapshotID) && has(self.snapshotSelectorTerms))"
 	// +required
 	EBS *BlockDevice `json:"ebs,omitempty"`
 	// RootVolume is a flag indicating if this device is mounted as kubelet root dir. You can
 	// configure at most one root volume in BlockDeviceMappings.
 	RootVolume bool `json:"rootVolume,omitempty"`
+	// ImageFSVolume is a flag indicating if this device is formatted and mounted as the root dir of containerd, so that
+	// container images are stored separately from the kubelet root dir. Bottlerocket and Windows store images on their
+	// default volumes and don't support it. You can configure at most one image filesystem volume in BlockDeviceMappings.
+	ImageFSVolume bool `json:"imageFSVolume,omitempty"`
 }
 
 type BlockDevice struct {
@@ -274,6 +443,12 @@ type BlockDevice struct {
 	// SnapshotID is the ID of an EBS snapshot
 	// +optional
 	SnapshotID *string `json:"snapshotID,omitempty"`
+	// SnapshotSelectorTerms select the EBS snapshot that the volume is created from. The newest completed snapshot
+	// that matches any of the terms is used each time a launch template is created, so that new snapshots, like the
+	// ones of a pipeline that pre-caches container images, are picked up by new nodes without changing the EC2NodeClass.
+	// +kubebuilder:validation:MaxItems:=30
+	// +optional
+	SnapshotSelectorTerms []SnapshotSelectorTerm `json:"snapshotSelectorTerms,omitempty"`
 	// Throughput to provision for a gp3 volume, with a maximum of 1,000 MiB/s.
 	// Valid Range: Minimum value of 125. Maximum value of 1000.
 	// +optional
@@ -304,6 +479,389 @@ type BlockDevice struct {
 	VolumeType *string `json:"volumeType,omitempty"`
 }
 
+// SnapshotSelectorTerm defines selection logic for an EBS snapshot.
+type SnapshotSelectorTerm struct {
+	// Tags is a map of key/value tags used to select snapshots
+	// Specifying '*' for a value selects all values for a given tag key.
+	// +kubebuilder:validation:XValidation:message="empty tag keys or values aren't supported",rule="self.all(k, k != '' && self[k] != '')"
+	// +kubebuilder:validation:MinProperties:=1
+	// +kubebuilder:validation:MaxProperties:=20
+	// +required
+	Tags map[string]string `json:"tags"`
+	// Owner is the AWS account ID that owns the snapshot, or "self". Snapshots of any owner that are shared with the
+	// account are selected when it isn't set.
+	// +kubebuilder:validation:Pattern:="^([0-9]{12}|self)$"
+	// +optional
+	Owner string `json:"owner,omitempty"`
+}
+
+// PodMetadataAccess enumerates whether pods are meant to reach the instance metadata service.
+// +kubebuilder:validation:Enum={Allowed,Blocked}
+type PodMetadataAccess string
+
+const (
+	// PodMetadataAccessAllowed lets the pods that don't use the host network reach the instance metadata service
+	PodMetadataAccessAllowed PodMetadataAccess = "Allowed"
+	// PodMetadataAccessBlocked keeps the pods that don't use the host network from reaching the instance metadata service
+	PodMetadataAccessBlocked PodMetadataAccess = "Blocked"
+)
+
+// CreditSpecification enumerates the credit options for the CPU usage of burstable performance instances.
+// +kubebuilder:validation:Enum={standard,unlimited}
+type CreditSpecification string
+
+const (
+	// CreditSpecificationStandard throttles instances to their baseline CPU utilization once their CPU credits are spent.
+	CreditSpecificationStandard CreditSpecification = "standard"
+	// CreditSpecificationUnlimited lets instances burst beyond their CPU credits, which are billed as surplus credits.
+	CreditSpecificationUnlimited CreditSpecification = "unlimited"
+)
+
+// SubnetSelectionPolicy enumerates the ways a subnet is chosen in an availability zone with multiple subnets.
+// +kubebuilder:validation:Enum={MostAvailableIPs,Priority,RoundRobin}
+type SubnetSelectionPolicy string
+
+const (
+	// SubnetSelectionPolicyMostAvailableIPs chooses the subnet with the most available IP addresses, accounting for
+	// the IP addresses of instances that are being launched.
+	SubnetSelectionPolicyMostAvailableIPs SubnetSelectionPolicy = "MostAvailableIPs"
+	// SubnetSelectionPolicyPriority chooses the subnet with the highest karpenter.k8s.aws/subnet-priority tag value.
+	// Subnets without the tag have a priority of 0, and subnets with the same priority are chosen by their available
+	// IP addresses.
+	SubnetSelectionPolicyPriority SubnetSelectionPolicy = "Priority"
+	// SubnetSelectionPolicyRoundRobin rotates through the subnets for each launch.
+	SubnetSelectionPolicyRoundRobin SubnetSelectionPolicy = "RoundRobin"
+)
+
+// ContainerRegistry configures how containerd pulls images from a container registry
+// +kubebuilder:validation:XValidation:message="expected at least one of ['mirrors', 'credentials']",rule="has(self.mirrors) || has(self.credentials)"
+type ContainerRegistry struct {
+	// Host of the registry that image references use, for example docker.io or registry.internal:5000.
+	// +kubebuilder:validation:Pattern:="^[a-zA-Z0-9.-]+(:[0-9]+)?$"
+	// +required
+	Host string `json:"host"`
+	// Mirrors are the endpoints that images of the registry are pulled from, in order, before falling back to the
+	// registry itself, for example an ECR pull through cache at https://111122223333.dkr.ecr.us-west-2.amazonaws.com.
+	// +kubebuilder:validation:items:Pattern:="^https?://"
+	// +kubebuilder:validation:MaxItems:=10
+	// +optional
+	Mirrors []string `json:"mirrors,omitempty"`
+	// Credentials authenticate with the registry, including when the host is used as a mirror of another registry.
+	// +optional
+	Credentials *ContainerRegistryCredentials `json:"credentials,omitempty"`
+}
+
+// ContainerRegistryCredentials are the basic authentication credentials of a container registry. They are references
+// to SSM parameters or Secrets Manager secrets, e.g. {{resolve:secretsmanager:registry:SecretString:password}}, which
+// are resolved when launch templates are created, so that the credentials aren't stored in the EC2NodeClass.
+type ContainerRegistryCredentials struct {
+	// Username is a reference to an SSM parameter, a SecureString SSM parameter or a Secrets Manager secret.
+	// +kubebuilder:validation:Pattern:="^\\{\\{resolve:(ssm|ssm-secure|secretsmanager):[^}]+\\}\\}$"
+	// +required
+	Username string `json:"username"`
+	// Password is a reference to a SecureString SSM parameter or a Secrets Manager secret.
+	// +kubebuilder:validation:Pattern:="^\\{\\{resolve:(ssm-secure|secretsmanager):[^}]+\\}\\}$"
+	// +required
+	Password string `json:"password"`
+}
+
+// Swap configures the swap space of nodes
+// +kubebuilder:validation:XValidation:message="size is required unless the location is InstanceStore",rule="(has(self.location) && self.location == 'InstanceStore') || has(self.size)"
+type Swap struct {
+	// Location is where the swap space is provisioned. RootVolume creates a swap file of the configured size on the
+	// root volume, and InstanceStore uses all NVMe instance store disks of the instance as swap devices. Instances
+	// without instance store disks get no swap space with InstanceStore. Defaults to RootVolume.
+	// +optional
+	Location *SwapLocation `json:"location,omitempty"`
+	// Size of the swap file on the root volume in `Mi`, `Gi`, `M` or `G`. The swap file takes up space of the root
+	// volume that is otherwise available to kubelet as ephemeral-storage.
+	// +kubebuilder:validation:Pattern:="^[1-9][0-9]{0,5}(Mi|Gi|M|G)$"
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type:=string
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty" hash:"string"`
+	// Swappiness is the vm.swappiness of the node, from 0 to 100. Lower values make the kernel reclaim the page cache
+	// before it swaps out memory of processes. The kernel default is kept when it isn't set.
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=100
+	// +optional
+	Swappiness *int32 `json:"swappiness,omitempty"`
+	// SwapBehavior is the memorySwap.swapBehavior of kubelet. LimitedSwap lets the containers of Burstable pods use
+	// swap in proportion to their memory requests, and NoSwap only lets processes outside of pods use swap.
+	// Defaults to LimitedSwap.
+	// +kubebuilder:validation:Enum:={LimitedSwap,NoSwap}
+	// +optional
+	SwapBehavior *string `json:"swapBehavior,omitempty"`
+}
+
+// SwapLocation enumerates where the swap space of nodes can be provisioned.
+// +kubebuilder:validation:Enum={RootVolume,InstanceStore}
+type SwapLocation string
+
+const (
+	SwapLocationRootVolume    SwapLocation = "RootVolume"
+	SwapLocationInstanceStore SwapLocation = "InstanceStore"
+)
+
+// WindowsConfiguration configures the Windows components of nodes
+type WindowsConfiguration struct {
+	// CSIProxy registers and starts the csi-proxy service that ships with the AMI, which the node plugins of CSI
+	// drivers, like the EBS CSI driver, use to manage the disks and volumes of the host.
+	// +optional
+	CSIProxy *bool `json:"csiProxy,omitempty"`
+	// HostProcessContainers starts kubelet with containerd as its container runtime, which is required for
+	// HostProcess pods that run directly on the host with privileged access to it.
+	// +optional
+	HostProcessContainers *bool `json:"hostProcessContainers,omitempty"`
+	// GMSA registers the AWS plugin of Container Credential Guard, which lets containers run as group Managed Service
+	// Accounts without joining the node to an Active Directory domain.
+	// +optional
+	GMSA *bool `json:"gmsa,omitempty"`
+}
+
+// PrivateDNSNameOptions configures the private DNS hostnames of instances and their DNS records
+type PrivateDNSNameOptions struct {
+	// HostnameType is the type of the hostnames. IPName hostnames are derived from the private IPv4 address of the
+	// instance, like ip-10-0-0-1.us-west-2.compute.internal, and ResourceName hostnames from the instance ID, like
+	// i-0123456789abcdef0.us-west-2.compute.internal. Instances in IPv6-only subnets require ResourceName hostnames.
+	// +optional
+	HostnameType *HostnameType `json:"hostnameType,omitempty"`
+	// EnableResourceNameDNSARecord resolves the ResourceName hostnames to the private IPv4 address of the instances.
+	// Defaults to true for ResourceName hostnames.
+	// +optional
+	EnableResourceNameDNSARecord *bool `json:"enableResourceNameDnsARecord,omitempty"`
+	// EnableResourceNameDNSAAAARecord resolves the ResourceName hostnames to the IPv6 address of the instances.
+	// +optional
+	EnableResourceNameDNSAAAARecord *bool `json:"enableResourceNameDnsAAAARecord,omitempty"`
+}
+
+// HostnameType enumerates the private DNS hostname types of instances.
+// +kubebuilder:validation:Enum={IPName,ResourceName}
+type HostnameType string
+
+const (
+	// HostnameTypeIPName derives the hostname from the private IPv4 address of the instance
+	HostnameTypeIPName HostnameType = "IPName"
+	// HostnameTypeResourceName derives the hostname from the ID of the instance
+	HostnameTypeResourceName HostnameType = "ResourceName"
+)
+
+// BottlerocketConfiguration configures the nodes of the Bottlerocket AMIFamily
+type BottlerocketConfiguration struct {
+	// UpdateStrategy is how nodes are updated to AMIs of a newer Bottlerocket patch version. Replace drifts and
+	// replaces the nodes. InPlace labels the nodes for the Bottlerocket update operator, which updates the OS of the
+	// nodes in place, and doesn't drift nodes whose Bottlerocket major and minor version match the AMIs. Nodes are
+	// replaced for AMIs of a newer major or minor version with either strategy. Defaults to Replace.
+	// +optional
+	UpdateStrategy *BottlerocketUpdateStrategy `json:"updateStrategy,omitempty"`
+}
+
+// BottlerocketUpdateStrategy enumerates the ways nodes of the Bottlerocket AMIFamily are updated to newer AMIs.
+// +kubebuilder:validation:Enum={Replace,InPlace}
+type BottlerocketUpdateStrategy string
+
+const (
+	// BottlerocketUpdateStrategyReplace replaces the nodes when the AMIs change
+	BottlerocketUpdateStrategyReplace BottlerocketUpdateStrategy = "Replace"
+	// BottlerocketUpdateStrategyInPlace leaves patch-level AMI changes to the Bottlerocket update operator
+	BottlerocketUpdateStrategyInPlace BottlerocketUpdateStrategy = "InPlace"
+)
+
+// Hugepages is the number of hugepages of a size that are allocated on the nodes
+type Hugepages struct {
+	// Size of the hugepages.
+	// +kubebuilder:validation:Enum:={2Mi,1Gi}
+	// +required
+	Size string `json:"size"`
+	// Count of the hugepages of the size to allocate.
+	// +kubebuilder:validation:Minimum:=1
+	// +required
+	Count int32 `json:"count"`
+}
+
+// KernelParameters configures the kernel of nodes for latency sensitive workloads
+type KernelParameters struct {
+	// NUMABalancing sets kernel.numa_balancing, which moves the memory of processes to the NUMA node they run on.
+	// Turning it off avoids the page faults of the migrations for workloads that pin their CPUs and memory.
+	// +optional
+	NUMABalancing *bool `json:"numaBalancing,omitempty"`
+	// IsolatedCPUs is the list of CPUs, like 2-7 or 2,4,6, that are isolated from the scheduler, timer ticks and RCU
+	// callbacks of the kernel through the isolcpus, nohz_full and rcu_nocbs kernel arguments. It changes the kernel
+	// command line, so it's only supported by the Bottlerocket AMIFamily, which reboots the node to apply it.
+	// +kubebuilder:validation:Pattern:="^[0-9]+(-[0-9]+)?(,[0-9]+(-[0-9]+)?)*$"
+	// +optional
+	IsolatedCPUs *string `json:"isolatedCPUs,omitempty"`
+}
+
+// KernelModule is the name of a kernel module, like nf_conntrack or br_netfilter
+// +kubebuilder:validation:Pattern:="^[a-zA-Z0-9_-]+$"
+// +kubebuilder:validation:MaxLength:=64
+type KernelModule string
+
+// CloudWatchAgent configures the Amazon CloudWatch agent of nodes
+type CloudWatchAgent struct {
+	// Enabled installs and starts the CloudWatch agent on the nodes. The role of the nodes needs the permissions of
+	// the CloudWatchAgentServerPolicy managed policy.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+	// LogGroupName is the log group that the system logs of the nodes are shipped to, with a log stream per instance
+	// and log file. Defaults to /aws/karpenter/<cluster name>/nodes.
+	// +kubebuilder:validation:Pattern:="^[-._/#A-Za-z0-9]+$"
+	// +kubebuilder:validation:MaxLength:=512
+	// +optional
+	LogGroupName *string `json:"logGroupName,omitempty"`
+	// Metrics publishes the CPU, memory and disk usage of the nodes to the CWAgent namespace. Defaults to true.
+	// +optional
+	Metrics *bool `json:"metrics,omitempty"`
+}
+
+// SSMAgent configures the AWS Systems Manager agent of nodes
+// +kubebuilder:validation:XValidation:message="hybridActivation can't be set when the SSM agent is disabled",rule="!(has(self.enabled) && !self.enabled && has(self.hybridActivation))"
+// +kubebuilder:validation:XValidation:message="sessionManager can't be enabled when the SSM agent is disabled",rule="!(has(self.enabled) && !self.enabled && has(self.sessionManager) && self.sessionManager)"
+type SSMAgent struct {
+	// Enabled runs the SSM agent on the nodes. The agent of the AMI is stopped and disabled when it's false.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+	// HybridActivation registers the SSM agent with a hybrid activation, so that the nodes are managed with the IAM
+	// role and tags of the activation instead of the role of their instance profile.
+	// +optional
+	HybridActivation *SSMHybridActivation `json:"hybridActivation,omitempty"`
+	// SessionManager prepares the nodes for shell access with Session Manager. Their instances are tagged with
+	// karpenter.k8s.aws/session-manager, which the IAM policies of operators and SSM associations can target, and the
+	// admin container of Bottlerocket nodes is enabled.
+	// +optional
+	SessionManager *bool `json:"sessionManager,omitempty"`
+}
+
+// SSMHybridActivation is an SSM hybrid activation that the SSM agent of nodes registers with
+type SSMHybridActivation struct {
+	// ID of the activation.
+	// +kubebuilder:validation:Pattern:="^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$"
+	// +required
+	ID string `json:"id"`
+	// Code of the activation, as a reference to a SecureString SSM parameter or a Secrets Manager secret, e.g.
+	// {{resolve:ssm-secure:/karpenter/activation-code}}. The reference is resolved when launch templates are created.
+	// The resolved code is part of the user data of the launch templates of the nodes, so the activation should be
+	// limited to the number of nodes that are expected to register with it.
+	// +kubebuilder:validation:Pattern:="^\\{\\{resolve:(ssm-secure|secretsmanager):[^}]+\\}\\}$"
+	// +required
+	Code string `json:"code"`
+	// Region that the activation was created in.
+	// +kubebuilder:validation:Pattern:="^[a-z]{2}(-[a-z]+)+-[0-9]+$"
+	// +required
+	Region string `json:"region"`
+}
+
+// NeuronConfiguration configures the Neuron runtime of nodes with AWS Inferentia or Trainium devices
+type NeuronConfiguration struct {
+	// LogicalNeuronCoreConfig is the number of physical NeuronCores that the Neuron runtime groups into each logical
+	// NeuronCore. The aws.amazon.com/neuroncore capacity of the instance types is the number of logical NeuronCores.
+	// Defaults to 1.
+	// +kubebuilder:validation:Enum:={1,2}
+	// +optional
+	LogicalNeuronCoreConfig *int32 `json:"logicalNeuronCoreConfig,omitempty"`
+}
+
+// PrimaryNetworkInterface configures the network interface at device index 0 of the instances
+type PrimaryNetworkInterface struct {
+	// InterfaceType is the type of the primary network interface. ena is a standard network interface and efa is an
+	// Elastic Fabric Adapter, which only instance types that support EFA can be launched with. The primary network
+	// interface of instances that are launched for pods that request EFA devices is always an EFA. Defaults to ena.
+	// +optional
+	InterfaceType *NetworkInterfaceType `json:"interfaceType,omitempty"`
+	// SourceDestCheck is whether the instance drops the traffic that it isn't the source or destination of. Disabling
+	// it lets nodes route traffic for other hosts, e.g. for NAT or VPN gateways. It's disabled after the instance is
+	// launched, and can't be disabled for instances with a public IP address. Defaults to true.
+	// +optional
+	SourceDestCheck *bool `json:"sourceDestCheck,omitempty"`
+	// Description of the primary network interface
+	// +kubebuilder:validation:MaxLength:=255
+	// +optional
+	Description *string `json:"description,omitempty"`
+	// Tags to be applied on the network interfaces that instances are launched with, in addition to the tags of the
+	// EC2NodeClass. Tags of the EC2NodeClass take precedence.
+	// +kubebuilder:validation:XValidation:message="empty tag keys aren't supported",rule="self.all(k, k != '')"
+	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching kubernetes.io/cluster/",rule="self.all(k, !k.startsWith('kubernetes.io/cluster') )"
+	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.sh/nodepool",rule="self.all(k, k != 'karpenter.sh/nodepool')"
+	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.sh/managed-by",rule="self.all(k, k !='karpenter.sh/managed-by')"
+	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.sh/nodeclaim",rule="self.all(k, k !='karpenter.sh/nodeclaim')"
+	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.k8s.aws/ec2nodeclass",rule="self.all(k, k !='karpenter.k8s.aws/ec2nodeclass')"
+	// +optional
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// MaintenanceWindow is a recurring window that nodes can be voluntarily disrupted in
+type MaintenanceWindow struct {
+	// Schedule is when the windows start, following the upstream cronjob syntax, like "0 2 * * 6".
+	// +kubebuilder:validation:Pattern:=`^(@(annually|yearly|monthly|weekly|daily|midnight|hourly))|((.+)\s(.+)\s(.+)\s(.+)\s(.+))$`
+	// +required
+	Schedule string `json:"schedule"`
+	// TimeZone is the IANA time zone of the schedule, like "America/New_York". The schedule is in UTC if it's omitted.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+	// Duration is how long each window lasts. Only minutes and hours are accepted.
+	// +kubebuilder:validation:Pattern=`^((([0-9]+(h|m))|([0-9]+h[0-9]+m))(0s)?)$`
+	// +kubebuilder:validation:Type="string"
+	// +required
+	Duration metav1.Duration `json:"duration"`
+}
+
+// NetworkInterfaceType enumerates the types of the primary network interface
+// +kubebuilder:validation:Enum:={ena,efa}
+type NetworkInterfaceType string
+
+const (
+	NetworkInterfaceTypeENA NetworkInterfaceType = "ena"
+	NetworkInterfaceTypeEFA NetworkInterfaceType = "efa"
+)
+
+// VPCCNIConfiguration describes the configuration of the VPC CNI that the pod density of the instance types depends on
+type VPCCNIConfiguration struct {
+	// ReservedENIs is the number of network interfaces of the instances that the VPC CNI doesn't assign pod IP
+	// addresses from. Defaults to the reserved-enis setting of the controller.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	ReservedENIs *int32 `json:"reservedENIs,omitempty"`
+	// ReservedIPsPerENI is the number of secondary IP addresses of each network interface that the VPC CNI doesn't
+	// assign to pods, e.g. because they're assigned to other interfaces of the node. Defaults to 0.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	ReservedIPsPerENI *int32 `json:"reservedIPsPerENI,omitempty"`
+	// CustomNetworking is whether custom networking is enabled in the VPC CNI, which assigns pod IP addresses from the
+	// subnets of ENIConfigs, so that the primary network interface isn't used for pods. Defaults to false.
+	// +optional
+	CustomNetworking *bool `json:"customNetworking,omitempty"`
+	// PrefixDelegation is whether prefix delegation is enabled in the VPC CNI, which assigns a /28 prefix rather than a
+	// single IP address to each secondary address slot of the network interfaces. Defaults to false.
+	// +optional
+	PrefixDelegation *bool `json:"prefixDelegation,omitempty"`
+}
+
+// DCGMExporter configures the NVIDIA DCGM exporter of nodes with NVIDIA GPUs
+type DCGMExporter struct {
+	// Enabled runs the DCGM exporter on the nodes, which serves the metrics of the GPUs on port 9400.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+	// Image is the container image of the DCGM exporter, which has to be pullable without credentials. Defaults to
+	// the dcgm-exporter image of the NVIDIA NGC catalog.
+	// +kubebuilder:validation:Pattern:="^[a-zA-Z0-9./_:@-]+$"
+	// +kubebuilder:validation:MaxLength:=255
+	// +optional
+	Image *string `json:"image,omitempty"`
+}
+
+// ContainerSnapshotter enumerates the snapshotters that containerd can be configured with.
+// +kubebuilder:validation:Enum={OverlayFS,SOCI}
+type ContainerSnapshotter string
+
+const (
+	// ContainerSnapshotterOverlayFS unpacks the full image before a container starts
+	ContainerSnapshotterOverlayFS ContainerSnapshotter = "OverlayFS"
+	// ContainerSnapshotterSOCI lazily loads the layers of images that have a SOCI index with the SOCI snapshotter,
+	// and falls back to unpacking the full image for the ones that don't
+	ContainerSnapshotterSOCI ContainerSnapshotter = "SOCI"
+)
+
 // InstanceStorePolicy enumerates options for configuring instance store disks.
 // +kubebuilder:validation:Enum={RAID0}
 type InstanceStorePolicy string
@@ -327,6 +885,8 @@ type EC2NodeClass struct {
 	// +kubebuilder:validation:XValidation:message="amiSelectorTerms is required when amiFamily == 'Custom'",rule="self.amiFamily == 'Custom' ? self.amiSelectorTerms.size() != 0 : true"
 	// +kubebuilder:validation:XValidation:message="must specify exactly one of ['role', 'instanceProfile']",rule="(has(self.role) && !has(self.instanceProfile)) || (!has(self.role) && has(self.instanceProfile))"
 	// +kubebuilder:validation:XValidation:message="changing from 'instanceProfile' to 'role' is not supported. You must delete and recreate this node class if you want to change this.",rule="(has(oldSelf.role) && has(self.role)) || (has(oldSelf.instanceProfile) && has(self.instanceProfile))"
+	// +kubebuilder:validation:XValidation:message="swap can't use the instance store when instanceStorePolicy is set",rule="!(has(self.swap) && has(self.swap.location) && self.swap.location == 'InstanceStore' && has(self.instanceStorePolicy))"
+	// +kubebuilder:validation:XValidation:message="sourceDestCheck can't be disabled for instances with a public IP address",rule="!(has(self.primaryNetworkInterface) && has(self.primaryNetworkInterface.sourceDestCheck) && !self.primaryNetworkInterface.sourceDestCheck && has(self.associatePublicIPAddress) && self.associatePublicIPAddress)"
 	Spec   EC2NodeClassSpec   `json:"spec,omitempty"`
 	Status EC2NodeClassStatus `json:"status,omitempty"`
 }
@@ -37,6 +37,12 @@ type Subnet struct {
 	// The associated availability zone ID
 	// +optional
 	ZoneID string `json:"zoneID,omitempty"`
+	// The IPv4 CIDR block of the subnet
+	// +optional
+	CIDR string `json:"cidr,omitempty"`
+	// The priority of the subnet from its karpenter.k8s.aws/subnet-priority tag
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
 }
 
 // SecurityGroup contains resolved SecurityGroup selector values utilized for node launch
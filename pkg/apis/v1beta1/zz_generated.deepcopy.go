@@ -68,6 +68,31 @@ func (in *AMISelectorTerm) DeepCopy() *AMISelectorTerm {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AMIVerification) DeepCopyInto(out *AMIVerification) {
+	*out = *in
+	if in.KMSKeyID != nil {
+		in, out := &in.KMSKeyID, &out.KMSKeyID
+		*out = new(string)
+		**out = **in
+	}
+	if in.PublicKey != nil {
+		in, out := &in.PublicKey, &out.PublicKey
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AMIVerification.
+func (in *AMIVerification) DeepCopy() *AMIVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(AMIVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BlockDevice) DeepCopyInto(out *BlockDevice) {
 	*out = *in
@@ -96,6 +121,13 @@ func (in *BlockDevice) DeepCopyInto(out *BlockDevice) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.SnapshotSelectorTerms != nil {
+		in, out := &in.SnapshotSelectorTerms, &out.SnapshotSelectorTerms
+		*out = make([]SnapshotSelectorTerm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Throughput != nil {
 		in, out := &in.Throughput, &out.Throughput
 		*out = new(int64)
@@ -148,6 +180,121 @@ func (in *BlockDeviceMapping) DeepCopy() *BlockDeviceMapping {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BottlerocketConfiguration) DeepCopyInto(out *BottlerocketConfiguration) {
+	*out = *in
+	if in.UpdateStrategy != nil {
+		in, out := &in.UpdateStrategy, &out.UpdateStrategy
+		*out = new(BottlerocketUpdateStrategy)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BottlerocketConfiguration.
+func (in *BottlerocketConfiguration) DeepCopy() *BottlerocketConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(BottlerocketConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudWatchAgent) DeepCopyInto(out *CloudWatchAgent) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.LogGroupName != nil {
+		in, out := &in.LogGroupName, &out.LogGroupName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudWatchAgent.
+func (in *CloudWatchAgent) DeepCopy() *CloudWatchAgent {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudWatchAgent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerRegistry) DeepCopyInto(out *ContainerRegistry) {
+	*out = *in
+	if in.Mirrors != nil {
+		in, out := &in.Mirrors, &out.Mirrors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(ContainerRegistryCredentials)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerRegistry.
+func (in *ContainerRegistry) DeepCopy() *ContainerRegistry {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerRegistry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerRegistryCredentials) DeepCopyInto(out *ContainerRegistryCredentials) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerRegistryCredentials.
+func (in *ContainerRegistryCredentials) DeepCopy() *ContainerRegistryCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerRegistryCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DCGMExporter) DeepCopyInto(out *DCGMExporter) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Image != nil {
+		in, out := &in.Image, &out.Image
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DCGMExporter.
+func (in *DCGMExporter) DeepCopy() *DCGMExporter {
+	if in == nil {
+		return nil
+	}
+	out := new(DCGMExporter)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EC2NodeClass) DeepCopyInto(out *EC2NodeClass) {
 	*out = *in
@@ -229,6 +376,16 @@ func (in *EC2NodeClassSpec) DeepCopyInto(out *EC2NodeClassSpec) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.PrivateDNSNameOptions != nil {
+		in, out := &in.PrivateDNSNameOptions, &out.PrivateDNSNameOptions
+		*out = new(PrivateDNSNameOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NameTag != nil {
+		in, out := &in.NameTag, &out.NameTag
+		*out = new(string)
+		**out = **in
+	}
 	if in.AMISelectorTerms != nil {
 		in, out := &in.AMISelectorTerms, &out.AMISelectorTerms
 		*out = make([]AMISelectorTerm, len(*in))
@@ -236,6 +393,11 @@ func (in *EC2NodeClassSpec) DeepCopyInto(out *EC2NodeClassSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.AMIVerification != nil {
+		in, out := &in.AMIVerification, &out.AMIVerification
+		*out = new(AMIVerification)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.AMIFamily != nil {
 		in, out := &in.AMIFamily, &out.AMIFamily
 		*out = new(string)
@@ -246,6 +408,55 @@ func (in *EC2NodeClassSpec) DeepCopyInto(out *EC2NodeClassSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.ContainerRegistries != nil {
+		in, out := &in.ContainerRegistries, &out.ContainerRegistries
+		*out = make([]ContainerRegistry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ContainerSnapshotter != nil {
+		in, out := &in.ContainerSnapshotter, &out.ContainerSnapshotter
+		*out = new(ContainerSnapshotter)
+		**out = **in
+	}
+	if in.Swap != nil {
+		in, out := &in.Swap, &out.Swap
+		*out = new(Swap)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Windows != nil {
+		in, out := &in.Windows, &out.Windows
+		*out = new(WindowsConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Bottlerocket != nil {
+		in, out := &in.Bottlerocket, &out.Bottlerocket
+		*out = new(BottlerocketConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Hugepages != nil {
+		in, out := &in.Hugepages, &out.Hugepages
+		*out = make([]Hugepages, len(*in))
+		copy(*out, *in)
+	}
+	if in.KernelParameters != nil {
+		in, out := &in.KernelParameters, &out.KernelParameters
+		*out = new(KernelParameters)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Sysctls != nil {
+		in, out := &in.Sysctls, &out.Sysctls
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.KernelModules != nil {
+		in, out := &in.KernelModules, &out.KernelModules
+		*out = make([]KernelModule, len(*in))
+		copy(*out, *in)
+	}
 	if in.InstanceProfile != nil {
 		in, out := &in.InstanceProfile, &out.InstanceProfile
 		*out = new(string)
@@ -274,21 +485,91 @@ func (in *EC2NodeClassSpec) DeepCopyInto(out *EC2NodeClassSpec) {
 		*out = new(InstanceStorePolicy)
 		**out = **in
 	}
+	if in.SubnetSelectionPolicy != nil {
+		in, out := &in.SubnetSelectionPolicy, &out.SubnetSelectionPolicy
+		*out = new(SubnetSelectionPolicy)
+		**out = **in
+	}
 	if in.DetailedMonitoring != nil {
 		in, out := &in.DetailedMonitoring, &out.DetailedMonitoring
 		*out = new(bool)
 		**out = **in
 	}
+	if in.EBSOptimized != nil {
+		in, out := &in.EBSOptimized, &out.EBSOptimized
+		*out = new(bool)
+		**out = **in
+	}
+	if in.KeyName != nil {
+		in, out := &in.KeyName, &out.KeyName
+		*out = new(string)
+		**out = **in
+	}
+	if in.CreditSpecification != nil {
+		in, out := &in.CreditSpecification, &out.CreditSpecification
+		*out = new(CreditSpecification)
+		**out = **in
+	}
+	if in.PTPHardwareClock != nil {
+		in, out := &in.PTPHardwareClock, &out.PTPHardwareClock
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DCGMExporter != nil {
+		in, out := &in.DCGMExporter, &out.DCGMExporter
+		*out = new(DCGMExporter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Neuron != nil {
+		in, out := &in.Neuron, &out.Neuron
+		*out = new(NeuronConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VPCCNI != nil {
+		in, out := &in.VPCCNI, &out.VPCCNI
+		*out = new(VPCCNIConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PrimaryNetworkInterface != nil {
+		in, out := &in.PrimaryNetworkInterface, &out.PrimaryNetworkInterface
+		*out = new(PrimaryNetworkInterface)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaintenanceWindow != nil {
+		in, out := &in.MaintenanceWindow, &out.MaintenanceWindow
+		*out = new(MaintenanceWindow)
+		**out = **in
+	}
+	if in.SSMAgent != nil {
+		in, out := &in.SSMAgent, &out.SSMAgent
+		*out = new(SSMAgent)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CloudWatchAgent != nil {
+		in, out := &in.CloudWatchAgent, &out.CloudWatchAgent
+		*out = new(CloudWatchAgent)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.MetadataOptions != nil {
 		in, out := &in.MetadataOptions, &out.MetadataOptions
 		*out = new(MetadataOptions)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.PodMetadataAccess != nil {
+		in, out := &in.PodMetadataAccess, &out.PodMetadataAccess
+		*out = new(PodMetadataAccess)
+		**out = **in
+	}
 	if in.Context != nil {
 		in, out := &in.Context, &out.Context
 		*out = new(string)
 		**out = **in
 	}
+	if in.AssumeRoleARN != nil {
+		in, out := &in.AssumeRoleARN, &out.AssumeRoleARN
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EC2NodeClassSpec.
@@ -340,6 +621,62 @@ func (in *EC2NodeClassStatus) DeepCopy() *EC2NodeClassStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Hugepages) DeepCopyInto(out *Hugepages) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Hugepages.
+func (in *Hugepages) DeepCopy() *Hugepages {
+	if in == nil {
+		return nil
+	}
+	out := new(Hugepages)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KernelParameters) DeepCopyInto(out *KernelParameters) {
+	*out = *in
+	if in.NUMABalancing != nil {
+		in, out := &in.NUMABalancing, &out.NUMABalancing
+		*out = new(bool)
+		**out = **in
+	}
+	if in.IsolatedCPUs != nil {
+		in, out := &in.IsolatedCPUs, &out.IsolatedCPUs
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KernelParameters.
+func (in *KernelParameters) DeepCopy() *KernelParameters {
+	if in == nil {
+		return nil
+	}
+	out := new(KernelParameters)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MetadataOptions) DeepCopyInto(out *MetadataOptions) {
 	*out = *in
@@ -375,6 +712,138 @@ func (in *MetadataOptions) DeepCopy() *MetadataOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NeuronConfiguration) DeepCopyInto(out *NeuronConfiguration) {
+	*out = *in
+	if in.LogicalNeuronCoreConfig != nil {
+		in, out := &in.LogicalNeuronCoreConfig, &out.LogicalNeuronCoreConfig
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NeuronConfiguration.
+func (in *NeuronConfiguration) DeepCopy() *NeuronConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(NeuronConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrimaryNetworkInterface) DeepCopyInto(out *PrimaryNetworkInterface) {
+	*out = *in
+	if in.InterfaceType != nil {
+		in, out := &in.InterfaceType, &out.InterfaceType
+		*out = new(NetworkInterfaceType)
+		**out = **in
+	}
+	if in.SourceDestCheck != nil {
+		in, out := &in.SourceDestCheck, &out.SourceDestCheck
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Description != nil {
+		in, out := &in.Description, &out.Description
+		*out = new(string)
+		**out = **in
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrimaryNetworkInterface.
+func (in *PrimaryNetworkInterface) DeepCopy() *PrimaryNetworkInterface {
+	if in == nil {
+		return nil
+	}
+	out := new(PrimaryNetworkInterface)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrivateDNSNameOptions) DeepCopyInto(out *PrivateDNSNameOptions) {
+	*out = *in
+	if in.HostnameType != nil {
+		in, out := &in.HostnameType, &out.HostnameType
+		*out = new(HostnameType)
+		**out = **in
+	}
+	if in.EnableResourceNameDNSARecord != nil {
+		in, out := &in.EnableResourceNameDNSARecord, &out.EnableResourceNameDNSARecord
+		*out = new(bool)
+		**out = **in
+	}
+	if in.EnableResourceNameDNSAAAARecord != nil {
+		in, out := &in.EnableResourceNameDNSAAAARecord, &out.EnableResourceNameDNSAAAARecord
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrivateDNSNameOptions.
+func (in *PrivateDNSNameOptions) DeepCopy() *PrivateDNSNameOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(PrivateDNSNameOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SSMAgent) DeepCopyInto(out *SSMAgent) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.HybridActivation != nil {
+		in, out := &in.HybridActivation, &out.HybridActivation
+		*out = new(SSMHybridActivation)
+		**out = **in
+	}
+	if in.SessionManager != nil {
+		in, out := &in.SessionManager, &out.SessionManager
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSMAgent.
+func (in *SSMAgent) DeepCopy() *SSMAgent {
+	if in == nil {
+		return nil
+	}
+	out := new(SSMAgent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SSMHybridActivation) DeepCopyInto(out *SSMHybridActivation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSMHybridActivation.
+func (in *SSMHybridActivation) DeepCopy() *SSMHybridActivation {
+	if in == nil {
+		return nil
+	}
+	out := new(SSMHybridActivation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecurityGroup) DeepCopyInto(out *SecurityGroup) {
 	*out = *in
@@ -412,6 +881,28 @@ func (in *SecurityGroupSelectorTerm) DeepCopy() *SecurityGroupSelectorTerm {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotSelectorTerm) DeepCopyInto(out *SnapshotSelectorTerm) {
+	*out = *in
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotSelectorTerm.
+func (in *SnapshotSelectorTerm) DeepCopy() *SnapshotSelectorTerm {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotSelectorTerm)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Subnet) DeepCopyInto(out *Subnet) {
 	*out = *in
@@ -448,3 +939,103 @@ func (in *SubnetSelectorTerm) DeepCopy() *SubnetSelectorTerm {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Swap) DeepCopyInto(out *Swap) {
+	*out = *in
+	if in.Location != nil {
+		in, out := &in.Location, &out.Location
+		*out = new(SwapLocation)
+		**out = **in
+	}
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Swappiness != nil {
+		in, out := &in.Swappiness, &out.Swappiness
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SwapBehavior != nil {
+		in, out := &in.SwapBehavior, &out.SwapBehavior
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Swap.
+func (in *Swap) DeepCopy() *Swap {
+	if in == nil {
+		return nil
+	}
+	out := new(Swap)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPCCNIConfiguration) DeepCopyInto(out *VPCCNIConfiguration) {
+	*out = *in
+	if in.ReservedENIs != nil {
+		in, out := &in.ReservedENIs, &out.ReservedENIs
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ReservedIPsPerENI != nil {
+		in, out := &in.ReservedIPsPerENI, &out.ReservedIPsPerENI
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CustomNetworking != nil {
+		in, out := &in.CustomNetworking, &out.CustomNetworking
+		*out = new(bool)
+		**out = **in
+	}
+	if in.PrefixDelegation != nil {
+		in, out := &in.PrefixDelegation, &out.PrefixDelegation
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPCCNIConfiguration.
+func (in *VPCCNIConfiguration) DeepCopy() *VPCCNIConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(VPCCNIConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WindowsConfiguration) DeepCopyInto(out *WindowsConfiguration) {
+	*out = *in
+	if in.CSIProxy != nil {
+		in, out := &in.CSIProxy, &out.CSIProxy
+		*out = new(bool)
+		**out = **in
+	}
+	if in.HostProcessContainers != nil {
+		in, out := &in.HostProcessContainers, &out.HostProcessContainers
+		*out = new(bool)
+		**out = **in
+	}
+	if in.GMSA != nil {
+		in, out := &in.GMSA, &out.GMSA
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WindowsConfiguration.
+func (in *WindowsConfiguration) DeepCopy() *WindowsConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(WindowsConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
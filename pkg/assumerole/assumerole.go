@@ -0,0 +1,173 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package assumerole
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/samber/lo"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+)
+
+// cacheKeySeparator separates the role from the rest of a cache key. IAM role ARNs can't contain it.
+const cacheKeySeparator = "|"
+
+// ClusterTagKey is the session tag key that the cluster is attached as when sessions are attributed to the cluster
+var ClusterTagKey = apis.Group + "/cluster"
+
+type roleKey struct{}
+
+type nodePoolKey struct{}
+
+// WithRole returns a context that causes AWS calls made with it to be signed with credentials for the passed role.
+// An empty role leaves the calls signed with the credentials of the Karpenter controller.
+func WithRole(ctx context.Context, roleARN string) context.Context {
+	return context.WithValue(ctx, roleKey{}, roleARN)
+}
+
+// WithNodeClass returns a context that causes AWS calls made with it to be signed with credentials for the
+// role of the passed EC2NodeClass, if any.
+func WithNodeClass(ctx context.Context, nodeClass *v1.EC2NodeClass) context.Context {
+	return WithRole(ctx, aws.StringValue(nodeClass.Spec.AssumeRoleARN))
+}
+
+// FromContext returns the role that AWS calls made with the passed context are signed for, if any
+func FromContext(ctx context.Context) string {
+	roleARN, _ := ctx.Value(roleKey{}).(string)
+	return roleARN
+}
+
+// WithNodePool returns a context that attributes the AWS calls made with it to the passed NodePool, if the sessions
+// of the assumed roles are tagged with the NodePool
+func WithNodePool(ctx context.Context, nodePoolName string) context.Context {
+	return context.WithValue(ctx, nodePoolKey{}, nodePoolName)
+}
+
+// NodePoolFromContext returns the NodePool that AWS calls made with the passed context are attributed to, if any
+func NodePoolFromContext(ctx context.Context) string {
+	nodePoolName, _ := ctx.Value(nodePoolKey{}).(string)
+	return nodePoolName
+}
+
+// CacheKey scopes a provider cache key to the role in the context so that the responses of AWS calls made under
+// different roles aren't mixed. Keys are unchanged when there is no role.
+func CacheKey(ctx context.Context, key string) string {
+	if roleARN := FromContext(ctx); roleARN != "" {
+		return key + cacheKeySeparator + roleARN
+	}
+	return key
+}
+
+// FromCacheKey splits a cache key produced by CacheKey into the original key and the role
+func FromCacheKey(cacheKey string) (key string, roleARN string) {
+	key, roleARN, _ = strings.Cut(cacheKey, cacheKeySeparator)
+	return key, roleARN
+}
+
+// Session configures the sessions of the assumed roles
+type Session struct {
+	Duration time.Duration
+	// Tags are attached to every session as session tags
+	Tags map[string]string
+	// SourceIdentity is set on every session if it isn't empty
+	SourceIdentity string
+	// NodePoolTagKey is the session tag key that the NodePool of an AWS call is attached as. Sessions aren't tagged
+	// with the NodePool if it's empty.
+	NodePoolTagKey string
+}
+
+// Configure applies the session to the passed provider. The tag for the NodePool is only attached if both the
+// NodePoolTagKey and the NodePool are set.
+func (s Session) Configure(provider *stscreds.AssumeRoleProvider, nodePoolName string) {
+	provider.Duration = s.Duration
+	provider.ExpiryWindow = time.Duration(10) * time.Second
+	tags := lo.Assign(s.Tags)
+	if s.NodePoolTagKey != "" && nodePoolName != "" {
+		tags[s.NodePoolTagKey] = nodePoolName
+	}
+	keys := lo.Keys(tags)
+	sort.Strings(keys)
+	provider.Tags = lo.Map(keys, func(key string, _ int) *sts.Tag {
+		return &sts.Tag{Key: aws.String(key), Value: aws.String(tags[key])}
+	})
+	if s.SourceIdentity != "" {
+		provider.SourceIdentity = aws.String(s.SourceIdentity)
+	}
+}
+
+// Provider caches credentials for each assumed role, so that each role is assumed once and then only refreshed
+// by STS shortly before the credentials expire. When sessions are tagged with the NodePool, credentials are cached
+// for each role and NodePool.
+type Provider struct {
+	sync.Mutex
+	stsapi      stsiface.STSAPI
+	session     Session
+	credentials map[string]*credentials.Credentials
+}
+
+// NewProvider creates a Provider that assumes roles with the passed STS client. The client must be signed with the
+// credentials of the Karpenter controller.
+func NewProvider(stsapi stsiface.STSAPI, session Session) *Provider {
+	return &Provider{
+		stsapi:      stsapi,
+		session:     session,
+		credentials: map[string]*credentials.Credentials{},
+	}
+}
+
+// Credentials returns the cached credentials for the passed role and NodePool
+func (p *Provider) Credentials(roleARN string, nodePoolName string) *credentials.Credentials {
+	p.Lock()
+	defer p.Unlock()
+	// Sessions are only distinguished by the NodePool when they are tagged with it
+	if p.session.NodePoolTagKey == "" {
+		nodePoolName = ""
+	}
+	key := roleARN
+	if nodePoolName != "" {
+		key += cacheKeySeparator + nodePoolName
+	}
+	if creds, ok := p.credentials[key]; ok {
+		return creds
+	}
+	creds := stscreds.NewCredentialsWithClient(p.stsapi, roleARN, func(provider *stscreds.AssumeRoleProvider) {
+		p.session.Configure(provider, nodePoolName)
+	})
+	p.credentials[key] = creds
+	return creds
+}
+
+// WithAssumeRole signs the AWS calls made with a context carrying a role with the credentials for that role
+func WithAssumeRole(sess *session.Session, provider *Provider) *session.Session {
+	sess.Handlers.Sign.PushFrontNamed(request.NamedHandler{Name: "karpenter.AssumeRoleHandler", Fn: func(r *request.Request) {
+		if roleARN := FromContext(r.Context()); roleARN != "" {
+			r.Config.Credentials = provider.Credentials(roleARN, NodePoolFromContext(r.Context()))
+		}
+	}})
+	return sess
+}
@@ -0,0 +1,148 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package assumerole_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/sts"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	teamARoleARN = "arn:aws:iam::123456789012:role/team-a"
+	teamBRoleARN = "arn:aws:iam::123456789012:role/team-b"
+)
+
+var ctx context.Context
+var stsapi *fake.STSAPI
+var ec2api *ec2.EC2
+
+func TestAssumeRole(t *testing.T) {
+	ctx = context.Background()
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AssumeRole")
+}
+
+var _ = BeforeEach(func() {
+	stsapi = &fake.STSAPI{}
+	ec2api = newEC2API(assumerole.Session{Duration: 15 * time.Minute})
+})
+
+// newEC2API returns an EC2 client that assumes roles with sessions configured like the passed session
+func newEC2API(s assumerole.Session) *ec2.EC2 {
+	sess := session.Must(session.NewSession(aws.NewConfig().
+		WithRegion("us-west-2").
+		WithCredentials(credentials.NewStaticCredentials("AKIDCONTROLLER", "secret", ""))))
+	return ec2.New(assumerole.WithAssumeRole(sess, assumerole.NewProvider(stsapi, s)))
+}
+
+// signedAccessKey returns the access key that a DescribeSubnets request made with the passed context is signed with
+func signedAccessKey(ctx context.Context) string {
+	req, _ := ec2api.DescribeSubnetsRequest(&ec2.DescribeSubnetsInput{})
+	req.SetContext(ctx)
+	Expect(req.Sign()).To(Succeed())
+	auth := req.HTTPRequest.Header.Get("Authorization")
+	Expect(auth).To(ContainSubstring("Credential="))
+	credential := auth[len("AWS4-HMAC-SHA256 Credential="):]
+	return credential[:strings.Index(credential, "/")]
+}
+
+var _ = Describe("AssumeRole", func() {
+	It("should sign requests with the controller credentials when there is no role in the context", func() {
+		Expect(signedAccessKey(ctx)).To(Equal("AKIDCONTROLLER"))
+		Expect(stsapi.AssumeRoleBehavior.Calls()).To(Equal(0))
+	})
+	It("should sign requests with the credentials of the role in the context", func() {
+		Expect(signedAccessKey(assumerole.WithRole(ctx, teamARoleARN))).To(Equal("ASIATEAM-A"))
+		Expect(signedAccessKey(assumerole.WithRole(ctx, teamBRoleARN))).To(Equal("ASIATEAM-B"))
+		Expect(stsapi.AssumeRoleBehavior.Calls()).To(Equal(2))
+	})
+	It("should cache the credentials for each role", func() {
+		roleCtx := assumerole.WithRole(ctx, teamARoleARN)
+		Expect(signedAccessKey(roleCtx)).To(Equal("ASIATEAM-A"))
+		Expect(signedAccessKey(roleCtx)).To(Equal("ASIATEAM-A"))
+		Expect(stsapi.AssumeRoleBehavior.Calls()).To(Equal(1))
+		input := stsapi.AssumeRoleBehavior.CalledWithInput.Pop()
+		Expect(aws.StringValue(input.RoleArn)).To(Equal(teamARoleARN))
+		Expect(aws.Int64Value(input.DurationSeconds)).To(BeNumerically("==", 900))
+	})
+	It("should not attach session tags or a source identity by default", func() {
+		Expect(signedAccessKey(assumerole.WithNodePool(assumerole.WithRole(ctx, teamARoleARN), "default"))).To(Equal("ASIATEAM-A"))
+		input := stsapi.AssumeRoleBehavior.CalledWithInput.Pop()
+		Expect(input.Tags).To(BeEmpty())
+		Expect(input.SourceIdentity).To(BeNil())
+	})
+	It("should attach the session tags and the source identity", func() {
+		ec2api = newEC2API(assumerole.Session{
+			Duration:       15 * time.Minute,
+			Tags:           map[string]string{"team": "platform", assumerole.ClusterTagKey: "test-cluster"},
+			SourceIdentity: "test-cluster",
+		})
+		Expect(signedAccessKey(assumerole.WithRole(ctx, teamARoleARN))).To(Equal("ASIATEAM-A"))
+		input := stsapi.AssumeRoleBehavior.CalledWithInput.Pop()
+		Expect(input.Tags).To(Equal([]*sts.Tag{
+			{Key: aws.String(assumerole.ClusterTagKey), Value: aws.String("test-cluster")},
+			{Key: aws.String("team"), Value: aws.String("platform")},
+		}))
+		Expect(aws.StringValue(input.SourceIdentity)).To(Equal("test-cluster"))
+	})
+	It("should tag sessions with the NodePool and cache the credentials for each NodePool", func() {
+		ec2api = newEC2API(assumerole.Session{Duration: 15 * time.Minute, NodePoolTagKey: "karpenter.sh/nodepool"})
+		roleCtx := assumerole.WithRole(ctx, teamARoleARN)
+		Expect(signedAccessKey(assumerole.WithNodePool(roleCtx, "default"))).To(Equal("ASIATEAM-A"))
+		Expect(signedAccessKey(assumerole.WithNodePool(roleCtx, "default"))).To(Equal("ASIATEAM-A"))
+		Expect(signedAccessKey(assumerole.WithNodePool(roleCtx, "gpu"))).To(Equal("ASIATEAM-A"))
+		Expect(signedAccessKey(roleCtx)).To(Equal("ASIATEAM-A"))
+		Expect(stsapi.AssumeRoleBehavior.Calls()).To(Equal(3))
+		Expect(stsapi.AssumeRoleBehavior.CalledWithInput.Pop().Tags).To(BeEmpty())
+		Expect(stsapi.AssumeRoleBehavior.CalledWithInput.Pop().Tags).To(Equal([]*sts.Tag{{Key: aws.String("karpenter.sh/nodepool"), Value: aws.String("gpu")}}))
+		Expect(stsapi.AssumeRoleBehavior.CalledWithInput.Pop().Tags).To(Equal([]*sts.Tag{{Key: aws.String("karpenter.sh/nodepool"), Value: aws.String("default")}}))
+	})
+	It("should share the credentials across NodePools when sessions aren't tagged with the NodePool", func() {
+		roleCtx := assumerole.WithRole(ctx, teamARoleARN)
+		Expect(signedAccessKey(assumerole.WithNodePool(roleCtx, "default"))).To(Equal("ASIATEAM-A"))
+		Expect(signedAccessKey(assumerole.WithNodePool(roleCtx, "gpu"))).To(Equal("ASIATEAM-A"))
+		Expect(stsapi.AssumeRoleBehavior.Calls()).To(Equal(1))
+	})
+	It("should use the role of the EC2NodeClass", func() {
+		nodeClass := &v1.EC2NodeClass{ObjectMeta: metav1.ObjectMeta{Name: "default"}, Spec: v1.EC2NodeClassSpec{AssumeRoleARN: aws.String(teamARoleARN)}}
+		Expect(assumerole.FromContext(assumerole.WithNodeClass(ctx, nodeClass))).To(Equal(teamARoleARN))
+		Expect(assumerole.FromContext(assumerole.WithNodeClass(ctx, &v1.EC2NodeClass{}))).To(BeEmpty())
+	})
+	It("should scope cache keys to the role in the context", func() {
+		Expect(assumerole.CacheKey(ctx, "key")).To(Equal("key"))
+		key, roleARN := assumerole.FromCacheKey(assumerole.CacheKey(assumerole.WithRole(ctx, teamARoleARN), "key"))
+		Expect(key).To(Equal("key"))
+		Expect(roleARN).To(Equal(teamARoleARN))
+		key, roleARN = assumerole.FromCacheKey("key")
+		Expect(key).To(Equal("key"))
+		Expect(roleARN).To(BeEmpty())
+	})
+})
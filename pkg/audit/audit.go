@@ -0,0 +1,179 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+const (
+	loggerName = "audit"
+
+	ReasonExoticInstanceType = "exotic instance type (metal or accelerator) while generic instance types are available"
+	ReasonUnwantedSpot       = "spot price is higher than the cheapest on-demand price"
+	ReasonArchitecture       = "architecture is less preferred by the NodePool than an architecture of other instance types"
+	ReasonTruncated          = "truncated from the launch request due to the instance type limit"
+	ReasonPodDensity         = "max pods needs more IP addresses than the subnet with the most free IP addresses has"
+	ReasonLaunchOverride     = "no available offering matches the launch override annotations of the NodeClaim"
+)
+
+// Offering is a single instance type, zone and capacity type combination that was considered for a launch
+type Offering struct {
+	InstanceType string  `json:"instanceType"`
+	Zone         string  `json:"zone"`
+	CapacityType string  `json:"capacityType"`
+	Price        float64 `json:"price"`
+}
+
+// FilteredInstanceType is an instance type that was removed from the launch request before calling CreateFleet
+type FilteredInstanceType struct {
+	InstanceType string `json:"instanceType"`
+	Reason       string `json:"reason"`
+}
+
+// FleetError is an error returned in the CreateFleet response for a specific launch template override
+type FleetError struct {
+	InstanceType string `json:"instanceType,omitempty"`
+	Zone         string `json:"zone,omitempty"`
+	Code         string `json:"code"`
+	Message      string `json:"message"`
+}
+
+// LaunchDecision is the record of a single launch for a NodeClaim
+type LaunchDecision struct {
+	NodeClaim    string                 `json:"nodeClaim"`
+	NodePool     string                 `json:"nodePool"`
+	NodeClass    string                 `json:"nodeClass"`
+	CapacityType string                 `json:"capacityType,omitempty"`
+	Candidates   []Offering             `json:"candidates"`
+	Filtered     []FilteredInstanceType `json:"filtered,omitempty"`
+	FleetErrors  []FleetError           `json:"fleetErrors,omitempty"`
+	Chosen       *Offering              `json:"chosen,omitempty"`
+	InstanceID   string                 `json:"instanceID,omitempty"`
+	Error        string                 `json:"error,omitempty"`
+
+	requirements scheduling.Requirements
+	offerings    map[string]cloudprovider.Offerings
+}
+
+// TerminationDecision is the record of a single instance termination for a NodeClaim. The disruption decision that led
+// to the termination (e.g. consolidation) is made by the core controllers and is identified by its conditions.
+type TerminationDecision struct {
+	NodeClaim    string   `json:"nodeClaim"`
+	NodePool     string   `json:"nodePool"`
+	InstanceID   string   `json:"instanceID"`
+	InstanceType string   `json:"instanceType"`
+	Zone         string   `json:"zone"`
+	CapacityType string   `json:"capacityType"`
+	Conditions   []string `json:"conditions,omitempty"`
+}
+
+func NewLaunchDecision(nodeClaim *karpv1.NodeClaim, nodeClassName string) *LaunchDecision {
+	return &LaunchDecision{
+		NodeClaim:    nodeClaim.Name,
+		NodePool:     nodeClaim.Labels[karpv1.NodePoolLabelKey],
+		NodeClass:    nodeClassName,
+		requirements: scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...),
+		offerings:    map[string]cloudprovider.Offerings{},
+	}
+}
+
+// Consider records the available offerings of the instance types that are compatible with the NodeClaim requirements
+func (d *LaunchDecision) Consider(instanceTypes []*cloudprovider.InstanceType) {
+	d.Candidates = nil
+	for _, it := range instanceTypes {
+		offerings := it.Offerings.Available().Compatible(d.requirements)
+		d.offerings[it.Name] = offerings
+		for _, o := range offerings {
+			d.Candidates = append(d.Candidates, newOffering(it.Name, o))
+		}
+	}
+}
+
+// Filter records the instance types that were present in before but were removed in after
+func (d *LaunchDecision) Filter(before, after []*cloudprovider.InstanceType, reason string) {
+	remaining := sets.New(lo.Map(after, func(it *cloudprovider.InstanceType, _ int) string { return it.Name })...)
+	for _, it := range before {
+		if !remaining.Has(it.Name) {
+			d.Filtered = append(d.Filtered, FilteredInstanceType{InstanceType: it.Name, Reason: reason})
+		}
+	}
+}
+
+// FleetResponse records the errors returned in a CreateFleet response. Errors of an earlier CreateFleet call, e.g. one
+// that is retried after its launch template wasn't found, are replaced so that only the final attempt is recorded.
+func (d *LaunchDecision) FleetResponse(errs []*ec2.CreateFleetError) {
+	d.FleetErrors = nil
+	for _, err := range errs {
+		fleetError := FleetError{Code: aws.StringValue(err.ErrorCode), Message: aws.StringValue(err.ErrorMessage)}
+		if err.LaunchTemplateAndOverrides != nil && err.LaunchTemplateAndOverrides.Overrides != nil {
+			fleetError.InstanceType = aws.StringValue(err.LaunchTemplateAndOverrides.Overrides.InstanceType)
+			fleetError.Zone = aws.StringValue(err.LaunchTemplateAndOverrides.Overrides.AvailabilityZone)
+		}
+		d.FleetErrors = append(d.FleetErrors, fleetError)
+	}
+}
+
+// Choose records the offering that was launched
+func (d *LaunchDecision) Choose(instanceID, instanceType, zone, capacityType string) {
+	d.InstanceID = instanceID
+	d.Chosen = &Offering{InstanceType: instanceType, Zone: zone, CapacityType: capacityType}
+	if o, ok := lo.Find(d.offerings[instanceType], func(o cloudprovider.Offering) bool {
+		return o.Requirements.Get(corev1.LabelTopologyZone).Any() == zone && o.Requirements.Get(karpv1.CapacityTypeLabelKey).Any() == capacityType
+	}); ok {
+		d.Chosen.Price = o.Price
+	}
+}
+
+// RecordLaunch emits the launch decision as a structured log line if decision audit logging is enabled
+func RecordLaunch(ctx context.Context, decision *LaunchDecision, err error) {
+	if !options.FromContext(ctx).DecisionAuditLog {
+		return
+	}
+	if err != nil {
+		decision.Error = err.Error()
+	}
+	log.FromContext(ctx).WithName(loggerName).Info("launch decision", "decision", decision)
+}
+
+// RecordTermination emits the termination decision as a structured log line if decision audit logging is enabled
+func RecordTermination(ctx context.Context, decision *TerminationDecision) {
+	if !options.FromContext(ctx).DecisionAuditLog {
+		return
+	}
+	log.FromContext(ctx).WithName(loggerName).Info("termination decision", "decision", decision)
+}
+
+func newOffering(instanceType string, o cloudprovider.Offering) Offering {
+	return Offering{
+		InstanceType: instanceType,
+		Zone:         o.Requirements.Get(corev1.LabelTopologyZone).Any(),
+		CapacityType: o.Requirements.Get(karpv1.CapacityTypeLabelKey).Any(),
+		Price:        o.Price,
+	}
+}
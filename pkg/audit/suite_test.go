@@ -0,0 +1,158 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/go-logr/logr/funcr"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/audit"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var ctx context.Context
+var records []string
+
+func TestAudit(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Audit")
+}
+
+var _ = BeforeEach(func() {
+	records = nil
+	ctx = log.IntoContext(context.Background(), funcr.NewJSON(func(obj string) { records = append(records, obj) }, funcr.Options{}))
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{DecisionAuditLog: lo.ToPtr(true)}))
+})
+
+func offering(zone, capacityType string, price float64) cloudprovider.Offering {
+	return cloudprovider.Offering{
+		Requirements: scheduling.NewRequirements(
+			scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, zone),
+			scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, capacityType),
+		),
+		Price:     price,
+		Available: true,
+	}
+}
+
+var _ = Describe("Audit", func() {
+	var nodeClaim *karpv1.NodeClaim
+	var instanceTypes []*cloudprovider.InstanceType
+
+	BeforeEach(func() {
+		nodeClaim = coretest.NodeClaim(karpv1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{karpv1.NodePoolLabelKey: "default"}},
+			Spec: karpv1.NodeClaimSpec{
+				Requirements: []karpv1.NodeSelectorRequirementWithMinValues{
+					{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeSpot}}},
+				},
+			},
+		})
+		instanceTypes = []*cloudprovider.InstanceType{
+			fake.NewInstanceType(fake.InstanceTypeOptions{Name: "m5.large", Offerings: cloudprovider.Offerings{
+				offering("test-zone-1a", karpv1.CapacityTypeSpot, 0.05),
+				offering("test-zone-1a", karpv1.CapacityTypeOnDemand, 0.1),
+			}}),
+			fake.NewInstanceType(fake.InstanceTypeOptions{Name: "m5.xlarge", Offerings: cloudprovider.Offerings{
+				offering("test-zone-1b", karpv1.CapacityTypeSpot, 0.1),
+			}}),
+		}
+	})
+	It("should record the compatible offerings of the candidate instance types", func() {
+		decision := audit.NewLaunchDecision(nodeClaim, "default")
+		decision.Consider(instanceTypes)
+		Expect(decision.NodePool).To(Equal("default"))
+		Expect(decision.Candidates).To(ConsistOf(
+			audit.Offering{InstanceType: "m5.large", Zone: "test-zone-1a", CapacityType: karpv1.CapacityTypeSpot, Price: 0.05},
+			audit.Offering{InstanceType: "m5.xlarge", Zone: "test-zone-1b", CapacityType: karpv1.CapacityTypeSpot, Price: 0.1},
+		))
+	})
+	It("should record the instance types that were filtered out", func() {
+		decision := audit.NewLaunchDecision(nodeClaim, "default")
+		decision.Filter(instanceTypes, instanceTypes[:1], audit.ReasonUnwantedSpot)
+		Expect(decision.Filtered).To(ConsistOf(audit.FilteredInstanceType{InstanceType: "m5.xlarge", Reason: audit.ReasonUnwantedSpot}))
+	})
+	It("should record the fleet errors and the price of the chosen offering", func() {
+		decision := audit.NewLaunchDecision(nodeClaim, "default")
+		decision.Consider(instanceTypes)
+		decision.FleetResponse([]*ec2.CreateFleetError{{
+			ErrorCode:    aws.String("InsufficientInstanceCapacity"),
+			ErrorMessage: aws.String("no capacity"),
+			LaunchTemplateAndOverrides: &ec2.LaunchTemplateAndOverridesResponse{
+				Overrides: &ec2.FleetLaunchTemplateOverrides{InstanceType: aws.String("m5.xlarge"), AvailabilityZone: aws.String("test-zone-1b")},
+			},
+		}})
+		decision.Choose("i-0123456789", "m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)
+		Expect(decision.FleetErrors).To(ConsistOf(audit.FleetError{InstanceType: "m5.xlarge", Zone: "test-zone-1b", Code: "InsufficientInstanceCapacity", Message: "no capacity"}))
+		Expect(decision.InstanceID).To(Equal("i-0123456789"))
+		Expect(decision.Chosen).To(Equal(&audit.Offering{InstanceType: "m5.large", Zone: "test-zone-1a", CapacityType: karpv1.CapacityTypeSpot, Price: 0.05}))
+	})
+	It("should only record the fleet errors of the final CreateFleet call", func() {
+		decision := audit.NewLaunchDecision(nodeClaim, "default")
+		decision.FleetResponse([]*ec2.CreateFleetError{{
+			ErrorCode:    aws.String("InvalidLaunchTemplateName.NotFoundException"),
+			ErrorMessage: aws.String("launch template not found"),
+		}})
+		decision.FleetResponse([]*ec2.CreateFleetError{{
+			ErrorCode:    aws.String("InsufficientInstanceCapacity"),
+			ErrorMessage: aws.String("no capacity"),
+		}})
+		Expect(decision.FleetErrors).To(ConsistOf(audit.FleetError{Code: "InsufficientInstanceCapacity", Message: "no capacity"}))
+	})
+	It("should emit the launch decision as json", func() {
+		decision := audit.NewLaunchDecision(nodeClaim, "default")
+		decision.Consider(instanceTypes)
+		audit.RecordLaunch(ctx, decision, nil)
+		Expect(records).To(HaveLen(1))
+		record := map[string]any{}
+		Expect(json.Unmarshal([]byte(records[0]), &record)).To(Succeed())
+		Expect(record["msg"]).To(Equal("launch decision"))
+		Expect(record["decision"]).To(HaveKeyWithValue("nodeClaim", nodeClaim.Name))
+		Expect(record["decision"]).To(HaveKey("candidates"))
+	})
+	It("should emit the termination decision as json", func() {
+		audit.RecordTermination(ctx, &audit.TerminationDecision{NodeClaim: nodeClaim.Name, InstanceID: "i-0123456789"})
+		Expect(records).To(HaveLen(1))
+		record := map[string]any{}
+		Expect(json.Unmarshal([]byte(records[0]), &record)).To(Succeed())
+		Expect(record["msg"]).To(Equal("termination decision"))
+		Expect(record["decision"]).To(HaveKeyWithValue("instanceID", "i-0123456789"))
+	})
+	It("should not emit decisions when the audit log is disabled", func() {
+		ctx = options.ToContext(ctx, test.Options())
+		audit.RecordLaunch(ctx, audit.NewLaunchDecision(nodeClaim, "default"), nil)
+		audit.RecordTermination(ctx, &audit.TerminationDecision{NodeClaim: nodeClaim.Name})
+		Expect(records).To(BeEmpty())
+	})
+})
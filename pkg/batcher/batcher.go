@@ -27,6 +27,8 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"sigs.k8s.io/karpenter/pkg/metrics"
+
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
 )
 
 // Options allows for configuration of the Batcher
@@ -101,7 +103,7 @@ func NewBatcher[T input, U output](ctx context.Context, options Options[T, U]) *
 func (b *Batcher[T, U]) Add(ctx context.Context, input *T) Result[U] {
 	request := &request[T, U]{
 		ctx:   ctx,
-		hash:  b.options.RequestHasher(ctx, input),
+		hash:  hashWithRole(ctx, b.options.RequestHasher(ctx, input)),
 		input: input,
 		// The requestor channel is buffered to ensure that the exec runner can always write the result out preventing
 		// any single caller from blocking the others. Specifically since we register our request and then trigger, the
@@ -115,6 +117,19 @@ func (b *Batcher[T, U]) Add(ctx context.Context, input *T) Result[U] {
 	return <-request.requestor
 }
 
+// hashWithRole mixes the role that the caller's AWS calls are signed for into the request hash. Batches are executed
+// with the context of the first request, so requests for different roles must never share a batch.
+func hashWithRole(ctx context.Context, hash uint64) uint64 {
+	roleARN := assumerole.FromContext(ctx)
+	if roleARN == "" {
+		return hash
+	}
+	return lo.Must(hashstructure.Hash(struct {
+		Hash uint64
+		Role string
+	}{hash, roleARN}, hashstructure.FormatV2, nil))
+}
+
 // DefaultHasher will hash the entire input
 func DefaultHasher[T input](_ context.Context, input *T) uint64 {
 	hash, err := hashstructure.Hash(input, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
@@ -22,6 +22,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"k8s.io/apimachinery/pkg/util/uuid"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -53,7 +54,17 @@ func execCreateFleetBatch(ec2api ec2iface.EC2API) BatchExecutor[ec2.CreateFleetI
 	return func(ctx context.Context, inputs []*ec2.CreateFleetInput) []Result[ec2.CreateFleetOutput] {
 		results := make([]Result[ec2.CreateFleetOutput], 0, len(inputs))
 		firstInput := inputs[0]
+		if firstInput.TargetCapacitySpecification == nil {
+			firstInput.TargetCapacitySpecification = &ec2.TargetCapacitySpecificationRequest{}
+		}
+		// all batched inputs are identical, so we request a single fleet with the total capacity of the batch
 		firstInput.TargetCapacitySpecification.TotalTargetCapacity = aws.Int64(int64(len(inputs)))
+		// the client token makes the retries of the SDK idempotent, so that a request whose response was lost doesn't
+		// launch the batch again. Inputs with a client token are only batched with identical inputs, since the token is
+		// part of their hash.
+		if firstInput.ClientToken == nil {
+			firstInput.ClientToken = aws.String(string(uuid.NewUUID()))
+		}
 		output, err := ec2api.CreateFleetWithContext(ctx, firstInput)
 		if err != nil {
 			for range inputs {
@@ -91,7 +102,7 @@ func execCreateFleetBatch(ec2api ec2iface.EC2API) BatchExecutor[ec2.CreateFleetI
 			}
 		}
 
-		if requestIdx != len(inputs) {
+		if requestIdx+1 < len(inputs) {
 			// we should receive some sort of error, but just in case
 			if len(output.Errors) == 0 {
 				output.Errors = append(output.Errors, &ec2.CreateFleetError{
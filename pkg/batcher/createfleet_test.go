@@ -81,6 +81,104 @@ var _ = Describe("CreateFleet Batching", func() {
 		call := fakeEC2API.CreateFleetBehavior.CalledWithInput.Pop()
 		Expect(*call.TargetCapacitySpecification.TotalTargetCapacity).To(BeNumerically("==", 5))
 	})
+	It("should make each fleet request idempotent with a client token", func() {
+		for _, zone := range []string{"us-east-1", "us-east-2"} {
+			_, err := cfb.CreateFleet(ctx, &ec2.CreateFleetInput{
+				LaunchTemplateConfigs: []*ec2.FleetLaunchTemplateConfigRequest{{
+					LaunchTemplateSpecification: &ec2.FleetLaunchTemplateSpecificationRequest{LaunchTemplateName: aws.String("my-template")},
+					Overrides:                   []*ec2.FleetLaunchTemplateOverridesRequest{{AvailabilityZone: aws.String(zone)}},
+				}},
+				TargetCapacitySpecification: &ec2.TargetCapacitySpecificationRequest{TotalTargetCapacity: aws.Int64(1)},
+			})
+			Expect(err).To(BeNil())
+		}
+		Expect(fakeEC2API.CreateFleetBehavior.CalledWithInput.Len()).To(BeNumerically("==", 2))
+		first, second := fakeEC2API.CreateFleetBehavior.CalledWithInput.Pop(), fakeEC2API.CreateFleetBehavior.CalledWithInput.Pop()
+		Expect(aws.StringValue(first.ClientToken)).ToNot(BeEmpty())
+		Expect(aws.StringValue(second.ClientToken)).ToNot(BeEmpty())
+		Expect(first.ClientToken).ToNot(Equal(second.ClientToken))
+	})
+	It("should keep the client token of the request", func() {
+		_, err := cfb.CreateFleet(ctx, &ec2.CreateFleetInput{
+			ClientToken: aws.String("nodeclaim-uid-1"),
+			LaunchTemplateConfigs: []*ec2.FleetLaunchTemplateConfigRequest{{
+				LaunchTemplateSpecification: &ec2.FleetLaunchTemplateSpecificationRequest{LaunchTemplateName: aws.String("my-template")},
+				Overrides:                   []*ec2.FleetLaunchTemplateOverridesRequest{{AvailabilityZone: aws.String("us-east-1")}},
+			}},
+			TargetCapacitySpecification: &ec2.TargetCapacitySpecificationRequest{TotalTargetCapacity: aws.Int64(1)},
+		})
+		Expect(err).To(BeNil())
+		Expect(aws.StringValue(fakeEC2API.CreateFleetBehavior.CalledWithInput.Pop().ClientToken)).To(Equal("nodeclaim-uid-1"))
+	})
+	It("should not return errors to callers when the batch is fully fulfilled", func() {
+		input := &ec2.CreateFleetInput{
+			LaunchTemplateConfigs: []*ec2.FleetLaunchTemplateConfigRequest{
+				{
+					LaunchTemplateSpecification: &ec2.FleetLaunchTemplateSpecificationRequest{
+						LaunchTemplateName: aws.String("my-template"),
+					},
+					Overrides: []*ec2.FleetLaunchTemplateOverridesRequest{
+						{
+							AvailabilityZone: aws.String("us-east-1"),
+						},
+					},
+				},
+			},
+			TargetCapacitySpecification: &ec2.TargetCapacitySpecificationRequest{
+				TotalTargetCapacity: aws.Int64(1),
+			},
+		}
+		var wg sync.WaitGroup
+		var numErrors int64
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				rsp, err := cfb.CreateFleet(ctx, input)
+				Expect(err).To(BeNil())
+				if len(rsp.Errors) != 0 {
+					atomic.AddInt64(&numErrors, 1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		Expect(numErrors).To(BeNumerically("==", 0))
+		Expect(fakeEC2API.CreateFleetBehavior.CalledWithInput.Len()).To(BeNumerically("==", 1))
+	})
+	It("should batch inputs that don't specify a target capacity", func() {
+		input := &ec2.CreateFleetInput{
+			LaunchTemplateConfigs: []*ec2.FleetLaunchTemplateConfigRequest{
+				{
+					LaunchTemplateSpecification: &ec2.FleetLaunchTemplateSpecificationRequest{
+						LaunchTemplateName: aws.String("my-template"),
+					},
+					Overrides: []*ec2.FleetLaunchTemplateOverridesRequest{
+						{
+							AvailabilityZone: aws.String("us-east-1"),
+						},
+					},
+				},
+			},
+		}
+		var wg sync.WaitGroup
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				rsp, err := cfb.CreateFleet(ctx, input)
+				Expect(err).To(BeNil())
+				Expect(rsp.Instances).To(HaveLen(1))
+			}()
+		}
+		wg.Wait()
+
+		Expect(fakeEC2API.CreateFleetBehavior.CalledWithInput.Len()).To(BeNumerically("==", 1))
+		call := fakeEC2API.CreateFleetBehavior.CalledWithInput.Pop()
+		Expect(*call.TargetCapacitySpecification.TotalTargetCapacity).To(BeNumerically("==", 2))
+	})
 	It("should batch different inputs into multiple calls", func() {
 		east1input := &ec2.CreateFleetInput{
 			LaunchTemplateConfigs: []*ec2.FleetLaunchTemplateConfigRequest{
@@ -16,6 +16,7 @@ package batcher_test
 
 import (
 	"context"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -25,6 +26,7 @@ import (
 	"sigs.k8s.io/karpenter/pkg/test"
 	"sigs.k8s.io/karpenter/pkg/test/expectations"
 
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
 	"github.com/aws/karpenter-provider-aws/pkg/batcher"
 	"github.com/aws/karpenter-provider-aws/pkg/fake"
 
@@ -89,6 +91,41 @@ var _ = Describe("Batcher", func() {
 			Eventually(fakeBatcher.completedBatches.Load, time.Second*3).Should(BeNumerically("==", 300))
 		})
 	})
+	Context("Roles", func() {
+		It("should not batch requests for different roles together", func() {
+			var mu sync.Mutex
+			batches := map[string][]string{}
+			b := batcher.NewBatcher(cancelCtx, batcher.Options[string, string]{
+				Name:          "fake",
+				IdleTimeout:   100 * time.Millisecond,
+				MaxTimeout:    1 * time.Second,
+				RequestHasher: batcher.OneBucketHasher[string],
+				BatchExecutor: func(ctx context.Context, items []*string) []batcher.Result[string] {
+					mu.Lock()
+					defer mu.Unlock()
+					batches[assumerole.FromContext(ctx)] = append(batches[assumerole.FromContext(ctx)], lo.Map(items, func(i *string, _ int) string { return *i })...)
+					return lo.Map(items, func(i *string, _ int) batcher.Result[string] { return batcher.Result[string]{Output: i} })
+				},
+			})
+			roles := []string{"", "arn:aws:iam::123456789012:role/team-a", "arn:aws:iam::123456789012:role/team-b"}
+			wg := sync.WaitGroup{}
+			for _, role := range roles {
+				for i := 0; i < 3; i++ {
+					wg.Add(1)
+					go func(role string) {
+						defer GinkgoRecover()
+						defer wg.Done()
+						b.Add(assumerole.WithRole(cancelCtx, role), lo.ToPtr(role))
+					}(role)
+				}
+			}
+			wg.Wait()
+			Expect(batches).To(HaveLen(3))
+			for _, role := range roles {
+				Expect(batches[role]).To(ConsistOf(role, role, role))
+			}
+		})
+	})
 	Context("Metrics", func() {
 		It("should create a batch_size metric when a batch is run", func() {
 			// This batcher will get canceled at the end of the test run
@@ -27,6 +27,16 @@ const (
 	// UnavailableOfferingsTTL is the time before offerings that were marked as unavailable
 	// are removed from the cache and are available for launch again
 	UnavailableOfferingsTTL = 3 * time.Minute
+	// ZoneImpairmentTTL is the time before a zone that was reported as impaired without an end time is considered
+	// healthy again. Ongoing AWS Health issues are updated well within this interval, which extends the impairment.
+	ZoneImpairmentTTL = time.Hour
+	// SpotInterruptionHistoryTTL is the time before a spot interruption is dropped from the interruption history of its pool
+	SpotInterruptionHistoryTTL = 24 * time.Hour
+	// SpotInterruptionHalfLife is the time over which the weight of a spot interruption in the history of its pool halves
+	SpotInterruptionHalfLife = 6 * time.Hour
+	// InterruptionWindowsTTL is the time before a spot interruption or rebalance recommendation is dropped from the
+	// interruption windows of its NodePool. It covers a week, so that the busy hours are learned from weekdays and weekends alike.
+	InterruptionWindowsTTL = 7 * 24 * time.Hour
 	// InstanceTypesAndZonesTTL is the time before we refresh instance types and zones at EC2
 	InstanceTypesAndZonesTTL = 5 * time.Minute
 	// InstanceProfileTTL is the time before we refresh checking instance profile existence at IAM
@@ -35,6 +45,11 @@ const (
 	AvailableIPAddressTTL = 5 * time.Minute
 	// AvailableIPAddressTTL is time to drop AssociatePublicIPAddressTTL data if it is not updated within the TTL
 	AssociatePublicIPAddressTTL = 5 * time.Minute
+	// QuotaUsageTTL is the time before the account quotas and their usage are checked again for the headroom of EC2NodeClasses
+	QuotaUsageTTL = 5 * time.Minute
+	// ClusterTTL is the time before the cluster is described again to detect drift of the values that nodes are
+	// bootstrapped with
+	ClusterTTL = time.Hour
 )
 
 const (
@@ -0,0 +1,88 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// ImpairedZones stores the availability zones that have been reported as impaired, either by AWS Health or by a
+// user-fed event. New launches avoid these zones for as long as they are in the cache, as long as there are other
+// zones that can satisfy the launch.
+type ImpairedZones struct {
+	// key: <zone>, value: ImpairedZone
+	cache *cache.Cache
+}
+
+// ImpairedZone is a zone that is currently being avoided, along with the time at which it's considered healthy again
+type ImpairedZone struct {
+	Zone   string    `json:"zone"`
+	Reason string    `json:"reason"`
+	Until  time.Time `json:"until"`
+}
+
+func NewImpairedZones() *ImpairedZones {
+	return &ImpairedZones{
+		cache: cache.New(ZoneImpairmentTTL, DefaultCleanupInterval),
+	}
+}
+
+// IsImpaired returns true if the zone appears in the cache
+func (z *ImpairedZones) IsImpaired(zone string) bool {
+	_, found := z.cache.Get(zone)
+	return found
+}
+
+// MarkImpaired avoids the zone for new launches until the passed time. A zero time falls back to ZoneImpairmentTTL.
+func (z *ImpairedZones) MarkImpaired(ctx context.Context, reason, zone string, until time.Time) {
+	if until.IsZero() {
+		until = time.Now().Add(ZoneImpairmentTTL)
+	}
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		z.MarkRecovered(ctx, zone)
+		return
+	}
+	log.FromContext(ctx).WithValues("reason", reason, "zone", zone, "until", until).V(1).Info("avoiding impaired zone")
+	z.cache.Set(zone, ImpairedZone{Zone: zone, Reason: reason, Until: until}, ttl)
+}
+
+// MarkRecovered removes the zone from the cache so that it's used for new launches again
+func (z *ImpairedZones) MarkRecovered(ctx context.Context, zone string) {
+	if !z.IsImpaired(zone) {
+		return
+	}
+	log.FromContext(ctx).WithValues("zone", zone).V(1).Info("zone is no longer impaired")
+	z.cache.Delete(zone)
+}
+
+// List returns all zones that are currently being avoided, sorted by zone
+func (z *ImpairedZones) List() []ImpairedZone {
+	var zones []ImpairedZone
+	for _, item := range z.cache.Items() {
+		zones = append(zones, item.Object.(ImpairedZone))
+	}
+	sort.Slice(zones, func(i, j int) bool { return zones[i].Zone < zones[j].Zone })
+	return zones
+}
+
+func (z *ImpairedZones) Flush() {
+	z.cache.Flush()
+}
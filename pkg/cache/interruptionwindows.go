@@ -0,0 +1,96 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// busyHourMinEvents is the number of events that an hour of the day needs to be busy, so that a NodePool with few
+// interruptions doesn't treat the hours of each of them as busy
+const busyHourMinEvents = 3
+
+// InterruptionWindows is the history of the spot interruptions and rebalance recommendations of the nodes of each
+// NodePool, by the hour of the day (UTC) that they were received in. An hour is busy for a NodePool when it received
+// at least busyHourMinEvents events in the hour, and more than twice the events of an average hour, so that the
+// voluntary disruption of the NodePool can be moved to the hours in which its instances are rarely interrupted.
+// Events are forgotten after InterruptionWindowsTTL.
+type InterruptionWindows struct {
+	mu  sync.RWMutex
+	clk clock.Clock
+	// key: the name of the NodePool, value: the times of its events
+	events map[string][]time.Time
+}
+
+func NewInterruptionWindows(clk clock.Clock) *InterruptionWindows {
+	return &InterruptionWindows{
+		clk:    clk,
+		events: map[string][]time.Time{},
+	}
+}
+
+// Record adds an event of the NodePool at the current time
+func (w *InterruptionWindows) Record(ctx context.Context, nodePool string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.events[nodePool] = append(w.prune(w.events[nodePool]), w.clk.Now())
+	log.FromContext(ctx).WithValues("NodePool", nodePool, "events", len(w.events[nodePool])).V(1).Info("recorded interruption event")
+}
+
+// Busy returns whether the current hour of the day is busy for the NodePool, along with the time until the hour ends
+func (w *InterruptionWindows) Busy(nodePool string) (bool, time.Duration) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	now := w.clk.Now().UTC()
+	events := w.prune(w.events[nodePool])
+	inHour := lo.CountBy(events, func(t time.Time) bool { return t.UTC().Hour() == now.Hour() })
+	busy := inHour >= busyHourMinEvents && float64(inHour) > 2*float64(len(events))/24
+	return busy, now.Truncate(time.Hour).Add(time.Hour).Sub(now)
+}
+
+// Snapshot returns the events of each NodePool that haven't been forgotten, so that they can be persisted
+func (w *InterruptionWindows) Snapshot() map[string][]time.Time {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return lo.OmitBy(lo.MapValues(w.events, func(events []time.Time, _ string) []time.Time {
+		return lo.Map(w.prune(events), func(t time.Time, _ int) time.Time { return t.UTC().Round(0) })
+	}), func(_ string, events []time.Time) bool { return len(events) == 0 })
+}
+
+// Restore adds the persisted events of each NodePool to the events that were recorded since the controller started
+func (w *InterruptionWindows) Restore(events map[string][]time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for nodePool, restored := range events {
+		w.events[nodePool] = w.prune(append(restored, w.events[nodePool]...))
+	}
+}
+
+func (w *InterruptionWindows) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.events = map[string][]time.Time{}
+}
+
+// prune drops the events that are older than InterruptionWindowsTTL
+func (w *InterruptionWindows) prune(events []time.Time) []time.Time {
+	return lo.Filter(events, func(t time.Time, _ int) bool { return w.clk.Since(t) < InterruptionWindowsTTL })
+}
@@ -0,0 +1,120 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// SpotInterruptionHistory is a rolling history of the spot interruptions of each pool, which is an instance type in a
+// zone. The weight of a pool is the sum of its interruptions, each of which is halved every SpotInterruptionHalfLife,
+// so that pools recover as their interruptions age. Interruptions are forgotten after SpotInterruptionHistoryTTL.
+type SpotInterruptionHistory struct {
+	mu  sync.RWMutex
+	clk clock.Clock
+	// key: <instance-type>:<zone>, value: the times of the interruptions of the pool
+	interruptions map[string][]time.Time
+}
+
+// SpotInterruptionPool is the interruption history of a pool, along with its weight
+type SpotInterruptionPool struct {
+	InstanceType     string    `json:"instanceType"`
+	Zone             string    `json:"zone"`
+	Interruptions    int       `json:"interruptions"`
+	LastInterruption time.Time `json:"lastInterruption"`
+	Weight           float64   `json:"weight"`
+}
+
+func NewSpotInterruptionHistory(clk clock.Clock) *SpotInterruptionHistory {
+	return &SpotInterruptionHistory{
+		clk:           clk,
+		interruptions: map[string][]time.Time{},
+	}
+}
+
+// Record adds an interruption of the pool at the current time
+func (h *SpotInterruptionHistory) Record(ctx context.Context, instanceType, zone string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	key := h.key(instanceType, zone)
+	h.interruptions[key] = append(h.prune(h.interruptions[key]), h.clk.Now())
+	log.FromContext(ctx).WithValues("instance-type", instanceType, "zone", zone, "interruptions", len(h.interruptions[key])).V(1).Info("recorded spot interruption")
+}
+
+// Weight returns the weight of the recent interruptions of the pool, which is zero for pools without interruptions
+func (h *SpotInterruptionHistory) Weight(instanceType, zone string) float64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.weight(h.interruptions[h.key(instanceType, zone)])
+}
+
+// List returns the pools with recent interruptions, sorted by descending weight
+func (h *SpotInterruptionHistory) List() []SpotInterruptionPool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var pools []SpotInterruptionPool
+	for key, interruptions := range h.interruptions {
+		if interruptions = h.prune(interruptions); len(interruptions) == 0 {
+			delete(h.interruptions, key)
+			continue
+		}
+		h.interruptions[key] = interruptions
+		instanceType, zone, _ := strings.Cut(key, ":")
+		pools = append(pools, SpotInterruptionPool{
+			InstanceType:     instanceType,
+			Zone:             zone,
+			Interruptions:    len(interruptions),
+			LastInterruption: lo.MaxBy(interruptions, func(a, b time.Time) bool { return a.After(b) }),
+			Weight:           h.weight(interruptions),
+		})
+	}
+	sort.Slice(pools, func(i, j int) bool {
+		if pools[i].Weight != pools[j].Weight {
+			return pools[i].Weight > pools[j].Weight
+		}
+		return h.key(pools[i].InstanceType, pools[i].Zone) < h.key(pools[j].InstanceType, pools[j].Zone)
+	})
+	return pools
+}
+
+func (h *SpotInterruptionHistory) Flush() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.interruptions = map[string][]time.Time{}
+}
+
+func (h *SpotInterruptionHistory) key(instanceType, zone string) string {
+	return instanceType + ":" + zone
+}
+
+// prune drops the interruptions that are older than SpotInterruptionHistoryTTL
+func (h *SpotInterruptionHistory) prune(interruptions []time.Time) []time.Time {
+	return lo.Filter(interruptions, func(t time.Time, _ int) bool { return h.clk.Since(t) < SpotInterruptionHistoryTTL })
+}
+
+func (h *SpotInterruptionHistory) weight(interruptions []time.Time) float64 {
+	return lo.SumBy(h.prune(interruptions), func(t time.Time) float64 {
+		return math.Pow(0.5, float64(h.clk.Since(t))/float64(SpotInterruptionHalfLife))
+	})
+}
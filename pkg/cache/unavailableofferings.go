@@ -17,7 +17,9 @@ package cache
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
@@ -29,7 +31,7 @@ import (
 // attempting to launch the capacity. These offerings are ignored as long as they are in the cache on
 // GetInstanceTypes responses
 type UnavailableOfferings struct {
-	// key: <capacityType>:<instanceType>:<zone>, value: struct{}{}
+	// key: <capacityType>:<instanceType>:<zone> or zone:<zone>, value: struct{}{}
 	cache  *cache.Cache
 	SeqNum uint64
 }
@@ -45,12 +47,45 @@ func NewUnavailableOfferings() *UnavailableOfferings {
 	return uo
 }
 
-// IsUnavailable returns true if the offering appears in the cache
+// IsUnavailable returns true if the offering or its zone appears in the cache
 func (u *UnavailableOfferings) IsUnavailable(instanceType, zone, capacityType string) bool {
+	if u.IsZoneUnavailable(zone) {
+		return true
+	}
 	_, found := u.cache.Get(u.key(instanceType, zone, capacityType))
 	return found
 }
 
+// IsZoneUnavailable returns true if every offering in the zone is unavailable
+func (u *UnavailableOfferings) IsZoneUnavailable(zone string) bool {
+	_, found := u.cache.Get(u.zoneKey(zone))
+	return found
+}
+
+// MarkZoneUnavailable removes every offering in the zone from the offerings until the passed time, e.g. while a zonal
+// shift moves traffic away from the zone. Unlike impaired zones, the zone isn't used even if it's the only one left.
+func (u *UnavailableOfferings) MarkZoneUnavailable(ctx context.Context, unavailableReason, zone string, until time.Time) {
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		u.MarkZoneAvailable(ctx, zone)
+		return
+	}
+	if !u.IsZoneUnavailable(zone) {
+		log.FromContext(ctx).WithValues("reason", unavailableReason, "zone", zone, "until", until).Info("removing zone from offerings")
+	}
+	u.cache.Set(u.zoneKey(zone), struct{}{}, ttl)
+	atomic.AddUint64(&u.SeqNum, 1)
+}
+
+// MarkZoneAvailable returns the offerings of the zone that were removed with MarkZoneUnavailable
+func (u *UnavailableOfferings) MarkZoneAvailable(ctx context.Context, zone string) {
+	if !u.IsZoneUnavailable(zone) {
+		return
+	}
+	log.FromContext(ctx).WithValues("zone", zone).Info("returning zone to offerings")
+	u.cache.Delete(u.zoneKey(zone))
+}
+
 // MarkUnavailable communicates recently observed temporary capacity shortages in the provided offerings
 func (u *UnavailableOfferings) MarkUnavailable(ctx context.Context, unavailableReason, instanceType, zone, capacityType string) {
 	// even if the key is already in the cache, we still need to call Set to extend the cached entry's TTL
@@ -70,6 +105,33 @@ func (u *UnavailableOfferings) MarkUnavailableForFleetErr(ctx context.Context, f
 	u.MarkUnavailable(ctx, aws.StringValue(fleetErr.ErrorCode), instanceType, zone, capacityType)
 }
 
+// UnavailableOffering is an offering that is currently in the cache, along with the time at which it expires
+type UnavailableOffering struct {
+	InstanceType string    `json:"instanceType"`
+	Zone         string    `json:"zone"`
+	CapacityType string    `json:"capacityType"`
+	Expiration   time.Time `json:"expiration"`
+}
+
+// List returns all offerings that are currently marked as unavailable
+func (u *UnavailableOfferings) List() []UnavailableOffering {
+	var offerings []UnavailableOffering
+	for k, item := range u.cache.Items() {
+		// keys of offerings are of the form <capacityType>:<instanceType>:<zone>, so the keys of zones are skipped
+		parts := strings.SplitN(k, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		offerings = append(offerings, UnavailableOffering{
+			CapacityType: parts[0],
+			InstanceType: parts[1],
+			Zone:         parts[2],
+			Expiration:   time.Unix(0, item.Expiration),
+		})
+	}
+	return offerings
+}
+
 func (u *UnavailableOfferings) Delete(instanceType string, zone string, capacityType string) {
 	u.cache.Delete(u.key(instanceType, zone, capacityType))
 }
@@ -78,6 +140,11 @@ func (u *UnavailableOfferings) Flush() {
 	u.cache.Flush()
 }
 
+// zoneKey returns the cache key for all offerings of a zone, which can't collide with the key of an offering
+func (u *UnavailableOfferings) zoneKey(zone string) string {
+	return fmt.Sprintf("zone:%s", zone)
+}
+
 // key returns the cache key for all offerings in the cache
 func (u *UnavailableOfferings) key(instanceType string, zone string, capacityType string) string {
 	return fmt.Sprintf("%s:%s:%s", capacityType, instanceType, zone)
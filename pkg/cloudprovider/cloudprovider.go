@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/aws/aws-sdk-go/service/ec2"
@@ -31,10 +32,16 @@ import (
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
+	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 	"sigs.k8s.io/karpenter/pkg/utils/resources"
 
+	"github.com/aws/karpenter-provider-aws/pkg/alerting"
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
+	"github.com/aws/karpenter-provider-aws/pkg/audit"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
 
 	"github.com/samber/lo"
@@ -48,6 +55,7 @@ import (
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
+	"github.com/aws/karpenter-provider-aws/pkg/tracing"
 
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 )
@@ -62,10 +70,15 @@ type CloudProvider struct {
 	instanceProvider      instance.Provider
 	amiProvider           amifamily.Provider
 	securityGroupProvider securitygroup.Provider
+	alertTracker          *alerting.Tracker
+	unavailableOfferings  *awscache.UnavailableOfferings
+	launches              *launches
+	cm                    *pretty.ChangeMonitor
 }
 
 func New(instanceTypeProvider instancetype.Provider, instanceProvider instance.Provider, recorder events.Recorder,
-	kubeClient client.Client, amiProvider amifamily.Provider, securityGroupProvider securitygroup.Provider) *CloudProvider {
+	kubeClient client.Client, amiProvider amifamily.Provider, securityGroupProvider securitygroup.Provider, alertTracker *alerting.Tracker,
+	unavailableOfferings *awscache.UnavailableOfferings) *CloudProvider {
 	return &CloudProvider{
 		instanceTypeProvider:  instanceTypeProvider,
 		instanceProvider:      instanceProvider,
@@ -73,11 +86,25 @@ func New(instanceTypeProvider instancetype.Provider, instanceProvider instance.P
 		amiProvider:           amiProvider,
 		securityGroupProvider: securityGroupProvider,
 		recorder:              recorder,
+		alertTracker:          alertTracker,
+		unavailableOfferings:  unavailableOfferings,
+		launches:              newLaunches(),
+		cm:                    pretty.NewChangeMonitor(),
 	}
 }
 
 // Create a NodeClaim given the constraints.
-func (c *CloudProvider) Create(ctx context.Context, nodeClaim *karpv1.NodeClaim) (*karpv1.NodeClaim, error) {
+func (c *CloudProvider) Create(ctx context.Context, nodeClaim *karpv1.NodeClaim) (_ *karpv1.NodeClaim, err error) {
+	ctx, span := tracing.Start(ctx, "CloudProvider.Create", tracing.AttributeNodeClaim.String(nodeClaim.Name))
+	defer func() { tracing.End(span, err) }()
+	defer func() {
+		if err != nil {
+			c.alertTracker.RecordLaunchFailure(ctx, nodeClaim.Labels[karpv1.NodePoolLabelKey], err)
+			return
+		}
+		c.alertTracker.RecordLaunch(nodeClaim.Labels[karpv1.NodePoolLabelKey])
+	}()
+
 	nodeClass, err := c.resolveNodeClassFromNodeClaim(ctx, nodeClaim)
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -86,6 +113,7 @@ func (c *CloudProvider) Create(ctx context.Context, nodeClaim *karpv1.NodeClaim)
 		// We treat a failure to resolve the NodeClass as an ICE since this means there is no capacity possibilities for this NodeClaim
 		return nil, cloudprovider.NewInsufficientCapacityError(fmt.Errorf("resolving node class, %w", err))
 	}
+	ctx = assumerole.WithNodePool(assumerole.WithNodeClass(ctx, nodeClass), nodeClaim.Labels[karpv1.NodePoolLabelKey])
 
 	// TODO: Remove this after v1
 	nodePool, err := utils.ResolveNodePoolFromNodeClaim(ctx, c.kubeClient, nodeClaim)
@@ -96,10 +124,12 @@ func (c *CloudProvider) Create(ctx context.Context, nodeClaim *karpv1.NodeClaim)
 	if err != nil {
 		return nil, err
 	}
+	ctx = instance.WithNodePool(ctx, nodePool)
 	nodeClassReady := nodeClass.StatusConditions().Get(status.ConditionReady)
 	if !nodeClassReady.IsTrue() {
 		return nil, fmt.Errorf("resolving ec2nodeclass, %s", nodeClassReady.Message)
 	}
+	span.SetAttributes(tracing.AttributeNodeClass.String(nodeClass.Name))
 	instanceTypes, err := c.resolveInstanceTypes(ctx, nodeClaim, nodeClass)
 	if err != nil {
 		return nil, fmt.Errorf("resolving instance types, %w", err)
@@ -107,27 +137,81 @@ func (c *CloudProvider) Create(ctx context.Context, nodeClaim *karpv1.NodeClaim)
 	if len(instanceTypes) == 0 {
 		return nil, cloudprovider.NewInsufficientCapacityError(fmt.Errorf("all requested instance types were unavailable during launch"))
 	}
+	reservedInstanceTypes, reservedNodeClaim, err := c.reserve(ctx, nodePool.Name, nodeClaim, instanceTypes)
+	if err != nil {
+		return nil, fmt.Errorf("reserving launch, %w", err)
+	}
+	instanceTypes, nodeClaim = reservedInstanceTypes, reservedNodeClaim
 	instance, err := c.instanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 	if err != nil {
+		c.launches.release(nodePool.Name, nodeClaim.Name)
 		return nil, fmt.Errorf("creating instance, %w", err)
 	}
+	c.launches.launched(nodePool.Name, nodeClaim.Name, instance.CapacityType, instance.Zone)
 	instanceType, _ := lo.Find(instanceTypes, func(i *cloudprovider.InstanceType) bool {
 		return i.Name == instance.Type
 	})
+	span.SetAttributes(tracing.AttributeInstanceID.String(instance.ID))
+	// Spot launches that are constrained to few pools are interrupted more often, so they're published to explain why
+	if threshold := options.FromContext(ctx).SpotDiversificationThreshold; instance.CapacityType == karpv1.CapacityTypeSpot && instance.SpotPools < threshold {
+		log.FromContext(ctx).WithValues("pools", instance.SpotPools, "threshold", threshold).Info("spot launch diversified across fewer pools than the threshold")
+		c.recorder.Publish(cloudproviderevents.NodeClaimSpotDiversificationLow(nodeClaim, instance.SpotPools, threshold))
+	}
 	nc := c.instanceToNodeClaim(instance, instanceType, nodeClass)
 	nc.Annotations = lo.Assign(nodeClass.Annotations, map[string]string{
 		v1.AnnotationKubeletCompatibilityHash: kubeletHash,
 		v1.AnnotationEC2NodeClassHash:         nodeClass.Hash(),
 		v1.AnnotationEC2NodeClassHashVersion:  v1.EC2NodeClassHashVersion,
-	})
+	}, lo.PickByKeys(nc.Annotations, []string{v1.AnnotationSubnetID}), c.launchPriceAnnotations(nodeClass, instance, instanceType))
+	// On-demand launches of NodeClaims that allow spot only happen when no spot offering is available, so they're
+	// recorded to be replaced with spot once it's available again
+	if instance.CapacityType == karpv1.CapacityTypeOnDemand &&
+		scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...).Get(karpv1.CapacityTypeLabelKey).Has(karpv1.CapacityTypeSpot) {
+		nc.Annotations[v1.AnnotationSpotFallback] = "true"
+	}
 	return nc, nil
 }
 
+// launchPriceAnnotations records the spot price paid at launch along with the on-demand price of the same instance type
+// so that the savings realized by spot can be tracked for the lifetime of the node. The spot price is the latest price
+// rather than the price of the offering, which is adjusted for the trend of the spot price.
+func (c *CloudProvider) launchPriceAnnotations(nodeClass *v1.EC2NodeClass, i *instance.Instance, instanceType *cloudprovider.InstanceType) map[string]string {
+	if i.CapacityType != karpv1.CapacityTypeSpot || instanceType == nil {
+		return nil
+	}
+	spotPrice, ok := c.instanceTypeProvider.LatestSpotPrice(nodeClass, i.Type, i.Zone)
+	if !ok {
+		return nil
+	}
+	offerings := instanceType.Offerings.Compatible(scheduling.NewLabelRequirements(map[string]string{
+		corev1.LabelTopologyZone:    i.Zone,
+		karpv1.CapacityTypeLabelKey: karpv1.CapacityTypeOnDemand,
+	}))
+	if len(offerings) == 0 {
+		return nil
+	}
+	onDemandPrice := offerings.Cheapest().Price
+	return map[string]string{
+		v1.AnnotationLaunchSpotPrice:     strconv.FormatFloat(spotPrice, 'f', -1, 64),
+		v1.AnnotationLaunchOnDemandPrice: strconv.FormatFloat(onDemandPrice, 'f', -1, 64),
+	}
+}
+
 func (c *CloudProvider) List(ctx context.Context) ([]*karpv1.NodeClaim, error) {
-	instances, err := c.instanceProvider.List(ctx)
+	roleARNs, err := c.resolveRoles(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("listing instances, %w", err)
+		return nil, err
 	}
+	var instances []*instance.Instance
+	for _, roleARN := range roleARNs {
+		out, err := c.instanceProvider.List(assumerole.WithRole(ctx, roleARN))
+		if err != nil {
+			return nil, fmt.Errorf("listing instances, %w", err)
+		}
+		instances = append(instances, out...)
+	}
+	// Roles that belong to the same account see the same instances
+	instances = lo.UniqBy(instances, func(i *instance.Instance) string { return i.ID })
 	var nodeClaims []*karpv1.NodeClaim
 	for _, instance := range instances {
 		instanceType, err := c.resolveInstanceTypeFromInstance(ctx, instance)
@@ -149,7 +233,7 @@ func (c *CloudProvider) Get(ctx context.Context, providerID string) (*karpv1.Nod
 		return nil, fmt.Errorf("getting instance ID, %w", err)
 	}
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("id", id))
-	instance, err := c.instanceProvider.Get(ctx, id)
+	instance, err := c.findInstance(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("getting instance, %w", err)
 	}
@@ -189,7 +273,7 @@ func (c *CloudProvider) GetInstanceTypes(ctx context.Context, nodePool *karpv1.N
 	if err != nil {
 		return nil, err
 	}
-	return instanceTypes, nil
+	return c.withDistribution(ctx, nodePool, instanceTypes)
 }
 
 func (c *CloudProvider) Delete(ctx context.Context, nodeClaim *karpv1.NodeClaim) error {
@@ -198,7 +282,30 @@ func (c *CloudProvider) Delete(ctx context.Context, nodeClaim *karpv1.NodeClaim)
 		return fmt.Errorf("getting instance ID, %w", err)
 	}
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("id", id))
-	return c.instanceProvider.Delete(ctx, id)
+	// The NodeClass is still resolved when it's terminating since the instance must be deleted with the role it was launched with
+	if nodeClaim.Spec.NodeClassRef != nil {
+		nodeClass := &v1.EC2NodeClass{}
+		if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodeClaim.Spec.NodeClassRef.Name}, nodeClass); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("resolving node class, %w", err)
+		}
+		ctx = assumerole.WithNodeClass(ctx, nodeClass)
+	}
+	ctx = assumerole.WithNodePool(ctx, nodeClaim.Labels[karpv1.NodePoolLabelKey])
+	if err := c.instanceProvider.Delete(ctx, id); err != nil {
+		return err
+	}
+	audit.RecordTermination(ctx, &audit.TerminationDecision{
+		NodeClaim:    nodeClaim.Name,
+		NodePool:     nodeClaim.Labels[karpv1.NodePoolLabelKey],
+		InstanceID:   id,
+		InstanceType: nodeClaim.Labels[corev1.LabelInstanceTypeStable],
+		Zone:         nodeClaim.Labels[corev1.LabelTopologyZone],
+		CapacityType: nodeClaim.Labels[karpv1.CapacityTypeLabelKey],
+		Conditions: lo.FilterMap(nodeClaim.Status.Conditions, func(c status.Condition, _ int) (string, bool) {
+			return c.Type, c.Status == metav1.ConditionTrue
+		}),
+	})
+	return nil
 }
 
 func (c *CloudProvider) IsDrifted(ctx context.Context, nodeClaim *karpv1.NodeClaim) (cloudprovider.DriftReason, error) {
@@ -207,6 +314,9 @@ func (c *CloudProvider) IsDrifted(ctx context.Context, nodeClaim *karpv1.NodeCla
 	if !ok {
 		return "", nil
 	}
+	if drifted := c.isZonalShiftDrifted(ctx, nodeClaim); drifted != "" {
+		return drifted, nil
+	}
 	nodePool := &karpv1.NodePool{}
 	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodePoolName}, nodePool); err != nil {
 		return "", client.IgnoreNotFound(err)
@@ -221,11 +331,14 @@ func (c *CloudProvider) IsDrifted(ctx context.Context, nodeClaim *karpv1.NodeCla
 		}
 		return "", client.IgnoreNotFound(fmt.Errorf("resolving node class, %w", err))
 	}
-	driftReason, err := c.isNodeClassDrifted(ctx, nodeClaim, nodePool, nodeClass)
+	driftReason, err := c.isNodeClassDrifted(assumerole.WithNodeClass(ctx, nodeClass), nodeClaim, nodePool, nodeClass)
 	if err != nil {
 		return "", err
 	}
-	return driftReason, nil
+	if driftReason != "" {
+		return driftReason, nil
+	}
+	return c.isSpotReturnDrifted(assumerole.WithNodeClass(ctx, nodeClass), nodeClaim, nodeClass)
 }
 
 // Name returns the CloudProvider implementation name.
@@ -237,6 +350,35 @@ func (c *CloudProvider) GetSupportedNodeClasses() []status.Object {
 	return []status.Object{&v1.EC2NodeClass{}}
 }
 
+// resolveRoles returns the roles that instances may have been launched with. The controller credentials, represented by
+// the empty role, always come first.
+func (c *CloudProvider) resolveRoles(ctx context.Context) ([]string, error) {
+	nodeClassList := &v1.EC2NodeClassList{}
+	if err := c.kubeClient.List(ctx, nodeClassList); err != nil {
+		return nil, fmt.Errorf("listing node classes, %w", err)
+	}
+	return lo.Uniq(append([]string{""}, lo.FilterMap(nodeClassList.Items, func(nc v1.EC2NodeClass, _ int) (string, bool) {
+		return lo.FromPtr(nc.Spec.AssumeRoleARN), lo.FromPtr(nc.Spec.AssumeRoleARN) != ""
+	})...)), nil
+}
+
+// findInstance gets the instance with the controller credentials and falls back to each assumed role, since the
+// NodeClass that the instance was launched with isn't known ahead of time
+func (c *CloudProvider) findInstance(ctx context.Context, id string) (*instance.Instance, error) {
+	roleARNs, err := c.resolveRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, roleARN := range roleARNs {
+		instance, err := c.instanceProvider.Get(assumerole.WithRole(ctx, roleARN), id)
+		if cloudprovider.IsNodeClaimNotFoundError(err) {
+			continue
+		}
+		return instance, err
+	}
+	return nil, cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("instance %s not found", id))
+}
+
 func (c *CloudProvider) resolveNodeClassFromNodeClaim(ctx context.Context, nodeClaim *karpv1.NodeClaim) (*v1.EC2NodeClass, error) {
 	nodeClass := &v1.EC2NodeClass{}
 	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodeClaim.Spec.NodeClassRef.Name}, nodeClass); err != nil {
@@ -354,6 +496,9 @@ func (c *CloudProvider) instanceToNodeClaim(i *instance.Instance, instanceType *
 		}); ok && subnet.ZoneID != "" {
 			labels[v1.LabelTopologyZoneID] = subnet.ZoneID
 		}
+		if nodeClass.BottlerocketInPlaceUpdates() {
+			labels[v1.LabelBottlerocketUpdaterInterfaceVersion] = v1.BottlerocketUpdaterInterfaceVersion
+		}
 	}
 	labels[karpv1.CapacityTypeLabelKey] = i.CapacityType
 	if v, ok := i.Tags[karpv1.NodePoolLabelKey]; ok {
@@ -362,6 +507,9 @@ func (c *CloudProvider) instanceToNodeClaim(i *instance.Instance, instanceType *
 	if v, ok := i.Tags[karpv1.ManagedByAnnotationKey]; ok {
 		annotations[karpv1.ManagedByAnnotationKey] = v
 	}
+	if i.SubnetID != "" {
+		annotations[v1.AnnotationSubnetID] = i.SubnetID
+	}
 	nodeClaim.Labels = labels
 	nodeClaim.Annotations = annotations
 	nodeClaim.CreationTimestamp = metav1.Time{Time: i.LaunchTime}
@@ -0,0 +1,291 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	cloudproviderevents "github.com/aws/karpenter-provider-aws/pkg/cloudprovider/events"
+)
+
+// launches are the NodeClaims of each NodePool with a distribution that are being launched. NodeClaims are only labeled
+// with the capacity type and the zone that they launched with once their launch completed, and NodeClaims are launched
+// in parallel, so each launch reserves its capacity type and zone here until its labels are observed. Otherwise all the
+// NodeClaims of a burst would see the same distribution and launch with the same capacity type and into the same zone.
+type launches struct {
+	mu sync.Mutex
+	// key: the name of the NodePool, value: the reservations of its NodeClaims by the name of the NodeClaim
+	reservations map[string]map[string]reservation
+}
+
+// reservation is the capacity type and the zone of a NodeClaim that's being launched, which are empty when the launch
+// isn't constrained to one
+type reservation struct {
+	capacityType string
+	zone         string
+}
+
+func newLaunches() *launches {
+	return &launches{reservations: map[string]map[string]reservation{}}
+}
+
+// reserve narrows the instance types and the requirements of the NodeClaim to the zones that haven't reached the
+// max-nodes-per-zone and to the target capacity type of its NodePool, and reserves them until the labels of the
+// NodeClaim are observed. NodeClaims of NodePools with a max-nodes-per-zone are launched into the zone of their cheapest
+// offering, so that the launch counts against that zone.
+func (c *CloudProvider) reserve(ctx context.Context, nodePoolName string, nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) ([]*cloudprovider.InstanceType, *karpv1.NodeClaim, error) {
+	if !hasDistribution(nodeClaim.Annotations) {
+		return instanceTypes, nodeClaim, nil
+	}
+	c.launches.mu.Lock()
+	defer c.launches.mu.Unlock()
+	capacityTypes, zones, err := c.distribution(ctx, nodePoolName, nodeClaim.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	var r reservation
+	reqs := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	full, limit, capped := fullZones(ctx, nodeClaim.Annotations, zones)
+	if capped {
+		instanceTypes = lo.Filter(withoutZones(instanceTypes, full), func(it *cloudprovider.InstanceType, _ int) bool {
+			return len(it.Offerings.Available().Compatible(reqs)) != 0
+		})
+		if len(instanceTypes) == 0 {
+			return nil, nil, cloudprovider.NewInsufficientCapacityError(fmt.Errorf("all requested zones reached the max-nodes-per-zone of %d", limit))
+		}
+	}
+	capacityType := targetCapacityType(ctx, nodeClaim.Annotations, capacityTypes)
+	if targeted, ok := withTargetCapacityType(instanceTypes, reqs, capacityType); ok {
+		instanceTypes, nodeClaim, r.capacityType = targeted, withRequirement(nodeClaim, karpv1.CapacityTypeLabelKey, capacityType), capacityType
+		reqs = scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	}
+	offerings := lo.FlatMap(instanceTypes, func(it *cloudprovider.InstanceType, _ int) []cloudprovider.Offering {
+		return it.Offerings.Available().Compatible(reqs)
+	})
+	if capped && len(offerings) != 0 {
+		r.zone = lo.MinBy(offerings, func(a, b cloudprovider.Offering) bool { return a.Price < b.Price }).Requirements.Get(corev1.LabelTopologyZone).Any()
+		instanceTypes = withUnavailableOfferings(instanceTypes, func(o cloudprovider.Offering) bool {
+			return o.Requirements.Get(corev1.LabelTopologyZone).Any() != r.zone
+		})
+		nodeClaim = withRequirement(nodeClaim, corev1.LabelTopologyZone, r.zone)
+	}
+	c.launches.reservations[nodePoolName] = lo.Assign(c.launches.reservations[nodePoolName], map[string]reservation{nodeClaim.Name: r})
+	return instanceTypes, nodeClaim, nil
+}
+
+// launched records the capacity type and the zone that the NodeClaim launched with, until its labels are observed
+func (l *launches) launched(nodePoolName, nodeClaimName, capacityType, zone string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.reservations[nodePoolName][nodeClaimName]; ok {
+		l.reservations[nodePoolName][nodeClaimName] = reservation{capacityType: capacityType, zone: zone}
+	}
+}
+
+// release drops the reservation of a NodeClaim whose launch failed
+func (l *launches) release(nodePoolName, nodeClaimName string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.reservations[nodePoolName], nodeClaimName)
+}
+
+// distribution returns the number of NodeClaims of the NodePool by the capacity type and by the zone that they launched
+// with, leaving out the NodeClaim that's being launched and the NodeClaims that are deleting. NodeClaims whose launch
+// hasn't been observed yet are counted by their reservation. Reservations are released once the labels of their
+// NodeClaim are observed, or once their NodeClaim is gone. Must be called with the lock of the launches held.
+func (c *CloudProvider) distribution(ctx context.Context, nodePoolName string, nodeClaimName string) (map[string]int, map[string]int, error) {
+	nodeClaims := &karpv1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaims, client.MatchingLabels{karpv1.NodePoolLabelKey: nodePoolName}); err != nil {
+		return nil, nil, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	reservations := c.launches.reservations[nodePoolName]
+	capacityTypes, zones := map[string]int{}, map[string]int{}
+	observed := sets.New[string]()
+	for _, nc := range nodeClaims.Items {
+		if nc.Name == nodeClaimName {
+			continue
+		}
+		observed.Insert(nc.Name)
+		if !nc.DeletionTimestamp.IsZero() {
+			delete(reservations, nc.Name)
+			continue
+		}
+		capacityType, zone := nc.Labels[karpv1.CapacityTypeLabelKey], nc.Labels[corev1.LabelTopologyZone]
+		if r, ok := reservations[nc.Name]; ok {
+			if capacityType != "" && zone != "" {
+				delete(reservations, nc.Name)
+			}
+			capacityType, zone = lo.CoalesceOrEmpty(capacityType, r.capacityType), lo.CoalesceOrEmpty(zone, r.zone)
+		}
+		if capacityType != "" {
+			capacityTypes[capacityType]++
+		}
+		if zone != "" {
+			zones[zone]++
+		}
+	}
+	for name := range reservations {
+		if name != nodeClaimName && !observed.Has(name) {
+			delete(reservations, name)
+		}
+	}
+	return capacityTypes, zones, nil
+}
+
+// withDistribution marks the offerings in the zones that reached the max-nodes-per-zone of the NodePool, and the
+// offerings of the other capacity type than its target capacity type, as unavailable. The zones are published when
+// they changed.
+func (c *CloudProvider) withDistribution(ctx context.Context, nodePool *karpv1.NodePool, instanceTypes []*cloudprovider.InstanceType) ([]*cloudprovider.InstanceType, error) {
+	if !hasDistribution(nodePool.Spec.Template.Annotations) {
+		return instanceTypes, nil
+	}
+	c.launches.mu.Lock()
+	capacityTypes, zones, err := c.distribution(ctx, nodePool.Name, "")
+	c.launches.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if full, limit, ok := fullZones(ctx, nodePool.Spec.Template.Annotations, zones); ok {
+		if c.cm.HasChanged(fmt.Sprintf("full-zones/%s", nodePool.Name), sets.List(full)) && len(full) != 0 {
+			c.recorder.Publish(cloudproviderevents.NodePoolZonesFull(nodePool, sets.List(full), limit))
+		}
+		instanceTypes = withoutZones(instanceTypes, full)
+	}
+	capacityType := targetCapacityType(ctx, nodePool.Spec.Template.Annotations, capacityTypes)
+	instanceTypes, _ = withTargetCapacityType(instanceTypes, scheduling.NewNodeSelectorRequirementsWithMinValues(nodePool.Spec.Template.Spec.Requirements...), capacityType)
+	return instanceTypes, nil
+}
+
+func hasDistribution(annotations map[string]string) bool {
+	_, spotPercentage := annotations[v1.AnnotationSpotPercentage]
+	_, maxNodesPerZone := annotations[v1.AnnotationMaxNodesPerZone]
+	return spotPercentage || maxNodesPerZone
+}
+
+// targetCapacityType returns the capacity type that the next NodeClaim of a NodePool should launch with to keep the
+// share of its spot NodeClaims at the spot-percentage of the NodePool. NodePools opt in by setting the annotation in
+// their template. The next NodeClaim is spot as long as the spot NodeClaims would stay below their share of the NodePool
+// with it.
+func targetCapacityType(ctx context.Context, annotations map[string]string, capacityTypes map[string]int) string {
+	value, ok := annotations[v1.AnnotationSpotPercentage]
+	if !ok {
+		return ""
+	}
+	percentage, err := strconv.ParseFloat(value, 64)
+	if err != nil || percentage < 0 || percentage > 100 {
+		log.FromContext(ctx).Error(fmt.Errorf("%q is not a percentage", value), fmt.Sprintf("ignoring %s", v1.AnnotationSpotPercentage))
+		return ""
+	}
+	launched := capacityTypes[karpv1.CapacityTypeSpot] + capacityTypes[karpv1.CapacityTypeOnDemand]
+	if float64(capacityTypes[karpv1.CapacityTypeSpot]) < percentage/100*float64(launched+1) {
+		return karpv1.CapacityTypeSpot
+	}
+	return karpv1.CapacityTypeOnDemand
+}
+
+// withTargetCapacityType marks the offerings of the other capacity type than the target capacity type of the NodePool
+// as unavailable, so that provisioning and consolidation only choose offerings of the target capacity type. Instance
+// types are returned unchanged when the requirements don't allow both capacity types, or when no offering of the target
+// capacity type is available, so that the distribution never blocks launches.
+func withTargetCapacityType(instanceTypes []*cloudprovider.InstanceType, requirements scheduling.Requirements, capacityType string) ([]*cloudprovider.InstanceType, bool) {
+	if capacityType == "" || !allowsBothCapacityTypes(requirements) {
+		return instanceTypes, false
+	}
+	target := scheduling.NewRequirements(scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, capacityType))
+	if !lo.ContainsBy(instanceTypes, func(it *cloudprovider.InstanceType) bool {
+		return len(it.Offerings.Available().Compatible(requirements).Compatible(target)) != 0
+	}) {
+		return instanceTypes, false
+	}
+	return withUnavailableOfferings(instanceTypes, func(o cloudprovider.Offering) bool {
+		return !o.Requirements.Get(karpv1.CapacityTypeLabelKey).Has(capacityType)
+	}), true
+}
+
+// withRequirement narrows the requirement of the key of a NodeClaim to the value, so that the instance is launched with
+// it
+func withRequirement(nodeClaim *karpv1.NodeClaim, key string, value string) *karpv1.NodeClaim {
+	nodeClaim = nodeClaim.DeepCopy()
+	nodeClaim.Spec.Requirements = append(lo.Reject(nodeClaim.Spec.Requirements, func(r karpv1.NodeSelectorRequirementWithMinValues, _ int) bool {
+		return r.Key == key
+	}), karpv1.NodeSelectorRequirementWithMinValues{NodeSelectorRequirement: corev1.NodeSelectorRequirement{
+		Key:      key,
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   []string{value},
+	}})
+	return nodeClaim
+}
+
+// fullZones returns the zones where the NodePool has as many NodeClaims as the max-nodes-per-zone of the NodePool, and
+// whether the NodePool has a valid max-nodes-per-zone. NodePools opt in by setting the annotation in their template.
+func fullZones(ctx context.Context, annotations map[string]string, zones map[string]int) (sets.Set[string], int, bool) {
+	value, ok := annotations[v1.AnnotationMaxNodesPerZone]
+	if !ok {
+		return nil, 0, false
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 {
+		log.FromContext(ctx).Error(fmt.Errorf("%q is not a positive number", value), fmt.Sprintf("ignoring %s", v1.AnnotationMaxNodesPerZone))
+		return nil, 0, false
+	}
+	return sets.New(lo.Keys(lo.PickBy(zones, func(_ string, count int) bool { return count >= limit }))...), limit, true
+}
+
+// withoutZones marks the offerings in the zones as unavailable, so that provisioning and consolidation only choose
+// offerings in the other zones
+func withoutZones(instanceTypes []*cloudprovider.InstanceType, zones sets.Set[string]) []*cloudprovider.InstanceType {
+	if len(zones) == 0 {
+		return instanceTypes
+	}
+	return withUnavailableOfferings(instanceTypes, func(o cloudprovider.Offering) bool {
+		return zones.Has(o.Requirements.Get(corev1.LabelTopologyZone).Any())
+	})
+}
+
+// withUnavailableOfferings returns copies of the instance types whose offerings that match unavailable are marked as
+// unavailable. The instance types are copied since they're shared through the instance type cache.
+func withUnavailableOfferings(instanceTypes []*cloudprovider.InstanceType, unavailable func(cloudprovider.Offering) bool) []*cloudprovider.InstanceType {
+	return lo.Map(instanceTypes, func(it *cloudprovider.InstanceType, _ int) *cloudprovider.InstanceType {
+		return &cloudprovider.InstanceType{
+			Name:         it.Name,
+			Requirements: it.Requirements,
+			Offerings: lo.Map(it.Offerings, func(o cloudprovider.Offering, _ int) cloudprovider.Offering {
+				o.Available = o.Available && !unavailable(o)
+				return o
+			}),
+			Capacity: it.Capacity,
+			Overhead: it.Overhead,
+		}
+	})
+}
+
+func allowsBothCapacityTypes(requirements scheduling.Requirements) bool {
+	return requirements.Get(karpv1.CapacityTypeLabelKey).Has(karpv1.CapacityTypeSpot) &&
+		requirements.Get(karpv1.CapacityTypeLabelKey).Has(karpv1.CapacityTypeOnDemand)
+}
@@ -17,15 +17,22 @@ package cloudprovider
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
@@ -36,8 +43,85 @@ const (
 	SubnetDrift        cloudprovider.DriftReason = "SubnetDrift"
 	SecurityGroupDrift cloudprovider.DriftReason = "SecurityGroupDrift"
 	NodeClassDrift     cloudprovider.DriftReason = "NodeClassDrift"
+	ZonalShiftDrift    cloudprovider.DriftReason = "ZonalShiftDrift"
+	// SourceDestCheckDrift is the drift of instances whose source/destination check was enabled after Karpenter
+	// disabled it for their EC2NodeClass
+	SourceDestCheckDrift cloudprovider.DriftReason = "SourceDestCheckDrift"
+	// SpotReturnDrift is the drift of on-demand instances that were launched as a fallback from spot, once spot
+	// capacity is available again at a lower price
+	SpotReturnDrift cloudprovider.DriftReason = "SpotReturnDrift"
 )
 
+// isZonalShiftDrifted drifts NodeClaims in zones that the cluster is shifted away from, so that they're replaced in the
+// other zones within the disruption budgets of their NodePool
+func (c *CloudProvider) isZonalShiftDrifted(ctx context.Context, nodeClaim *karpv1.NodeClaim) cloudprovider.DriftReason {
+	if options.FromContext(ctx).ZonalShift != options.ZonalShiftDrift {
+		return ""
+	}
+	if zone, ok := nodeClaim.Labels[corev1.LabelTopologyZone]; !ok || !c.unavailableOfferings.IsZoneUnavailable(zone) {
+		return ""
+	}
+	return ZonalShiftDrift
+}
+
+// isSpotReturnDrifted drifts the on-demand NodeClaims that were launched because spot capacity was unavailable, so that
+// they're replaced with spot within the disruption budgets of their NodePool. NodePools opt in by setting the
+// spot-return-after annotation in their template to the age that fallback NodeClaims must reach before they're
+// replaced, which keeps them from churning while spot capacity recovers. They're only drifted once a spot offering
+// that fits the NodeClaim is available again, i.e. it's no longer in the unavailable offerings cache, and is cheaper
+// than the on-demand price of the instance.
+func (c *CloudProvider) isSpotReturnDrifted(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodeClass *v1.EC2NodeClass) (cloudprovider.DriftReason, error) {
+	if nodeClaim.Annotations[v1.AnnotationSpotFallback] != "true" || nodeClaim.Labels[karpv1.CapacityTypeLabelKey] != karpv1.CapacityTypeOnDemand {
+		return "", nil
+	}
+	value, ok := nodeClaim.Annotations[v1.AnnotationSpotReturnAfter]
+	if !ok {
+		return "", nil
+	}
+	returnAfter, err := time.ParseDuration(value)
+	if err != nil || returnAfter < 0 {
+		log.FromContext(ctx).Error(fmt.Errorf("%q is not a positive duration", value), fmt.Sprintf("ignoring %s", v1.AnnotationSpotReturnAfter))
+		return "", nil
+	}
+	if time.Since(nodeClaim.CreationTimestamp.Time) < returnAfter {
+		return "", nil
+	}
+	kubeletConfig, err := utils.GetKubeletConfigurationWithNodeClaim(nodeClaim, nodeClass)
+	if err != nil {
+		return "", fmt.Errorf("resolving kubelet configuration, %w", err)
+	}
+	instanceTypes, err := c.instanceTypeProvider.List(ctx, kubeletConfig, nodeClass)
+	if err != nil {
+		return "", fmt.Errorf("getting instance types, %w", err)
+	}
+	current, ok := lo.Find(instanceTypes, func(it *cloudprovider.InstanceType) bool {
+		return it.Name == nodeClaim.Labels[corev1.LabelInstanceTypeStable]
+	})
+	if !ok {
+		return "", nil
+	}
+	onDemand := current.Offerings.Compatible(scheduling.NewLabelRequirements(map[string]string{
+		corev1.LabelTopologyZone:    nodeClaim.Labels[corev1.LabelTopologyZone],
+		karpv1.CapacityTypeLabelKey: karpv1.CapacityTypeOnDemand,
+	}))
+	if len(onDemand) == 0 {
+		return "", nil
+	}
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	requirements.Add(scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, karpv1.CapacityTypeSpot))
+	spot := cloudprovider.Offerings(lo.Flatten(lo.FilterMap(instanceTypes, func(it *cloudprovider.InstanceType, _ int) ([]cloudprovider.Offering, bool) {
+		if requirements.Compatible(it.Requirements, scheduling.AllowUndefinedWellKnownLabels) != nil || !resources.Fits(nodeClaim.Spec.Resources.Requests, it.Allocatable()) {
+			return nil, false
+		}
+		offerings := it.Offerings.Compatible(requirements).Available()
+		return offerings, len(offerings) != 0
+	})))
+	if len(spot) == 0 || spot.Cheapest().Price >= onDemand.Cheapest().Price {
+		return "", nil
+	}
+	return SpotReturnDrift, nil
+}
+
 func (c *CloudProvider) isNodeClassDrifted(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodePool *karpv1.NodePool, nodeClass *v1.EC2NodeClass) (cloudprovider.DriftReason, error) {
 	// First check if the node class is statically drifted to save on API calls.
 	if drifted := c.areStaticFieldsDrifted(nodeClaim, nodeClass); drifted != "" {
@@ -63,7 +147,8 @@ func (c *CloudProvider) isNodeClassDrifted(ctx context.Context, nodeClaim *karpv
 	if err != nil {
 		return "", fmt.Errorf("calculating subnet drift, %w", err)
 	}
-	drifted := lo.FindOrElse([]cloudprovider.DriftReason{amiDrifted, securitygroupDrifted, subnetDrifted, kubeletDrifted}, "", func(i cloudprovider.DriftReason) bool {
+	sourceDestCheckDrifted := c.isSourceDestCheckDrifted(nodeClaim, instance, nodeClass)
+	drifted := lo.FindOrElse([]cloudprovider.DriftReason{amiDrifted, securitygroupDrifted, subnetDrifted, sourceDestCheckDrifted, kubeletDrifted}, "", func(i cloudprovider.DriftReason) bool {
 		return string(i) != ""
 	})
 	return drifted, nil
@@ -85,10 +170,40 @@ func (c *CloudProvider) isAMIDrifted(ctx context.Context, nodeClaim *karpv1.Node
 		return "", fmt.Errorf("no amis exist given constraints")
 	}
 	mappedAMIs := amifamily.MapToInstanceTypes([]*cloudprovider.InstanceType{nodeInstanceType}, nodeClass.Status.AMIs)
-	if !lo.Contains(lo.Keys(mappedAMIs), instance.ImageID) {
-		return AMIDrift, nil
+	if lo.Contains(lo.Keys(mappedAMIs), instance.ImageID) {
+		return "", nil
 	}
-	return "", nil
+	if nodeClass.BottlerocketInPlaceUpdates() {
+		updated, err := c.isUpdatedInPlace(ctx, nodeClaim, nodeClass, lo.Keys(mappedAMIs))
+		if err != nil {
+			return "", err
+		}
+		if updated {
+			return "", nil
+		}
+	}
+	return AMIDrift, nil
+}
+
+// isUpdatedInPlace returns true if the Bottlerocket update operator updates the node to the AMIs in place, which is
+// when the AMIs are of the minor version of Bottlerocket that the node runs. The version of the node, rather than of
+// the AMI of its instance, is compared since the instance keeps its AMI when it's updated in place.
+func (c *CloudProvider) isUpdatedInPlace(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodeClass *v1.EC2NodeClass, amiIDs []string) (bool, error) {
+	if nodeClaim.Status.NodeName == "" {
+		return false, nil
+	}
+	node := &corev1.Node{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodeClaim.Status.NodeName}, node); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	nodeVersion, ok := amifamily.BottlerocketMinorVersion(node.Status.NodeInfo.OSImage)
+	if !ok {
+		return false, nil
+	}
+	return lo.ContainsBy(nodeClass.Status.AMIs, func(ami v1.AMI) bool {
+		version, ok := amifamily.BottlerocketMinorVersion(ami.Name)
+		return ok && version == nodeVersion && lo.Contains(amiIDs, ami.ID)
+	}), nil
 }
 
 // Checks if the security groups are drifted, by comparing the subnet returned from the subnetProvider
@@ -123,6 +238,19 @@ func (c *CloudProvider) areSecurityGroupsDrifted(ec2Instance *instance.Instance,
 	return "", nil
 }
 
+// isSourceDestCheckDrifted checks if the source/destination check of an instance whose EC2NodeClass disables it was
+// enabled again outside of Karpenter. Instances are only compared once the check has been disabled for their NodeClaim,
+// which happens along with tagging them, so that instances that are still being launched aren't drifted.
+func (c *CloudProvider) isSourceDestCheckDrifted(nodeClaim *karpv1.NodeClaim, instance *instance.Instance, nodeClass *v1.EC2NodeClass) cloudprovider.DriftReason {
+	if nodeClass.Spec.PrimaryNetworkInterface == nil || lo.FromPtrOr(nodeClass.Spec.PrimaryNetworkInterface.SourceDestCheck, true) {
+		return ""
+	}
+	if nodeClaim.Annotations[v1.AnnotationInstanceTagged] != "true" {
+		return ""
+	}
+	return lo.Ternary(instance.SourceDestCheck, SourceDestCheckDrift, "")
+}
+
 func (c *CloudProvider) areStaticFieldsDrifted(nodeClaim *karpv1.NodeClaim, nodeClass *v1.EC2NodeClass) cloudprovider.DriftReason {
 	nodeClassHash, foundNodeClassHash := nodeClass.Annotations[v1.AnnotationEC2NodeClassHash]
 	nodeClassHashVersion, foundNodeClassHashVersion := nodeClass.Annotations[v1.AnnotationEC2NodeClassHashVersion]
@@ -15,6 +15,9 @@ limitations under the License.
 package events
 
 import (
+	"fmt"
+	"strings"
+
 	corev1 "k8s.io/api/core/v1"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
@@ -30,6 +33,17 @@ func NodePoolFailedToResolveNodeClass(nodePool *v1.NodePool) events.Event {
 	}
 }
 
+func NodePoolZonesFull(nodePool *v1.NodePool, zones []string, limit int) events.Event {
+	return events.Event{
+		InvolvedObject: nodePool,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "ZonesFull",
+		Message: fmt.Sprintf("Zones %s reached the max-nodes-per-zone of %d, scheduling to the other zones",
+			strings.Join(zones, ", "), limit),
+		DedupeValues: append([]string{string(nodePool.UID)}, zones...),
+	}
+}
+
 func NodeClaimFailedToResolveNodeClass(nodeClaim *v1.NodeClaim) events.Event {
 	return events.Event{
 		InvolvedObject: nodeClaim,
@@ -38,3 +52,14 @@ func NodeClaimFailedToResolveNodeClass(nodeClaim *v1.NodeClaim) events.Event {
 		DedupeValues:   []string{string(nodeClaim.UID)},
 	}
 }
+
+func NodeClaimSpotDiversificationLow(nodeClaim *v1.NodeClaim, pools, threshold int) events.Event {
+	return events.Event{
+		InvolvedObject: nodeClaim,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "SpotDiversificationLow",
+		Message: fmt.Sprintf("Spot launch diversified across %d pools, fewer than the spot-diversification-threshold of %d. Relax the instance type and zone requirements of NodePool %s so that spot launches are interrupted less often",
+			pools, threshold, nodeClaim.Labels[v1.NodePoolLabelKey]),
+		DedupeValues: []string{string(nodeClaim.UID)},
+	}
+}
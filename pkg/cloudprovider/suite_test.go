@@ -16,6 +16,7 @@ package cloudprovider_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"strings"
@@ -29,7 +30,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
-	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	clock "k8s.io/utils/clock/testing"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -38,6 +39,7 @@ import (
 	"github.com/imdario/mergo"
 	"github.com/samber/lo"
 
+	"github.com/aws/karpenter-provider-aws/pkg/alerting"
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
@@ -50,7 +52,6 @@ import (
 	corecloudproivder "sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
-	"sigs.k8s.io/karpenter/pkg/events"
 	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 	coretest "sigs.k8s.io/karpenter/pkg/test"
@@ -69,7 +70,7 @@ var prov *provisioning.Provisioner
 var cloudProvider *cloudprovider.CloudProvider
 var cluster *state.Cluster
 var fakeClock *clock.FakeClock
-var recorder events.Recorder
+var recorder *coretest.EventRecorder
 
 func TestAWS(t *testing.T) {
 	ctx = TestContextWithLogger(t)
@@ -84,9 +85,9 @@ var _ = BeforeSuite(func() {
 	ctx, stop = context.WithCancel(ctx)
 	awsEnv = test.NewEnvironment(ctx, env)
 	fakeClock = clock.NewFakeClock(time.Now())
-	recorder = events.NewRecorder(&record.FakeRecorder{})
+	recorder = coretest.NewEventRecorder()
 	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, recorder,
-		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
+		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider, awsEnv.AlertTracker, awsEnv.UnavailableOfferingsCache)
 	cluster = state.NewCluster(fakeClock, env.Client)
 	prov = provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster)
 })
@@ -102,6 +103,7 @@ var _ = BeforeEach(func() {
 
 	cluster.Reset()
 	awsEnv.Reset()
+	recorder.Reset()
 
 	awsEnv.LaunchTemplateProvider.KubeDNSIP = net.ParseIP("10.0.100.10")
 	awsEnv.LaunchTemplateProvider.ClusterEndpoint = "https://test-cluster"
@@ -241,6 +243,14 @@ var _ = Describe("CloudProvider", func() {
 		Expect(ok).To(BeTrue())
 		Expect(zoneID).To(Equal(subnet.ZoneID))
 	})
+	It("should label the nodeClaim for the Bottlerocket update operator when it updates the nodes in place", func() {
+		nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "bottlerocket@latest"}}
+		nodeClass.Spec.Bottlerocket = &v1.BottlerocketConfiguration{UpdateStrategy: lo.ToPtr(v1.BottlerocketUpdateStrategyInPlace)}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+		cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cloudProviderNodeClaim.Labels).To(HaveKeyWithValue(v1.LabelBottlerocketUpdaterInterfaceVersion, v1.BottlerocketUpdaterInterfaceVersion))
+	})
 	It("should return NodeClass Hash on the nodeClaim", func() {
 		ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
 		cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
@@ -258,6 +268,289 @@ var _ = Describe("CloudProvider", func() {
 		Expect(ok).To(BeTrue())
 		Expect(v).To(Equal(v1.EC2NodeClassHashVersion))
 	})
+	It("should return the subnet the instance was launched in on the nodeClaim", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+		cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+		Expect(err).To(BeNil())
+		zone := cloudProviderNodeClaim.Labels[corev1.LabelTopologyZone]
+		subnetIDs := lo.FilterMap(nodeClass.Status.Subnets, func(s v1.Subnet, _ int) (string, bool) { return s.ID, s.Zone == zone })
+		Expect(subnetIDs).To(ContainElement(cloudProviderNodeClaim.Annotations[v1.AnnotationSubnetID]))
+	})
+	It("should return the spot and on-demand prices at launch on a spot nodeClaim", func() {
+		nodeClaim.Spec.Requirements[0].Values = []string{karpv1.CapacityTypeSpot}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+		cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+		Expect(err).To(BeNil())
+		Expect(cloudProviderNodeClaim.Labels).To(HaveKeyWithValue(karpv1.CapacityTypeLabelKey, karpv1.CapacityTypeSpot))
+		instanceType := cloudProviderNodeClaim.Labels[corev1.LabelInstanceTypeStable]
+		zone := cloudProviderNodeClaim.Labels[corev1.LabelTopologyZone]
+		spotPrice, ok := awsEnv.PricingProvider.LatestSpotPrice(instanceType, zone)
+		Expect(ok).To(BeTrue())
+		onDemandPrice, ok := awsEnv.PricingProvider.OnDemandPrice(instanceType)
+		Expect(ok).To(BeTrue())
+		Expect(cloudProviderNodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationLaunchSpotPrice, fmt.Sprint(spotPrice)))
+		Expect(cloudProviderNodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationLaunchOnDemandPrice, fmt.Sprint(onDemandPrice)))
+	})
+	It("should return the spot fallback annotation on a nodeClaim that launched on-demand when spot was unavailable", func() {
+		nodeClaim.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeSpot, karpv1.CapacityTypeOnDemand}}},
+			{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"m5.large"}}},
+		}
+		for _, zone := range []string{"test-zone-1a", "test-zone-1b", "test-zone-1c"} {
+			awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "InsufficientInstanceCapacity", "m5.large", zone, karpv1.CapacityTypeSpot)
+		}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+		cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+		Expect(err).To(BeNil())
+		Expect(cloudProviderNodeClaim.Labels).To(HaveKeyWithValue(karpv1.CapacityTypeLabelKey, karpv1.CapacityTypeOnDemand))
+		Expect(cloudProviderNodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationSpotFallback, "true"))
+	})
+	It("should not return the spot fallback annotation on a nodeClaim that only allows on-demand", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+		cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+		Expect(err).To(BeNil())
+		Expect(cloudProviderNodeClaim.Annotations).ToNot(HaveKey(v1.AnnotationSpotFallback))
+	})
+	Context("Capacity Type Distribution", func() {
+		var launched *karpv1.NodeClaim
+		BeforeEach(func() {
+			nodePool.Spec.Template.Annotations = map[string]string{v1.AnnotationSpotPercentage: "50"}
+			nodePool.Spec.Template.Spec.Requirements[0].Values = []string{karpv1.CapacityTypeSpot, karpv1.CapacityTypeOnDemand}
+			nodeClaim.Annotations = map[string]string{v1.AnnotationSpotPercentage: "50"}
+			nodeClaim.Spec.Requirements[0].Values = []string{karpv1.CapacityTypeSpot, karpv1.CapacityTypeOnDemand}
+			launched = coretest.NodeClaim(karpv1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{karpv1.NodePoolLabelKey: nodePool.Name}}})
+		})
+		It("should launch spot while the spot nodeClaims of the nodePool are below their share", func() {
+			launched.Labels[karpv1.CapacityTypeLabelKey] = karpv1.CapacityTypeOnDemand
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim, launched)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			Expect(cloudProviderNodeClaim.Labels).To(HaveKeyWithValue(karpv1.CapacityTypeLabelKey, karpv1.CapacityTypeSpot))
+		})
+		It("should launch on-demand once the spot nodeClaims of the nodePool reach their share", func() {
+			launched.Labels[karpv1.CapacityTypeLabelKey] = karpv1.CapacityTypeSpot
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim, launched)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			Expect(cloudProviderNodeClaim.Labels).To(HaveKeyWithValue(karpv1.CapacityTypeLabelKey, karpv1.CapacityTypeOnDemand))
+			// On-demand launches for the distribution aren't a fallback from spot
+			Expect(cloudProviderNodeClaim.Annotations).ToNot(HaveKey(v1.AnnotationSpotFallback))
+		})
+		It("should only return available offerings of the target capacity type", func() {
+			launched.Labels[karpv1.CapacityTypeLabelKey] = karpv1.CapacityTypeSpot
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, launched)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			for _, it := range instanceTypes {
+				for _, o := range it.Offerings.Available() {
+					Expect(o.Requirements.Get(karpv1.CapacityTypeLabelKey).Any()).To(Equal(karpv1.CapacityTypeOnDemand))
+				}
+			}
+		})
+		It("should launch spot when no on-demand offering is available", func() {
+			launched.Labels[karpv1.CapacityTypeLabelKey] = karpv1.CapacityTypeSpot
+			nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"m5.large"}},
+			})
+			for _, zone := range []string{"test-zone-1a", "test-zone-1b", "test-zone-1c"} {
+				awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "InsufficientInstanceCapacity", "m5.large", zone, karpv1.CapacityTypeOnDemand)
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim, launched)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			Expect(cloudProviderNodeClaim.Labels).To(HaveKeyWithValue(karpv1.CapacityTypeLabelKey, karpv1.CapacityTypeSpot))
+		})
+		It("should count the nodeClaims that are launched in parallel", func() {
+			nodeClaims := lo.Times(4, func(_ int) *karpv1.NodeClaim {
+				return coretest.NodeClaim(karpv1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: nodeClaim.Labels, Annotations: nodeClaim.Annotations}, Spec: *nodeClaim.Spec.DeepCopy()})
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			for _, nc := range nodeClaims {
+				ExpectApplied(ctx, env.Client, nc)
+			}
+			launched := make([]*karpv1.NodeClaim, len(nodeClaims))
+			workqueue.ParallelizeUntil(ctx, len(nodeClaims), len(nodeClaims), func(i int) {
+				defer GinkgoRecover()
+				var err error
+				launched[i], err = cloudProvider.Create(ctx, nodeClaims[i])
+				Expect(err).ToNot(HaveOccurred())
+			})
+			Expect(lo.CountValuesBy(launched, func(nc *karpv1.NodeClaim) string { return nc.Labels[karpv1.CapacityTypeLabelKey] })).To(Equal(map[string]int{
+				karpv1.CapacityTypeSpot:     2,
+				karpv1.CapacityTypeOnDemand: 2,
+			}))
+		})
+		It("should ignore invalid percentages", func() {
+			nodePool.Spec.Template.Annotations[v1.AnnotationSpotPercentage] = "150"
+			launched.Labels[karpv1.CapacityTypeLabelKey] = karpv1.CapacityTypeSpot
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, launched)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lo.ContainsBy(instanceTypes, func(it *corecloudproivder.InstanceType) bool {
+				return lo.ContainsBy(it.Offerings.Available(), func(o corecloudproivder.Offering) bool {
+					return o.Requirements.Get(karpv1.CapacityTypeLabelKey).Any() == karpv1.CapacityTypeSpot
+				})
+			})).To(BeTrue())
+		})
+	})
+	Context("Max Nodes Per Zone", func() {
+		var launched *karpv1.NodeClaim
+		BeforeEach(func() {
+			nodePool.Spec.Template.Annotations = map[string]string{v1.AnnotationMaxNodesPerZone: "1"}
+			nodeClaim.Annotations = map[string]string{v1.AnnotationMaxNodesPerZone: "1"}
+			launched = coretest.NodeClaim(karpv1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+				karpv1.NodePoolLabelKey:  nodePool.Name,
+				corev1.LabelTopologyZone: "test-zone-1a",
+			}}})
+		})
+		It("should launch into the zones that haven't reached the limit", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim, launched)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			Expect(cloudProviderNodeClaim.Labels[corev1.LabelTopologyZone]).ToNot(Equal("test-zone-1a"))
+		})
+		It("should return an ICE error when every requested zone reached the limit", func() {
+			nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-1a"}},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim, launched)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(corecloudproivder.IsInsufficientCapacityError(err)).To(BeTrue())
+		})
+		It("should only return available offerings in the zones that haven't reached the limit", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, launched)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			for _, it := range instanceTypes {
+				for _, o := range it.Offerings.Available() {
+					Expect(o.Requirements.Get(corev1.LabelTopologyZone).Any()).ToNot(Equal("test-zone-1a"))
+				}
+			}
+		})
+		It("should count the nodeClaims that are launched in parallel", func() {
+			nodeClaims := lo.Times(4, func(_ int) *karpv1.NodeClaim {
+				return coretest.NodeClaim(karpv1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: nodeClaim.Labels, Annotations: nodeClaim.Annotations}, Spec: *nodeClaim.Spec.DeepCopy()})
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			for _, nc := range nodeClaims {
+				ExpectApplied(ctx, env.Client, nc)
+			}
+			launched := make([]*karpv1.NodeClaim, len(nodeClaims))
+			errs := make([]error, len(nodeClaims))
+			workqueue.ParallelizeUntil(ctx, len(nodeClaims), len(nodeClaims), func(i int) {
+				launched[i], errs[i] = cloudProvider.Create(ctx, nodeClaims[i])
+			})
+			// Three zones with a limit of one node each fit three of the four nodeClaims
+			Expect(lo.Count(errs, nil)).To(Equal(3))
+			Expect(corecloudproivder.IsInsufficientCapacityError(lo.FindOrElse(errs, nil, func(err error) bool { return err != nil }))).To(BeTrue())
+			Expect(lo.FilterMap(launched, func(nc *karpv1.NodeClaim, _ int) (string, bool) {
+				if nc == nil {
+					return "", false
+				}
+				return nc.Labels[corev1.LabelTopologyZone], true
+			})).To(ConsistOf("test-zone-1a", "test-zone-1b", "test-zone-1c"))
+		})
+		It("should only publish the full zones when they change", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, launched)
+			for range 3 {
+				_, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(recorder.Calls("ZonesFull")).To(Equal(1))
+		})
+		It("should ignore invalid limits", func() {
+			nodePool.Spec.Template.Annotations[v1.AnnotationMaxNodesPerZone] = "0"
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, launched)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lo.ContainsBy(instanceTypes, func(it *corecloudproivder.InstanceType) bool {
+				return lo.ContainsBy(it.Offerings.Available(), func(o corecloudproivder.Offering) bool {
+					return o.Requirements.Get(corev1.LabelTopologyZone).Any() == "test-zone-1a"
+				})
+			})).To(BeTrue())
+		})
+	})
+	It("should not return launch prices on an on-demand nodeClaim", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+		cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+		Expect(err).To(BeNil())
+		Expect(cloudProviderNodeClaim.Labels).To(HaveKeyWithValue(karpv1.CapacityTypeLabelKey, karpv1.CapacityTypeOnDemand))
+		Expect(cloudProviderNodeClaim.Annotations).ToNot(HaveKey(v1.AnnotationLaunchSpotPrice))
+		Expect(cloudProviderNodeClaim.Annotations).ToNot(HaveKey(v1.AnnotationLaunchOnDemandPrice))
+	})
+	Context("Alerting", func() {
+		It("should alert once a NodePool reaches the threshold of consecutive launch failures", func() {
+			nodeClass.StatusConditions().SetFalse(opstatus.ConditionReady, "NodeClassNotReady", "NodeClass not ready")
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			for range options.FromContext(ctx).AlertThreshold {
+				_, err := cloudProvider.Create(ctx, nodeClaim)
+				Expect(err).To(HaveOccurred())
+			}
+			Eventually(awsEnv.SNSAPI.PublishBehavior.CalledWithInput.Len).Should(Equal(1))
+
+			alert := alerting.Alert{}
+			Expect(json.Unmarshal([]byte(aws.StringValue(awsEnv.SNSAPI.PublishBehavior.CalledWithInput.Pop().Message)), &alert)).To(Succeed())
+			Expect(alert.Reason).To(Equal(alerting.ReasonLaunchFailures))
+			Expect(alert.NodePool).To(Equal(nodePool.Name))
+			Expect(alert.Errors).To(Equal(map[string]int{"resolving ec2nodeclass, NodeClass not ready": options.FromContext(ctx).AlertThreshold}))
+		})
+		It("should not alert when a launch succeeds before the threshold is reached", func() {
+			nodeClass.StatusConditions().SetFalse(opstatus.ConditionReady, "NodeClassNotReady", "NodeClass not ready")
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			for range options.FromContext(ctx).AlertThreshold - 1 {
+				_, err := cloudProvider.Create(ctx, nodeClaim)
+				Expect(err).To(HaveOccurred())
+			}
+			nodeClass.StatusConditions().SetTrue(opstatus.ConditionReady)
+			ExpectApplied(ctx, env.Client, nodeClass)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+
+			nodeClass.StatusConditions().SetFalse(opstatus.ConditionReady, "NodeClassNotReady", "NodeClass not ready")
+			ExpectApplied(ctx, env.Client, nodeClass)
+			_, err = cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(HaveOccurred())
+			Consistently(awsEnv.SNSAPI.PublishBehavior.CalledWithInput.Len).Should(BeZero())
+		})
+	})
+	Context("Assume Role", func() {
+		roleARN := "arn:aws:iam::123456789012:role/team-a"
+		It("should scope the launch templates of the EC2NodeClass to its role", func() {
+			nodeClass.Spec.AssumeRoleARN = aws.String(roleARN)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.LaunchTemplateCache.Items()).ToNot(BeEmpty())
+			for key := range awsEnv.LaunchTemplateCache.Items() {
+				Expect(key).To(HaveSuffix("|" + roleARN))
+			}
+		})
+		It("should list instances with each distinct role and deduplicate them", func() {
+			awsEnv.EC2API.Instances.Store("i-0123456789abcdef0", &ec2.Instance{
+				InstanceId:     aws.String("i-0123456789abcdef0"),
+				InstanceType:   aws.String("m5.large"),
+				PrivateDnsName: aws.String(fake.PrivateDNSName()),
+				Placement:      &ec2.Placement{AvailabilityZone: aws.String("test-zone-1a")},
+				State:          &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)},
+				Tags: []*ec2.Tag{
+					{Key: aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)), Value: aws.String("owned")},
+					{Key: aws.String(karpv1.NodePoolLabelKey), Value: aws.String(nodePool.Name)},
+					{Key: aws.String(v1.LabelNodeClass), Value: aws.String(nodeClass.Name)},
+				},
+			})
+			roleNodeClasses := []*v1.EC2NodeClass{
+				test.EC2NodeClass(v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{AssumeRoleARN: aws.String(roleARN)}}),
+				test.EC2NodeClass(v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{AssumeRoleARN: aws.String(roleARN)}}),
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, roleNodeClasses[0], roleNodeClasses[1])
+			nodeClaims, err := cloudProvider.List(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nodeClaims).To(HaveLen(1))
+			Expect(awsEnv.EC2API.DescribeInstancesBehavior.CalledWithInput.Len()).To(Equal(2))
+			ExpectDeleted(ctx, env.Client, roleNodeClasses[0], roleNodeClasses[1])
+		})
+	})
 	Context("EC2 Context", func() {
 		contextID := "context-1234"
 		It("should set context on the CreateFleet request if specified on the NodePool", func() {
@@ -777,6 +1070,42 @@ var _ = Describe("CloudProvider", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(isDrifted).To(Equal(cloudprovider.SubnetDrift))
 		})
+		It("should return drifted if the source/destination check was enabled after it was disabled", func() {
+			nodeClass.Spec.PrimaryNetworkInterface = &v1.PrimaryNetworkInterface{SourceDestCheck: lo.ToPtr(false)}
+			nodeClass.Annotations = lo.Assign(nodeClass.Annotations, map[string]string{v1.AnnotationEC2NodeClassHash: nodeClass.Hash()})
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+				v1.AnnotationEC2NodeClassHash: nodeClass.Hash(),
+				v1.AnnotationInstanceTagged:   "true",
+			})
+			ExpectApplied(ctx, env.Client, nodeClass)
+			instance.SourceDestCheck = aws.Bool(true)
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(Equal(cloudprovider.SourceDestCheckDrift))
+		})
+		It("should not return drifted if the source/destination check hasn't been disabled yet", func() {
+			nodeClass.Spec.PrimaryNetworkInterface = &v1.PrimaryNetworkInterface{SourceDestCheck: lo.ToPtr(false)}
+			nodeClass.Annotations = lo.Assign(nodeClass.Annotations, map[string]string{v1.AnnotationEC2NodeClassHash: nodeClass.Hash()})
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationEC2NodeClassHash: nodeClass.Hash()})
+			ExpectApplied(ctx, env.Client, nodeClass)
+			instance.SourceDestCheck = aws.Bool(true)
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(BeEmpty())
+		})
+		It("should not return drifted if the source/destination check is disabled", func() {
+			nodeClass.Spec.PrimaryNetworkInterface = &v1.PrimaryNetworkInterface{SourceDestCheck: lo.ToPtr(false)}
+			nodeClass.Annotations = lo.Assign(nodeClass.Annotations, map[string]string{v1.AnnotationEC2NodeClassHash: nodeClass.Hash()})
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+				v1.AnnotationEC2NodeClassHash: nodeClass.Hash(),
+				v1.AnnotationInstanceTagged:   "true",
+			})
+			ExpectApplied(ctx, env.Client, nodeClass)
+			instance.SourceDestCheck = aws.Bool(false)
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(BeEmpty())
+		})
 		It("should return an error if subnets are empty", func() {
 			awsEnv.SubnetCache.Flush()
 			nodeClass.Status.Subnets = []v1.Subnet{}
@@ -789,6 +1118,90 @@ var _ = Describe("CloudProvider", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(isDrifted).To(BeEmpty())
 		})
+		It("should return drifted if the zone of the NodeClaim is shifted away from with zonal shift drift enabled", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ZonalShift: lo.ToPtr(options.ZonalShiftDrift)}))
+			nodeClaim.Labels = lo.Assign(nodeClaim.Labels, map[string]string{corev1.LabelTopologyZone: "test-zone-1a"})
+			awsEnv.UnavailableOfferingsCache.MarkZoneUnavailable(ctx, "ZonalShift", "test-zone-1a", time.Now().Add(time.Hour))
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(Equal(cloudprovider.ZonalShiftDrift))
+		})
+		It("should not return drifted if the zone of the NodeClaim is shifted away from with zonal shift drift disabled", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ZonalShift: lo.ToPtr(options.ZonalShiftAvoid)}))
+			nodeClaim.Labels = lo.Assign(nodeClaim.Labels, map[string]string{corev1.LabelTopologyZone: "test-zone-1a"})
+			awsEnv.UnavailableOfferingsCache.MarkZoneUnavailable(ctx, "ZonalShift", "test-zone-1a", time.Now().Add(time.Hour))
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(BeEmpty())
+		})
+		Context("Spot Return", func() {
+			BeforeEach(func() {
+				nodeClaim.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+					{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeSpot, karpv1.CapacityTypeOnDemand}}},
+					{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"m5.large"}}},
+				}
+				nodeClaim.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+				nodeClaim.Labels = lo.Assign(nodeClaim.Labels, map[string]string{
+					karpv1.CapacityTypeLabelKey:    karpv1.CapacityTypeOnDemand,
+					corev1.LabelTopologyZone:       "test-zone-1a",
+					corev1.LabelInstanceTypeStable: "m5.large",
+				})
+				nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+					v1.AnnotationSpotFallback:    "true",
+					v1.AnnotationSpotReturnAfter: "30m",
+				})
+				// The offerings of the NodeClaim are only available in the zones of the subnets of the EC2NodeClass
+				nodeClass.Status.Subnets = append(nodeClass.Status.Subnets, v1.Subnet{ID: "subnet-test1", Zone: "test-zone-1a", ZoneID: "tstz1-1a"})
+				ExpectApplied(ctx, env.Client, nodeClass)
+				// Spot is cheaper than on-demand again
+				awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+					SpotPriceHistory: []*ec2.SpotPrice{{
+						AvailabilityZone: aws.String("test-zone-1a"),
+						InstanceType:     aws.String("m5.large"),
+						SpotPrice:        aws.String("0.001"),
+						Timestamp:        lo.ToPtr(time.Now()),
+					}},
+				})
+				Expect(awsEnv.PricingProvider.UpdateSpotPricing(ctx)).To(Succeed())
+			})
+			It("should return drifted if spot is available for a NodeClaim that fell back to on-demand", func() {
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(Equal(cloudprovider.SpotReturnDrift))
+			})
+			It("should not return drifted if the NodeClaim is younger than the spot return duration", func() {
+				nodeClaim.Annotations[v1.AnnotationSpotReturnAfter] = "2h"
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(BeEmpty())
+			})
+			It("should not return drifted if the NodePool doesn't opt in to returning to spot", func() {
+				delete(nodeClaim.Annotations, v1.AnnotationSpotReturnAfter)
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(BeEmpty())
+			})
+			It("should not return drifted if the spot return duration is invalid", func() {
+				nodeClaim.Annotations[v1.AnnotationSpotReturnAfter] = "soon"
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(BeEmpty())
+			})
+			It("should not return drifted if the NodeClaim didn't fall back from spot", func() {
+				delete(nodeClaim.Annotations, v1.AnnotationSpotFallback)
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(BeEmpty())
+			})
+			It("should not return drifted if spot is still unavailable", func() {
+				for _, zone := range []string{"test-zone-1a", "test-zone-1b", "test-zone-1c"} {
+					awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "InsufficientInstanceCapacity", "m5.large", zone, karpv1.CapacityTypeSpot)
+				}
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(BeEmpty())
+			})
+		})
 		It("should return an error if the security groups are empty", func() {
 			nodeClass.Status.SecurityGroups = []v1.SecurityGroup{}
 			ExpectApplied(ctx, env.Client, nodeClass)
@@ -866,6 +1279,44 @@ var _ = Describe("CloudProvider", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(isDrifted).To(Equal(cloudprovider.AMIDrift))
 		})
+		Context("Bottlerocket In-Place Updates", func() {
+			var node *corev1.Node
+			BeforeEach(func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "bottlerocket@latest"}}
+				nodeClass.Spec.Bottlerocket = &v1.BottlerocketConfiguration{UpdateStrategy: lo.ToPtr(v1.BottlerocketUpdateStrategyInPlace)}
+				nodeClass.Status.AMIs[1].Name = "bottlerocket-aws-k8s-1.30-x86_64-v1.20.3-5d9ac849"
+				node = coretest.Node(coretest.NodeOptions{ProviderID: nodeClaim.Status.ProviderID})
+				node.Status.NodeInfo.OSImage = "Bottlerocket OS 1.20.1 (aws-k8s-1.30)"
+				nodeClaim.Status.NodeName = node.Name
+				instance.ImageId = aws.String(fake.ImageID())
+				ExpectApplied(ctx, env.Client, nodeClass, node)
+			})
+			It("should not return drifted if the node is updated in place to the AMI of a newer patch version", func() {
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(BeEmpty())
+			})
+			It("should return drifted if the AMI is of a newer minor version", func() {
+				node.Status.NodeInfo.OSImage = "Bottlerocket OS 1.19.5 (aws-k8s-1.30)"
+				ExpectApplied(ctx, env.Client, node)
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(Equal(cloudprovider.AMIDrift))
+			})
+			It("should return drifted if the nodes are replaced when the AMIs change", func() {
+				nodeClass.Spec.Bottlerocket.UpdateStrategy = lo.ToPtr(v1.BottlerocketUpdateStrategyReplace)
+				ExpectApplied(ctx, env.Client, nodeClass)
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(Equal(cloudprovider.AMIDrift))
+			})
+			It("should return drifted if the node hasn't registered", func() {
+				nodeClaim.Status.NodeName = ""
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(Equal(cloudprovider.AMIDrift))
+			})
+		})
 		Context("Static Drift Detection", func() {
 			BeforeEach(func() {
 				armRequirements := []corev1.NodeSelectorRequirement{
@@ -964,6 +1415,8 @@ var _ = Describe("CloudProvider", func() {
 				Entry("Tags", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{Tags: map[string]string{"keyTag-test-3": "valueTag-test-3"}}}),
 				Entry("Context", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{Context: lo.ToPtr("context-2")}}),
 				Entry("DetailedMonitoring", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{DetailedMonitoring: aws.Bool(true)}}),
+				Entry("CreditSpecification", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{CreditSpecification: lo.ToPtr(v1.CreditSpecificationStandard)}}),
+				Entry("PodMetadataAccess", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{PodMetadataAccess: lo.ToPtr(v1.PodMetadataAccessBlocked)}}),
 				Entry("InstanceStorePolicy", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{InstanceStorePolicy: lo.ToPtr(v1.InstanceStorePolicyRAID0)}}),
 				Entry("AssociatePublicIPAddress", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{AssociatePublicIPAddress: lo.ToPtr(true)}}),
 				Entry("MetadataOptions HTTPEndpoint", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{MetadataOptions: &v1.MetadataOptions{HTTPEndpoint: lo.ToPtr("enabled")}}}),
@@ -1108,7 +1561,7 @@ var _ = Describe("CloudProvider", func() {
 				{SubnetId: aws.String("test-subnet-2"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), AvailableIpAddressCount: aws.Int64(100),
 					Tags: []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-2")}}},
 			}})
-			controller := status.NewController(env.Client, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider)
+			controller := status.NewController(env.Client, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider, awsEnv.ImpairedZonesCache, awsEnv.EC2API, awsEnv.EBSEncryptionCache, awsEnv.ServiceQuotasAPI, awsEnv.QuotaUsageCache)
 			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
 			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
 			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{corev1.LabelTopologyZone: "test-zone-1a"}})
@@ -1125,7 +1578,7 @@ var _ = Describe("CloudProvider", func() {
 				{SubnetId: aws.String("test-subnet-2"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), AvailableIpAddressCount: aws.Int64(11),
 					Tags: []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-2")}}},
 			}})
-			controller := status.NewController(env.Client, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider)
+			controller := status.NewController(env.Client, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider, awsEnv.ImpairedZonesCache, awsEnv.EC2API, awsEnv.EBSEncryptionCache, awsEnv.ServiceQuotasAPI, awsEnv.QuotaUsageCache)
 			nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{
 				MaxPods: aws.Int32(1),
 			}
@@ -1166,7 +1619,7 @@ var _ = Describe("CloudProvider", func() {
 			}})
 			nodeClass.Spec.SubnetSelectorTerms = []v1.SubnetSelectorTerm{{Tags: map[string]string{"Name": "test-subnet-1"}}}
 			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
-			controller := status.NewController(env.Client, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider)
+			controller := status.NewController(env.Client, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider, awsEnv.ImpairedZonesCache, awsEnv.EC2API, awsEnv.EBSEncryptionCache, awsEnv.ServiceQuotasAPI, awsEnv.QuotaUsageCache)
 			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
 			podSubnet1 := coretest.UnschedulablePod()
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, podSubnet1)
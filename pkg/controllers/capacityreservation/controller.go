@@ -0,0 +1,326 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacityreservation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// pollPeriod is how often the reservations are reconciled, since the capacity of a reservation that failed to be
+// created may be available later
+const pollPeriod = time.Minute
+
+// Controller maintains the On-Demand Capacity Reservations of the NodePools that declare a capacity floor with the
+// karpenter.k8s.aws/capacity-floor annotation, like "m5.xlarge=6,c5.2xlarge=2". The instances of each instance type
+// are reserved evenly across the zones of the NodePool, so that most of the floor is kept when a zone is impaired.
+// The reservations are open, and on-demand instances are launched into unused reservations first, so the reserved
+// capacity is used by the NodePool. The reservations of NodePools that no longer declare them are cancelled, while the
+// reservations of NodePools whose floor can't be resolved are kept as they are.
+type Controller struct {
+	kubeClient client.Client
+	recorder   events.Recorder
+	ec2api     ec2iface.EC2API
+}
+
+func NewController(kubeClient client.Client, recorder events.Recorder, ec2api ec2iface.EC2API) *Controller {
+	return &Controller{
+		kubeClient: kubeClient,
+		recorder:   recorder,
+		ec2api:     ec2api,
+	}
+}
+
+// pool is the instance type and zone of the reservations of a NodePool
+type pool struct {
+	nodePool     string
+	instanceType string
+	zone         string
+}
+
+// reservation is the capacity that's reserved for a pool
+type reservation struct {
+	nodePool *karpv1.NodePool
+	platform string
+	count    int64
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "capacityreservation")
+
+	desired, unresolved, err := c.desired(ctx)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	existing, err := c.reservations(ctx)
+	if err != nil {
+		// The permissions of the controller are only required once a NodePool declares a capacity floor
+		if len(desired) == 0 && len(unresolved) == 0 && awserrors.IsAccessDenied(err) {
+			return reconcile.Result{RequeueAfter: pollPeriod}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("describing capacity reservations, %w", err)
+	}
+	var errs []error
+	reserved := map[pool]bool{}
+	for _, cr := range existing {
+		p := pool{
+			nodePool:     lo.SliceToMap(cr.Tags, func(t *ec2.Tag) (string, string) { return aws.StringValue(t.Key), aws.StringValue(t.Value) })[karpv1.NodePoolLabelKey],
+			instanceType: aws.StringValue(cr.InstanceType),
+			zone:         aws.StringValue(cr.AvailabilityZone),
+		}
+		r, ok := desired[p]
+		switch {
+		// The reservations of a NodePool whose floor can't be resolved, e.g. because the annotation is invalid or the
+		// EC2NodeClass temporarily has no subnets in the zones of the NodePool, are kept until the floor is fixed
+		case unresolved.Has(p.nodePool):
+		// Duplicate reservations of a pool are cancelled along with the reservations that are no longer declared
+		case !ok || reserved[p]:
+			errs = append(errs, c.cancel(ctx, cr))
+		case r.count != aws.Int64Value(cr.TotalInstanceCount):
+			errs = append(errs, c.modify(ctx, cr, r))
+		}
+		reserved[p] = true
+	}
+	for p, r := range desired {
+		if !reserved[p] {
+			c.create(ctx, p, r)
+		}
+	}
+	if err := multierr.Combine(errs...); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: pollPeriod}, nil
+}
+
+// desired returns the reservations that are declared by the capacity floors of the NodePools, and the NodePools that
+// declare a capacity floor that can't be resolved. Reservations are only cancelled when their NodePool no longer
+// declares a capacity floor, so the existing reservations of these NodePools are kept, and the reason is published.
+func (c *Controller) desired(ctx context.Context) (map[pool]reservation, sets.Set[string], error) {
+	nodePools := &karpv1.NodePoolList{}
+	if err := c.kubeClient.List(ctx, nodePools); err != nil {
+		return nil, nil, fmt.Errorf("listing nodepools, %w", err)
+	}
+	desired, unresolved := map[pool]reservation{}, sets.New[string]()
+	for i := range nodePools.Items {
+		nodePool := &nodePools.Items[i]
+		value, ok := nodePool.Annotations[v1.AnnotationCapacityFloor]
+		if !ok || !nodePool.DeletionTimestamp.IsZero() {
+			continue
+		}
+		reservations, reason, err := c.resolve(ctx, nodePool, value)
+		if err != nil {
+			return nil, nil, err
+		}
+		if reason != "" {
+			log.FromContext(ctx).WithValues("NodePool", klog.KObj(nodePool), "reason", reason).V(1).Info("keeping capacity reservations of unresolved capacity floor")
+			c.recorder.Publish(InvalidCapacityFloorEvent(nodePool, reason))
+			unresolved.Insert(nodePool.Name)
+			continue
+		}
+		for p, r := range reservations {
+			desired[p] = r
+		}
+	}
+	return desired, unresolved, nil
+}
+
+// resolve returns the reservations of the capacity floor of a NodePool, or the reason that they can't be resolved
+func (c *Controller) resolve(ctx context.Context, nodePool *karpv1.NodePool, value string) (map[pool]reservation, string, error) {
+	floor, err := ParseFloor(value)
+	if err != nil {
+		return nil, err.Error(), nil
+	}
+	nodeClass, err := c.nodeClass(ctx, nodePool)
+	if err != nil {
+		return nil, "", err
+	}
+	if nodeClass == nil {
+		return nil, "the nodepool doesn't reference an existing ec2nodeclass", nil
+	}
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodePool.Spec.Template.Spec.Requirements...)
+	if !requirements.Get(karpv1.CapacityTypeLabelKey).Has(karpv1.CapacityTypeOnDemand) {
+		return nil, "the nodepool doesn't allow on-demand capacity", nil
+	}
+	if instanceType, ok := lo.Find(lo.Keys(floor), func(instanceType string) bool {
+		return !requirements.Get(corev1.LabelInstanceTypeStable).Has(instanceType)
+	}); ok {
+		return nil, fmt.Sprintf("the nodepool doesn't allow instance type %s", instanceType), nil
+	}
+	zones := lo.Uniq(lo.FilterMap(nodeClass.Status.Subnets, func(s v1.Subnet, _ int) (string, bool) {
+		return s.Zone, requirements.Get(corev1.LabelTopologyZone).Has(s.Zone)
+	}))
+	sort.Strings(zones)
+	if len(zones) == 0 {
+		return nil, "none of the subnets of the nodeclass are in the zones of the nodepool", nil
+	}
+	platform := ec2.CapacityReservationInstancePlatformLinuxUnix
+	if lo.Contains([]string{v1.AMIFamilyWindows2019, v1.AMIFamilyWindows2022}, nodeClass.AMIFamily()) {
+		platform = ec2.CapacityReservationInstancePlatformWindows
+	}
+	reservations := map[pool]reservation{}
+	for instanceType, count := range floor {
+		// The remainder of the count is reserved in the first zones, so that the zones differ by at most one instance
+		for i, zone := range zones {
+			zonal := count / int64(len(zones))
+			if int64(i) < count%int64(len(zones)) {
+				zonal++
+			}
+			if zonal > 0 {
+				reservations[pool{nodePool: nodePool.Name, instanceType: instanceType, zone: zone}] = reservation{nodePool: nodePool, platform: platform, count: zonal}
+			}
+		}
+	}
+	return reservations, "", nil
+}
+
+// nodeClass returns the EC2NodeClass of the NodePool, or nil if the NodePool references another kind of node class or
+// its EC2NodeClass doesn't exist
+func (c *Controller) nodeClass(ctx context.Context, nodePool *karpv1.NodePool) (*v1.EC2NodeClass, error) {
+	ref := nodePool.Spec.Template.Spec.NodeClassRef
+	if ref == nil || ref.Group != object.GVK(&v1.EC2NodeClass{}).Group || ref.Kind != object.GVK(&v1.EC2NodeClass{}).Kind {
+		return nil, nil
+	}
+	nodeClass := &v1.EC2NodeClass{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: ref.Name}, nodeClass); err != nil {
+		return nil, client.IgnoreNotFound(fmt.Errorf("getting ec2nodeclass, %w", err))
+	}
+	return nodeClass, nil
+}
+
+// reservations returns the reservations that the controller created for the cluster, which haven't been cancelled
+func (c *Controller) reservations(ctx context.Context) ([]*ec2.CapacityReservation, error) {
+	var reservations []*ec2.CapacityReservation
+	if err := c.ec2api.DescribeCapacityReservationsPagesWithContext(ctx, &ec2.DescribeCapacityReservationsInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String(fmt.Sprintf("tag:%s", karpv1.ManagedByAnnotationKey)), Values: aws.StringSlice([]string{options.FromContext(ctx).ClusterName})},
+			{Name: aws.String(fmt.Sprintf("tag:%s", v1.AnnotationCapacityFloor)), Values: aws.StringSlice([]string{"true"})},
+			{Name: aws.String("state"), Values: aws.StringSlice([]string{ec2.CapacityReservationStateActive, ec2.CapacityReservationStatePending})},
+		},
+	}, func(out *ec2.DescribeCapacityReservationsOutput, _ bool) bool {
+		reservations = append(reservations, out.CapacityReservations...)
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+// create reserves the capacity of a pool. Failures, like insufficient capacity in the zone, are published to the
+// NodePool rather than returned, so that the reservations of the other pools are still reconciled
+func (c *Controller) create(ctx context.Context, p pool, r reservation) {
+	out, err := c.ec2api.CreateCapacityReservationWithContext(ctx, &ec2.CreateCapacityReservationInput{
+		InstanceType:          aws.String(p.instanceType),
+		InstancePlatform:      aws.String(r.platform),
+		AvailabilityZone:      aws.String(p.zone),
+		InstanceCount:         aws.Int64(r.count),
+		InstanceMatchCriteria: aws.String(ec2.InstanceMatchCriteriaOpen),
+		EndDateType:           aws.String(ec2.EndDateTypeUnlimited),
+		TagSpecifications: []*ec2.TagSpecification{{
+			ResourceType: aws.String(ec2.ResourceTypeCapacityReservation),
+			Tags: utils.MergeTags(map[string]string{
+				fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName): "owned",
+				karpv1.ManagedByAnnotationKey: options.FromContext(ctx).ClusterName,
+				karpv1.NodePoolLabelKey:       p.nodePool,
+				v1.AnnotationCapacityFloor:    "true",
+			}),
+		}},
+	})
+	if err != nil {
+		log.FromContext(ctx).WithValues("NodePool", p.nodePool, "instance-type", p.instanceType, "zone", p.zone).Error(err, "failed creating capacity reservation")
+		c.recorder.Publish(CapacityReservationFailedEvent(r.nodePool, p.instanceType, p.zone, r.count, err))
+		return
+	}
+	log.FromContext(ctx).WithValues("NodePool", p.nodePool, "capacity-reservation", aws.StringValue(out.CapacityReservation.CapacityReservationId),
+		"instance-type", p.instanceType, "zone", p.zone, "count", r.count).Info("created capacity reservation")
+	c.recorder.Publish(CapacityReservationCreatedEvent(r.nodePool, p.instanceType, p.zone, r.count))
+}
+
+func (c *Controller) modify(ctx context.Context, cr *ec2.CapacityReservation, r reservation) error {
+	if _, err := c.ec2api.ModifyCapacityReservationWithContext(ctx, &ec2.ModifyCapacityReservationInput{
+		CapacityReservationId: cr.CapacityReservationId,
+		InstanceCount:         aws.Int64(r.count),
+	}); err != nil {
+		return fmt.Errorf("modifying capacity reservation %s, %w", aws.StringValue(cr.CapacityReservationId), err)
+	}
+	log.FromContext(ctx).WithValues("capacity-reservation", aws.StringValue(cr.CapacityReservationId), "count", r.count).Info("modified capacity reservation")
+	return nil
+}
+
+func (c *Controller) cancel(ctx context.Context, cr *ec2.CapacityReservation) error {
+	if _, err := c.ec2api.CancelCapacityReservationWithContext(ctx, &ec2.CancelCapacityReservationInput{
+		CapacityReservationId: cr.CapacityReservationId,
+	}); awserrors.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("cancelling capacity reservation %s, %w", aws.StringValue(cr.CapacityReservationId), err)
+	}
+	log.FromContext(ctx).WithValues("capacity-reservation", aws.StringValue(cr.CapacityReservationId)).Info("cancelled capacity reservation")
+	return nil
+}
+
+// ParseFloor parses the value of the karpenter.k8s.aws/capacity-floor annotation, which is a comma separated list of
+// instance types and the number of their instances, like "m5.xlarge=6,c5.2xlarge=2"
+func ParseFloor(value string) (map[string]int64, error) {
+	floor := map[string]int64{}
+	for _, entry := range strings.Split(value, ",") {
+		instanceType, count, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || instanceType == "" {
+			return nil, fmt.Errorf("%q isn't an instance type and a count, like m5.xlarge=3", entry)
+		}
+		n, err := strconv.ParseInt(count, 10, 64)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("the count of instance type %s isn't a positive integer", instanceType)
+		}
+		if _, ok := floor[instanceType]; ok {
+			return nil, fmt.Errorf("instance type %s is declared more than once", instanceType)
+		}
+		floor[instanceType] = n
+	}
+	return floor, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("capacityreservation").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
@@ -0,0 +1,54 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacityreservation
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	"sigs.k8s.io/karpenter/pkg/events"
+)
+
+func CapacityReservationCreatedEvent(nodePool *karpv1.NodePool, instanceType, zone string, count int64) events.Event {
+	return events.Event{
+		InvolvedObject: nodePool,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "CapacityReservationCreated",
+		Message:        fmt.Sprintf("Reserved %d %s instances in %s", count, instanceType, zone),
+		DedupeValues:   []string{string(nodePool.UID), instanceType, zone},
+	}
+}
+
+func CapacityReservationFailedEvent(nodePool *karpv1.NodePool, instanceType, zone string, count int64, err error) events.Event {
+	return events.Event{
+		InvolvedObject: nodePool,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "CapacityReservationFailed",
+		Message:        fmt.Sprintf("Failed to reserve %d %s instances in %s, %s", count, instanceType, zone, err),
+		DedupeValues:   []string{string(nodePool.UID), instanceType, zone},
+	}
+}
+
+func InvalidCapacityFloorEvent(nodePool *karpv1.NodePool, reason string) events.Event {
+	return events.Event{
+		InvolvedObject: nodePool,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "InvalidCapacityFloor",
+		Message:        fmt.Sprintf("Keeping the existing capacity reservations, the capacity floor can't be resolved, %s", reason),
+		DedupeValues:   []string{string(nodePool.UID), reason},
+	}
+}
@@ -0,0 +1,240 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacityreservation_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/capacityreservation"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var kubeClient client.Client
+var ec2api *fake.EC2API
+var recorder *coretest.EventRecorder
+var controller *capacityreservation.Controller
+var nodePool *karpv1.NodePool
+var nodeClass *v1.EC2NodeClass
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CapacityReservation")
+}
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	kubeClient = crfake.NewClientBuilder().Build()
+	ec2api = fake.NewEC2API()
+	recorder = coretest.NewEventRecorder()
+	controller = capacityreservation.NewController(kubeClient, recorder, ec2api)
+
+	nodeClass = test.EC2NodeClass()
+	nodeClass.Status.Subnets = []v1.Subnet{
+		{ID: "subnet-1", Zone: "test-zone-1a"},
+		{ID: "subnet-2", Zone: "test-zone-1b"},
+		{ID: "subnet-3", Zone: "test-zone-1c"},
+	}
+	nodePool = coretest.NodePool(karpv1.NodePool{ObjectMeta: metav1.ObjectMeta{
+		Name:        "critical",
+		Annotations: map[string]string{v1.AnnotationCapacityFloor: "m5.xlarge=4"},
+	}})
+	nodePool.Spec.Template.Spec.NodeClassRef = &karpv1.NodeClassReference{Group: "karpenter.k8s.aws", Kind: "EC2NodeClass", Name: nodeClass.Name}
+	Expect(kubeClient.Create(ctx, nodeClass)).To(Succeed())
+})
+
+// reservations returns the number of instances that are reserved in each zone for an instance type, of the
+// reservations that haven't been cancelled
+func reservations(instanceType string) map[string]int64 {
+	counts := map[string]int64{}
+	ec2api.CapacityReservations.Range(func(_, v any) bool {
+		cr := v.(*ec2.CapacityReservation)
+		if aws.StringValue(cr.InstanceType) == instanceType && aws.StringValue(cr.State) != ec2.CapacityReservationStateCancelled {
+			counts[aws.StringValue(cr.AvailabilityZone)] += aws.Int64Value(cr.TotalInstanceCount)
+		}
+		return true
+	})
+	return counts
+}
+
+var _ = Describe("CapacityReservation", func() {
+	It("should reserve the floor of the NodePool evenly across its zones", func() {
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(reservations("m5.xlarge")).To(Equal(map[string]int64{"test-zone-1a": 2, "test-zone-1b": 1, "test-zone-1c": 1}))
+
+		input := ec2api.CreateCapacityReservationBehavior.CalledWithInput.Pop()
+		Expect(aws.StringValue(input.InstanceMatchCriteria)).To(Equal(ec2.InstanceMatchCriteriaOpen))
+		Expect(aws.StringValue(input.InstancePlatform)).To(Equal(ec2.CapacityReservationInstancePlatformLinuxUnix))
+		Expect(aws.StringValue(input.EndDateType)).To(Equal(ec2.EndDateTypeUnlimited))
+		tags := lo.SliceToMap(input.TagSpecifications[0].Tags, func(t *ec2.Tag) (string, string) { return aws.StringValue(t.Key), aws.StringValue(t.Value) })
+		Expect(tags).To(HaveKeyWithValue(karpv1.NodePoolLabelKey, "critical"))
+		Expect(tags).To(HaveKeyWithValue(karpv1.ManagedByAnnotationKey, options.FromContext(ctx).ClusterName))
+		Expect(recorder.Calls("CapacityReservationCreated")).To(Equal(3))
+	})
+	It("should only reserve capacity in the zones of the NodePool", func() {
+		nodePool.Spec.Template.Spec.Requirements = append(nodePool.Spec.Template.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+			NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-1b", "test-zone-1c"}},
+		})
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(reservations("m5.xlarge")).To(Equal(map[string]int64{"test-zone-1b": 2, "test-zone-1c": 2}))
+	})
+	It("should reserve Windows capacity for the NodePools of Windows node classes", func() {
+		nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "windows2022@latest"}}
+		Expect(kubeClient.Update(ctx, nodeClass)).To(Succeed())
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(aws.StringValue(ec2api.CreateCapacityReservationBehavior.CalledWithInput.Pop().InstancePlatform)).To(Equal(ec2.CapacityReservationInstancePlatformWindows))
+	})
+	It("should resize the reservations when the floor changes", func() {
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		nodePool.Annotations[v1.AnnotationCapacityFloor] = "m5.xlarge=6"
+		Expect(kubeClient.Update(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(reservations("m5.xlarge")).To(Equal(map[string]int64{"test-zone-1a": 2, "test-zone-1b": 2, "test-zone-1c": 2}))
+		Expect(ec2api.ModifyCapacityReservationBehavior.Calls()).To(Equal(2))
+		Expect(ec2api.CreateCapacityReservationBehavior.Calls()).To(Equal(3))
+	})
+	It("should cancel the reservations of instance types that are removed from the floor", func() {
+		nodePool.Annotations[v1.AnnotationCapacityFloor] = "m5.xlarge=3,c5.large=3"
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(reservations("c5.large")).To(HaveLen(3))
+		nodePool.Annotations[v1.AnnotationCapacityFloor] = "m5.xlarge=3"
+		Expect(kubeClient.Update(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(reservations("c5.large")).To(BeEmpty())
+		Expect(reservations("m5.xlarge")).To(HaveLen(3))
+	})
+	It("should cancel the reservations of deleted NodePools", func() {
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(kubeClient.Delete(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(reservations("m5.xlarge")).To(BeEmpty())
+		Expect(ec2api.CancelCapacityReservationBehavior.Calls()).To(Equal(3))
+	})
+	It("should cancel the reservations of NodePools that no longer declare a floor", func() {
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		delete(nodePool.Annotations, v1.AnnotationCapacityFloor)
+		Expect(kubeClient.Update(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(reservations("m5.xlarge")).To(BeEmpty())
+	})
+	It("should keep the reservations of NodePools whose floor becomes invalid", func() {
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		nodePool.Annotations[v1.AnnotationCapacityFloor] = "m5.xlarge"
+		Expect(kubeClient.Update(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(reservations("m5.xlarge")).To(Equal(map[string]int64{"test-zone-1a": 2, "test-zone-1b": 1, "test-zone-1c": 1}))
+		Expect(ec2api.CancelCapacityReservationBehavior.Calls()).To(BeZero())
+		Expect(recorder.Calls("InvalidCapacityFloor")).To(Equal(1))
+	})
+	It("should keep the reservations of NodePools whose node class resolves no zones", func() {
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		nodeClass.Status.Subnets = nil
+		Expect(kubeClient.Update(ctx, nodeClass)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(reservations("m5.xlarge")).To(HaveLen(3))
+		Expect(ec2api.CancelCapacityReservationBehavior.Calls()).To(BeZero())
+		Expect(ec2api.ModifyCapacityReservationBehavior.Calls()).To(BeZero())
+		Expect(recorder.Calls("InvalidCapacityFloor")).To(Equal(1))
+	})
+	It("should not cancel the reservations of other clusters", func() {
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(kubeClient.Delete(ctx, nodePool)).To(Succeed())
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ClusterName: lo.ToPtr("other-cluster")}))
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(reservations("m5.xlarge")).To(HaveLen(3))
+	})
+	It("should keep reconciling the other pools when a reservation can't be created", func() {
+		nodePool.Annotations[v1.AnnotationCapacityFloor] = "m5.xlarge=1"
+		ec2api.CreateCapacityReservationBehavior.Error.Set(awserr.New("InsufficientInstanceCapacity", "", nil), fake.MaxCalls(1))
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(reservations("m5.xlarge")).To(BeEmpty())
+		Expect(recorder.Calls("CapacityReservationFailed")).To(Equal(1))
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(reservations("m5.xlarge")).To(Equal(map[string]int64{"test-zone-1a": 1}))
+	})
+	It("should ignore floors of instance types that the NodePool doesn't allow", func() {
+		nodePool.Spec.Template.Spec.Requirements = append(nodePool.Spec.Template.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+			NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"c5.large"}},
+		})
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(reservations("m5.xlarge")).To(BeEmpty())
+		Expect(recorder.Calls("InvalidCapacityFloor")).To(Equal(1))
+	})
+	It("should ignore floors of NodePools that don't allow on-demand capacity", func() {
+		nodePool.Spec.Template.Spec.Requirements = append(nodePool.Spec.Template.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+			NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeSpot}},
+		})
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(ec2api.CreateCapacityReservationBehavior.Calls()).To(BeZero())
+		Expect(recorder.Calls("InvalidCapacityFloor")).To(Equal(1))
+	})
+	It("should not require the permissions of the controller until a NodePool declares a floor", func() {
+		ec2api.NextError.Set(awserr.New("UnauthorizedOperation", "", nil))
+		result := ExpectSingletonReconciled(ctx, controller)
+		Expect(result.RequeueAfter).ToNot(BeZero())
+	})
+	DescribeTable("should parse the floor",
+		func(value string, expected map[string]int64, valid bool) {
+			floor, err := capacityreservation.ParseFloor(value)
+			if !valid {
+				Expect(err).To(HaveOccurred())
+				return
+			}
+			Expect(err).ToNot(HaveOccurred())
+			Expect(floor).To(Equal(expected))
+		},
+		Entry("with one instance type", "m5.xlarge=3", map[string]int64{"m5.xlarge": 3}, true),
+		Entry("with several instance types", "m5.xlarge=3, c5.large=1", map[string]int64{"m5.xlarge": 3, "c5.large": 1}, true),
+		Entry("without a count", "m5.xlarge", nil, false),
+		Entry("with a count that isn't positive", "m5.xlarge=0", nil, false),
+		Entry("with an instance type that's declared twice", "m5.xlarge=1,m5.xlarge=2", nil, false),
+	)
+})
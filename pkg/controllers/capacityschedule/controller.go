@@ -0,0 +1,290 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacityschedule
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/awslabs/operatorpkg/status"
+	"github.com/robfig/cron/v3"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	corescheduling "sigs.k8s.io/karpenter/pkg/scheduling"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+)
+
+const (
+	// pollPeriod is how often the nodes of an active window are launched, since the launches are spread across the lead
+	// time of the window
+	pollPeriod = 15 * time.Second
+	// maxLaunchesPerPoll limits the NodeClaims that are created at once, so that a schedule that becomes active with many
+	// nodes, like when it's created during a window, doesn't exceed the CreateFleet limits of the account
+	maxLaunchesPerPoll = 10
+)
+
+// Controller launches the nodes of the active windows of CapacitySchedules. The nodes are NodeClaims of the NodePool of
+// the schedule, which are annotated with the name of the schedule and karpenter.sh/do-not-disrupt, so that they aren't
+// consolidated while they're empty before the traffic of the window arrives. NodeClaims that are deleted during the
+// window, like when their spot instances are interrupted, are replaced. When the window ends, the annotations are
+// removed from the NodeClaims and their nodes, and the nodes are consolidated like the other nodes of the NodePool.
+type Controller struct {
+	kubeClient    client.Client
+	clk           clock.Clock
+	recorder      events.Recorder
+	cloudProvider cloudprovider.CloudProvider
+}
+
+func NewController(kubeClient client.Client, clk clock.Clock, recorder events.Recorder, cloudProvider cloudprovider.CloudProvider) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		clk:           clk,
+		recorder:      recorder,
+		cloudProvider: cloudProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, schedule *v1.CapacitySchedule) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "capacityschedule")
+
+	if !schedule.DeletionTimestamp.IsZero() {
+		return c.finalize(ctx, schedule)
+	}
+	if !controllerutil.ContainsFinalizer(schedule, v1.TerminationFinalizer) {
+		stored := schedule.DeepCopy()
+		controllerutil.AddFinalizer(schedule, v1.TerminationFinalizer)
+		if err := c.kubeClient.Patch(ctx, schedule, client.MergeFrom(stored)); err != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(err)
+		}
+	}
+	stored := schedule.DeepCopy()
+	result, err := c.reconcile(ctx, schedule)
+	if !equality.Semantic.DeepEqual(stored.Status, schedule.Status) {
+		if patchErr := c.kubeClient.Status().Patch(ctx, schedule, client.MergeFrom(stored)); patchErr != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(multierr.Append(err, patchErr))
+		}
+	}
+	return result, err
+}
+
+func (c *Controller) reconcile(ctx context.Context, schedule *v1.CapacitySchedule) (reconcile.Result, error) {
+	nodeClaims, err := c.nodeClaims(ctx, schedule)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	now := c.clk.Now()
+	start, end, err := Window(schedule, now)
+	if err != nil {
+		schedule.Status.Active, schedule.Status.Nodes, schedule.Status.Message = false, 0, err.Error()
+		return reconcile.Result{}, c.release(ctx, nodeClaims)
+	}
+	launchStart := start.Add(-leadTime(schedule))
+	schedule.Status.WindowStart, schedule.Status.WindowEnd = lo.ToPtr(metav1.NewTime(start)), lo.ToPtr(metav1.NewTime(end))
+	schedule.Status.Active = !now.Before(launchStart)
+	if !schedule.Status.Active {
+		schedule.Status.Nodes, schedule.Status.Message = 0, ""
+		if err := c.release(ctx, nodeClaims); err != nil {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{RequeueAfter: launchStart.Sub(now)}, nil
+	}
+	if count := lo.Min([]int{target(schedule, now, launchStart, start) - len(nodeClaims), maxLaunchesPerPoll}); count > 0 {
+		launched, reason, err := c.launch(ctx, schedule, count)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		nodeClaims = append(nodeClaims, launched...)
+		schedule.Status.Message = reason
+	}
+	schedule.Status.Nodes = int32(len(nodeClaims))
+	return reconcile.Result{RequeueAfter: lo.Min([]time.Duration{pollPeriod, end.Sub(now)})}, nil
+}
+
+// finalize releases the NodeClaims of the active window of a deleted schedule, so that they don't keep
+// karpenter.sh/do-not-disrupt
+func (c *Controller) finalize(ctx context.Context, schedule *v1.CapacitySchedule) (reconcile.Result, error) {
+	if !controllerutil.ContainsFinalizer(schedule, v1.TerminationFinalizer) {
+		return reconcile.Result{}, nil
+	}
+	nodeClaims, err := c.nodeClaims(ctx, schedule)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if err := c.release(ctx, nodeClaims); err != nil {
+		return reconcile.Result{}, err
+	}
+	controllerutil.RemoveFinalizer(schedule, v1.TerminationFinalizer)
+	// Update() is used rather than Patch() since a JSON merge patch replaces the list of finalizers
+	if err := c.kubeClient.Update(ctx, schedule); err != nil {
+		if errors.IsConflict(err) {
+			return reconcile.Result{Requeue: true}, nil
+		}
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("removing termination finalizer, %w", err))
+	}
+	return reconcile.Result{}, nil
+}
+
+// Window returns the start and the end of the earliest window of the schedule that hasn't ended at now, which is the
+// active window, or the next window if none is active
+func Window(schedule *v1.CapacitySchedule, now time.Time) (time.Time, time.Time, error) {
+	spec := schedule.Spec.Schedule
+	if schedule.Spec.TimeZone != "" {
+		if _, err := time.LoadLocation(schedule.Spec.TimeZone); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid time zone %q", schedule.Spec.TimeZone)
+		}
+		spec = fmt.Sprintf("CRON_TZ=%s %s", schedule.Spec.TimeZone, spec)
+	}
+	cronSchedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid schedule %q, %w", schedule.Spec.Schedule, err)
+	}
+	start := cronSchedule.Next(now.Add(-schedule.Spec.Duration.Duration))
+	return start, start.Add(schedule.Spec.Duration.Duration), nil
+}
+
+func leadTime(schedule *v1.CapacitySchedule) time.Duration {
+	return lo.FromPtr(schedule.Spec.LeadTime).Duration
+}
+
+// target returns the number of nodes that should be launched at now. The nodes are launched evenly across the lead
+// time, starting with one node, and all of them are launched once the window starts.
+func target(schedule *v1.CapacitySchedule, now, launchStart, start time.Time) int {
+	if !now.Before(start) {
+		return int(schedule.Spec.Nodes)
+	}
+	elapsed := float64(now.Sub(launchStart)) / float64(start.Sub(launchStart))
+	return lo.Clamp(int(math.Ceil(float64(schedule.Spec.Nodes)*elapsed)), 1, int(schedule.Spec.Nodes))
+}
+
+// nodeClaims returns the NodeClaims that are launched for the active window of the schedule, which aren't deleted
+func (c *Controller) nodeClaims(ctx context.Context, schedule *v1.CapacitySchedule) ([]*karpv1.NodeClaim, error) {
+	nodeClaims := &karpv1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaims); err != nil {
+		return nil, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	return lo.FilterMap(nodeClaims.Items, func(nc karpv1.NodeClaim, _ int) (*karpv1.NodeClaim, bool) {
+		return &nc, nc.Annotations[v1.AnnotationCapacitySchedule] == schedule.Name && nc.DeletionTimestamp.IsZero()
+	}), nil
+}
+
+// launch creates count NodeClaims of the NodePool of the schedule. It returns the reason that the nodes can't be
+// launched, like a NodePool that isn't ready, rather than an error, since the schedule is retried when it's polled.
+func (c *Controller) launch(ctx context.Context, schedule *v1.CapacitySchedule, count int) ([]*karpv1.NodeClaim, string, error) {
+	nodePool := &karpv1.NodePool{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: schedule.Spec.NodePool}, nodePool); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, fmt.Sprintf("nodepool %q not found", schedule.Spec.NodePool), nil
+		}
+		return nil, "", fmt.Errorf("getting nodepool, %w", err)
+	}
+	if !nodePool.StatusConditions().IsTrue(status.ConditionReady) {
+		return nil, fmt.Sprintf("nodepool %q isn't ready", nodePool.Name), nil
+	}
+	if err := nodePool.Spec.Limits.ExceededBy(nodePool.Status.Resources); err != nil {
+		return nil, fmt.Sprintf("nodepool %q %s", nodePool.Name, err), nil
+	}
+	instanceTypes, err := c.cloudProvider.GetInstanceTypes(ctx, nodePool)
+	if err != nil {
+		return nil, "", fmt.Errorf("getting instance types, %w", err)
+	}
+	var launched []*karpv1.NodeClaim
+	for range count {
+		template := scheduling.NewNodeClaimTemplate(nodePool)
+		template.Requirements.Add(corescheduling.NewNodeSelectorRequirements(schedule.Spec.Requirements...).Values()...)
+		template.InstanceTypeOptions = lo.Filter(cloudprovider.InstanceTypes(instanceTypes).Compatible(template.Requirements), func(it *cloudprovider.InstanceType, _ int) bool {
+			return template.Requirements.Compatible(it.Requirements, corescheduling.AllowUndefinedWellKnownLabels) == nil
+		})
+		if len(template.InstanceTypeOptions) == 0 {
+			return launched, fmt.Sprintf("no instance types of nodepool %q satisfy the requirements", nodePool.Name), nil
+		}
+		nodeClaim := template.ToNodeClaim(nodePool)
+		nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+			v1.AnnotationCapacitySchedule:    schedule.Name,
+			karpv1.DoNotDisruptAnnotationKey: "true",
+		})
+		if err := c.kubeClient.Create(ctx, nodeClaim); err != nil {
+			return launched, "", fmt.Errorf("creating nodeclaim, %w", err)
+		}
+		launched = append(launched, nodeClaim)
+	}
+	log.FromContext(ctx).WithValues("NodePool", nodePool.Name, "count", len(launched)).Info("launched nodes for capacity schedule")
+	c.recorder.Publish(NodesLaunchedEvent(schedule, len(launched)))
+	return launched, "", nil
+}
+
+// release removes the annotations of the schedule from the NodeClaims of the window that ended, and from their nodes
+// which copied them when they registered, so that they can be consolidated
+func (c *Controller) release(ctx context.Context, nodeClaims []*karpv1.NodeClaim) error {
+	for _, nodeClaim := range nodeClaims {
+		if nodeClaim.Status.NodeName != "" {
+			node := &corev1.Node{}
+			if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Status.NodeName}, node); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("getting node, %w", err)
+			} else if err == nil {
+				if err := c.unannotate(ctx, node); err != nil {
+					return fmt.Errorf("releasing node, %w", err)
+				}
+			}
+		}
+		if err := c.unannotate(ctx, nodeClaim); err != nil {
+			return fmt.Errorf("releasing nodeclaim, %w", err)
+		}
+	}
+	if len(nodeClaims) > 0 {
+		log.FromContext(ctx).WithValues("count", len(nodeClaims)).Info("released nodes of capacity schedule")
+	}
+	return nil
+}
+
+func (c *Controller) unannotate(ctx context.Context, obj client.Object) error {
+	stored := obj.DeepCopyObject().(client.Object)
+	annotations := obj.GetAnnotations()
+	delete(annotations, v1.AnnotationCapacitySchedule)
+	delete(annotations, karpv1.DoNotDisruptAnnotationKey)
+	obj.SetAnnotations(annotations)
+	return client.IgnoreNotFound(c.kubeClient.Patch(ctx, obj, client.MergeFrom(stored)))
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("capacityschedule").
+		For(&v1.CapacitySchedule{}).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 1,
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
@@ -0,0 +1,35 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacityschedule
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/karpenter/pkg/events"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+)
+
+func NodesLaunchedEvent(schedule *v1.CapacitySchedule, count int) events.Event {
+	return events.Event{
+		InvolvedObject: schedule,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "NodesLaunched",
+		Message:        fmt.Sprintf("Launched %d nodes of nodepool %s", count, schedule.Spec.NodePool),
+		DedupeValues:   []string{string(schedule.UID)},
+	}
+}
@@ -0,0 +1,246 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacityschedule_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	corecloudproviderfake "sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/capacityschedule"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var kubeClient client.Client
+var fakeClock *clock.FakeClock
+var controller *capacityschedule.Controller
+var nodePool *karpv1.NodePool
+var schedule *v1.CapacitySchedule
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CapacitySchedule")
+}
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	kubeClient = crfake.NewClientBuilder().WithStatusSubresource(&v1.CapacitySchedule{}).Build()
+	fakeClock = clock.NewFakeClock(time.Date(2024, 7, 1, 8, 30, 0, 0, time.UTC))
+	controller = capacityschedule.NewController(kubeClient, fakeClock, coretest.NewEventRecorder(), corecloudproviderfake.NewCloudProvider())
+
+	nodePool = coretest.NodePool(karpv1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+	nodePool.StatusConditions().SetTrue(status.ConditionReady)
+	schedule = &v1.CapacitySchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "business-hours"},
+		Spec: v1.CapacityScheduleSpec{
+			NodePool: "default",
+			Schedule: "0 9 * * *",
+			Duration: metav1.Duration{Duration: 8 * time.Hour},
+			LeadTime: &metav1.Duration{Duration: 20 * time.Minute},
+			Nodes:    4,
+		},
+	}
+	Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+	Expect(kubeClient.Create(ctx, schedule)).To(Succeed())
+})
+
+func reconcileSchedule() reconcile.Result {
+	result := ExpectObjectReconciled(ctx, kubeClient, controller, schedule)
+	Expect(kubeClient.Get(ctx, client.ObjectKeyFromObject(schedule), schedule)).To(Succeed())
+	return result
+}
+
+func nodeClaims() []karpv1.NodeClaim {
+	list := &karpv1.NodeClaimList{}
+	Expect(kubeClient.List(ctx, list)).To(Succeed())
+	return list.Items
+}
+
+func at(hour, minute int) {
+	fakeClock.SetTime(time.Date(2024, 7, 1, hour, minute, 0, 0, time.UTC))
+}
+
+var _ = Describe("CapacitySchedule", func() {
+	It("should wait for the lead time of the next window", func() {
+		result := reconcileSchedule()
+		Expect(nodeClaims()).To(BeEmpty())
+		Expect(schedule.Status.Active).To(BeFalse())
+		Expect(schedule.Status.WindowStart.Time).To(BeTemporally("==", time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)))
+		Expect(schedule.Status.WindowEnd.Time).To(BeTemporally("==", time.Date(2024, 7, 1, 17, 0, 0, 0, time.UTC)))
+		Expect(result.RequeueAfter).To(Equal(10 * time.Minute))
+		Expect(schedule.Finalizers).To(ContainElement(v1.TerminationFinalizer))
+	})
+	It("should spread the launches across the lead time", func() {
+		at(8, 45)
+		reconcileSchedule()
+		Expect(schedule.Status.Active).To(BeTrue())
+		Expect(nodeClaims()).To(HaveLen(1))
+		at(8, 50)
+		reconcileSchedule()
+		Expect(nodeClaims()).To(HaveLen(2))
+		at(9, 0)
+		reconcileSchedule()
+		Expect(nodeClaims()).To(HaveLen(4))
+		Expect(schedule.Status.Nodes).To(BeNumerically("==", 4))
+	})
+	It("should launch nodes of the NodePool that aren't disrupted during the window", func() {
+		at(9, 0)
+		result := reconcileSchedule()
+		Expect(result.RequeueAfter).ToNot(BeZero())
+		for _, nc := range nodeClaims() {
+			Expect(nc.Labels).To(HaveKeyWithValue(karpv1.NodePoolLabelKey, "default"))
+			Expect(nc.Annotations).To(HaveKeyWithValue(v1.AnnotationCapacitySchedule, "business-hours"))
+			Expect(nc.Annotations).To(HaveKeyWithValue(karpv1.DoNotDisruptAnnotationKey, "true"))
+			Expect(nc.Spec.NodeClassRef).To(Equal(nodePool.Spec.Template.Spec.NodeClassRef))
+			Expect(nc.OwnerReferences).To(HaveLen(1))
+			Expect(nc.OwnerReferences[0].Name).To(Equal("default"))
+		}
+	})
+	It("should limit the nodes that are launched at once", func() {
+		schedule.Spec.Nodes = 25
+		Expect(kubeClient.Update(ctx, schedule)).To(Succeed())
+		at(12, 0)
+		reconcileSchedule()
+		Expect(nodeClaims()).To(HaveLen(10))
+		reconcileSchedule()
+		reconcileSchedule()
+		Expect(nodeClaims()).To(HaveLen(25))
+	})
+	It("should launch nodes that satisfy the requirements of the schedule", func() {
+		schedule.Spec.Requirements = []corev1.NodeSelectorRequirement{
+			{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"small-instance-type"}},
+		}
+		Expect(kubeClient.Update(ctx, schedule)).To(Succeed())
+		at(9, 0)
+		reconcileSchedule()
+		Expect(nodeClaims()).To(HaveLen(4))
+		for _, nc := range nodeClaims() {
+			requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nc.Spec.Requirements...)
+			Expect(requirements.Get(corev1.LabelInstanceTypeStable).Values()).To(ConsistOf("small-instance-type"))
+		}
+	})
+	It("should replace the nodes that are deleted during the window", func() {
+		at(9, 0)
+		reconcileSchedule()
+		nc := nodeClaims()[0]
+		Expect(kubeClient.Delete(ctx, &nc)).To(Succeed())
+		at(10, 0)
+		reconcileSchedule()
+		Expect(nodeClaims()).To(HaveLen(4))
+	})
+	It("should release the nodes when the window ends", func() {
+		at(9, 0)
+		reconcileSchedule()
+		at(17, 0)
+		result := reconcileSchedule()
+		Expect(schedule.Status.Active).To(BeFalse())
+		Expect(schedule.Status.Nodes).To(BeZero())
+		Expect(schedule.Status.WindowStart.Time).To(BeTemporally("==", time.Date(2024, 7, 2, 9, 0, 0, 0, time.UTC)))
+		Expect(result.RequeueAfter).To(Equal(15*time.Hour + 40*time.Minute))
+		Expect(nodeClaims()).To(HaveLen(4))
+		for _, nc := range nodeClaims() {
+			Expect(nc.Annotations).ToNot(HaveKey(v1.AnnotationCapacitySchedule))
+			Expect(nc.Annotations).ToNot(HaveKey(karpv1.DoNotDisruptAnnotationKey))
+		}
+	})
+	It("should release the registered nodes when the window ends", func() {
+		at(9, 0)
+		reconcileSchedule()
+		nc := nodeClaims()[0]
+		node := coretest.Node(coretest.NodeOptions{ObjectMeta: metav1.ObjectMeta{Annotations: lo.Assign(nc.Annotations)}})
+		Expect(kubeClient.Create(ctx, node)).To(Succeed())
+		nc.Status.NodeName = node.Name
+		Expect(kubeClient.Update(ctx, &nc)).To(Succeed())
+		at(17, 0)
+		reconcileSchedule()
+		Expect(kubeClient.Get(ctx, client.ObjectKeyFromObject(node), node)).To(Succeed())
+		Expect(node.Annotations).ToNot(HaveKey(karpv1.DoNotDisruptAnnotationKey))
+		Expect(node.Annotations).ToNot(HaveKey(v1.AnnotationCapacitySchedule))
+	})
+	It("should release the nodes when the schedule is deleted", func() {
+		at(9, 0)
+		reconcileSchedule()
+		Expect(kubeClient.Delete(ctx, schedule)).To(Succeed())
+		ExpectObjectReconciled(ctx, kubeClient, controller, schedule)
+		for _, nc := range nodeClaims() {
+			Expect(nc.Annotations).ToNot(HaveKey(karpv1.DoNotDisruptAnnotationKey))
+		}
+		Expect(kubeClient.Get(ctx, client.ObjectKeyFromObject(schedule), schedule)).ToNot(Succeed())
+	})
+	It("should start the windows in the time zone of the schedule", func() {
+		schedule.Spec.TimeZone = "America/New_York"
+		Expect(kubeClient.Update(ctx, schedule)).To(Succeed())
+		reconcileSchedule()
+		Expect(schedule.Status.WindowStart.Time).To(BeTemporally("==", time.Date(2024, 7, 1, 13, 0, 0, 0, time.UTC)))
+	})
+	It("should explain why nodes aren't launched for an invalid time zone", func() {
+		schedule.Spec.TimeZone = "Mars/Olympus_Mons"
+		Expect(kubeClient.Update(ctx, schedule)).To(Succeed())
+		reconcileSchedule()
+		Expect(schedule.Status.Active).To(BeFalse())
+		Expect(schedule.Status.Message).To(Equal(`invalid time zone "Mars/Olympus_Mons"`))
+	})
+	It("should wait for the NodePool to be ready", func() {
+		nodePool.StatusConditions().SetFalse(status.ConditionReady, "NodeClassNotReady", "")
+		Expect(kubeClient.Update(ctx, nodePool)).To(Succeed())
+		at(9, 0)
+		reconcileSchedule()
+		Expect(nodeClaims()).To(BeEmpty())
+		Expect(schedule.Status.Message).To(Equal(`nodepool "default" isn't ready`))
+	})
+	It("should not launch nodes past the limits of the NodePool", func() {
+		nodePool.Spec.Limits = karpv1.Limits{corev1.ResourceCPU: resource.MustParse("1")}
+		nodePool.Status.Resources = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}
+		Expect(kubeClient.Update(ctx, nodePool)).To(Succeed())
+		at(9, 0)
+		reconcileSchedule()
+		Expect(nodeClaims()).To(BeEmpty())
+		Expect(schedule.Status.Message).To(ContainSubstring(`nodepool "default"`))
+	})
+	It("should keep launched nodes when the NodePool can't launch more", func() {
+		at(9, 0)
+		reconcileSchedule()
+		lo.Must0(kubeClient.Delete(ctx, lo.ToPtr(nodeClaims()[0])))
+		Expect(kubeClient.Delete(ctx, nodePool)).To(Succeed())
+		reconcileSchedule()
+		Expect(schedule.Status.Nodes).To(BeNumerically("==", 3))
+		Expect(schedule.Status.Message).To(Equal(`nodepool "default" not found`))
+	})
+})
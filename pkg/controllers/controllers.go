@@ -16,10 +16,12 @@ package controllers
 
 import (
 	"context"
+	"os"
 
 	"github.com/awslabs/operatorpkg/controller"
 	"github.com/awslabs/operatorpkg/status"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
@@ -27,26 +29,57 @@ import (
 	nodeclassstatus "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclass/status"
 	nodeclasstermination "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclass/termination"
 	controllersinstancetype "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/instancetype"
+	controllersipcapacity "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/ipcapacity"
 	controllerspricing "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/pricing"
+	controllersspotprice "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/spotprice"
+	controllerswarmup "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/warmup"
+	controllerszonalshift "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/zonalshift"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
 
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/arczonalshift"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/servicequotas"
 	servicesqs "github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/sts"
+	gocache "github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
 	"k8s.io/utils/clock"
+	"knative.dev/pkg/system"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"sigs.k8s.io/karpenter/pkg/events"
 
+	"github.com/aws/karpenter-provider-aws/pkg/alerting"
 	"github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/capacityreservation"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/capacityschedule"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/maintenancewindow"
+	metricsemf "github.com/aws/karpenter-provider-aws/pkg/controllers/metrics/emf"
+	nodeidentityreadiness "github.com/aws/karpenter-provider-aws/pkg/controllers/node/identityreadiness"
+	nodeclaimadoption "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/adoption"
 	nodeclaimgarbagecollection "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/garbagecollection"
+	nodeclaimlaunchjournal "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/launchjournal"
+	nodeclaimlaunchlatency "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/launchlatency"
+	nodeclaimmetadata "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/metadata"
+	nodeclaimspotsavings "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/spotsavings"
 	nodeclaimtagging "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/tagging"
+	nodeclaimterminationprotection "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/terminationprotection"
+	nodeclaimunregistered "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/unregistered"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodegroupmigration"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/permissions"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/settings"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/launchjournal"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/sqs"
@@ -54,24 +87,79 @@ import (
 )
 
 func NewControllers(ctx context.Context, mgr manager.Manager, sess *session.Session, clk clock.Clock, kubeClient client.Client, recorder events.Recorder,
-	unavailableOfferings *cache.UnavailableOfferings, cloudProvider cloudprovider.CloudProvider, subnetProvider subnet.Provider,
+	unavailableOfferings *cache.UnavailableOfferings, impairedZones *cache.ImpairedZones, spotInterruptions *cache.SpotInterruptionHistory, interruptionWindows *cache.InterruptionWindows, cloudProvider cloudprovider.CloudProvider, subnetProvider subnet.Provider,
 	securityGroupProvider securitygroup.Provider, instanceProfileProvider instanceprofile.Provider, instanceProvider instance.Provider,
-	pricingProvider pricing.Provider, amiProvider amifamily.Provider, launchTemplateProvider launchtemplate.Provider, instanceTypeProvider instancetype.Provider) []controller.Controller {
+	launchJournal launchjournal.Provider, pricingProvider pricing.Provider, amiProvider amifamily.Provider, launchTemplateProvider launchtemplate.Provider, instanceTypeProvider instancetype.Provider,
+	alertTracker *alerting.Tracker) []controller.Controller {
 
 	controllers := []controller.Controller{
 		nodeclasshash.NewController(kubeClient),
-		nodeclassstatus.NewController(kubeClient, subnetProvider, securityGroupProvider, amiProvider, instanceProfileProvider, launchTemplateProvider),
+		nodeclassstatus.NewController(kubeClient, subnetProvider, securityGroupProvider, amiProvider, instanceProfileProvider, launchTemplateProvider, impairedZones,
+			ec2.New(sess), gocache.New(cache.DefaultTTL, cache.DefaultCleanupInterval), servicequotas.New(sess), gocache.New(cache.QuotaUsageTTL, cache.DefaultCleanupInterval)),
 		nodeclasstermination.NewController(kubeClient, recorder, instanceProfileProvider, launchTemplateProvider),
-		nodeclaimgarbagecollection.NewController(kubeClient, cloudProvider),
-		nodeclaimtagging.NewController(kubeClient, instanceProvider),
-		controllerspricing.NewController(pricingProvider),
+		nodeclaimlaunchlatency.NewController(kubeClient, instanceProvider, clk),
+		nodeclaimspotsavings.NewController(kubeClient, clk),
+		nodeidentityreadiness.NewController(kubeClient),
 		controllersinstancetype.NewController(instanceTypeProvider),
+		controllersipcapacity.NewController(kubeClient, recorder, subnetProvider),
+		controllerswarmup.NewController(kubeClient, recorder, subnetProvider, securityGroupProvider, amiProvider, instanceTypeProvider, pricingProvider),
 		status.NewController[*v1.EC2NodeClass](kubeClient, mgr.GetEventRecorderFor("karpenter")),
 	}
-	if options.FromContext(ctx).InterruptionQueue != "" {
+	if options.FromContext(ctx).ControllerEnabled(options.ControllerGarbageCollection) {
+		controllers = append(controllers, nodeclaimgarbagecollection.NewController(kubeClient, cloudProvider))
+	}
+	if options.FromContext(ctx).ControllerEnabled(options.ControllerLaunchJournal) {
+		controllers = append(controllers, nodeclaimlaunchjournal.NewController(kubeClient, ec2.New(sess), launchJournal))
+	}
+	if options.FromContext(ctx).ControllerEnabled(options.ControllerInstanceAdoption) {
+		controllers = append(controllers, nodeclaimadoption.NewController(kubeClient, recorder, ec2.New(sess), cloudProvider))
+	}
+	if options.FromContext(ctx).ControllerEnabled(options.ControllerTagging) {
+		controllers = append(controllers, nodeclaimtagging.NewController(kubeClient, instanceProvider))
+	}
+	if options.FromContext(ctx).ControllerEnabled(options.ControllerTerminationProtection) {
+		controllers = append(controllers, nodeclaimterminationprotection.NewController(kubeClient, recorder, instanceProvider))
+	}
+	if options.FromContext(ctx).ControllerEnabled(options.ControllerPricing) {
+		controllers = append(controllers, controllerspricing.NewController(pricingProvider))
+		if options.FromContext(ctx).SpotPriceRefreshPeriod != 0 {
+			controllers = append(controllers, controllersspotprice.NewController(kubeClient, pricingProvider))
+		}
+	}
+	if options.FromContext(ctx).ControllerEnabled(options.ControllerNodeGroupMigration) {
+		controllers = append(controllers, nodegroupmigration.NewController(kubeClient, clk, recorder, autoscaling.New(sess), eks.New(sess)))
+	}
+	if options.FromContext(ctx).ControllerEnabled(options.ControllerCapacityReservation) {
+		controllers = append(controllers, capacityreservation.NewController(kubeClient, recorder, ec2.New(sess)))
+	}
+	if options.FromContext(ctx).ControllerEnabled(options.ControllerCapacitySchedule) {
+		controllers = append(controllers, capacityschedule.NewController(kubeClient, clk, recorder, cloudProvider))
+	}
+	if options.FromContext(ctx).ControllerEnabled(options.ControllerMaintenanceWindow) {
+		controllers = append(controllers, maintenancewindow.NewController(kubeClient, mgr.GetAPIReader(), clk, recorder, ssm.New(sess), gocache.New(cache.DefaultTTL, cache.DefaultCleanupInterval), interruptionWindows, system.Namespace()))
+	}
+	if options.FromContext(ctx).InterruptionQueue != "" && options.FromContext(ctx).ControllerEnabled(options.ControllerInterruption) {
 		sqsapi := servicesqs.New(sess)
 		out := lo.Must(sqsapi.GetQueueUrlWithContext(ctx, &servicesqs.GetQueueUrlInput{QueueName: lo.ToPtr(options.FromContext(ctx).InterruptionQueue)}))
-		controllers = append(controllers, interruption.NewController(kubeClient, clk, recorder, lo.Must(sqs.NewDefaultProvider(sqsapi, lo.FromPtr(out.QueueUrl))), unavailableOfferings))
+		controllers = append(controllers, interruption.NewController(kubeClient, clk, recorder, lo.Must(sqs.NewDefaultProvider(sqsapi, lo.FromPtr(out.QueueUrl))), unavailableOfferings, impairedZones, spotInterruptions, interruptionWindows, subnetProvider, securityGroupProvider))
+	}
+	if options.FromContext(ctx).ZonalShift != options.ZonalShiftDisabled {
+		controllers = append(controllers, controllerszonalshift.NewController(ec2.New(sess), eks.New(sess), arczonalshift.New(sess), unavailableOfferings))
+	}
+	if options.FromContext(ctx).NodeMetadataAnnotations != "" {
+		controllers = append(controllers, nodeclaimmetadata.NewController(kubeClient, instanceProvider))
+	}
+	if options.FromContext(ctx).AlertWebhookURL != "" || options.FromContext(ctx).AlertSNSTopicARN != "" {
+		controllers = append(controllers, nodeclaimunregistered.NewController(clk, alertTracker))
+	}
+	if options.FromContext(ctx).PermissionsCheckPeriod != 0 {
+		controllers = append(controllers, permissions.NewController(sts.New(sess), iam.New(sess), *sess.Config.Region))
+	}
+	if options.FromContext(ctx).SettingsConfigMap != "" {
+		controllers = append(controllers, settings.NewController(ctx, kubeClient, mgr.GetAPIReader(), recorder, system.Namespace()))
+	}
+	if options.FromContext(ctx).EMFNamespace != "" {
+		controllers = append(controllers, metricsemf.NewController(crmetrics.Registry, os.Stdout, clk))
 	}
 	return controllers
 }
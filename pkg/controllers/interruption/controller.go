@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws/arn"
 	sqsapi "github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/awslabs/operatorpkg/singleton"
 	"github.com/prometheus/client_golang/prometheus"
@@ -39,11 +40,16 @@ import (
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 	"github.com/aws/karpenter-provider-aws/pkg/cache"
 	interruptionevents "github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/events"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/networkchange"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/statechange"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/zoneimpairment"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/sqs"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
 
 	"sigs.k8s.io/karpenter/pkg/events"
@@ -65,12 +71,19 @@ type Controller struct {
 	recorder                  events.Recorder
 	sqsProvider               sqs.Provider
 	unavailableOfferingsCache *cache.UnavailableOfferings
+	impairedZones             *cache.ImpairedZones
+	spotInterruptions         *cache.SpotInterruptionHistory
+	interruptionWindows       *cache.InterruptionWindows
+	subnetProvider            subnet.Provider
+	securityGroupProvider     securitygroup.Provider
 	parser                    *EventParser
 	cm                        *pretty.ChangeMonitor
 }
 
 func NewController(kubeClient client.Client, clk clock.Clock, recorder events.Recorder,
-	sqsProvider sqs.Provider, unavailableOfferingsCache *cache.UnavailableOfferings) *Controller {
+	sqsProvider sqs.Provider, unavailableOfferingsCache *cache.UnavailableOfferings, impairedZones *cache.ImpairedZones,
+	spotInterruptions *cache.SpotInterruptionHistory, interruptionWindows *cache.InterruptionWindows, subnetProvider subnet.Provider,
+	securityGroupProvider securitygroup.Provider) *Controller {
 
 	return &Controller{
 		kubeClient:                kubeClient,
@@ -78,6 +91,11 @@ func NewController(kubeClient client.Client, clk clock.Clock, recorder events.Re
 		recorder:                  recorder,
 		sqsProvider:               sqsProvider,
 		unavailableOfferingsCache: unavailableOfferingsCache,
+		impairedZones:             impairedZones,
+		spotInterruptions:         spotInterruptions,
+		interruptionWindows:       interruptionWindows,
+		subnetProvider:            subnetProvider,
+		securityGroupProvider:     securityGroupProvider,
 		parser:                    NewEventParser(DefaultParsers...),
 		cm:                        pretty.NewChangeMonitor(),
 	}
@@ -89,6 +107,7 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 	if c.cm.HasChanged(c.sqsProvider.Name(), nil) {
 		log.FromContext(ctx).V(1).Info("watching interruption queue")
 	}
+	defer c.updateImpairedZoneMetrics()
 	sqsMessages, err := c.sqsProvider.GetSQSMessages(ctx)
 	if err != nil {
 		return reconcile.Result{}, fmt.Errorf("getting messages from queue, %w", err)
@@ -104,6 +123,10 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 	if err != nil {
 		return reconcile.Result{}, fmt.Errorf("making node instance id map, %w", err)
 	}
+	nodeClassAccountMap, err := c.makeNodeClassAccountMap(ctx)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("making nodeclass account map, %w", err)
+	}
 	errs := make([]error, len(sqsMessages))
 	workqueue.ParallelizeUntil(ctx, 10, len(sqsMessages), func(i int) {
 		msg, e := c.parseMessage(sqsMessages[i])
@@ -113,7 +136,7 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 			errs[i] = c.deleteMessage(ctx, sqsMessages[i])
 			return
 		}
-		if e = c.handleMessage(ctx, nodeClaimInstanceIDMap, nodeInstanceIDMap, msg); e != nil {
+		if e = c.handleMessage(ctx, nodeClaimInstanceIDMap, nodeInstanceIDMap, nodeClassAccountMap, msg); e != nil {
 			errs[i] = fmt.Errorf("handling message, %w", e)
 			return
 		}
@@ -145,9 +168,10 @@ func (c *Controller) parseMessage(raw *sqsapi.Message) (messages.Message, error)
 	return msg, nil
 }
 
-// handleMessage takes an action against every node involved in the message that is owned by a NodePool
+// handleMessage takes an action against every node involved in the message that is owned by a NodePool and that was
+// launched into the account that emitted the message
 func (c *Controller) handleMessage(ctx context.Context, nodeClaimInstanceIDMap map[string]*karpv1.NodeClaim,
-	nodeInstanceIDMap map[string]*corev1.Node, msg messages.Message) (err error) {
+	nodeInstanceIDMap map[string]*corev1.Node, nodeClassAccountMap map[string]string, msg messages.Message) (err error) {
 
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("messageKind", msg.Kind()))
 	receivedMessages.WithLabelValues(string(msg.Kind())).Inc()
@@ -155,11 +179,30 @@ func (c *Controller) handleMessage(ctx context.Context, nodeClaimInstanceIDMap m
 	if msg.Kind() == messages.NoOpKind {
 		return nil
 	}
+	if msg.Kind() == messages.ZoneImpairmentKind {
+		c.handleZoneImpairment(ctx, msg.(zoneimpairment.Message))
+		messageLatency.Observe(time.Since(msg.StartTime()).Seconds())
+		return nil
+	}
+	if msg.Kind() == messages.NetworkChangeKind {
+		c.handleNetworkChange(ctx, msg.(networkchange.Message))
+		messageLatency.Observe(time.Since(msg.StartTime()).Seconds())
+		return nil
+	}
 	for _, instanceID := range msg.EC2InstanceIDs() {
 		nodeClaim, ok := nodeClaimInstanceIDMap[instanceID]
 		if !ok {
 			continue
 		}
+		// Events that are forwarded from workload accounts are routed to the NodeClaims whose EC2NodeClass assumes a
+		// role in the account. NodeClaims that were launched with the credentials of the controller accept the events
+		// of any account, since the account of the controller isn't known.
+		if nodeClaim.Spec.NodeClassRef != nil {
+			if account, ok := nodeClassAccountMap[nodeClaim.Spec.NodeClassRef.Name]; ok && account != msg.AccountID() {
+				log.FromContext(ctx).WithValues("NodeClaim", klog.KRef("", nodeClaim.Name), "account", msg.AccountID()).V(1).Info("ignoring interruption message from another account")
+				continue
+			}
+		}
 		node := nodeInstanceIDMap[instanceID]
 		if e := c.handleNodeClaim(ctx, msg, nodeClaim, node); e != nil {
 			err = multierr.Append(err, e)
@@ -172,6 +215,36 @@ func (c *Controller) handleMessage(ctx context.Context, nodeClaimInstanceIDMap m
 	return nil
 }
 
+// handleZoneImpairment avoids the impaired zone for new launches until the issue is resolved
+func (c *Controller) handleZoneImpairment(ctx context.Context, msg zoneimpairment.Message) {
+	if msg.Resolved() {
+		c.impairedZones.MarkRecovered(ctx, msg.Detail.AvailabilityZone)
+		return
+	}
+	c.impairedZones.MarkImpaired(ctx, msg.Detail.EventTypeCode, msg.Detail.AvailabilityZone, msg.Until())
+}
+
+// handleNetworkChange drops the discovered subnets or security groups so that created, deleted and retagged ones are
+// picked up the next time EC2NodeClasses are resolved rather than once the cached ones expire
+func (c *Controller) handleNetworkChange(ctx context.Context, msg networkchange.Message) {
+	log.FromContext(ctx).WithValues("event", msg.Detail.EventName).V(1).Info("invalidating discovered network resources")
+	if msg.AffectsSubnets() {
+		c.subnetProvider.Invalidate()
+	}
+	if msg.AffectsSecurityGroups() {
+		c.securityGroupProvider.Invalidate()
+	}
+}
+
+// updateImpairedZoneMetrics reflects the zones that are currently being avoided, including the ones that have expired
+// from the cache since the last reconcile
+func (c *Controller) updateImpairedZoneMetrics() {
+	impairedZoneExpiration.Reset()
+	for _, zone := range c.impairedZones.List() {
+		impairedZoneExpiration.With(prometheus.Labels{zoneLabel: zone.Zone}).Set(float64(zone.Until.Unix()))
+	}
+}
+
 // deleteMessage removes the passed SQS message from the queue and fires a metric for the deletion
 func (c *Controller) deleteMessage(ctx context.Context, msg *sqsapi.Message) error {
 	if err := c.sqsProvider.DeleteSQSMessage(ctx, msg); err != nil {
@@ -198,12 +271,21 @@ func (c *Controller) handleNodeClaim(ctx context.Context, msg messages.Message,
 		},
 	).Inc()
 
-	// Mark the offering as unavailable in the ICE cache since we got a spot interruption warning
+	// Mark the offering as unavailable in the ICE cache since we got a spot interruption warning, and record the
+	// interruption in the history of its pool so that NodePools can avoid pools that are interrupted often
 	if msg.Kind() == messages.SpotInterruptionKind {
 		zone := nodeClaim.Labels[corev1.LabelTopologyZone]
 		instanceType := nodeClaim.Labels[corev1.LabelInstanceTypeStable]
 		if zone != "" && instanceType != "" {
 			c.unavailableOfferingsCache.MarkUnavailable(ctx, string(msg.Kind()), instanceType, zone, karpv1.CapacityTypeSpot)
+			c.spotInterruptions.Record(ctx, instanceType, zone)
+		}
+	}
+	// Spot interruptions and rebalance recommendations are recorded by the hour that they're received in, so that
+	// NodePools can be disrupted in the hours in which their instances are rarely interrupted
+	if msg.Kind() == messages.SpotInterruptionKind || msg.Kind() == messages.RebalanceRecommendationKind {
+		if nodePool := nodeClaim.Labels[karpv1.NodePoolLabelKey]; nodePool != "" {
+			c.interruptionWindows.Record(ctx, nodePool)
 		}
 	}
 	if action != NoAction {
@@ -296,6 +378,27 @@ func (c *Controller) makeNodeInstanceIDMap(ctx context.Context) (map[string]*cor
 	return m, nil
 }
 
+// makeNodeClassAccountMap builds a map between the name of each EC2NodeClass that assumes a role and the account of
+// the role, which is the account that the instances of the EC2NodeClass are launched into
+func (c *Controller) makeNodeClassAccountMap(ctx context.Context) (map[string]string, error) {
+	m := map[string]string{}
+	nodeClassList := &v1.EC2NodeClassList{}
+	if err := c.kubeClient.List(ctx, nodeClassList); err != nil {
+		return nil, fmt.Errorf("listing ec2nodeclasses, %w", err)
+	}
+	for i := range nodeClassList.Items {
+		if nodeClassList.Items[i].Spec.AssumeRoleARN == nil {
+			continue
+		}
+		role, err := arn.Parse(lo.FromPtr(nodeClassList.Items[i].Spec.AssumeRoleARN))
+		if err != nil {
+			continue
+		}
+		m[nodeClassList.Items[i].Name] = role.AccountID
+	}
+	return m, nil
+}
+
 func actionForMessage(msg messages.Message) Action {
 	switch msg.Kind() {
 	case messages.ScheduledChangeKind, messages.SpotInterruptionKind, messages.StateChangeKind:
@@ -33,6 +33,7 @@ import (
 	servicesqs "github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
 	"github.com/go-logr/zapr"
+	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
 	"go.uber.org/zap"
@@ -50,7 +51,9 @@ import (
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/events"
 	"github.com/aws/karpenter-provider-aws/pkg/fake"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/sqs"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 	"github.com/aws/karpenter-provider-aws/pkg/test"
 
 	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
@@ -114,7 +117,9 @@ func benchmarkNotificationController(b *testing.B, messageCount int) {
 	unavailableOfferingsCache = awscache.NewUnavailableOfferings()
 
 	// Set-up the controllers
-	interruptionController := interruption.NewController(env.Client, fakeClock, recorder, providers.sqsProvider, unavailableOfferingsCache)
+	interruptionController := interruption.NewController(env.Client, fakeClock, recorder, providers.sqsProvider, unavailableOfferingsCache, awscache.NewImpairedZones(), awscache.NewSpotInterruptionHistory(fakeClock), awscache.NewInterruptionWindows(fakeClock),
+		subnet.NewDefaultProvider(&fake.EC2API{}, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AvailableIPAddressTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AssociatePublicIPAddressTTL, awscache.DefaultCleanupInterval)),
+		securitygroup.NewDefaultProvider(&fake.EC2API{}, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)))
 
 	messages, nodes := makeDiverseMessagesAndNodes(messageCount)
 	log.FromContext(ctx).Info("provisioning nodes")
@@ -0,0 +1,79 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package networkchange
+
+import (
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+)
+
+// Message contains the properties defined in AWS EventBridge schema
+// aws.ec2@AWSAPICallViaCloudTrail v0 for calls that create, delete or tag subnets and security groups.
+type Message struct {
+	messages.Metadata
+
+	Detail Detail `json:"detail"`
+}
+
+// EC2InstanceIDs returns no instances since a network change affects the discovery of subnets and security groups
+// rather than specific instances
+func (Message) EC2InstanceIDs() []string {
+	return nil
+}
+
+func (Message) Kind() messages.Kind {
+	return messages.NetworkChangeKind
+}
+
+// AffectsSubnets returns true if the call created, deleted or tagged a subnet
+func (m Message) AffectsSubnets() bool {
+	return m.affects("Subnet", "subnet-")
+}
+
+// AffectsSecurityGroups returns true if the call created, deleted or tagged a security group
+func (m Message) AffectsSecurityGroups() bool {
+	return m.affects("SecurityGroup", "sg-")
+}
+
+func (m Message) affects(resource, idPrefix string) bool {
+	if strings.HasSuffix(m.Detail.EventName, resource) {
+		return true
+	}
+	return lo.SomeBy(m.Detail.RequestParameters.ResourcesSet.Items, func(item ResourceItem) bool {
+		return strings.HasPrefix(item.ResourceID, idPrefix)
+	})
+}
+
+type Detail struct {
+	EventSource       string            `json:"eventSource"`
+	EventName         string            `json:"eventName"`
+	ErrorCode         string            `json:"errorCode"`
+	RequestParameters RequestParameters `json:"requestParameters"`
+}
+
+type RequestParameters struct {
+	ResourcesSet ResourcesSet `json:"resourcesSet"`
+}
+
+type ResourcesSet struct {
+	Items []ResourceItem `json:"items"`
+}
+
+type ResourceItem struct {
+	ResourceID string `json:"resourceId"`
+}
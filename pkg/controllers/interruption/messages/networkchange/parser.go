@@ -0,0 +1,64 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package networkchange
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/samber/lo"
+
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+)
+
+const acceptedEventSource = "ec2.amazonaws.com"
+
+var acceptedEventNames = []string{
+	"CreateSubnet",
+	"DeleteSubnet",
+	"CreateSecurityGroup",
+	"DeleteSecurityGroup",
+	"CreateTags",
+	"DeleteTags",
+}
+
+type Parser struct{}
+
+func (p Parser) Parse(raw string) (messages.Message, error) {
+	msg := Message{}
+	if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+		return nil, fmt.Errorf("unmarhsalling the message as AWSAPICallViaCloudTrail, %w", err)
+	}
+	// We ignore calls that we don't watch and calls that failed, as well as tags of other resources
+	if msg.Detail.EventSource != acceptedEventSource ||
+		!lo.Contains(acceptedEventNames, msg.Detail.EventName) ||
+		msg.Detail.ErrorCode != "" ||
+		(!msg.AffectsSubnets() && !msg.AffectsSecurityGroups()) {
+		return nil, nil
+	}
+	return msg, nil
+}
+
+func (p Parser) Version() string {
+	return "0"
+}
+
+func (p Parser) Source() string {
+	return "aws.ec2"
+}
+
+func (p Parser) DetailType() string {
+	return "AWS API Call via CloudTrail"
+}
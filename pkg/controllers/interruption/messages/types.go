@@ -30,6 +30,7 @@ type Message interface {
 	EC2InstanceIDs() []string
 	Kind() Kind
 	StartTime() time.Time
+	AccountID() string
 }
 
 type Kind string
@@ -39,6 +40,8 @@ const (
 	ScheduledChangeKind         Kind = "ScheduledChangeKind"
 	SpotInterruptionKind        Kind = "SpotInterruptionKind"
 	StateChangeKind             Kind = "StateChangeKind"
+	ZoneImpairmentKind          Kind = "ZoneImpairmentKind"
+	NetworkChangeKind           Kind = "NetworkChangeKind"
 	NoOpKind                    Kind = "NoOpKind"
 )
 
@@ -56,3 +59,9 @@ type Metadata struct {
 func (m Metadata) StartTime() time.Time {
 	return m.Time
 }
+
+// AccountID returns the account that emitted the event, which differs from the account of the queue when the event
+// was forwarded from another account
+func (m Metadata) AccountID() string {
+	return m.Account
+}
@@ -0,0 +1,66 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zoneimpairment
+
+import (
+	"time"
+
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+)
+
+const statusCodeClosed = "closed"
+
+// Message contains the properties defined in AWS EventBridge schema
+// aws.health@AWSHealthEvent v0 for issues that affect a single availability zone.
+type Message struct {
+	messages.Metadata
+
+	Detail Detail `json:"detail"`
+}
+
+// EC2InstanceIDs returns no instances since an impairment affects launches into the zone rather than specific instances
+func (Message) EC2InstanceIDs() []string {
+	return nil
+}
+
+func (Message) Kind() messages.Kind {
+	return messages.ZoneImpairmentKind
+}
+
+// Resolved returns true if AWS Health reports that the issue is no longer ongoing
+func (m Message) Resolved() bool {
+	return m.Detail.StatusCode == statusCodeClosed
+}
+
+// Until returns the time at which the issue is expected to end, or the zero time if the end time isn't known
+func (m Message) Until() time.Time {
+	for _, layout := range []string{time.RFC1123, time.RFC3339} {
+		if t, err := time.Parse(layout, m.Detail.EndTime); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+type Detail struct {
+	EventARN          string `json:"eventArn"`
+	EventTypeCode     string `json:"eventTypeCode"`
+	Service           string `json:"service"`
+	StatusCode        string `json:"statusCode"`
+	StartTime         string `json:"startTime"`
+	EndTime           string `json:"endTime"`
+	EventTypeCategory string `json:"eventTypeCategory"`
+	AvailabilityZone  string `json:"availabilityZone"`
+}
@@ -0,0 +1,55 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zoneimpairment
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+)
+
+const (
+	acceptedService           = "EC2"
+	acceptedEventTypeCategory = "issue"
+)
+
+type Parser struct{}
+
+func (p Parser) Parse(raw string) (messages.Message, error) {
+	msg := Message{}
+	if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+		return nil, fmt.Errorf("unmarhsalling the message as AWSHealthEvent, %w", err)
+	}
+	// We ignore services and event categories that we don't watch, as well as issues that aren't scoped to a zone
+	if msg.Detail.Service != acceptedService ||
+		msg.Detail.EventTypeCategory != acceptedEventTypeCategory ||
+		msg.Detail.AvailabilityZone == "" {
+		return nil, nil
+	}
+	return msg, nil
+}
+
+func (p Parser) Version() string {
+	return "0"
+}
+
+func (p Parser) Source() string {
+	return "aws.health"
+}
+
+func (p Parser) DetailType() string {
+	return "AWS Health Event"
+}
@@ -25,6 +25,7 @@ const (
 	interruptionSubsystem  = "interruption"
 	messageTypeLabel       = "message_type"
 	actionTypeLabel        = "action_type"
+	zoneLabel              = "zone"
 	terminationReasonLabel = "interruption"
 )
 
@@ -55,6 +56,15 @@ var (
 			Buckets:   metrics.DurationBuckets(),
 		},
 	)
+	impairedZoneExpiration = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: interruptionSubsystem,
+			Name:      "impaired_zone_expiration_timestamp_seconds",
+			Help:      "Unix timestamp until which new launches avoid a zone that has been reported as impaired. Labeled by zone.",
+		},
+		[]string{zoneLabel},
+	)
 	actionsPerformed = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: metrics.Namespace,
@@ -70,5 +80,5 @@ var (
 )
 
 func init() {
-	crmetrics.Registry.MustRegister(receivedMessages, deletedMessages, messageLatency, actionsPerformed)
+	crmetrics.Registry.MustRegister(receivedMessages, deletedMessages, messageLatency, impairedZoneExpiration, actionsPerformed)
 }
@@ -21,11 +21,13 @@ import (
 	"github.com/samber/lo"
 
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/networkchange"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/noop"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/rebalancerecommendation"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/scheduledchange"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/spotinterruption"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/statechange"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/zoneimpairment"
 )
 
 type parserKey struct {
@@ -56,18 +58,21 @@ var (
 		spotinterruption.Parser{},
 		scheduledchange.Parser{},
 		rebalancerecommendation.Parser{},
+		zoneimpairment.Parser{},
+		networkchange.Parser{},
 	}
 )
 
+// EventParser routes messages to the parsers registered for their version, source, and detail type. Multiple parsers
+// may be registered for the same event, e.g. AWS Health events, in which case the first parser that accepts the
+// message is used.
 type EventParser struct {
-	parserMap map[parserKey]messages.Parser
+	parserMap map[parserKey][]messages.Parser
 }
 
 func NewEventParser(parsers ...messages.Parser) *EventParser {
 	return &EventParser{
-		parserMap: lo.SliceToMap(parsers, func(p messages.Parser) (parserKey, messages.Parser) {
-			return newParserKeyFromParser(p), p
-		}),
+		parserMap: lo.GroupBy(parsers, newParserKeyFromParser),
 	}
 }
 
@@ -79,15 +84,18 @@ func (p EventParser) Parse(msg string) (messages.Message, error) {
 	if err := json.Unmarshal([]byte(msg), &md); err != nil {
 		return noop.Message{}, fmt.Errorf("unmarshalling the message as Metadata, %w", err)
 	}
-	if parser, ok := p.parserMap[newParserKey(md)]; ok {
+	parsers, ok := p.parserMap[newParserKey(md)]
+	if !ok {
+		return noop.Message{Metadata: md}, nil
+	}
+	for _, parser := range parsers {
 		evt, err := parser.Parse(msg)
 		if err != nil {
 			return noop.Message{}, fmt.Errorf("parsing event message, %w", err)
 		}
-		if evt == nil {
-			return noop.Message{}, nil
+		if evt != nil {
+			return evt, nil
 		}
-		return evt, nil
 	}
-	return noop.Message{Metadata: md}, nil
+	return noop.Message{}, nil
 }
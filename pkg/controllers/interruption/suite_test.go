@@ -25,7 +25,9 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
 	servicesqs "github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -40,14 +42,21 @@ import (
 	coretest "sigs.k8s.io/karpenter/pkg/test"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/networkchange"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/scheduledchange"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/spotinterruption"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/statechange"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/zoneimpairment"
 	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/sqs"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -67,6 +76,12 @@ var env *coretest.Environment
 var sqsapi *fake.SQSAPI
 var sqsProvider *sqs.DefaultProvider
 var unavailableOfferingsCache *awscache.UnavailableOfferings
+var impairedZonesCache *awscache.ImpairedZones
+var spotInterruptionHistory *awscache.SpotInterruptionHistory
+var interruptionWindows *awscache.InterruptionWindows
+var ec2api *fake.EC2API
+var subnetProvider *subnet.DefaultProvider
+var securityGroupProvider *securitygroup.DefaultProvider
 var fakeClock *clock.FakeClock
 var controller *interruption.Controller
 
@@ -80,9 +95,15 @@ var _ = BeforeSuite(func() {
 	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
 	fakeClock = &clock.FakeClock{}
 	unavailableOfferingsCache = awscache.NewUnavailableOfferings()
+	impairedZonesCache = awscache.NewImpairedZones()
+	spotInterruptionHistory = awscache.NewSpotInterruptionHistory(fakeClock)
+	interruptionWindows = awscache.NewInterruptionWindows(fakeClock)
 	sqsapi = &fake.SQSAPI{}
 	sqsProvider = lo.Must(sqs.NewDefaultProvider(sqsapi, fmt.Sprintf("https://sqs.%s.amazonaws.com/%s/test-cluster", fake.DefaultRegion, fake.DefaultAccount)))
-	controller = interruption.NewController(env.Client, fakeClock, events.NewRecorder(&record.FakeRecorder{}), sqsProvider, unavailableOfferingsCache)
+	ec2api = &fake.EC2API{}
+	subnetProvider = subnet.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AvailableIPAddressTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AssociatePublicIPAddressTTL, awscache.DefaultCleanupInterval))
+	securityGroupProvider = securitygroup.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+	controller = interruption.NewController(env.Client, fakeClock, events.NewRecorder(&record.FakeRecorder{}), sqsProvider, unavailableOfferingsCache, impairedZonesCache, spotInterruptionHistory, interruptionWindows, subnetProvider, securityGroupProvider)
 })
 
 var _ = AfterSuite(func() {
@@ -91,8 +112,15 @@ var _ = AfterSuite(func() {
 
 var _ = BeforeEach(func() {
 	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
 	unavailableOfferingsCache.Flush()
+	impairedZonesCache.Flush()
+	spotInterruptionHistory.Flush()
+	interruptionWindows.Flush()
 	sqsapi.Reset()
+	ec2api.Reset()
+	subnetProvider.Invalidate()
+	securityGroupProvider.Invalidate()
 })
 
 var _ = AfterEach(func() {
@@ -124,6 +152,28 @@ var _ = Describe("InterruptionHandling", func() {
 			ExpectNotFound(ctx, env.Client, nodeClaim)
 			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
 		})
+		It("should delete the NodeClaim of another account when receiving a message from the account", func() {
+			nodeClass := test.EC2NodeClass(v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{AssumeRoleARN: aws.String("arn:aws:iam::111122223333:role/KarpenterWorkloadRole")}})
+			nodeClaim.Spec.NodeClassRef.Name = nodeClass.Name
+			msg := spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID)))
+			msg.Account = "111122223333"
+			ExpectMessagesCreated(msg)
+			ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+		})
+		It("should not delete the NodeClaim of another account when receiving a message from a different account", func() {
+			nodeClass := test.EC2NodeClass(v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{AssumeRoleARN: aws.String("arn:aws:iam::111122223333:role/KarpenterWorkloadRole")}})
+			nodeClaim.Spec.NodeClassRef.Name = nodeClass.Name
+			ExpectMessagesCreated(spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+		})
 		It("should delete the NodeClaim when receiving a scheduled change message", func() {
 			ExpectMessagesCreated(scheduledChangeMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
 			ExpectApplied(ctx, env.Client, nodeClaim, node)
@@ -229,6 +279,109 @@ var _ = Describe("InterruptionHandling", func() {
 			// Expect a t3.large in coretest-zone-1a to be added to the ICE cache
 			Expect(unavailableOfferingsCache.IsUnavailable("t3.large", "coretest-zone-1a", karpv1.CapacityTypeSpot)).To(BeTrue())
 		})
+		It("should record the spot interruption in the history of the pool", func() {
+			nodeClaim.Labels = lo.Assign(nodeClaim.Labels, map[string]string{
+				corev1.LabelTopologyZone:       "coretest-zone-1a",
+				corev1.LabelInstanceTypeStable: "t3.large",
+				karpv1.CapacityTypeLabelKey:    karpv1.CapacityTypeSpot,
+			})
+			ExpectMessagesCreated(spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			Expect(spotInterruptionHistory.Weight("t3.large", "coretest-zone-1a")).To(BeNumerically("==", 1))
+			pools := spotInterruptionHistory.List()
+			Expect(pools).To(HaveLen(1))
+			Expect(pools[0].InstanceType).To(Equal("t3.large"))
+			Expect(pools[0].Interruptions).To(Equal(1))
+		})
+		It("should record the spot interruption in the interruption windows of the NodePool", func() {
+			interruptionWindows.Record(ctx, "default")
+			interruptionWindows.Record(ctx, "default")
+			ExpectMessagesCreated(spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			Expect(lo.T2(interruptionWindows.Busy("default")).A).To(BeTrue())
+		})
+		It("should avoid a zone when receiving a zone impairment message", func() {
+			ExpectMessagesCreated(zoneImpairmentMessage("coretest-zone-1a", "open", ""))
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+			// Zone impairments don't interrupt running capacity
+			ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(impairedZonesCache.IsImpaired("coretest-zone-1a")).To(BeTrue())
+			zones := impairedZonesCache.List()
+			Expect(zones).To(HaveLen(1))
+			Expect(zones[0].Until).To(BeTemporally("~", time.Now().Add(awscache.ZoneImpairmentTTL), time.Minute))
+
+			m, found := FindMetricWithLabelValues("karpenter_interruption_impaired_zone_expiration_timestamp_seconds", map[string]string{"zone": "coretest-zone-1a"})
+			Expect(found).To(BeTrue())
+			Expect(m.GetGauge().GetValue()).To(BeNumerically("==", zones[0].Until.Unix()))
+		})
+		It("should avoid a zone until the end time of the impairment", func() {
+			end := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Second)
+			ExpectMessagesCreated(zoneImpairmentMessage("coretest-zone-1a", "open", end.Format(time.RFC1123)))
+			ExpectSingletonReconciled(ctx, controller)
+			zones := impairedZonesCache.List()
+			Expect(zones).To(HaveLen(1))
+			Expect(zones[0].Until.Equal(end)).To(BeTrue())
+		})
+		It("should stop avoiding a zone when the impairment is resolved", func() {
+			impairedZonesCache.MarkImpaired(ctx, "AWS_EC2_OPERATIONAL_ISSUE", "coretest-zone-1a", time.Time{})
+			ExpectMessagesCreated(zoneImpairmentMessage("coretest-zone-1a", "closed", ""))
+			ExpectSingletonReconciled(ctx, controller)
+			Expect(impairedZonesCache.IsImpaired("coretest-zone-1a")).To(BeFalse())
+			_, found := FindMetricWithLabelValues("karpenter_interruption_impaired_zone_expiration_timestamp_seconds", map[string]string{"zone": "coretest-zone-1a"})
+			Expect(found).To(BeFalse())
+		})
+		It("should ignore AWS Health issues that aren't scoped to a zone", func() {
+			ExpectMessagesCreated(zoneImpairmentMessage("", "open", ""))
+			ExpectSingletonReconciled(ctx, controller)
+			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+			Expect(impairedZonesCache.List()).To(BeEmpty())
+		})
+		Context("Network Changes", func() {
+			var nodeClass *v1.EC2NodeClass
+			BeforeEach(func() {
+				nodeClass = test.EC2NodeClass()
+				Expect(subnetProvider.List(ctx, nodeClass)).To(HaveLen(4))
+				Expect(securityGroupProvider.List(ctx, nodeClass)).To(HaveLen(3))
+				ec2api.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{
+					{SubnetId: aws.String("subnet-new"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), Tags: []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("new")}}},
+				}})
+				ec2api.DescribeSecurityGroupsOutput.Set(&ec2.DescribeSecurityGroupsOutput{SecurityGroups: []*ec2.SecurityGroup{
+					{GroupId: aws.String("sg-new"), GroupName: aws.String("new"), Tags: []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("new")}}},
+				}})
+			})
+			It("should rediscover subnets when a subnet is created", func() {
+				ExpectMessagesCreated(networkChangeMessage("CreateSubnet", "", ""))
+				ExpectSingletonReconciled(ctx, controller)
+				Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+				Expect(subnetProvider.List(ctx, nodeClass)).To(HaveLen(1))
+				Expect(securityGroupProvider.List(ctx, nodeClass)).To(HaveLen(3))
+			})
+			It("should rediscover security groups when a security group is tagged", func() {
+				ExpectMessagesCreated(networkChangeMessage("CreateTags", "sg-new", ""))
+				ExpectSingletonReconciled(ctx, controller)
+				Expect(subnetProvider.List(ctx, nodeClass)).To(HaveLen(4))
+				Expect(securityGroupProvider.List(ctx, nodeClass)).To(HaveLen(1))
+			})
+			It("should ignore tags of other resources", func() {
+				ExpectMessagesCreated(networkChangeMessage("CreateTags", "i-0123456789abcdef0", ""))
+				ExpectSingletonReconciled(ctx, controller)
+				Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+				Expect(subnetProvider.List(ctx, nodeClass)).To(HaveLen(4))
+				Expect(securityGroupProvider.List(ctx, nodeClass)).To(HaveLen(3))
+			})
+			It("should ignore calls that failed", func() {
+				ExpectMessagesCreated(networkChangeMessage("DeleteSubnet", "", "Client.DependencyViolation"))
+				ExpectSingletonReconciled(ctx, controller)
+				Expect(subnetProvider.List(ctx, nodeClass)).To(HaveLen(4))
+			})
+		})
 	})
 })
 
@@ -307,6 +460,51 @@ func stateChangeMessage(involvedInstanceID, state string) statechange.Message {
 	}
 }
 
+func zoneImpairmentMessage(zone, statusCode, endTime string) zoneimpairment.Message {
+	return zoneimpairment.Message{
+		Metadata: messages.Metadata{
+			Version:    "0",
+			Account:    defaultAccountID,
+			DetailType: "AWS Health Event",
+			ID:         string(uuid.NewUUID()),
+			Region:     fake.DefaultRegion,
+			Source:     healthSource,
+			Time:       time.Now(),
+		},
+		Detail: zoneimpairment.Detail{
+			EventTypeCode:     "AWS_EC2_OPERATIONAL_ISSUE",
+			Service:           "EC2",
+			EventTypeCategory: "issue",
+			StatusCode:        statusCode,
+			EndTime:           endTime,
+			AvailabilityZone:  zone,
+		},
+	}
+}
+
+func networkChangeMessage(eventName, resourceID, errorCode string) networkchange.Message {
+	msg := networkchange.Message{
+		Metadata: messages.Metadata{
+			Version:    "0",
+			Account:    defaultAccountID,
+			DetailType: "AWS API Call via CloudTrail",
+			ID:         string(uuid.NewUUID()),
+			Region:     fake.DefaultRegion,
+			Source:     ec2Source,
+			Time:       time.Now(),
+		},
+		Detail: networkchange.Detail{
+			EventSource: "ec2.amazonaws.com",
+			EventName:   eventName,
+			ErrorCode:   errorCode,
+		},
+	}
+	if resourceID != "" {
+		msg.Detail.RequestParameters.ResourcesSet.Items = []networkchange.ResourceItem{{ResourceID: resourceID}}
+	}
+	return msg
+}
+
 func scheduledChangeMessage(involvedInstanceID string) scheduledchange.Message {
 	return scheduledchange.Message{
 		Metadata: messages.Metadata{
@@ -0,0 +1,417 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maintenancewindow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/patrickmn/go-cache"
+	"github.com/robfig/cron/v3"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+)
+
+// pollPeriod is how often the windows are reconciled when none of them starts or ends sooner, so that the changes to
+// the SSM maintenance windows are picked up
+const pollPeriod = time.Minute
+
+// ConfigMapName is the name of the ConfigMap in the namespace of Karpenter that the interruption windows of the
+// NodePools are persisted in
+const ConfigMapName = "karpenter-interruption-windows"
+
+// The reasons that the disruption of a NodePool is blocked for
+const (
+	outsideMaintenanceWindow = "outside of the maintenance window"
+	inBusyInterruptionWindow = "in an hour with frequent spot interruptions"
+)
+
+// Controller restricts the voluntary disruption of the nodes of NodePools, like consolidation and drift, to their
+// maintenance windows. The window of a NodePool is the SSM maintenance window of its karpenter.k8s.aws/maintenance-window
+// annotation, or the spec.maintenanceWindow of its EC2NodeClass. Outside of the window, a budget of zero nodes is added
+// to the disruption budgets of the NodePool, and it's removed when the window starts. The NodePool is annotated with
+// karpenter.k8s.aws/maintenance-window-budget while it has the budget, so that only the budget that was added is removed.
+// NodePools that are annotated with karpenter.k8s.aws/interruption-aware-disruption are also blocked during the hours of
+// the day in which their nodes frequently receive spot interruptions and rebalance recommendations. The interruption
+// windows are persisted in a ConfigMap, and restored from it once the controller starts, so that they outlive restarts.
+type Controller struct {
+	kubeClient          client.Client
+	reader              client.Reader
+	clk                 clock.Clock
+	recorder            events.Recorder
+	ssmapi              ssmiface.SSMAPI
+	cache               *cache.Cache
+	interruptionWindows *awscache.InterruptionWindows
+	namespace           string
+	restored            bool
+}
+
+func NewController(kubeClient client.Client, reader client.Reader, clk clock.Clock, recorder events.Recorder, ssmapi ssmiface.SSMAPI, cache *cache.Cache,
+	interruptionWindows *awscache.InterruptionWindows, namespace string) *Controller {
+	return &Controller{
+		kubeClient:          kubeClient,
+		reader:              reader,
+		clk:                 clk,
+		recorder:            recorder,
+		ssmapi:              ssmapi,
+		cache:               cache,
+		interruptionWindows: interruptionWindows,
+		namespace:           namespace,
+	}
+}
+
+// invalidError is the error of a maintenance window that can't be used, like a window that doesn't exist, which isn't
+// retried until the window is polled again
+type invalidError struct {
+	error
+}
+
+// Schedule is a recurring window. Each window starts Offset after a time of the cron schedule, and lasts Duration.
+type Schedule struct {
+	Cron     string
+	TimeZone string
+	Duration time.Duration
+	Offset   time.Duration
+	// Disabled schedules don't have windows, so the nodes are never disrupted
+	Disabled bool
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "maintenancewindow")
+
+	if !c.restored {
+		if err := c.restore(ctx); err != nil {
+			return reconcile.Result{}, err
+		}
+		c.restored = true
+	}
+	nodePools := &karpv1.NodePoolList{}
+	if err := c.kubeClient.List(ctx, nodePools); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodepools, %w", err)
+	}
+	now := c.clk.Now()
+	requeueAfter := pollPeriod
+	var errs []error
+	for i := range nodePools.Items {
+		nodePool := &nodePools.Items[i]
+		if !nodePool.DeletionTimestamp.IsZero() {
+			continue
+		}
+		schedule, err := c.schedule(ctx, nodePool)
+		var start, end time.Time
+		if err == nil && schedule != nil {
+			if start, end, err = Window(*schedule, now); err != nil {
+				err = invalidError{err}
+			}
+		}
+		switch {
+		case err != nil && !errors.As(err, &invalidError{}):
+			errs = append(errs, err)
+		// The nodes of NodePools whose window can't be used aren't disrupted until it's fixed
+		case err != nil:
+			c.recorder.Publish(InvalidMaintenanceWindowEvent(nodePool, err.Error()))
+			errs = append(errs, c.block(ctx, nodePool, outsideMaintenanceWindow))
+		case schedule == nil:
+			errs = append(errs, c.unblockUnlessBusy(ctx, nodePool, &requeueAfter))
+		case schedule.Disabled:
+			errs = append(errs, c.block(ctx, nodePool, outsideMaintenanceWindow))
+		case now.Before(start):
+			errs = append(errs, c.block(ctx, nodePool, outsideMaintenanceWindow))
+			requeueAfter = lo.Min([]time.Duration{requeueAfter, start.Sub(now)})
+		default:
+			errs = append(errs, c.unblockUnlessBusy(ctx, nodePool, &requeueAfter))
+			requeueAfter = lo.Min([]time.Duration{requeueAfter, end.Sub(now)})
+		}
+	}
+	errs = append(errs, c.persist(ctx))
+	if err := multierr.Combine(errs...); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// schedule returns the schedule of the maintenance window of the NodePool, or nil if it doesn't have one. The SSM
+// maintenance window of its annotation takes precedence over the maintenance window of its EC2NodeClass.
+func (c *Controller) schedule(ctx context.Context, nodePool *karpv1.NodePool) (*Schedule, error) {
+	if id, ok := nodePool.Annotations[v1.AnnotationMaintenanceWindow]; ok {
+		window, err := c.maintenanceWindow(ctx, id)
+		if err != nil {
+			err = fmt.Errorf("getting maintenance window %s, %w", id, err)
+			if awserrors.IsNotFound(err) || awserrors.IsAccessDenied(err) {
+				return nil, invalidError{err}
+			}
+			return nil, err
+		}
+		schedule, err := FromMaintenanceWindow(window)
+		if err != nil {
+			return nil, invalidError{err}
+		}
+		return schedule, nil
+	}
+	nodeClass, err := c.nodeClass(ctx, nodePool)
+	if err != nil || nodeClass == nil || nodeClass.Spec.MaintenanceWindow == nil {
+		return nil, err
+	}
+	return &Schedule{
+		Cron:     nodeClass.Spec.MaintenanceWindow.Schedule,
+		TimeZone: nodeClass.Spec.MaintenanceWindow.TimeZone,
+		Duration: nodeClass.Spec.MaintenanceWindow.Duration.Duration,
+	}, nil
+}
+
+func (c *Controller) maintenanceWindow(ctx context.Context, id string) (*ssm.GetMaintenanceWindowOutput, error) {
+	if window, ok := c.cache.Get(id); ok {
+		return window.(*ssm.GetMaintenanceWindowOutput), nil
+	}
+	window, err := c.ssmapi.GetMaintenanceWindowWithContext(ctx, &ssm.GetMaintenanceWindowInput{WindowId: aws.String(id)})
+	if err != nil {
+		return nil, err
+	}
+	c.cache.SetDefault(id, window)
+	return window, nil
+}
+
+// nodeClass returns the EC2NodeClass of the NodePool, or nil if the NodePool references another kind of node class or
+// its EC2NodeClass doesn't exist
+func (c *Controller) nodeClass(ctx context.Context, nodePool *karpv1.NodePool) (*v1.EC2NodeClass, error) {
+	ref := nodePool.Spec.Template.Spec.NodeClassRef
+	if ref == nil || ref.Group != object.GVK(&v1.EC2NodeClass{}).Group || ref.Kind != object.GVK(&v1.EC2NodeClass{}).Kind {
+		return nil, nil
+	}
+	nodeClass := &v1.EC2NodeClass{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: ref.Name}, nodeClass); err != nil {
+		return nil, client.IgnoreNotFound(fmt.Errorf("getting ec2nodeclass, %w", err))
+	}
+	return nodeClass, nil
+}
+
+// restore adds the interruption windows that were persisted before the controller started to the ones that were
+// recorded since. Windows that can't be parsed are skipped.
+func (c *Controller) restore(ctx context.Context) error {
+	configMap := &corev1.ConfigMap{}
+	if err := c.reader.Get(ctx, types.NamespacedName{Namespace: c.namespace, Name: ConfigMapName}, configMap); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("getting interruption windows, %w", err))
+	}
+	windows := map[string][]time.Time{}
+	for nodePool, data := range configMap.Data {
+		var events []time.Time
+		if err := json.Unmarshal([]byte(data), &events); err != nil {
+			log.FromContext(ctx).WithValues("NodePool", klog.KRef("", nodePool)).Error(err, "failed parsing interruption windows")
+			continue
+		}
+		windows[nodePool] = events
+	}
+	c.interruptionWindows.Restore(windows)
+	return nil
+}
+
+// persist writes the interruption windows to the ConfigMap when they changed, creating the ConfigMap if it doesn't exist
+func (c *Controller) persist(ctx context.Context) error {
+	data := map[string]string{}
+	for nodePool, events := range c.interruptionWindows.Snapshot() {
+		raw, err := json.Marshal(events)
+		if err != nil {
+			return fmt.Errorf("marshaling interruption windows, %w", err)
+		}
+		data[nodePool] = string(raw)
+	}
+	configMap := &corev1.ConfigMap{}
+	if err := c.reader.Get(ctx, types.NamespacedName{Namespace: c.namespace, Name: ConfigMapName}, configMap); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("getting interruption windows, %w", err)
+		}
+		if len(data) == 0 {
+			return nil
+		}
+		configMap = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: c.namespace}, Data: data}
+		if err := c.kubeClient.Create(ctx, configMap); err != nil {
+			return fmt.Errorf("creating interruption windows, %w", err)
+		}
+		return nil
+	}
+	if len(configMap.Data) == 0 && len(data) == 0 || reflect.DeepEqual(configMap.Data, data) {
+		return nil
+	}
+	stored := configMap.DeepCopy()
+	configMap.Data = data
+	if err := c.kubeClient.Patch(ctx, configMap, client.MergeFrom(stored)); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("patching interruption windows, %w", err)
+	}
+	return nil
+}
+
+// unblockUnlessBusy allows the disruption of the NodePool, unless it's interruption-aware and the current hour is busy
+// with the spot interruptions of its nodes, in which case its disruption is blocked until the hour ends
+func (c *Controller) unblockUnlessBusy(ctx context.Context, nodePool *karpv1.NodePool, requeueAfter *time.Duration) error {
+	if nodePool.Annotations[v1.AnnotationInterruptionAwareDisruption] == "true" {
+		if busy, remaining := c.interruptionWindows.Busy(nodePool.Name); busy {
+			*requeueAfter = lo.Min([]time.Duration{*requeueAfter, remaining})
+			return c.block(ctx, nodePool, inBusyInterruptionWindow)
+		}
+	}
+	return c.unblock(ctx, nodePool)
+}
+
+// block adds a budget of zero nodes to the NodePool, which stops its nodes from being voluntarily disrupted. The budget
+// is added again when it was removed by another client, like a GitOps tool that applied the budgets of the NodePool.
+func (c *Controller) block(ctx context.Context, nodePool *karpv1.NodePool, reason string) error {
+	if _, ok := addedBudget(nodePool); ok {
+		return nil
+	}
+	stored := nodePool.DeepCopy()
+	nodePool.Spec.Disruption.Budgets = append(nodePool.Spec.Disruption.Budgets, karpv1.Budget{Nodes: "0"})
+	nodePool.Annotations = lo.Assign(nodePool.Annotations, map[string]string{
+		v1.AnnotationMaintenanceWindowBudget: strconv.Itoa(len(nodePool.Spec.Disruption.Budgets) - 1),
+	})
+	// The optimistic lock stops the budgets that are changed concurrently from being overwritten
+	if err := c.kubeClient.Patch(ctx, nodePool, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("blocking disruption of nodepool %s, %w", nodePool.Name, err))
+	}
+	log.FromContext(ctx).WithValues("NodePool", nodePool.Name).Info("blocked disruption " + reason)
+	c.recorder.Publish(DisruptionBlockedEvent(nodePool, reason))
+	return nil
+}
+
+// unblock removes the budget that was added to the NodePool when its maintenance window ended
+func (c *Controller) unblock(ctx context.Context, nodePool *karpv1.NodePool) error {
+	if _, ok := nodePool.Annotations[v1.AnnotationMaintenanceWindowBudget]; !ok {
+		return nil
+	}
+	stored := nodePool.DeepCopy()
+	if i, ok := addedBudget(nodePool); ok {
+		nodePool.Spec.Disruption.Budgets = append(nodePool.Spec.Disruption.Budgets[:i:i], nodePool.Spec.Disruption.Budgets[i+1:]...)
+	}
+	delete(nodePool.Annotations, v1.AnnotationMaintenanceWindowBudget)
+	if err := c.kubeClient.Patch(ctx, nodePool, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("allowing disruption of nodepool %s, %w", nodePool.Name, err))
+	}
+	log.FromContext(ctx).WithValues("NodePool", nodePool.Name).Info("allowed disruption during maintenance window")
+	c.recorder.Publish(DisruptionAllowedEvent(nodePool))
+	return nil
+}
+
+// addedBudget returns the index of the budget that was added to the NodePool, which is recorded in its annotation. The
+// budget is only present when the budget at the index is still a budget of zero nodes, so that the budgets of other
+// clients aren't removed once the added budget was dropped or moved by them.
+func addedBudget(nodePool *karpv1.NodePool) (int, bool) {
+	i, err := strconv.Atoi(nodePool.Annotations[v1.AnnotationMaintenanceWindowBudget])
+	if err != nil || i < 0 || i >= len(nodePool.Spec.Disruption.Budgets) || !isBlockingBudget(nodePool.Spec.Disruption.Budgets[i]) {
+		return 0, false
+	}
+	return i, true
+}
+
+// isBlockingBudget is whether the budget is the one that's added outside of the maintenance window
+func isBlockingBudget(budget karpv1.Budget) bool {
+	return budget.Nodes == "0" && budget.Schedule == nil && budget.Duration == nil && len(budget.Reasons) == 0
+}
+
+// Window returns the start and the end of the earliest window of the schedule that hasn't ended at now, which is the
+// active window, or the next window if none is active
+func Window(schedule Schedule, now time.Time) (time.Time, time.Time, error) {
+	spec := schedule.Cron
+	if schedule.TimeZone != "" {
+		if _, err := time.LoadLocation(schedule.TimeZone); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid time zone %q", schedule.TimeZone)
+		}
+		spec = fmt.Sprintf("CRON_TZ=%s %s", schedule.TimeZone, spec)
+	}
+	cronSchedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid schedule %q, %w", schedule.Cron, err)
+	}
+	if schedule.Duration <= 0 {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid duration %s", schedule.Duration)
+	}
+	start := cronSchedule.Next(now.Add(-schedule.Duration - schedule.Offset)).Add(schedule.Offset)
+	return start, start.Add(schedule.Duration), nil
+}
+
+// ssmCron matches the cron expressions of SSM maintenance windows, which have six fields: minutes, hours, day of month,
+// month, day of week and year
+var ssmCron = regexp.MustCompile(`^cron\((\S+) (\S+) (\S+) (\S+) (\S+) (\S+)\)$`)
+
+// FromMaintenanceWindow returns the schedule of an SSM maintenance window. The windows of its cron expression end at
+// its cutoff, so that nodes aren't disrupted after tasks of the window can no longer start. Rate and at expressions, and
+// cron expressions that use L, W or #, aren't supported.
+func FromMaintenanceWindow(window *ssm.GetMaintenanceWindowOutput) (*Schedule, error) {
+	fields := ssmCron.FindStringSubmatch(aws.StringValue(window.Schedule))
+	if fields == nil {
+		return nil, fmt.Errorf("unsupported schedule %q of maintenance window %s, only cron expressions are supported", aws.StringValue(window.Schedule), aws.StringValue(window.WindowId))
+	}
+	minutes, hours, dayOfMonth, month, dayOfWeek, year := fields[1], fields[2], fields[3], fields[4], fields[5], fields[6]
+	if year != "*" || strings.ContainsAny(dayOfMonth+dayOfWeek, "LW#") {
+		return nil, fmt.Errorf("unsupported schedule %q of maintenance window %s", aws.StringValue(window.Schedule), aws.StringValue(window.WindowId))
+	}
+	return &Schedule{
+		Cron:     strings.Join([]string{minutes, hours, strings.ReplaceAll(dayOfMonth, "?", "*"), month, ssmDayOfWeek(dayOfWeek)}, " "),
+		TimeZone: aws.StringValue(window.ScheduleTimezone),
+		Duration: time.Duration(aws.Int64Value(window.Duration)-aws.Int64Value(window.Cutoff)) * time.Hour,
+		Offset:   time.Duration(aws.Int64Value(window.ScheduleOffset)) * 24 * time.Hour,
+		Disabled: !aws.BoolValue(window.Enabled),
+	}, nil
+}
+
+// ssmDayOfWeek converts the day of week field of an SSM cron expression to the standard syntax. The days of SSM are
+// numbered from 1 (Sunday) to 7 (Saturday), rather than from 0 to 6, and the steps after a / aren't days.
+func ssmDayOfWeek(field string) string {
+	return strings.Join(lo.Map(strings.Split(strings.ReplaceAll(field, "?", "*"), ","), func(entry string, _ int) string {
+		days, step, stepped := strings.Cut(entry, "/")
+		days = strings.Join(lo.Map(strings.Split(days, "-"), func(day string, _ int) string {
+			if n, err := strconv.Atoi(day); err == nil {
+				return strconv.Itoa(n - 1)
+			}
+			return day
+		}), "-")
+		return lo.Ternary(stepped, days+"/"+step, days)
+	}), ",")
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("maintenancewindow").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
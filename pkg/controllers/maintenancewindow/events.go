@@ -0,0 +1,52 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maintenancewindow
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	"sigs.k8s.io/karpenter/pkg/events"
+)
+
+func InvalidMaintenanceWindowEvent(nodePool *karpv1.NodePool, reason string) events.Event {
+	return events.Event{
+		InvolvedObject: nodePool,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "InvalidMaintenanceWindow",
+		Message:        "Blocking disruption, the maintenance window can't be used, " + reason,
+		DedupeValues:   []string{string(nodePool.UID), reason},
+	}
+}
+
+func DisruptionBlockedEvent(nodePool *karpv1.NodePool, reason string) events.Event {
+	return events.Event{
+		InvolvedObject: nodePool,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "DisruptionBlocked",
+		Message:        "Blocked disruption " + reason,
+		DedupeValues:   []string{string(nodePool.UID), reason},
+	}
+}
+
+func DisruptionAllowedEvent(nodePool *karpv1.NodePool) events.Event {
+	return events.Event{
+		InvolvedObject: nodePool,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "DisruptionAllowed",
+		Message:        "Allowed disruption during the maintenance window",
+		DedupeValues:   []string{string(nodePool.UID)},
+	}
+}
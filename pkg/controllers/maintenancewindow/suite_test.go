@@ -0,0 +1,362 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maintenancewindow_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/patrickmn/go-cache"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/maintenancewindow"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var kubeClient client.Client
+var fakeClock *clock.FakeClock
+var ssmapi *fake.SSMAPI
+var recorder *coretest.EventRecorder
+var interruptionWindows *awscache.InterruptionWindows
+var controller *maintenancewindow.Controller
+var nodePool *karpv1.NodePool
+var nodeClass *v1.EC2NodeClass
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "MaintenanceWindow")
+}
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	kubeClient = crfake.NewClientBuilder().Build()
+	// Saturday, 00:00 UTC
+	fakeClock = clock.NewFakeClock(time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC))
+	ssmapi = fake.NewSSMAPI()
+	recorder = coretest.NewEventRecorder()
+	interruptionWindows = awscache.NewInterruptionWindows(fakeClock)
+	controller = maintenancewindow.NewController(kubeClient, kubeClient, fakeClock, recorder, ssmapi, cache.New(time.Minute, time.Minute), interruptionWindows, "karpenter")
+
+	nodeClass = test.EC2NodeClass()
+	nodeClass.Spec.MaintenanceWindow = &v1.MaintenanceWindow{Schedule: "0 2 * * 6", Duration: metav1.Duration{Duration: 4 * time.Hour}}
+	nodePool = coretest.NodePool()
+	nodePool.Spec.Disruption.Budgets = []karpv1.Budget{{Nodes: "10%"}}
+	nodePool.Spec.Template.Spec.NodeClassRef = &karpv1.NodeClassReference{Group: "karpenter.k8s.aws", Kind: "EC2NodeClass", Name: nodeClass.Name}
+	Expect(kubeClient.Create(ctx, nodeClass)).To(Succeed())
+})
+
+var _ = Describe("MaintenanceWindow", func() {
+	It("should block disruption outside of the maintenance window of the EC2NodeClass", func() {
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		result := ExpectSingletonReconciled(ctx, controller)
+		nodePool = ExpectExists(ctx, kubeClient, nodePool)
+		Expect(nodePool.Spec.Disruption.Budgets).To(Equal([]karpv1.Budget{{Nodes: "10%"}, {Nodes: "0"}}))
+		Expect(nodePool.Annotations).To(HaveKeyWithValue(v1.AnnotationMaintenanceWindowBudget, "1"))
+		Expect(result.RequeueAfter).To(Equal(time.Minute))
+		Expect(recorder.Calls("DisruptionBlocked")).To(Equal(1))
+	})
+	It("should allow disruption during the maintenance window and block it once the window ends", func() {
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+
+		fakeClock.Step(3 * time.Hour)
+		result := ExpectSingletonReconciled(ctx, controller)
+		nodePool = ExpectExists(ctx, kubeClient, nodePool)
+		Expect(nodePool.Spec.Disruption.Budgets).To(Equal([]karpv1.Budget{{Nodes: "10%"}}))
+		Expect(nodePool.Annotations).ToNot(HaveKey(v1.AnnotationMaintenanceWindowBudget))
+		Expect(result.RequeueAfter).To(Equal(time.Minute))
+
+		fakeClock.Step(3 * time.Hour)
+		ExpectSingletonReconciled(ctx, controller)
+		nodePool = ExpectExists(ctx, kubeClient, nodePool)
+		Expect(nodePool.Spec.Disruption.Budgets).To(Equal([]karpv1.Budget{{Nodes: "10%"}, {Nodes: "0"}}))
+	})
+	It("should requeue when the maintenance window starts or ends", func() {
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		fakeClock.Step(119 * time.Minute)
+		Expect(ExpectSingletonReconciled(ctx, controller).RequeueAfter).To(Equal(time.Minute))
+		fakeClock.Step(time.Minute + 4*time.Hour - 30*time.Second)
+		Expect(ExpectSingletonReconciled(ctx, controller).RequeueAfter).To(Equal(30 * time.Second))
+	})
+	It("should only remove the budget that was added", func() {
+		nodePool.Spec.Disruption.Budgets = []karpv1.Budget{{Nodes: "0", Reasons: []karpv1.DisruptionReason{karpv1.DisruptionReasonDrifted}}, {Nodes: "10%"}}
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		fakeClock.Step(3 * time.Hour)
+		ExpectSingletonReconciled(ctx, controller)
+		nodePool = ExpectExists(ctx, kubeClient, nodePool)
+		Expect(nodePool.Spec.Disruption.Budgets).To(Equal([]karpv1.Budget{{Nodes: "0", Reasons: []karpv1.DisruptionReason{karpv1.DisruptionReasonDrifted}}, {Nodes: "10%"}}))
+	})
+	It("should add the budget again when it was removed by another client", func() {
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		nodePool = ExpectExists(ctx, kubeClient, nodePool)
+		nodePool.Spec.Disruption.Budgets = []karpv1.Budget{{Nodes: "10%"}}
+		Expect(kubeClient.Update(ctx, nodePool)).To(Succeed())
+
+		ExpectSingletonReconciled(ctx, controller)
+		nodePool = ExpectExists(ctx, kubeClient, nodePool)
+		Expect(nodePool.Spec.Disruption.Budgets).To(Equal([]karpv1.Budget{{Nodes: "10%"}, {Nodes: "0"}}))
+		Expect(nodePool.Annotations).To(HaveKeyWithValue(v1.AnnotationMaintenanceWindowBudget, "1"))
+	})
+	It("should not remove a budget of zero nodes of another client when the added budget was removed", func() {
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		nodePool = ExpectExists(ctx, kubeClient, nodePool)
+		nodePool.Spec.Disruption.Budgets = []karpv1.Budget{{Nodes: "0"}}
+		Expect(kubeClient.Update(ctx, nodePool)).To(Succeed())
+
+		fakeClock.Step(3 * time.Hour)
+		ExpectSingletonReconciled(ctx, controller)
+		nodePool = ExpectExists(ctx, kubeClient, nodePool)
+		Expect(nodePool.Spec.Disruption.Budgets).To(Equal([]karpv1.Budget{{Nodes: "0"}}))
+		Expect(nodePool.Annotations).ToNot(HaveKey(v1.AnnotationMaintenanceWindowBudget))
+	})
+	It("should remove the budget when the maintenance window is removed", func() {
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		nodeClass.Spec.MaintenanceWindow = nil
+		Expect(kubeClient.Update(ctx, nodeClass)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		nodePool = ExpectExists(ctx, kubeClient, nodePool)
+		Expect(nodePool.Spec.Disruption.Budgets).To(Equal([]karpv1.Budget{{Nodes: "10%"}}))
+		Expect(nodePool.Annotations).ToNot(HaveKey(v1.AnnotationMaintenanceWindowBudget))
+	})
+	It("should not change the budgets of NodePools without a maintenance window", func() {
+		nodeClass.Spec.MaintenanceWindow = nil
+		Expect(kubeClient.Update(ctx, nodeClass)).To(Succeed())
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		nodePool = ExpectExists(ctx, kubeClient, nodePool)
+		Expect(nodePool.Spec.Disruption.Budgets).To(Equal([]karpv1.Budget{{Nodes: "10%"}}))
+	})
+	It("should use the SSM maintenance window of the NodePool over the maintenance window of the EC2NodeClass", func() {
+		ssmapi.MaintenanceWindows = map[string]*ssm.GetMaintenanceWindowOutput{
+			"mw-0123456789abcdef0": {
+				WindowId: aws.String("mw-0123456789abcdef0"),
+				Schedule: aws.String("cron(0 23 ? * FRI *)"),
+				Duration: aws.Int64(3),
+				Cutoff:   aws.Int64(1),
+				Enabled:  aws.Bool(true),
+			},
+		}
+		nodePool.Annotations = map[string]string{v1.AnnotationMaintenanceWindow: "mw-0123456789abcdef0"}
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		nodePool = ExpectExists(ctx, kubeClient, nodePool)
+		Expect(nodePool.Spec.Disruption.Budgets).To(Equal([]karpv1.Budget{{Nodes: "10%"}}))
+
+		// The window ends at its cutoff
+		fakeClock.Step(time.Hour)
+		ExpectSingletonReconciled(ctx, controller)
+		nodePool = ExpectExists(ctx, kubeClient, nodePool)
+		Expect(nodePool.Spec.Disruption.Budgets).To(Equal([]karpv1.Budget{{Nodes: "10%"}, {Nodes: "0"}}))
+	})
+	It("should block disruption of NodePools whose SSM maintenance window is disabled", func() {
+		ssmapi.MaintenanceWindows = map[string]*ssm.GetMaintenanceWindowOutput{
+			"mw-0123456789abcdef0": {
+				WindowId: aws.String("mw-0123456789abcdef0"),
+				Schedule: aws.String("cron(0 23 ? * FRI *)"),
+				Duration: aws.Int64(3),
+				Enabled:  aws.Bool(false),
+			},
+		}
+		nodePool.Annotations = map[string]string{v1.AnnotationMaintenanceWindow: "mw-0123456789abcdef0"}
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		nodePool = ExpectExists(ctx, kubeClient, nodePool)
+		Expect(nodePool.Spec.Disruption.Budgets).To(Equal([]karpv1.Budget{{Nodes: "10%"}, {Nodes: "0"}}))
+	})
+	It("should block disruption of NodePools whose SSM maintenance window doesn't exist", func() {
+		nodePool.Annotations = map[string]string{v1.AnnotationMaintenanceWindow: "mw-0123456789abcdef0"}
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		nodePool = ExpectExists(ctx, kubeClient, nodePool)
+		Expect(nodePool.Spec.Disruption.Budgets).To(Equal([]karpv1.Budget{{Nodes: "10%"}, {Nodes: "0"}}))
+		Expect(recorder.Calls("InvalidMaintenanceWindow")).To(Equal(1))
+	})
+	It("should block disruption of NodePools whose maintenance window is invalid", func() {
+		nodeClass.Spec.MaintenanceWindow.TimeZone = "Mars/Olympus_Mons"
+		Expect(kubeClient.Update(ctx, nodeClass)).To(Succeed())
+		fakeClock.Step(3 * time.Hour)
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		nodePool = ExpectExists(ctx, kubeClient, nodePool)
+		Expect(nodePool.Spec.Disruption.Budgets).To(Equal([]karpv1.Budget{{Nodes: "10%"}, {Nodes: "0"}}))
+		Expect(recorder.Calls("InvalidMaintenanceWindow")).To(Equal(1))
+	})
+	Context("Interruption-Aware Disruption", func() {
+		BeforeEach(func() {
+			nodeClass.Spec.MaintenanceWindow = nil
+			Expect(kubeClient.Update(ctx, nodeClass)).To(Succeed())
+			nodePool.Annotations = map[string]string{v1.AnnotationInterruptionAwareDisruption: "true"}
+		})
+		It("should block disruption in the hours with frequent spot interruptions until the hour ends", func() {
+			for range 3 {
+				interruptionWindows.Record(ctx, nodePool.Name)
+			}
+			Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+			ExpectSingletonReconciled(ctx, controller)
+			nodePool = ExpectExists(ctx, kubeClient, nodePool)
+			Expect(nodePool.Spec.Disruption.Budgets).To(Equal([]karpv1.Budget{{Nodes: "10%"}, {Nodes: "0"}}))
+			Expect(recorder.Calls("DisruptionBlocked")).To(Equal(1))
+
+			fakeClock.Step(time.Hour)
+			ExpectSingletonReconciled(ctx, controller)
+			nodePool = ExpectExists(ctx, kubeClient, nodePool)
+			Expect(nodePool.Spec.Disruption.Budgets).To(Equal([]karpv1.Budget{{Nodes: "10%"}}))
+		})
+		It("should requeue when the busy hour ends", func() {
+			fakeClock.Step(59*time.Minute + 30*time.Second)
+			for range 3 {
+				interruptionWindows.Record(ctx, nodePool.Name)
+			}
+			Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+			Expect(ExpectSingletonReconciled(ctx, controller).RequeueAfter).To(Equal(30 * time.Second))
+		})
+		It("should not block disruption in hours with as many spot interruptions as the other hours", func() {
+			for range 24 {
+				interruptionWindows.Record(ctx, nodePool.Name)
+				fakeClock.Step(time.Hour)
+			}
+			Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+			ExpectSingletonReconciled(ctx, controller)
+			nodePool = ExpectExists(ctx, kubeClient, nodePool)
+			Expect(nodePool.Spec.Disruption.Budgets).To(Equal([]karpv1.Budget{{Nodes: "10%"}}))
+		})
+		It("should not block disruption of NodePools that aren't interruption-aware", func() {
+			nodePool.Annotations = nil
+			for range 3 {
+				interruptionWindows.Record(ctx, nodePool.Name)
+			}
+			Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+			ExpectSingletonReconciled(ctx, controller)
+			nodePool = ExpectExists(ctx, kubeClient, nodePool)
+			Expect(nodePool.Spec.Disruption.Budgets).To(Equal([]karpv1.Budget{{Nodes: "10%"}}))
+		})
+		It("should block disruption during the maintenance window in the hours with frequent spot interruptions", func() {
+			nodeClass.Spec.MaintenanceWindow = &v1.MaintenanceWindow{Schedule: "0 2 * * 6", Duration: metav1.Duration{Duration: 4 * time.Hour}}
+			Expect(kubeClient.Update(ctx, nodeClass)).To(Succeed())
+			fakeClock.Step(3 * time.Hour)
+			for range 3 {
+				interruptionWindows.Record(ctx, nodePool.Name)
+			}
+			Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+			ExpectSingletonReconciled(ctx, controller)
+			nodePool = ExpectExists(ctx, kubeClient, nodePool)
+			Expect(nodePool.Spec.Disruption.Budgets).To(Equal([]karpv1.Budget{{Nodes: "10%"}, {Nodes: "0"}}))
+		})
+		It("should persist the interruption windows", func() {
+			for range 3 {
+				interruptionWindows.Record(ctx, nodePool.Name)
+			}
+			Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+			ExpectSingletonReconciled(ctx, controller)
+			configMap := &corev1.ConfigMap{}
+			Expect(kubeClient.Get(ctx, types.NamespacedName{Namespace: "karpenter", Name: maintenancewindow.ConfigMapName}, configMap)).To(Succeed())
+			var events []time.Time
+			Expect(json.Unmarshal([]byte(configMap.Data[nodePool.Name]), &events)).To(Succeed())
+			Expect(events).To(HaveLen(3))
+
+			interruptionWindows.Record(ctx, nodePool.Name)
+			ExpectSingletonReconciled(ctx, controller)
+			Expect(kubeClient.Get(ctx, types.NamespacedName{Namespace: "karpenter", Name: maintenancewindow.ConfigMapName}, configMap)).To(Succeed())
+			Expect(json.Unmarshal([]byte(configMap.Data[nodePool.Name]), &events)).To(Succeed())
+			Expect(events).To(HaveLen(4))
+		})
+		It("should restore the persisted interruption windows after a restart", func() {
+			for range 3 {
+				interruptionWindows.Record(ctx, nodePool.Name)
+			}
+			Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+			ExpectSingletonReconciled(ctx, controller)
+
+			interruptionWindows = awscache.NewInterruptionWindows(fakeClock)
+			controller = maintenancewindow.NewController(kubeClient, kubeClient, fakeClock, recorder, ssmapi, cache.New(time.Minute, time.Minute), interruptionWindows, "karpenter")
+			fakeClock.Step(24 * time.Hour)
+			ExpectSingletonReconciled(ctx, controller)
+			nodePool = ExpectExists(ctx, kubeClient, nodePool)
+			Expect(nodePool.Spec.Disruption.Budgets).To(Equal([]karpv1.Budget{{Nodes: "10%"}, {Nodes: "0"}}))
+		})
+		It("should skip persisted interruption windows that can't be parsed", func() {
+			Expect(kubeClient.Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "karpenter", Name: maintenancewindow.ConfigMapName},
+				Data:       map[string]string{nodePool.Name: "invalid"},
+			})).To(Succeed())
+			Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+			ExpectSingletonReconciled(ctx, controller)
+			nodePool = ExpectExists(ctx, kubeClient, nodePool)
+			Expect(nodePool.Spec.Disruption.Budgets).To(Equal([]karpv1.Budget{{Nodes: "10%"}}))
+		})
+	})
+	DescribeTable("should convert the schedules of SSM maintenance windows",
+		func(schedule string, expected string, valid bool) {
+			s, err := maintenancewindow.FromMaintenanceWindow(&ssm.GetMaintenanceWindowOutput{Schedule: aws.String(schedule), Duration: aws.Int64(2), Enabled: aws.Bool(true)})
+			if !valid {
+				Expect(err).To(HaveOccurred())
+				return
+			}
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s.Cron).To(Equal(expected))
+		},
+		Entry("day of week names", "cron(0 2 ? * SUN *)", "0 2 * * SUN", true),
+		Entry("day of week numbers", "cron(30 1 ? * 1,7 *)", "30 1 * * 0,6", true),
+		Entry("day of week ranges", "cron(0 4 ? * 2-6 *)", "0 4 * * 1-5", true),
+		Entry("day of week steps", "cron(0 4 ? * 2/2 *)", "0 4 * * 1/2", true),
+		Entry("day of month", "cron(0 4 15 * ? *)", "0 4 15 * *", true),
+		Entry("last day of month", "cron(0 4 L * ? *)", "", false),
+		Entry("nth day of week", "cron(0 4 ? * TUE#2 *)", "", false),
+		Entry("year", "cron(0 4 ? * TUE 2025)", "", false),
+		Entry("rate", "rate(7 days)", "", false),
+	)
+	It("should offset the windows of SSM maintenance windows", func() {
+		s, err := maintenancewindow.FromMaintenanceWindow(&ssm.GetMaintenanceWindowOutput{
+			Schedule:         aws.String("cron(0 2 ? * SUN *)"),
+			ScheduleOffset:   aws.Int64(2),
+			ScheduleTimezone: aws.String("America/New_York"),
+			Duration:         aws.Int64(4),
+			Enabled:          aws.Bool(true),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		start, end, err := maintenancewindow.Window(*s, fakeClock.Now())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(start).To(BeTemporally("==", time.Date(2024, time.June, 4, 6, 0, 0, 0, time.UTC)))
+		Expect(end).To(BeTemporally("==", time.Date(2024, time.June, 4, 10, 0, 0, 0, time.UTC)))
+	})
+})
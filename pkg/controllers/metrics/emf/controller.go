@@ -0,0 +1,183 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package emf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/samber/lo"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+const (
+	// CloudWatch allows at most 30 dimensions per metric
+	maxDimensions = 30
+
+	dimensionClusterName = "ClusterName"
+)
+
+// MetricPrefixes select the metrics that are published. These cover launches and registrations, nodepool usage,
+// interruptions, and AWS API errors and throttles.
+var MetricPrefixes = []string{
+	"karpenter_nodeclaims_",
+	"karpenter_nodepool_",
+	"karpenter_interruption_",
+	"karpenter_cloudprovider_aws_api_",
+}
+
+// Controller periodically publishes key metrics from the Prometheus registry in CloudWatch Embedded Metric Format (EMF).
+// Each series is written as a single JSON document so that the CloudWatch agent or Fluent Bit can extract it into
+// CloudWatch metrics without scraping Prometheus.
+type Controller struct {
+	gatherer prometheus.Gatherer
+	writer   io.Writer
+	clock    clock.Clock
+
+	mu sync.Mutex
+	// previous holds the last published cumulative value of every counter and histogram series so that CloudWatch receives
+	// the delta for each period rather than a monotonically increasing total
+	previous map[string]float64
+}
+
+func NewController(gatherer prometheus.Gatherer, writer io.Writer, clk clock.Clock) *Controller {
+	return &Controller{
+		gatherer: gatherer,
+		writer:   writer,
+		clock:    clk,
+		previous: map[string]float64{},
+	}
+}
+
+type metric struct {
+	name  string
+	unit  string
+	value float64
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "metrics.emf")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	families, err := c.gatherer.Gather()
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("gathering metrics, %w", err)
+	}
+	timestamp := c.clock.Now().UnixMilli()
+	for _, family := range families {
+		if !lo.ContainsBy(MetricPrefixes, func(prefix string) bool { return strings.HasPrefix(family.GetName(), prefix) }) {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			metrics := c.metrics(family, m)
+			if len(metrics) == 0 {
+				continue
+			}
+			document, err := json.Marshal(newDocument(ctx, timestamp, m.GetLabel(), metrics))
+			if err != nil {
+				return reconcile.Result{}, fmt.Errorf("marshaling emf document, %w", err)
+			}
+			if _, err := fmt.Fprintln(c.writer, string(document)); err != nil {
+				return reconcile.Result{}, fmt.Errorf("writing emf document, %w", err)
+			}
+		}
+	}
+	return reconcile.Result{RequeueAfter: options.FromContext(ctx).EMFExportPeriod}, nil
+}
+
+// metrics converts a single Prometheus series into the CloudWatch metrics that are published for it
+func (c *Controller) metrics(family *dto.MetricFamily, m *dto.Metric) []metric {
+	key := seriesKey(family.GetName(), m.GetLabel())
+	switch family.GetType() {
+	case dto.MetricType_COUNTER:
+		return []metric{{name: family.GetName(), unit: "Count", value: c.delta(key, m.GetCounter().GetValue())}}
+	case dto.MetricType_GAUGE:
+		return []metric{{name: family.GetName(), unit: "None", value: m.GetGauge().GetValue()}}
+	case dto.MetricType_HISTOGRAM:
+		return []metric{
+			{name: family.GetName() + "_count", unit: "Count", value: c.delta(key+"_count", float64(m.GetHistogram().GetSampleCount()))},
+			{name: family.GetName() + "_sum", unit: lo.Ternary(strings.HasSuffix(family.GetName(), "_seconds"), "Seconds", "None"), value: c.delta(key+"_sum", m.GetHistogram().GetSampleSum())},
+		}
+	default:
+		return nil
+	}
+}
+
+func (c *Controller) delta(key string, value float64) float64 {
+	previous := c.previous[key]
+	c.previous[key] = value
+	// The counter was reset, so everything that has been observed since is new
+	if value < previous {
+		return value
+	}
+	return value - previous
+}
+
+func newDocument(ctx context.Context, timestamp int64, labels []*dto.LabelPair, metrics []metric) map[string]any {
+	document := map[string]any{dimensionClusterName: options.FromContext(ctx).ClusterName}
+	dimensions := []string{dimensionClusterName}
+	for _, label := range labels {
+		// CloudWatch rejects empty dimension values
+		if label.GetValue() == "" {
+			continue
+		}
+		document[label.GetName()] = label.GetValue()
+		if len(dimensions) < maxDimensions {
+			dimensions = append(dimensions, label.GetName())
+		}
+	}
+	for _, m := range metrics {
+		document[m.name] = m.value
+	}
+	document["_aws"] = map[string]any{
+		"Timestamp": timestamp,
+		"CloudWatchMetrics": []map[string]any{{
+			"Namespace":  options.FromContext(ctx).EMFNamespace,
+			"Dimensions": [][]string{dimensions},
+			"Metrics": lo.Map(metrics, func(m metric, _ int) map[string]string {
+				return map[string]string{"Name": m.name, "Unit": m.unit}
+			}),
+		}},
+	}
+	return document
+}
+
+func seriesKey(name string, labels []*dto.LabelPair) string {
+	return name + "{" + strings.Join(lo.Map(labels, func(l *dto.LabelPair, _ int) string {
+		return fmt.Sprintf("%s=%q", l.GetName(), l.GetValue())
+	}), ",") + "}"
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("metrics.emf").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
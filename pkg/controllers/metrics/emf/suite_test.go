@@ -0,0 +1,130 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package emf_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	clock "k8s.io/utils/clock/testing"
+
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/metrics/emf"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var fakeClock *clock.FakeClock
+var registry *prometheus.Registry
+var buffer *bytes.Buffer
+var controller *emf.Controller
+
+var throttles *prometheus.CounterVec
+var usage *prometheus.GaugeVec
+var latency *prometheus.HistogramVec
+
+func TestEMF(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "EMF")
+}
+
+var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{EMFNamespace: lo.ToPtr("Karpenter")}))
+	fakeClock = clock.NewFakeClock(time.Now())
+	buffer = &bytes.Buffer{}
+	registry = prometheus.NewRegistry()
+	throttles = prometheus.NewCounterVec(prometheus.CounterOpts{Name: "karpenter_cloudprovider_aws_api_throttles_total"}, []string{"service", "operation"})
+	usage = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "karpenter_nodepool_usage"}, []string{"nodepool", "resource_type"})
+	latency = prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "karpenter_interruption_message_latency_time_seconds"}, []string{})
+	registry.MustRegister(throttles, usage, latency, prometheus.NewCounter(prometheus.CounterOpts{Name: "karpenter_unrelated_total"}))
+	controller = emf.NewController(registry, buffer, fakeClock)
+})
+
+func ExpectDocuments() []map[string]any {
+	GinkgoHelper()
+	var documents []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		document := map[string]any{}
+		Expect(json.Unmarshal([]byte(line), &document)).To(Succeed())
+		documents = append(documents, document)
+	}
+	buffer.Reset()
+	return documents
+}
+
+var _ = Describe("EMF", func() {
+	It("should publish the selected metrics in embedded metric format", func() {
+		throttles.WithLabelValues("EC2", "CreateFleet").Add(3)
+		usage.WithLabelValues("default", "cpu").Set(8)
+		ExpectSingletonReconciled(ctx, controller)
+
+		documents := ExpectDocuments()
+		Expect(documents).To(HaveLen(2))
+		throttled, ok := lo.Find(documents, func(d map[string]any) bool { return d["karpenter_cloudprovider_aws_api_throttles_total"] != nil })
+		Expect(ok).To(BeTrue())
+		Expect(throttled).To(HaveKeyWithValue("karpenter_cloudprovider_aws_api_throttles_total", BeNumerically("==", 3)))
+		Expect(throttled).To(HaveKeyWithValue("service", "EC2"))
+		Expect(throttled).To(HaveKeyWithValue("operation", "CreateFleet"))
+		Expect(throttled).To(HaveKeyWithValue("ClusterName", "test-cluster"))
+
+		metadata := throttled["_aws"].(map[string]any)
+		Expect(metadata).To(HaveKeyWithValue("Timestamp", BeNumerically("==", fakeClock.Now().UnixMilli())))
+		directive := metadata["CloudWatchMetrics"].([]any)[0].(map[string]any)
+		Expect(directive).To(HaveKeyWithValue("Namespace", "Karpenter"))
+		Expect(directive["Dimensions"]).To(Equal([]any{[]any{"ClusterName", "operation", "service"}}))
+		Expect(directive["Metrics"]).To(Equal([]any{map[string]any{"Name": "karpenter_cloudprovider_aws_api_throttles_total", "Unit": "Count"}}))
+	})
+	It("should publish the delta of counters since the last export", func() {
+		throttles.WithLabelValues("EC2", "CreateFleet").Add(3)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(ExpectDocuments()[0]).To(HaveKeyWithValue("karpenter_cloudprovider_aws_api_throttles_total", BeNumerically("==", 3)))
+
+		throttles.WithLabelValues("EC2", "CreateFleet").Add(2)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(ExpectDocuments()[0]).To(HaveKeyWithValue("karpenter_cloudprovider_aws_api_throttles_total", BeNumerically("==", 2)))
+	})
+	It("should publish the count and sum of histograms", func() {
+		latency.WithLabelValues().Observe(2)
+		latency.WithLabelValues().Observe(4)
+		ExpectSingletonReconciled(ctx, controller)
+
+		documents := ExpectDocuments()
+		Expect(documents).To(HaveLen(1))
+		Expect(documents[0]).To(HaveKeyWithValue("karpenter_interruption_message_latency_time_seconds_count", BeNumerically("==", 2)))
+		Expect(documents[0]).To(HaveKeyWithValue("karpenter_interruption_message_latency_time_seconds_sum", BeNumerically("==", 6)))
+		directive := documents[0]["_aws"].(map[string]any)["CloudWatchMetrics"].([]any)[0].(map[string]any)
+		Expect(directive["Metrics"]).To(ContainElement(map[string]any{"Name": "karpenter_interruption_message_latency_time_seconds_sum", "Unit": "Seconds"}))
+	})
+	It("should requeue after the export period", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{EMFNamespace: lo.ToPtr("Karpenter"), EMFExportPeriod: lo.ToPtr(30 * time.Second)}))
+		result := ExpectSingletonReconciled(ctx, controller)
+		Expect(result.RequeueAfter).To(Equal(30 * time.Second))
+	})
+})
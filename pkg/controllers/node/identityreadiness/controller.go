@@ -0,0 +1,119 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identityreadiness
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+// Controller removes the identity-not-ready startup taint from a node once the identity agents on the node, such as
+// the EKS Pod Identity Agent, are ready. Pods that don't tolerate the taint aren't scheduled before they can retrieve
+// credentials, which would otherwise fail with AccessDenied until the agents are up.
+type Controller struct {
+	kubeClient client.Client
+}
+
+func NewController(kubeClient client.Client) *Controller {
+	return &Controller{
+		kubeClient: kubeClient,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, node *corev1.Node) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "node.identityreadiness")
+	if !hasIdentityNotReadyTaint(node) {
+		return reconcile.Result{}, nil
+	}
+	pods := &corev1.PodList{}
+	if err := c.kubeClient.List(ctx, pods, client.MatchingLabelsSelector{Selector: agentSelector(ctx)}, client.MatchingFields{"spec.nodeName": node.Name}); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing identity agent pods, %w", err)
+	}
+	// The node is requeued by the pod watch once the agents are scheduled to the node and become ready
+	if len(pods.Items) == 0 || !lo.EveryBy(pods.Items, isReady) {
+		return reconcile.Result{}, nil
+	}
+	node.Spec.Taints = lo.Reject(node.Spec.Taints, func(t corev1.Taint, _ int) bool { return t.Key == v1.TaintIdentityNotReady })
+	// We call Update() here rather than Patch() because patching a list with a JSON merge patch
+	// can cause races due to the fact that it fully replaces the list on a change
+	if err := c.kubeClient.Update(ctx, node); err != nil {
+		if errors.IsConflict(err) {
+			return reconcile.Result{Requeue: true}, nil
+		}
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("removing identity-not-ready taint, %w", err))
+	}
+	log.FromContext(ctx).WithValues("Node", node.Name, "pods", lo.Map(pods.Items, func(p corev1.Pod, _ int) string {
+		return client.ObjectKeyFromObject(&p).String()
+	})).V(1).Info("identity agents are ready, removed startup taint")
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) Register(ctx context.Context, m manager.Manager) error {
+	selector := agentSelector(ctx)
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("node.identityreadiness").
+		For(&corev1.Node{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(o client.Object) bool {
+			return hasIdentityNotReadyTaint(o.(*corev1.Node))
+		}))).
+		Watches(
+			&corev1.Pod{},
+			handler.EnqueueRequestsFromMapFunc(func(_ context.Context, o client.Object) []reconcile.Request {
+				pod := o.(*corev1.Pod)
+				if pod.Spec.NodeName == "" || !selector.Matches(labels.Set(pod.Labels)) {
+					return nil
+				}
+				return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: pod.Spec.NodeName}}}
+			}),
+		).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 10,
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
+
+func agentSelector(ctx context.Context) labels.Selector {
+	return lo.Must(labels.Parse(options.FromContext(ctx).IdentityAgentSelector))
+}
+
+func hasIdentityNotReadyTaint(node *corev1.Node) bool {
+	return lo.ContainsBy(node.Spec.Taints, func(t corev1.Taint) bool { return t.Key == v1.TaintIdentityNotReady })
+}
+
+func isReady(pod corev1.Pod) bool {
+	return lo.ContainsBy(pod.Status.Conditions, func(c corev1.PodCondition) bool {
+		return c.Type == corev1.PodReady && c.Status == corev1.ConditionTrue
+	})
+}
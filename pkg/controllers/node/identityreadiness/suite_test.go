@@ -0,0 +1,116 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identityreadiness_test
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/node/identityreadiness"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var controller *identityreadiness.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "IdentityReadiness")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...), coretest.WithFieldIndexers(coretest.NodeClaimFieldIndexer(ctx)))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	controller = identityreadiness.NewController(env.Client)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("IdentityReadiness", func() {
+	var node *corev1.Node
+
+	agentPod := func(ready corev1.ConditionStatus) *corev1.Pod {
+		return coretest.Pod(coretest.PodOptions{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app.kubernetes.io/name": "eks-pod-identity-agent"}},
+			NodeName:   node.Name,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}},
+		})
+	}
+
+	BeforeEach(func() {
+		node = coretest.Node(coretest.NodeOptions{
+			Taints: []corev1.Taint{
+				{Key: v1.TaintIdentityNotReady, Effect: corev1.TaintEffectNoSchedule},
+				{Key: "example.com/other", Effect: corev1.TaintEffectNoSchedule},
+			},
+		})
+	})
+	It("should keep the taint until an identity agent is scheduled to the node", func() {
+		ExpectApplied(ctx, env.Client, node)
+		ExpectObjectReconciled(ctx, env.Client, controller, node)
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).To(ContainElement(HaveField("Key", v1.TaintIdentityNotReady)))
+	})
+	It("should keep the taint while an identity agent isn't ready", func() {
+		ExpectApplied(ctx, env.Client, node, agentPod(corev1.ConditionTrue), agentPod(corev1.ConditionFalse))
+		ExpectObjectReconciled(ctx, env.Client, controller, node)
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).To(ContainElement(HaveField("Key", v1.TaintIdentityNotReady)))
+	})
+	It("should ignore pods that don't match the identity agent selector", func() {
+		pod := agentPod(corev1.ConditionTrue)
+		pod.Labels = map[string]string{"app": "workload"}
+		ExpectApplied(ctx, env.Client, node, pod)
+		ExpectObjectReconciled(ctx, env.Client, controller, node)
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).To(ContainElement(HaveField("Key", v1.TaintIdentityNotReady)))
+	})
+	It("should remove the taint once the identity agents are ready", func() {
+		ExpectApplied(ctx, env.Client, node, agentPod(corev1.ConditionTrue))
+		ExpectObjectReconciled(ctx, env.Client, controller, node)
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).ToNot(ContainElement(HaveField("Key", v1.TaintIdentityNotReady)))
+		Expect(node.Spec.Taints).To(ContainElement(HaveField("Key", "example.com/other")))
+	})
+	It("should not modify nodes without the taint", func() {
+		node.Spec.Taints = []corev1.Taint{{Key: "example.com/other", Effect: corev1.TaintEffectNoSchedule}}
+		ExpectApplied(ctx, env.Client, node)
+		ExpectObjectReconciled(ctx, env.Client, controller, node)
+		Expect(ExpectExists(ctx, env.Client, node).Spec.Taints).To(Equal(node.Spec.Taints))
+	})
+})
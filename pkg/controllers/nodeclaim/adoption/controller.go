@@ -0,0 +1,194 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adoption
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	corescheduling "sigs.k8s.io/karpenter/pkg/scheduling"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// pollPeriod is how often instances that are tagged for adoption are discovered
+const pollPeriod = time.Minute
+
+// Controller adopts the instances that were launched outside of Karpenter and are tagged with
+// karpenter.k8s.aws/adopt-nodepool, like hand-launched GPU nodes. Once the node of an instance has joined the cluster,
+// a NodeClaim of the NodePool is created for it, which is linked to the instance rather than launching one. The node is
+// then drifted, disrupted and expired like the nodes that Karpenter launches, and its instance is terminated when the
+// NodeClaim is deleted.
+type Controller struct {
+	kubeClient    client.Client
+	recorder      events.Recorder
+	ec2api        ec2iface.EC2API
+	cloudProvider cloudprovider.CloudProvider
+}
+
+func NewController(kubeClient client.Client, recorder events.Recorder, ec2api ec2iface.EC2API, cloudProvider cloudprovider.CloudProvider) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		recorder:      recorder,
+		ec2api:        ec2api,
+		cloudProvider: cloudProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.adoption")
+
+	var instances []*ec2.Instance
+	if err := c.ec2api.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("tag-key"), Values: aws.StringSlice([]string{v1.TagAdoptNodePool})},
+			{Name: aws.String("instance-state-name"), Values: aws.StringSlice([]string{ec2.InstanceStateNameRunning})},
+		},
+	}, func(out *ec2.DescribeInstancesOutput, _ bool) bool {
+		for _, reservation := range out.Reservations {
+			instances = append(instances, reservation.Instances...)
+		}
+		return true
+	}); err != nil {
+		return reconcile.Result{}, fmt.Errorf("describing instances, %w", err)
+	}
+	if len(instances) == 0 {
+		return reconcile.Result{RequeueAfter: pollPeriod}, nil
+	}
+	nodeClaimList := &karpv1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaimList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	adopted := sets.New[string]()
+	for _, nodeClaim := range nodeClaimList.Items {
+		if id, ok := nodeClaim.Annotations[v1.AnnotationAdoptedInstance]; ok {
+			adopted.Insert(id)
+		}
+		if id, err := utils.ParseInstanceID(nodeClaim.Status.ProviderID); err == nil {
+			adopted.Insert(id)
+		}
+	}
+	nodeList := &corev1.NodeList{}
+	if err := c.kubeClient.List(ctx, nodeList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodes, %w", err)
+	}
+	nodes := lo.SliceToMap(lo.Filter(nodeList.Items, func(n corev1.Node, _ int) bool { return n.Spec.ProviderID != "" }), func(n corev1.Node) (string, corev1.Node) {
+		id, _ := utils.ParseInstanceID(n.Spec.ProviderID)
+		return id, n
+	})
+
+	var errs error
+	for _, instance := range instances {
+		if adopted.Has(aws.StringValue(instance.InstanceId)) {
+			continue
+		}
+		// The instances that Karpenter launched are already managed by it
+		if _, ok := lo.Find(instance.Tags, func(t *ec2.Tag) bool { return aws.StringValue(t.Key) == karpv1.ManagedByAnnotationKey }); ok {
+			continue
+		}
+		// The instance is adopted once its node has joined the cluster
+		node, ok := nodes[aws.StringValue(instance.InstanceId)]
+		if !ok || node.Labels[karpv1.NodePoolLabelKey] != "" {
+			continue
+		}
+		errs = multierr.Append(errs, c.adopt(ctx, instance, &node))
+	}
+	if errs != nil {
+		return reconcile.Result{}, errs
+	}
+	return reconcile.Result{RequeueAfter: pollPeriod}, nil
+}
+
+// adopt creates a NodeClaim of the NodePool in the tag of the instance, which requires the instance type, zone and
+// capacity type of the instance. Instances that the NodePool can't launch aren't adopted.
+func (c *Controller) adopt(ctx context.Context, instance *ec2.Instance, node *corev1.Node) error {
+	instanceID := aws.StringValue(instance.InstanceId)
+	nodePoolName := aws.StringValue(lo.FindOrElse(instance.Tags, &ec2.Tag{}, func(t *ec2.Tag) bool { return aws.StringValue(t.Key) == v1.TagAdoptNodePool }).Value)
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("instance-id", instanceID, "NodePool", klog.KRef("", nodePoolName), "Node", klog.KRef("", node.Name)))
+
+	nodePool := &karpv1.NodePool{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodePoolName}, nodePool); err != nil {
+		if errors.IsNotFound(err) {
+			log.FromContext(ctx).Error(err, "failed adopting instance, nodepool not found")
+			return nil
+		}
+		return fmt.Errorf("getting nodepool, %w", err)
+	}
+	if !nodePool.StatusConditions().IsTrue(status.ConditionReady) {
+		c.recorder.Publish(AdoptionFailedEvent(nodePool, instanceID, "nodepool isn't ready"))
+		return nil
+	}
+	instanceTypes, err := c.cloudProvider.GetInstanceTypes(ctx, nodePool)
+	if err != nil {
+		return fmt.Errorf("getting instance types, %w", err)
+	}
+	template := scheduling.NewNodeClaimTemplate(nodePool)
+	template.Requirements.Add(corescheduling.NewLabelRequirements(map[string]string{
+		corev1.LabelInstanceTypeStable: aws.StringValue(instance.InstanceType),
+		corev1.LabelTopologyZone:       aws.StringValue(instance.Placement.AvailabilityZone),
+		karpv1.CapacityTypeLabelKey:    lo.Ternary(aws.StringValue(instance.InstanceLifecycle) == ec2.InstanceLifecycleSpot, karpv1.CapacityTypeSpot, karpv1.CapacityTypeOnDemand),
+	}).Values()...)
+	template.InstanceTypeOptions = lo.Filter(cloudprovider.InstanceTypes(instanceTypes).Compatible(template.Requirements), func(it *cloudprovider.InstanceType, _ int) bool {
+		return template.Requirements.Compatible(it.Requirements, corescheduling.AllowUndefinedWellKnownLabels) == nil
+	})
+	if len(template.InstanceTypeOptions) == 0 {
+		c.recorder.Publish(AdoptionFailedEvent(nodePool, instanceID, "the instance type, zone or capacity type of the instance isn't allowed by the nodepool"))
+		return nil
+	}
+	// Registration requires nodes that were launched without the unregistered taint to be labeled as registered
+	stored := node.DeepCopy()
+	node.Labels = lo.Assign(node.Labels, map[string]string{karpv1.NodeRegisteredLabelKey: "true"})
+	if err = c.kubeClient.Patch(ctx, node, client.MergeFrom(stored)); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("labeling node, %w", err))
+	}
+	nodeClaim := template.ToNodeClaim(nodePool)
+	nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationAdoptedInstance: instanceID})
+	if err = c.kubeClient.Create(ctx, nodeClaim); err != nil {
+		return fmt.Errorf("creating nodeclaim, %w", err)
+	}
+	log.FromContext(ctx).WithValues("NodeClaim", klog.KRef("", nodeClaim.Name)).Info("adopted instance")
+	c.recorder.Publish(InstanceAdoptedEvent(nodeClaim, instanceID))
+	return nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.adoption").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
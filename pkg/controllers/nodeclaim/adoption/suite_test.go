@@ -0,0 +1,157 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adoption_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/awslabs/operatorpkg/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	corecloudproviderfake "sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	corescheduling "sigs.k8s.io/karpenter/pkg/scheduling"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/adoption"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var kubeClient client.Client
+var ec2api *fake.EC2API
+var recorder *coretest.EventRecorder
+var controller *adoption.Controller
+var nodePool *karpv1.NodePool
+var instance *ec2.Instance
+var node *corev1.Node
+
+func TestAdoption(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Adoption")
+}
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	kubeClient = crfake.NewClientBuilder().Build()
+	ec2api = &fake.EC2API{}
+	recorder = coretest.NewEventRecorder()
+	controller = adoption.NewController(kubeClient, recorder, ec2api, corecloudproviderfake.NewCloudProvider())
+
+	nodePool = coretest.NodePool(karpv1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "gpu"}})
+	nodePool.StatusConditions().SetTrue(status.ConditionReady)
+	instance = &ec2.Instance{
+		InstanceId:   aws.String("i-0123456789"),
+		InstanceType: aws.String("default-instance-type"),
+		Placement:    &ec2.Placement{AvailabilityZone: aws.String("test-zone-1")},
+		State:        &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)},
+		Tags:         []*ec2.Tag{{Key: aws.String(v1.TagAdoptNodePool), Value: aws.String("gpu")}},
+	}
+	node = coretest.Node(coretest.NodeOptions{ProviderID: "aws:///test-zone-1/i-0123456789"})
+	ec2api.Instances.Store(aws.StringValue(instance.InstanceId), instance)
+	Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+	Expect(kubeClient.Create(ctx, node)).To(Succeed())
+})
+
+func nodeClaims() []karpv1.NodeClaim {
+	list := &karpv1.NodeClaimList{}
+	Expect(kubeClient.List(ctx, list)).To(Succeed())
+	return list.Items
+}
+
+var _ = Describe("Adoption", func() {
+	It("should create a NodeClaim of the NodePool for the instance", func() {
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(nodeClaims()).To(HaveLen(1))
+		nodeClaim := nodeClaims()[0]
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationAdoptedInstance, "i-0123456789"))
+		Expect(nodeClaim.Labels).To(HaveKeyWithValue(karpv1.NodePoolLabelKey, "gpu"))
+		requirements := corescheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+		Expect(requirements.Get(corev1.LabelInstanceTypeStable).Values()).To(ConsistOf("default-instance-type"))
+		Expect(requirements.Get(corev1.LabelTopologyZone).Values()).To(ConsistOf("test-zone-1"))
+		Expect(requirements.Get(karpv1.CapacityTypeLabelKey).Values()).To(ConsistOf(karpv1.CapacityTypeOnDemand))
+		Expect(recorder.Calls("InstanceAdopted")).To(Equal(1))
+
+		// Nodes that joined without the unregistered taint are labeled as registered for the registration of the NodeClaim
+		Expect(kubeClient.Get(ctx, client.ObjectKeyFromObject(node), node)).To(Succeed())
+		Expect(node.Labels).To(HaveKeyWithValue(karpv1.NodeRegisteredLabelKey, "true"))
+	})
+	It("should require the spot capacity type for spot instances", func() {
+		instance.InstanceLifecycle = aws.String(ec2.InstanceLifecycleSpot)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(nodeClaims()).To(HaveLen(1))
+		requirements := corescheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaims()[0].Spec.Requirements...)
+		Expect(requirements.Get(karpv1.CapacityTypeLabelKey).Values()).To(ConsistOf(karpv1.CapacityTypeSpot))
+	})
+	It("should adopt each instance once", func() {
+		ExpectSingletonReconciled(ctx, controller)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(nodeClaims()).To(HaveLen(1))
+	})
+	It("should wait for the node of the instance to join the cluster", func() {
+		ExpectDeleted(ctx, kubeClient, node)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(nodeClaims()).To(BeEmpty())
+	})
+	It("should not adopt instances that Karpenter launched", func() {
+		instance.Tags = append(instance.Tags, &ec2.Tag{Key: aws.String(karpv1.ManagedByAnnotationKey), Value: aws.String("test-cluster")})
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(nodeClaims()).To(BeEmpty())
+	})
+	It("should not adopt instances that a NodeClaim was launched for", func() {
+		nodeClaim := coretest.NodeClaim()
+		nodeClaim.Status.ProviderID = "aws:///test-zone-1/i-0123456789"
+		Expect(kubeClient.Create(ctx, nodeClaim)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(nodeClaims()).To(HaveLen(1))
+	})
+	It("should not adopt instances whose instance type the NodePool doesn't allow", func() {
+		nodePool.Spec.Template.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{{
+			NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"small-instance-type"}},
+		}}
+		Expect(kubeClient.Update(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(nodeClaims()).To(BeEmpty())
+		Expect(recorder.Calls("AdoptionFailed")).To(Equal(1))
+	})
+	It("should not adopt instances of NodePools that aren't ready", func() {
+		nodePool.StatusConditions().SetFalse(status.ConditionReady, "NotReady", "NotReady")
+		Expect(kubeClient.Update(ctx, nodePool)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(nodeClaims()).To(BeEmpty())
+		Expect(recorder.Calls("AdoptionFailed")).To(Equal(1))
+	})
+	It("should not adopt instances of NodePools that don't exist", func() {
+		ExpectDeleted(ctx, kubeClient, nodePool)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(nodeClaims()).To(BeEmpty())
+	})
+})
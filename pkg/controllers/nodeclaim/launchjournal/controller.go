@@ -0,0 +1,128 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package launchjournal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/launchjournal"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// pollPeriod is how often the journaled launches are settled
+const pollPeriod = 30 * time.Second
+
+// Controller settles the launches that are journaled by the instance provider. Launches are removed from the journal
+// once their NodeClaims are updated with their instances. The instances of launches whose NodeClaims were deleted, or
+// were updated with another instance, are terminated rather than leaking until they're garbage collected. Launches
+// whose NodeClaims are still waiting for an instance are kept, since the instance is adopted when the NodeClaim is
+// launched again.
+type Controller struct {
+	kubeClient    client.Client
+	ec2api        ec2iface.EC2API
+	launchJournal launchjournal.Provider
+}
+
+func NewController(kubeClient client.Client, ec2api ec2iface.EC2API, launchJournal launchjournal.Provider) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		ec2api:        ec2api,
+		launchJournal: launchJournal,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.launchjournal")
+
+	launches, err := c.launchJournal.List(ctx)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if len(launches) == 0 {
+		return reconcile.Result{RequeueAfter: pollPeriod}, nil
+	}
+	nodeClaimList := &karpv1.NodeClaimList{}
+	if err = c.kubeClient.List(ctx, nodeClaimList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	nodeClaims := lo.SliceToMap(nodeClaimList.Items, func(nc karpv1.NodeClaim) (string, karpv1.NodeClaim) { return nc.Name, nc })
+	resolvedInstanceIDs := sets.New(lo.FilterMap(nodeClaimList.Items, func(nc karpv1.NodeClaim, _ int) (string, bool) {
+		id, err := utils.ParseInstanceID(nc.Status.ProviderID)
+		return id, err == nil
+	})...)
+
+	var settled []string
+	var errs error
+	for name, launch := range launches {
+		nodeClaim, ok := nodeClaims[name]
+		if ok && nodeClaim.UID == launch.NodeClaimUID && nodeClaim.Status.ProviderID == "" {
+			continue
+		}
+		// The instance of a launch that was abandoned while its CreateFleet request was in flight isn't known, and is
+		// left to garbage collection
+		if launch.InstanceID != "" && !resolvedInstanceIDs.Has(launch.InstanceID) {
+			if err = c.terminate(ctx, name, launch); err != nil {
+				errs = multierr.Append(errs, err)
+				continue
+			}
+		}
+		settled = append(settled, name)
+	}
+	if err = c.launchJournal.Remove(ctx, settled...); err != nil {
+		errs = multierr.Append(errs, err)
+	}
+	if errs != nil {
+		return reconcile.Result{}, errs
+	}
+	return reconcile.Result{RequeueAfter: pollPeriod}, nil
+}
+
+// terminate terminates the instance of a launch whose NodeClaim won't be updated with it
+func (c *Controller) terminate(ctx context.Context, nodeClaimName string, launch launchjournal.Launch) error {
+	if _, err := c.ec2api.TerminateInstancesWithContext(assumerole.WithRole(ctx, launch.RoleARN), &ec2.TerminateInstancesInput{
+		InstanceIds: aws.StringSlice([]string{launch.InstanceID}),
+	}); awserrors.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("terminating instance %s, %w", launch.InstanceID, err)
+	}
+	log.FromContext(ctx).WithValues("NodeClaim", klog.KRef("", nodeClaimName), "instance-id", launch.InstanceID).Info("terminated instance of an abandoned launch")
+	return nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.launchjournal").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
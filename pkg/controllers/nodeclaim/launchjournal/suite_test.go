@@ -0,0 +1,144 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package launchjournal_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/launchjournal"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var kubeClient client.Client
+var ec2api *fake.EC2API
+var journal *fake.LaunchJournal
+var controller *launchjournal.Controller
+
+func TestLaunchJournal(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "LaunchJournal")
+}
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	kubeClient = crfake.NewClientBuilder().WithStatusSubresource(&karpv1.NodeClaim{}).Build()
+	ec2api = &fake.EC2API{}
+	journal = &fake.LaunchJournal{}
+	controller = launchjournal.NewController(kubeClient, ec2api, journal)
+})
+
+var _ = Describe("LaunchJournal", func() {
+	var nodeClaim *karpv1.NodeClaim
+	BeforeEach(func() {
+		nodeClaim = coretest.NodeClaim(karpv1.NodeClaim{ObjectMeta: metav1.ObjectMeta{UID: types.UID("nodeclaim-uid")}})
+		nodeClaim.Status.ProviderID = ""
+	})
+	It("should keep the launches of NodeClaims that haven't been updated with their instances", func() {
+		ExpectApplied(ctx, kubeClient, nodeClaim)
+		Expect(journal.Record(ctx, nodeClaim, "i-0123456789")).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(lo.Must(journal.List(ctx))).To(HaveKey(nodeClaim.Name))
+		Expect(ec2api.TerminateInstancesBehavior.Calls()).To(BeZero())
+	})
+	It("should remove the launches of NodeClaims that have been updated with their instances", func() {
+		nodeClaim.Status.ProviderID = "aws:///test-zone-1a/i-0123456789"
+		ExpectApplied(ctx, kubeClient, nodeClaim)
+		Expect(journal.Record(ctx, nodeClaim, "i-0123456789")).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(lo.Must(journal.List(ctx))).To(BeEmpty())
+		Expect(ec2api.TerminateInstancesBehavior.Calls()).To(BeZero())
+	})
+	It("should terminate the instances of NodeClaims that were deleted before they were updated", func() {
+		Expect(journal.Record(ctx, nodeClaim, "i-0123456789")).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(lo.Must(journal.List(ctx))).To(BeEmpty())
+		Expect(ec2api.TerminateInstancesBehavior.Calls()).To(Equal(1))
+		Expect(aws.StringValueSlice(ec2api.TerminateInstancesBehavior.CalledWithInput.Pop().InstanceIds)).To(ConsistOf("i-0123456789"))
+	})
+	It("should terminate the instances of NodeClaims that were updated with another instance", func() {
+		nodeClaim.Status.ProviderID = "aws:///test-zone-1a/i-9876543210"
+		ExpectApplied(ctx, kubeClient, nodeClaim)
+		Expect(journal.Record(ctx, nodeClaim, "i-0123456789")).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(lo.Must(journal.List(ctx))).To(BeEmpty())
+		Expect(aws.StringValueSlice(ec2api.TerminateInstancesBehavior.CalledWithInput.Pop().InstanceIds)).To(ConsistOf("i-0123456789"))
+	})
+	It("should terminate the instances of previous NodeClaims with the same name", func() {
+		Expect(journal.Record(ctx, nodeClaim, "i-0123456789")).To(Succeed())
+		nodeClaim.UID = "replacement-uid"
+		ExpectApplied(ctx, kubeClient, nodeClaim)
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(lo.Must(journal.List(ctx))).To(BeEmpty())
+		Expect(ec2api.TerminateInstancesBehavior.Calls()).To(Equal(1))
+	})
+	It("should not terminate instances that another NodeClaim was updated with", func() {
+		Expect(journal.Record(ctx, nodeClaim, "i-0123456789")).To(Succeed())
+		other := coretest.NodeClaim()
+		other.Status.ProviderID = "aws:///test-zone-1a/i-0123456789"
+		ExpectApplied(ctx, kubeClient, other)
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(lo.Must(journal.List(ctx))).To(BeEmpty())
+		Expect(ec2api.TerminateInstancesBehavior.Calls()).To(BeZero())
+	})
+	It("should remove the launches in flight of NodeClaims that were deleted", func() {
+		Expect(journal.Begin(ctx, nodeClaim, "nodeclaim-uid-1")).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(lo.Must(journal.List(ctx))).To(BeEmpty())
+		Expect(ec2api.TerminateInstancesBehavior.Calls()).To(BeZero())
+	})
+	It("should keep the launch when the instance fails to terminate", func() {
+		ec2api.TerminateInstancesBehavior.Error.Set(awserr.New("UnauthorizedOperation", "", nil))
+		Expect(journal.Record(ctx, nodeClaim, "i-0123456789")).To(Succeed())
+		_ = ExpectSingletonReconcileFailed(ctx, controller)
+
+		Expect(lo.Must(journal.List(ctx))).To(HaveKey(nodeClaim.Name))
+	})
+	It("should settle the launch when the instance was already terminated", func() {
+		ec2api.TerminateInstancesBehavior.Error.Set(awserr.New("InvalidInstanceID.NotFound", "", nil))
+		Expect(journal.Record(ctx, nodeClaim, "i-0123456789")).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(lo.Must(journal.List(ctx))).To(BeEmpty())
+	})
+})
@@ -0,0 +1,137 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package launchlatency
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/patrickmn/go-cache"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// runningPollInterval is the interval at which the state of launched instances is checked until they are running. It
+// bounds the resolution of the running phase duration.
+const runningPollInterval = 5 * time.Second
+
+// Controller observes the duration of each phase of a node launch after CreateFleet has returned: until the instance
+// is running, until the kubelet registered the node, and until the node is initialized.
+type Controller struct {
+	kubeClient       client.Client
+	instanceProvider instance.Provider
+	clock            clock.Clock
+	// startTime ensures that phases that completed before the controller started aren't observed with inflated durations
+	startTime time.Time
+	// observed tracks the phases that have already been observed for each NodeClaim
+	observed *cache.Cache
+}
+
+func NewController(kubeClient client.Client, instanceProvider instance.Provider, clk clock.Clock) *Controller {
+	return &Controller{
+		kubeClient:       kubeClient,
+		instanceProvider: instanceProvider,
+		clock:            clk,
+		startTime:        clk.Now(),
+		observed:         cache.New(24*time.Hour, time.Hour),
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *karpv1.NodeClaim) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.launchlatency")
+
+	launched := nodeClaim.StatusConditions().Get(karpv1.ConditionTypeLaunched)
+	if !nodeClaim.DeletionTimestamp.IsZero() || !launched.IsTrue() || launched.LastTransitionTime.Time.Before(c.startTime) {
+		return reconcile.Result{}, nil
+	}
+	if c.isObserved(nodeClaim, instance.LaunchPhaseReady) {
+		return reconcile.Result{}, nil
+	}
+	nodeClass := &v1.EC2NodeClass{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodeClaim.Spec.NodeClassRef.Name}, nodeClass); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	ctx = assumerole.WithNodeClass(ctx, nodeClass)
+	observe := func(phase string, end time.Time) {
+		if c.isObserved(nodeClaim, phase) {
+			return
+		}
+		instance.LaunchPhaseDurationSeconds.With(instance.LaunchPhaseLabels(
+			phase,
+			nodeClass.AMIFamily(),
+			nodeClaim.Labels[v1.LabelInstanceFamily],
+			nodeClaim.Labels[corev1.LabelTopologyZone],
+		)).Observe(end.Sub(launched.LastTransitionTime.Time).Seconds())
+		c.observed.SetDefault(observedKey(nodeClaim, phase), struct{}{})
+	}
+
+	// The instance must have been running for the kubelet to register, so the running phase can't be observed anymore
+	if registered := nodeClaim.StatusConditions().Get(karpv1.ConditionTypeRegistered); registered.IsTrue() {
+		observe(instance.LaunchPhaseRegistered, registered.LastTransitionTime.Time)
+		if initialized := nodeClaim.StatusConditions().Get(karpv1.ConditionTypeInitialized); initialized.IsTrue() {
+			observe(instance.LaunchPhaseReady, initialized.LastTransitionTime.Time)
+		}
+		return reconcile.Result{}, nil
+	}
+	if c.isObserved(nodeClaim, instance.LaunchPhaseRunning) {
+		return reconcile.Result{}, nil
+	}
+	id, err := utils.ParseInstanceID(nodeClaim.Status.ProviderID)
+	if err != nil {
+		// We don't retry since the NodeClaim will be reconciled again once the ProviderID is updated
+		return reconcile.Result{}, nil
+	}
+	inst, err := c.instanceProvider.Get(ctx, id)
+	if err != nil {
+		return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(fmt.Errorf("getting instance, %w", err))
+	}
+	if inst.State != ec2.InstanceStateNameRunning {
+		return reconcile.Result{RequeueAfter: runningPollInterval}, nil
+	}
+	observe(instance.LaunchPhaseRunning, c.clock.Now())
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) isObserved(nodeClaim *karpv1.NodeClaim, phase string) bool {
+	_, ok := c.observed.Get(observedKey(nodeClaim, phase))
+	return ok
+}
+
+func observedKey(nodeClaim *karpv1.NodeClaim, phase string) string {
+	return fmt.Sprintf("%s/%s", nodeClaim.UID, phase)
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.launchlatency").
+		For(&karpv1.NodeClaim{}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
@@ -0,0 +1,168 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package launchlatency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/awslabs/operatorpkg/object"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/launchlatency"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var awsEnv *test.Environment
+var env *coretest.Environment
+var fakeClock *clock.FakeClock
+var controller *launchlatency.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "LaunchLatency")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	awsEnv.Reset()
+	instance.LaunchPhaseDurationSeconds.Reset()
+	fakeClock = clock.NewFakeClock(time.Now().Add(-time.Minute))
+	controller = launchlatency.NewController(env.Client, awsEnv.InstanceProvider, fakeClock)
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("LaunchLatency", func() {
+	var nodeClass *v1.EC2NodeClass
+	var nodeClaim *karpv1.NodeClaim
+	var ec2Instance *ec2.Instance
+
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass()
+		ec2Instance = &ec2.Instance{
+			State:        &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNamePending)},
+			Placement:    &ec2.Placement{AvailabilityZone: aws.String("test-zone-1a")},
+			InstanceId:   aws.String(fake.InstanceID()),
+			InstanceType: aws.String("m5.large"),
+		}
+		awsEnv.EC2API.Instances.Store(*ec2Instance.InstanceId, ec2Instance)
+		nodeClaim = coretest.NodeClaim(karpv1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.LabelInstanceFamily:   "m5",
+					corev1.LabelTopologyZone: "test-zone-1a",
+				},
+			},
+			Spec: karpv1.NodeClaimSpec{
+				NodeClassRef: &karpv1.NodeClassReference{
+					Group: object.GVK(nodeClass).Group,
+					Kind:  object.GVK(nodeClass).Kind,
+					Name:  nodeClass.Name,
+				},
+			},
+			Status: karpv1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(*ec2Instance.InstanceId),
+			},
+		})
+		nodeClaim.StatusConditions().SetTrue(karpv1.ConditionTypeLaunched)
+	})
+
+	ExpectPhaseObserved := func(phase string, count int) {
+		GinkgoHelper()
+		metric, ok := FindMetricWithLabelValues("karpenter_cloudprovider_launch_phase_duration_seconds", map[string]string{
+			"phase":           phase,
+			"ami_family":      nodeClass.AMIFamily(),
+			"instance_family": "m5",
+			"zone":            "test-zone-1a",
+		})
+		if count == 0 {
+			Expect(ok).To(BeFalse())
+			return
+		}
+		Expect(ok).To(BeTrue())
+		Expect(metric.GetHistogram().GetSampleCount()).To(BeNumerically("==", count))
+	}
+
+	It("should poll the instance until it is running", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		result := ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		Expect(result.RequeueAfter).ToNot(BeZero())
+		ExpectPhaseObserved(instance.LaunchPhaseRunning, 0)
+
+		ec2Instance.State.Name = aws.String(ec2.InstanceStateNameRunning)
+		fakeClock.Step(2 * time.Minute)
+		result = ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		Expect(result.RequeueAfter).To(BeZero())
+		ExpectPhaseObserved(instance.LaunchPhaseRunning, 1)
+	})
+	It("should observe the registered and ready phases once", func() {
+		nodeClaim.StatusConditions().SetTrue(karpv1.ConditionTypeRegistered)
+		nodeClaim.StatusConditions().SetTrue(karpv1.ConditionTypeInitialized)
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		ExpectPhaseObserved(instance.LaunchPhaseRegistered, 1)
+		ExpectPhaseObserved(instance.LaunchPhaseReady, 1)
+		ExpectPhaseObserved(instance.LaunchPhaseRunning, 0)
+	})
+	It("should not observe phases of NodeClaims launched before the controller started", func() {
+		nodeClaim.StatusConditions().SetTrue(karpv1.ConditionTypeRegistered)
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		controller = launchlatency.NewController(env.Client, awsEnv.InstanceProvider, clock.NewFakeClock(time.Now().Add(time.Minute)))
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		ExpectPhaseObserved(instance.LaunchPhaseRegistered, 0)
+	})
+	It("should not observe phases of NodeClaims that are not launched", func() {
+		nodeClaim.StatusConditions().SetFalse(karpv1.ConditionTypeLaunched, "Pending", "Pending")
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		result := ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		Expect(result.RequeueAfter).To(BeZero())
+		ExpectPhaseObserved(instance.LaunchPhaseRunning, 0)
+	})
+})
@@ -0,0 +1,180 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// annotations are the annotations that each attribute of node-metadata-annotations is added to nodes as
+var annotations = map[string]string{
+	options.NodeMetadataUsageOperation:        v1.AnnotationUsageOperation,
+	options.NodeMetadataPlacementGroup:        v1.AnnotationPlacementGroup,
+	options.NodeMetadataHostID:                v1.AnnotationHostID,
+	options.NodeMetadataCapacityReservationID: v1.AnnotationCapacityReservationID,
+	options.NodeMetadataAMIName:               v1.AnnotationAMIName,
+}
+
+// Controller adds the EC2 attributes of the instances of NodeClaims that are selected by node-metadata-annotations to
+// the NodeClaims and their nodes as annotations. The instance is described once, as soon as it's launched, so that the
+// annotations are usually added to the NodeClaim before its node registers and synced to the node on registration. The
+// annotations are copied to the node if it registered first.
+type Controller struct {
+	kubeClient       client.Client
+	instanceProvider instance.Provider
+}
+
+func NewController(kubeClient client.Client, instanceProvider instance.Provider) *Controller {
+	return &Controller{
+		kubeClient:       kubeClient,
+		instanceProvider: instanceProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *karpv1.NodeClaim) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.metadata")
+
+	if !isDescribable(nodeClaim) {
+		return reconcile.Result{}, nil
+	}
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("provider-id", nodeClaim.Status.ProviderID))
+	if nodeClaim.Annotations[v1.AnnotationInstanceMetadata] != "true" {
+		if err := c.annotateNodeClaim(ctx, nodeClaim); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	if err := c.annotateNode(ctx, nodeClaim); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, nil
+}
+
+// annotateNodeClaim describes the instance of the NodeClaim and adds its attributes to the NodeClaim. The NodeClaim is
+// marked with karpenter.k8s.aws/instance-metadata so that the instance isn't described again, including when none of
+// its attributes are set.
+func (c *Controller) annotateNodeClaim(ctx context.Context, nodeClaim *karpv1.NodeClaim) error {
+	id, err := utils.ParseInstanceID(nodeClaim.Status.ProviderID)
+	if err != nil {
+		// We don't throw an error here since we don't want to retry until the ProviderID has been updated.
+		log.FromContext(ctx).Error(err, "failed parsing instance id")
+		return nil
+	}
+	nodeClass := &v1.EC2NodeClass{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Spec.NodeClassRef.Name}, nodeClass); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("resolving nodeclass, %w", err)
+	}
+	ctx = assumerole.WithNodePool(assumerole.WithNodeClass(ctx, nodeClass), nodeClaim.Labels[karpv1.NodePoolLabelKey])
+	i, err := c.instanceProvider.Get(ctx, id)
+	if err != nil {
+		return cloudprovider.IgnoreNodeClaimNotFoundError(fmt.Errorf("describing instance, %w", err))
+	}
+	stored := nodeClaim.DeepCopy()
+	nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, metadata(ctx, i, nodeClass), map[string]string{v1.AnnotationInstanceMetadata: "true"})
+	if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	return nil
+}
+
+// annotateNode copies the metadata annotations of the NodeClaim to its node, for nodes that registered before the
+// NodeClaim was annotated
+func (c *Controller) annotateNode(ctx context.Context, nodeClaim *karpv1.NodeClaim) error {
+	if nodeClaim.Status.NodeName == "" {
+		return nil
+	}
+	node := &corev1.Node{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Status.NodeName}, node); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("getting node, %w", err))
+	}
+	stored := node.DeepCopy()
+	node.Annotations = lo.Assign(node.Annotations, lo.PickByKeys(nodeClaim.Annotations, lo.Values(annotations)))
+	if equality.Semantic.DeepEqual(node, stored) {
+		return nil
+	}
+	if err := c.kubeClient.Patch(ctx, node, client.MergeFrom(stored)); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("patching node, %w", err))
+	}
+	return nil
+}
+
+// metadata returns the annotations of the attributes in node-metadata-annotations that are set on the instance
+func metadata(ctx context.Context, i *instance.Instance, nodeClass *v1.EC2NodeClass) map[string]string {
+	values := map[string]string{
+		options.NodeMetadataUsageOperation:        i.UsageOperation,
+		options.NodeMetadataPlacementGroup:        i.PlacementGroup,
+		options.NodeMetadataHostID:                i.HostID,
+		options.NodeMetadataCapacityReservationID: i.CapacityReservationID,
+		// The AMI is resolved from the status of the EC2NodeClass, so the name of an AMI that's no longer selected
+		// isn't known
+		options.NodeMetadataAMIName: lo.FindOrElse(nodeClass.Status.AMIs, v1.AMI{}, func(ami v1.AMI) bool {
+			return ami.ID == i.ImageID
+		}).Name,
+	}
+	result := map[string]string{}
+	for _, attribute := range options.FromContext(ctx).NodeMetadata() {
+		if values[attribute] != "" {
+			result[annotations[attribute]] = values[attribute]
+		}
+	}
+	return result
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.metadata").
+		For(&karpv1.NodeClaim{}).
+		WithEventFilter(predicate.NewPredicateFuncs(func(o client.Object) bool {
+			return isDescribable(o.(*karpv1.NodeClaim))
+		})).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 10,
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
+
+func isDescribable(nc *karpv1.NodeClaim) bool {
+	// Instance isn't launched yet
+	if nc.Status.ProviderID == "" {
+		return false
+	}
+	// NodeClaim is currently terminating
+	if !nc.DeletionTimestamp.IsZero() {
+		return false
+	}
+	return true
+}
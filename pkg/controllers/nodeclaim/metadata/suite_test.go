@@ -0,0 +1,152 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/metadata"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var awsEnv *test.Environment
+var env *coretest.Environment
+var controller *metadata.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Metadata")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+		NodeMetadataAnnotations: lo.ToPtr("placement-group,host-id,capacity-reservation-id,ami-name"),
+	}))
+	awsEnv = test.NewEnvironment(ctx, env)
+	controller = metadata.NewController(env.Client, awsEnv.InstanceProvider)
+})
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	awsEnv.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Metadata", func() {
+	var nodeClass *v1.EC2NodeClass
+	var nodeClaim *karpv1.NodeClaim
+	var node *corev1.Node
+	var instanceID string
+
+	BeforeEach(func() {
+		instanceID = fake.InstanceID()
+		awsEnv.EC2API.Instances.Store(instanceID, &ec2.Instance{
+			InstanceId: aws.String(instanceID),
+			ImageId:    aws.String("ami-123"),
+			State:      &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)},
+			Placement: &ec2.Placement{
+				AvailabilityZone: aws.String(fake.DefaultRegion),
+				GroupName:        aws.String("cluster-pg"),
+			},
+			CapacityReservationId: aws.String("cr-123"),
+			UsageOperation:        aws.String("RunInstances:0002"),
+			InstanceType:          aws.String("m5.large"),
+		})
+		nodeClass = test.EC2NodeClass()
+		nodeClass.Status.AMIs = []v1.AMI{{ID: "ami-123", Name: "amazon-eks-node-al2023-x86_64-standard-1.30-v20240807"}}
+		node = coretest.Node(coretest.NodeOptions{ProviderID: fake.ProviderID(instanceID)})
+		nodeClaim = coretest.NodeClaim(karpv1.NodeClaim{
+			Spec: karpv1.NodeClaimSpec{NodeClassRef: &karpv1.NodeClassReference{
+				Group: "karpenter.k8s.aws",
+				Kind:  "EC2NodeClass",
+				Name:  nodeClass.Name,
+			}},
+			Status: karpv1.NodeClaimStatus{ProviderID: fake.ProviderID(instanceID)},
+		})
+	})
+
+	It("should annotate the NodeClaim with the selected attributes of its instance", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationPlacementGroup, "cluster-pg"))
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationCapacityReservationID, "cr-123"))
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationAMIName, "amazon-eks-node-al2023-x86_64-standard-1.30-v20240807"))
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationInstanceMetadata, "true"))
+		// Attributes that aren't selected aren't added
+		Expect(nodeClaim.Annotations).ToNot(HaveKey(v1.AnnotationUsageOperation))
+		// Attributes that aren't set on the instance aren't added
+		Expect(nodeClaim.Annotations).ToNot(HaveKey(v1.AnnotationHostID))
+	})
+	It("should copy the annotations to nodes that registered before the NodeClaim was annotated", func() {
+		nodeClaim.Status.NodeName = node.Name
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Annotations).To(HaveKeyWithValue(v1.AnnotationPlacementGroup, "cluster-pg"))
+		Expect(node.Annotations).To(HaveKeyWithValue(v1.AnnotationCapacityReservationID, "cr-123"))
+		Expect(node.Annotations).ToNot(HaveKey(v1.AnnotationInstanceMetadata))
+	})
+	It("should not describe the instances of NodeClaims that are already annotated", func() {
+		nodeClaim.Annotations = map[string]string{v1.AnnotationInstanceMetadata: "true"}
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		Expect(awsEnv.EC2API.DescribeInstancesBehavior.Calls()).To(BeZero())
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).ToNot(HaveKey(v1.AnnotationPlacementGroup))
+	})
+	It("should not annotate NodeClaims that aren't launched", func() {
+		nodeClaim.Status.ProviderID = ""
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).ToNot(HaveKey(v1.AnnotationInstanceMetadata))
+	})
+	It("should gracefully handle missing instances", func() {
+		awsEnv.EC2API.Instances.Delete(instanceID)
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).ToNot(HaveKey(v1.AnnotationInstanceMetadata))
+	})
+})
@@ -0,0 +1,112 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spotsavings
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+)
+
+const pollInterval = time.Minute
+
+// Controller accrues the savings of spot nodes compared to the on-demand price of the same instance types. The spot and
+// on-demand prices are recorded on the NodeClaim at launch, so savings reflect the price that was actually paid rather
+// than the current spot price.
+type Controller struct {
+	kubeClient client.Client
+	clock      clock.Clock
+	// lastAccrued is the time up to which savings have been accrued. Savings from before the controller started aren't
+	// accrued, since the counter starts from zero on every restart.
+	lastAccrued time.Time
+}
+
+func NewController(kubeClient client.Client, clk clock.Clock) *Controller {
+	return &Controller{
+		kubeClient:  kubeClient,
+		clock:       clk,
+		lastAccrued: clk.Now(),
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.spotsavings")
+
+	nodeClaimList := &karpv1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaimList, client.MatchingLabels{karpv1.CapacityTypeLabelKey: karpv1.CapacityTypeSpot}); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	now := c.clock.Now()
+	rates := map[string]float64{}
+	for i := range nodeClaimList.Items {
+		nodeClaim := &nodeClaimList.Items[i]
+		launched := nodeClaim.StatusConditions().Get(karpv1.ConditionTypeLaunched)
+		if !launched.IsTrue() {
+			continue
+		}
+		rate, ok := hourlySavings(nodeClaim)
+		if !ok {
+			continue
+		}
+		nodePool := nodeClaim.Labels[karpv1.NodePoolLabelKey]
+		rates[nodePool] += rate
+		since := lo.Ternary(launched.LastTransitionTime.Time.After(c.lastAccrued), launched.LastTransitionTime.Time, c.lastAccrued)
+		if elapsed := now.Sub(since); elapsed > 0 {
+			savingsDollars.With(prometheus.Labels{metrics.NodePoolLabel: nodePool}).Add(rate * elapsed.Hours())
+		}
+	}
+	c.lastAccrued = now
+	savingsRate.Reset()
+	for nodePool, rate := range rates {
+		savingsRate.With(prometheus.Labels{metrics.NodePoolLabel: nodePool}).Set(rate)
+	}
+	return reconcile.Result{RequeueAfter: pollInterval}, nil
+}
+
+// hourlySavings returns the difference between the on-demand and spot prices recorded on the NodeClaim at launch
+func hourlySavings(nodeClaim *karpv1.NodeClaim) (float64, bool) {
+	spotPrice, err := strconv.ParseFloat(nodeClaim.Annotations[v1.AnnotationLaunchSpotPrice], 64)
+	if err != nil {
+		return 0, false
+	}
+	onDemandPrice, err := strconv.ParseFloat(nodeClaim.Annotations[v1.AnnotationLaunchOnDemandPrice], 64)
+	if err != nil {
+		return 0, false
+	}
+	// Spot can be priced above on-demand, in which case the savings are negative
+	return onDemandPrice - spotPrice, true
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.spotsavings").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
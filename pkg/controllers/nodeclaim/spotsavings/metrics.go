@@ -0,0 +1,51 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spotsavings
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	nodePoolSubsystem = "nodepool"
+)
+
+var (
+	savingsDollars = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: nodePoolSubsystem,
+			Name:      "spot_savings_dollars_total",
+			Help:      "Cumulative savings of the spot nodes of a NodePool compared to the on-demand price of the same instance types, based on the prices at launch. Labeled by nodepool.",
+		},
+		[]string{metrics.NodePoolLabel},
+	)
+	savingsRate = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: nodePoolSubsystem,
+			Name:      "spot_savings_hourly_dollars",
+			Help:      "Current hourly savings of the spot nodes of a NodePool compared to the on-demand price of the same instance types, based on the prices at launch. Labeled by nodepool.",
+		},
+		[]string{metrics.NodePoolLabel},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(savingsDollars, savingsRate)
+}
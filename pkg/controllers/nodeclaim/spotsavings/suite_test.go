@@ -0,0 +1,143 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spotsavings_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/spotsavings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var fakeClock *clock.FakeClock
+var controller *spotsavings.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SpotSavings")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	fakeClock = clock.NewFakeClock(time.Now().Add(-time.Hour))
+	controller = spotsavings.NewController(env.Client, fakeClock)
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("SpotSavings", func() {
+	var nodePool *karpv1.NodePool
+
+	nodeClaimWithPrices := func(capacityType, spotPrice, onDemandPrice string) *karpv1.NodeClaim {
+		nodeClaim := coretest.NodeClaim(karpv1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					karpv1.NodePoolLabelKey:     nodePool.Name,
+					karpv1.CapacityTypeLabelKey: capacityType,
+				},
+				Annotations: map[string]string{
+					v1.AnnotationLaunchSpotPrice:     spotPrice,
+					v1.AnnotationLaunchOnDemandPrice: onDemandPrice,
+				},
+			},
+		})
+		nodeClaim.StatusConditions().SetTrue(karpv1.ConditionTypeLaunched)
+		return nodeClaim
+	}
+	ExpectSavings := func(total, hourly float64) {
+		GinkgoHelper()
+		counter, ok := FindMetricWithLabelValues("karpenter_nodepool_spot_savings_dollars_total", map[string]string{"nodepool": nodePool.Name})
+		Expect(ok).To(BeTrue())
+		Expect(counter.GetCounter().GetValue()).To(BeNumerically("~", total, 1e-9))
+		gauge, ok := FindMetricWithLabelValues("karpenter_nodepool_spot_savings_hourly_dollars", map[string]string{"nodepool": nodePool.Name})
+		Expect(ok).To(BeTrue())
+		Expect(gauge.GetGauge().GetValue()).To(BeNumerically("~", hourly, 1e-9))
+	}
+
+	BeforeEach(func() {
+		nodePool = coretest.NodePool()
+	})
+	It("should accrue the savings of spot nodes since launch", func() {
+		nodeClaim := nodeClaimWithPrices(karpv1.CapacityTypeSpot, "0.04", "0.1")
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		launched := nodeClaim.StatusConditions().Get(karpv1.ConditionTypeLaunched).LastTransitionTime.Time
+
+		fakeClock.SetTime(launched.Add(2 * time.Hour))
+		ExpectSingletonReconciled(ctx, controller)
+		ExpectSavings(0.12, 0.06)
+
+		// Savings are only accrued once for each interval
+		fakeClock.SetTime(launched.Add(3 * time.Hour))
+		ExpectSingletonReconciled(ctx, controller)
+		ExpectSavings(0.18, 0.06)
+	})
+	It("should sum the savings of all spot nodes of a NodePool", func() {
+		nodeClaims := []*karpv1.NodeClaim{
+			nodeClaimWithPrices(karpv1.CapacityTypeSpot, "0.04", "0.1"),
+			nodeClaimWithPrices(karpv1.CapacityTypeSpot, "0.1", "0.3"),
+		}
+		ExpectApplied(ctx, env.Client, nodeClaims[0], nodeClaims[1])
+		ExpectSingletonReconciled(ctx, controller)
+		gauge, ok := FindMetricWithLabelValues("karpenter_nodepool_spot_savings_hourly_dollars", map[string]string{"nodepool": nodePool.Name})
+		Expect(ok).To(BeTrue())
+		Expect(gauge.GetGauge().GetValue()).To(BeNumerically("~", 0.26, 1e-9))
+	})
+	It("should ignore on-demand nodes and nodes without launch prices", func() {
+		ExpectApplied(ctx, env.Client,
+			nodeClaimWithPrices(karpv1.CapacityTypeOnDemand, "0.04", "0.1"),
+			nodeClaimWithPrices(karpv1.CapacityTypeSpot, "", ""),
+		)
+		ExpectSingletonReconciled(ctx, controller)
+		_, ok := FindMetricWithLabelValues("karpenter_nodepool_spot_savings_hourly_dollars", map[string]string{"nodepool": nodePool.Name})
+		Expect(ok).To(BeFalse())
+	})
+	It("should not accrue savings for NodeClaims that haven't launched", func() {
+		nodeClaim := nodeClaimWithPrices(karpv1.CapacityTypeSpot, "0.04", "0.1")
+		nodeClaim.StatusConditions().SetFalse(karpv1.ConditionTypeLaunched, "Pending", "Pending")
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectSingletonReconciled(ctx, controller)
+		_, ok := FindMetricWithLabelValues("karpenter_nodepool_spot_savings_hourly_dollars", map[string]string{"nodepool": nodePool.Name})
+		Expect(ok).To(BeFalse())
+	})
+})
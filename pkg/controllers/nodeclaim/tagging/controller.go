@@ -15,11 +15,14 @@ limitations under the License.
 package tagging
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"text/template"
 	"time"
 
 	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -30,10 +33,12 @@ import (
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
 
 	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
 
 	"github.com/awslabs/operatorpkg/reasonable"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
 
@@ -67,7 +72,15 @@ func (c *Controller) Reconcile(ctx context.Context, nodeClaim *karpv1.NodeClaim)
 		log.FromContext(ctx).Error(err, "failed parsing instance id")
 		return reconcile.Result{}, nil
 	}
-	if err = c.tagInstance(ctx, nodeClaim, id); err != nil {
+	nodeClass := &v1.EC2NodeClass{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodeClaim.Spec.NodeClassRef.Name}, nodeClass); client.IgnoreNotFound(err) != nil {
+		return reconcile.Result{}, fmt.Errorf("resolving nodeclass, %w", err)
+	}
+	ctx = assumerole.WithNodePool(assumerole.WithNodeClass(ctx, nodeClass), nodeClaim.Labels[karpv1.NodePoolLabelKey])
+	if err = c.tagInstance(ctx, nodeClass, nodeClaim, id); err != nil {
+		return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(err)
+	}
+	if err = c.disableSourceDestCheck(ctx, nodeClass, id); err != nil {
 		return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(err)
 	}
 	nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationInstanceTagged: "true"})
@@ -93,9 +106,9 @@ func (c *Controller) Register(_ context.Context, m manager.Manager) error {
 		Complete(reconcile.AsReconciler(m.GetClient(), c))
 }
 
-func (c *Controller) tagInstance(ctx context.Context, nc *karpv1.NodeClaim, id string) error {
+func (c *Controller) tagInstance(ctx context.Context, nodeClass *v1.EC2NodeClass, nc *karpv1.NodeClaim, id string) error {
 	tags := map[string]string{
-		v1.TagName:      nc.Status.NodeName,
+		v1.TagName:      nameTag(ctx, nodeClass, nc, id),
 		v1.TagNodeClaim: nc.Name,
 	}
 
@@ -118,6 +131,62 @@ func (c *Controller) tagInstance(ctx context.Context, nc *karpv1.NodeClaim, id s
 	return nil
 }
 
+// disableSourceDestCheck disables the source/destination check of the instance when its EC2NodeClass disables it on the
+// primary network interface, since it can't be disabled by the launch template
+func (c *Controller) disableSourceDestCheck(ctx context.Context, nodeClass *v1.EC2NodeClass, id string) error {
+	if nodeClass.Spec.PrimaryNetworkInterface == nil || lo.FromPtrOr(nodeClass.Spec.PrimaryNetworkInterface.SourceDestCheck, true) {
+		return nil
+	}
+	instance, err := c.instanceProvider.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("disabling source/destination check, %w", err)
+	}
+	if !instance.SourceDestCheck {
+		return nil
+	}
+	return c.instanceProvider.DisableSourceDestCheck(ctx, id)
+}
+
+// nameTagData is the data that the Name tag templates of EC2NodeClasses are executed with
+type nameTagData struct {
+	NodeName     string
+	InstanceID   string
+	NodePool     string
+	Zone         string
+	InstanceType string
+	CapacityType string
+}
+
+// nameTag returns the Name tag of the instance from the Name tag template of its EC2NodeClass. Instances are named
+// after their nodes when the EC2NodeClass doesn't have a template, or its template can't be executed.
+func nameTag(ctx context.Context, nodeClass *v1.EC2NodeClass, nc *karpv1.NodeClaim, id string) string {
+	if nodeClass.Spec.NameTag == nil {
+		return nc.Status.NodeName
+	}
+	t, err := template.New("nameTag").Parse(*nodeClass.Spec.NameTag)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed parsing name tag template, using the node name")
+		return nc.Status.NodeName
+	}
+	name := &bytes.Buffer{}
+	if err = t.Execute(name, nameTagData{
+		NodeName:     nc.Status.NodeName,
+		InstanceID:   id,
+		NodePool:     nc.Labels[karpv1.NodePoolLabelKey],
+		Zone:         nc.Labels[corev1.LabelTopologyZone],
+		InstanceType: nc.Labels[corev1.LabelInstanceTypeStable],
+		CapacityType: nc.Labels[karpv1.CapacityTypeLabelKey],
+	}); err != nil {
+		log.FromContext(ctx).Error(err, "failed executing name tag template, using the node name")
+		return nc.Status.NodeName
+	}
+	if name.Len() == 0 {
+		return nc.Status.NodeName
+	}
+	// Tag values are limited to 256 characters
+	return lo.Substring(name.String(), 0, 256)
+}
+
 func isTaggable(nc *karpv1.NodeClaim) bool {
 	// Instance has already been tagged
 	if val := nc.Annotations[v1.AnnotationInstanceTagged]; val == "true" {
@@ -185,6 +185,85 @@ var _ = Describe("TaggingController", func() {
 		})).To(BeFalse())
 	})
 
+	It("should tag the instance with the name tag template of the EC2NodeClass", func() {
+		nodeClass := test.EC2NodeClass(v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{
+			NameTag: lo.ToPtr("{{ .NodePool }}-{{ .InstanceID }}"),
+		}})
+		nodeClaim := coretest.NodeClaim(karpv1.NodeClaim{
+			ObjectMeta: corev1.ObjectMeta{Labels: map[string]string{karpv1.NodePoolLabelKey: "default"}},
+			Spec: karpv1.NodeClaimSpec{NodeClassRef: &karpv1.NodeClassReference{
+				Group: "karpenter.k8s.aws",
+				Kind:  "EC2NodeClass",
+				Name:  nodeClass.Name,
+			}},
+			Status: karpv1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(*ec2Instance.InstanceId),
+				NodeName:   "default",
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, taggingController, nodeClaim)
+		Expect(instance.NewInstance(ec2Instance).Tags).To(HaveKeyWithValue(v1.TagName, "default-"+*ec2Instance.InstanceId))
+	})
+	It("should tag the instance with the node name when the name tag template can't be executed", func() {
+		nodeClass := test.EC2NodeClass(v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{
+			NameTag: lo.ToPtr("{{ .Hostname }}"),
+		}})
+		nodeClaim := coretest.NodeClaim(karpv1.NodeClaim{
+			Spec: karpv1.NodeClaimSpec{NodeClassRef: &karpv1.NodeClassReference{
+				Group: "karpenter.k8s.aws",
+				Kind:  "EC2NodeClass",
+				Name:  nodeClass.Name,
+			}},
+			Status: karpv1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(*ec2Instance.InstanceId),
+				NodeName:   "default",
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, taggingController, nodeClaim)
+		Expect(instance.NewInstance(ec2Instance).Tags).To(HaveKeyWithValue(v1.TagName, "default"))
+	})
+	It("should disable the source/destination check when the EC2NodeClass disables it", func() {
+		nodeClass := test.EC2NodeClass(v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{
+			PrimaryNetworkInterface: &v1.PrimaryNetworkInterface{SourceDestCheck: lo.ToPtr(false)},
+		}})
+		nodeClaim := coretest.NodeClaim(karpv1.NodeClaim{
+			Spec: karpv1.NodeClaimSpec{NodeClassRef: &karpv1.NodeClassReference{
+				Group: "karpenter.k8s.aws",
+				Kind:  "EC2NodeClass",
+				Name:  nodeClass.Name,
+			}},
+			Status: karpv1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(*ec2Instance.InstanceId),
+				NodeName:   "default",
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, taggingController, nodeClaim)
+		Expect(instance.NewInstance(ec2Instance).SourceDestCheck).To(BeFalse())
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationInstanceTagged, "true"))
+	})
+	It("should keep the source/destination check when the EC2NodeClass doesn't disable it", func() {
+		nodeClass := test.EC2NodeClass()
+		nodeClaim := coretest.NodeClaim(karpv1.NodeClaim{
+			Spec: karpv1.NodeClaimSpec{NodeClassRef: &karpv1.NodeClassReference{
+				Group: "karpenter.k8s.aws",
+				Kind:  "EC2NodeClass",
+				Name:  nodeClass.Name,
+			}},
+			Status: karpv1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(*ec2Instance.InstanceId),
+				NodeName:   "default",
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, taggingController, nodeClaim)
+		Expect(instance.NewInstance(ec2Instance).SourceDestCheck).To(BeTrue())
+		Expect(awsEnv.EC2API.ModifyInstanceAttributeBehavior.Calls()).To(Equal(0))
+	})
+
 	DescribeTable(
 		"should tag taggable instances",
 		func(customTags ...string) {
@@ -0,0 +1,195 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package terminationprotection
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// pollPeriod is how often the termination protection of the instances is checked, since it can be set outside of
+// Karpenter at any time
+const pollPeriod = 10 * time.Minute
+
+// Controller manages the termination protection, the disableApiTermination attribute, of the instances of NodeClaims.
+// The instances of NodePools with the karpenter.k8s.aws/termination-protection annotation are protected, so that they
+// can't be terminated outside of Karpenter, and their protection is cleared when their NodeClaims are deleted.
+// Instances that are protected outside of Karpenter can't be terminated by Karpenter either, so their nodes are
+// annotated with karpenter.sh/do-not-disrupt until the protection is cleared, rather than being drained by
+// consolidation or drift and never terminated.
+type Controller struct {
+	kubeClient       client.Client
+	recorder         events.Recorder
+	instanceProvider instance.Provider
+}
+
+func NewController(kubeClient client.Client, recorder events.Recorder, instanceProvider instance.Provider) *Controller {
+	return &Controller{
+		kubeClient:       kubeClient,
+		recorder:         recorder,
+		instanceProvider: instanceProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *karpv1.NodeClaim) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.terminationprotection")
+
+	if nodeClaim.Status.ProviderID == "" {
+		return reconcile.Result{}, nil
+	}
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("provider-id", nodeClaim.Status.ProviderID))
+	id, err := utils.ParseInstanceID(nodeClaim.Status.ProviderID)
+	if err != nil {
+		// We don't throw an error here since we don't want to retry until the ProviderID has been updated.
+		log.FromContext(ctx).Error(err, "failed parsing instance id")
+		return reconcile.Result{}, nil
+	}
+	nodeClass := &v1.EC2NodeClass{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Spec.NodeClassRef.Name}, nodeClass); client.IgnoreNotFound(err) != nil {
+		return reconcile.Result{}, fmt.Errorf("resolving nodeclass, %w", err)
+	}
+	ctx = assumerole.WithNodePool(assumerole.WithNodeClass(ctx, nodeClass), nodeClaim.Labels[karpv1.NodePoolLabelKey])
+	managed := nodeClaim.Annotations[v1.AnnotationTerminationProtected] == "true"
+	if !nodeClaim.DeletionTimestamp.IsZero() {
+		return c.finalize(ctx, nodeClaim, id, managed)
+	}
+	protect, err := c.protect(ctx, nodeClaim)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if protect != managed {
+		if err := c.instanceProvider.SetTerminationProtection(ctx, id, protect); err != nil {
+			return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(err)
+		}
+		stored := nodeClaim.DeepCopy()
+		if protect {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationTerminationProtected: "true"})
+		} else {
+			delete(nodeClaim.Annotations, v1.AnnotationTerminationProtected)
+		}
+		if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(err)
+		}
+		log.FromContext(ctx).WithValues("protected", protect).Info("set termination protection")
+	}
+	if protect {
+		return reconcile.Result{}, nil
+	}
+	protected, err := c.instanceProvider.IsTerminationProtected(ctx, id)
+	if err != nil {
+		return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(err)
+	}
+	if err := c.blockDisruption(ctx, nodeClaim, protected); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: pollPeriod}, nil
+}
+
+// protect returns whether the NodePool of the NodeClaim requests termination protection for its instances
+func (c *Controller) protect(ctx context.Context, nodeClaim *karpv1.NodeClaim) (bool, error) {
+	nodePool := &karpv1.NodePool{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Labels[karpv1.NodePoolLabelKey]}, nodePool); err != nil {
+		return false, client.IgnoreNotFound(fmt.Errorf("getting nodepool, %w", err))
+	}
+	return nodePool.Annotations[v1.AnnotationTerminationProtection] == "true", nil
+}
+
+// finalize clears the protection that was set by the controller once the NodeClaim is deleted, so that its instance
+// can be terminated. The protection that was set outside of Karpenter is kept, and published to the NodeClaim, since
+// the instance isn't terminated until it's cleared.
+func (c *Controller) finalize(ctx context.Context, nodeClaim *karpv1.NodeClaim, id string, managed bool) (reconcile.Result, error) {
+	if managed {
+		if err := c.instanceProvider.SetTerminationProtection(ctx, id, false); err != nil {
+			return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(err)
+		}
+		return reconcile.Result{}, nil
+	}
+	protected, err := c.instanceProvider.IsTerminationProtected(ctx, id)
+	if err != nil {
+		return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(err)
+	}
+	if !protected {
+		return reconcile.Result{}, nil
+	}
+	c.recorder.Publish(TerminationBlockedEvent(nodeClaim, id))
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
+
+// blockDisruption annotates the node of the NodeClaim with karpenter.sh/do-not-disrupt while its instance is protected
+// outside of Karpenter. The node is also annotated with karpenter.k8s.aws/externally-protected, so that only the
+// karpenter.sh/do-not-disrupt annotations that were added by the controller are removed once the protection is
+// cleared.
+func (c *Controller) blockDisruption(ctx context.Context, nodeClaim *karpv1.NodeClaim, protected bool) error {
+	if nodeClaim.Status.NodeName == "" {
+		return nil
+	}
+	node := &corev1.Node{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Status.NodeName}, node); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("getting node, %w", err))
+	}
+	stored := node.DeepCopy()
+	blocked := node.Annotations[v1.AnnotationExternallyProtected] == "true"
+	switch {
+	case protected && !blocked && node.Annotations[karpv1.DoNotDisruptAnnotationKey] != "true":
+		node.Annotations = lo.Assign(node.Annotations, map[string]string{
+			karpv1.DoNotDisruptAnnotationKey: "true",
+			v1.AnnotationExternallyProtected: "true",
+		})
+		c.recorder.Publish(DisruptionBlockedEvent(nodeClaim))
+	case !protected && blocked:
+		delete(node.Annotations, karpv1.DoNotDisruptAnnotationKey)
+		delete(node.Annotations, v1.AnnotationExternallyProtected)
+	}
+	if equality.Semantic.DeepEqual(node, stored) {
+		return nil
+	}
+	if err := c.kubeClient.Patch(ctx, node, client.MergeFrom(stored)); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("patching node, %w", err))
+	}
+	log.FromContext(ctx).WithValues("Node", node.Name, "protected", protected).Info("updated disruption of externally protected instance")
+	return nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.terminationprotection").
+		For(&karpv1.NodeClaim{}).
+		// Ok with using the default MaxConcurrentReconciles of 1 to avoid throttling from ModifyInstanceAttribute
+		WithOptions(controller.Options{
+			RateLimiter: reasonable.RateLimiter(),
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
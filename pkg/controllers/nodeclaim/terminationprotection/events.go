@@ -0,0 +1,43 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package terminationprotection
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+)
+
+func DisruptionBlockedEvent(nodeClaim *karpv1.NodeClaim) events.Event {
+	return events.Event{
+		InvolvedObject: nodeClaim,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "TerminationProtected",
+		Message:        "Blocked disruption, the instance is protected from termination outside of Karpenter",
+		DedupeValues:   []string{string(nodeClaim.UID)},
+	}
+}
+
+func TerminationBlockedEvent(nodeClaim *karpv1.NodeClaim, instanceID string) events.Event {
+	return events.Event{
+		InvolvedObject: nodeClaim,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "TerminationBlocked",
+		Message:        fmt.Sprintf("Instance %s can't be terminated until its termination protection is cleared", instanceID),
+		DedupeValues:   []string{string(nodeClaim.UID)},
+	}
+}
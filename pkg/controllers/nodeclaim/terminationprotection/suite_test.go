@@ -0,0 +1,199 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package terminationprotection_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/terminationprotection"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var awsEnv *test.Environment
+var env *coretest.Environment
+var recorder *coretest.EventRecorder
+var controller *terminationprotection.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TerminationProtection")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+	recorder = coretest.NewEventRecorder()
+	controller = terminationprotection.NewController(env.Client, recorder, awsEnv.InstanceProvider)
+})
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	awsEnv.Reset()
+	recorder.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("TerminationProtection", func() {
+	var nodeClass *v1.EC2NodeClass
+	var nodePool *karpv1.NodePool
+	var nodeClaim *karpv1.NodeClaim
+	var node *corev1.Node
+	var instanceID string
+
+	BeforeEach(func() {
+		instanceID = fake.InstanceID()
+		awsEnv.EC2API.Instances.Store(instanceID, &ec2.Instance{
+			InstanceId: aws.String(instanceID),
+			State:      &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)},
+			Tags: []*ec2.Tag{
+				{Key: aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)), Value: aws.String("owned")},
+				{Key: aws.String(karpv1.NodePoolLabelKey), Value: aws.String("default")},
+			},
+			Placement:    &ec2.Placement{AvailabilityZone: aws.String(fake.DefaultRegion)},
+			InstanceType: aws.String("m5.large"),
+		})
+		nodeClass = test.EC2NodeClass()
+		nodePool = coretest.NodePool(karpv1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+		node = coretest.Node(coretest.NodeOptions{ProviderID: fake.ProviderID(instanceID)})
+		nodeClaim = coretest.NodeClaim(karpv1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{karpv1.NodePoolLabelKey: nodePool.Name}},
+			Spec: karpv1.NodeClaimSpec{NodeClassRef: &karpv1.NodeClassReference{
+				Group: "karpenter.k8s.aws",
+				Kind:  "EC2NodeClass",
+				Name:  nodeClass.Name,
+			}},
+			Status: karpv1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(instanceID),
+				NodeName:   node.Name,
+			},
+		})
+	})
+
+	It("should protect the instances of NodePools that request termination protection", func() {
+		nodePool.Annotations = map[string]string{v1.AnnotationTerminationProtection: "true"}
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		_, protected := awsEnv.EC2API.TerminationProtectedInstances.Load(instanceID)
+		Expect(protected).To(BeTrue())
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationTerminationProtected, "true"))
+		// The protection that's set by Karpenter doesn't block disruption
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Annotations).ToNot(HaveKey(karpv1.DoNotDisruptAnnotationKey))
+	})
+	It("should clear the protection of instances whose NodePools no longer request it", func() {
+		nodePool.Annotations = map[string]string{v1.AnnotationTerminationProtection: "true"}
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		nodePool.Annotations = nil
+		ExpectApplied(ctx, env.Client, nodePool)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		_, protected := awsEnv.EC2API.TerminationProtectedInstances.Load(instanceID)
+		Expect(protected).To(BeFalse())
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).ToNot(HaveKey(v1.AnnotationTerminationProtected))
+	})
+	It("should clear the protection that it set when the NodeClaim is deleted", func() {
+		nodePool.Annotations = map[string]string{v1.AnnotationTerminationProtection: "true"}
+		nodeClaim.Finalizers = []string{"testing/finalizer"}
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(env.Client.Delete(ctx, nodeClaim)).To(Succeed())
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		_, protected := awsEnv.EC2API.TerminationProtectedInstances.Load(instanceID)
+		Expect(protected).To(BeFalse())
+		ExpectFinalizersRemoved(ctx, env.Client, nodeClaim)
+	})
+	It("should block the disruption of instances that are protected outside of Karpenter", func() {
+		awsEnv.EC2API.TerminationProtectedInstances.Store(instanceID, true)
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool, nodeClaim, node)
+		result := ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		Expect(result.RequeueAfter).ToNot(BeZero())
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Annotations).To(HaveKeyWithValue(karpv1.DoNotDisruptAnnotationKey, "true"))
+		Expect(node.Annotations).To(HaveKeyWithValue(v1.AnnotationExternallyProtected, "true"))
+		Expect(recorder.Calls("TerminationProtected")).To(Equal(1))
+		// The protection that's set outside of Karpenter isn't cleared
+		_, protected := awsEnv.EC2API.TerminationProtectedInstances.Load(instanceID)
+		Expect(protected).To(BeTrue())
+
+		awsEnv.EC2API.TerminationProtectedInstances.Delete(instanceID)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Annotations).ToNot(HaveKey(karpv1.DoNotDisruptAnnotationKey))
+		Expect(node.Annotations).ToNot(HaveKey(v1.AnnotationExternallyProtected))
+	})
+	It("should keep the do-not-disrupt annotation that it didn't add", func() {
+		node.Annotations = map[string]string{karpv1.DoNotDisruptAnnotationKey: "true"}
+		awsEnv.EC2API.TerminationProtectedInstances.Store(instanceID, true)
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		awsEnv.EC2API.TerminationProtectedInstances.Delete(instanceID)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Annotations).To(HaveKeyWithValue(karpv1.DoNotDisruptAnnotationKey, "true"))
+		Expect(node.Annotations).ToNot(HaveKey(v1.AnnotationExternallyProtected))
+	})
+	It("should publish that the instance of a deleted NodeClaim is protected outside of Karpenter", func() {
+		awsEnv.EC2API.TerminationProtectedInstances.Store(instanceID, true)
+		nodeClaim.Finalizers = []string{"testing/finalizer"}
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool, nodeClaim, node)
+		Expect(env.Client.Delete(ctx, nodeClaim)).To(Succeed())
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		Expect(recorder.Calls("TerminationBlocked")).To(Equal(1))
+		_, protected := awsEnv.EC2API.TerminationProtectedInstances.Load(instanceID)
+		Expect(protected).To(BeTrue())
+		ExpectFinalizersRemoved(ctx, env.Client, nodeClaim)
+	})
+	It("should gracefully handle missing instances", func() {
+		awsEnv.EC2API.Instances.Delete(instanceID)
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(lo.Keys(node.Annotations)).ToNot(ContainElement(karpv1.DoNotDisruptAnnotationKey))
+	})
+})
@@ -0,0 +1,94 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unregistered
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/alerting"
+)
+
+const (
+	// registrationTTL matches the time that the core liveness controller waits for a launched NodeClaim to register
+	// before terminating it
+	registrationTTL = 15 * time.Minute
+	// observedTTL bounds how long the outcome of a NodeClaim is remembered. Registrations that are older than this
+	// aren't recorded so that a periodic resync of long-lived NodeClaims doesn't reset an ongoing streak.
+	observedTTL = 24 * time.Hour
+)
+
+// Controller reports launched NodeClaims that didn't register within the registration TTL, and NodeClaims that
+// registered, to the alert tracker so that NodePools that repeatedly launch nodes that never join the cluster are alerted on
+type Controller struct {
+	clock        clock.Clock
+	alertTracker *alerting.Tracker
+	// observed tracks the NodeClaims whose registration outcome has already been recorded
+	observed *cache.Cache
+}
+
+func NewController(clk clock.Clock, alertTracker *alerting.Tracker) *Controller {
+	return &Controller{
+		clock:        clk,
+		alertTracker: alertTracker,
+		observed:     cache.New(observedTTL, time.Hour),
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *karpv1.NodeClaim) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.unregistered")
+
+	if _, ok := c.observed.Get(string(nodeClaim.UID)); ok {
+		return reconcile.Result{}, nil
+	}
+	launched := nodeClaim.StatusConditions().Get(karpv1.ConditionTypeLaunched)
+	if !launched.IsTrue() {
+		return reconcile.Result{}, nil
+	}
+	nodePool := nodeClaim.Labels[karpv1.NodePoolLabelKey]
+	if registered := nodeClaim.StatusConditions().Get(karpv1.ConditionTypeRegistered); registered.IsTrue() {
+		if c.clock.Since(registered.LastTransitionTime.Time) < observedTTL {
+			c.alertTracker.RecordRegistered(nodePool)
+		}
+		c.observed.SetDefault(string(nodeClaim.UID), struct{}{})
+		return reconcile.Result{}, nil
+	}
+	if elapsed := c.clock.Since(launched.LastTransitionTime.Time); elapsed < registrationTTL {
+		return reconcile.Result{RequeueAfter: registrationTTL - elapsed}, nil
+	}
+	// Unregistered nodes are aggregated by where they were launched since a bad AMI or subnet tends to affect all of
+	// the nodes launched with it
+	c.alertTracker.RecordUnregistered(ctx, nodePool, fmt.Sprintf("%s/%s", nodeClaim.Labels[corev1.LabelInstanceTypeStable], nodeClaim.Labels[corev1.LabelTopologyZone]))
+	c.observed.SetDefault(string(nodeClaim.UID), struct{}{})
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.unregistered").
+		For(&karpv1.NodeClaim{}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
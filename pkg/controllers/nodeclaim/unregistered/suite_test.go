@@ -0,0 +1,149 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unregistered_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/alerting"
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/unregistered"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var awsEnv *test.Environment
+var env *coretest.Environment
+var fakeClock *clock.FakeClock
+var controller *unregistered.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Unregistered")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	awsEnv.Reset()
+	fakeClock = clock.NewFakeClock(time.Now())
+	controller = unregistered.NewController(fakeClock, alerting.NewTracker(fakeClock, 2, alerting.NewSNSNotifier(awsEnv.SNSAPI, fake.DefaultAlertTopicARN)))
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Unregistered", func() {
+	newNodeClaim := func() *karpv1.NodeClaim {
+		nodeClaim := coretest.NodeClaim(karpv1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					karpv1.NodePoolLabelKey:        "default",
+					corev1.LabelInstanceTypeStable: "m5.large",
+					corev1.LabelTopologyZone:       "test-zone-1a",
+				},
+			},
+		})
+		nodeClaim.StatusConditions().SetTrue(karpv1.ConditionTypeLaunched)
+		return nodeClaim
+	}
+	ExpectAlerted := func(count int) {
+		GinkgoHelper()
+		Eventually(awsEnv.SNSAPI.PublishBehavior.CalledWithInput.Len).Should(Equal(count))
+		Consistently(awsEnv.SNSAPI.PublishBehavior.CalledWithInput.Len).Should(Equal(count))
+	}
+
+	It("should requeue until the registration TTL has passed", func() {
+		nodeClaim := newNodeClaim()
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		result := ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		Expect(result.RequeueAfter).To(BeNumerically("~", 15*time.Minute, time.Minute))
+	})
+	It("should alert when consecutive nodes fail to register", func() {
+		nodeClaims := []*karpv1.NodeClaim{newNodeClaim(), newNodeClaim()}
+		ExpectApplied(ctx, env.Client, nodeClaims[0], nodeClaims[1])
+		fakeClock.Step(16 * time.Minute)
+		for _, nodeClaim := range nodeClaims {
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		}
+		ExpectAlerted(1)
+
+		alert := alerting.Alert{}
+		Expect(json.Unmarshal([]byte(aws.StringValue(awsEnv.SNSAPI.PublishBehavior.CalledWithInput.Pop().Message)), &alert)).To(Succeed())
+		Expect(alert.Reason).To(Equal(alerting.ReasonUnregisteredNodes))
+		Expect(alert.NodePool).To(Equal("default"))
+		Expect(alert.Errors).To(Equal(map[string]int{"m5.large/test-zone-1a": 2}))
+	})
+	It("should only record each NodeClaim once", func() {
+		nodeClaim := newNodeClaim()
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		fakeClock.Step(16 * time.Minute)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		ExpectAlerted(0)
+	})
+	It("should reset the streak when a node registers", func() {
+		nodeClaims := []*karpv1.NodeClaim{newNodeClaim(), newNodeClaim(), newNodeClaim()}
+		nodeClaims[1].StatusConditions().SetTrue(karpv1.ConditionTypeRegistered)
+		ExpectApplied(ctx, env.Client, nodeClaims[0], nodeClaims[1], nodeClaims[2])
+		fakeClock.Step(16 * time.Minute)
+		for _, nodeClaim := range nodeClaims {
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim)
+		}
+		ExpectAlerted(0)
+	})
+	It("should ignore NodeClaims that haven't launched", func() {
+		nodeClaims := []*karpv1.NodeClaim{newNodeClaim(), newNodeClaim()}
+		for _, nodeClaim := range nodeClaims {
+			nodeClaim.StatusConditions().SetFalse(karpv1.ConditionTypeLaunched, "LaunchFailed", "failed")
+		}
+		ExpectApplied(ctx, env.Client, nodeClaims[0], nodeClaims[1])
+		fakeClock.Step(16 * time.Minute)
+		for _, nodeClaim := range nodeClaims {
+			Expect(ExpectObjectReconciled(ctx, env.Client, controller, nodeClaim).RequeueAfter).To(BeZero())
+		}
+		ExpectAlerted(0)
+	})
+})
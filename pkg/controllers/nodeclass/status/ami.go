@@ -36,6 +36,11 @@ type AMI struct {
 
 func (a *AMI) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
 	amis, err := a.amiProvider.List(ctx, nodeClass)
+	if amifamily.IsVerificationError(err) {
+		nodeClass.Status.AMIs = nil
+		nodeClass.StatusConditions().SetFalse(v1.ConditionTypeAMIsReady, "AMIVerificationFailed", err.Error())
+		return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+	}
 	if err != nil {
 		return reconcile.Result{}, fmt.Errorf("getting amis, %w", err)
 	}
@@ -15,6 +15,11 @@ limitations under the License.
 package status_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"time"
 
@@ -316,4 +321,17 @@ var _ = Describe("NodeClass AMI Status Controller", func() {
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeAMIsReady)).To(BeFalse())
 	})
+	It("should set the status condition to false when no AMI passes verification", func() {
+		key := lo.Must(ecdsa.GenerateKey(elliptic.P256(), rand.Reader))
+		nodeClass.Spec.AMIVerification = &v1.AMIVerification{
+			PublicKey: lo.ToPtr(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: lo.Must(x509.MarshalPKIXPublicKey(&key.PublicKey))}))),
+		}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.AMIs).To(BeEmpty())
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeAMIsReady)
+		Expect(condition.IsFalse()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("AMIVerificationFailed"))
+	})
 })
@@ -0,0 +1,49 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
+)
+
+// Cluster surfaces the values of the cluster that nodes of the EC2NodeClass are bootstrapped with, and reports the
+// values that don't match the values that EKS reports for the cluster. A configured cluster endpoint or CA bundle
+// that's stale breaks the registration of every node that's launched.
+type Cluster struct {
+	launchTemplateProvider launchtemplate.Provider
+}
+
+func (c *Cluster) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
+	cluster, drifted, err := c.launchTemplateProvider.ResolveCluster(ctx)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("resolving cluster, %w", err)
+	}
+	nodeClass.Status.Cluster = cluster
+	if len(drifted) != 0 {
+		nodeClass.StatusConditions().SetTrueWithReason(v1.ConditionTypeClusterConfigDrifted, "ClusterConfigDrifted",
+			fmt.Sprintf("the cluster values that nodes are bootstrapped with don't match EKS, %s", strings.Join(drifted, ", ")))
+		return reconcile.Result{}, nil
+	}
+	// ClusterConfigDrifted isn't a dependent of the Ready condition, so it can be cleared once the values match
+	_ = nodeClass.StatusConditions().Clear(v1.ConditionTypeClusterConfigDrifted)
+	return reconcile.Result{}, nil
+}
@@ -0,0 +1,96 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/samber/lo"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass Cluster Status Controller", func() {
+	BeforeEach(func() {
+		// Cluster CIDR will only be resolved once per lifetime of the launch template provider, reset to nil between tests
+		awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(nil)
+	})
+	It("should surface the values of the cluster that nodes are bootstrapped with", func() {
+		awsEnv.EKSAPI.DescribeClusterBehavior.Output.Set(&eks.DescribeClusterOutput{
+			Cluster: &eks.Cluster{
+				Endpoint: lo.ToPtr("https://test-cluster"),
+				KubernetesNetworkConfig: &eks.KubernetesNetworkConfigResponse{
+					IpFamily:        lo.ToPtr(eks.IpFamilyIpv4),
+					ServiceIpv4Cidr: lo.ToPtr("10.100.0.0/16"),
+				},
+			},
+		})
+		nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2023@latest"}}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.Cluster).To(Equal(&v1.Cluster{
+			Endpoint:                 "https://test-cluster",
+			CertificateAuthorityHash: fmt.Sprintf("%x", sha256.Sum256([]byte(lo.FromPtr(awsEnv.LaunchTemplateProvider.CABundle)))),
+			ServiceCIDR:              "10.100.0.0/16",
+			IPFamily:                 eks.IpFamilyIpv4,
+		}))
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeClusterConfigDrifted)).To(BeNil())
+	})
+	It("should report an endpoint that doesn't match the endpoint of the cluster", func() {
+		awsEnv.EKSAPI.DescribeClusterBehavior.Output.Set(&eks.DescribeClusterOutput{
+			Cluster: &eks.Cluster{Endpoint: lo.ToPtr("https://other-cluster")},
+		})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeClusterConfigDrifted)
+		Expect(condition.IsTrue()).To(BeTrue())
+		Expect(condition.Message).To(ContainSubstring("https://other-cluster"))
+		// The condition doesn't block launches
+		Expect(nodeClass.StatusConditions().Root().IsTrue()).To(BeTrue())
+	})
+	It("should report a service CIDR that doesn't match the service CIDR of the cluster", func() {
+		awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(lo.ToPtr("10.200.0.0/16"))
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeClusterConfigDrifted)
+		Expect(condition.IsTrue()).To(BeTrue())
+		Expect(condition.Message).To(ContainSubstring("10.100.0.0/16"))
+	})
+	It("should surface the values that nodes are bootstrapped with when the cluster isn't known to EKS", func() {
+		awsEnv.EKSAPI.DescribeClusterBehavior.Error.Set(awserr.New(eks.ErrCodeResourceNotFoundException, "No cluster found", nil))
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.Cluster.Endpoint).To(Equal("https://test-cluster"))
+		Expect(nodeClass.Status.Cluster.IPFamily).To(BeEmpty())
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeClusterConfigDrifted)).To(BeNil())
+	})
+	It("should describe the cluster once for every EC2NodeClass", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		Expect(awsEnv.EKSAPI.DescribeClusterBehavior.Calls()).To(Equal(1))
+	})
+})
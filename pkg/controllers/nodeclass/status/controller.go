@@ -17,6 +17,9 @@ package status
 import (
 	"context"
 
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/servicequotas/servicequotasiface"
+	gocache "github.com/patrickmn/go-cache"
 	"go.uber.org/multierr"
 	"k8s.io/apimachinery/pkg/api/equality"
 	controllerruntime "sigs.k8s.io/controller-runtime"
@@ -32,6 +35,8 @@ import (
 	"github.com/awslabs/operatorpkg/reasonable"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
+	"github.com/aws/karpenter-provider-aws/pkg/cache"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
@@ -48,26 +53,41 @@ type Controller struct {
 
 	ami             *AMI
 	instanceprofile *InstanceProfile
+	keypair         *KeyPair
 	subnet          *Subnet
 	securitygroup   *SecurityGroup
+	zoneimpairment  *ZoneImpairment
+	ebsencryption   *EBSEncryption
+	quotaheadroom   *QuotaHeadroom
+	metadataaccess  *PodMetadataAccess
+	cluster         *Cluster
 	readiness       *Readiness //TODO : Remove this when we have sub status conditions
 }
 
 func NewController(kubeClient client.Client, subnetProvider subnet.Provider, securityGroupProvider securitygroup.Provider,
-	amiProvider amifamily.Provider, instanceProfileProvider instanceprofile.Provider, launchTemplateProvider launchtemplate.Provider) *Controller {
+	amiProvider amifamily.Provider, instanceProfileProvider instanceprofile.Provider, launchTemplateProvider launchtemplate.Provider,
+	impairedZones *cache.ImpairedZones, ec2api ec2iface.EC2API, ebsEncryptionCache *gocache.Cache, servicequotasapi servicequotasiface.ServiceQuotasAPI,
+	quotaUsageCache *gocache.Cache) *Controller {
 	return &Controller{
 		kubeClient: kubeClient,
 
 		ami:             &AMI{amiProvider: amiProvider},
 		subnet:          &Subnet{subnetProvider: subnetProvider},
-		securitygroup:   &SecurityGroup{securityGroupProvider: securityGroupProvider},
+		securitygroup:   &SecurityGroup{securityGroupProvider: securityGroupProvider, subnetProvider: subnetProvider},
 		instanceprofile: &InstanceProfile{instanceProfileProvider: instanceProfileProvider},
+		keypair:         &KeyPair{ec2api: ec2api},
+		zoneimpairment:  &ZoneImpairment{impairedZones: impairedZones},
+		ebsencryption:   &EBSEncryption{ec2api: ec2api, cache: ebsEncryptionCache},
+		quotaheadroom:   &QuotaHeadroom{ec2api: ec2api, servicequotasapi: servicequotasapi, cache: quotaUsageCache},
+		metadataaccess:  &PodMetadataAccess{},
+		cluster:         &Cluster{launchTemplateProvider: launchTemplateProvider},
 		readiness:       &Readiness{launchTemplateProvider: launchTemplateProvider},
 	}
 }
 
 func (c *Controller) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
 	ctx = injection.WithControllerName(ctx, "nodeclass.status")
+	ctx = assumerole.WithNodeClass(ctx, nodeClass)
 
 	if !controllerutil.ContainsFinalizer(nodeClass, v1.TerminationFinalizer) {
 		stored := nodeClass.DeepCopy()
@@ -85,7 +105,13 @@ func (c *Controller) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass)
 		c.subnet,
 		c.securitygroup,
 		c.instanceprofile,
+		c.keypair,
+		c.zoneimpairment,
+		c.ebsencryption,
+		c.quotaheadroom,
+		c.metadataaccess,
 		c.readiness,
+		c.cluster,
 	} {
 		res, err := reconciler.Reconcile(ctx, nodeClass)
 		errs = multierr.Append(errs, err)
@@ -0,0 +1,131 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+)
+
+// awsManagedEBSKey is the alias of the AWS managed key that EBS volumes are encrypted with when neither the volume nor
+// the account specify a customer managed key
+const awsManagedEBSKey = "alias/aws/ebs"
+
+type EBSEncryption struct {
+	ec2api ec2iface.EC2API
+	cache  *cache.Cache
+}
+
+// ebsEncryptionDefaults are the account-level EBS encryption settings of the region
+type ebsEncryptionDefaults struct {
+	EncryptionByDefault bool
+	KMSKeyID            string
+}
+
+func (e *EBSEncryption) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
+	policy := options.FromContext(ctx).EBSEncryptionPolicy
+	if policy == options.EBSEncryptionPolicyDisabled {
+		_ = nodeClass.StatusConditions().Clear(v1.ConditionTypeEBSEncryptionPolicyViolated)
+		return reconcile.Result{}, nil
+	}
+	defaults, err := e.defaults(ctx)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("getting ebs encryption defaults, %w", err)
+	}
+	var unencrypted, awsManaged []string
+	for _, volume := range volumes(nodeClass) {
+		// Block device mappings without EBS parameters don't create EBS volumes
+		if volume.DeviceName != nil && volume.EBS == nil {
+			continue
+		}
+		name := lo.Ternary(volume.DeviceName != nil, aws.StringValue(volume.DeviceName), "the volumes of the AMI")
+		// Encryption by default encrypts every new volume, even if the block device mapping doesn't ask for it
+		if !defaults.EncryptionByDefault && (volume.EBS == nil || !aws.BoolValue(volume.EBS.Encrypted)) {
+			unencrypted = append(unencrypted, name)
+			continue
+		}
+		keyID := defaults.KMSKeyID
+		if volume.EBS != nil && volume.EBS.KMSKeyID != nil {
+			keyID = aws.StringValue(volume.EBS.KMSKeyID)
+		}
+		if isAWSManagedEBSKey(keyID) {
+			awsManaged = append(awsManaged, name)
+		}
+	}
+	switch {
+	case len(unencrypted) != 0:
+		nodeClass.StatusConditions().SetTrueWithReason(v1.ConditionTypeEBSEncryptionPolicyViolated, "UnencryptedVolumes",
+			fmt.Sprintf("EBS encryption by default is disabled and %s wouldn't be encrypted", strings.Join(unencrypted, ", ")))
+	case policy == options.EBSEncryptionPolicyCustomerManagedKey && len(awsManaged) != 0:
+		nodeClass.StatusConditions().SetTrueWithReason(v1.ConditionTypeEBSEncryptionPolicyViolated, "AWSManagedKey",
+			fmt.Sprintf("%s would be encrypted with the AWS managed key instead of a customer managed key", strings.Join(awsManaged, ", ")))
+	default:
+		// EBSEncryptionPolicyViolated isn't a dependent of the Ready condition, so it can be cleared once the volumes comply
+		_ = nodeClass.StatusConditions().Clear(v1.ConditionTypeEBSEncryptionPolicyViolated)
+	}
+	return reconcile.Result{}, nil
+}
+
+// volumes returns the block device mappings that instances of the EC2NodeClass are launched with. An EC2NodeClass of
+// the Custom AMI family without block device mappings uses the ones of its AMIs, which is represented with an empty
+// block device mapping.
+func volumes(nodeClass *v1.EC2NodeClass) []*v1.BlockDeviceMapping {
+	if len(nodeClass.Spec.BlockDeviceMappings) != 0 {
+		return nodeClass.Spec.BlockDeviceMappings
+	}
+	if defaults := amifamily.GetAMIFamily(lo.ToPtr(nodeClass.AMIFamily()), &amifamily.Options{}).DefaultBlockDeviceMappings(); len(defaults) != 0 {
+		return defaults
+	}
+	return []*v1.BlockDeviceMapping{{}}
+}
+
+func (e *EBSEncryption) defaults(ctx context.Context) (ebsEncryptionDefaults, error) {
+	key := assumerole.CacheKey(ctx, "ebs-encryption-defaults")
+	if defaults, ok := e.cache.Get(key); ok {
+		return defaults.(ebsEncryptionDefaults), nil
+	}
+	encryption, err := e.ec2api.GetEbsEncryptionByDefaultWithContext(ctx, &ec2.GetEbsEncryptionByDefaultInput{})
+	if err != nil {
+		return ebsEncryptionDefaults{}, fmt.Errorf("getting ebs encryption by default, %w", err)
+	}
+	defaults := ebsEncryptionDefaults{EncryptionByDefault: aws.BoolValue(encryption.EbsEncryptionByDefault), KMSKeyID: awsManagedEBSKey}
+	if options.FromContext(ctx).EBSEncryptionPolicy == options.EBSEncryptionPolicyCustomerManagedKey {
+		out, err := e.ec2api.GetEbsDefaultKmsKeyIdWithContext(ctx, &ec2.GetEbsDefaultKmsKeyIdInput{})
+		if err != nil {
+			return ebsEncryptionDefaults{}, fmt.Errorf("getting ebs default kms key, %w", err)
+		}
+		defaults.KMSKeyID = aws.StringValue(out.KmsKeyId)
+	}
+	e.cache.SetDefault(key, defaults)
+	return defaults, nil
+}
+
+// isAWSManagedEBSKey returns true if the key is the AWS managed key of EBS, referenced by its alias or its alias ARN
+func isAWSManagedEBSKey(keyID string) bool {
+	return keyID == "" || keyID == awsManagedEBSKey || strings.HasSuffix(keyID, ":"+awsManagedEBSKey)
+}
@@ -0,0 +1,125 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status_test
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass EBS Encryption Status Controller", func() {
+	BeforeEach(func() {
+		nodeClass.Spec.BlockDeviceMappings = []*v1.BlockDeviceMapping{
+			{
+				DeviceName: aws.String("/dev/xvda"),
+				EBS:        &v1.BlockDevice{VolumeSize: lo.ToPtr(resource.MustParse("20Gi")), Encrypted: aws.Bool(true)},
+			},
+			{
+				DeviceName: aws.String("/dev/xvdb"),
+				EBS:        &v1.BlockDevice{VolumeSize: lo.ToPtr(resource.MustParse("100Gi")), Encrypted: aws.Bool(false)},
+			},
+		}
+	})
+	It("should not check volumes when no policy is configured", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeEBSEncryptionPolicyViolated)).To(BeNil())
+	})
+	It("should report unencrypted volumes", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{EBSEncryptionPolicy: aws.String(options.EBSEncryptionPolicyEncrypted)}))
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeEBSEncryptionPolicyViolated)
+		Expect(condition.IsTrue()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("UnencryptedVolumes"))
+		Expect(condition.Message).To(ContainSubstring("/dev/xvdb"))
+		Expect(condition.Message).ToNot(ContainSubstring("/dev/xvda"))
+		Expect(nodeClass.StatusConditions().Root().IsTrue()).To(BeTrue())
+	})
+	It("should allow the default volumes of the AMI family when no block device mappings are specified", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{EBSEncryptionPolicy: aws.String(options.EBSEncryptionPolicyEncrypted)}))
+		nodeClass.Spec.BlockDeviceMappings = nil
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeEBSEncryptionPolicyViolated)).To(BeNil())
+	})
+	It("should report the volumes of the AMI for the Custom AMI family when no block device mappings are specified", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{EBSEncryptionPolicy: aws.String(options.EBSEncryptionPolicyEncrypted)}))
+		nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{ID: "ami-id-123"}}
+		nodeClass.Spec.BlockDeviceMappings = nil
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeEBSEncryptionPolicyViolated)
+		Expect(condition.IsTrue()).To(BeTrue())
+		Expect(condition.Message).To(ContainSubstring("the volumes of the AMI"))
+	})
+	It("should allow unencrypted volumes when EBS encryption by default is enabled", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{EBSEncryptionPolicy: aws.String(options.EBSEncryptionPolicyEncrypted)}))
+		awsEnv.EC2API.GetEbsEncryptionByDefaultOutput.Set(&ec2.GetEbsEncryptionByDefaultOutput{EbsEncryptionByDefault: aws.Bool(true)})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeEBSEncryptionPolicyViolated)).To(BeNil())
+	})
+	It("should report volumes that are encrypted with the AWS managed key", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{EBSEncryptionPolicy: aws.String(options.EBSEncryptionPolicyCustomerManagedKey)}))
+		awsEnv.EC2API.GetEbsEncryptionByDefaultOutput.Set(&ec2.GetEbsEncryptionByDefaultOutput{EbsEncryptionByDefault: aws.Bool(true)})
+		nodeClass.Spec.BlockDeviceMappings[0].EBS.KMSKeyID = aws.String("arn:aws:kms:us-west-2:123456789012:key/test")
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeEBSEncryptionPolicyViolated)
+		Expect(condition.IsTrue()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("AWSManagedKey"))
+		Expect(condition.Message).To(ContainSubstring("/dev/xvdb"))
+		Expect(condition.Message).ToNot(ContainSubstring("/dev/xvda"))
+	})
+	It("should allow volumes that are encrypted with the default customer managed key of the account", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{EBSEncryptionPolicy: aws.String(options.EBSEncryptionPolicyCustomerManagedKey)}))
+		awsEnv.EC2API.GetEbsEncryptionByDefaultOutput.Set(&ec2.GetEbsEncryptionByDefaultOutput{EbsEncryptionByDefault: aws.Bool(true)})
+		awsEnv.EC2API.GetEbsDefaultKmsKeyIdOutput.Set(&ec2.GetEbsDefaultKmsKeyIdOutput{KmsKeyId: aws.String("arn:aws:kms:us-west-2:123456789012:key/test")})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeEBSEncryptionPolicyViolated)).To(BeNil())
+	})
+	It("should clear the condition once the volumes comply with the policy", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{EBSEncryptionPolicy: aws.String(options.EBSEncryptionPolicyEncrypted)}))
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeEBSEncryptionPolicyViolated)).To(BeTrue())
+
+		nodeClass.Spec.BlockDeviceMappings[1].EBS.Encrypted = aws.Bool(true)
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeEBSEncryptionPolicyViolated)).To(BeNil())
+	})
+})
@@ -22,6 +22,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
 )
 
@@ -31,11 +32,25 @@ type InstanceProfile struct {
 
 func (ip *InstanceProfile) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
 	if nodeClass.Spec.Role != "" {
+		if !options.FromContext(ctx).ControllerEnabled(options.ControllerInstanceProfile) {
+			nodeClass.StatusConditions().SetFalse(v1.ConditionTypeInstanceProfileReady, "InstanceProfileManagementDisabled", "Instance profile management is disabled, set spec.instanceProfile to an instance profile that is managed outside of Karpenter")
+			return reconcile.Result{}, nil
+		}
 		name, err := ip.instanceProfileProvider.Create(ctx, nodeClass)
 		if err != nil {
 			return reconcile.Result{}, fmt.Errorf("creating instance profile, %w", err)
 		}
 		nodeClass.Status.InstanceProfile = name
+		if err := ip.instanceProfileProvider.ReconcileRole(ctx, nodeClass); err != nil {
+			nodeClass.StatusConditions().SetFalse(v1.ConditionTypeInstanceProfileReady, "RoleReconciliationFailed", err.Error())
+			return reconcile.Result{}, fmt.Errorf("reconciling role, %w", err)
+		}
+		// The instance profile isn't used until it has propagated through IAM, so that the first launches of a new
+		// EC2NodeClass don't fail with an invalid instance profile
+		if remaining := ip.instanceProfileProvider.Propagating(ctx, nodeClass); remaining > 0 {
+			nodeClass.StatusConditions().SetFalse(v1.ConditionTypeInstanceProfileReady, "InstanceProfilePropagating", "Waiting for the instance profile to propagate through IAM")
+			return reconcile.Result{RequeueAfter: remaining}, nil
+		}
 	} else {
 		nodeClass.Status.InstanceProfile = lo.FromPtr(nodeClass.Spec.InstanceProfile)
 	}
@@ -15,7 +15,11 @@ limitations under the License.
 package status_test
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/samber/lo"
 
@@ -23,6 +27,7 @@ import (
 
 	"github.com/aws/karpenter-provider-aws/pkg/fake"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -118,6 +123,127 @@ var _ = Describe("NodeClass InstanceProfile Status Controller", func() {
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeInstanceProfileReady)).To(BeTrue())
 	})
+	It("should resolve the instance profile that would be created in dry-run mode", func() {
+		nodeClass.Spec.Role = "test-role"
+		awsEnv.IAMAPI.CreateInstanceProfileBehavior.Error.Set(awserr.New("DryRunOperation", "", nil))
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+
+		Expect(awsEnv.IAMAPI.InstanceProfiles).To(BeEmpty())
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.InstanceProfile).To(Equal(profileName))
+		Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeInstanceProfileReady)).To(BeTrue())
+	})
+	Context("Role Reconciliation", func() {
+		const boundary = "arn:aws:iam::123456789012:policy/boundary"
+		const policyA = "arn:aws:iam::aws:policy/AmazonEKSWorkerNodePolicy"
+		const policyB = "arn:aws:iam::aws:policy/AmazonSSMManagedInstanceCore"
+		BeforeEach(func() {
+			nodeClass.Spec.Role = "test-role"
+		})
+		It("should not call the IAM role APIs when no boundary or required policies are configured", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+
+			Expect(awsEnv.IAMAPI.GetRoleBehavior.Calls()).To(BeZero())
+			Expect(awsEnv.IAMAPI.ListAttachedRolePoliciesBehavior.Calls()).To(BeZero())
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeInstanceProfileReady)).To(BeTrue())
+		})
+		It("should set the permissions boundary on the role", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{NodeRolePermissionsBoundary: lo.ToPtr(boundary)}))
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+
+			Expect(awsEnv.IAMAPI.PermissionsBoundaries).To(HaveKeyWithValue("test-role", boundary))
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeInstanceProfileReady)).To(BeTrue())
+		})
+		It("should not put the permissions boundary when the role already has it", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{NodeRolePermissionsBoundary: lo.ToPtr(boundary)}))
+			awsEnv.IAMAPI.PermissionsBoundaries["test-role"] = boundary
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+
+			Expect(awsEnv.IAMAPI.GetRoleBehavior.Calls()).To(Equal(1))
+			Expect(awsEnv.IAMAPI.PutRolePermissionsBoundaryBehavior.Calls()).To(BeZero())
+		})
+		It("should attach the required policies that are missing from the role", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{NodeRoleRequiredPolicies: lo.ToPtr(fmt.Sprintf("%s,%s", policyA, policyB))}))
+			awsEnv.IAMAPI.AttachedPolicies["test-role"] = []string{policyA}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+
+			Expect(awsEnv.IAMAPI.AttachRolePolicyBehavior.Calls()).To(Equal(1))
+			Expect(awsEnv.IAMAPI.AttachedPolicies["test-role"]).To(ConsistOf(policyA, policyB))
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeInstanceProfileReady)).To(BeTrue())
+		})
+		It("should reattach a required policy that was detached from the role", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{NodeRoleRequiredPolicies: lo.ToPtr(policyA)}))
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			Expect(awsEnv.IAMAPI.AttachedPolicies["test-role"]).To(ConsistOf(policyA))
+
+			awsEnv.IAMAPI.AttachedPolicies["test-role"] = nil
+			awsEnv.InstanceProfileCache.Flush()
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			Expect(awsEnv.IAMAPI.AttachedPolicies["test-role"]).To(ConsistOf(policyA))
+		})
+		It("should set InstanceProfileReady to false when a required policy can't be attached", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{NodeRoleRequiredPolicies: lo.ToPtr(policyA)}))
+			awsEnv.IAMAPI.AttachRolePolicyBehavior.Error.Set(fmt.Errorf("access denied"))
+			ExpectApplied(ctx, env.Client, nodeClass)
+			_ = ExpectObjectReconcileFailed(ctx, env.Client, statusController, nodeClass)
+
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeInstanceProfileReady).IsFalse()).To(BeTrue())
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeInstanceProfileReady).Reason).To(Equal("RoleReconciliationFailed"))
+		})
+	})
+	Context("Propagation", func() {
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InstanceProfilePropagationDelay: lo.ToPtr(10 * time.Second)}))
+		})
+		It("should set InstanceProfileReady to false until a created instance profile has propagated", func() {
+			nodeClass.Spec.Role = "test-role"
+			ExpectApplied(ctx, env.Client, nodeClass)
+			result := ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+			Expect(result.RequeueAfter).To(BeNumerically("<=", 10*time.Second))
+
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.Status.InstanceProfile).To(Equal(profileName))
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeInstanceProfileReady).IsFalse()).To(BeTrue())
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeInstanceProfileReady).Reason).To(Equal("InstanceProfilePropagating"))
+		})
+		It("should not wait for instance profiles that already have the role", func() {
+			awsEnv.IAMAPI.InstanceProfiles = map[string]*iam.InstanceProfile{
+				profileName: {
+					InstanceProfileId:   aws.String(fake.InstanceProfileID()),
+					InstanceProfileName: aws.String(profileName),
+					Roles:               []*iam.Role{{RoleName: aws.String("test-role")}},
+				},
+			}
+			nodeClass.Spec.Role = "test-role"
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeInstanceProfileReady)).To(BeTrue())
+		})
+	})
+	It("should set InstanceProfileReady to false without creating the instance profile when instance profile management is disabled", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{DisabledControllers: lo.ToPtr(options.ControllerInstanceProfile)}))
+		nodeClass.Spec.Role = "test-role"
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+
+		Expect(awsEnv.IAMAPI.CreateInstanceProfileBehavior.Calls()).To(BeZero())
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeInstanceProfileReady).IsFalse()).To(BeTrue())
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeInstanceProfileReady).Reason).To(Equal("InstanceProfileManagementDisabled"))
+	})
 	It("should resolve the specified instance profile into the status when using instanceProfile field", func() {
 		nodeClass.Spec.Role = ""
 		nodeClass.Spec.InstanceProfile = lo.ToPtr("test-instance-profile")
@@ -0,0 +1,55 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+)
+
+// KeyPair validates that the key pair of the EC2NodeClass exists, since every launch with a key pair that doesn't exist
+// fails. EC2NodeClasses with a key pair are reported with KeyPairInUse, which recommends Session Manager instead.
+type KeyPair struct {
+	ec2api ec2iface.EC2API
+}
+
+func (k *KeyPair) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
+	if nodeClass.Spec.KeyName == nil {
+		nodeClass.StatusConditions().SetTrue(v1.ConditionTypeKeyPairReady)
+		_ = nodeClass.StatusConditions().Clear(v1.ConditionTypeKeyPairInUse)
+		return reconcile.Result{}, nil
+	}
+	keyName := aws.StringValue(nodeClass.Spec.KeyName)
+	nodeClass.StatusConditions().SetTrueWithReason(v1.ConditionTypeKeyPairInUse, "SessionManagerRecommended",
+		fmt.Sprintf("Instances are launched with key pair %q, set ssmAgent.sessionManager for shell access without key pairs", keyName))
+	if _, err := k.ec2api.DescribeKeyPairsWithContext(ctx, &ec2.DescribeKeyPairsInput{KeyNames: aws.StringSlice([]string{keyName})}); err != nil {
+		if !awserrors.IsNotFound(err) {
+			return reconcile.Result{}, fmt.Errorf("describing key pair %s, %w", keyName, err)
+		}
+		nodeClass.StatusConditions().SetFalse(v1.ConditionTypeKeyPairReady, "KeyPairNotFound", fmt.Sprintf("Key pair %q doesn't exist", keyName))
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
+	nodeClass.StatusConditions().SetTrue(v1.ConditionTypeKeyPairReady)
+	return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+}
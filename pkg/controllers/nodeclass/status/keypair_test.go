@@ -0,0 +1,75 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status_test
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/awslabs/operatorpkg/status"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass Key Pair Status Controller", func() {
+	BeforeEach(func() {
+		awsEnv.EC2API.DescribeKeyPairsOutput.Set(&ec2.DescribeKeyPairsOutput{KeyPairs: []*ec2.KeyPairInfo{
+			{KeyName: aws.String("incident-response"), KeyPairId: aws.String("key-0123456789abcdef0")},
+		}})
+	})
+	It("should be ready without a key pair", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeKeyPairReady).IsTrue()).To(BeTrue())
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeKeyPairInUse)).To(BeNil())
+	})
+	It("should be ready with a key pair that exists and recommend Session Manager", func() {
+		nodeClass.Spec.KeyName = aws.String("incident-response")
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeKeyPairReady).IsTrue()).To(BeTrue())
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeKeyPairInUse)
+		Expect(condition.IsTrue()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("SessionManagerRecommended"))
+		// The recommendation doesn't block launches
+		Expect(nodeClass.StatusConditions().Root().IsTrue()).To(BeTrue())
+	})
+	It("should not be ready with a key pair that doesn't exist", func() {
+		nodeClass.Spec.KeyName = aws.String("deleted")
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeKeyPairReady)
+		Expect(condition.IsFalse()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("KeyPairNotFound"))
+		Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsFalse()).To(BeTrue())
+	})
+	It("should clear the recommendation once the key pair is removed", func() {
+		nodeClass.Spec.KeyName = aws.String("incident-response")
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		nodeClass.Spec.KeyName = nil
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeKeyPairInUse)).To(BeNil())
+	})
+})
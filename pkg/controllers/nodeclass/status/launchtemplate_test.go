@@ -88,4 +88,19 @@ var _ = Describe("NodeClass Launch Template CIDR Resolution Controller", func()
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.StatusConditions().IsTrue(status.ConditionReady)).To(BeTrue())
 	})
+	It("should resolve the cluster CIDR of the IP family of dual-stack clusters", func() {
+		awsEnv.EKSAPI.DescribeClusterBehavior.Output.Set(&eks.DescribeClusterOutput{
+			Cluster: &eks.Cluster{
+				KubernetesNetworkConfig: &eks.KubernetesNetworkConfigResponse{
+					IpFamily:        lo.ToPtr(eks.IpFamilyIpv6),
+					ServiceIpv4Cidr: lo.ToPtr("10.100.0.0/16"),
+					ServiceIpv6Cidr: lo.ToPtr("2001:db8::/64"),
+				},
+			},
+		})
+		nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2023@latest"}}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		Expect(lo.FromPtr(awsEnv.LaunchTemplateProvider.ClusterCIDR.Load())).To(Equal("2001:db8::/64"))
+	})
 })
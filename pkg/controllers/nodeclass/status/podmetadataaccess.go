@@ -0,0 +1,59 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+)
+
+// PodMetadataAccess reports the EC2NodeClasses whose metadata options don't give pods the access to the instance
+// metadata service that their podMetadataAccess intends. A wrong hop limit silently breaks the pods that rely on the
+// credentials of the node role, or exposes them to the pods that shouldn't have them.
+type PodMetadataAccess struct{}
+
+func (p *PodMetadataAccess) Reconcile(_ context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
+	if nodeClass.Spec.PodMetadataAccess == nil {
+		_ = nodeClass.StatusConditions().Clear(v1.ConditionTypePodMetadataAccessMismatch)
+		return reconcile.Result{}, nil
+	}
+	metadataOptions := lo.FromPtrOr(nodeClass.Spec.MetadataOptions, lo.FromPtr(amifamily.Options{}.DefaultMetadataOptions()))
+	// EC2 defaults the hop limit to 1
+	hopLimit := lo.FromPtrOr(metadataOptions.HTTPPutResponseHopLimit, 1)
+	adjusted := nodeClass.MetadataHopLimit(hopLimit)
+	switch {
+	case *nodeClass.Spec.PodMetadataAccess == v1.PodMetadataAccessAllowed && lo.FromPtr(metadataOptions.HTTPEndpoint) == ec2.LaunchTemplateInstanceMetadataEndpointStateDisabled:
+		nodeClass.StatusConditions().SetTrueWithReason(v1.ConditionTypePodMetadataAccessMismatch, "MetadataEndpointDisabled",
+			"podMetadataAccess is Allowed but the httpEndpoint of metadataOptions is disabled, so pods can't reach the instance metadata service")
+	case adjusted > hopLimit:
+		nodeClass.StatusConditions().SetTrueWithReason(v1.ConditionTypePodMetadataAccessMismatch, "HopLimitRaised",
+			fmt.Sprintf("httpPutResponseHopLimit %d doesn't let pods reach the instance metadata service, instances are launched with %d", hopLimit, adjusted))
+	case adjusted < hopLimit:
+		nodeClass.StatusConditions().SetTrueWithReason(v1.ConditionTypePodMetadataAccessMismatch, "HopLimitLowered",
+			fmt.Sprintf("httpPutResponseHopLimit %d lets pods reach the instance metadata service, instances are launched with %d", hopLimit, adjusted))
+	default:
+		// PodMetadataAccessMismatch isn't a dependent of the Ready condition, so it can be cleared once the metadata
+		// options match
+		_ = nodeClass.StatusConditions().Clear(v1.ConditionTypePodMetadataAccessMismatch)
+	}
+	return reconcile.Result{}, nil
+}
@@ -0,0 +1,81 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status_test
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/samber/lo"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass Pod Metadata Access Status Controller", func() {
+	BeforeEach(func() {
+		nodeClass.Spec.MetadataOptions = &v1.MetadataOptions{
+			HTTPEndpoint:            aws.String("enabled"),
+			HTTPPutResponseHopLimit: aws.Int64(1),
+		}
+	})
+	It("should not check the metadata options when podMetadataAccess isn't set", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypePodMetadataAccessMismatch)).To(BeNil())
+	})
+	It("should report hop limits that are raised for pods that are allowed to reach IMDS", func() {
+		nodeClass.Spec.PodMetadataAccess = lo.ToPtr(v1.PodMetadataAccessAllowed)
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypePodMetadataAccessMismatch)
+		Expect(condition.IsTrue()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("HopLimitRaised"))
+		// The condition doesn't block launches
+		Expect(nodeClass.StatusConditions().Root().IsTrue()).To(BeTrue())
+	})
+	It("should report hop limits that are lowered for pods that are blocked from reaching IMDS", func() {
+		nodeClass.Spec.PodMetadataAccess = lo.ToPtr(v1.PodMetadataAccessBlocked)
+		nodeClass.Spec.MetadataOptions.HTTPPutResponseHopLimit = aws.Int64(2)
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypePodMetadataAccessMismatch)
+		Expect(condition.IsTrue()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("HopLimitLowered"))
+	})
+	It("should report a disabled metadata endpoint for pods that are allowed to reach IMDS", func() {
+		nodeClass.Spec.PodMetadataAccess = lo.ToPtr(v1.PodMetadataAccessAllowed)
+		nodeClass.Spec.MetadataOptions.HTTPEndpoint = aws.String("disabled")
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypePodMetadataAccessMismatch).Reason).To(Equal("MetadataEndpointDisabled"))
+	})
+	It("should clear the condition once the metadata options match", func() {
+		nodeClass.Spec.PodMetadataAccess = lo.ToPtr(v1.PodMetadataAccessAllowed)
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		nodeClass.Spec.MetadataOptions.HTTPPutResponseHopLimit = aws.Int64(3)
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypePodMetadataAccessMismatch)).To(BeNil())
+	})
+})
@@ -0,0 +1,226 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/servicequotas"
+	"github.com/aws/aws-sdk-go/service/servicequotas/servicequotasiface"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+const (
+	// spotVCPUQuotaCode is the code of the "All Standard (A, C, D, H, I, M, R, T, Z) Spot Instance Requests" quota
+	spotVCPUQuotaCode = "L-34B43A08"
+	// networkInterfaceQuotaCode is the code of the "Network interfaces per Region" quota
+	networkInterfaceQuotaCode = "L-DF5E4CA3"
+	// elasticIPQuotaCode is the code of the "EC2-VPC Elastic IPs" quota
+	elasticIPQuotaCode = "L-0263D0A3"
+	// launchTemplateLimit is the number of launch templates per region, which isn't a quota in Service Quotas
+	launchTemplateLimit = 5000
+)
+
+var (
+	// standardInstanceClasses are the classes of the instance types whose vCPUs count against the standard spot quota
+	standardInstanceClasses = sets.New("a", "c", "d", "h", "i", "m", "r", "t", "z")
+	// nonStandardInstanceClasses start with the letter of a standard class, but count against quotas of their own
+	nonStandardInstanceClasses = sets.New("dl", "hpc", "inf", "trn")
+)
+
+type QuotaHeadroom struct {
+	ec2api           ec2iface.EC2API
+	servicequotasapi servicequotasiface.ServiceQuotasAPI
+	cache            *cache.Cache
+}
+
+// quotaUsage is the usage of an account quota in the region
+type quotaUsage struct {
+	Name  string
+	Used  float64
+	Limit float64
+}
+
+func (q *QuotaHeadroom) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
+	percent := options.FromContext(ctx).QuotaHeadroomPercent
+	if percent == 0 {
+		_ = nodeClass.StatusConditions().Clear(v1.ConditionTypeQuotaHeadroomLow)
+		return reconcile.Result{}, nil
+	}
+	usages, err := q.usages(ctx)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("getting quota usage, %w", err)
+	}
+	low := lo.Filter(usages, func(u quotaUsage, _ int) bool {
+		return u.Limit-u.Used < u.Limit*float64(percent)/100
+	})
+	if len(low) == 0 {
+		// QuotaHeadroomLow isn't a dependent of the Ready condition, so it can be cleared once there's headroom again
+		_ = nodeClass.StatusConditions().Clear(v1.ConditionTypeQuotaHeadroomLow)
+	} else {
+		nodeClass.StatusConditions().SetTrueWithReason(v1.ConditionTypeQuotaHeadroomLow, "QuotaHeadroomLow",
+			strings.Join(lo.Map(low, func(u quotaUsage, _ int) string {
+				return fmt.Sprintf("%s: %.0f of %.0f used", u.Name, u.Used, u.Limit)
+			}), ", "))
+	}
+	// Usage changes without changes to the EC2NodeClass, so it's checked again once the cached usage expires
+	return reconcile.Result{RequeueAfter: awscache.QuotaUsageTTL}, nil
+}
+
+// usages returns the usage of the account quotas that launches count against. Every EC2NodeClass of an account shares
+// the same quotas, so they're cached per assumed role rather than per EC2NodeClass.
+func (q *QuotaHeadroom) usages(ctx context.Context) ([]quotaUsage, error) {
+	key := assumerole.CacheKey(ctx, "quota-usages")
+	if usages, ok := q.cache.Get(key); ok {
+		return usages.([]quotaUsage), nil
+	}
+	spotVCPUs, err := q.spotVCPUs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	networkInterfaces, err := q.networkInterfaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	elasticIPs, err := q.elasticIPs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	launchTemplates, err := q.launchTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	usages := []quotaUsage{
+		{Name: "spot vCPUs", Used: spotVCPUs},
+		{Name: "network interfaces", Used: networkInterfaces},
+		{Name: "Elastic IP addresses", Used: elasticIPs},
+		{Name: "launch templates", Used: launchTemplates, Limit: launchTemplateLimit},
+	}
+	for i, quota := range []struct{ serviceCode, quotaCode string }{
+		{serviceCode: "ec2", quotaCode: spotVCPUQuotaCode},
+		{serviceCode: "vpc", quotaCode: networkInterfaceQuotaCode},
+		{serviceCode: "ec2", quotaCode: elasticIPQuotaCode},
+	} {
+		if usages[i].Limit, err = q.quota(ctx, quota.serviceCode, quota.quotaCode); err != nil {
+			return nil, err
+		}
+	}
+	q.cache.SetDefault(key, usages)
+	return usages, nil
+}
+
+// quota returns the applied value of a quota, which is only set for quotas that were increased, or else its AWS default
+func (q *QuotaHeadroom) quota(ctx context.Context, serviceCode, quotaCode string) (float64, error) {
+	out, err := q.servicequotasapi.GetServiceQuotaWithContext(ctx, &servicequotas.GetServiceQuotaInput{
+		ServiceCode: aws.String(serviceCode),
+		QuotaCode:   aws.String(quotaCode),
+	})
+	if err == nil {
+		return aws.Float64Value(out.Quota.Value), nil
+	}
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) || aerr.Code() != servicequotas.ErrCodeNoSuchResourceException {
+		return 0, fmt.Errorf("getting service quota %s, %w", quotaCode, err)
+	}
+	defaultOut, err := q.servicequotasapi.GetAWSDefaultServiceQuotaWithContext(ctx, &servicequotas.GetAWSDefaultServiceQuotaInput{
+		ServiceCode: aws.String(serviceCode),
+		QuotaCode:   aws.String(quotaCode),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("getting default service quota %s, %w", quotaCode, err)
+	}
+	return aws.Float64Value(defaultOut.Quota.Value), nil
+}
+
+// spotVCPUs returns the vCPUs of the pending and running spot instances of the standard instance classes
+func (q *QuotaHeadroom) spotVCPUs(ctx context.Context) (float64, error) {
+	var vcpus float64
+	if err := q.ec2api.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("instance-lifecycle"), Values: aws.StringSlice([]string{ec2.InstanceLifecycleTypeSpot})},
+			{Name: aws.String("instance-state-name"), Values: aws.StringSlice([]string{ec2.InstanceStateNamePending, ec2.InstanceStateNameRunning})},
+		},
+	}, func(page *ec2.DescribeInstancesOutput, _ bool) bool {
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				if instance.CpuOptions == nil || !isStandardInstanceType(aws.StringValue(instance.InstanceType)) {
+					continue
+				}
+				vcpus += float64(aws.Int64Value(instance.CpuOptions.CoreCount) * aws.Int64Value(instance.CpuOptions.ThreadsPerCore))
+			}
+		}
+		return true
+	}); err != nil {
+		return 0, fmt.Errorf("describing spot instances, %w", err)
+	}
+	return vcpus, nil
+}
+
+func (q *QuotaHeadroom) networkInterfaces(ctx context.Context) (float64, error) {
+	var count float64
+	if err := q.ec2api.DescribeNetworkInterfacesPagesWithContext(ctx, &ec2.DescribeNetworkInterfacesInput{}, func(page *ec2.DescribeNetworkInterfacesOutput, _ bool) bool {
+		count += float64(len(page.NetworkInterfaces))
+		return true
+	}); err != nil {
+		return 0, fmt.Errorf("describing network interfaces, %w", err)
+	}
+	return count, nil
+}
+
+func (q *QuotaHeadroom) elasticIPs(ctx context.Context) (float64, error) {
+	out, err := q.ec2api.DescribeAddressesWithContext(ctx, &ec2.DescribeAddressesInput{
+		Filters: []*ec2.Filter{{Name: aws.String("domain"), Values: aws.StringSlice([]string{ec2.DomainTypeVpc})}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("describing addresses, %w", err)
+	}
+	return float64(len(out.Addresses)), nil
+}
+
+func (q *QuotaHeadroom) launchTemplates(ctx context.Context) (float64, error) {
+	var count float64
+	if err := q.ec2api.DescribeLaunchTemplatesPagesWithContext(ctx, &ec2.DescribeLaunchTemplatesInput{}, func(page *ec2.DescribeLaunchTemplatesOutput, _ bool) bool {
+		count += float64(len(page.LaunchTemplates))
+		return true
+	}); err != nil {
+		return 0, fmt.Errorf("describing launch templates, %w", err)
+	}
+	return count, nil
+}
+
+// isStandardInstanceType returns true if the instance type is of a standard instance class, like m5.large or r7gd.xlarge
+func isStandardInstanceType(instanceType string) bool {
+	class := instanceType
+	if i := strings.IndexFunc(instanceType, unicode.IsDigit); i != -1 {
+		class = instanceType[:i]
+	}
+	return class != "" && standardInstanceClasses.Has(class[:1]) && !nonStandardInstanceClasses.Has(class)
+}
@@ -0,0 +1,126 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status_test
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/samber/lo"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass Quota Headroom Status Controller", func() {
+	storeSpotInstance := func(id, instanceType string, vcpus int64) {
+		awsEnv.EC2API.Instances.Store(id, &ec2.Instance{
+			InstanceId:        aws.String(id),
+			InstanceType:      aws.String(instanceType),
+			InstanceLifecycle: aws.String(ec2.InstanceLifecycleTypeSpot),
+			State:             &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)},
+			CpuOptions:        &ec2.CpuOptions{CoreCount: aws.Int64(vcpus / 2), ThreadsPerCore: aws.Int64(2)},
+		})
+	}
+	BeforeEach(func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{QuotaHeadroomPercent: lo.ToPtr(20)}))
+	})
+	It("should not check quotas when no headroom is configured", func() {
+		ctx = options.ToContext(ctx, test.Options())
+		awsEnv.ServiceQuotasAPI.Quotas.Store("L-34B43A08", 10.0)
+		storeSpotInstance("i-spot", "m5.4xlarge", 16)
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeQuotaHeadroomLow)).To(BeNil())
+		Expect(awsEnv.ServiceQuotasAPI.GetServiceQuotaBehavior.Calls()).To(BeZero())
+	})
+	It("should not set the condition when there's headroom", func() {
+		storeSpotInstance("i-spot", "m5.4xlarge", 16)
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeQuotaHeadroomLow)).To(BeNil())
+	})
+	It("should report low headroom of the applied spot vCPU quota", func() {
+		awsEnv.ServiceQuotasAPI.Quotas.Store("L-34B43A08", 100.0)
+		storeSpotInstance("i-spot-1", "m5.8xlarge", 32)
+		storeSpotInstance("i-spot-2", "c6g.16xlarge", 64)
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeQuotaHeadroomLow)
+		Expect(condition.IsTrue()).To(BeTrue())
+		Expect(condition.Message).To(Equal("spot vCPUs: 96 of 100 used"))
+		// QuotaHeadroomLow is a warning, so the EC2NodeClass stays ready
+		Expect(nodeClass.StatusConditions().Root().IsTrue()).To(BeTrue())
+	})
+	It("should not count the vCPUs of instance types that count against other spot quotas", func() {
+		awsEnv.ServiceQuotasAPI.Quotas.Store("L-34B43A08", 100.0)
+		storeSpotInstance("i-spot-1", "p3.16xlarge", 64)
+		storeSpotInstance("i-spot-2", "inf2.24xlarge", 96)
+		storeSpotInstance("i-spot-3", "m5.large", 2)
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeQuotaHeadroomLow)).To(BeNil())
+	})
+	It("should report low headroom of the network interface and Elastic IP address quotas", func() {
+		awsEnv.ServiceQuotasAPI.Quotas.Store("L-DF5E4CA3", 10.0)
+		awsEnv.EC2API.DescribeNetworkInterfacesOutput.Set(&ec2.DescribeNetworkInterfacesOutput{
+			NetworkInterfaces: lo.Times(9, func(i int) *ec2.NetworkInterface {
+				return &ec2.NetworkInterface{NetworkInterfaceId: aws.String(fmt.Sprintf("eni-%d", i))}
+			}),
+		})
+		awsEnv.EC2API.DescribeAddressesOutput.Set(&ec2.DescribeAddressesOutput{
+			Addresses: lo.Times(5, func(i int) *ec2.Address {
+				return &ec2.Address{AllocationId: aws.String(fmt.Sprintf("eipalloc-%d", i))}
+			}),
+		})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeQuotaHeadroomLow)
+		Expect(condition.IsTrue()).To(BeTrue())
+		Expect(condition.Message).To(Equal("network interfaces: 9 of 10 used, Elastic IP addresses: 5 of 5 used"))
+	})
+	It("should clear the condition once there's headroom again", func() {
+		awsEnv.ServiceQuotasAPI.Quotas.Store("L-34B43A08", 100.0)
+		storeSpotInstance("i-spot", "m5.24xlarge", 96)
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeQuotaHeadroomLow)).To(BeTrue())
+
+		awsEnv.ServiceQuotasAPI.Quotas.Store("L-34B43A08", 1000.0)
+		awsEnv.QuotaUsageCache.Flush()
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeQuotaHeadroomLow)).To(BeNil())
+	})
+	It("should cache the quota usage across EC2NodeClasses", func() {
+		other := test.EC2NodeClass()
+		ExpectApplied(ctx, env.Client, nodeClass, other)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, other)
+		Expect(awsEnv.ServiceQuotasAPI.GetServiceQuotaBehavior.Calls()).To(Equal(3))
+	})
+})
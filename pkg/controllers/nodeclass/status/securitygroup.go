@@ -18,18 +18,22 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/samber/lo"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 )
 
 type SecurityGroup struct {
 	securityGroupProvider securitygroup.Provider
+	subnetProvider        subnet.Provider
 }
 
 func (sg *SecurityGroup) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
@@ -42,6 +46,13 @@ func (sg *SecurityGroup) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeCla
 		nodeClass.StatusConditions().SetFalse(v1.ConditionTypeSecurityGroupsReady, "SecurityGroupsNotFound", "SecurityGroupSelector did not match any SecurityGroups")
 		return reconcile.Result{}, nil
 	}
+	if message, err := sg.vpcMismatch(ctx, nodeClass, securityGroups); err != nil {
+		return reconcile.Result{}, err
+	} else if message != "" {
+		nodeClass.Status.SecurityGroups = nil
+		nodeClass.StatusConditions().SetFalse(v1.ConditionTypeSecurityGroupsReady, "SecurityGroupsVPCMismatch", message)
+		return reconcile.Result{}, nil
+	}
 	sort.Slice(securityGroups, func(i, j int) bool {
 		return *securityGroups[i].GroupId < *securityGroups[j].GroupId
 	})
@@ -54,3 +65,30 @@ func (sg *SecurityGroup) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeCla
 	nodeClass.StatusConditions().SetTrue(v1.ConditionTypeSecurityGroupsReady)
 	return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
 }
+
+// vpcMismatch returns a message describing why the security groups can't be used together if they belong to multiple
+// VPCs, or to a different VPC than the subnets of the EC2NodeClass. Instances can only be launched with security groups
+// of the VPC that their subnet belongs to.
+func (sg *SecurityGroup) vpcMismatch(ctx context.Context, nodeClass *v1.EC2NodeClass, securityGroups []*ec2.SecurityGroup) (string, error) {
+	securityGroupVPCIDs := vpcIDs(securityGroups, func(s *ec2.SecurityGroup) *string { return s.VpcId })
+	if len(securityGroupVPCIDs) > 1 {
+		return fmt.Sprintf("SecurityGroupSelector matched SecurityGroups of multiple VPCs (%s)", strings.Join(securityGroupVPCIDs, ", ")), nil
+	}
+	if len(securityGroupVPCIDs) == 0 {
+		return "", nil
+	}
+	subnets, err := sg.subnetProvider.List(ctx, nodeClass)
+	if err != nil {
+		return "", fmt.Errorf("getting subnets, %w", err)
+	}
+	if subnetVPCIDs := vpcIDs(subnets, func(s *ec2.Subnet) *string { return s.VpcId }); len(subnetVPCIDs) != 0 && !lo.Contains(subnetVPCIDs, securityGroupVPCIDs[0]) {
+		return fmt.Sprintf("SecurityGroups belong to VPC %s, which doesn't match the VPC of the subnets (%s)", securityGroupVPCIDs[0], strings.Join(subnetVPCIDs, ", ")), nil
+	}
+	return "", nil
+}
+
+func vpcIDs[T any](resources []T, vpcID func(T) *string) []string {
+	ids := lo.Uniq(lo.Compact(lo.Map(resources, func(r T, _ int) string { return aws.StringValue(vpcID(r)) })))
+	sort.Strings(ids)
+	return ids
+}
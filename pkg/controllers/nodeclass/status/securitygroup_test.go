@@ -15,6 +15,9 @@ limitations under the License.
 package status_test
 
 import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 	"github.com/aws/karpenter-provider-aws/pkg/test"
 
@@ -225,4 +228,66 @@ var _ = Describe("NodeClass Security Group Status Controller", func() {
 		Expect(nodeClass.Status.SecurityGroups).To(BeNil())
 		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeSecurityGroupsReady).IsFalse()).To(BeTrue())
 	})
+	Context("VPC Scoping", func() {
+		BeforeEach(func() {
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{
+				{
+					SubnetId:                aws.String("subnet-test1"),
+					VpcId:                   aws.String("vpc-test1"),
+					AvailabilityZone:        aws.String("test-zone-1a"),
+					AvailabilityZoneId:      aws.String("tstz1-1a"),
+					AvailableIpAddressCount: aws.Int64(100),
+				},
+			}})
+			awsEnv.EC2API.DescribeSecurityGroupsOutput.Set(&ec2.DescribeSecurityGroupsOutput{SecurityGroups: []*ec2.SecurityGroup{
+				{
+					GroupId:   aws.String("sg-test1"),
+					GroupName: aws.String("securityGroup-test1"),
+					VpcId:     aws.String("vpc-test1"),
+					Tags:      []*ec2.Tag{{Key: aws.String("foo"), Value: aws.String("bar")}},
+				},
+				{
+					GroupId:   aws.String("sg-test2"),
+					GroupName: aws.String("securityGroup-test2"),
+					VpcId:     aws.String("vpc-test2"),
+					Tags:      []*ec2.Tag{{Key: aws.String("foo"), Value: aws.String("bar")}},
+				},
+			}})
+		})
+		It("should fail when the selected security groups belong to multiple VPCs", func() {
+			nodeClass.Spec.SecurityGroupSelectorTerms = []v1.SecurityGroupSelectorTerm{{Tags: map[string]string{"foo": "bar"}}}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.Status.SecurityGroups).To(BeNil())
+			condition := nodeClass.StatusConditions().Get(v1.ConditionTypeSecurityGroupsReady)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal("SecurityGroupsVPCMismatch"))
+			Expect(condition.Message).To(ContainSubstring("vpc-test1, vpc-test2"))
+		})
+		It("should only select the security groups of the VPC of the term", func() {
+			nodeClass.Spec.SecurityGroupSelectorTerms = []v1.SecurityGroupSelectorTerm{{Tags: map[string]string{"foo": "bar"}, VPCID: "vpc-test1"}}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.Status.SecurityGroups).To(Equal([]v1.SecurityGroup{
+				{
+					ID:   "sg-test1",
+					Name: "securityGroup-test1",
+				},
+			}))
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeSecurityGroupsReady).IsTrue()).To(BeTrue())
+		})
+		It("should fail when the selected security groups don't belong to the VPC of the subnets", func() {
+			nodeClass.Spec.SecurityGroupSelectorTerms = []v1.SecurityGroupSelectorTerm{{Tags: map[string]string{"foo": "bar"}, VPCID: "vpc-test2"}}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.Status.SecurityGroups).To(BeNil())
+			condition := nodeClass.StatusConditions().Get(v1.ConditionTypeSecurityGroupsReady)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal("SecurityGroupsVPCMismatch"))
+			Expect(condition.Message).To(ContainSubstring("vpc-test2"))
+		})
+	})
 })
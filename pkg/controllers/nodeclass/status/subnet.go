@@ -18,6 +18,8 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/service/ec2"
@@ -25,6 +27,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 )
 
@@ -50,11 +53,55 @@ func (s *Subnet) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (rec
 	})
 	nodeClass.Status.Subnets = lo.Map(subnets, func(ec2subnet *ec2.Subnet, _ int) v1.Subnet {
 		return v1.Subnet{
-			ID:     *ec2subnet.SubnetId,
-			Zone:   *ec2subnet.AvailabilityZone,
-			ZoneID: *ec2subnet.AvailabilityZoneId,
+			ID:       *ec2subnet.SubnetId,
+			Zone:     *ec2subnet.AvailabilityZone,
+			ZoneID:   *ec2subnet.AvailabilityZoneId,
+			CIDR:     lo.FromPtr(ec2subnet.CidrBlock),
+			Priority: subnetPriority(ec2subnet),
 		}
 	})
-	nodeClass.StatusConditions().SetTrue(v1.ConditionTypeSubnetsReady)
+	exposed, err := s.publicIPExposedSubnets(ctx, nodeClass, subnets)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("checking public ip exposure, %w", err)
+	}
+	if len(exposed) == 0 {
+		// PublicIPExposed isn't a dependent of the Ready condition, so it can be cleared once no subnet is exposed
+		_ = nodeClass.StatusConditions().Clear(v1.ConditionTypePublicIPExposed)
+		nodeClass.StatusConditions().SetTrue(v1.ConditionTypeSubnetsReady)
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
+	message := fmt.Sprintf("Subnets %s assign public IP addresses and route to an internet gateway, set associatePublicIPAddress to allow public IP addresses", strings.Join(exposed, ", "))
+	nodeClass.StatusConditions().SetTrueWithReason(v1.ConditionTypePublicIPExposed, "PublicIPExposed", message)
+	if options.FromContext(ctx).PublicIPGuardrail == options.PublicIPGuardrailEnforce {
+		nodeClass.StatusConditions().SetFalse(v1.ConditionTypeSubnetsReady, "PublicIPExposed", message)
+	} else {
+		nodeClass.StatusConditions().SetTrue(v1.ConditionTypeSubnetsReady)
+	}
 	return reconcile.Result{RequeueAfter: time.Minute}, nil
 }
+
+// subnetPriority returns the priority from the subnet's priority tag, defaulting to 0 when the tag is missing or
+// isn't an integer
+func subnetPriority(ec2subnet *ec2.Subnet) int32 {
+	tag, ok := lo.Find(ec2subnet.Tags, func(t *ec2.Tag) bool { return lo.FromPtr(t.Key) == v1.TagSubnetPriority })
+	if !ok {
+		return 0
+	}
+	priority, err := strconv.ParseInt(lo.FromPtr(tag.Value), 10, 32)
+	if err != nil {
+		return 0
+	}
+	return int32(priority)
+}
+
+// publicIPExposedSubnets returns the subnets that would assign public IP addresses to the instances of the EC2NodeClass
+// while routing to an internet gateway. Setting associatePublicIPAddress on the EC2NodeClass explicitly decides
+// whether instances are assigned public IP addresses, so no subnet is exposed unintentionally when it's set.
+func (s *Subnet) publicIPExposedSubnets(ctx context.Context, nodeClass *v1.EC2NodeClass, subnets []*ec2.Subnet) ([]string, error) {
+	if options.FromContext(ctx).PublicIPGuardrail == options.PublicIPGuardrailDisabled || nodeClass.Spec.AssociatePublicIPAddress != nil {
+		return nil, nil
+	}
+	return s.subnetProvider.ListPublic(ctx, lo.Filter(subnets, func(ec2subnet *ec2.Subnet, _ int) bool {
+		return lo.FromPtr(ec2subnet.MapPublicIpOnLaunch)
+	}))
+}
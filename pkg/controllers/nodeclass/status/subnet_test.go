@@ -19,6 +19,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/ec2"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/test"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -104,6 +105,53 @@ var _ = Describe("NodeClass Subnet Status Controller", func() {
 		}))
 		Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeSubnetsReady)).To(BeTrue())
 	})
+	It("Should resolve subnets by CIDR range and report their CIDR blocks", func() {
+		awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{
+			{SubnetId: aws.String("subnet-test1"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), AvailableIpAddressCount: aws.Int64(20), CidrBlock: aws.String("10.0.0.0/20")},
+			{SubnetId: aws.String("subnet-test2"), AvailabilityZone: aws.String("test-zone-1b"), AvailabilityZoneId: aws.String("tstz1-1b"), AvailableIpAddressCount: aws.Int64(100), CidrBlock: aws.String("10.0.128.0/19")},
+		}})
+		nodeClass.Spec.SubnetSelectorTerms = []v1.SubnetSelectorTerm{
+			{
+				CIDR: "10.0.128.0/17",
+			},
+		}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.Subnets).To(Equal([]v1.Subnet{
+			{
+				ID:     "subnet-test2",
+				Zone:   "test-zone-1b",
+				ZoneID: "tstz1-1b",
+				CIDR:   "10.0.128.0/19",
+			},
+		}))
+		Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeSubnetsReady)).To(BeTrue())
+	})
+	It("Should report the priority of subnets from their priority tag", func() {
+		awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{
+			{SubnetId: aws.String("subnet-test1"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), AvailableIpAddressCount: aws.Int64(100),
+				Tags: []*ec2.Tag{{Key: aws.String(v1.TagSubnetPriority), Value: aws.String("10")}}},
+			{SubnetId: aws.String("subnet-test2"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), AvailableIpAddressCount: aws.Int64(50),
+				Tags: []*ec2.Tag{{Key: aws.String(v1.TagSubnetPriority), Value: aws.String("invalid")}}},
+		}})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.Subnets).To(Equal([]v1.Subnet{
+			{
+				ID:       "subnet-test1",
+				Zone:     "test-zone-1a",
+				ZoneID:   "tstz1-1a",
+				Priority: 10,
+			},
+			{
+				ID:     "subnet-test2",
+				Zone:   "test-zone-1a",
+				ZoneID: "tstz1-1a",
+			},
+		}))
+	})
 	It("Should resolve a valid selectors for Subnet by tags", func() {
 		nodeClass.Spec.SubnetSelectorTerms = []v1.SubnetSelectorTerm{
 			{
@@ -298,4 +346,96 @@ var _ = Describe("NodeClass Subnet Status Controller", func() {
 		Expect(nodeClass.Status.Subnets).To(BeNil())
 		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeSubnetsReady).IsFalse()).To(BeTrue())
 	})
+	Context("Public IP Guardrail", func() {
+		BeforeEach(func() {
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{
+				{
+					SubnetId:                aws.String("subnet-public"),
+					VpcId:                   aws.String("vpc-test"),
+					AvailabilityZone:        aws.String("test-zone-1a"),
+					AvailabilityZoneId:      aws.String("tstz1-1a"),
+					AvailableIpAddressCount: aws.Int64(20),
+					MapPublicIpOnLaunch:     aws.Bool(true),
+				},
+				{
+					SubnetId:                aws.String("subnet-private"),
+					VpcId:                   aws.String("vpc-test"),
+					AvailabilityZone:        aws.String("test-zone-1b"),
+					AvailabilityZoneId:      aws.String("tstz1-1b"),
+					AvailableIpAddressCount: aws.Int64(10),
+					MapPublicIpOnLaunch:     aws.Bool(true),
+				},
+			}})
+			awsEnv.EC2API.DescribeRouteTablesOutput.Set(&ec2.DescribeRouteTablesOutput{RouteTables: []*ec2.RouteTable{
+				{
+					RouteTableId: aws.String("rtb-main"),
+					Associations: []*ec2.RouteTableAssociation{{Main: aws.Bool(true)}},
+					Routes: []*ec2.Route{
+						{DestinationCidrBlock: aws.String("10.0.0.0/16"), GatewayId: aws.String("local"), State: aws.String(ec2.RouteStateActive)},
+						{DestinationCidrBlock: aws.String("0.0.0.0/0"), GatewayId: aws.String("igw-test"), State: aws.String(ec2.RouteStateActive)},
+					},
+				},
+				{
+					RouteTableId: aws.String("rtb-private"),
+					Associations: []*ec2.RouteTableAssociation{{SubnetId: aws.String("subnet-private")}},
+					Routes: []*ec2.Route{
+						{DestinationCidrBlock: aws.String("10.0.0.0/16"), GatewayId: aws.String("local"), State: aws.String(ec2.RouteStateActive)},
+						{DestinationCidrBlock: aws.String("0.0.0.0/0"), NatGatewayId: aws.String("nat-test"), State: aws.String(ec2.RouteStateActive)},
+					},
+				},
+			}})
+		})
+		It("should not check subnets when the guardrail is disabled", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypePublicIPExposed)).To(BeNil())
+			Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeSubnetsReady)).To(BeTrue())
+		})
+		It("should warn about subnets that assign public IP addresses and route to an internet gateway", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{PublicIPGuardrail: aws.String(options.PublicIPGuardrailWarn)}))
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			condition := nodeClass.StatusConditions().Get(v1.ConditionTypePublicIPExposed)
+			Expect(condition.IsTrue()).To(BeTrue())
+			Expect(condition.Message).To(ContainSubstring("subnet-public"))
+			Expect(condition.Message).ToNot(ContainSubstring("subnet-private"))
+			Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeSubnetsReady)).To(BeTrue())
+		})
+		It("should fail the subnets when the guardrail is enforced", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{PublicIPGuardrail: aws.String(options.PublicIPGuardrailEnforce)}))
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypePublicIPExposed)).To(BeTrue())
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeSubnetsReady).IsFalse()).To(BeTrue())
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeSubnetsReady).Reason).To(Equal("PublicIPExposed"))
+		})
+		It("should allow public IP addresses when associatePublicIPAddress is set", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{PublicIPGuardrail: aws.String(options.PublicIPGuardrailEnforce)}))
+			nodeClass.Spec.AssociatePublicIPAddress = aws.Bool(true)
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypePublicIPExposed)).To(BeNil())
+			Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeSubnetsReady)).To(BeTrue())
+		})
+		It("should clear the condition once subnets don't assign public IP addresses", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{PublicIPGuardrail: aws.String(options.PublicIPGuardrailEnforce)}))
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypePublicIPExposed)).To(BeTrue())
+
+			subnets := awsEnv.EC2API.DescribeSubnetsOutput.Clone().Subnets
+			subnets[0].MapPublicIpOnLaunch = aws.Bool(false)
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: subnets})
+			awsEnv.SubnetCache.Flush()
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1.ConditionTypePublicIPExposed)).To(BeNil())
+			Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeSubnetsReady)).To(BeTrue())
+		})
+	})
 })
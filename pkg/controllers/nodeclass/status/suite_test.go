@@ -60,6 +60,11 @@ var _ = BeforeSuite(func() {
 		awsEnv.AMIProvider,
 		awsEnv.InstanceProfileProvider,
 		awsEnv.LaunchTemplateProvider,
+		awsEnv.ImpairedZonesCache,
+		awsEnv.EC2API,
+		awsEnv.EBSEncryptionCache,
+		awsEnv.ServiceQuotasAPI,
+		awsEnv.QuotaUsageCache,
 	)
 })
 
@@ -69,6 +74,7 @@ var _ = AfterSuite(func() {
 
 var _ = BeforeEach(func() {
 	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
 	nodeClass = test.EC2NodeClass()
 	awsEnv.Reset()
 })
@@ -0,0 +1,51 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/cache"
+)
+
+type ZoneImpairment struct {
+	impairedZones *cache.ImpairedZones
+}
+
+func (z *ZoneImpairment) Reconcile(_ context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
+	zones := lo.SliceToMap(nodeClass.Status.Subnets, func(s v1.Subnet) (string, struct{}) { return s.Zone, struct{}{} })
+	impaired := lo.Filter(z.impairedZones.List(), func(zone cache.ImpairedZone, _ int) bool {
+		_, ok := zones[zone.Zone]
+		return ok
+	})
+	if len(impaired) == 0 {
+		// ZonesImpaired isn't a dependent of the Ready condition, so it can be cleared once there are no impaired zones
+		_ = nodeClass.StatusConditions().Clear(v1.ConditionTypeZonesImpaired)
+		return reconcile.Result{}, nil
+	}
+	nodeClass.StatusConditions().SetTrueWithReason(v1.ConditionTypeZonesImpaired, "ZonesImpaired", fmt.Sprintf("Avoiding launches into %s",
+		strings.Join(lo.Map(impaired, func(zone cache.ImpairedZone, _ int) string {
+			return fmt.Sprintf("%s until %s", zone.Zone, zone.Until.UTC().Format(time.RFC3339))
+		}), ", ")))
+	// Requeue when the next impairment expires so that the condition doesn't outlive it
+	return reconcile.Result{RequeueAfter: time.Until(lo.MinBy(impaired, func(a, b cache.ImpairedZone) bool { return a.Until.Before(b.Until) }).Until)}, nil
+}
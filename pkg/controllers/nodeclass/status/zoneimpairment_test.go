@@ -0,0 +1,64 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status_test
+
+import (
+	"time"
+
+	"github.com/awslabs/operatorpkg/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass Zone Impairment Status Controller", func() {
+	It("should not set the ZonesImpaired condition when no zones are impaired", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeZonesImpaired)).To(BeNil())
+	})
+	It("should set the ZonesImpaired condition for impaired zones of the EC2NodeClass", func() {
+		until := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		awsEnv.ImpairedZonesCache.MarkImpaired(ctx, "AWS_EC2_OPERATIONAL_ISSUE", "test-zone-1a", until)
+		awsEnv.ImpairedZonesCache.MarkImpaired(ctx, "AWS_EC2_OPERATIONAL_ISSUE", "other-zone-1a", until)
+		ExpectApplied(ctx, env.Client, nodeClass)
+		result := ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		Expect(result.RequeueAfter).To(BeNumerically("<=", time.Hour))
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeZonesImpaired)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Message).To(Equal("Avoiding launches into test-zone-1a until " + until.Format(time.RFC3339)))
+		// Impaired zones don't affect the readiness of the EC2NodeClass
+		Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsTrue()).To(BeTrue())
+	})
+	It("should clear the ZonesImpaired condition once the zone has recovered", func() {
+		awsEnv.ImpairedZonesCache.MarkImpaired(ctx, "AWS_EC2_OPERATIONAL_ISSUE", "test-zone-1a", time.Time{})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeZonesImpaired)).ToNot(BeNil())
+
+		awsEnv.ImpairedZonesCache.MarkRecovered(ctx, "test-zone-1a")
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeZonesImpaired)).To(BeNil())
+	})
+})
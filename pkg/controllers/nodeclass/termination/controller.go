@@ -43,6 +43,8 @@ import (
 	"sigs.k8s.io/karpenter/pkg/events"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
 )
 
@@ -66,6 +68,7 @@ func NewController(kubeClient client.Client, recorder events.Recorder,
 
 func (c *Controller) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
 	ctx = injection.WithControllerName(ctx, "nodeclass.termination")
+	ctx = assumerole.WithNodeClass(ctx, nodeClass)
 
 	if !nodeClass.GetDeletionTimestamp().IsZero() {
 		return c.finalize(ctx, nodeClass)
@@ -86,7 +89,7 @@ func (c *Controller) finalize(ctx context.Context, nodeClass *v1.EC2NodeClass) (
 		c.recorder.Publish(WaitingOnNodeClaimTerminationEvent(nodeClass, lo.Map(nodeClaimList.Items, func(nc karpv1.NodeClaim, _ int) string { return nc.Name })))
 		return reconcile.Result{RequeueAfter: time.Minute * 10}, nil // periodically fire the event
 	}
-	if nodeClass.Spec.Role != "" {
+	if nodeClass.Spec.Role != "" && options.FromContext(ctx).ControllerEnabled(options.ControllerInstanceProfile) {
 		if err := c.instanceProfileProvider.Delete(ctx, nodeClass); err != nil {
 			return reconcile.Result{}, fmt.Errorf("deleting instance profile, %w", err)
 		}
@@ -194,6 +194,22 @@ var _ = Describe("NodeClass Termination", func() {
 		Expect(awsEnv.IAMAPI.InstanceProfiles).To(HaveLen(0))
 		ExpectNotFound(ctx, env.Client, nodeClass)
 	})
+	It("should not delete the instance profile when instance profile management is disabled", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{DisabledControllers: lo.ToPtr(options.ControllerInstanceProfile)}))
+		awsEnv.IAMAPI.InstanceProfiles = map[string]*iam.InstanceProfile{
+			profileName: {
+				InstanceProfileName: aws.String(profileName),
+			},
+		}
+		controllerutil.AddFinalizer(nodeClass, v1.TerminationFinalizer)
+		ExpectApplied(ctx, env.Client, nodeClass)
+
+		Expect(env.Client.Delete(ctx, nodeClass)).To(Succeed())
+		ExpectObjectReconciled(ctx, env.Client, terminationController, nodeClass)
+		Expect(awsEnv.IAMAPI.InstanceProfiles).To(HaveLen(1))
+		Expect(awsEnv.IAMAPI.DeleteInstanceProfileBehavior.Calls()).To(BeZero())
+		ExpectNotFound(ctx, env.Client, nodeClass)
+	})
 	It("should succeed to delete the NodeClass when the instance profile doesn't exist", func() {
 		Expect(awsEnv.IAMAPI.InstanceProfiles).To(HaveLen(0))
 		controllerutil.AddFinalizer(nodeClass, v1.TerminationFinalizer)
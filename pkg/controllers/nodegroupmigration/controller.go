@@ -0,0 +1,465 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodegroupmigration
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/importer"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// pollPeriod is how often the progress of a migration is checked, since the drain of its nodes and the scheduling of
+// their pods aren't watched
+const pollPeriod = 10 * time.Second
+
+var taintEffects = map[string]corev1.TaintEffect{
+	eks.TaintEffectNoSchedule:       corev1.TaintEffectNoSchedule,
+	eks.TaintEffectNoExecute:        corev1.TaintEffectNoExecute,
+	eks.TaintEffectPreferNoSchedule: corev1.TaintEffectPreferNoSchedule,
+}
+
+// Controller migrates the nodes of auto scaling groups and EKS managed node groups to the capacity of NodePools. It
+// cordons every node of the node group, and then drains BatchSize nodes at a time. The next batch is drained once the
+// evicted pods are scheduled, so that the capacity that Karpenter launches for them is ready before more pods are
+// evicted. Drained nodes are terminated, and the node group is scaled in with them. A batch that isn't drained within
+// the DrainTimeout stops the migration until it's changed. Rollback uncordons the nodes that the migration cordoned
+// and restores the original capacity of the node group, which replaces the terminated nodes.
+type Controller struct {
+	kubeClient     client.Client
+	clk            clock.Clock
+	recorder       events.Recorder
+	autoscalingapi autoscalingiface.AutoScalingAPI
+	eksapi         eksiface.EKSAPI
+}
+
+func NewController(kubeClient client.Client, clk clock.Clock, recorder events.Recorder, autoscalingapi autoscalingiface.AutoScalingAPI, eksapi eksiface.EKSAPI) *Controller {
+	return &Controller{
+		kubeClient:     kubeClient,
+		clk:            clk,
+		recorder:       recorder,
+		autoscalingapi: autoscalingapi,
+		eksapi:         eksapi,
+	}
+}
+
+// nodeGroup is an auto scaling group, with the labels and the taints of its nodes
+type nodeGroup struct {
+	*autoscaling.Group
+	labels map[string]string
+	taints []corev1.Taint
+}
+
+func (c *Controller) Reconcile(ctx context.Context, migration *v1.NodeGroupMigration) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodegroupmigration")
+
+	stored := migration.DeepCopy()
+	result, err := c.reconcile(ctx, migration)
+	if !equality.Semantic.DeepEqual(stored.Status, migration.Status) {
+		if patchErr := c.kubeClient.Status().Patch(ctx, migration, client.MergeFrom(stored)); patchErr != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(multierr.Append(err, patchErr))
+		}
+	}
+	return result, err
+}
+
+func (c *Controller) reconcile(ctx context.Context, migration *v1.NodeGroupMigration) (reconcile.Result, error) {
+	if migration.Spec.Rollback {
+		return reconcile.Result{}, c.rollback(ctx, migration)
+	}
+	if migration.Status.Phase == v1.NodeGroupMigrationPhaseCompleted || migration.Status.Phase == v1.NodeGroupMigrationPhaseRolledBack {
+		return reconcile.Result{}, nil
+	}
+	group, err := c.nodeGroup(ctx, migration)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	migration.Status.AutoScalingGroupName = aws.StringValue(group.AutoScalingGroupName)
+	if migration.Status.OriginalCapacity == nil {
+		if reason, err := c.validate(ctx, migration, group); err != nil || reason != "" {
+			migration.Status.Phase, migration.Status.Message = v1.NodeGroupMigrationPhasePending, reason
+			return reconcile.Result{RequeueAfter: pollPeriod}, err
+		}
+		// The min size is released so that the node group is scaled in as its nodes are terminated
+		if err := c.updateCapacity(ctx, migration, &v1.NodeGroupCapacity{MinSize: 0, MaxSize: aws.Int64Value(group.MaxSize), DesiredSize: aws.Int64Value(group.DesiredCapacity)}); err != nil {
+			return reconcile.Result{}, err
+		}
+		migration.Status.OriginalCapacity = &v1.NodeGroupCapacity{
+			MinSize:     aws.Int64Value(group.MinSize),
+			MaxSize:     aws.Int64Value(group.MaxSize),
+			DesiredSize: aws.Int64Value(group.DesiredCapacity),
+		}
+		migration.Status.Phase = v1.NodeGroupMigrationPhaseMigrating
+	}
+
+	nodes, err := c.nodes(ctx, group)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	for _, node := range nodes {
+		if err := c.cordon(ctx, migration, node); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	// A drain that timed out isn't retried until the migration is changed, like when its drainTimeout is raised once
+	// the PDBs that blocked the drain are relaxed
+	if drained := migration.StatusConditions().Get(v1.ConditionTypeDrained); drained.IsFalse() {
+		if drained.ObservedGeneration == migration.Generation {
+			return reconcile.Result{}, nil
+		}
+		migration.Status.DrainStartTime = lo.ToPtr(metav1.NewTime(c.clk.Now()))
+		migration.StatusConditions().SetUnknown(v1.ConditionTypeDrained)
+	}
+	if len(migration.Status.DrainingNodes) != 0 && migration.Status.DrainStartTime == nil {
+		migration.Status.DrainStartTime = lo.ToPtr(metav1.NewTime(c.clk.Now()))
+	}
+	var draining, remaining []string
+	owners := sets.New(migration.Status.EvictedPodOwners...)
+	for _, name := range migration.Status.DrainingNodes {
+		node, ok := nodes[name]
+		if !ok {
+			continue
+		}
+		pods, evicted, err := c.drain(ctx, node)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		owners.Insert(evicted...)
+		if len(pods) != 0 {
+			draining = append(draining, name)
+			remaining = append(remaining, pods...)
+			continue
+		}
+		if err := c.terminate(ctx, node); err != nil {
+			return reconcile.Result{}, err
+		}
+		delete(nodes, name)
+		migration.Status.MigratedNodes++
+		c.recorder.Publish(NodeMigratedEvent(migration, node))
+	}
+	migration.Status.DrainingNodes = draining
+	migration.Status.EvictedPodOwners = sets.List(owners)
+	migration.Status.RemainingNodes = int32(len(nodes))
+	if len(nodes) == 0 {
+		migration.Status.Phase, migration.Status.Message = v1.NodeGroupMigrationPhaseCompleted, "all nodes of the node group were migrated"
+		migration.Status.DrainStartTime = nil
+		migration.StatusConditions().SetTrue(v1.ConditionTypeDrained)
+		c.recorder.Publish(MigrationCompletedEvent(migration))
+		return reconcile.Result{}, nil
+	}
+	if timeout := lo.FromPtr(migration.Spec.DrainTimeout).Duration; len(draining) != 0 && timeout != 0 && c.clk.Since(migration.Status.DrainStartTime.Time) >= timeout {
+		sort.Strings(remaining)
+		message := fmt.Sprintf("nodes %s weren't drained within %s, pods %s weren't evicted", strings.Join(draining, ", "), timeout, strings.Join(remaining, ", "))
+		migration.StatusConditions().Set(status.Condition{
+			Type:               v1.ConditionTypeDrained,
+			Status:             metav1.ConditionFalse,
+			Reason:             "DrainTimedOut",
+			Message:            message,
+			ObservedGeneration: migration.Generation,
+		})
+		migration.Status.Message = message
+		c.recorder.Publish(DrainTimedOutEvent(migration, message))
+		return reconcile.Result{}, nil
+	}
+	if len(draining) == 0 {
+		migration.StatusConditions().SetTrue(v1.ConditionTypeDrained)
+		if reason, err := c.waitForCapacity(ctx, migration, group); err != nil || reason != "" {
+			migration.Status.Message = reason
+			return reconcile.Result{RequeueAfter: pollPeriod}, err
+		}
+		names := lo.Keys(nodes)
+		sort.Strings(names)
+		migration.Status.DrainingNodes = lo.Slice(names, 0, int(migration.Spec.BatchSize))
+		migration.Status.DrainStartTime = lo.ToPtr(metav1.NewTime(c.clk.Now()))
+		migration.Status.EvictedPodOwners = nil
+	}
+	migration.Status.Message = fmt.Sprintf("draining %s", strings.Join(migration.Status.DrainingNodes, ", "))
+	return reconcile.Result{RequeueAfter: pollPeriod}, nil
+}
+
+// nodeGroup describes the auto scaling group of the migration, which is resolved from the EKS managed node group when
+// nodeGroupName is set
+func (c *Controller) nodeGroup(ctx context.Context, migration *v1.NodeGroupMigration) (*nodeGroup, error) {
+	name := migration.Spec.AutoScalingGroupName
+	var managed *eks.Nodegroup
+	if migration.Spec.NodeGroupName != "" {
+		out, err := c.eksapi.DescribeNodegroupWithContext(ctx, &eks.DescribeNodegroupInput{
+			ClusterName:   aws.String(options.FromContext(ctx).ClusterName),
+			NodegroupName: aws.String(migration.Spec.NodeGroupName),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("describing node group %q, %w", migration.Spec.NodeGroupName, err)
+		}
+		managed = out.Nodegroup
+		if managed.Resources == nil || len(managed.Resources.AutoScalingGroups) == 0 {
+			return nil, fmt.Errorf("node group %q doesn't have an auto scaling group", migration.Spec.NodeGroupName)
+		}
+		name = aws.StringValue(managed.Resources.AutoScalingGroups[0].Name)
+	}
+	out, err := c.autoscalingapi.DescribeAutoScalingGroupsWithContext(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: aws.StringSlice([]string{name}),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describing auto scaling group %q, %w", name, err)
+	}
+	if len(out.AutoScalingGroups) == 0 {
+		return nil, fmt.Errorf("auto scaling group %q not found", name)
+	}
+	group := &nodeGroup{Group: out.AutoScalingGroups[0]}
+	if managed != nil {
+		group.labels = aws.StringValueMap(managed.Labels)
+		group.taints = lo.Map(managed.Taints, func(t *eks.Taint, _ int) corev1.Taint {
+			return corev1.Taint{Key: aws.StringValue(t.Key), Value: aws.StringValue(t.Value), Effect: taintEffects[aws.StringValue(t.Effect)]}
+		})
+	} else {
+		group.labels, group.taints = importer.NodeTemplate(group.Tags)
+	}
+	return group, nil
+}
+
+// validate returns why the pods of the node group can't schedule to the nodes of the NodePool of the migration, which
+// must be ready, and must label and taint its nodes like the node group
+func (c *Controller) validate(ctx context.Context, migration *v1.NodeGroupMigration, group *nodeGroup) (string, error) {
+	nodePool := &karpv1.NodePool{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: migration.Spec.NodePool}, nodePool); err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Sprintf("nodepool %q not found", migration.Spec.NodePool), nil
+		}
+		return "", fmt.Errorf("getting nodepool, %w", err)
+	}
+	if !nodePool.StatusConditions().Root().IsTrue() {
+		return fmt.Sprintf("nodepool %q isn't ready", nodePool.Name), nil
+	}
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodePool.Spec.Template.Spec.Requirements...)
+	requirements.Add(scheduling.NewLabelRequirements(nodePool.Spec.Template.Labels).Values()...)
+	keys := lo.Keys(group.labels)
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !requirements.Has(key) || !requirements.Get(key).Has(group.labels[key]) {
+			return fmt.Sprintf("nodepool %q doesn't label its nodes with %s=%s", nodePool.Name, key, group.labels[key]), nil
+		}
+	}
+	for _, taint := range group.taints {
+		if !lo.ContainsBy(nodePool.Spec.Template.Spec.Taints, func(t corev1.Taint) bool { return t.MatchTaint(&taint) && t.Value == taint.Value }) {
+			return fmt.Sprintf("nodepool %q doesn't taint its nodes with %s", nodePool.Name, taint.ToString()), nil
+		}
+	}
+	return "", nil
+}
+
+// waitForCapacity returns what the migration waits for before it drains the next batch of nodes. The pods that
+// replace the pods of the previous batch must be scheduled, and the NodePool must still be valid. The replacements
+// are the pods of the controllers of the evicted pods, so that pending pods of other workloads don't stall the
+// migration.
+func (c *Controller) waitForCapacity(ctx context.Context, migration *v1.NodeGroupMigration, group *nodeGroup) (string, error) {
+	pods := &corev1.PodList{}
+	if err := c.kubeClient.List(ctx, pods); err != nil {
+		return "", fmt.Errorf("listing pods, %w", err)
+	}
+	owners := sets.New(migration.Status.EvictedPodOwners...)
+	if pending := lo.CountBy(pods.Items, func(p corev1.Pod) bool {
+		owner := metav1.GetControllerOf(&p)
+		return owner != nil && owners.Has(string(owner.UID)) && podutils.IsProvisionable(&p)
+	}); pending != 0 {
+		return fmt.Sprintf("waiting for %d pending pods of the evicted workloads to schedule", pending), nil
+	}
+	return c.validate(ctx, migration, group)
+}
+
+// nodes returns the nodes of the instances of the node group that aren't terminating, by name
+func (c *Controller) nodes(ctx context.Context, group *nodeGroup) (map[string]*corev1.Node, error) {
+	instanceIDs := sets.New(lo.FilterMap(group.Instances, func(i *autoscaling.Instance, _ int) (string, bool) {
+		return aws.StringValue(i.InstanceId), !strings.HasPrefix(aws.StringValue(i.LifecycleState), "Terminating")
+	})...)
+	nodeList := &corev1.NodeList{}
+	if err := c.kubeClient.List(ctx, nodeList); err != nil {
+		return nil, fmt.Errorf("listing nodes, %w", err)
+	}
+	nodes := map[string]*corev1.Node{}
+	for i := range nodeList.Items {
+		if id, err := utils.ParseInstanceID(nodeList.Items[i].Spec.ProviderID); err == nil && instanceIDs.Has(id) {
+			nodes[nodeList.Items[i].Name] = &nodeList.Items[i]
+		}
+	}
+	return nodes, nil
+}
+
+// cordon marks a node unschedulable, and annotates it with the migration so that rollback only uncordons the nodes
+// that the migration cordoned
+func (c *Controller) cordon(ctx context.Context, migration *v1.NodeGroupMigration, node *corev1.Node) error {
+	if node.Spec.Unschedulable {
+		return nil
+	}
+	stored := node.DeepCopy()
+	node.Spec.Unschedulable = true
+	node.Annotations = lo.Assign(node.Annotations, map[string]string{v1.AnnotationNodeGroupMigration: migration.Name})
+	if err := c.kubeClient.Patch(ctx, node, client.MergeFrom(stored)); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("cordoning node, %w", err))
+	}
+	return nil
+}
+
+// drain evicts the pods of a node, and returns the pods that must still be evicted, and the UIDs of the controllers of
+// the pods that were evicted. Pods that can't be evicted, like the pods whose eviction violates a PDB, are retried on
+// the next poll until the drain times out.
+func (c *Controller) drain(ctx context.Context, node *corev1.Node) ([]string, []string, error) {
+	pods := &corev1.PodList{}
+	if err := c.kubeClient.List(ctx, pods, client.MatchingFields{"spec.nodeName": node.Name}); err != nil {
+		return nil, nil, fmt.Errorf("listing pods, %w", err)
+	}
+	waiting := lo.Filter(pods.Items, func(p corev1.Pod, _ int) bool {
+		return podutils.IsWaitingEviction(&p, c.clk) && !podutils.IsOwnedByDaemonSet(&p)
+	})
+	var evicted []string
+	for i := range waiting {
+		if !podutils.IsEvictable(&waiting[i]) {
+			continue
+		}
+		if err := c.kubeClient.SubResource("eviction").Create(ctx, &waiting[i], &policyv1.Eviction{
+			DeleteOptions: &metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: lo.ToPtr(waiting[i].UID)}},
+		}); err != nil && !errors.IsNotFound(err) && !errors.IsConflict(err) {
+			if !errors.IsTooManyRequests(err) {
+				log.FromContext(ctx).Error(err, "failed evicting pod", "Pod", client.ObjectKeyFromObject(&waiting[i]))
+			}
+			continue
+		}
+		if owner := metav1.GetControllerOf(&waiting[i]); owner != nil {
+			evicted = append(evicted, string(owner.UID))
+		}
+	}
+	return lo.Map(waiting, func(p corev1.Pod, _ int) string { return client.ObjectKeyFromObject(&p).String() }), evicted, nil
+}
+
+// terminate terminates the instance of a node, and decrements the desired capacity of its node group
+func (c *Controller) terminate(ctx context.Context, node *corev1.Node) error {
+	id, err := utils.ParseInstanceID(node.Spec.ProviderID)
+	if err != nil {
+		return err
+	}
+	if _, err := c.autoscalingapi.TerminateInstanceInAutoScalingGroupWithContext(ctx, &autoscaling.TerminateInstanceInAutoScalingGroupInput{
+		InstanceId:                     aws.String(id),
+		ShouldDecrementDesiredCapacity: aws.Bool(true),
+	}); err != nil {
+		return fmt.Errorf("terminating instance %q, %w", id, err)
+	}
+	return nil
+}
+
+// updateCapacity scales the node group. EKS managed node groups are scaled with their scaling config, since EKS
+// would revert the changes that are made to their auto scaling groups.
+func (c *Controller) updateCapacity(ctx context.Context, migration *v1.NodeGroupMigration, capacity *v1.NodeGroupCapacity) error {
+	if migration.Spec.NodeGroupName != "" {
+		if _, err := c.eksapi.UpdateNodegroupConfigWithContext(ctx, &eks.UpdateNodegroupConfigInput{
+			ClusterName:   aws.String(options.FromContext(ctx).ClusterName),
+			NodegroupName: aws.String(migration.Spec.NodeGroupName),
+			ScalingConfig: &eks.NodegroupScalingConfig{
+				MinSize:     aws.Int64(capacity.MinSize),
+				MaxSize:     aws.Int64(capacity.MaxSize),
+				DesiredSize: aws.Int64(capacity.DesiredSize),
+			},
+		}); err != nil {
+			return fmt.Errorf("updating node group %q, %w", migration.Spec.NodeGroupName, err)
+		}
+		return nil
+	}
+	if _, err := c.autoscalingapi.UpdateAutoScalingGroupWithContext(ctx, &autoscaling.UpdateAutoScalingGroupInput{
+		AutoScalingGroupName: aws.String(migration.Spec.AutoScalingGroupName),
+		MinSize:              aws.Int64(capacity.MinSize),
+		MaxSize:              aws.Int64(capacity.MaxSize),
+		DesiredCapacity:      aws.Int64(capacity.DesiredSize),
+	}); err != nil {
+		return fmt.Errorf("updating auto scaling group %q, %w", migration.Spec.AutoScalingGroupName, err)
+	}
+	return nil
+}
+
+// rollback restores the original capacity of the node group, and uncordons the nodes that the migration cordoned
+func (c *Controller) rollback(ctx context.Context, migration *v1.NodeGroupMigration) error {
+	if migration.Status.Phase == v1.NodeGroupMigrationPhaseRolledBack {
+		return nil
+	}
+	if migration.Status.OriginalCapacity != nil {
+		if err := c.updateCapacity(ctx, migration, migration.Status.OriginalCapacity); err != nil {
+			return err
+		}
+	}
+	nodes := &corev1.NodeList{}
+	if err := c.kubeClient.List(ctx, nodes); err != nil {
+		return fmt.Errorf("listing nodes, %w", err)
+	}
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if node.Annotations[v1.AnnotationNodeGroupMigration] != migration.Name {
+			continue
+		}
+		stored := node.DeepCopy()
+		node.Spec.Unschedulable = false
+		delete(node.Annotations, v1.AnnotationNodeGroupMigration)
+		if err := c.kubeClient.Patch(ctx, node, client.MergeFrom(stored)); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("uncordoning node, %w", err)
+		}
+	}
+	migration.Status.Phase = v1.NodeGroupMigrationPhaseRolledBack
+	migration.Status.Message = "restored the capacity of the node group and uncordoned its nodes"
+	migration.Status.DrainingNodes = nil
+	migration.Status.DrainStartTime = nil
+	c.recorder.Publish(MigrationRolledBackEvent(migration))
+	return nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodegroupmigration").
+		For(&v1.NodeGroupMigration{}).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 1,
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
@@ -0,0 +1,65 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodegroupmigration
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/karpenter/pkg/events"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+)
+
+func NodeMigratedEvent(migration *v1.NodeGroupMigration, node *corev1.Node) events.Event {
+	return events.Event{
+		InvolvedObject: migration,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "NodeMigrated",
+		Message:        fmt.Sprintf("Drained and terminated node %s", node.Name),
+		DedupeValues:   []string{string(migration.UID), node.Name},
+	}
+}
+
+func MigrationCompletedEvent(migration *v1.NodeGroupMigration) events.Event {
+	return events.Event{
+		InvolvedObject: migration,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "MigrationCompleted",
+		Message:        fmt.Sprintf("Migrated %d nodes to nodepool %s", migration.Status.MigratedNodes, migration.Spec.NodePool),
+		DedupeValues:   []string{string(migration.UID)},
+	}
+}
+
+func MigrationRolledBackEvent(migration *v1.NodeGroupMigration) events.Event {
+	return events.Event{
+		InvolvedObject: migration,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "MigrationRolledBack",
+		Message:        "Restored the capacity of the node group and uncordoned its nodes",
+		DedupeValues:   []string{string(migration.UID)},
+	}
+}
+
+func DrainTimedOutEvent(migration *v1.NodeGroupMigration, message string) events.Event {
+	return events.Event{
+		InvolvedObject: migration,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "DrainTimedOut",
+		Message:        fmt.Sprintf("Stopped the migration, %s", message),
+		DedupeValues:   []string{string(migration.UID)},
+	}
+}
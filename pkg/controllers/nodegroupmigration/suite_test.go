@@ -0,0 +1,298 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodegroupmigration_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodegroupmigration"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var kubeClient client.Client
+var autoscalingapi *fake.AutoScalingAPI
+var eksapi *fake.EKSAPI
+var controller *nodegroupmigration.Controller
+var fakeClock *clock.FakeClock
+var recorder *coretest.EventRecorder
+var nodePool *karpv1.NodePool
+var migration *v1.NodeGroupMigration
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "NodeGroupMigration")
+}
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	kubeClient = crfake.NewClientBuilder().
+		WithStatusSubresource(&v1.NodeGroupMigration{}).
+		WithIndex(&corev1.Pod{}, "spec.nodeName", func(o client.Object) []string { return []string{o.(*corev1.Pod).Spec.NodeName} }).
+		Build()
+	autoscalingapi = &fake.AutoScalingAPI{}
+	eksapi = fake.NewEKSAPI()
+	fakeClock = clock.NewFakeClock(time.Now())
+	recorder = coretest.NewEventRecorder()
+	controller = nodegroupmigration.NewController(kubeClient, fakeClock, recorder, autoscalingapi, eksapi)
+
+	nodePool = coretest.NodePool(karpv1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+	nodePool.Spec.Template.Labels = map[string]string{"workload": "batch"}
+	nodePool.StatusConditions().SetTrue(status.ConditionReady)
+	migration = &v1.NodeGroupMigration{
+		ObjectMeta: metav1.ObjectMeta{Name: "workers"},
+		Spec:       v1.NodeGroupMigrationSpec{AutoScalingGroupName: "workers", NodePool: "default", BatchSize: 1},
+	}
+	autoScalingGroup("i-1", "i-2")
+	node("node-1", "i-1")
+	node("node-2", "i-2")
+})
+
+// autoScalingGroup sets the auto scaling group of the migration, with the instances that are in service
+func autoScalingGroup(instanceIDs ...string) {
+	autoscalingapi.DescribeAutoScalingGroupsBehavior.Output.Set(&autoscaling.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []*autoscaling.Group{{
+			AutoScalingGroupName: aws.String("workers"),
+			MinSize:              aws.Int64(2),
+			MaxSize:              aws.Int64(4),
+			DesiredCapacity:      aws.Int64(int64(len(instanceIDs))),
+			Tags: []*autoscaling.TagDescription{
+				{Key: aws.String("k8s.io/cluster-autoscaler/node-template/label/workload"), Value: aws.String("batch")},
+			},
+			Instances: lo.Map(instanceIDs, func(id string, _ int) *autoscaling.Instance {
+				return &autoscaling.Instance{InstanceId: aws.String(id), LifecycleState: aws.String(autoscaling.LifecycleStateInService)}
+			}),
+		}},
+	})
+}
+
+func node(name, instanceID string) {
+	Expect(kubeClient.Create(ctx, coretest.Node(coretest.NodeOptions{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		ProviderID: "aws:///test-zone-1a/" + instanceID,
+	}))).To(Succeed())
+}
+
+func reconcileMigration() {
+	ExpectObjectReconciled(ctx, kubeClient, controller, migration)
+	Expect(kubeClient.Get(ctx, client.ObjectKeyFromObject(migration), migration)).To(Succeed())
+}
+
+func expectUnschedulable(name string, unschedulable bool) {
+	n := &corev1.Node{}
+	Expect(kubeClient.Get(ctx, client.ObjectKey{Name: name}, n)).To(Succeed())
+	Expect(n.Spec.Unschedulable).To(Equal(unschedulable))
+}
+
+var _ = Describe("NodeGroupMigration", func() {
+	It("should wait for the NodePool to exist", func() {
+		Expect(kubeClient.Create(ctx, migration)).To(Succeed())
+		reconcileMigration()
+		Expect(migration.Status.Phase).To(Equal(v1.NodeGroupMigrationPhasePending))
+		Expect(migration.Status.Message).To(Equal(`nodepool "default" not found`))
+		Expect(autoscalingapi.UpdateAutoScalingGroupBehavior.Calls()).To(BeZero())
+		expectUnschedulable("node-1", false)
+	})
+	It("should wait for the NodePool to label its nodes like the node group", func() {
+		nodePool.Spec.Template.Labels = nil
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		Expect(kubeClient.Create(ctx, migration)).To(Succeed())
+		reconcileMigration()
+		Expect(migration.Status.Phase).To(Equal(v1.NodeGroupMigrationPhasePending))
+		Expect(migration.Status.Message).To(Equal(`nodepool "default" doesn't label its nodes with workload=batch`))
+	})
+	It("should cordon the nodes, release the min size and drain the first batch", func() {
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		Expect(kubeClient.Create(ctx, migration)).To(Succeed())
+		reconcileMigration()
+
+		Expect(migration.Status.Phase).To(Equal(v1.NodeGroupMigrationPhaseMigrating))
+		Expect(migration.Status.OriginalCapacity).To(Equal(&v1.NodeGroupCapacity{MinSize: 2, MaxSize: 4, DesiredSize: 2}))
+		Expect(migration.Status.DrainingNodes).To(ConsistOf("node-1"))
+		Expect(migration.Status.RemainingNodes).To(BeNumerically("==", 2))
+		input := autoscalingapi.UpdateAutoScalingGroupBehavior.CalledWithInput.Pop()
+		Expect(aws.Int64Value(input.MinSize)).To(BeZero())
+		Expect(aws.Int64Value(input.DesiredCapacity)).To(BeNumerically("==", 2))
+		expectUnschedulable("node-1", true)
+		expectUnschedulable("node-2", true)
+	})
+	It("should evict the pods of a draining node and terminate it once it's drained", func() {
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		Expect(kubeClient.Create(ctx, migration)).To(Succeed())
+		pod := coretest.Pod(coretest.PodOptions{NodeName: "node-1"})
+		Expect(kubeClient.Create(ctx, pod)).To(Succeed())
+		reconcileMigration()
+
+		// The first reconcile selects the batch, the second one evicts its pods, and the third one terminates the node
+		reconcileMigration()
+		Expect(kubeClient.Get(ctx, client.ObjectKeyFromObject(pod), pod)).ToNot(Succeed())
+		Expect(autoscalingapi.TerminateInstanceInAutoScalingGroupBehavior.Calls()).To(BeZero())
+		reconcileMigration()
+		input := autoscalingapi.TerminateInstanceInAutoScalingGroupBehavior.CalledWithInput.Pop()
+		Expect(aws.StringValue(input.InstanceId)).To(Equal("i-1"))
+		Expect(aws.BoolValue(input.ShouldDecrementDesiredCapacity)).To(BeTrue())
+		Expect(migration.Status.MigratedNodes).To(BeNumerically("==", 1))
+		Expect(migration.Status.RemainingNodes).To(BeNumerically("==", 1))
+		Expect(migration.Status.DrainingNodes).To(ConsistOf("node-2"))
+	})
+	It("should wait for the pods of the evicted workloads to schedule before draining the next batch", func() {
+		owner := metav1.OwnerReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web", UID: "web-uid", Controller: lo.ToPtr(true)}
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		Expect(kubeClient.Create(ctx, migration)).To(Succeed())
+		Expect(kubeClient.Create(ctx, coretest.Pod(coretest.PodOptions{NodeName: "node-1", ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{owner}}}))).To(Succeed())
+		reconcileMigration()
+		reconcileMigration()
+		Expect(migration.Status.EvictedPodOwners).To(ConsistOf("web-uid"))
+
+		// The replacement of the evicted pod is pending
+		Expect(kubeClient.Create(ctx, coretest.UnschedulablePod(coretest.PodOptions{ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{owner}}}))).To(Succeed())
+		reconcileMigration()
+		Expect(migration.Status.MigratedNodes).To(BeNumerically("==", 1))
+		Expect(migration.Status.DrainingNodes).To(BeEmpty())
+		Expect(migration.Status.Message).To(Equal("waiting for 1 pending pods of the evicted workloads to schedule"))
+	})
+	It("should not wait for pending pods of other workloads", func() {
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		Expect(kubeClient.Create(ctx, migration)).To(Succeed())
+		reconcileMigration()
+		Expect(kubeClient.Create(ctx, coretest.UnschedulablePod())).To(Succeed())
+		reconcileMigration()
+
+		Expect(migration.Status.MigratedNodes).To(BeNumerically("==", 1))
+		Expect(migration.Status.DrainingNodes).To(ConsistOf("node-2"))
+		Expect(migration.Status.EvictedPodOwners).To(BeEmpty())
+	})
+	It("should stop the migration when a batch isn't drained within the drain timeout", func() {
+		migration.Spec.DrainTimeout = &metav1.Duration{Duration: 10 * time.Minute}
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		Expect(kubeClient.Create(ctx, migration)).To(Succeed())
+		pod := coretest.Pod(coretest.PodOptions{NodeName: "node-1", ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{karpv1.DoNotDisruptAnnotationKey: "true"}}})
+		Expect(kubeClient.Create(ctx, pod)).To(Succeed())
+		reconcileMigration()
+		reconcileMigration()
+		Expect(migration.StatusConditions().Get(v1.ConditionTypeDrained).IsFalse()).To(BeFalse())
+
+		fakeClock.Step(10 * time.Minute)
+		reconcileMigration()
+		drained := migration.StatusConditions().Get(v1.ConditionTypeDrained)
+		Expect(drained.IsFalse()).To(BeTrue())
+		Expect(drained.Reason).To(Equal("DrainTimedOut"))
+		Expect(drained.Message).To(ContainSubstring(client.ObjectKeyFromObject(pod).String()))
+		Expect(migration.Status.DrainingNodes).To(ConsistOf("node-1"))
+		Expect(recorder.Calls("DrainTimedOut")).To(Equal(1))
+
+		// The drain isn't retried until the migration is changed
+		Expect(kubeClient.Delete(ctx, pod)).To(Succeed())
+		reconcileMigration()
+		Expect(autoscalingapi.TerminateInstanceInAutoScalingGroupBehavior.Calls()).To(BeZero())
+		migration.Spec.DrainTimeout = &metav1.Duration{Duration: 20 * time.Minute}
+		// The fake client doesn't bump the generation of changed objects like the API server
+		migration.Generation++
+		Expect(kubeClient.Update(ctx, migration)).To(Succeed())
+		reconcileMigration()
+		Expect(autoscalingapi.TerminateInstanceInAutoScalingGroupBehavior.Calls()).To(Equal(1))
+		Expect(migration.StatusConditions().Get(v1.ConditionTypeDrained).IsTrue()).To(BeTrue())
+	})
+	It("should complete once the node group doesn't have nodes", func() {
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		Expect(kubeClient.Create(ctx, migration)).To(Succeed())
+		reconcileMigration()
+		reconcileMigration()
+		autoScalingGroup("i-2")
+		reconcileMigration()
+		Expect(migration.Status.Phase).To(Equal(v1.NodeGroupMigrationPhaseCompleted))
+		Expect(migration.Status.MigratedNodes).To(BeNumerically("==", 2))
+		Expect(migration.Status.RemainingNodes).To(BeZero())
+	})
+	It("should restore the capacity of the node group and uncordon its nodes on rollback", func() {
+		unschedulable := coretest.Node(coretest.NodeOptions{ObjectMeta: metav1.ObjectMeta{Name: "node-3"}, ProviderID: "aws:///test-zone-1a/i-3", Unschedulable: true})
+		Expect(kubeClient.Create(ctx, unschedulable)).To(Succeed())
+		autoScalingGroup("i-1", "i-2", "i-3")
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		Expect(kubeClient.Create(ctx, migration)).To(Succeed())
+		reconcileMigration()
+
+		migration.Spec.Rollback = true
+		Expect(kubeClient.Update(ctx, migration)).To(Succeed())
+		reconcileMigration()
+		Expect(migration.Status.Phase).To(Equal(v1.NodeGroupMigrationPhaseRolledBack))
+		input := autoscalingapi.UpdateAutoScalingGroupBehavior.CalledWithInput.Pop()
+		Expect(aws.Int64Value(input.MinSize)).To(BeNumerically("==", 2))
+		Expect(aws.Int64Value(input.MaxSize)).To(BeNumerically("==", 4))
+		Expect(aws.Int64Value(input.DesiredCapacity)).To(BeNumerically("==", 3))
+		expectUnschedulable("node-1", false)
+		expectUnschedulable("node-2", false)
+		// Nodes that were cordoned before the migration stay cordoned
+		expectUnschedulable("node-3", true)
+	})
+	Context("EKS Managed Node Groups", func() {
+		BeforeEach(func() {
+			migration.Spec = v1.NodeGroupMigrationSpec{NodeGroupName: "workers", NodePool: "default", BatchSize: 2}
+			eksapi.DescribeNodegroupBehavior.Output.Set(&eks.DescribeNodegroupOutput{Nodegroup: &eks.Nodegroup{
+				NodegroupName: aws.String("workers"),
+				Labels:        aws.StringMap(map[string]string{"workload": "batch"}),
+				Taints:        []*eks.Taint{{Key: aws.String("dedicated"), Value: aws.String("batch"), Effect: aws.String(eks.TaintEffectNoSchedule)}},
+				Resources:     &eks.NodegroupResources{AutoScalingGroups: []*eks.AutoScalingGroup{{Name: aws.String("workers")}}},
+			}})
+		})
+		It("should wait for the NodePool to taint its nodes like the node group", func() {
+			Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+			Expect(kubeClient.Create(ctx, migration)).To(Succeed())
+			reconcileMigration()
+			Expect(migration.Status.Phase).To(Equal(v1.NodeGroupMigrationPhasePending))
+			Expect(migration.Status.Message).To(Equal(`nodepool "default" doesn't taint its nodes with dedicated=batch:NoSchedule`))
+		})
+		It("should scale the node group with its scaling config", func() {
+			nodePool.Spec.Template.Spec.Taints = []corev1.Taint{{Key: "dedicated", Value: "batch", Effect: corev1.TaintEffectNoSchedule}}
+			Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+			Expect(kubeClient.Create(ctx, migration)).To(Succeed())
+			reconcileMigration()
+
+			Expect(migration.Status.Phase).To(Equal(v1.NodeGroupMigrationPhaseMigrating))
+			Expect(migration.Status.AutoScalingGroupName).To(Equal("workers"))
+			Expect(migration.Status.DrainingNodes).To(ConsistOf("node-1", "node-2"))
+			Expect(autoscalingapi.UpdateAutoScalingGroupBehavior.Calls()).To(BeZero())
+			input := eksapi.UpdateNodegroupConfigBehavior.CalledWithInput.Pop()
+			Expect(aws.StringValue(input.NodegroupName)).To(Equal("workers"))
+			Expect(aws.Int64Value(input.ScalingConfig.MinSize)).To(BeZero())
+		})
+	})
+})
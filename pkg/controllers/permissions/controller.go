@@ -0,0 +1,259 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package permissions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/utils/pretty"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// Controller periodically simulates the IAM policies of the controller's principal for every action that the
+// controller requires, so that missing permissions are surfaced before they cause the first launch to fail.
+type Controller struct {
+	stsapi stsiface.STSAPI
+	iamapi iamiface.IAMAPI
+	region string
+	cm     *pretty.ChangeMonitor
+
+	mu      sync.RWMutex
+	missing []string
+}
+
+func NewController(stsapi stsiface.STSAPI, iamapi iamiface.IAMAPI, region string) *Controller {
+	return &Controller{
+		stsapi: stsapi,
+		iamapi: iamapi,
+		region: region,
+		cm:     pretty.NewChangeMonitor(),
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "permissions")
+
+	principal, decisions, err := c.Simulate(ctx)
+	if err != nil {
+		// The check is best-effort, so the controller shouldn't be reported as unready when it can't run at all. This is
+		// the case when the controller isn't allowed to simulate its policies or when its role has a path, since the path
+		// isn't part of the ARN of an assumed role session.
+		if awserrors.IsAccessDenied(err) || awserrors.IsNotFound(err) {
+			if c.cm.HasChanged("skipped", principal) {
+				log.FromContext(ctx).WithValues("principal", principal).Error(err, "failed simulating permissions, skipping permissions check")
+			}
+			return reconcile.Result{RequeueAfter: options.FromContext(ctx).PermissionsCheckPeriod}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	missing := Missing(decisions)
+	for action, decision := range decisions {
+		missingPermissions.With(prometheus.Labels{actionLabel: action}).Set(lo.Ternary(decision == iam.PolicyEvaluationDecisionTypeAllowed, 0.0, 1.0))
+	}
+	if c.cm.HasChanged("missing", missing) && len(missing) != 0 {
+		log.FromContext(ctx).WithValues("principal", principal, "actions", missing).Error(fmt.Errorf("missing permissions"), "controller is missing permissions that it requires")
+	}
+	c.mu.Lock()
+	c.missing = missing
+	c.mu.Unlock()
+	return reconcile.Result{RequeueAfter: options.FromContext(ctx).PermissionsCheckPeriod}, nil
+}
+
+// Simulate returns the principal of the controller's credentials and the decision of its IAM policies for each action
+// that the controller requires with the configured options
+func (c *Controller) Simulate(ctx context.Context) (string, map[string]string, error) {
+	principal, err := c.principal(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	decisions := map[string]string{}
+	if err = c.iamapi.SimulatePrincipalPolicyPagesWithContext(ctx, &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: aws.String(principal),
+		ActionNames:     aws.StringSlice(requiredActions(ctx)),
+		ContextEntries:  c.contextEntries(ctx),
+	}, func(out *iam.SimulatePolicyResponse, _ bool) bool {
+		for _, result := range out.EvaluationResults {
+			decisions[aws.StringValue(result.EvalActionName)] = aws.StringValue(result.EvalDecision)
+		}
+		return true
+	}); err != nil {
+		return principal, nil, fmt.Errorf("simulating permissions, %w", err)
+	}
+	return principal, decisions, nil
+}
+
+// Missing returns the sorted actions of the decisions that aren't allowed
+func Missing(decisions map[string]string) []string {
+	missing := lo.Keys(lo.OmitByValues(decisions, []string{iam.PolicyEvaluationDecisionTypeAllowed}))
+	sort.Strings(missing)
+	return missing
+}
+
+// Check is a readiness check that fails while the last simulation found missing permissions
+func (c *Controller) Check(_ *http.Request) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.missing) != 0 {
+		return fmt.Errorf("missing permissions for %s", strings.Join(c.missing, ", "))
+	}
+	return nil
+}
+
+// principal returns the ARN of the IAM user or role that the controller's credentials belong to. Policies can't be
+// simulated for an assumed role session, so the session is mapped back to the role that it was assumed from.
+func (c *Controller) principal(ctx context.Context) (string, error) {
+	out, err := c.stsapi.GetCallerIdentityWithContext(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", fmt.Errorf("getting caller identity, %w", err)
+	}
+	principal, err := arn.Parse(aws.StringValue(out.Arn))
+	if err != nil {
+		return "", fmt.Errorf("parsing caller identity, %w", err)
+	}
+	if parts := strings.Split(principal.Resource, "/"); principal.Service == "sts" && parts[0] == "assumed-role" && len(parts) > 1 {
+		principal.Service = "iam"
+		principal.Resource = fmt.Sprintf("role/%s", parts[1])
+	}
+	return principal.String(), nil
+}
+
+// contextEntries returns the condition keys of the requests that the controller makes, so that the statements which
+// scope permissions to the resources of the cluster are matched during the simulation
+func (c *Controller) contextEntries(ctx context.Context) []*iam.ContextEntry {
+	clusterTag := fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)
+	tags := map[string]string{
+		clusterTag:                 "owned",
+		karpv1.NodePoolLabelKey:    "default",
+		v1.LabelNodeClass:          "default",
+		corev1.LabelTopologyRegion: c.region,
+	}
+	entries := []*iam.ContextEntry{
+		stringEntry("aws:RequestedRegion", c.region),
+		stringEntry("ec2:CreateAction", "CreateFleet"),
+		stringEntry("iam:PassedToService", utils.ServicePrincipal("ec2", c.region)),
+		{
+			ContextKeyName:   aws.String("aws:TagKeys"),
+			ContextKeyType:   aws.String(iam.ContextKeyTypeEnumStringList),
+			ContextKeyValues: aws.StringSlice([]string{v1.TagNodeClaim}),
+		},
+	}
+	for k, v := range tags {
+		entries = append(entries, stringEntry(fmt.Sprintf("aws:RequestTag/%s", k), v), stringEntry(fmt.Sprintf("aws:ResourceTag/%s", k), v))
+	}
+	return entries
+}
+
+func stringEntry(key, value string) *iam.ContextEntry {
+	return &iam.ContextEntry{
+		ContextKeyName:   aws.String(key),
+		ContextKeyType:   aws.String(iam.ContextKeyTypeEnumString),
+		ContextKeyValues: aws.StringSlice([]string{value}),
+	}
+}
+
+// requiredActions returns the actions that the controller calls with the configured options
+func requiredActions(ctx context.Context) []string {
+	actions := []string{
+		"ec2:CreateFleet",
+		"ec2:CreateLaunchTemplate",
+		"ec2:CreateTags",
+		"ec2:DeleteLaunchTemplate",
+		"ec2:DescribeAvailabilityZones",
+		"ec2:DescribeImages",
+		"ec2:DescribeInstanceTypeOfferings",
+		"ec2:DescribeInstanceTypes",
+		"ec2:DescribeInstances",
+		"ec2:DescribeLaunchTemplates",
+		"ec2:DescribeSecurityGroups",
+		"ec2:DescribeSubnets",
+		"ec2:RunInstances",
+		"ec2:TerminateInstances",
+		"iam:GetInstanceProfile",
+		"iam:PassRole",
+		"ssm:GetParametersByPath",
+	}
+	if options.FromContext(ctx).ControllerEnabled(options.ControllerInstanceProfile) {
+		actions = append(actions, "iam:AddRoleToInstanceProfile", "iam:CreateInstanceProfile", "iam:DeleteInstanceProfile", "iam:RemoveRoleFromInstanceProfile", "iam:TagInstanceProfile")
+	}
+	if options.FromContext(ctx).ControllerEnabled(options.ControllerPricing) {
+		actions = append(actions, "ec2:DescribeSpotPriceHistory")
+		if !options.FromContext(ctx).IsolatedVPC {
+			actions = append(actions, "pricing:GetProducts")
+		}
+	}
+	if options.FromContext(ctx).InterruptionQueue != "" && options.FromContext(ctx).ControllerEnabled(options.ControllerInterruption) {
+		actions = append(actions, "sqs:DeleteMessage", "sqs:GetQueueUrl", "sqs:ReceiveMessage")
+	}
+	if options.FromContext(ctx).PublicIPGuardrail != options.PublicIPGuardrailDisabled {
+		actions = append(actions, "ec2:DescribeRouteTables")
+	}
+	if options.FromContext(ctx).EBSEncryptionPolicy != options.EBSEncryptionPolicyDisabled {
+		actions = append(actions, "ec2:GetEbsEncryptionByDefault")
+	}
+	if options.FromContext(ctx).EBSEncryptionPolicy == options.EBSEncryptionPolicyCustomerManagedKey {
+		actions = append(actions, "ec2:GetEbsDefaultKmsKeyId")
+	}
+	if options.FromContext(ctx).QuotaHeadroomPercent != 0 {
+		actions = append(actions, "ec2:DescribeAddresses", "ec2:DescribeNetworkInterfaces", "servicequotas:GetAWSDefaultServiceQuota", "servicequotas:GetServiceQuota")
+	}
+	if options.FromContext(ctx).ZonalShift != options.ZonalShiftDisabled {
+		actions = append(actions, "arc-zonal-shift:GetManagedResource", "eks:DescribeCluster")
+	}
+	if options.FromContext(ctx).NodeRolePermissionsBoundary != "" {
+		actions = append(actions, "iam:GetRole", "iam:PutRolePermissionsBoundary")
+	}
+	if len(options.FromContext(ctx).RequiredNodeRolePolicies()) != 0 {
+		actions = append(actions, "iam:AttachRolePolicy", "iam:ListAttachedRolePolicies")
+	}
+	if options.FromContext(ctx).AlertSNSTopicARN != "" {
+		actions = append(actions, "sns:Publish")
+	}
+	return actions
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	if err := m.AddReadyzCheck("permissions", c.Check); err != nil {
+		return fmt.Errorf("adding readiness check, %w", err)
+	}
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("permissions").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
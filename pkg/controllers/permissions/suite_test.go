@@ -0,0 +1,210 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package permissions_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/samber/lo"
+
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/permissions"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var stsapi *fake.STSAPI
+var iamapi *fake.IAMAPI
+var controller *permissions.Controller
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Permissions")
+}
+
+var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options())
+	stsapi = &fake.STSAPI{}
+	iamapi = fake.NewIAMAPI()
+	controller = permissions.NewController(stsapi, iamapi, "us-west-2")
+})
+
+func simulatedActions() []string {
+	Expect(iamapi.SimulatePrincipalPolicyBehavior.CalledWithInput.Len()).To(Equal(1))
+	return aws.StringValueSlice(iamapi.SimulatePrincipalPolicyBehavior.CalledWithInput.Pop().ActionNames)
+}
+
+var _ = Describe("Permissions", func() {
+	It("should simulate the policies of the role that the controller's session was assumed from", func() {
+		result := ExpectSingletonReconciled(ctx, controller)
+		Expect(result.RequeueAfter).To(Equal(options.FromContext(ctx).PermissionsCheckPeriod))
+
+		input := iamapi.SimulatePrincipalPolicyBehavior.CalledWithInput.Pop()
+		Expect(aws.StringValue(input.PolicySourceArn)).To(Equal("arn:aws:iam::123456789012:role/KarpenterControllerRole"))
+		Expect(aws.StringValueSlice(input.ActionNames)).To(ContainElements("ec2:CreateFleet", "ec2:RunInstances", "ec2:CreateTags", "iam:PassRole", "ssm:GetParametersByPath"))
+		entry, ok := lo.Find(input.ContextEntries, func(e *iam.ContextEntry) bool {
+			return aws.StringValue(e.ContextKeyName) == "aws:RequestTag/kubernetes.io/cluster/test-cluster"
+		})
+		Expect(ok).To(BeTrue())
+		Expect(aws.StringValueSlice(entry.ContextKeyValues)).To(ConsistOf("owned"))
+	})
+	It("should pass the instance profile role to the EC2 service principal of the partition", func() {
+		for region, principal := range map[string]string{
+			"us-west-2":      "ec2.amazonaws.com",
+			"cn-north-1":     "ec2.amazonaws.com.cn",
+			"us-iso-east-1":  "ec2.c2s.ic.gov",
+			"us-isob-east-1": "ec2.sc2s.sgov.gov",
+		} {
+			controller = permissions.NewController(stsapi, iamapi, region)
+			ExpectSingletonReconciled(ctx, controller)
+			entry, ok := lo.Find(iamapi.SimulatePrincipalPolicyBehavior.CalledWithInput.Pop().ContextEntries, func(e *iam.ContextEntry) bool {
+				return aws.StringValue(e.ContextKeyName) == "iam:PassedToService"
+			})
+			Expect(ok).To(BeTrue())
+			Expect(aws.StringValueSlice(entry.ContextKeyValues)).To(ConsistOf(principal))
+		}
+	})
+	It("should simulate the policies of an IAM user as-is", func() {
+		stsapi.GetCallerIdentityBehavior.Output.Set(&sts.GetCallerIdentityOutput{Arn: aws.String("arn:aws:iam::123456789012:user/karpenter")})
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(aws.StringValue(iamapi.SimulatePrincipalPolicyBehavior.CalledWithInput.Pop().PolicySourceArn)).To(Equal("arn:aws:iam::123456789012:user/karpenter"))
+	})
+	It("should only simulate the interruption and alerting actions when they're enabled", func() {
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(simulatedActions()).ToNot(ContainElements("sqs:ReceiveMessage", "sns:Publish"))
+
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			InterruptionQueue: lo.ToPtr("test-cluster"),
+			AlertSNSTopicARN:  lo.ToPtr("arn:aws:sns:us-west-2:123456789012:karpenter-alerts"),
+		}))
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(simulatedActions()).To(ContainElements("sqs:DeleteMessage", "sqs:GetQueueUrl", "sqs:ReceiveMessage", "sns:Publish"))
+	})
+	It("should only simulate the node role actions when a permissions boundary or required policies are configured", func() {
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(simulatedActions()).ToNot(ContainElements("iam:PutRolePermissionsBoundary", "iam:AttachRolePolicy"))
+
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			NodeRolePermissionsBoundary: lo.ToPtr("arn:aws:iam::123456789012:policy/boundary"),
+			NodeRoleRequiredPolicies:    lo.ToPtr("arn:aws:iam::aws:policy/AmazonSSMManagedInstanceCore"),
+		}))
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(simulatedActions()).To(ContainElements("iam:GetRole", "iam:PutRolePermissionsBoundary", "iam:AttachRolePolicy", "iam:ListAttachedRolePolicies"))
+	})
+	It("should only simulate the EBS encryption actions when an EBS encryption policy is configured", func() {
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(simulatedActions()).ToNot(ContainElements("ec2:GetEbsEncryptionByDefault", "ec2:GetEbsDefaultKmsKeyId"))
+
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{EBSEncryptionPolicy: lo.ToPtr(options.EBSEncryptionPolicyEncrypted)}))
+		ExpectSingletonReconciled(ctx, controller)
+		actions := simulatedActions()
+		Expect(actions).To(ContainElement("ec2:GetEbsEncryptionByDefault"))
+		Expect(actions).ToNot(ContainElement("ec2:GetEbsDefaultKmsKeyId"))
+
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{EBSEncryptionPolicy: lo.ToPtr(options.EBSEncryptionPolicyCustomerManagedKey)}))
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(simulatedActions()).To(ContainElements("ec2:GetEbsEncryptionByDefault", "ec2:GetEbsDefaultKmsKeyId"))
+	})
+	It("should only simulate the quota actions when the quota headroom is checked", func() {
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(simulatedActions()).ToNot(ContainElements("servicequotas:GetServiceQuota", "ec2:DescribeNetworkInterfaces"))
+
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{QuotaHeadroomPercent: lo.ToPtr(20)}))
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(simulatedActions()).To(ContainElements("ec2:DescribeAddresses", "ec2:DescribeNetworkInterfaces", "servicequotas:GetAWSDefaultServiceQuota", "servicequotas:GetServiceQuota"))
+	})
+	It("should only simulate the zonal shift actions when zonal shifts are followed", func() {
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(simulatedActions()).ToNot(ContainElement("arc-zonal-shift:GetManagedResource"))
+
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ZonalShift: lo.ToPtr(options.ZonalShiftAvoid)}))
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(simulatedActions()).To(ContainElements("arc-zonal-shift:GetManagedResource", "eks:DescribeCluster"))
+	})
+	It("should not simulate the actions of the controllers that are disabled", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			InterruptionQueue:   lo.ToPtr("test-cluster"),
+			DisabledControllers: lo.ToPtr("interruption,pricing,instance-profile"),
+		}))
+		ExpectSingletonReconciled(ctx, controller)
+		actions := simulatedActions()
+		Expect(actions).ToNot(ContainElements("sqs:ReceiveMessage", "pricing:GetProducts", "ec2:DescribeSpotPriceHistory", "iam:CreateInstanceProfile", "iam:DeleteInstanceProfile"))
+		Expect(actions).To(ContainElements("iam:GetInstanceProfile", "iam:PassRole"))
+	})
+	It("should pass the readiness check when every action is allowed", func() {
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(controller.Check(nil)).To(Succeed())
+
+		m, found := FindMetricWithLabelValues("karpenter_cloudprovider_iam_missing_permissions", map[string]string{"action": "ec2:CreateFleet"})
+		Expect(found).To(BeTrue())
+		Expect(m.GetGauge().GetValue()).To(BeNumerically("==", 0))
+	})
+	It("should fail the readiness check and export the actions that are denied", func() {
+		iamapi.SimulatePrincipalPolicyBehavior.Output.Set(&iam.SimulatePolicyResponse{
+			EvaluationResults: []*iam.EvaluationResult{
+				{EvalActionName: aws.String("ec2:CreateFleet"), EvalDecision: aws.String(iam.PolicyEvaluationDecisionTypeImplicitDeny)},
+				{EvalActionName: aws.String("ec2:RunInstances"), EvalDecision: aws.String(iam.PolicyEvaluationDecisionTypeExplicitDeny)},
+				{EvalActionName: aws.String("ec2:CreateTags"), EvalDecision: aws.String(iam.PolicyEvaluationDecisionTypeAllowed)},
+			},
+		})
+		ExpectSingletonReconciled(ctx, controller)
+		err := controller.Check(nil)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("ec2:CreateFleet, ec2:RunInstances"))
+
+		m, found := FindMetricWithLabelValues("karpenter_cloudprovider_iam_missing_permissions", map[string]string{"action": "ec2:CreateFleet"})
+		Expect(found).To(BeTrue())
+		Expect(m.GetGauge().GetValue()).To(BeNumerically("==", 1))
+		m, found = FindMetricWithLabelValues("karpenter_cloudprovider_iam_missing_permissions", map[string]string{"action": "ec2:CreateTags"})
+		Expect(found).To(BeTrue())
+		Expect(m.GetGauge().GetValue()).To(BeNumerically("==", 0))
+	})
+	It("should pass the readiness check again once the permissions are granted", func() {
+		iamapi.SimulatePrincipalPolicyBehavior.Output.Set(&iam.SimulatePolicyResponse{
+			EvaluationResults: []*iam.EvaluationResult{
+				{EvalActionName: aws.String("ec2:CreateFleet"), EvalDecision: aws.String(iam.PolicyEvaluationDecisionTypeImplicitDeny)},
+			},
+		})
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(controller.Check(nil)).ToNot(Succeed())
+
+		iamapi.SimulatePrincipalPolicyBehavior.Reset()
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(controller.Check(nil)).To(Succeed())
+	})
+	It("should not fail the readiness check when the controller isn't allowed to simulate its policies", func() {
+		iamapi.SimulatePrincipalPolicyBehavior.Error.Set(awserr.New("AccessDenied", "not authorized to perform: iam:SimulatePrincipalPolicy", nil))
+		result := ExpectSingletonReconciled(ctx, controller)
+		Expect(result.RequeueAfter).To(Equal(options.FromContext(ctx).PermissionsCheckPeriod))
+		Expect(controller.Check(nil)).To(Succeed())
+	})
+	It("should not fail the readiness check when the role of the controller can't be found", func() {
+		iamapi.SimulatePrincipalPolicyBehavior.Error.Set(awserr.New(iam.ErrCodeNoSuchEntityException, "The role with name KarpenterControllerRole cannot be found", nil))
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(controller.Check(nil)).To(Succeed())
+	})
+})
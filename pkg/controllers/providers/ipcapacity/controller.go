@@ -0,0 +1,205 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipcapacity
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+)
+
+const pollInterval = time.Minute
+
+// Controller exports the free IP addresses of the subnets selected by EC2NodeClasses and the IP address utilization of
+// each node, and warns when the pod density configured for an EC2NodeClass can't be satisfied by any of its subnets.
+// IP exhaustion otherwise only surfaces as pods that are stuck in ContainerCreating on a node that launched fine.
+type Controller struct {
+	kubeClient     client.Client
+	recorder       events.Recorder
+	subnetProvider subnet.Provider
+}
+
+func NewController(kubeClient client.Client, recorder events.Recorder, subnetProvider subnet.Provider) *Controller {
+	return &Controller{
+		kubeClient:     kubeClient,
+		recorder:       recorder,
+		subnetProvider: subnetProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "providers.ipcapacity")
+
+	if err := c.updateSubnets(ctx); err != nil {
+		return reconcile.Result{}, err
+	}
+	if err := c.updateNodes(ctx); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: pollInterval}, nil
+}
+
+func (c *Controller) updateSubnets(ctx context.Context) error {
+	nodeClassList := &v1.EC2NodeClassList{}
+	if err := c.kubeClient.List(ctx, nodeClassList); err != nil {
+		return fmt.Errorf("listing ec2nodeclasses, %w", err)
+	}
+	subnets := map[string]*ec2.Subnet{}
+	for i := range nodeClassList.Items {
+		nodeClass := &nodeClassList.Items[i]
+		if !nodeClass.DeletionTimestamp.IsZero() {
+			continue
+		}
+		nodeClassSubnets, err := c.subnetProvider.List(assumerole.WithNodeClass(ctx, nodeClass), nodeClass)
+		if err != nil {
+			return fmt.Errorf("listing subnets for ec2nodeclass %s, %w", nodeClass.Name, err)
+		}
+		for _, s := range nodeClassSubnets {
+			subnets[lo.FromPtr(s.SubnetId)] = s
+		}
+		c.checkPodDensity(ctx, nodeClass, nodeClassSubnets)
+		c.checkIPCapacity(ctx, nodeClass)
+	}
+	subnetAvailableIPAddresses.Reset()
+	for id, s := range subnets {
+		subnetAvailableIPAddresses.With(prometheus.Labels{
+			subnetIDLabel: id,
+			zoneLabel:     lo.FromPtr(s.AvailabilityZone),
+		}).Set(float64(lo.FromPtr(s.AvailableIpAddressCount)))
+	}
+	return nil
+}
+
+// checkPodDensity warns when none of the subnets of the EC2NodeClass have enough free IP addresses for a single node at
+// the configured maxPods. Each pod that doesn't use the host network is assigned an address in addition to the primary
+// address of the node.
+func (c *Controller) checkPodDensity(ctx context.Context, nodeClass *v1.EC2NodeClass, subnets []*ec2.Subnet) {
+	if nodeClass.Spec.Kubelet == nil || nodeClass.Spec.Kubelet.MaxPods == nil || len(subnets) == 0 {
+		return
+	}
+	ipsPerNode := int64(lo.FromPtr(nodeClass.Spec.Kubelet.MaxPods)) + 1
+	maxAvailable := lo.Max(lo.Map(subnets, func(s *ec2.Subnet, _ int) int64 { return lo.FromPtr(s.AvailableIpAddressCount) }))
+	if maxAvailable >= ipsPerNode {
+		return
+	}
+	log.FromContext(ctx).WithValues("EC2NodeClass", nodeClass.Name, "ips-per-node", ipsPerNode, "max-available-ips", maxAvailable).
+		Info("subnets don't have enough free IP addresses for the configured pod density")
+	c.recorder.Publish(InsufficientSubnetCapacityEvent(nodeClass, ipsPerNode, maxAvailable))
+}
+
+// checkIPCapacity warns when the configured maxPods is more than the VPC CNI can assign IP addresses to on some instance
+// types, which are excluded from the instance types of the EC2NodeClass
+func (c *Controller) checkIPCapacity(ctx context.Context, nodeClass *v1.EC2NodeClass) {
+	if nodeClass.Spec.VPCCNI == nil || nodeClass.Spec.Kubelet == nil || nodeClass.Spec.Kubelet.MaxPods == nil ||
+		lo.FromPtr(nodeClass.Spec.Kubelet.NodeIPFamily) == v1.NodeIPFamilyIPv6 {
+		return
+	}
+	maxPods := int(lo.FromPtr(nodeClass.Spec.Kubelet.MaxPods))
+	// The 2 host network pods that ENI limited pod density accounts for don't need an IP address
+	excluded := lo.Filter(lo.Keys(instancetype.Limits), func(instanceType string, _ int) bool {
+		capacity, ok := podIPCapacity(ctx, instanceType, nodeClass.Spec.VPCCNI)
+		return ok && capacity+2 < maxPods
+	})
+	if len(excluded) == 0 {
+		return
+	}
+	sort.Strings(excluded)
+	c.recorder.Publish(PodDensityExceedsIPCapacityEvent(nodeClass, maxPods, excluded))
+}
+
+func (c *Controller) updateNodes(ctx context.Context) error {
+	nodeList := &corev1.NodeList{}
+	if err := c.kubeClient.List(ctx, nodeList, client.HasLabels{karpv1.NodePoolLabelKey}); err != nil {
+		return fmt.Errorf("listing nodes, %w", err)
+	}
+	nodeClassList := &v1.EC2NodeClassList{}
+	if err := c.kubeClient.List(ctx, nodeClassList); err != nil {
+		return fmt.Errorf("listing ec2nodeclasses, %w", err)
+	}
+	vpcCNIs := lo.SliceToMap(nodeClassList.Items, func(nc v1.EC2NodeClass) (string, *v1.VPCCNIConfiguration) {
+		return nc.Name, nc.Spec.VPCCNI
+	})
+	podList := &corev1.PodList{}
+	if err := c.kubeClient.List(ctx, podList); err != nil {
+		return fmt.Errorf("listing pods, %w", err)
+	}
+	allocated := map[string]int{}
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Spec.NodeName == "" || pod.Spec.HostNetwork || podutils.IsTerminal(pod) {
+			continue
+		}
+		allocated[pod.Spec.NodeName]++
+	}
+	nodePodIPAddressesAllocated.Reset()
+	nodePodIPAddressesCapacity.Reset()
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		labels := prometheus.Labels{
+			nodeNameLabel:         node.Name,
+			metrics.NodePoolLabel: node.Labels[karpv1.NodePoolLabelKey],
+		}
+		nodePodIPAddressesAllocated.With(labels).Set(float64(allocated[node.Name]))
+		if capacity, ok := podIPCapacity(ctx, node.Labels[corev1.LabelInstanceTypeStable], vpcCNIs[node.Labels[v1.LabelNodeClass]]); ok {
+			nodePodIPAddressesCapacity.With(labels).Set(float64(capacity))
+		}
+	}
+	return nil
+}
+
+// podIPCapacity returns the number of secondary IP addresses that the usable ENIs of the instance type can assign to
+// pods, which matches how the VPC CNI assigns addresses
+func podIPCapacity(ctx context.Context, instanceType string, vpcCNI *v1.VPCCNIConfiguration) (int, bool) {
+	limits, ok := instancetype.Limits[instanceType]
+	if !ok || limits.DefaultNetworkCardIndex >= len(limits.NetworkCards) {
+		return 0, false
+	}
+	interfaces := int(limits.NetworkCards[limits.DefaultNetworkCardIndex].MaximumNetworkInterfaces) - instancetype.ReservedENIs(ctx, instanceType, vpcCNI)
+	addresses := limits.IPv4PerInterface - 1 - int(instancetype.ReservedIPsPerENI(vpcCNI))
+	if vpcCNI != nil && lo.FromPtr(vpcCNI.PrefixDelegation) {
+		// Each secondary address slot is a prefix of 16 addresses
+		addresses *= 16
+	}
+	return lo.Max([]int{interfaces, 0}) * lo.Max([]int{addresses, 0}), true
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("providers.ipcapacity").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
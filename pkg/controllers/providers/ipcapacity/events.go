@@ -0,0 +1,49 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipcapacity
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/karpenter/pkg/events"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+)
+
+func InsufficientSubnetCapacityEvent(nodeClass *v1.EC2NodeClass, ipsPerNode, maxAvailable int64) events.Event {
+	return events.Event{
+		InvolvedObject: nodeClass,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "InsufficientSubnetCapacity",
+		Message: fmt.Sprintf("Nodes with maxPods %d require %d IP addresses but the subnet with the most free IP addresses only has %d, nodes may not be able to run pods at the configured density",
+			ipsPerNode-1, ipsPerNode, maxAvailable),
+		DedupeValues: []string{string(nodeClass.UID)},
+	}
+}
+
+func PodDensityExceedsIPCapacityEvent(nodeClass *v1.EC2NodeClass, maxPods int, instanceTypes []string) events.Event {
+	return events.Event{
+		InvolvedObject: nodeClass,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "PodDensityExceedsIPCapacity",
+		Message: fmt.Sprintf("maxPods %d is more than the VPC CNI can assign IP addresses to on %d instance types, which are excluded, e.g. %s",
+			maxPods, len(instanceTypes), strings.Join(lo.Slice(instanceTypes, 0, 5), ", ")),
+		DedupeValues: []string{string(nodeClass.UID)},
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipcapacity
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	subnetSubsystem = "cloudprovider_subnet"
+
+	subnetIDLabel = "subnet_id"
+	zoneLabel     = "zone"
+	nodeNameLabel = "node_name"
+)
+
+var (
+	subnetAvailableIPAddresses = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: subnetSubsystem,
+			Name:      "available_ip_addresses",
+			Help:      "Number of free IPv4 addresses in a subnet that is selected by an EC2NodeClass. Labeled by subnet and zone.",
+		},
+		[]string{subnetIDLabel, zoneLabel},
+	)
+	nodePodIPAddressesAllocated = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metrics.NodeSubsystem,
+			Name:      "pod_ip_addresses_allocated",
+			Help:      "Number of pods on a node that are assigned an IP address from the VPC, i.e. pods that don't use the host network. Labeled by node and nodepool.",
+		},
+		[]string{nodeNameLabel, metrics.NodePoolLabel},
+	)
+	nodePodIPAddressesCapacity = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metrics.NodeSubsystem,
+			Name:      "pod_ip_addresses_capacity",
+			Help:      "Number of IP addresses that the ENIs of a node can assign to pods, excluding reserved ENIs. Labeled by node and nodepool.",
+		},
+		[]string{nodeNameLabel, metrics.NodePoolLabel},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(subnetAvailableIPAddresses, nodePodIPAddressesAllocated, nodePodIPAddressesCapacity)
+}
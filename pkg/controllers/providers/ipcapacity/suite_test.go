@@ -0,0 +1,216 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipcapacity_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	controllersipcapacity "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/ipcapacity"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var stop context.CancelFunc
+var env *coretest.Environment
+var awsEnv *test.Environment
+var recorder *coretest.EventRecorder
+var controller *controllersipcapacity.Controller
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "IPCapacity")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	ctx, stop = context.WithCancel(ctx)
+	awsEnv = test.NewEnvironment(ctx, env)
+	recorder = coretest.NewEventRecorder()
+	controller = controllersipcapacity.NewController(env.Client, recorder, awsEnv.SubnetProvider)
+})
+
+var _ = AfterSuite(func() {
+	stop()
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+
+	awsEnv.Reset()
+	recorder.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("IPCapacity", func() {
+	var nodeClass *v1.EC2NodeClass
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass()
+	})
+	AfterEach(func() {
+		// EC2NodeClasses aren't removed by ExpectCleanedUp and would otherwise be picked up by later tests
+		ExpectDeleted(ctx, env.Client, nodeClass)
+	})
+	Context("Subnets", func() {
+		It("should export the free IP addresses of the subnets selected by an EC2NodeClass", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			result := ExpectSingletonReconciled(ctx, controller)
+			Expect(result.RequeueAfter).ToNot(BeZero())
+
+			m, found := FindMetricWithLabelValues("karpenter_cloudprovider_subnet_available_ip_addresses", map[string]string{
+				"subnet_id": "subnet-test1",
+				"zone":      "test-zone-1a",
+			})
+			Expect(found).To(BeTrue())
+			Expect(m.GetGauge().GetValue()).To(BeNumerically("==", 100))
+		})
+		It("should remove subnets that are no longer selected", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectSingletonReconciled(ctx, controller)
+			_, found := FindMetricWithLabelValues("karpenter_cloudprovider_subnet_available_ip_addresses", map[string]string{"subnet_id": "subnet-test1"})
+			Expect(found).To(BeTrue())
+
+			ExpectDeleted(ctx, env.Client, nodeClass)
+			ExpectSingletonReconciled(ctx, controller)
+			_, found = FindMetricWithLabelValues("karpenter_cloudprovider_subnet_available_ip_addresses", map[string]string{"subnet_id": "subnet-test1"})
+			Expect(found).To(BeFalse())
+		})
+		It("should warn when no subnet has enough free IP addresses for the configured maxPods", func() {
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{
+				{SubnetId: aws.String("subnet-test1"), AvailabilityZone: aws.String("test-zone-1a"), AvailableIpAddressCount: aws.Int64(20)},
+				{SubnetId: aws.String("subnet-test2"), AvailabilityZone: aws.String("test-zone-1b"), AvailableIpAddressCount: aws.Int64(50)},
+			}})
+			nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{MaxPods: lo.ToPtr[int32](110)}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectSingletonReconciled(ctx, controller)
+			Expect(recorder.Calls("InsufficientSubnetCapacity")).To(Equal(1))
+		})
+		It("should warn when maxPods exceeds the pod IP capacity of instance types of an EC2NodeClass that describes its VPC CNI", func() {
+			nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{MaxPods: lo.ToPtr[int32](29)}
+			nodeClass.Spec.VPCCNI = &v1.VPCCNIConfiguration{}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectSingletonReconciled(ctx, controller)
+			Expect(recorder.Calls("PodDensityExceedsIPCapacity")).To(Equal(1))
+		})
+		It("should not warn about the pod IP capacity when the EC2NodeClass doesn't describe its VPC CNI", func() {
+			nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{MaxPods: lo.ToPtr[int32](29)}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectSingletonReconciled(ctx, controller)
+			Expect(recorder.Calls("PodDensityExceedsIPCapacity")).To(Equal(0))
+		})
+		It("should not warn when a subnet has enough free IP addresses for the configured maxPods", func() {
+			nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{MaxPods: lo.ToPtr[int32](29)}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectSingletonReconciled(ctx, controller)
+			Expect(recorder.Calls("InsufficientSubnetCapacity")).To(Equal(0))
+		})
+	})
+	Context("Nodes", func() {
+		var node *corev1.Node
+		BeforeEach(func() {
+			node = coretest.Node(coretest.NodeOptions{ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					karpv1.NodePoolLabelKey:        "default",
+					corev1.LabelInstanceTypeStable: "m5.large",
+				},
+			}})
+		})
+		It("should export the pod IP addresses allocated on and assignable to a node", func() {
+			hostNetworkPod := coretest.Pod(coretest.PodOptions{NodeName: node.Name})
+			hostNetworkPod.Spec.HostNetwork = true
+			ExpectApplied(ctx, env.Client, node,
+				coretest.Pod(coretest.PodOptions{NodeName: node.Name}),
+				coretest.Pod(coretest.PodOptions{NodeName: node.Name}),
+				coretest.Pod(coretest.PodOptions{NodeName: node.Name, Phase: corev1.PodSucceeded}),
+				hostNetworkPod,
+			)
+			ExpectSingletonReconciled(ctx, controller)
+
+			labels := map[string]string{"node_name": node.Name, "nodepool": "default"}
+			m, found := FindMetricWithLabelValues("karpenter_nodes_pod_ip_addresses_allocated", labels)
+			Expect(found).To(BeTrue())
+			Expect(m.GetGauge().GetValue()).To(BeNumerically("==", 2))
+			// m5.large supports 3 ENIs with 10 IPv4 addresses each, one of which is the primary address of the ENI
+			m, found = FindMetricWithLabelValues("karpenter_nodes_pod_ip_addresses_capacity", labels)
+			Expect(found).To(BeTrue())
+			Expect(m.GetGauge().GetValue()).To(BeNumerically("==", 27))
+		})
+		It("should exclude reserved ENIs from the pod IP address capacity", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ReservedENIs: lo.ToPtr(1)}))
+			ExpectApplied(ctx, env.Client, node)
+			ExpectSingletonReconciled(ctx, controller)
+
+			m, found := FindMetricWithLabelValues("karpenter_nodes_pod_ip_addresses_capacity", map[string]string{"node_name": node.Name})
+			Expect(found).To(BeTrue())
+			Expect(m.GetGauge().GetValue()).To(BeNumerically("==", 18))
+		})
+		It("should exclude the trunk ENI from the pod IP address capacity when security groups for pods are enabled", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{SecurityGroupsForPods: lo.ToPtr(true)}))
+			ExpectApplied(ctx, env.Client, node)
+			ExpectSingletonReconciled(ctx, controller)
+
+			m, found := FindMetricWithLabelValues("karpenter_nodes_pod_ip_addresses_capacity", map[string]string{"node_name": node.Name})
+			Expect(found).To(BeTrue())
+			Expect(m.GetGauge().GetValue()).To(BeNumerically("==", 18))
+		})
+		It("should use the VPC CNI configuration of the EC2NodeClass of a node for the pod IP address capacity", func() {
+			nodeClass.Spec.VPCCNI = &v1.VPCCNIConfiguration{ReservedIPsPerENI: lo.ToPtr[int32](2), CustomNetworking: lo.ToPtr(true)}
+			node.Labels[v1.LabelNodeClass] = nodeClass.Name
+			ExpectApplied(ctx, env.Client, nodeClass, node)
+			ExpectSingletonReconciled(ctx, controller)
+
+			// The primary ENI isn't used with custom networking, and 2 of the 9 secondary addresses of each ENI are reserved
+			m, found := FindMetricWithLabelValues("karpenter_nodes_pod_ip_addresses_capacity", map[string]string{"node_name": node.Name})
+			Expect(found).To(BeTrue())
+			Expect(m.GetGauge().GetValue()).To(BeNumerically("==", 14))
+		})
+		It("should not export a pod IP address capacity for unknown instance types", func() {
+			node.Labels[corev1.LabelInstanceTypeStable] = "unknown.large"
+			ExpectApplied(ctx, env.Client, node)
+			ExpectSingletonReconciled(ctx, controller)
+
+			_, found := FindMetricWithLabelValues("karpenter_nodes_pod_ip_addresses_allocated", map[string]string{"node_name": node.Name})
+			Expect(found).To(BeTrue())
+			_, found = FindMetricWithLabelValues("karpenter_nodes_pod_ip_addresses_capacity", map[string]string{"node_name": node.Name})
+			Expect(found).To(BeFalse())
+		})
+	})
+})
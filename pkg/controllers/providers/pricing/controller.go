@@ -17,7 +17,6 @@ package pricing
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/awslabs/operatorpkg/singleton"
 	lop "github.com/samber/lo/parallel"
@@ -27,6 +26,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
 
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
 )
 
@@ -56,7 +56,7 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 	if err := multierr.Combine(errs...); err != nil {
 		return reconcile.Result{}, fmt.Errorf("updating pricing, %w", err)
 	}
-	return reconcile.Result{RequeueAfter: 12 * time.Hour}, nil
+	return reconcile.Result{RequeueAfter: options.FromContext(ctx).PricingUpdatePeriod}, nil
 }
 
 func (c *Controller) Register(_ context.Context, m manager.Manager) error {
@@ -23,6 +23,7 @@ import (
 	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	awspricing "github.com/aws/aws-sdk-go/service/pricing"
 	"github.com/samber/lo"
@@ -231,6 +232,112 @@ var _ = Describe("Pricing", func() {
 		_, ok := awsEnv.PricingProvider.SpotPrice("c99.large", "test-zone-1b")
 		Expect(ok).To(BeFalse())
 	})
+	Context("Spot Price Trend", func() {
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{SpotPriceTrendWindow: lo.ToPtr(24 * time.Hour)}))
+			awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
+				PriceList: []aws.JSONValue{
+					fake.NewOnDemandPrice("c99.large", 1.50),
+				},
+			})
+		})
+		It("should query the spot price history of the trend window", func() {
+			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+				SpotPriceHistory: []*ec2.SpotPrice{
+					{
+						AvailabilityZone: aws.String("test-zone-1a"),
+						InstanceType:     aws.String("c99.large"),
+						SpotPrice:        aws.String("0.40"),
+						Timestamp:        aws.Time(time.Now()),
+					},
+				},
+			})
+			ExpectSingletonReconciled(ctx, controller)
+			Expect(aws.TimeValue(awsEnv.EC2API.DescribeSpotPriceHistoryInput.Clone().StartTime)).To(BeTemporally("~", time.Now().Add(-24*time.Hour), time.Minute))
+		})
+		It("should price offerings whose price is falling at their average price over the window", func() {
+			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+				SpotPriceHistory: []*ec2.SpotPrice{
+					{
+						AvailabilityZone: aws.String("test-zone-1a"),
+						InstanceType:     aws.String("c99.large"),
+						SpotPrice:        aws.String("0.40"),
+						Timestamp:        aws.Time(time.Now().Add(-12 * time.Hour)),
+					},
+					{
+						AvailabilityZone: aws.String("test-zone-1a"),
+						InstanceType:     aws.String("c99.large"),
+						SpotPrice:        aws.String("1.00"),
+						Timestamp:        aws.Time(time.Now().Add(-30 * time.Hour)),
+					},
+				},
+			})
+			ExpectSingletonReconciled(ctx, controller)
+			price, ok := awsEnv.PricingProvider.SpotPrice("c99.large", "test-zone-1a")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("~", 0.70, 0.001))
+		})
+		It("should price offerings whose price is rising at their latest price projected by the rise", func() {
+			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+				SpotPriceHistory: []*ec2.SpotPrice{
+					{
+						AvailabilityZone: aws.String("test-zone-1a"),
+						InstanceType:     aws.String("c99.large"),
+						SpotPrice:        aws.String("1.00"),
+						Timestamp:        aws.Time(time.Now().Add(-12 * time.Hour)),
+					},
+					{
+						AvailabilityZone: aws.String("test-zone-1a"),
+						InstanceType:     aws.String("c99.large"),
+						SpotPrice:        aws.String("0.40"),
+						Timestamp:        aws.Time(time.Now().Add(-30 * time.Hour)),
+					},
+				},
+			})
+			ExpectSingletonReconciled(ctx, controller)
+			price, ok := awsEnv.PricingProvider.SpotPrice("c99.large", "test-zone-1a")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("~", 1.30, 0.001))
+		})
+		It("should keep the latest price of offerings alongside their trend price", func() {
+			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+				SpotPriceHistory: []*ec2.SpotPrice{
+					{
+						AvailabilityZone: aws.String("test-zone-1a"),
+						InstanceType:     aws.String("c99.large"),
+						SpotPrice:        aws.String("1.00"),
+						Timestamp:        aws.Time(time.Now().Add(-12 * time.Hour)),
+					},
+					{
+						AvailabilityZone: aws.String("test-zone-1a"),
+						InstanceType:     aws.String("c99.large"),
+						SpotPrice:        aws.String("0.40"),
+						Timestamp:        aws.Time(time.Now().Add(-30 * time.Hour)),
+					},
+				},
+			})
+			ExpectSingletonReconciled(ctx, controller)
+			price, ok := awsEnv.PricingProvider.LatestSpotPrice("c99.large", "test-zone-1a")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.00))
+		})
+		It("should price offerings whose price hasn't changed in the window at their latest price", func() {
+			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+				SpotPriceHistory: []*ec2.SpotPrice{
+					{
+						AvailabilityZone: aws.String("test-zone-1a"),
+						InstanceType:     aws.String("c99.large"),
+						SpotPrice:        aws.String("0.40"),
+						Timestamp:        aws.Time(time.Now().Add(-30 * time.Hour)),
+					},
+				},
+			})
+			ExpectSingletonReconciled(ctx, controller)
+			price, ok := awsEnv.PricingProvider.SpotPrice("c99.large", "test-zone-1a")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("~", 0.40, 0.001))
+		})
+	})
 	It("should query for both `Linux/UNIX` and `Linux/UNIX (Amazon VPC)`", func() {
 		// If an account supports EC2 classic, then the non-classic instance types have a product
 		// description of Linux/UNIX (Amazon VPC)
@@ -328,4 +435,25 @@ var _ = Describe("Pricing", func() {
 		Expect(ok).To(BeTrue())
 		Expect(price).To(BeNumerically("==", 1.23))
 	})
+	It("should fall back to the static pricing data of the partition", func() {
+		cnPricingProvider := pricing.NewDefaultProvider(ctx, awsEnv.PricingAPI, awsEnv.EC2API, "cn-northwest-1")
+		price, ok := cnPricingProvider.OnDemandPrice("c3.2xlarge")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", pricing.InitialOnDemandPricesCN["cn-north-1"]["c3.2xlarge"]))
+
+		isoPricingProvider := pricing.NewDefaultProvider(ctx, awsEnv.PricingAPI, awsEnv.EC2API, "us-iso-east-1")
+		price, ok = isoPricingProvider.OnDemandPrice("c3.2xlarge")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", pricing.InitialOnDemandPricesAWS["us-east-1"]["c3.2xlarge"]))
+	})
+	It("should use the pricing API endpoint of the isolated partitions", func() {
+		for region, endpoint := range map[string]string{
+			"us-iso-east-1":  "https://api.pricing.us-iso-east-1.c2s.ic.gov",
+			"us-iso-west-1":  "https://api.pricing.us-iso-east-1.c2s.ic.gov",
+			"us-isob-east-1": "https://api.pricing.us-isob-east-1.sc2s.sgov.gov",
+		} {
+			sess := session.Must(session.NewSession(&aws.Config{Region: aws.String(region)}))
+			Expect(pricing.NewAPI(sess, region).(*awspricing.Pricing).Endpoint).To(Equal(endpoint))
+		}
+	})
 })
@@ -0,0 +1,73 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spotprice
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+)
+
+// Controller refreshes the spot prices of the instance types of the launched spot NodeClaims at the
+// spot-price-refresh-period, since the prices that consolidation compares them by otherwise lag behind their market
+// price until the next update of all spot prices.
+type Controller struct {
+	kubeClient      client.Client
+	pricingProvider pricing.Provider
+}
+
+func NewController(kubeClient client.Client, pricingProvider pricing.Provider) *Controller {
+	return &Controller{
+		kubeClient:      kubeClient,
+		pricingProvider: pricingProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "providers.spotprice")
+
+	nodeClaims := &karpv1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaims, client.MatchingLabels{karpv1.CapacityTypeLabelKey: karpv1.CapacityTypeSpot}); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	instanceTypes := lo.Uniq(lo.FilterMap(nodeClaims.Items, func(nc karpv1.NodeClaim, _ int) (string, bool) {
+		return nc.Labels[corev1.LabelInstanceTypeStable], nc.Labels[corev1.LabelInstanceTypeStable] != ""
+	}))
+	sort.Strings(instanceTypes)
+	if err := c.pricingProvider.UpdateSpotPricingForInstanceTypes(ctx, instanceTypes); err != nil {
+		return reconcile.Result{}, fmt.Errorf("refreshing spot pricing, %w", err)
+	}
+	return reconcile.Result{RequeueAfter: options.FromContext(ctx).SpotPriceRefreshPeriod}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("providers.spotprice").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
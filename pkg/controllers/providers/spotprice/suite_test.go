@@ -0,0 +1,113 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spotprice_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/providers/spotprice"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var awsEnv *test.Environment
+var controller *spotprice.Controller
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SpotPrice")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{SpotPriceRefreshPeriod: lo.ToPtr(10 * time.Minute)}))
+	awsEnv = test.NewEnvironment(ctx, env)
+	controller = spotprice.NewController(env.Client, awsEnv.PricingProvider)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	awsEnv.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("SpotPrice", func() {
+	BeforeEach(func() {
+		// All spot prices are updated first, so that the refreshed prices are used over the static prices
+		awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+			SpotPriceHistory: []*ec2.SpotPrice{
+				{AvailabilityZone: aws.String("test-zone-1a"), InstanceType: aws.String("p3.2xlarge"), SpotPrice: aws.String("1.50"), Timestamp: aws.Time(time.Now().Add(-time.Hour))},
+				{AvailabilityZone: aws.String("test-zone-1a"), InstanceType: aws.String("m5.large"), SpotPrice: aws.String("0.05"), Timestamp: aws.Time(time.Now().Add(-time.Hour))},
+			},
+		})
+		Expect(awsEnv.PricingProvider.UpdateSpotPricing(ctx)).To(Succeed())
+	})
+	It("should refresh the spot prices of the instance types of spot nodeClaims", func() {
+		ExpectApplied(ctx, env.Client, coretest.NodeClaim(karpv1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+			karpv1.CapacityTypeLabelKey:    karpv1.CapacityTypeSpot,
+			corev1.LabelInstanceTypeStable: "p3.2xlarge",
+		}}}), coretest.NodeClaim(karpv1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+			karpv1.CapacityTypeLabelKey:    karpv1.CapacityTypeOnDemand,
+			corev1.LabelInstanceTypeStable: "m5.large",
+		}}}))
+		awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+			SpotPriceHistory: []*ec2.SpotPrice{
+				{AvailabilityZone: aws.String("test-zone-1a"), InstanceType: aws.String("p3.2xlarge"), SpotPrice: aws.String("2.25"), Timestamp: aws.Time(time.Now())},
+			},
+		})
+		result := ExpectSingletonReconciled(ctx, controller)
+		Expect(result.RequeueAfter).To(Equal(10 * time.Minute))
+		Expect(aws.StringValueSlice(awsEnv.EC2API.DescribeSpotPriceHistoryInput.Clone().InstanceTypes)).To(ConsistOf("p3.2xlarge"))
+		price, ok := awsEnv.PricingProvider.SpotPrice("p3.2xlarge", "test-zone-1a")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", 2.25))
+		price, ok = awsEnv.PricingProvider.SpotPrice("m5.large", "test-zone-1a")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", 0.05))
+	})
+	It("should not describe the spot price history without spot nodeClaims", func() {
+		awsEnv.EC2API.DescribeSpotPriceHistoryInput.Reset()
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(awsEnv.EC2API.DescribeSpotPriceHistoryInput.IsNil()).To(BeTrue())
+	})
+})
@@ -0,0 +1,179 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package warmup
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/samber/lo"
+	lop "github.com/samber/lo/parallel"
+	"go.uber.org/multierr"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+)
+
+// Controller warms the instance type, pricing and provider caches for every existing EC2NodeClass once on startup so
+// that the first provisioning decision after a restart doesn't pay the latency of resolving everything from a cold
+// cache. The controller isn't readied until the instance types have been warmed, and it runs on every replica so that
+// a replica that takes over leadership already has warm caches. EC2NodeClasses that fail to warm don't hold back
+// readiness, since their caches are resolved on their first launch instead.
+type Controller struct {
+	kubeClient            client.Client
+	recorder              events.Recorder
+	subnetProvider        subnet.Provider
+	securityGroupProvider securitygroup.Provider
+	amiProvider           amifamily.Provider
+	instanceTypeProvider  instancetype.Provider
+	pricingProvider       pricing.Provider
+	warmed                atomic.Bool
+}
+
+func NewController(kubeClient client.Client, recorder events.Recorder, subnetProvider subnet.Provider, securityGroupProvider securitygroup.Provider,
+	amiProvider amifamily.Provider, instanceTypeProvider instancetype.Provider, pricingProvider pricing.Provider) *Controller {
+	return &Controller{
+		kubeClient:            kubeClient,
+		recorder:              recorder,
+		subnetProvider:        subnetProvider,
+		securityGroupProvider: securityGroupProvider,
+		amiProvider:           amiProvider,
+		instanceTypeProvider:  instanceTypeProvider,
+		pricingProvider:       pricingProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "providers.warmup")
+
+	start := time.Now()
+	// The instance types of the EC2NodeClasses can only be resolved once the instance types and their offerings have
+	// been discovered, which the instance type controller only does on the leader
+	work := []func(ctx context.Context) error{
+		c.instanceTypeProvider.UpdateInstanceTypes,
+		c.instanceTypeProvider.UpdateInstanceTypeOfferings,
+	}
+	errs := make([]error, len(work))
+	lop.ForEach(work, func(f func(ctx context.Context) error, i int) {
+		errs[i] = f(ctx)
+	})
+	if err := multierr.Combine(errs...); err != nil {
+		return reconcile.Result{}, fmt.Errorf("warming instance types, %w", err)
+	}
+	c.warmPricing(ctx)
+	nodeClassList := &v1.EC2NodeClassList{}
+	if err := c.kubeClient.List(ctx, nodeClassList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing ec2nodeclasses, %w", err)
+	}
+	nodeClasses := lo.Filter(lo.ToSlicePtr(nodeClassList.Items), func(nc *v1.EC2NodeClass, _ int) bool {
+		return nc.DeletionTimestamp.IsZero()
+	})
+	failed := lop.Map(nodeClasses, func(nodeClass *v1.EC2NodeClass, _ int) bool {
+		if err := c.warm(ctx, nodeClass); err != nil {
+			log.FromContext(ctx).WithValues("EC2NodeClass", nodeClass.Name).Error(err, "failed warming provider caches")
+			c.recorder.Publish(WarmupFailedEvent(nodeClass, err))
+			return true
+		}
+		return false
+	})
+	c.warmed.Store(true)
+	log.FromContext(ctx).WithValues("ec2nodeclasses", len(nodeClasses), "failed", lo.Count(failed, true), "duration", time.Since(start)).V(1).Info("warmed provider caches")
+	// Warming only needs to happen once on startup, after which the caches are kept warm by normal operation
+	return reconcile.Result{}, nil
+}
+
+// warmPricing updates the on-demand and spot prices. Failures don't hold back the warmup, since the static prices are
+// used until the pricing controller's next update succeeds.
+func (c *Controller) warmPricing(ctx context.Context) {
+	if !options.FromContext(ctx).ControllerEnabled(options.ControllerPricing) {
+		return
+	}
+	work := []func(ctx context.Context) error{
+		c.pricingProvider.UpdateOnDemandPricing,
+		c.pricingProvider.UpdateSpotPricing,
+	}
+	lop.ForEach(work, func(f func(ctx context.Context) error, _ int) {
+		if err := f(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "failed warming pricing")
+		}
+	})
+}
+
+func (c *Controller) warm(ctx context.Context, nodeClass *v1.EC2NodeClass) error {
+	work := []func(context.Context, *v1.EC2NodeClass) error{
+		func(ctx context.Context, nodeClass *v1.EC2NodeClass) error {
+			_, err := c.subnetProvider.List(ctx, nodeClass)
+			return err
+		},
+		func(ctx context.Context, nodeClass *v1.EC2NodeClass) error {
+			_, err := c.securityGroupProvider.List(ctx, nodeClass)
+			return err
+		},
+		func(ctx context.Context, nodeClass *v1.EC2NodeClass) error {
+			_, err := c.amiProvider.List(ctx, nodeClass)
+			return err
+		},
+	}
+	// Instance types can only be resolved once the subnets for the EC2NodeClass have been discovered
+	if len(nodeClass.Status.Subnets) != 0 {
+		work = append(work, func(ctx context.Context, nodeClass *v1.EC2NodeClass) error {
+			_, err := c.instanceTypeProvider.List(ctx, nodeClass.Spec.Kubelet, nodeClass)
+			return err
+		})
+	}
+	errs := make([]error, len(work))
+	lop.ForEach(work, func(f func(context.Context, *v1.EC2NodeClass) error, i int) {
+		errs[i] = f(assumerole.WithNodeClass(ctx, nodeClass), nodeClass)
+	})
+	return multierr.Combine(errs...)
+}
+
+// Check fails until the instance types have been warmed, so that the controller isn't ready to provision with cold caches
+func (c *Controller) Check(_ *http.Request) error {
+	if !c.warmed.Load() {
+		return fmt.Errorf("provider caches haven't been warmed")
+	}
+	return nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	if err := m.AddReadyzCheck("warmup", c.Check); err != nil {
+		return fmt.Errorf("adding readiness check, %w", err)
+	}
+	// Includes a default exponential failure rate limiter of base: time.Millisecond, and max: 1000*time.Second
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("providers.warmup").
+		WithOptions(controller.Options{NeedLeaderElection: lo.ToPtr(false)}).
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
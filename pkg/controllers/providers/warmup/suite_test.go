@@ -0,0 +1,147 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package warmup_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	controllerswarmup "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/warmup"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var stop context.CancelFunc
+var env *coretest.Environment
+var awsEnv *test.Environment
+var controller *controllerswarmup.Controller
+var recorder *coretest.EventRecorder
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Warmup")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	ctx, stop = context.WithCancel(ctx)
+	awsEnv = test.NewEnvironment(ctx, env)
+	recorder = coretest.NewEventRecorder()
+})
+
+var _ = AfterSuite(func() {
+	stop()
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+
+	awsEnv.Reset()
+	recorder.Reset()
+	controller = controllerswarmup.NewController(env.Client, recorder, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceTypesProvider, awsEnv.PricingProvider)
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Warmup", func() {
+	It("should warm the provider caches for all EC2NodeClasses", func() {
+		ExpectApplied(ctx, env.Client, test.EC2NodeClass(), test.EC2NodeClass())
+
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(awsEnv.SubnetCache.ItemCount()).To(BeNumerically(">", 0))
+		Expect(awsEnv.SecurityGroupCache.ItemCount()).To(BeNumerically(">", 0))
+		Expect(awsEnv.EC2Cache.ItemCount()).To(BeNumerically(">", 0))
+		Expect(awsEnv.InstanceTypeCache.ItemCount()).To(BeNumerically(">", 0))
+	})
+	It("should not requeue once the caches are warmed", func() {
+		ExpectApplied(ctx, env.Client, test.EC2NodeClass())
+
+		result := ExpectSingletonReconciled(ctx, controller)
+		Expect(result.RequeueAfter).To(BeZero())
+		Expect(result.Requeue).To(BeFalse())
+	})
+	It("should warm the pricing", func() {
+		now := time.Now()
+		awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+			SpotPriceHistory: []*ec2.SpotPrice{
+				{
+					AvailabilityZone: aws.String("test-zone-1a"),
+					InstanceType:     aws.String("m5.large"),
+					SpotPrice:        aws.String("1.23"),
+					Timestamp:        &now,
+				},
+			},
+		})
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(awsEnv.PricingProvider.SpotLastUpdated()).ToNot(BeZero())
+		price, ok := awsEnv.PricingProvider.SpotPrice("m5.large", "test-zone-1a")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", 1.23))
+	})
+	It("should not fail when the pricing can't be warmed", func() {
+		ExpectApplied(ctx, env.Client, test.EC2NodeClass())
+
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(awsEnv.PricingProvider.SpotLastUpdated()).To(BeZero())
+		Expect(controller.Check(nil)).To(Succeed())
+	})
+	It("should fail and retry when instance types can't be discovered", func() {
+		awsEnv.EC2API.NextError.Set(fmt.Errorf("failed"))
+		ExpectApplied(ctx, env.Client, test.EC2NodeClass())
+
+		Expect(ExpectSingletonReconcileFailed(ctx, controller)).To(HaveOccurred())
+		Expect(controller.Check(nil)).To(HaveOccurred())
+	})
+	It("should be ready when an EC2NodeClass fails to warm", func() {
+		nodeClass := test.EC2NodeClass()
+		ExpectApplied(ctx, env.Client, nodeClass)
+		awsEnv.SSMAPI.WantErr = fmt.Errorf("failed")
+
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(controller.Check(nil)).To(Succeed())
+		Expect(recorder.Calls("WarmupFailed")).To(Equal(1))
+	})
+	It("should only be ready once the caches are warmed", func() {
+		ExpectApplied(ctx, env.Client, test.EC2NodeClass())
+
+		Expect(controller.Check(nil)).To(HaveOccurred())
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(controller.Check(nil)).To(Succeed())
+	})
+})
@@ -0,0 +1,128 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zonalshift
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/arczonalshift"
+	"github.com/aws/aws-sdk-go/service/arczonalshift/arczonalshiftiface"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/awslabs/operatorpkg/singleton"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	"github.com/aws/karpenter-provider-aws/pkg/cache"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+const (
+	// pollPeriod is the period at which the zonal shifts of the cluster are polled. Zonal shifts take effect within
+	// minutes, so this keeps new launches in line with the traffic of the cluster.
+	pollPeriod = 30 * time.Second
+	// autoshiftTTL is the time that a zone stays unavailable for a zonal autoshift, which has no expiry of its own. It's
+	// refreshed on every poll, so it only expires if the controller stops polling.
+	autoshiftTTL = 5 * time.Minute
+)
+
+// Controller follows the zonal shifts and zonal autoshifts of the cluster in Route 53 Application Recovery Controller,
+// and removes the offerings of the zones that the cluster is shifted away from until the shifts end.
+type Controller struct {
+	ec2api               ec2iface.EC2API
+	eksapi               eksiface.EKSAPI
+	zonalshiftapi        arczonalshiftiface.ARCZonalShiftAPI
+	unavailableOfferings *cache.UnavailableOfferings
+
+	clusterARN string
+}
+
+func NewController(ec2api ec2iface.EC2API, eksapi eksiface.EKSAPI, zonalshiftapi arczonalshiftiface.ARCZonalShiftAPI, unavailableOfferings *cache.UnavailableOfferings) *Controller {
+	return &Controller{
+		ec2api:               ec2api,
+		eksapi:               eksapi,
+		zonalshiftapi:        zonalshiftapi,
+		unavailableOfferings: unavailableOfferings,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "providers.zonalshift")
+
+	if c.clusterARN == "" {
+		out, err := c.eksapi.DescribeClusterWithContext(ctx, &eks.DescribeClusterInput{Name: aws.String(options.FromContext(ctx).ClusterName)})
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("describing cluster, %w", err)
+		}
+		c.clusterARN = aws.StringValue(out.Cluster.Arn)
+	}
+	shifts, err := c.shifts(ctx)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	out, err := c.ec2api.DescribeAvailabilityZonesWithContext(ctx, &ec2.DescribeAvailabilityZonesInput{})
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("describing availability zones, %w", err)
+	}
+	// Zonal shifts move traffic away from zone IDs, while offerings are keyed by zone names
+	for _, zone := range out.AvailabilityZones {
+		if until, ok := shifts[aws.StringValue(zone.ZoneId)]; ok {
+			c.unavailableOfferings.MarkZoneUnavailable(ctx, "ZonalShift", aws.StringValue(zone.ZoneName), until)
+		} else {
+			c.unavailableOfferings.MarkZoneAvailable(ctx, aws.StringValue(zone.ZoneName))
+		}
+	}
+	return reconcile.Result{RequeueAfter: pollPeriod}, nil
+}
+
+// shifts returns the IDs of the zones that the cluster is shifted away from, along with the time at which the shifts end
+func (c *Controller) shifts(ctx context.Context) (map[string]time.Time, error) {
+	out, err := c.zonalshiftapi.GetManagedResourceWithContext(ctx, &arczonalshift.GetManagedResourceInput{ResourceIdentifier: aws.String(c.clusterARN)})
+	if err != nil {
+		// The cluster isn't a managed resource of ARC until zonal shift is enabled on it
+		if awserrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("getting zonal shifts of cluster, %w", err)
+	}
+	shifts := map[string]time.Time{}
+	// Shifts that aren't applied are overridden by another shift of the resource
+	for _, shift := range out.ZonalShifts {
+		if aws.StringValue(shift.AppliedStatus) == arczonalshift.AppliedStatusApplied {
+			shifts[aws.StringValue(shift.AwayFrom)] = aws.TimeValue(shift.ExpiryTime)
+		}
+	}
+	for _, autoshift := range out.Autoshifts {
+		if aws.StringValue(autoshift.AppliedStatus) == arczonalshift.AutoshiftAppliedStatusApplied {
+			shifts[aws.StringValue(autoshift.AwayFrom)] = time.Now().Add(autoshiftTTL)
+		}
+	}
+	return shifts, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("providers.zonalshift").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
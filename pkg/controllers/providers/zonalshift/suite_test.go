@@ -0,0 +1,134 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zonalshift_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/arczonalshift"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/providers/zonalshift"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var ec2api *fake.EC2API
+var eksapi *fake.EKSAPI
+var zonalshiftapi *fake.ARCZonalShiftAPI
+var unavailableOfferings *awscache.UnavailableOfferings
+var controller *zonalshift.Controller
+
+func TestZonalShift(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ZonalShift")
+}
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ZonalShift: aws.String(options.ZonalShiftAvoid)}))
+	ec2api = fake.NewEC2API()
+	eksapi = fake.NewEKSAPI()
+	zonalshiftapi = fake.NewARCZonalShiftAPI()
+	unavailableOfferings = awscache.NewUnavailableOfferings()
+	controller = zonalshift.NewController(ec2api, eksapi, zonalshiftapi, unavailableOfferings)
+})
+
+var _ = Describe("ZonalShift", func() {
+	It("should get the zonal shifts of the cluster", func() {
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(zonalshiftapi.GetManagedResourceBehavior.CalledWithInput.Len()).To(Equal(1))
+		Expect(aws.StringValue(zonalshiftapi.GetManagedResourceBehavior.CalledWithInput.Pop().ResourceIdentifier)).
+			To(Equal(fmt.Sprintf("arn:aws:eks:%s:000000000000:cluster/test-cluster", fake.DefaultRegion)))
+	})
+	It("should only describe the cluster once", func() {
+		ExpectSingletonReconciled(ctx, controller)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(eksapi.DescribeClusterBehavior.Calls()).To(Equal(1))
+		Expect(zonalshiftapi.GetManagedResourceBehavior.Calls()).To(Equal(2))
+	})
+	It("should remove the offerings of the zone that an applied zonal shift moves the cluster away from", func() {
+		zonalshiftapi.GetManagedResourceBehavior.Output.Set(&arczonalshift.GetManagedResourceOutput{
+			ZonalShifts: []*arczonalshift.ZonalShiftInResource{{
+				AwayFrom:      aws.String("tstz1-1b"),
+				AppliedStatus: aws.String(arczonalshift.AppliedStatusApplied),
+				ExpiryTime:    aws.Time(time.Now().Add(time.Hour)),
+			}},
+		})
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(unavailableOfferings.IsZoneUnavailable("test-zone-1b")).To(BeTrue())
+		Expect(unavailableOfferings.IsUnavailable("m5.large", "test-zone-1b", "on-demand")).To(BeTrue())
+		Expect(unavailableOfferings.IsZoneUnavailable("test-zone-1a")).To(BeFalse())
+		Expect(unavailableOfferings.IsZoneUnavailable("test-zone-1c")).To(BeFalse())
+	})
+	It("should ignore zonal shifts that aren't applied", func() {
+		zonalshiftapi.GetManagedResourceBehavior.Output.Set(&arczonalshift.GetManagedResourceOutput{
+			ZonalShifts: []*arczonalshift.ZonalShiftInResource{{
+				AwayFrom:      aws.String("tstz1-1b"),
+				AppliedStatus: aws.String(arczonalshift.AppliedStatusNotApplied),
+				ExpiryTime:    aws.Time(time.Now().Add(time.Hour)),
+			}},
+		})
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(unavailableOfferings.IsZoneUnavailable("test-zone-1b")).To(BeFalse())
+	})
+	It("should remove the offerings of the zone that an applied zonal autoshift moves the cluster away from", func() {
+		zonalshiftapi.GetManagedResourceBehavior.Output.Set(&arczonalshift.GetManagedResourceOutput{
+			Autoshifts: []*arczonalshift.AutoshiftInResource{{
+				AwayFrom:      aws.String("tstz1-1c"),
+				AppliedStatus: aws.String(arczonalshift.AutoshiftAppliedStatusApplied),
+				StartTime:     aws.Time(time.Now()),
+			}},
+		})
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(unavailableOfferings.IsZoneUnavailable("test-zone-1c")).To(BeTrue())
+	})
+	It("should return the offerings of the zone once the zonal shift ends", func() {
+		zonalshiftapi.GetManagedResourceBehavior.Output.Set(&arczonalshift.GetManagedResourceOutput{
+			ZonalShifts: []*arczonalshift.ZonalShiftInResource{{
+				AwayFrom:      aws.String("tstz1-1b"),
+				AppliedStatus: aws.String(arczonalshift.AppliedStatusApplied),
+				ExpiryTime:    aws.Time(time.Now().Add(time.Hour)),
+			}},
+		})
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(unavailableOfferings.IsZoneUnavailable("test-zone-1b")).To(BeTrue())
+
+		zonalshiftapi.GetManagedResourceBehavior.Output.Set(&arczonalshift.GetManagedResourceOutput{})
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(unavailableOfferings.IsZoneUnavailable("test-zone-1b")).To(BeFalse())
+	})
+	It("should not remove any offerings when zonal shift isn't enabled on the cluster", func() {
+		zonalshiftapi.GetManagedResourceBehavior.Error.Set(awserr.New(arczonalshift.ErrCodeResourceNotFoundException, "not found", nil))
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(unavailableOfferings.IsZoneUnavailable("test-zone-1a")).To(BeFalse())
+		Expect(unavailableOfferings.IsZoneUnavailable("test-zone-1b")).To(BeFalse())
+	})
+})
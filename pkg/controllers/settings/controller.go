@@ -0,0 +1,122 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/utils/pretty"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+// pollPeriod is the period at which the settings ConfigMap is read. The ConfigMap is polled rather than watched since
+// the controller is only allowed to read ConfigMaps in its own namespace, which the cache of the manager isn't scoped to.
+const pollPeriod = 30 * time.Second
+
+// Controller reloads the reloadable options from the settings-configmap, and records the settings that are active,
+// or why they couldn't be applied, in the annotations of the ConfigMap. It runs on every replica, since every replica
+// reads the options.
+type Controller struct {
+	kubeClient client.Client
+	reader     client.Reader
+	recorder   events.Recorder
+	namespace  string
+	cm         *pretty.ChangeMonitor
+
+	// base are the options that the controller was started with, which the settings are applied on top of
+	base *options.Options
+}
+
+func NewController(ctx context.Context, kubeClient client.Client, reader client.Reader, recorder events.Recorder, namespace string) *Controller {
+	return &Controller{
+		kubeClient: kubeClient,
+		reader:     reader,
+		recorder:   recorder,
+		namespace:  namespace,
+		cm:         pretty.NewChangeMonitor(),
+		base:       lo.ToPtr(*options.FromContext(ctx)),
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "settings")
+
+	configMap := &corev1.ConfigMap{}
+	if err := c.reader.Get(ctx, types.NamespacedName{Namespace: c.namespace, Name: options.FromContext(ctx).SettingsConfigMap}, configMap); err != nil {
+		if !errors.IsNotFound(err) {
+			return reconcile.Result{}, fmt.Errorf("getting settings configmap, %w", err)
+		}
+		// Without the ConfigMap, the controller runs with the options that it was started with
+		if err = options.Reload(ctx, c.base, nil); err != nil {
+			return reconcile.Result{}, fmt.Errorf("reverting settings, %w", err)
+		}
+		if c.cm.HasChanged("settings", map[string]string(nil)) {
+			log.FromContext(ctx).WithValues("configmap", options.FromContext(ctx).SettingsConfigMap).Info("settings configmap not found, using the startup settings")
+		}
+		return reconcile.Result{RequeueAfter: pollPeriod}, nil
+	}
+	stored := configMap.DeepCopy()
+	if err := options.Reload(ctx, c.base, configMap.Data); err != nil {
+		if c.cm.HasChanged("error", err.Error()) {
+			log.FromContext(ctx).WithValues("configmap", configMap.Name).Error(err, "failed reloading settings, keeping the active settings")
+			c.recorder.Publish(InvalidSettingsEvent(configMap, err))
+		}
+		configMap.Annotations = lo.Assign(configMap.Annotations, map[string]string{v1.AnnotationSettingsError: err.Error()})
+	} else {
+		if c.cm.HasChanged("settings", configMap.Data) {
+			log.FromContext(ctx).WithValues("configmap", configMap.Name, "settings", configMap.Data).Info("reloaded settings")
+			c.recorder.Publish(SettingsReloadedEvent(configMap))
+		}
+		c.cm.HasChanged("error", "")
+		applied, err := json.Marshal(lo.Ternary(configMap.Data == nil, map[string]string{}, configMap.Data))
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("marshaling applied settings, %w", err)
+		}
+		configMap.Annotations = lo.OmitByKeys(lo.Assign(configMap.Annotations, map[string]string{v1.AnnotationAppliedSettings: string(applied)}), []string{v1.AnnotationSettingsError})
+	}
+	if !equality.Semantic.DeepEqual(stored.Annotations, configMap.Annotations) {
+		if err := c.kubeClient.Patch(ctx, configMap, client.MergeFrom(stored)); client.IgnoreNotFound(err) != nil {
+			return reconcile.Result{}, fmt.Errorf("patching settings configmap, %w", err)
+		}
+	}
+	return reconcile.Result{RequeueAfter: pollPeriod}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("settings").
+		WithOptions(controller.Options{NeedLeaderElection: lo.ToPtr(false)}).
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
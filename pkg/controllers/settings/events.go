@@ -0,0 +1,43 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package settings
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/karpenter/pkg/events"
+)
+
+func SettingsReloadedEvent(configMap *corev1.ConfigMap) events.Event {
+	return events.Event{
+		InvolvedObject: configMap,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "SettingsReloaded",
+		Message:        "Reloaded the settings of the controller",
+		DedupeValues:   []string{string(configMap.UID), configMap.ResourceVersion},
+	}
+}
+
+func InvalidSettingsEvent(configMap *corev1.ConfigMap, err error) events.Event {
+	return events.Event{
+		InvolvedObject: configMap,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "InvalidSettings",
+		Message:        fmt.Sprintf("Keeping the active settings of the controller, %s", err),
+		DedupeValues:   []string{string(configMap.UID), configMap.ResourceVersion},
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package settings_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/settings"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var kubeClient client.Client
+var recorder *coretest.EventRecorder
+var controller *settings.Controller
+var configMap *corev1.ConfigMap
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Settings")
+}
+
+var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{SettingsConfigMap: lo.ToPtr("karpenter-settings")}))
+	kubeClient = crfake.NewClientBuilder().Build()
+	recorder = coretest.NewEventRecorder()
+	controller = settings.NewController(ctx, kubeClient, kubeClient, recorder, "karpenter")
+	configMap = &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "karpenter-settings", Namespace: "karpenter"},
+		Data:       map[string]string{"reserved-enis": "2", "ami-cache-ttl": "10m"},
+	}
+})
+
+var _ = Describe("Settings", func() {
+	It("should reload the settings of the ConfigMap and record them", func() {
+		Expect(kubeClient.Create(ctx, configMap)).To(Succeed())
+		result := ExpectSingletonReconciled(ctx, controller)
+		Expect(result.RequeueAfter).ToNot(BeZero())
+
+		Expect(options.FromContext(ctx).ReservedENIs).To(Equal(2))
+		Expect(options.FromContext(ctx).AMICacheTTL).To(Equal(10 * time.Minute))
+		Expect(kubeClient.Get(ctx, client.ObjectKeyFromObject(configMap), configMap)).To(Succeed())
+		Expect(configMap.Annotations).To(HaveKeyWithValue(v1.AnnotationAppliedSettings, `{"ami-cache-ttl":"10m","reserved-enis":"2"}`))
+		Expect(configMap.Annotations).ToNot(HaveKey(v1.AnnotationSettingsError))
+		Expect(recorder.Calls("SettingsReloaded")).To(Equal(1))
+	})
+	It("should keep the active settings and record the error when the settings are invalid", func() {
+		Expect(kubeClient.Create(ctx, configMap)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(kubeClient.Get(ctx, client.ObjectKeyFromObject(configMap), configMap)).To(Succeed())
+		configMap.Data = map[string]string{"reserved-enis": "-1"}
+		Expect(kubeClient.Update(ctx, configMap)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(options.FromContext(ctx).ReservedENIs).To(Equal(2))
+		Expect(options.FromContext(ctx).AMICacheTTL).To(Equal(10 * time.Minute))
+		Expect(kubeClient.Get(ctx, client.ObjectKeyFromObject(configMap), configMap)).To(Succeed())
+		Expect(configMap.Annotations).To(HaveKeyWithValue(v1.AnnotationAppliedSettings, `{"ami-cache-ttl":"10m","reserved-enis":"2"}`))
+		Expect(configMap.Annotations).To(HaveKeyWithValue(v1.AnnotationSettingsError, ContainSubstring("reserved-enis cannot be negative")))
+		Expect(recorder.Calls("InvalidSettings")).To(Equal(1))
+	})
+	It("should clear the error once the settings are fixed", func() {
+		configMap.Data = map[string]string{"cluster-name": "other-cluster"}
+		Expect(kubeClient.Create(ctx, configMap)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(kubeClient.Get(ctx, client.ObjectKeyFromObject(configMap), configMap)).To(Succeed())
+		Expect(configMap.Annotations).To(HaveKey(v1.AnnotationSettingsError))
+
+		configMap.Data = map[string]string{"reserved-enis": "1"}
+		Expect(kubeClient.Update(ctx, configMap)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(options.FromContext(ctx).ReservedENIs).To(Equal(1))
+		Expect(kubeClient.Get(ctx, client.ObjectKeyFromObject(configMap), configMap)).To(Succeed())
+		Expect(configMap.Annotations).ToNot(HaveKey(v1.AnnotationSettingsError))
+		Expect(configMap.Annotations).To(HaveKeyWithValue(v1.AnnotationAppliedSettings, `{"reserved-enis":"1"}`))
+	})
+	It("should revert to the startup settings when the ConfigMap is deleted", func() {
+		Expect(kubeClient.Create(ctx, configMap)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(options.FromContext(ctx).ReservedENIs).To(Equal(2))
+
+		Expect(kubeClient.Delete(ctx, configMap)).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(options.FromContext(ctx).ReservedENIs).To(Equal(0))
+		Expect(options.FromContext(ctx).AMICacheTTL).To(Equal(test.Options().AMICacheTTL))
+	})
+})
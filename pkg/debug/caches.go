@@ -0,0 +1,137 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	"k8s.io/utils/clock"
+
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+)
+
+// CacheEntry is a single entry of a provider cache
+type CacheEntry struct {
+	Key        string     `json:"key"`
+	Expiration *time.Time `json:"expiration,omitempty"`
+	Value      any        `json:"value"`
+}
+
+// AMI is the serialized form of an amifamily.AMI, since scheduling requirements can't be marshalled directly
+type AMI struct {
+	Name         string `json:"name"`
+	ID           string `json:"id"`
+	CreationDate string `json:"creationDate"`
+	Requirements string `json:"requirements"`
+}
+
+// PricingSnapshot describes when the pricing data was last refreshed from the AWS APIs. A nil LastUpdated means that
+// the static pricing data compiled into the binary is still in use.
+type PricingSnapshot struct {
+	LastUpdated *time.Time `json:"lastUpdated,omitempty"`
+	Age         string     `json:"age,omitempty"`
+}
+
+type Pricing struct {
+	OnDemand PricingSnapshot `json:"onDemand"`
+	Spot     PricingSnapshot `json:"spot"`
+}
+
+// readOnly rejects any request that isn't a GET before serving the response returned by the passed func
+func readOnly(f func() any) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "only GET requests are supported", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, f())
+	})
+}
+
+// NewCacheHandler serves the unexpired contents of the passed cache, sorted by key. The convert func is used to
+// transform cached values into a form that can be marshalled.
+func NewCacheHandler(c *cache.Cache, convert func(any) any) http.Handler {
+	return readOnly(func() any {
+		entries := lo.MapToSlice(c.Items(), func(k string, item cache.Item) CacheEntry {
+			entry := CacheEntry{Key: k, Value: convert(item.Object)}
+			if item.Expiration > 0 {
+				entry.Expiration = lo.ToPtr(time.Unix(0, item.Expiration))
+			}
+			return entry
+		})
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+		return entries
+	})
+}
+
+// NewAMICacheHandler serves the contents of the AMI provider's cache
+func NewAMICacheHandler(c *cache.Cache) http.Handler {
+	return NewCacheHandler(c, func(v any) any {
+		amis, ok := v.(amifamily.AMIs)
+		if !ok {
+			return v
+		}
+		return lo.Map(amis, func(ami amifamily.AMI, _ int) AMI {
+			return AMI{Name: ami.Name, ID: ami.AmiID, CreationDate: ami.CreationDate, Requirements: ami.Requirements.String()}
+		})
+	})
+}
+
+// NewLaunchTemplateCacheHandler serves the contents of the launch template provider's cache
+func NewLaunchTemplateCacheHandler(c *cache.Cache) http.Handler {
+	// launch templates are cached as *ec2.LaunchTemplate which can be marshalled as-is
+	return NewCacheHandler(c, func(v any) any { return v })
+}
+
+// NewUnavailableOfferingsHandler serves the offerings that are currently excluded from scheduling due to insufficient
+// capacity errors
+func NewUnavailableOfferingsHandler(unavailableOfferings *awscache.UnavailableOfferings) http.Handler {
+	return readOnly(func() any {
+		offerings := unavailableOfferings.List()
+		sort.Slice(offerings, func(i, j int) bool { return offerings[i].Expiration.Before(offerings[j].Expiration) })
+		return lo.Ternary(offerings == nil, []awscache.UnavailableOffering{}, offerings)
+	})
+}
+
+// NewSpotInterruptionHistoryHandler serves the spot pools with recent interruptions, along with the weights that are
+// compared to the spot-interruption-threshold of NodePools, sorted by descending weight
+func NewSpotInterruptionHistoryHandler(spotInterruptions *awscache.SpotInterruptionHistory) http.Handler {
+	return readOnly(func() any {
+		pools := spotInterruptions.List()
+		return lo.Ternary(pools == nil, []awscache.SpotInterruptionPool{}, pools)
+	})
+}
+
+// NewPricingHandler serves the age of the on-demand and spot pricing data
+func NewPricingHandler(pricingProvider pricing.Provider, clk clock.Clock) http.Handler {
+	snapshot := func(t time.Time) PricingSnapshot {
+		if t.IsZero() {
+			return PricingSnapshot{}
+		}
+		return PricingSnapshot{LastUpdated: lo.ToPtr(t), Age: clk.Since(t).Truncate(time.Second).String()}
+	}
+	return readOnly(func() any {
+		return Pricing{
+			OnDemand: snapshot(pricingProvider.OnDemandLastUpdated()),
+			Spot:     snapshot(pricingProvider.SpotLastUpdated()),
+		}
+	})
+}
@@ -0,0 +1,165 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
+
+	"github.com/aws/karpenter-provider-aws/pkg/cache"
+)
+
+// Explanation describes why each instance type is or isn't eligible to launch a pod for a NodePool
+type Explanation struct {
+	NodePool string `json:"nodePool"`
+	// Reasons apply to the NodePool as a whole, so no instance type is eligible if any are present
+	Reasons       []string                  `json:"reasons,omitempty"`
+	InstanceTypes []InstanceTypeExplanation `json:"instanceTypes"`
+}
+
+type InstanceTypeExplanation struct {
+	Name     string   `json:"name"`
+	Eligible bool     `json:"eligible"`
+	Reasons  []string `json:"reasons,omitempty"`
+}
+
+// ExplainHandler explains the instance type exclusions for the pod in the request body when launched by the NodePool in
+// the "nodepool" query parameter, e.g.
+//
+//	kubectl get pod <name> -o json | curl -s --data-binary @- "localhost:<port>/debug/explain?nodepool=default"
+type ExplainHandler struct {
+	kubeClient           client.Client
+	cloudProvider        cloudprovider.CloudProvider
+	unavailableOfferings *cache.UnavailableOfferings
+}
+
+func NewExplainHandler(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, unavailableOfferings *cache.UnavailableOfferings) *ExplainHandler {
+	return &ExplainHandler{
+		kubeClient:           kubeClient,
+		cloudProvider:        cloudProvider,
+		unavailableOfferings: unavailableOfferings,
+	}
+}
+
+func (h *ExplainHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "the pod must be passed in the body of a POST request", http.StatusMethodNotAllowed)
+		return
+	}
+	name := r.URL.Query().Get("nodepool")
+	if name == "" {
+		http.Error(w, "missing query parameter, nodepool", http.StatusBadRequest)
+		return
+	}
+	pod := &corev1.Pod{}
+	if err := json.NewDecoder(r.Body).Decode(pod); err != nil {
+		http.Error(w, fmt.Sprintf("decoding pod, %s", err), http.StatusBadRequest)
+		return
+	}
+	nodePool := &karpv1.NodePool{}
+	if err := h.kubeClient.Get(r.Context(), types.NamespacedName{Name: name}, nodePool); err != nil {
+		http.Error(w, fmt.Sprintf("getting nodepool, %s", err), lo.Ternary(errors.IsNotFound(err), http.StatusNotFound, http.StatusInternalServerError))
+		return
+	}
+	explanation, err := h.Explain(r.Context(), nodePool, pod)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, explanation)
+}
+
+// Explain evaluates the same constraints that are used to select instance types when launching a node. Constraints
+// that don't depend on the instance type (e.g. taints) are reported for the NodePool.
+func (h *ExplainHandler) Explain(ctx context.Context, nodePool *karpv1.NodePool, pod *corev1.Pod) (*Explanation, error) {
+	explanation := &Explanation{NodePool: nodePool.Name}
+	if err := scheduling.Taints(nodePool.Spec.Template.Spec.Taints).Tolerates(pod); err != nil {
+		explanation.Reasons = append(explanation.Reasons, fmt.Sprintf("taints not tolerated, %s", err))
+	}
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodePool.Spec.Template.Spec.Requirements...)
+	requirements.Add(scheduling.NewLabelRequirements(nodePool.Spec.Template.Labels).Values()...)
+	podRequirements := scheduling.NewStrictPodRequirements(pod)
+	if err := requirements.Compatible(podRequirements, scheduling.AllowUndefinedWellKnownLabels); err != nil {
+		explanation.Reasons = append(explanation.Reasons, fmt.Sprintf("incompatible requirements, %s", err))
+	}
+	requirements.Add(podRequirements.Values()...)
+	requests := resources.RequestsForPods(pod)
+
+	instanceTypes, err := h.cloudProvider.GetInstanceTypes(ctx, nodePool)
+	if err != nil {
+		return nil, fmt.Errorf("getting instance types, %w", err)
+	}
+	for _, it := range instanceTypes {
+		reasons := h.explain(it, requirements, requests)
+		explanation.InstanceTypes = append(explanation.InstanceTypes, InstanceTypeExplanation{
+			Name:     it.Name,
+			Eligible: len(reasons) == 0 && len(explanation.Reasons) == 0,
+			Reasons:  reasons,
+		})
+	}
+	sort.Slice(explanation.InstanceTypes, func(i, j int) bool {
+		return explanation.InstanceTypes[i].Name < explanation.InstanceTypes[j].Name
+	})
+	return explanation, nil
+}
+
+func (h *ExplainHandler) explain(it *cloudprovider.InstanceType, requirements scheduling.Requirements, requests corev1.ResourceList) []string {
+	var reasons []string
+	if err := it.Requirements.Intersects(requirements); err != nil {
+		reasons = append(reasons, fmt.Sprintf("requirement mismatch, %s", err))
+	}
+	if !resources.Fits(requests, it.Allocatable()) {
+		reasons = append(reasons, fmt.Sprintf("insufficient resources, requests %s exceed allocatable %s", resources.String(requests), resources.String(it.Allocatable())))
+	}
+	offerings := it.Offerings.Compatible(requirements)
+	if len(offerings) == 0 {
+		return append(reasons, "no offering matches the zone and capacity type requirements")
+	}
+	if len(offerings.Available()) != 0 {
+		return reasons
+	}
+	var ice, unavailable []string
+	for _, o := range offerings {
+		zone := o.Requirements.Get(corev1.LabelTopologyZone).Any()
+		capacityType := o.Requirements.Get(karpv1.CapacityTypeLabelKey).Any()
+		if h.unavailableOfferings.IsUnavailable(it.Name, zone, capacityType) {
+			ice = append(ice, fmt.Sprintf("%s/%s", zone, capacityType))
+		} else {
+			unavailable = append(unavailable, fmt.Sprintf("%s/%s", zone, capacityType))
+		}
+	}
+	if len(ice) != 0 {
+		reasons = append(reasons, fmt.Sprintf("offerings are in the insufficient capacity cache, %s", strings.Join(ice, ", ")))
+	}
+	if len(unavailable) != 0 {
+		reasons = append(reasons, fmt.Sprintf("offerings are unavailable, %s", strings.Join(unavailable, ", ")))
+	}
+	return reasons
+}
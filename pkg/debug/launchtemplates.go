@@ -0,0 +1,128 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
+)
+
+// LaunchTemplateHandler renders the launch templates that would be used to launch a node for the NodePool in the
+// "nodepool" query parameter, one for each set of instance types that resolve to a different AMI or configuration,
+// e.g.
+//
+//	curl -s "localhost:<port>/debug/launch-templates?nodepool=default&capacity-type=spot"
+//
+// The launch templates aren't created. The user data is replaced by its hash since it contains the values of secrets.
+type LaunchTemplateHandler struct {
+	kubeClient             client.Client
+	cloudProvider          cloudprovider.CloudProvider
+	launchTemplateProvider launchtemplate.Provider
+}
+
+func NewLaunchTemplateHandler(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, launchTemplateProvider launchtemplate.Provider) *LaunchTemplateHandler {
+	return &LaunchTemplateHandler{
+		kubeClient:             kubeClient,
+		cloudProvider:          cloudProvider,
+		launchTemplateProvider: launchTemplateProvider,
+	}
+}
+
+func (h *LaunchTemplateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	name := r.URL.Query().Get("nodepool")
+	if name == "" {
+		http.Error(w, "missing query parameter, nodepool", http.StatusBadRequest)
+		return
+	}
+	capacityType := r.URL.Query().Get("capacity-type")
+	if capacityType != "" && capacityType != karpv1.CapacityTypeSpot && capacityType != karpv1.CapacityTypeOnDemand {
+		http.Error(w, fmt.Sprintf("invalid capacity-type %q, must be %s or %s", capacityType, karpv1.CapacityTypeSpot, karpv1.CapacityTypeOnDemand), http.StatusBadRequest)
+		return
+	}
+	nodePool := &karpv1.NodePool{}
+	if err := h.kubeClient.Get(r.Context(), types.NamespacedName{Name: name}, nodePool); err != nil {
+		http.Error(w, fmt.Sprintf("getting nodepool, %s", err), lo.Ternary(errors.IsNotFound(err), http.StatusNotFound, http.StatusInternalServerError))
+		return
+	}
+	launchTemplates, err := h.Render(r.Context(), nodePool, capacityType)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, launchTemplates)
+}
+
+// Render resolves the launch templates for a NodeClaim of the NodePool's template with the instance types that are
+// compatible with the NodePool. The capacity type defaults to spot when the NodePool allows it, matching launches.
+func (h *LaunchTemplateHandler) Render(ctx context.Context, nodePool *karpv1.NodePool, capacityType string) ([]*launchtemplate.RenderedLaunchTemplate, error) {
+	nodeClaim := &karpv1.NodeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: lo.Assign(nodePool.Spec.Template.Labels, map[string]string{karpv1.NodePoolLabelKey: nodePool.Name}),
+		},
+		Spec: nodePool.Spec.Template.Spec,
+	}
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	requirements.Add(scheduling.NewLabelRequirements(nodeClaim.Labels).Values()...)
+	if capacityType == "" {
+		capacityType = lo.Ternary(requirements.Get(karpv1.CapacityTypeLabelKey).Has(karpv1.CapacityTypeSpot), karpv1.CapacityTypeSpot, karpv1.CapacityTypeOnDemand)
+	}
+	if !requirements.Get(karpv1.CapacityTypeLabelKey).Has(capacityType) {
+		return nil, fmt.Errorf("nodepool doesn't allow capacity type %s", capacityType)
+	}
+	requirements[karpv1.CapacityTypeLabelKey] = scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, capacityType)
+
+	nodeClass := &v1.EC2NodeClass{}
+	if err := h.kubeClient.Get(ctx, types.NamespacedName{Name: nodePool.Spec.Template.Spec.NodeClassRef.Name}, nodeClass); err != nil {
+		return nil, fmt.Errorf("getting nodeclass, %w", err)
+	}
+	ctx = assumerole.WithNodeClass(ctx, nodeClass)
+	instanceTypes, err := h.cloudProvider.GetInstanceTypes(ctx, nodePool)
+	if err != nil {
+		return nil, fmt.Errorf("getting instance types, %w", err)
+	}
+	instanceTypes = lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		return it.Requirements.Intersects(requirements) == nil && len(it.Offerings.Available().Compatible(requirements)) != 0
+	})
+	if len(instanceTypes) == 0 {
+		return nil, fmt.Errorf("no instance types with available offerings are compatible with the nodepool")
+	}
+	ctx = instance.WithNodePool(ctx, nodePool)
+	tags, err := instance.Tags(ctx, nodeClass, nodeClaim)
+	if err != nil {
+		return nil, fmt.Errorf("resolving tags, %w", err)
+	}
+	return h.launchTemplateProvider.Render(ctx, nodeClass, nodeClaim, instanceTypes, capacityType, tags)
+}
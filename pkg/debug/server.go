@@ -0,0 +1,81 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Server serves read-only debug endpoints on a dedicated port. It is run by the manager, and since the provider caches
+// are only kept warm on the leader, it is only served by the elected leader. The endpoints aren't authenticated, so the
+// server binds to the loopback address unless configured otherwise.
+type Server struct {
+	address string
+	port    int
+	mux     *http.ServeMux
+}
+
+func NewServer(address string, port int) *Server {
+	return &Server{
+		address: address,
+		port:    port,
+		mux:     http.NewServeMux(),
+	}
+}
+
+// With registers the handler for the passed pattern
+func (s *Server) With(pattern string, handler http.Handler) *Server {
+	s.mux.Handle(pattern, handler)
+	return s
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	server := &http.Server{
+		Addr:              net.JoinHostPort(s.address, strconv.Itoa(s.port)),
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.FromContext(ctx).Error(err, "failed shutting down debug server")
+		}
+	}()
+	log.FromContext(ctx).WithValues("address", s.address, "port", s.port).Info("starting debug server")
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serving debug endpoints, %w", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
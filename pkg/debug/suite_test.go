@@ -0,0 +1,330 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/tools/record"
+	clock "k8s.io/utils/clock/testing"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
+	"github.com/aws/karpenter-provider-aws/pkg/debug"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var awsEnv *test.Environment
+var cloudProvider *cloudprovider.CloudProvider
+
+func TestDebug(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Debug")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
+		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider, awsEnv.AlertTracker, awsEnv.UnavailableOfferingsCache)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Explain", func() {
+	var nodeClass *v1.EC2NodeClass
+	var nodePool *karpv1.NodePool
+	var handler *debug.ExplainHandler
+
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass()
+		nodePool = coretest.NodePool(karpv1.NodePool{
+			Spec: karpv1.NodePoolSpec{
+				Template: karpv1.NodeClaimTemplate{
+					Spec: karpv1.NodeClaimSpec{
+						NodeClassRef: &karpv1.NodeClassReference{
+							Group: object.GVK(nodeClass).Group,
+							Kind:  object.GVK(nodeClass).Kind,
+							Name:  nodeClass.Name,
+						},
+					},
+				},
+			},
+		})
+		handler = debug.NewExplainHandler(env.Client, cloudProvider, awsEnv.UnavailableOfferingsCache)
+		_, err := awsEnv.SubnetProvider.List(ctx, nodeClass) // Hydrate the subnet cache
+		Expect(err).To(BeNil())
+		Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+		Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+	})
+
+	explain := func(pod *corev1.Pod) map[string]debug.InstanceTypeExplanation {
+		GinkgoHelper()
+		explanation, err := handler.Explain(ctx, nodePool, pod)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(explanation.InstanceTypes).ToNot(BeEmpty())
+		return lo.SliceToMap(explanation.InstanceTypes, func(e debug.InstanceTypeExplanation) (string, debug.InstanceTypeExplanation) { return e.Name, e })
+	}
+
+	It("should explain requirement mismatches", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		explanations := explain(coretest.UnschedulablePod(coretest.PodOptions{
+			NodeSelector: map[string]string{corev1.LabelInstanceTypeStable: "m5.large"},
+		}))
+		Expect(explanations["m5.large"].Eligible).To(BeTrue())
+		Expect(explanations["m5.xlarge"].Eligible).To(BeFalse())
+		Expect(explanations["m5.xlarge"].Reasons).To(ContainElement(ContainSubstring("requirement mismatch")))
+	})
+	It("should explain insufficient resources", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		explanations := explain(coretest.UnschedulablePod(coretest.PodOptions{
+			ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4096")}},
+		}))
+		for _, e := range explanations {
+			Expect(e.Eligible).To(BeFalse())
+			Expect(e.Reasons).To(ContainElement(ContainSubstring("insufficient resources")))
+		}
+	})
+	It("should explain offerings that are in the insufficient capacity cache", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		for _, zone := range []string{"test-zone-1a", "test-zone-1b", "test-zone-1c", "test-zone-1a-local"} {
+			awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "InsufficientInstanceCapacity", "m5.large", zone, karpv1.CapacityTypeOnDemand)
+		}
+		explanations := explain(coretest.UnschedulablePod(coretest.PodOptions{
+			NodeSelector: map[string]string{karpv1.CapacityTypeLabelKey: karpv1.CapacityTypeOnDemand},
+		}))
+		Expect(explanations["m5.large"].Eligible).To(BeFalse())
+		Expect(explanations["m5.large"].Reasons).To(ContainElement(ContainSubstring("insufficient capacity cache")))
+		Expect(explanations["m5.xlarge"].Eligible).To(BeTrue())
+	})
+	It("should explain taints that aren't tolerated by the pod", func() {
+		nodePool.Spec.Template.Spec.Taints = []corev1.Taint{{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		explanation, err := handler.Explain(ctx, nodePool, coretest.UnschedulablePod())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(explanation.Reasons).To(ContainElement(ContainSubstring("taints not tolerated")))
+		Expect(lo.SomeBy(explanation.InstanceTypes, func(e debug.InstanceTypeExplanation) bool { return e.Eligible })).To(BeFalse())
+	})
+	It("should serve the explanation over HTTP", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		body := lo.Must(json.Marshal(coretest.UnschedulablePod()))
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/debug/explain?nodepool="+nodePool.Name, bytes.NewReader(body)).WithContext(ctx))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		explanation := &debug.Explanation{}
+		Expect(json.Unmarshal(recorder.Body.Bytes(), explanation)).To(Succeed())
+		Expect(explanation.NodePool).To(Equal(nodePool.Name))
+		Expect(explanation.InstanceTypes).ToNot(BeEmpty())
+	})
+	It("should return not found for a missing NodePool", func() {
+		body := lo.Must(json.Marshal(coretest.UnschedulablePod()))
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/debug/explain?nodepool=missing", bytes.NewReader(body)).WithContext(ctx))
+		Expect(recorder.Code).To(Equal(http.StatusNotFound))
+	})
+	It("should return bad request without a NodePool", func() {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/debug/explain", bytes.NewReader([]byte("{}"))).WithContext(ctx))
+		Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+	})
+})
+
+var _ = Describe("LaunchTemplates", func() {
+	var nodeClass *v1.EC2NodeClass
+	var nodePool *karpv1.NodePool
+	var handler *debug.LaunchTemplateHandler
+
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass()
+		nodePool = coretest.NodePool(karpv1.NodePool{
+			Spec: karpv1.NodePoolSpec{
+				Template: karpv1.NodeClaimTemplate{
+					Spec: karpv1.NodeClaimSpec{
+						NodeClassRef: &karpv1.NodeClassReference{
+							Group: object.GVK(nodeClass).Group,
+							Kind:  object.GVK(nodeClass).Kind,
+							Name:  nodeClass.Name,
+						},
+					},
+				},
+			},
+		})
+		handler = debug.NewLaunchTemplateHandler(env.Client, cloudProvider, awsEnv.LaunchTemplateProvider)
+		_, err := awsEnv.SubnetProvider.List(ctx, nodeClass) // Hydrate the subnet cache
+		Expect(err).To(BeNil())
+		Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+		Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+	})
+
+	It("should render the launch templates without creating them", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		launchTemplates, err := handler.Render(ctx, nodePool, karpv1.CapacityTypeOnDemand)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(launchTemplates).ToNot(BeEmpty())
+		for _, lt := range launchTemplates {
+			Expect(lt.InstanceTypes).ToNot(BeEmpty())
+			Expect(lt.UserDataHash).To(HaveLen(64))
+			Expect(lt.Data.UserData).To(BeNil())
+			Expect(lt.Data.ImageId).ToNot(BeNil())
+			Expect(lt.Data.MetadataOptions).ToNot(BeNil())
+		}
+		Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeZero())
+	})
+	It("should render the launch templates with the role of the EC2NodeClass", func() {
+		awsEnv.SecretsManagerAPI.Secrets["registry"] = "hunter2"
+		nodeClass.Spec.AssumeRoleARN = lo.ToPtr("arn:aws:iam::111122223333:role/KarpenterWorkloadRole")
+		nodeClass.Spec.UserData = lo.ToPtr("password={{resolve:secretsmanager:registry}}")
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		_, err := handler.Render(ctx, nodePool, karpv1.CapacityTypeOnDemand)
+		Expect(err).ToNot(HaveOccurred())
+		_, ok := awsEnv.SecretCache.Get("secretsmanager:registry|arn:aws:iam::111122223333:role/KarpenterWorkloadRole")
+		Expect(ok).To(BeTrue())
+	})
+	It("should reject capacity types that the NodePool doesn't allow", func() {
+		nodePool.Spec.Template.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeOnDemand}}},
+		}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		_, err := handler.Render(ctx, nodePool, karpv1.CapacityTypeSpot)
+		Expect(err).To(HaveOccurred())
+	})
+	It("should return bad request for an invalid capacity type", func() {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/launch-templates?nodepool=default&capacity-type=reserved", nil).WithContext(ctx))
+		Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+	})
+})
+
+var _ = Describe("Caches", func() {
+	get := func(handler http.Handler, v any) {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+		ExpectWithOffset(1, recorder.Code).To(Equal(http.StatusOK))
+		ExpectWithOffset(1, json.Unmarshal(recorder.Body.Bytes(), v)).To(Succeed())
+	}
+	It("should serve the contents of the AMI cache", func() {
+		_, err := awsEnv.AMIProvider.List(ctx, test.EC2NodeClass())
+		Expect(err).ToNot(HaveOccurred())
+		var entries []debug.CacheEntry
+		get(debug.NewAMICacheHandler(awsEnv.EC2Cache), &entries)
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Expiration).ToNot(BeNil())
+		amis := entries[0].Value.([]any)
+		Expect(amis).ToNot(BeEmpty())
+		Expect(amis[0]).To(HaveKey("id"))
+		Expect(amis[0]).To(HaveKey("requirements"))
+	})
+	It("should serve the contents of the launch template cache", func() {
+		awsEnv.LaunchTemplateCache.SetDefault("karpenter.k8s.aws/test", &ec2.LaunchTemplate{LaunchTemplateName: aws.String("karpenter.k8s.aws/test"), LaunchTemplateId: aws.String("lt-123")})
+		var entries []debug.CacheEntry
+		get(debug.NewLaunchTemplateCacheHandler(awsEnv.LaunchTemplateCache), &entries)
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Key).To(Equal("karpenter.k8s.aws/test"))
+		Expect(entries[0].Value).To(HaveKeyWithValue("LaunchTemplateId", "lt-123"))
+	})
+	It("should serve the unavailable offerings", func() {
+		awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "InsufficientInstanceCapacity", "m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)
+		var offerings []awscache.UnavailableOffering
+		get(debug.NewUnavailableOfferingsHandler(awsEnv.UnavailableOfferingsCache), &offerings)
+		Expect(offerings).To(HaveLen(1))
+		Expect(offerings[0].InstanceType).To(Equal("m5.large"))
+		Expect(offerings[0].Zone).To(Equal("test-zone-1a"))
+		Expect(offerings[0].CapacityType).To(Equal(karpv1.CapacityTypeSpot))
+		Expect(offerings[0].Expiration).To(BeTemporally(">", time.Now()))
+	})
+	It("should serve the spot interruption history", func() {
+		awsEnv.SpotInterruptionHistory.Record(ctx, "m5.large", "test-zone-1a")
+		var pools []awscache.SpotInterruptionPool
+		get(debug.NewSpotInterruptionHistoryHandler(awsEnv.SpotInterruptionHistory), &pools)
+		Expect(pools).To(HaveLen(1))
+		Expect(pools[0].InstanceType).To(Equal("m5.large"))
+		Expect(pools[0].Zone).To(Equal("test-zone-1a"))
+		Expect(pools[0].Interruptions).To(Equal(1))
+		Expect(pools[0].Weight).To(BeNumerically("~", 1, 0.01))
+	})
+	It("should serve the age of the pricing data", func() {
+		fakeClock := clock.NewFakeClock(time.Now())
+		handler := debug.NewPricingHandler(awsEnv.PricingProvider, fakeClock)
+		snapshot := debug.Pricing{}
+		get(handler, &snapshot)
+		Expect(snapshot.OnDemand.LastUpdated).To(BeNil())
+		Expect(snapshot.Spot.LastUpdated).To(BeNil())
+
+		now := time.Now()
+		awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+			SpotPriceHistory: []*ec2.SpotPrice{
+				{
+					AvailabilityZone: aws.String("test-zone-1a"),
+					InstanceType:     aws.String("m5.large"),
+					SpotPrice:        aws.String("1.23"),
+					Timestamp:        &now,
+				},
+			},
+		})
+		Expect(awsEnv.PricingProvider.UpdateSpotPricing(ctx)).To(Succeed())
+		fakeClock.SetTime(awsEnv.PricingProvider.SpotLastUpdated().Add(time.Minute))
+		get(handler, &snapshot)
+		Expect(snapshot.OnDemand.LastUpdated).To(BeNil())
+		Expect(snapshot.Spot.LastUpdated).ToNot(BeNil())
+		Expect(snapshot.Spot.Age).To(Equal("1m0s"))
+	})
+	It("should reject requests that aren't a GET", func() {
+		recorder := httptest.NewRecorder()
+		debug.NewUnavailableOfferingsHandler(awsEnv.UnavailableOfferingsCache).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", nil))
+		Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
@@ -0,0 +1,402 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package doctor validates the prerequisites of Karpenter in a cluster, like the permissions of the controller, the
+// wiring of the interruption queue and the discovery tags of subnets and security groups, and reports each problem
+// with the action that resolves it
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/aws/aws-sdk-go/service/eventbridge/eventbridgeiface"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/permissions"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/operator"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+)
+
+type Status string
+
+const (
+	StatusPass Status = "PASS"
+	StatusWarn Status = "WARN"
+	StatusFail Status = "FAIL"
+)
+
+// Result is the outcome of a check. Remediation is the action that resolves a warning or a failure.
+type Result struct {
+	Check       string
+	Status      Status
+	Message     string
+	Remediation string
+}
+
+// Doctor runs the checks of the prerequisites with the credentials and options that it's started with, so it should
+// run with the credentials and options of the controller
+type Doctor struct {
+	kubeClient            client.Client
+	iamapi                iamiface.IAMAPI
+	sqsapi                sqsiface.SQSAPI
+	eksapi                eksiface.EKSAPI
+	eventbridgeapi        eventbridgeiface.EventBridgeAPI
+	permissions           *permissions.Controller
+	subnetProvider        subnet.Provider
+	securityGroupProvider securitygroup.Provider
+	serviceChecks         []operator.ServiceCheck
+	region                string
+}
+
+func New(kubeClient client.Client, stsapi stsiface.STSAPI, iamapi iamiface.IAMAPI, sqsapi sqsiface.SQSAPI, eksapi eksiface.EKSAPI,
+	eventbridgeapi eventbridgeiface.EventBridgeAPI, subnetProvider subnet.Provider, securityGroupProvider securitygroup.Provider,
+	serviceChecks []operator.ServiceCheck, region string) *Doctor {
+	return &Doctor{
+		kubeClient:            kubeClient,
+		iamapi:                iamapi,
+		sqsapi:                sqsapi,
+		eksapi:                eksapi,
+		eventbridgeapi:        eventbridgeapi,
+		permissions:           permissions.NewController(stsapi, iamapi, region),
+		subnetProvider:        subnetProvider,
+		securityGroupProvider: securityGroupProvider,
+		serviceChecks:         serviceChecks,
+		region:                region,
+	}
+}
+
+// Run returns the results of every check. The checks of the EC2NodeClasses are skipped when the AWS services
+// can't be reached, since each of their calls would fail the same way.
+func (d *Doctor) Run(ctx context.Context) []Result {
+	results := d.checkConnectivity(ctx)
+	if Failed(results) {
+		return results
+	}
+	results = append(results, d.checkPermissions(ctx))
+	results = append(results, d.checkInterruptionQueue(ctx))
+	return append(results, d.checkNodeClasses(ctx)...)
+}
+
+func (d *Doctor) checkConnectivity(ctx context.Context) []Result {
+	return lo.Map(d.serviceChecks, func(check operator.ServiceCheck, _ int) Result {
+		name := fmt.Sprintf("connectivity/%s", check.Service)
+		if err := check.Call(ctx); !operator.IsUnreachable(err) {
+			return Result{Check: name, Status: StatusPass, Message: fmt.Sprintf("%s is reachable", check.Endpoint)}
+		}
+		return Result{
+			Check:       name,
+			Status:      lo.Ternary(check.Optional, StatusWarn, StatusFail),
+			Message:     fmt.Sprintf("%s isn't reachable", check.Endpoint),
+			Remediation: fmt.Sprintf("Create a VPC endpoint for com.amazonaws.%s.%s in the VPC of the cluster, or allow egress to %s", d.region, check.Service, check.Endpoint),
+		}
+	})
+}
+
+func (d *Doctor) checkPermissions(ctx context.Context) Result {
+	principal, decisions, err := d.permissions.Simulate(ctx)
+	if awserrors.IsAccessDenied(err) || awserrors.IsNotFound(err) {
+		return Result{
+			Check:       "permissions",
+			Status:      StatusWarn,
+			Message:     fmt.Sprintf("can't simulate the policies of %s, %s", principal, err),
+			Remediation: fmt.Sprintf("Allow iam:SimulatePrincipalPolicy on %s to verify its permissions", principal),
+		}
+	}
+	if err != nil {
+		return Result{Check: "permissions", Status: StatusFail, Message: err.Error()}
+	}
+	if missing := permissions.Missing(decisions); len(missing) != 0 {
+		return Result{
+			Check:       "permissions",
+			Status:      StatusFail,
+			Message:     fmt.Sprintf("%s isn't allowed to call %s", principal, strings.Join(missing, ", ")),
+			Remediation: "Add the actions to the policies of the controller role, as in the CloudFormation template of the getting started guide",
+		}
+	}
+	return Result{Check: "permissions", Status: StatusPass, Message: fmt.Sprintf("%s is allowed to call every required action", principal)}
+}
+
+func (d *Doctor) checkInterruptionQueue(ctx context.Context) Result {
+	queue := options.FromContext(ctx).InterruptionQueue
+	if queue == "" || !options.FromContext(ctx).ControllerEnabled(options.ControllerInterruption) {
+		return Result{
+			Check:       "interruption-queue",
+			Status:      StatusWarn,
+			Message:     "interruption handling is disabled",
+			Remediation: "Create the queue and EventBridge rules of the CloudFormation template of the getting started guide, and set INTERRUPTION_QUEUE to the name of the queue",
+		}
+	}
+	url, err := d.sqsapi.GetQueueUrlWithContext(ctx, &sqs.GetQueueUrlInput{QueueName: aws.String(queue)})
+	if awserrors.IsNotFound(err) {
+		return Result{
+			Check:       "interruption-queue",
+			Status:      StatusFail,
+			Message:     fmt.Sprintf("queue %q doesn't exist in %s", queue, d.region),
+			Remediation: "Create the queue, or set INTERRUPTION_QUEUE to the name of an existing queue",
+		}
+	}
+	if err != nil {
+		return Result{Check: "interruption-queue", Status: StatusFail, Message: fmt.Sprintf("getting url of queue %q, %s", queue, err)}
+	}
+	attributes, err := d.sqsapi.GetQueueAttributesWithContext(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       url.QueueUrl,
+		AttributeNames: aws.StringSlice([]string{sqs.QueueAttributeNameQueueArn, sqs.QueueAttributeNamePolicy}),
+	})
+	if err != nil {
+		return Result{Check: "interruption-queue", Status: StatusFail, Message: fmt.Sprintf("getting attributes of queue %q, %s", queue, err)}
+	}
+	queueARN := aws.StringValue(attributes.Attributes[sqs.QueueAttributeNameQueueArn])
+	if !strings.Contains(aws.StringValue(attributes.Attributes[sqs.QueueAttributeNamePolicy]), "events.amazonaws.com") {
+		return Result{
+			Check:       "interruption-queue",
+			Status:      StatusFail,
+			Message:     fmt.Sprintf("the policy of queue %q doesn't allow EventBridge to send messages", queue),
+			Remediation: fmt.Sprintf("Allow the events.amazonaws.com service principal to call sqs:SendMessage on %s in the policy of the queue", queueARN),
+		}
+	}
+	rules, err := d.eventbridgeapi.ListRuleNamesByTargetWithContext(ctx, &eventbridge.ListRuleNamesByTargetInput{TargetArn: aws.String(queueARN)})
+	if err != nil {
+		return Result{Check: "interruption-queue", Status: StatusFail, Message: fmt.Sprintf("listing rules targeting queue %q, %s", queue, err)}
+	}
+	if len(rules.RuleNames) == 0 {
+		return Result{
+			Check:       "interruption-queue",
+			Status:      StatusFail,
+			Message:     fmt.Sprintf("no EventBridge rules send events to queue %q", queue),
+			Remediation: "Create the EventBridge rules for spot interruptions, rebalance recommendations, scheduled changes and instance state changes with the queue as their target",
+		}
+	}
+	return Result{Check: "interruption-queue", Status: StatusPass, Message: fmt.Sprintf("queue %q receives events from %d EventBridge rules", queue, len(rules.RuleNames))}
+}
+
+func (d *Doctor) checkNodeClasses(ctx context.Context) []Result {
+	nodeClasses := &v1.EC2NodeClassList{}
+	if err := d.kubeClient.List(ctx, nodeClasses); err != nil {
+		return []Result{{Check: "ec2nodeclasses", Status: StatusFail, Message: fmt.Sprintf("listing ec2nodeclasses, %s", err)}}
+	}
+	if len(nodeClasses.Items) == 0 {
+		return []Result{{
+			Check:       "ec2nodeclasses",
+			Status:      StatusWarn,
+			Message:     "there are no ec2nodeclasses",
+			Remediation: "Create an EC2NodeClass, and a NodePool that references it",
+		}}
+	}
+	authenticationMode, err := d.authenticationMode(ctx)
+	if err != nil {
+		return []Result{{Check: "ec2nodeclasses", Status: StatusFail, Message: err.Error()}}
+	}
+	var results []Result
+	for i := range nodeClasses.Items {
+		nodeClass := &nodeClasses.Items[i]
+		results = append(results,
+			d.checkSubnets(ctx, nodeClass),
+			d.checkSecurityGroups(ctx, nodeClass),
+			d.checkRole(ctx, nodeClass, authenticationMode),
+		)
+	}
+	return results
+}
+
+func (d *Doctor) checkSubnets(ctx context.Context, nodeClass *v1.EC2NodeClass) Result {
+	name := fmt.Sprintf("ec2nodeclass/%s/subnets", nodeClass.Name)
+	subnets, err := d.subnetProvider.List(ctx, nodeClass)
+	if err != nil {
+		return Result{Check: name, Status: StatusFail, Message: err.Error()}
+	}
+	if len(subnets) == 0 {
+		return Result{
+			Check:       name,
+			Status:      StatusFail,
+			Message:     "no subnets match the subnetSelectorTerms",
+			Remediation: fmt.Sprintf("Tag the private subnets of the cluster with karpenter.sh/discovery=%s, or update the subnetSelectorTerms", options.FromContext(ctx).ClusterName),
+		}
+	}
+	return Result{Check: name, Status: StatusPass, Message: fmt.Sprintf("%d subnets match the subnetSelectorTerms", len(subnets))}
+}
+
+func (d *Doctor) checkSecurityGroups(ctx context.Context, nodeClass *v1.EC2NodeClass) Result {
+	name := fmt.Sprintf("ec2nodeclass/%s/security-groups", nodeClass.Name)
+	securityGroups, err := d.securityGroupProvider.List(ctx, nodeClass)
+	if err != nil {
+		return Result{Check: name, Status: StatusFail, Message: err.Error()}
+	}
+	if len(securityGroups) == 0 {
+		return Result{
+			Check:       name,
+			Status:      StatusFail,
+			Message:     "no security groups match the securityGroupSelectorTerms",
+			Remediation: fmt.Sprintf("Tag the node security group of the cluster with karpenter.sh/discovery=%s, or update the securityGroupSelectorTerms", options.FromContext(ctx).ClusterName),
+		}
+	}
+	return Result{Check: name, Status: StatusPass, Message: fmt.Sprintf("%d security groups match the securityGroupSelectorTerms", len(securityGroups))}
+}
+
+// checkRole verifies that the role of the nodes exists, and that the role is allowed to join the cluster with an
+// access entry or a mapping in the aws-auth ConfigMap
+func (d *Doctor) checkRole(ctx context.Context, nodeClass *v1.EC2NodeClass, authenticationMode string) Result {
+	name := fmt.Sprintf("ec2nodeclass/%s/role", nodeClass.Name)
+	roleARN, result := d.roleARN(ctx, nodeClass)
+	if result != nil {
+		result.Check = name
+		return *result
+	}
+	if authenticationMode != eks.AuthenticationModeConfigMap {
+		_, err := d.eksapi.DescribeAccessEntryWithContext(ctx, &eks.DescribeAccessEntryInput{
+			ClusterName:  aws.String(options.FromContext(ctx).ClusterName),
+			PrincipalArn: aws.String(roleARN),
+		})
+		if err == nil {
+			return Result{Check: name, Status: StatusPass, Message: fmt.Sprintf("%s has an access entry", roleARN)}
+		}
+		if !awserrors.IsNotFound(err) {
+			return Result{Check: name, Status: StatusFail, Message: fmt.Sprintf("describing access entry of %s, %s", roleARN, err)}
+		}
+		if authenticationMode == eks.AuthenticationModeApi {
+			return Result{
+				Check:       name,
+				Status:      StatusFail,
+				Message:     fmt.Sprintf("%s doesn't have an access entry, so nodes can't join the cluster", roleARN),
+				Remediation: fmt.Sprintf("aws eks create-access-entry --cluster-name %s --principal-arn %s --type EC2_LINUX", options.FromContext(ctx).ClusterName, roleARN),
+			}
+		}
+	}
+	mapped, err := d.mappedInAWSAuth(ctx, roleARN)
+	if err != nil {
+		return Result{Check: name, Status: StatusFail, Message: err.Error()}
+	}
+	if !mapped {
+		return Result{
+			Check:       name,
+			Status:      StatusFail,
+			Message:     fmt.Sprintf("%s doesn't have an access entry or a mapping in the aws-auth ConfigMap, so nodes can't join the cluster", roleARN),
+			Remediation: fmt.Sprintf("Map %s to the system:bootstrappers and system:nodes groups in the aws-auth ConfigMap of kube-system", roleARN),
+		}
+	}
+	return Result{Check: name, Status: StatusPass, Message: fmt.Sprintf("%s is mapped in the aws-auth ConfigMap", roleARN)}
+}
+
+// roleARN returns the ARN of the role of the nodes, or the result of the check when the role or the instance profile
+// doesn't exist
+func (d *Doctor) roleARN(ctx context.Context, nodeClass *v1.EC2NodeClass) (string, *Result) {
+	if nodeClass.Spec.Role != "" {
+		out, err := d.iamapi.GetRoleWithContext(ctx, &iam.GetRoleInput{RoleName: aws.String(nodeClass.Spec.Role)})
+		if awserrors.IsNotFound(err) {
+			return "", &Result{
+				Status:      StatusFail,
+				Message:     fmt.Sprintf("role %q doesn't exist", nodeClass.Spec.Role),
+				Remediation: "Create the node role with the AmazonEKSWorkerNodePolicy, AmazonEKS_CNI_Policy, AmazonEC2ContainerRegistryReadOnly and AmazonSSMManagedInstanceCore policies",
+			}
+		}
+		if err != nil {
+			return "", &Result{Status: StatusFail, Message: fmt.Sprintf("getting role %q, %s", nodeClass.Spec.Role, err)}
+		}
+		return aws.StringValue(out.Role.Arn), nil
+	}
+	profile := aws.StringValue(nodeClass.Spec.InstanceProfile)
+	out, err := d.iamapi.GetInstanceProfileWithContext(ctx, &iam.GetInstanceProfileInput{InstanceProfileName: aws.String(profile)})
+	if awserrors.IsNotFound(err) {
+		return "", &Result{
+			Status:      StatusFail,
+			Message:     fmt.Sprintf("instance profile %q doesn't exist", profile),
+			Remediation: "Create the instance profile with the node role, or set the role of the EC2NodeClass so that Karpenter manages the instance profile",
+		}
+	}
+	if err != nil {
+		return "", &Result{Status: StatusFail, Message: fmt.Sprintf("getting instance profile %q, %s", profile, err)}
+	}
+	if len(out.InstanceProfile.Roles) == 0 {
+		return "", &Result{
+			Status:      StatusFail,
+			Message:     fmt.Sprintf("instance profile %q doesn't have a role", profile),
+			Remediation: fmt.Sprintf("aws iam add-role-to-instance-profile --instance-profile-name %s --role-name <node-role>", profile),
+		}
+	}
+	return aws.StringValue(out.InstanceProfile.Roles[0].Arn), nil
+}
+
+// authenticationMode returns how the cluster authenticates IAM principals. Clusters that were created before access
+// entries don't report a mode, and only use the aws-auth ConfigMap.
+func (d *Doctor) authenticationMode(ctx context.Context) (string, error) {
+	out, err := d.eksapi.DescribeClusterWithContext(ctx, &eks.DescribeClusterInput{Name: aws.String(options.FromContext(ctx).ClusterName)})
+	if err != nil {
+		return "", fmt.Errorf("describing cluster %q, %w", options.FromContext(ctx).ClusterName, err)
+	}
+	if out.Cluster.AccessConfig == nil || out.Cluster.AccessConfig.AuthenticationMode == nil {
+		return eks.AuthenticationModeConfigMap, nil
+	}
+	return aws.StringValue(out.Cluster.AccessConfig.AuthenticationMode), nil
+}
+
+// mappedInAWSAuth returns true if the role is mapped in the aws-auth ConfigMap. The ARNs in the ConfigMap don't
+// include the path of the role, so the roles are compared by name.
+func (d *Doctor) mappedInAWSAuth(ctx context.Context, roleARN string) (bool, error) {
+	configMap := &corev1.ConfigMap{}
+	if err := d.kubeClient.Get(ctx, types.NamespacedName{Namespace: "kube-system", Name: "aws-auth"}, configMap); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	var mappings []roleMapping
+	if err := yaml.Unmarshal([]byte(configMap.Data["mapRoles"]), &mappings); err != nil {
+		return false, fmt.Errorf("parsing mapRoles of the aws-auth ConfigMap, %w", err)
+	}
+	roleName := roleARN[strings.LastIndex(roleARN, "/")+1:]
+	return lo.ContainsBy(mappings, func(m roleMapping) bool {
+		return m.RoleARN[strings.LastIndex(m.RoleARN, "/")+1:] == roleName
+	}), nil
+}
+
+type roleMapping struct {
+	RoleARN string `json:"rolearn"`
+}
+
+// Print writes the results as a table, followed by the remediation of each warning and failure
+func Print(w io.Writer, results []Result) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tCHECK\tMESSAGE")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Status, r.Check, r.Message)
+	}
+	tw.Flush()
+	for _, r := range results {
+		if r.Status != StatusPass && r.Remediation != "" {
+			fmt.Fprintf(w, "\n%s: %s\n", r.Check, r.Remediation)
+		}
+	}
+}
+
+// Failed returns true if any of the checks failed
+func Failed(results []Result) bool {
+	return lo.ContainsBy(results, func(r Result) bool { return r.Status == StatusFail })
+}
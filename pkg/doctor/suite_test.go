@@ -0,0 +1,242 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doctor_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/doctor"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var ec2api *fake.EC2API
+var stsapi *fake.STSAPI
+var iamapi *fake.IAMAPI
+var sqsapi *fake.SQSAPI
+var eksapi *fake.EKSAPI
+var eventbridgeapi *fake.EventBridgeAPI
+var kubeClient client.Client
+var serviceChecks []operator.ServiceCheck
+var nodeClass *v1.EC2NodeClass
+
+func TestDoctor(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Doctor")
+}
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionQueue: lo.ToPtr("Karpenter-cluster-Queue")}))
+	ec2api = fake.NewEC2API()
+	stsapi = &fake.STSAPI{}
+	iamapi = fake.NewIAMAPI()
+	sqsapi = &fake.SQSAPI{}
+	eksapi = fake.NewEKSAPI()
+	eksapi.DescribeClusterBehavior.Output.Set(&eks.DescribeClusterOutput{Cluster: &eks.Cluster{
+		AccessConfig: &eks.AccessConfigResponse{AuthenticationMode: aws.String(eks.AuthenticationModeApiAndConfigMap)},
+	}})
+	eventbridgeapi = &fake.EventBridgeAPI{}
+	serviceChecks = []operator.ServiceCheck{{Service: "ec2", Endpoint: "https://ec2.us-west-2.amazonaws.com", Call: func(context.Context) error { return nil }}}
+	nodeClass = test.EC2NodeClass(v1.EC2NodeClass{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+	kubeClient = crfake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(nodeClass).Build()
+})
+
+func run() map[string]doctor.Result {
+	return lo.KeyBy(doctor.New(
+		kubeClient,
+		stsapi,
+		iamapi,
+		sqsapi,
+		eksapi,
+		eventbridgeapi,
+		subnet.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AvailableIPAddressTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AssociatePublicIPAddressTTL, awscache.DefaultCleanupInterval)),
+		securitygroup.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)),
+		serviceChecks,
+		"us-west-2",
+	).Run(ctx), func(r doctor.Result) string { return r.Check })
+}
+
+var _ = Describe("Doctor", func() {
+	It("should pass every check when the prerequisites are met", func() {
+		results := run()
+		Expect(lo.Keys(results)).To(ConsistOf(
+			"connectivity/ec2",
+			"permissions",
+			"interruption-queue",
+			"ec2nodeclass/default/subnets",
+			"ec2nodeclass/default/security-groups",
+			"ec2nodeclass/default/role",
+		))
+		for _, r := range results {
+			Expect(r.Status).To(Equal(doctor.StatusPass), r.Message)
+		}
+	})
+	It("should only check connectivity when a required service isn't reachable", func() {
+		serviceChecks = append(serviceChecks, operator.ServiceCheck{Service: "sts", Endpoint: "https://sts.us-west-2.amazonaws.com", Call: func(context.Context) error {
+			return awserr.New(request.ErrCodeRequestError, "send request failed", nil)
+		}})
+		results := run()
+		Expect(lo.Keys(results)).To(ConsistOf("connectivity/ec2", "connectivity/sts"))
+		Expect(results["connectivity/sts"].Status).To(Equal(doctor.StatusFail))
+		Expect(results["connectivity/sts"].Remediation).To(ContainSubstring("com.amazonaws.us-west-2.sts"))
+	})
+	It("should warn when an optional service isn't reachable", func() {
+		serviceChecks = append(serviceChecks, operator.ServiceCheck{Service: "pricing", Endpoint: "https://api.pricing.us-east-1.amazonaws.com", Optional: true, Call: func(context.Context) error {
+			return awserr.New(request.ErrCodeRequestError, "send request failed", nil)
+		}})
+		results := run()
+		Expect(results["connectivity/pricing"].Status).To(Equal(doctor.StatusWarn))
+		Expect(results).To(HaveKey("permissions"))
+	})
+	It("should fail with the actions that the controller isn't allowed to call", func() {
+		iamapi.SimulatePrincipalPolicyBehavior.Output.Set(&iam.SimulatePolicyResponse{
+			EvaluationResults: []*iam.EvaluationResult{
+				{EvalActionName: aws.String("ec2:CreateFleet"), EvalDecision: aws.String(iam.PolicyEvaluationDecisionTypeImplicitDeny)},
+				{EvalActionName: aws.String("ec2:RunInstances"), EvalDecision: aws.String(iam.PolicyEvaluationDecisionTypeAllowed)},
+			},
+		})
+		results := run()
+		Expect(results["permissions"].Status).To(Equal(doctor.StatusFail))
+		Expect(results["permissions"].Message).To(ContainSubstring("ec2:CreateFleet"))
+		Expect(results["permissions"].Message).ToNot(ContainSubstring("ec2:RunInstances"))
+	})
+	It("should warn when the policies can't be simulated", func() {
+		iamapi.SimulatePrincipalPolicyBehavior.Error.Set(awserr.New("AccessDenied", "not authorized to perform: iam:SimulatePrincipalPolicy", nil))
+		results := run()
+		Expect(results["permissions"].Status).To(Equal(doctor.StatusWarn))
+		Expect(results["permissions"].Remediation).To(ContainSubstring("arn:aws:iam::123456789012:role/KarpenterControllerRole"))
+	})
+	It("should warn when interruption handling is disabled", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionQueue: lo.ToPtr("")}))
+		Expect(run()["interruption-queue"].Status).To(Equal(doctor.StatusWarn))
+	})
+	It("should fail when the interruption queue doesn't exist", func() {
+		sqsapi.GetQueueURLBehavior.Error.Set(awserr.New(sqs.ErrCodeQueueDoesNotExist, "the queue doesn't exist", nil))
+		Expect(run()["interruption-queue"].Status).To(Equal(doctor.StatusFail))
+	})
+	It("should fail when the policy of the interruption queue doesn't allow EventBridge", func() {
+		sqsapi.GetQueueAttributesBehavior.Output.Set(&sqs.GetQueueAttributesOutput{Attributes: map[string]*string{
+			sqs.QueueAttributeNameQueueArn: aws.String("arn:aws:sqs:us-west-2:000000000000:Karpenter-cluster-Queue"),
+		}})
+		result := run()["interruption-queue"]
+		Expect(result.Status).To(Equal(doctor.StatusFail))
+		Expect(result.Remediation).To(ContainSubstring("events.amazonaws.com"))
+	})
+	It("should fail when no EventBridge rules target the interruption queue", func() {
+		eventbridgeapi.ListRuleNamesByTargetBehavior.Output.Set(&eventbridge.ListRuleNamesByTargetOutput{})
+		Expect(run()["interruption-queue"].Status).To(Equal(doctor.StatusFail))
+		Expect(eventbridgeapi.ListRuleNamesByTargetBehavior.CalledWithInput.Pop().TargetArn).To(Equal(aws.String("arn:aws:sqs:us-west-2:000000000000:Karpenter-cluster-Queue")))
+	})
+	It("should warn when there are no EC2NodeClasses", func() {
+		kubeClient = crfake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+		Expect(run()["ec2nodeclasses"].Status).To(Equal(doctor.StatusWarn))
+	})
+	It("should fail when no subnets or security groups match the selector terms", func() {
+		ec2api.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{})
+		ec2api.DescribeSecurityGroupsOutput.Set(&ec2.DescribeSecurityGroupsOutput{})
+		results := run()
+		Expect(results["ec2nodeclass/default/subnets"].Status).To(Equal(doctor.StatusFail))
+		Expect(results["ec2nodeclass/default/subnets"].Remediation).To(ContainSubstring("karpenter.sh/discovery=test-cluster"))
+		Expect(results["ec2nodeclass/default/security-groups"].Status).To(Equal(doctor.StatusFail))
+	})
+	It("should fail when the node role doesn't exist", func() {
+		iamapi.GetRoleBehavior.Error.Set(awserr.New(iam.ErrCodeNoSuchEntityException, "the role doesn't exist", nil))
+		Expect(run()["ec2nodeclass/default/role"].Status).To(Equal(doctor.StatusFail))
+	})
+	It("should fail when the instance profile doesn't have a role", func() {
+		nodeClass.Spec.Role = ""
+		nodeClass.Spec.InstanceProfile = aws.String("test-profile")
+		kubeClient = crfake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(nodeClass).Build()
+		iamapi.InstanceProfiles["test-profile"] = &iam.InstanceProfile{InstanceProfileName: aws.String("test-profile")}
+		result := run()["ec2nodeclass/default/role"]
+		Expect(result.Status).To(Equal(doctor.StatusFail))
+		Expect(result.Remediation).To(ContainSubstring("add-role-to-instance-profile"))
+	})
+	Context("Cluster Access", func() {
+		BeforeEach(func() {
+			eksapi.DescribeAccessEntryBehavior.Error.Set(awserr.New(eks.ErrCodeResourceNotFoundException, "the access entry doesn't exist", nil))
+		})
+		It("should fail when the node role doesn't have an access entry", func() {
+			eksapi.DescribeClusterBehavior.Output.Set(&eks.DescribeClusterOutput{Cluster: &eks.Cluster{
+				AccessConfig: &eks.AccessConfigResponse{AuthenticationMode: aws.String(eks.AuthenticationModeApi)},
+			}})
+			result := run()["ec2nodeclass/default/role"]
+			Expect(result.Status).To(Equal(doctor.StatusFail))
+			Expect(result.Remediation).To(ContainSubstring("aws eks create-access-entry --cluster-name test-cluster --principal-arn arn:aws:iam::000000000000:role/test-role"))
+		})
+		It("should fall back to the aws-auth ConfigMap without an access entry", func() {
+			kubeClient = crfake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(nodeClass, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "aws-auth"},
+				Data: map[string]string{"mapRoles": `- rolearn: arn:aws:iam::000000000000:role/test-role
+  username: system:node:{{EC2PrivateDNSName}}
+  groups:
+  - system:bootstrappers
+  - system:nodes
+`},
+			}).Build()
+			Expect(run()["ec2nodeclass/default/role"].Status).To(Equal(doctor.StatusPass))
+		})
+		It("should fail when the node role isn't mapped in the aws-auth ConfigMap", func() {
+			eksapi.DescribeClusterBehavior.Output.Set(&eks.DescribeClusterOutput{Cluster: &eks.Cluster{}})
+			result := run()["ec2nodeclass/default/role"]
+			Expect(result.Status).To(Equal(doctor.StatusFail))
+			Expect(result.Remediation).To(ContainSubstring("aws-auth"))
+			// Clusters that don't report an authentication mode don't support access entries
+			Expect(eksapi.DescribeAccessEntryBehavior.CalledWithInput.Len()).To(BeZero())
+		})
+	})
+	It("should print the remediation of each failure", func() {
+		ec2api.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{})
+		results := lo.Values(run())
+		buf := &bytes.Buffer{}
+		doctor.Print(buf, results)
+		Expect(buf.String()).To(MatchRegexp(`FAIL\s+ec2nodeclass/default/subnets\s+no subnets match the subnetSelectorTerms`))
+		Expect(buf.String()).To(ContainSubstring("ec2nodeclass/default/subnets: Tag the private subnets"))
+		Expect(doctor.Failed(results)).To(BeTrue())
+	})
+})
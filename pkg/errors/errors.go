@@ -19,13 +19,17 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/eks"
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/ssm"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 const (
 	launchTemplateNameNotFoundCode = "InvalidLaunchTemplateName.NotFoundException"
+	dryRunOperationCode            = "DryRunOperation"
+	idempotentParameterMismatch    = "IdempotentParameterMismatch"
 )
 
 var (
@@ -34,12 +38,21 @@ var (
 		"InvalidInstanceID.NotFound",
 		launchTemplateNameNotFoundCode,
 		"InvalidLaunchTemplateId.NotFound",
+		"InvalidCapacityReservationId.NotFound",
+		"InvalidKeyPair.NotFound",
 		sqs.ErrCodeQueueDoesNotExist,
 		iam.ErrCodeNoSuchEntityException,
+		eks.ErrCodeResourceNotFoundException,
+		ssm.ErrCodeDoesNotExistException,
 	)
 	alreadyExistsErrorCodes = sets.New[string](
 		iam.ErrCodeEntityAlreadyExistsException,
 	)
+	accessDeniedErrorCodes = sets.New[string](
+		"AccessDenied",
+		"AccessDeniedException",
+		"UnauthorizedOperation",
+	)
 	// unfulfillableCapacityErrorCodes signify that capacity is temporarily unable to be launched
 	unfulfillableCapacityErrorCodes = sets.New[string](
 		"InsufficientInstanceCapacity",
@@ -90,6 +103,40 @@ func IgnoreAlreadyExists(err error) error {
 	return err
 }
 
+// IsAccessDenied returns true if the err is an AWS error (even if it's
+// wrapped) that means the caller isn't authorized to perform the action
+func IsAccessDenied(err error) bool {
+	if err == nil {
+		return false
+	}
+	var awsError awserr.Error
+	if errors.As(err, &awsError) {
+		return accessDeniedErrorCodes.Has(awsError.Code())
+	}
+	return false
+}
+
+// IsDryRun returns true if the err is an AWS error (even if it's
+// wrapped) that means the request would have succeeded, but wasn't
+// made because of a DryRun parameter or dry-run mode
+func IsDryRun(err error) bool {
+	if err == nil {
+		return false
+	}
+	var awsError awserr.Error
+	if errors.As(err, &awsError) {
+		return awsError.Code() == dryRunOperationCode
+	}
+	return false
+}
+
+func IgnoreDryRun(err error) error {
+	if IsDryRun(err) {
+		return nil
+	}
+	return err
+}
+
 // IsUnfulfillableCapacity returns true if the Fleet err means
 // capacity is temporarily unavailable for launching.
 // This could be due to account limits, insufficient ec2 capacity, etc.
@@ -107,3 +154,17 @@ func IsLaunchTemplateNotFound(err error) bool {
 	}
 	return false
 }
+
+// IsIdempotentParameterMismatch returns true if the err is an AWS error (even if it's
+// wrapped) that means the client token of the request was used by a request with
+// different parameters
+func IsIdempotentParameterMismatch(err error) bool {
+	if err == nil {
+		return false
+	}
+	var awsError awserr.Error
+	if errors.As(err, &awsError) {
+		return awsError.Code() == idempotentParameterMismatch
+	}
+	return false
+}
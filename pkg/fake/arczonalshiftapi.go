@@ -0,0 +1,50 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/arczonalshift"
+	"github.com/aws/aws-sdk-go/service/arczonalshift/arczonalshiftiface"
+)
+
+// ARCZonalShiftBehavior must be reset between tests otherwise tests will
+// pollute each other.
+type ARCZonalShiftBehavior struct {
+	GetManagedResourceBehavior MockedFunction[arczonalshift.GetManagedResourceInput, arczonalshift.GetManagedResourceOutput]
+}
+
+type ARCZonalShiftAPI struct {
+	arczonalshiftiface.ARCZonalShiftAPI
+	ARCZonalShiftBehavior
+}
+
+func NewARCZonalShiftAPI() *ARCZonalShiftAPI {
+	return &ARCZonalShiftAPI{}
+}
+
+// Reset must be called between tests otherwise tests will pollute
+// each other.
+func (a *ARCZonalShiftAPI) Reset() {
+	a.GetManagedResourceBehavior.Reset()
+}
+
+func (a *ARCZonalShiftAPI) GetManagedResourceWithContext(_ context.Context, input *arczonalshift.GetManagedResourceInput, _ ...request.Option) (*arczonalshift.GetManagedResourceOutput, error) {
+	return a.GetManagedResourceBehavior.Invoke(input, func(input *arczonalshift.GetManagedResourceInput) (*arczonalshift.GetManagedResourceOutput, error) {
+		return &arczonalshift.GetManagedResourceOutput{Arn: input.ResourceIdentifier}, nil
+	})
+}
@@ -0,0 +1,62 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+)
+
+// AutoScalingBehavior must be reset between tests otherwise tests will
+// pollute each other.
+type AutoScalingBehavior struct {
+	DescribeAutoScalingGroupsBehavior           MockedFunction[autoscaling.DescribeAutoScalingGroupsInput, autoscaling.DescribeAutoScalingGroupsOutput]
+	UpdateAutoScalingGroupBehavior              MockedFunction[autoscaling.UpdateAutoScalingGroupInput, autoscaling.UpdateAutoScalingGroupOutput]
+	TerminateInstanceInAutoScalingGroupBehavior MockedFunction[autoscaling.TerminateInstanceInAutoScalingGroupInput, autoscaling.TerminateInstanceInAutoScalingGroupOutput]
+}
+
+type AutoScalingAPI struct {
+	autoscalingiface.AutoScalingAPI
+	AutoScalingBehavior
+}
+
+// Reset must be called between tests otherwise tests will pollute
+// each other.
+func (s *AutoScalingAPI) Reset() {
+	s.DescribeAutoScalingGroupsBehavior.Reset()
+	s.UpdateAutoScalingGroupBehavior.Reset()
+	s.TerminateInstanceInAutoScalingGroupBehavior.Reset()
+}
+
+func (s *AutoScalingAPI) DescribeAutoScalingGroupsWithContext(_ context.Context, input *autoscaling.DescribeAutoScalingGroupsInput, _ ...request.Option) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
+	return s.DescribeAutoScalingGroupsBehavior.Invoke(input, func(_ *autoscaling.DescribeAutoScalingGroupsInput) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
+		return &autoscaling.DescribeAutoScalingGroupsOutput{}, nil
+	})
+}
+
+func (s *AutoScalingAPI) UpdateAutoScalingGroupWithContext(_ context.Context, input *autoscaling.UpdateAutoScalingGroupInput, _ ...request.Option) (*autoscaling.UpdateAutoScalingGroupOutput, error) {
+	return s.UpdateAutoScalingGroupBehavior.Invoke(input, func(_ *autoscaling.UpdateAutoScalingGroupInput) (*autoscaling.UpdateAutoScalingGroupOutput, error) {
+		return &autoscaling.UpdateAutoScalingGroupOutput{}, nil
+	})
+}
+
+func (s *AutoScalingAPI) TerminateInstanceInAutoScalingGroupWithContext(_ context.Context, input *autoscaling.TerminateInstanceInAutoScalingGroupInput, _ ...request.Option) (*autoscaling.TerminateInstanceInAutoScalingGroupOutput, error) {
+	return s.TerminateInstanceInAutoScalingGroupBehavior.Invoke(input, func(_ *autoscaling.TerminateInstanceInAutoScalingGroupInput) (*autoscaling.TerminateInstanceInAutoScalingGroupOutput, error) {
+		return &autoscaling.TerminateInstanceInAutoScalingGroupOutput{}, nil
+	})
+}
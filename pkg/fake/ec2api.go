@@ -46,25 +46,43 @@ type CapacityPool struct {
 // EC2Behavior must be reset between tests otherwise tests will
 // pollute each other.
 type EC2Behavior struct {
-	DescribeImagesOutput                AtomicPtr[ec2.DescribeImagesOutput]
-	DescribeLaunchTemplatesOutput       AtomicPtr[ec2.DescribeLaunchTemplatesOutput]
-	DescribeSubnetsOutput               AtomicPtr[ec2.DescribeSubnetsOutput]
-	DescribeSecurityGroupsOutput        AtomicPtr[ec2.DescribeSecurityGroupsOutput]
-	DescribeInstanceTypesOutput         AtomicPtr[ec2.DescribeInstanceTypesOutput]
-	DescribeInstanceTypeOfferingsOutput AtomicPtr[ec2.DescribeInstanceTypeOfferingsOutput]
-	DescribeAvailabilityZonesOutput     AtomicPtr[ec2.DescribeAvailabilityZonesOutput]
-	DescribeSpotPriceHistoryInput       AtomicPtr[ec2.DescribeSpotPriceHistoryInput]
-	DescribeSpotPriceHistoryOutput      AtomicPtr[ec2.DescribeSpotPriceHistoryOutput]
-	CreateFleetBehavior                 MockedFunction[ec2.CreateFleetInput, ec2.CreateFleetOutput]
-	TerminateInstancesBehavior          MockedFunction[ec2.TerminateInstancesInput, ec2.TerminateInstancesOutput]
-	DescribeInstancesBehavior           MockedFunction[ec2.DescribeInstancesInput, ec2.DescribeInstancesOutput]
-	CreateTagsBehavior                  MockedFunction[ec2.CreateTagsInput, ec2.CreateTagsOutput]
-	CalledWithCreateLaunchTemplateInput AtomicPtrSlice[ec2.CreateLaunchTemplateInput]
-	CalledWithDescribeImagesInput       AtomicPtrSlice[ec2.DescribeImagesInput]
-	Instances                           sync.Map
-	LaunchTemplates                     sync.Map
-	InsufficientCapacityPools           atomic.Slice[CapacityPool]
-	NextError                           AtomicError
+	DescribeImagesOutput                   AtomicPtr[ec2.DescribeImagesOutput]
+	DescribeLaunchTemplatesOutput          AtomicPtr[ec2.DescribeLaunchTemplatesOutput]
+	DescribeSubnetsOutput                  AtomicPtr[ec2.DescribeSubnetsOutput]
+	DescribeSecurityGroupsOutput           AtomicPtr[ec2.DescribeSecurityGroupsOutput]
+	DescribeRouteTablesOutput              AtomicPtr[ec2.DescribeRouteTablesOutput]
+	DescribeInstanceTypesOutput            AtomicPtr[ec2.DescribeInstanceTypesOutput]
+	DescribeInstanceTypeOfferingsOutput    AtomicPtr[ec2.DescribeInstanceTypeOfferingsOutput]
+	DescribeAvailabilityZonesOutput        AtomicPtr[ec2.DescribeAvailabilityZonesOutput]
+	DescribeSnapshotsOutput                AtomicPtr[ec2.DescribeSnapshotsOutput]
+	DescribeNetworkInterfacesOutput        AtomicPtr[ec2.DescribeNetworkInterfacesOutput]
+	DescribeAddressesOutput                AtomicPtr[ec2.DescribeAddressesOutput]
+	DescribeSpotPriceHistoryInput          AtomicPtr[ec2.DescribeSpotPriceHistoryInput]
+	DescribeSpotPriceHistoryOutput         AtomicPtr[ec2.DescribeSpotPriceHistoryOutput]
+	GetEbsEncryptionByDefaultOutput        AtomicPtr[ec2.GetEbsEncryptionByDefaultOutput]
+	GetEbsDefaultKmsKeyIdOutput            AtomicPtr[ec2.GetEbsDefaultKmsKeyIdOutput]
+	DescribeKeyPairsOutput                 AtomicPtr[ec2.DescribeKeyPairsOutput]
+	CreateFleetBehavior                    MockedFunction[ec2.CreateFleetInput, ec2.CreateFleetOutput]
+	TerminateInstancesBehavior             MockedFunction[ec2.TerminateInstancesInput, ec2.TerminateInstancesOutput]
+	DescribeInstancesBehavior              MockedFunction[ec2.DescribeInstancesInput, ec2.DescribeInstancesOutput]
+	CreateTagsBehavior                     MockedFunction[ec2.CreateTagsInput, ec2.CreateTagsOutput]
+	DescribeLaunchTemplateVersionsBehavior MockedFunction[ec2.DescribeLaunchTemplateVersionsInput, ec2.DescribeLaunchTemplateVersionsOutput]
+	CreateCapacityReservationBehavior      MockedFunction[ec2.CreateCapacityReservationInput, ec2.CreateCapacityReservationOutput]
+	ModifyCapacityReservationBehavior      MockedFunction[ec2.ModifyCapacityReservationInput, ec2.ModifyCapacityReservationOutput]
+	CancelCapacityReservationBehavior      MockedFunction[ec2.CancelCapacityReservationInput, ec2.CancelCapacityReservationOutput]
+	ModifyInstanceAttributeBehavior        MockedFunction[ec2.ModifyInstanceAttributeInput, ec2.ModifyInstanceAttributeOutput]
+	DescribeInstanceAttributeBehavior      MockedFunction[ec2.DescribeInstanceAttributeInput, ec2.DescribeInstanceAttributeOutput]
+	CalledWithCreateLaunchTemplateInput    AtomicPtrSlice[ec2.CreateLaunchTemplateInput]
+	CalledWithDescribeImagesInput          AtomicPtrSlice[ec2.DescribeImagesInput]
+	CalledWithDescribeSnapshotsInput       AtomicPtrSlice[ec2.DescribeSnapshotsInput]
+	Instances                              sync.Map
+	LaunchTemplates                        sync.Map
+	CapacityReservations                   sync.Map
+	TerminationProtectedInstances          sync.Map
+	// Fleets are the outputs of the CreateFleet requests by their client tokens
+	Fleets                    sync.Map
+	InsufficientCapacityPools atomic.Slice[CapacityPool]
+	NextError                 AtomicError
 }
 
 type EC2API struct {
@@ -86,16 +104,30 @@ func (e *EC2API) Reset() {
 	e.DescribeLaunchTemplatesOutput.Reset()
 	e.DescribeSubnetsOutput.Reset()
 	e.DescribeSecurityGroupsOutput.Reset()
+	e.DescribeRouteTablesOutput.Reset()
 	e.DescribeInstanceTypesOutput.Reset()
 	e.DescribeInstanceTypeOfferingsOutput.Reset()
 	e.DescribeAvailabilityZonesOutput.Reset()
+	e.DescribeSnapshotsOutput.Reset()
+	e.DescribeNetworkInterfacesOutput.Reset()
+	e.DescribeAddressesOutput.Reset()
 	e.CreateFleetBehavior.Reset()
 	e.TerminateInstancesBehavior.Reset()
 	e.DescribeInstancesBehavior.Reset()
+	e.DescribeLaunchTemplateVersionsBehavior.Reset()
+	e.CreateCapacityReservationBehavior.Reset()
+	e.ModifyCapacityReservationBehavior.Reset()
+	e.CancelCapacityReservationBehavior.Reset()
+	e.ModifyInstanceAttributeBehavior.Reset()
+	e.DescribeInstanceAttributeBehavior.Reset()
 	e.CalledWithCreateLaunchTemplateInput.Reset()
 	e.CalledWithDescribeImagesInput.Reset()
+	e.CalledWithDescribeSnapshotsInput.Reset()
 	e.DescribeSpotPriceHistoryInput.Reset()
 	e.DescribeSpotPriceHistoryOutput.Reset()
+	e.GetEbsEncryptionByDefaultOutput.Reset()
+	e.GetEbsDefaultKmsKeyIdOutput.Reset()
+	e.DescribeKeyPairsOutput.Reset()
 	e.Instances.Range(func(k, v any) bool {
 		e.Instances.Delete(k)
 		return true
@@ -104,6 +136,18 @@ func (e *EC2API) Reset() {
 		e.LaunchTemplates.Delete(k)
 		return true
 	})
+	e.CapacityReservations.Range(func(k, v any) bool {
+		e.CapacityReservations.Delete(k)
+		return true
+	})
+	e.TerminationProtectedInstances.Range(func(k, v any) bool {
+		e.TerminationProtectedInstances.Delete(k)
+		return true
+	})
+	e.Fleets.Range(func(k, v any) bool {
+		e.Fleets.Delete(k)
+		return true
+	})
 	e.InsufficientCapacityPools.Reset()
 	e.NextError.Reset()
 }
@@ -114,6 +158,9 @@ func (e *EC2API) CreateFleetWithContext(_ context.Context, input *ec2.CreateFlee
 		if input.LaunchTemplateConfigs[0].LaunchTemplateSpecification.LaunchTemplateName == nil {
 			return nil, fmt.Errorf("missing launch template name")
 		}
+		if output, ok := e.Fleets.Load(aws.StringValue(input.ClientToken)); ok {
+			return output.(*ec2.CreateFleetOutput), nil
+		}
 		var instanceIds []*string
 		var skippedPools []CapacityPool
 		var spotInstanceRequestID *string
@@ -192,6 +239,9 @@ func (e *EC2API) CreateFleetWithContext(_ context.Context, input *ec2.CreateFlee
 				},
 			})
 		}
+		if input.ClientToken != nil {
+			e.Fleets.Store(aws.StringValue(input.ClientToken), result)
+		}
 		return result, nil
 	})
 }
@@ -293,6 +343,11 @@ func filterInstances(instances []*ec2.Instance, filters []*ec2.Filter) []*ec2.In
 					passesFilter = false
 					break OUTER
 				}
+			case aws.StringValue(filter.Name) == "instance-lifecycle":
+				if !sets.New(aws.StringValueSlice(filter.Values)...).Has(aws.StringValue(instance.InstanceLifecycle)) {
+					passesFilter = false
+					break OUTER
+				}
 			case aws.StringValue(filter.Name) == "tag-key":
 				values := sets.New(aws.StringValueSlice(filter.Values)...)
 				if _, ok := lo.Find(instance.Tags, func(t *ec2.Tag) bool {
@@ -361,6 +416,22 @@ func (e *EC2API) DescribeImagesPagesWithContext(ctx context.Context, input *ec2.
 	return nil
 }
 
+func (e *EC2API) DescribeSnapshotsPagesWithContext(_ context.Context, input *ec2.DescribeSnapshotsInput, fn func(*ec2.DescribeSnapshotsOutput, bool) bool, _ ...request.Option) error {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return e.NextError.Get()
+	}
+	e.CalledWithDescribeSnapshotsInput.Add(input)
+	if !e.DescribeSnapshotsOutput.IsNil() {
+		describeSnapshotsOutput := e.DescribeSnapshotsOutput.Clone()
+		describeSnapshotsOutput.Snapshots = FilterDescribeSnapshots(describeSnapshotsOutput.Snapshots, input.OwnerIds, input.Filters)
+		fn(describeSnapshotsOutput, false)
+		return nil
+	}
+	fn(&ec2.DescribeSnapshotsOutput{}, false)
+	return nil
+}
+
 func (e *EC2API) DescribeLaunchTemplatesWithContext(_ context.Context, input *ec2.DescribeLaunchTemplatesInput, _ ...request.Option) (*ec2.DescribeLaunchTemplatesOutput, error) {
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
@@ -372,12 +443,14 @@ func (e *EC2API) DescribeLaunchTemplatesWithContext(_ context.Context, input *ec
 	output := &ec2.DescribeLaunchTemplatesOutput{}
 	e.LaunchTemplates.Range(func(key, value interface{}) bool {
 		launchTemplate := value.(*ec2.LaunchTemplate)
-		if lo.Contains(aws.StringValueSlice(input.LaunchTemplateNames), aws.StringValue(launchTemplate.LaunchTemplateName)) || len(input.Filters) != 0 && Filter(input.Filters, aws.StringValue(launchTemplate.LaunchTemplateId), aws.StringValue(launchTemplate.LaunchTemplateName), launchTemplate.Tags) {
+		if len(input.LaunchTemplateNames) == 0 && len(input.Filters) == 0 ||
+			lo.Contains(aws.StringValueSlice(input.LaunchTemplateNames), aws.StringValue(launchTemplate.LaunchTemplateName)) ||
+			len(input.Filters) != 0 && Filter(input.Filters, aws.StringValue(launchTemplate.LaunchTemplateId), aws.StringValue(launchTemplate.LaunchTemplateName), launchTemplate.Tags) {
 			output.LaunchTemplates = append(output.LaunchTemplates, launchTemplate)
 		}
 		return true
 	})
-	if len(input.Filters) != 0 {
+	if len(input.LaunchTemplateNames) == 0 {
 		return output, nil
 	}
 	if len(output.LaunchTemplates) == 0 {
@@ -395,6 +468,36 @@ func (e *EC2API) DescribeLaunchTemplatesPagesWithContext(ctx context.Context, in
 	return nil
 }
 
+func (e *EC2API) DescribeNetworkInterfacesPagesWithContext(_ context.Context, _ *ec2.DescribeNetworkInterfacesInput, fn func(*ec2.DescribeNetworkInterfacesOutput, bool) bool, _ ...request.Option) error {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return e.NextError.Get()
+	}
+	if !e.DescribeNetworkInterfacesOutput.IsNil() {
+		fn(e.DescribeNetworkInterfacesOutput.Clone(), false)
+		return nil
+	}
+	fn(&ec2.DescribeNetworkInterfacesOutput{}, false)
+	return nil
+}
+
+func (e *EC2API) DescribeAddressesWithContext(_ context.Context, _ *ec2.DescribeAddressesInput, _ ...request.Option) (*ec2.DescribeAddressesOutput, error) {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return nil, e.NextError.Get()
+	}
+	if !e.DescribeAddressesOutput.IsNil() {
+		return e.DescribeAddressesOutput.Clone(), nil
+	}
+	return &ec2.DescribeAddressesOutput{}, nil
+}
+
+func (e *EC2API) DescribeLaunchTemplateVersionsWithContext(_ context.Context, input *ec2.DescribeLaunchTemplateVersionsInput, _ ...request.Option) (*ec2.DescribeLaunchTemplateVersionsOutput, error) {
+	return e.DescribeLaunchTemplateVersionsBehavior.Invoke(input, func(_ *ec2.DescribeLaunchTemplateVersionsInput) (*ec2.DescribeLaunchTemplateVersionsOutput, error) {
+		return &ec2.DescribeLaunchTemplateVersionsOutput{}, nil
+	})
+}
+
 func (e *EC2API) DeleteLaunchTemplateWithContext(_ context.Context, input *ec2.DeleteLaunchTemplateInput, _ ...request.Option) (*ec2.DeleteLaunchTemplateOutput, error) {
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
@@ -473,7 +576,7 @@ func (e *EC2API) DescribeSecurityGroupsWithContext(_ context.Context, input *ec2
 	if !e.DescribeSecurityGroupsOutput.IsNil() {
 		describeSecurityGroupsOutput := e.DescribeSecurityGroupsOutput.Clone()
 		describeSecurityGroupsOutput.SecurityGroups = FilterDescribeSecurtyGroups(describeSecurityGroupsOutput.SecurityGroups, input.Filters)
-		return e.DescribeSecurityGroupsOutput.Clone(), nil
+		return describeSecurityGroupsOutput, nil
 	}
 	sgs := []*ec2.SecurityGroup{
 		{
@@ -508,6 +611,61 @@ func (e *EC2API) DescribeSecurityGroupsWithContext(_ context.Context, input *ec2
 	return &ec2.DescribeSecurityGroupsOutput{SecurityGroups: FilterDescribeSecurtyGroups(sgs, input.Filters)}, nil
 }
 
+func (e *EC2API) DescribeRouteTablesPagesWithContext(_ context.Context, _ *ec2.DescribeRouteTablesInput, fn func(*ec2.DescribeRouteTablesOutput, bool) bool, _ ...request.Option) error {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return e.NextError.Get()
+	}
+	if !e.DescribeRouteTablesOutput.IsNil() {
+		fn(e.DescribeRouteTablesOutput.Clone(), false)
+		return nil
+	}
+	fn(&ec2.DescribeRouteTablesOutput{}, false)
+	return nil
+}
+
+func (e *EC2API) GetEbsEncryptionByDefaultWithContext(_ context.Context, _ *ec2.GetEbsEncryptionByDefaultInput, _ ...request.Option) (*ec2.GetEbsEncryptionByDefaultOutput, error) {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return nil, e.NextError.Get()
+	}
+	if !e.GetEbsEncryptionByDefaultOutput.IsNil() {
+		return e.GetEbsEncryptionByDefaultOutput.Clone(), nil
+	}
+	return &ec2.GetEbsEncryptionByDefaultOutput{EbsEncryptionByDefault: aws.Bool(false)}, nil
+}
+
+func (e *EC2API) GetEbsDefaultKmsKeyIdWithContext(_ context.Context, _ *ec2.GetEbsDefaultKmsKeyIdInput, _ ...request.Option) (*ec2.GetEbsDefaultKmsKeyIdOutput, error) {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return nil, e.NextError.Get()
+	}
+	if !e.GetEbsDefaultKmsKeyIdOutput.IsNil() {
+		return e.GetEbsDefaultKmsKeyIdOutput.Clone(), nil
+	}
+	return &ec2.GetEbsDefaultKmsKeyIdOutput{KmsKeyId: aws.String("alias/aws/ebs")}, nil
+}
+
+// DescribeKeyPairsWithContext returns the key pairs of DescribeKeyPairsOutput with the requested names, and fails like
+// EC2 does when one of them doesn't exist
+func (e *EC2API) DescribeKeyPairsWithContext(_ context.Context, input *ec2.DescribeKeyPairsInput, _ ...request.Option) (*ec2.DescribeKeyPairsOutput, error) {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return nil, e.NextError.Get()
+	}
+	output := lo.FromPtrOr(e.DescribeKeyPairsOutput.Clone(), ec2.DescribeKeyPairsOutput{})
+	if len(input.KeyNames) == 0 {
+		return &output, nil
+	}
+	keyPairs := lo.Filter(output.KeyPairs, func(k *ec2.KeyPairInfo, _ int) bool {
+		return lo.Contains(aws.StringValueSlice(input.KeyNames), aws.StringValue(k.KeyName))
+	})
+	if len(keyPairs) != len(input.KeyNames) {
+		return nil, awserr.New("InvalidKeyPair.NotFound", "The key pair does not exist", nil)
+	}
+	return &ec2.DescribeKeyPairsOutput{KeyPairs: keyPairs}, nil
+}
+
 func (e *EC2API) DescribeAvailabilityZonesWithContext(context.Context, *ec2.DescribeAvailabilityZonesInput, ...request.Option) (*ec2.DescribeAvailabilityZonesOutput, error) {
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
@@ -704,3 +862,95 @@ func (e *EC2API) DescribeSpotPriceHistoryPagesWithContext(ctx aws.Context, input
 	fn(out, false)
 	return nil
 }
+
+func (e *EC2API) CreateCapacityReservationWithContext(_ context.Context, input *ec2.CreateCapacityReservationInput, _ ...request.Option) (*ec2.CreateCapacityReservationOutput, error) {
+	return e.CreateCapacityReservationBehavior.Invoke(input, func(input *ec2.CreateCapacityReservationInput) (*ec2.CreateCapacityReservationOutput, error) {
+		reservation := &ec2.CapacityReservation{
+			CapacityReservationId:  aws.String(fmt.Sprintf("cr-%s", CapacityReservationID())),
+			InstanceType:           input.InstanceType,
+			InstancePlatform:       input.InstancePlatform,
+			AvailabilityZone:       input.AvailabilityZone,
+			TotalInstanceCount:     input.InstanceCount,
+			AvailableInstanceCount: input.InstanceCount,
+			InstanceMatchCriteria:  input.InstanceMatchCriteria,
+			EndDateType:            input.EndDateType,
+			State:                  aws.String(ec2.CapacityReservationStateActive),
+			Tags: lo.FlatMap(input.TagSpecifications, func(s *ec2.TagSpecification, _ int) []*ec2.Tag {
+				return s.Tags
+			}),
+		}
+		e.CapacityReservations.Store(aws.StringValue(reservation.CapacityReservationId), reservation)
+		return &ec2.CreateCapacityReservationOutput{CapacityReservation: reservation}, nil
+	})
+}
+
+func (e *EC2API) ModifyCapacityReservationWithContext(_ context.Context, input *ec2.ModifyCapacityReservationInput, _ ...request.Option) (*ec2.ModifyCapacityReservationOutput, error) {
+	return e.ModifyCapacityReservationBehavior.Invoke(input, func(input *ec2.ModifyCapacityReservationInput) (*ec2.ModifyCapacityReservationOutput, error) {
+		raw, ok := e.CapacityReservations.Load(aws.StringValue(input.CapacityReservationId))
+		if !ok {
+			return nil, awserr.New("InvalidCapacityReservationId.NotFound", "", nil)
+		}
+		reservation := raw.(*ec2.CapacityReservation)
+		reservation.TotalInstanceCount = input.InstanceCount
+		reservation.AvailableInstanceCount = input.InstanceCount
+		return &ec2.ModifyCapacityReservationOutput{Return: aws.Bool(true)}, nil
+	})
+}
+
+func (e *EC2API) CancelCapacityReservationWithContext(_ context.Context, input *ec2.CancelCapacityReservationInput, _ ...request.Option) (*ec2.CancelCapacityReservationOutput, error) {
+	return e.CancelCapacityReservationBehavior.Invoke(input, func(input *ec2.CancelCapacityReservationInput) (*ec2.CancelCapacityReservationOutput, error) {
+		raw, ok := e.CapacityReservations.Load(aws.StringValue(input.CapacityReservationId))
+		if !ok {
+			return nil, awserr.New("InvalidCapacityReservationId.NotFound", "", nil)
+		}
+		raw.(*ec2.CapacityReservation).State = aws.String(ec2.CapacityReservationStateCancelled)
+		return &ec2.CancelCapacityReservationOutput{Return: aws.Bool(true)}, nil
+	})
+}
+
+func (e *EC2API) DescribeCapacityReservationsPagesWithContext(_ context.Context, input *ec2.DescribeCapacityReservationsInput, fn func(*ec2.DescribeCapacityReservationsOutput, bool) bool, _ ...request.Option) error {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return e.NextError.Get()
+	}
+	var reservations []*ec2.CapacityReservation
+	e.CapacityReservations.Range(func(_, v any) bool {
+		reservations = append(reservations, v.(*ec2.CapacityReservation))
+		return true
+	})
+	fn(&ec2.DescribeCapacityReservationsOutput{CapacityReservations: FilterDescribeCapacityReservations(reservations, input.Filters)}, false)
+	return nil
+}
+
+func (e *EC2API) ModifyInstanceAttributeWithContext(_ context.Context, input *ec2.ModifyInstanceAttributeInput, _ ...request.Option) (*ec2.ModifyInstanceAttributeOutput, error) {
+	return e.ModifyInstanceAttributeBehavior.Invoke(input, func(input *ec2.ModifyInstanceAttributeInput) (*ec2.ModifyInstanceAttributeOutput, error) {
+		raw, ok := e.Instances.Load(aws.StringValue(input.InstanceId))
+		if !ok {
+			return nil, awserr.New("InvalidInstanceID.NotFound", "", nil)
+		}
+		if input.SourceDestCheck != nil {
+			raw.(*ec2.Instance).SourceDestCheck = input.SourceDestCheck.Value
+		}
+		if input.DisableApiTermination != nil {
+			if aws.BoolValue(input.DisableApiTermination.Value) {
+				e.TerminationProtectedInstances.Store(aws.StringValue(input.InstanceId), true)
+			} else {
+				e.TerminationProtectedInstances.Delete(aws.StringValue(input.InstanceId))
+			}
+		}
+		return &ec2.ModifyInstanceAttributeOutput{}, nil
+	})
+}
+
+func (e *EC2API) DescribeInstanceAttributeWithContext(_ context.Context, input *ec2.DescribeInstanceAttributeInput, _ ...request.Option) (*ec2.DescribeInstanceAttributeOutput, error) {
+	return e.DescribeInstanceAttributeBehavior.Invoke(input, func(input *ec2.DescribeInstanceAttributeInput) (*ec2.DescribeInstanceAttributeOutput, error) {
+		if _, ok := e.Instances.Load(aws.StringValue(input.InstanceId)); !ok {
+			return nil, awserr.New("InvalidInstanceID.NotFound", "", nil)
+		}
+		_, protected := e.TerminationProtectedInstances.Load(aws.StringValue(input.InstanceId))
+		return &ec2.DescribeInstanceAttributeOutput{
+			InstanceId:            input.InstanceId,
+			DisableApiTermination: &ec2.AttributeBooleanValue{Value: aws.Bool(protected)},
+		}, nil
+	})
+}
@@ -16,7 +16,9 @@ package fake
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/eks"
 	"github.com/aws/aws-sdk-go/service/eks/eksiface"
@@ -28,7 +30,10 @@ const ()
 // EKSAPIBehavior must be reset between tests otherwise tests will
 // pollute each other.
 type EKSAPIBehavior struct {
-	DescribeClusterBehavior MockedFunction[eks.DescribeClusterInput, eks.DescribeClusterOutput]
+	DescribeClusterBehavior       MockedFunction[eks.DescribeClusterInput, eks.DescribeClusterOutput]
+	DescribeAccessEntryBehavior   MockedFunction[eks.DescribeAccessEntryInput, eks.DescribeAccessEntryOutput]
+	DescribeNodegroupBehavior     MockedFunction[eks.DescribeNodegroupInput, eks.DescribeNodegroupOutput]
+	UpdateNodegroupConfigBehavior MockedFunction[eks.UpdateNodegroupConfigInput, eks.UpdateNodegroupConfigOutput]
 }
 
 type EKSAPI struct {
@@ -44,12 +49,17 @@ func NewEKSAPI() *EKSAPI {
 // each other.
 func (s *EKSAPI) Reset() {
 	s.DescribeClusterBehavior.Reset()
+	s.DescribeAccessEntryBehavior.Reset()
+	s.DescribeNodegroupBehavior.Reset()
+	s.UpdateNodegroupConfigBehavior.Reset()
 }
 
 func (s *EKSAPI) DescribeClusterWithContext(_ context.Context, input *eks.DescribeClusterInput, _ ...request.Option) (*eks.DescribeClusterOutput, error) {
-	return s.DescribeClusterBehavior.Invoke(input, func(*eks.DescribeClusterInput) (*eks.DescribeClusterOutput, error) {
+	return s.DescribeClusterBehavior.Invoke(input, func(input *eks.DescribeClusterInput) (*eks.DescribeClusterOutput, error) {
 		return &eks.DescribeClusterOutput{
 			Cluster: &eks.Cluster{
+				Arn:  lo.ToPtr(fmt.Sprintf("arn:aws:eks:%s:000000000000:cluster/%s", DefaultRegion, aws.StringValue(input.Name))),
+				Name: input.Name,
 				KubernetesNetworkConfig: &eks.KubernetesNetworkConfigResponse{
 					ServiceIpv4Cidr: lo.ToPtr("10.100.0.0/16"),
 				},
@@ -57,3 +67,32 @@ func (s *EKSAPI) DescribeClusterWithContext(_ context.Context, input *eks.Descri
 		}, nil
 	})
 }
+
+func (s *EKSAPI) DescribeAccessEntryWithContext(_ context.Context, input *eks.DescribeAccessEntryInput, _ ...request.Option) (*eks.DescribeAccessEntryOutput, error) {
+	return s.DescribeAccessEntryBehavior.Invoke(input, func(input *eks.DescribeAccessEntryInput) (*eks.DescribeAccessEntryOutput, error) {
+		return &eks.DescribeAccessEntryOutput{
+			AccessEntry: &eks.AccessEntry{
+				ClusterName:  input.ClusterName,
+				PrincipalArn: input.PrincipalArn,
+				Type:         lo.ToPtr("EC2_LINUX"),
+			},
+		}, nil
+	})
+}
+
+func (s *EKSAPI) DescribeNodegroupWithContext(_ context.Context, input *eks.DescribeNodegroupInput, _ ...request.Option) (*eks.DescribeNodegroupOutput, error) {
+	return s.DescribeNodegroupBehavior.Invoke(input, func(input *eks.DescribeNodegroupInput) (*eks.DescribeNodegroupOutput, error) {
+		return &eks.DescribeNodegroupOutput{
+			Nodegroup: &eks.Nodegroup{
+				ClusterName:   input.ClusterName,
+				NodegroupName: input.NodegroupName,
+			},
+		}, nil
+	})
+}
+
+func (s *EKSAPI) UpdateNodegroupConfigWithContext(_ context.Context, input *eks.UpdateNodegroupConfigInput, _ ...request.Option) (*eks.UpdateNodegroupConfigOutput, error) {
+	return s.UpdateNodegroupConfigBehavior.Invoke(input, func(_ *eks.UpdateNodegroupConfigInput) (*eks.UpdateNodegroupConfigOutput, error) {
+		return &eks.UpdateNodegroupConfigOutput{}, nil
+	})
+}
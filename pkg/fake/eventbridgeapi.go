@@ -0,0 +1,54 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/aws/aws-sdk-go/service/eventbridge/eventbridgeiface"
+)
+
+// EventBridgeBehavior must be reset between tests otherwise tests will
+// pollute each other.
+type EventBridgeBehavior struct {
+	ListRuleNamesByTargetBehavior MockedFunction[eventbridge.ListRuleNamesByTargetInput, eventbridge.ListRuleNamesByTargetOutput]
+}
+
+type EventBridgeAPI struct {
+	eventbridgeiface.EventBridgeAPI
+	EventBridgeBehavior
+}
+
+// Reset must be called between tests otherwise tests will pollute
+// each other.
+func (s *EventBridgeAPI) Reset() {
+	s.ListRuleNamesByTargetBehavior.Reset()
+}
+
+func (s *EventBridgeAPI) ListRuleNamesByTargetWithContext(_ context.Context, input *eventbridge.ListRuleNamesByTargetInput, _ ...request.Option) (*eventbridge.ListRuleNamesByTargetOutput, error) {
+	return s.ListRuleNamesByTargetBehavior.Invoke(input, func(_ *eventbridge.ListRuleNamesByTargetInput) (*eventbridge.ListRuleNamesByTargetOutput, error) {
+		return &eventbridge.ListRuleNamesByTargetOutput{
+			RuleNames: aws.StringSlice([]string{
+				"KarpenterScheduledChangeRule",
+				"KarpenterSpotInterruptionRule",
+				"KarpenterRebalanceRule",
+				"KarpenterInstanceStateChangeRule",
+			}),
+		}, nil
+	})
+}
@@ -17,6 +17,7 @@ package fake
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -38,6 +39,11 @@ type IAMAPIBehavior struct {
 	DeleteInstanceProfileBehavior         MockedFunction[iam.DeleteInstanceProfileInput, iam.DeleteInstanceProfileOutput]
 	AddRoleToInstanceProfileBehavior      MockedFunction[iam.AddRoleToInstanceProfileInput, iam.AddRoleToInstanceProfileOutput]
 	RemoveRoleFromInstanceProfileBehavior MockedFunction[iam.RemoveRoleFromInstanceProfileInput, iam.RemoveRoleFromInstanceProfileOutput]
+	SimulatePrincipalPolicyBehavior       MockedFunction[iam.SimulatePrincipalPolicyInput, iam.SimulatePolicyResponse]
+	GetRoleBehavior                       MockedFunction[iam.GetRoleInput, iam.GetRoleOutput]
+	PutRolePermissionsBoundaryBehavior    MockedFunction[iam.PutRolePermissionsBoundaryInput, iam.PutRolePermissionsBoundaryOutput]
+	ListAttachedRolePoliciesBehavior      MockedFunction[iam.ListAttachedRolePoliciesInput, iam.ListAttachedRolePoliciesOutput]
+	AttachRolePolicyBehavior              MockedFunction[iam.AttachRolePolicyInput, iam.AttachRolePolicyOutput]
 }
 
 type IAMAPI struct {
@@ -47,10 +53,14 @@ type IAMAPI struct {
 	IAMAPIBehavior
 
 	InstanceProfiles map[string]*iam.InstanceProfile
+	// PermissionsBoundaries and AttachedPolicies are the permissions boundary and the attached managed policies of
+	// each role. Every role exists, roles that aren't in the maps have neither.
+	PermissionsBoundaries map[string]string
+	AttachedPolicies      map[string][]string
 }
 
 func NewIAMAPI() *IAMAPI {
-	return &IAMAPI{InstanceProfiles: map[string]*iam.InstanceProfile{}}
+	return &IAMAPI{InstanceProfiles: map[string]*iam.InstanceProfile{}, PermissionsBoundaries: map[string]string{}, AttachedPolicies: map[string][]string{}}
 }
 
 // Reset must be called between tests otherwise tests will pollute
@@ -61,7 +71,14 @@ func (s *IAMAPI) Reset() {
 	s.DeleteInstanceProfileBehavior.Reset()
 	s.AddRoleToInstanceProfileBehavior.Reset()
 	s.RemoveRoleFromInstanceProfileBehavior.Reset()
+	s.SimulatePrincipalPolicyBehavior.Reset()
+	s.GetRoleBehavior.Reset()
+	s.PutRolePermissionsBoundaryBehavior.Reset()
+	s.ListAttachedRolePoliciesBehavior.Reset()
+	s.AttachRolePolicyBehavior.Reset()
 	s.InstanceProfiles = map[string]*iam.InstanceProfile{}
+	s.PermissionsBoundaries = map[string]string{}
+	s.AttachedPolicies = map[string][]string{}
 }
 
 func (s *IAMAPI) GetInstanceProfileWithContext(_ context.Context, input *iam.GetInstanceProfileInput, _ ...request.Option) (*iam.GetInstanceProfileOutput, error) {
@@ -146,3 +163,76 @@ func (s *IAMAPI) RemoveRoleFromInstanceProfileWithContext(_ context.Context, inp
 		return nil, awserr.New(iam.ErrCodeNoSuchEntityException, fmt.Sprintf("Instance Profile %s cannot be found", aws.StringValue(input.InstanceProfileName)), nil)
 	})
 }
+
+func (s *IAMAPI) SimulatePrincipalPolicyPagesWithContext(_ context.Context, input *iam.SimulatePrincipalPolicyInput, fn func(*iam.SimulatePolicyResponse, bool) bool, _ ...request.Option) error {
+	out, err := s.SimulatePrincipalPolicyBehavior.Invoke(input, func(input *iam.SimulatePrincipalPolicyInput) (*iam.SimulatePolicyResponse, error) {
+		// Allow every action unless the test overrides the output
+		return &iam.SimulatePolicyResponse{
+			EvaluationResults: lo.Map(input.ActionNames, func(action *string, _ int) *iam.EvaluationResult {
+				return &iam.EvaluationResult{EvalActionName: action, EvalResourceName: aws.String("*"), EvalDecision: aws.String(iam.PolicyEvaluationDecisionTypeAllowed)}
+			}),
+		}, nil
+	})
+	if err != nil {
+		return err
+	}
+	fn(out, true)
+	return nil
+}
+
+func (s *IAMAPI) GetRoleWithContext(_ context.Context, input *iam.GetRoleInput, _ ...request.Option) (*iam.GetRoleOutput, error) {
+	return s.GetRoleBehavior.Invoke(input, func(input *iam.GetRoleInput) (*iam.GetRoleOutput, error) {
+		s.Lock()
+		defer s.Unlock()
+
+		role := &iam.Role{RoleId: aws.String(RoleID()), RoleName: input.RoleName, Arn: aws.String(fmt.Sprintf("arn:aws:iam::000000000000:role/%s", aws.StringValue(input.RoleName)))}
+		if boundary, ok := s.PermissionsBoundaries[aws.StringValue(input.RoleName)]; ok {
+			role.PermissionsBoundary = &iam.AttachedPermissionsBoundary{
+				PermissionsBoundaryArn:  aws.String(boundary),
+				PermissionsBoundaryType: aws.String(iam.PermissionsBoundaryAttachmentTypePermissionsBoundaryPolicy),
+			}
+		}
+		return &iam.GetRoleOutput{Role: role}, nil
+	})
+}
+
+func (s *IAMAPI) PutRolePermissionsBoundaryWithContext(_ context.Context, input *iam.PutRolePermissionsBoundaryInput, _ ...request.Option) (*iam.PutRolePermissionsBoundaryOutput, error) {
+	return s.PutRolePermissionsBoundaryBehavior.Invoke(input, func(input *iam.PutRolePermissionsBoundaryInput) (*iam.PutRolePermissionsBoundaryOutput, error) {
+		s.Lock()
+		defer s.Unlock()
+
+		s.PermissionsBoundaries[aws.StringValue(input.RoleName)] = aws.StringValue(input.PermissionsBoundary)
+		return &iam.PutRolePermissionsBoundaryOutput{}, nil
+	})
+}
+
+func (s *IAMAPI) ListAttachedRolePoliciesPagesWithContext(_ context.Context, input *iam.ListAttachedRolePoliciesInput, fn func(*iam.ListAttachedRolePoliciesOutput, bool) bool, _ ...request.Option) error {
+	out, err := s.ListAttachedRolePoliciesBehavior.Invoke(input, func(input *iam.ListAttachedRolePoliciesInput) (*iam.ListAttachedRolePoliciesOutput, error) {
+		s.Lock()
+		defer s.Unlock()
+
+		return &iam.ListAttachedRolePoliciesOutput{
+			AttachedPolicies: lo.Map(s.AttachedPolicies[aws.StringValue(input.RoleName)], func(policyARN string, _ int) *iam.AttachedPolicy {
+				return &iam.AttachedPolicy{PolicyArn: aws.String(policyARN), PolicyName: aws.String(policyARN[strings.LastIndex(policyARN, "/")+1:])}
+			}),
+		}, nil
+	})
+	if err != nil {
+		return err
+	}
+	fn(out, true)
+	return nil
+}
+
+func (s *IAMAPI) AttachRolePolicyWithContext(_ context.Context, input *iam.AttachRolePolicyInput, _ ...request.Option) (*iam.AttachRolePolicyOutput, error) {
+	return s.AttachRolePolicyBehavior.Invoke(input, func(input *iam.AttachRolePolicyInput) (*iam.AttachRolePolicyOutput, error) {
+		s.Lock()
+		defer s.Unlock()
+
+		roleName := aws.StringValue(input.RoleName)
+		if !lo.Contains(s.AttachedPolicies[roleName], aws.StringValue(input.PolicyArn)) {
+			s.AttachedPolicies[roleName] = append(s.AttachedPolicies[roleName], aws.StringValue(input.PolicyArn))
+		}
+		return &iam.AttachRolePolicyOutput{}, nil
+	})
+}
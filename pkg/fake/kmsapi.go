@@ -0,0 +1,65 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+)
+
+// KMSBehavior must be reset between tests otherwise tests will
+// pollute each other.
+type KMSBehavior struct {
+	GetPublicKeyBehavior MockedFunction[kms.GetPublicKeyInput, kms.GetPublicKeyOutput]
+}
+
+type KMSAPI struct {
+	kmsiface.KMSAPI
+	KMSBehavior
+
+	// PublicKeys maps key ids to their DER encoded public keys
+	PublicKeys map[string][]byte
+}
+
+func NewKMSAPI() *KMSAPI {
+	return &KMSAPI{PublicKeys: map[string][]byte{}}
+}
+
+// Reset must be called between tests otherwise tests will pollute
+// each other.
+func (k *KMSAPI) Reset() {
+	k.GetPublicKeyBehavior.Reset()
+	k.PublicKeys = map[string][]byte{}
+}
+
+func (k *KMSAPI) GetPublicKeyWithContext(_ context.Context, input *kms.GetPublicKeyInput, _ ...request.Option) (*kms.GetPublicKeyOutput, error) {
+	return k.GetPublicKeyBehavior.Invoke(input, func(input *kms.GetPublicKeyInput) (*kms.GetPublicKeyOutput, error) {
+		publicKey, ok := k.PublicKeys[aws.StringValue(input.KeyId)]
+		if !ok {
+			return nil, awserr.New(kms.ErrCodeNotFoundException, fmt.Sprintf("key %s can't be found", aws.StringValue(input.KeyId)), nil)
+		}
+		return &kms.GetPublicKeyOutput{
+			KeyId:     input.KeyId,
+			KeyUsage:  aws.String(kms.KeyUsageTypeSignVerify),
+			PublicKey: publicKey,
+		}, nil
+	})
+}
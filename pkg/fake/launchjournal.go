@@ -0,0 +1,87 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/launchjournal"
+)
+
+// LaunchJournal journals launches in memory
+type LaunchJournal struct {
+	mu       sync.Mutex
+	launches map[string]launchjournal.Launch
+}
+
+func (j *LaunchJournal) Reset() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.launches = nil
+}
+
+func (j *LaunchJournal) Begin(ctx context.Context, nodeClaim *karpv1.NodeClaim, clientToken string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.launches == nil {
+		j.launches = map[string]launchjournal.Launch{}
+	}
+	j.launches[nodeClaim.Name] = launchjournal.Launch{NodeClaimUID: nodeClaim.UID, ClientToken: clientToken, LaunchTime: time.Now(), RoleARN: assumerole.FromContext(ctx)}
+	return nil
+}
+
+func (j *LaunchJournal) Record(ctx context.Context, nodeClaim *karpv1.NodeClaim, instanceID string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.launches == nil {
+		j.launches = map[string]launchjournal.Launch{}
+	}
+	j.launches[nodeClaim.Name] = launchjournal.Launch{NodeClaimUID: nodeClaim.UID, InstanceID: instanceID, LaunchTime: time.Now(), RoleARN: assumerole.FromContext(ctx)}
+	return nil
+}
+
+func (j *LaunchJournal) Get(_ context.Context, nodeClaim *karpv1.NodeClaim) (*launchjournal.Launch, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	launch, ok := j.launches[nodeClaim.Name]
+	if !ok || nodeClaim.UID == "" || launch.NodeClaimUID != nodeClaim.UID {
+		return nil, nil
+	}
+	return &launch, nil
+}
+
+func (j *LaunchJournal) List(_ context.Context) (map[string]launchjournal.Launch, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	launches := make(map[string]launchjournal.Launch, len(j.launches))
+	for name, launch := range j.launches {
+		launches[name] = launch
+	}
+	return launches, nil
+}
+
+func (j *LaunchJournal) Remove(_ context.Context, names ...string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, name := range names {
+		delete(j.launches, name)
+	}
+	return nil
+}
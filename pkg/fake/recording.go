@@ -0,0 +1,275 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	"github.com/aws/aws-sdk-go/service/pricing/pricingiface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/samber/lo"
+)
+
+// Recording is a set of responses of the real EC2, SSM and Pricing APIs. The Recording* APIs capture it from the
+// providers' calls to a region, and the fake APIs replay it, so that tests can validate the providers against realistic
+// data, like the DescribeInstanceTypes payloads of a region, without AWS credentials. Recordings are stored as gzipped
+// JSON, since the instance types of a region are several megabytes.
+type Recording struct {
+	mu sync.Mutex
+
+	Region                string                      `json:"region"`
+	InstanceTypes         []*ec2.InstanceTypeInfo     `json:"instanceTypes,omitempty"`
+	InstanceTypeOfferings []*ec2.InstanceTypeOffering `json:"instanceTypeOfferings,omitempty"`
+	AvailabilityZones     []*ec2.AvailabilityZone     `json:"availabilityZones,omitempty"`
+	Subnets               []*ec2.Subnet               `json:"subnets,omitempty"`
+	SecurityGroups        []*ec2.SecurityGroup        `json:"securityGroups,omitempty"`
+	Images                []*ec2.Image                `json:"images,omitempty"`
+	SpotPriceHistory      []*ec2.SpotPrice            `json:"spotPriceHistory,omitempty"`
+	Parameters            map[string]string           `json:"parameters,omitempty"`
+	PriceList             []aws.JSONValue             `json:"priceList,omitempty"`
+}
+
+func NewRecording(region string) *Recording {
+	return &Recording{Region: region, Parameters: map[string]string{}}
+}
+
+// LoadRecording reads a recording that was saved with Save
+func LoadRecording(path string) (*Recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening recording, %w", err)
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing recording, %w", err)
+	}
+	defer r.Close()
+	recording := &Recording{}
+	if err := json.NewDecoder(r).Decode(recording); err != nil {
+		return nil, fmt.Errorf("decoding recording, %w", err)
+	}
+	return recording, nil
+}
+
+// Save deduplicates the recorded responses, since the providers describe the same resources on every refresh, and
+// writes them to path
+func (r *Recording) Save(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.InstanceTypes = sortedUniqBy(r.InstanceTypes, func(i *ec2.InstanceTypeInfo) string { return aws.StringValue(i.InstanceType) })
+	r.InstanceTypeOfferings = sortedUniqBy(r.InstanceTypeOfferings, func(o *ec2.InstanceTypeOffering) string {
+		return fmt.Sprintf("%s/%s/%s", aws.StringValue(o.InstanceType), aws.StringValue(o.LocationType), aws.StringValue(o.Location))
+	})
+	r.AvailabilityZones = sortedUniqBy(r.AvailabilityZones, func(z *ec2.AvailabilityZone) string { return aws.StringValue(z.ZoneName) })
+	r.Subnets = sortedUniqBy(r.Subnets, func(s *ec2.Subnet) string { return aws.StringValue(s.SubnetId) })
+	r.SecurityGroups = sortedUniqBy(r.SecurityGroups, func(s *ec2.SecurityGroup) string { return aws.StringValue(s.GroupId) })
+	r.Images = sortedUniqBy(r.Images, func(i *ec2.Image) string { return aws.StringValue(i.ImageId) })
+	// The spot price history is returned newest first, so only the latest price of each pool is kept
+	r.SpotPriceHistory = sortedUniqBy(r.SpotPriceHistory, func(p *ec2.SpotPrice) string {
+		return fmt.Sprintf("%s/%s/%s", aws.StringValue(p.InstanceType), aws.StringValue(p.AvailabilityZone), aws.StringValue(p.ProductDescription))
+	})
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating recording, %w", err)
+	}
+	defer f.Close()
+	w := gzip.NewWriter(f)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return fmt.Errorf("encoding recording, %w", err)
+	}
+	return w.Close()
+}
+
+func (r *Recording) record(fn func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn()
+}
+
+func sortedUniqBy[T any](items []T, key func(T) string) []T {
+	items = lo.UniqBy(items, key)
+	sort.SliceStable(items, func(i, j int) bool { return key(items[i]) < key(items[j]) })
+	return items
+}
+
+// Replay sets the outputs of the fake EC2 API to the recorded responses. The subnets, security groups and images are
+// still filtered by the inputs of the calls.
+func (e *EC2API) Replay(r *Recording) {
+	if len(r.InstanceTypes) != 0 {
+		e.DescribeInstanceTypesOutput.Set(&ec2.DescribeInstanceTypesOutput{InstanceTypes: r.InstanceTypes})
+	}
+	if len(r.InstanceTypeOfferings) != 0 {
+		e.DescribeInstanceTypeOfferingsOutput.Set(&ec2.DescribeInstanceTypeOfferingsOutput{InstanceTypeOfferings: r.InstanceTypeOfferings})
+	}
+	if len(r.AvailabilityZones) != 0 {
+		e.DescribeAvailabilityZonesOutput.Set(&ec2.DescribeAvailabilityZonesOutput{AvailabilityZones: r.AvailabilityZones})
+	}
+	if len(r.Subnets) != 0 {
+		e.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: r.Subnets})
+	}
+	if len(r.SecurityGroups) != 0 {
+		e.DescribeSecurityGroupsOutput.Set(&ec2.DescribeSecurityGroupsOutput{SecurityGroups: r.SecurityGroups})
+	}
+	if len(r.Images) != 0 {
+		e.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: r.Images})
+	}
+	if len(r.SpotPriceHistory) != 0 {
+		e.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{SpotPriceHistory: r.SpotPriceHistory})
+	}
+}
+
+// Replay sets the parameters of the fake SSM API to the recorded parameters
+func (a *SSMAPI) Replay(r *Recording) {
+	if len(r.Parameters) != 0 {
+		a.Parameters = lo.Assign(r.Parameters)
+	}
+}
+
+// Replay sets the output of the fake Pricing API to the recorded price list
+func (p *PricingAPI) Replay(r *Recording) {
+	if len(r.PriceList) != 0 {
+		p.GetProductsOutput.Set(&pricing.GetProductsOutput{PriceList: r.PriceList})
+	}
+}
+
+// RecordingEC2API passes the calls of the providers through to a real EC2 API and records the responses
+type RecordingEC2API struct {
+	ec2iface.EC2API
+	Recording *Recording
+}
+
+func NewRecordingEC2API(api ec2iface.EC2API, recording *Recording) *RecordingEC2API {
+	return &RecordingEC2API{EC2API: api, Recording: recording}
+}
+
+func (e *RecordingEC2API) DescribeInstanceTypesPagesWithContext(ctx context.Context, input *ec2.DescribeInstanceTypesInput, fn func(*ec2.DescribeInstanceTypesOutput, bool) bool, opts ...request.Option) error {
+	return e.EC2API.DescribeInstanceTypesPagesWithContext(ctx, input, func(out *ec2.DescribeInstanceTypesOutput, lastPage bool) bool {
+		e.Recording.record(func() { e.Recording.InstanceTypes = append(e.Recording.InstanceTypes, out.InstanceTypes...) })
+		return fn(out, lastPage)
+	}, opts...)
+}
+
+func (e *RecordingEC2API) DescribeInstanceTypeOfferingsPagesWithContext(ctx context.Context, input *ec2.DescribeInstanceTypeOfferingsInput, fn func(*ec2.DescribeInstanceTypeOfferingsOutput, bool) bool, opts ...request.Option) error {
+	return e.EC2API.DescribeInstanceTypeOfferingsPagesWithContext(ctx, input, func(out *ec2.DescribeInstanceTypeOfferingsOutput, lastPage bool) bool {
+		e.Recording.record(func() {
+			e.Recording.InstanceTypeOfferings = append(e.Recording.InstanceTypeOfferings, out.InstanceTypeOfferings...)
+		})
+		return fn(out, lastPage)
+	}, opts...)
+}
+
+func (e *RecordingEC2API) DescribeAvailabilityZonesWithContext(ctx context.Context, input *ec2.DescribeAvailabilityZonesInput, opts ...request.Option) (*ec2.DescribeAvailabilityZonesOutput, error) {
+	out, err := e.EC2API.DescribeAvailabilityZonesWithContext(ctx, input, opts...)
+	if err == nil {
+		e.Recording.record(func() {
+			e.Recording.AvailabilityZones = append(e.Recording.AvailabilityZones, out.AvailabilityZones...)
+		})
+	}
+	return out, err
+}
+
+func (e *RecordingEC2API) DescribeSubnetsWithContext(ctx context.Context, input *ec2.DescribeSubnetsInput, opts ...request.Option) (*ec2.DescribeSubnetsOutput, error) {
+	out, err := e.EC2API.DescribeSubnetsWithContext(ctx, input, opts...)
+	if err == nil {
+		e.Recording.record(func() { e.Recording.Subnets = append(e.Recording.Subnets, out.Subnets...) })
+	}
+	return out, err
+}
+
+func (e *RecordingEC2API) DescribeSecurityGroupsWithContext(ctx context.Context, input *ec2.DescribeSecurityGroupsInput, opts ...request.Option) (*ec2.DescribeSecurityGroupsOutput, error) {
+	out, err := e.EC2API.DescribeSecurityGroupsWithContext(ctx, input, opts...)
+	if err == nil {
+		e.Recording.record(func() { e.Recording.SecurityGroups = append(e.Recording.SecurityGroups, out.SecurityGroups...) })
+	}
+	return out, err
+}
+
+func (e *RecordingEC2API) DescribeImagesPagesWithContext(ctx context.Context, input *ec2.DescribeImagesInput, fn func(*ec2.DescribeImagesOutput, bool) bool, opts ...request.Option) error {
+	return e.EC2API.DescribeImagesPagesWithContext(ctx, input, func(out *ec2.DescribeImagesOutput, lastPage bool) bool {
+		e.Recording.record(func() { e.Recording.Images = append(e.Recording.Images, out.Images...) })
+		return fn(out, lastPage)
+	}, opts...)
+}
+
+func (e *RecordingEC2API) DescribeSpotPriceHistoryPagesWithContext(ctx context.Context, input *ec2.DescribeSpotPriceHistoryInput, fn func(*ec2.DescribeSpotPriceHistoryOutput, bool) bool, opts ...request.Option) error {
+	return e.EC2API.DescribeSpotPriceHistoryPagesWithContext(ctx, input, func(out *ec2.DescribeSpotPriceHistoryOutput, lastPage bool) bool {
+		e.Recording.record(func() { e.Recording.SpotPriceHistory = append(e.Recording.SpotPriceHistory, out.SpotPriceHistory...) })
+		return fn(out, lastPage)
+	}, opts...)
+}
+
+// RecordingSSMAPI passes the calls of the providers through to a real SSM API and records the parameters
+type RecordingSSMAPI struct {
+	ssmiface.SSMAPI
+	Recording *Recording
+}
+
+func NewRecordingSSMAPI(api ssmiface.SSMAPI, recording *Recording) *RecordingSSMAPI {
+	return &RecordingSSMAPI{SSMAPI: api, Recording: recording}
+}
+
+func (a *RecordingSSMAPI) GetParametersByPathPagesWithContext(ctx context.Context, input *ssm.GetParametersByPathInput, fn func(*ssm.GetParametersByPathOutput, bool) bool, opts ...request.Option) error {
+	return a.SSMAPI.GetParametersByPathPagesWithContext(ctx, input, func(out *ssm.GetParametersByPathOutput, lastPage bool) bool {
+		a.Recording.record(func() {
+			for _, p := range out.Parameters {
+				if p.Name != nil && p.Value != nil {
+					a.Recording.Parameters[*p.Name] = *p.Value
+				}
+			}
+		})
+		return fn(out, lastPage)
+	}, opts...)
+}
+
+func (a *RecordingSSMAPI) GetParameterWithContext(ctx context.Context, input *ssm.GetParameterInput, opts ...request.Option) (*ssm.GetParameterOutput, error) {
+	out, err := a.SSMAPI.GetParameterWithContext(ctx, input, opts...)
+	if err == nil && out.Parameter != nil && out.Parameter.Name != nil && out.Parameter.Value != nil {
+		a.Recording.record(func() { a.Recording.Parameters[*out.Parameter.Name] = *out.Parameter.Value })
+	}
+	return out, err
+}
+
+// RecordingPricingAPI passes the calls of the providers through to a real Pricing API and records the price list
+type RecordingPricingAPI struct {
+	pricingiface.PricingAPI
+	Recording *Recording
+}
+
+func NewRecordingPricingAPI(api pricingiface.PricingAPI, recording *Recording) *RecordingPricingAPI {
+	return &RecordingPricingAPI{PricingAPI: api, Recording: recording}
+}
+
+func (p *RecordingPricingAPI) GetProductsPagesWithContext(ctx aws.Context, input *pricing.GetProductsInput, fn func(*pricing.GetProductsOutput, bool) bool, opts ...request.Option) error {
+	return p.PricingAPI.GetProductsPagesWithContext(ctx, input, func(out *pricing.GetProductsOutput, lastPage bool) bool {
+		p.Recording.record(func() { p.Recording.PriceList = append(p.Recording.PriceList, out.PriceList...) })
+		return fn(out, lastPage)
+	}, opts...)
+}
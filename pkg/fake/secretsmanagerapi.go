@@ -0,0 +1,61 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+)
+
+// SecretsManagerBehavior must be reset between tests otherwise tests will
+// pollute each other.
+type SecretsManagerBehavior struct {
+	GetSecretValueBehavior MockedFunction[secretsmanager.GetSecretValueInput, secretsmanager.GetSecretValueOutput]
+}
+
+type SecretsManagerAPI struct {
+	secretsmanageriface.SecretsManagerAPI
+	SecretsManagerBehavior
+
+	// Secrets maps secret ids to their secret strings
+	Secrets map[string]string
+}
+
+func NewSecretsManagerAPI() *SecretsManagerAPI {
+	return &SecretsManagerAPI{Secrets: map[string]string{}}
+}
+
+// Reset must be called between tests otherwise tests will pollute
+// each other.
+func (s *SecretsManagerAPI) Reset() {
+	s.GetSecretValueBehavior.Reset()
+	s.Secrets = map[string]string{}
+}
+
+func (s *SecretsManagerAPI) GetSecretValueWithContext(_ context.Context, input *secretsmanager.GetSecretValueInput, _ ...request.Option) (*secretsmanager.GetSecretValueOutput, error) {
+	return s.GetSecretValueBehavior.Invoke(input, func(input *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+		secret, ok := s.Secrets[aws.StringValue(input.SecretId)]
+		if !ok {
+			return nil, awserr.New(secretsmanager.ErrCodeResourceNotFoundException, fmt.Sprintf("secret %s can't be found", aws.StringValue(input.SecretId)), nil)
+		}
+		return &secretsmanager.GetSecretValueOutput{Name: input.SecretId, SecretString: aws.String(secret)}, nil
+	})
+}
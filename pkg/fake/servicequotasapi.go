@@ -0,0 +1,91 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/servicequotas"
+	"github.com/aws/aws-sdk-go/service/servicequotas/servicequotasiface"
+)
+
+// DefaultServiceQuotas are the AWS default values of the quotas that the fake returns, keyed by quota code
+var DefaultServiceQuotas = map[string]float64{
+	"L-34B43A08": 1152, // All Standard (A, C, D, H, I, M, R, T, Z) Spot Instance Requests
+	"L-DF5E4CA3": 5000, // Network interfaces per Region
+	"L-0263D0A3": 5,    // EC2-VPC Elastic IPs
+}
+
+// ServiceQuotasBehavior must be reset between tests otherwise tests will
+// pollute each other.
+type ServiceQuotasBehavior struct {
+	GetServiceQuotaBehavior           MockedFunction[servicequotas.GetServiceQuotaInput, servicequotas.GetServiceQuotaOutput]
+	GetAWSDefaultServiceQuotaBehavior MockedFunction[servicequotas.GetAWSDefaultServiceQuotaInput, servicequotas.GetAWSDefaultServiceQuotaOutput]
+	// Quotas are the applied values of the quotas that were increased, keyed by quota code
+	Quotas sync.Map
+}
+
+type ServiceQuotasAPI struct {
+	servicequotasiface.ServiceQuotasAPI
+	ServiceQuotasBehavior
+}
+
+func NewServiceQuotasAPI() *ServiceQuotasAPI {
+	return &ServiceQuotasAPI{}
+}
+
+// Reset must be called between tests otherwise tests will pollute
+// each other.
+func (s *ServiceQuotasAPI) Reset() {
+	s.GetServiceQuotaBehavior.Reset()
+	s.GetAWSDefaultServiceQuotaBehavior.Reset()
+	s.Quotas.Range(func(k, _ any) bool {
+		s.Quotas.Delete(k)
+		return true
+	})
+}
+
+func (s *ServiceQuotasAPI) GetServiceQuotaWithContext(_ context.Context, input *servicequotas.GetServiceQuotaInput, _ ...request.Option) (*servicequotas.GetServiceQuotaOutput, error) {
+	return s.GetServiceQuotaBehavior.Invoke(input, func(input *servicequotas.GetServiceQuotaInput) (*servicequotas.GetServiceQuotaOutput, error) {
+		// Quotas that were never increased don't have an applied value
+		value, ok := s.Quotas.Load(aws.StringValue(input.QuotaCode))
+		if !ok {
+			return nil, awserr.New(servicequotas.ErrCodeNoSuchResourceException, "The request failed because the specified resource does not exist.", nil)
+		}
+		return &servicequotas.GetServiceQuotaOutput{Quota: &servicequotas.ServiceQuota{
+			ServiceCode: input.ServiceCode,
+			QuotaCode:   input.QuotaCode,
+			Value:       aws.Float64(value.(float64)),
+		}}, nil
+	})
+}
+
+func (s *ServiceQuotasAPI) GetAWSDefaultServiceQuotaWithContext(_ context.Context, input *servicequotas.GetAWSDefaultServiceQuotaInput, _ ...request.Option) (*servicequotas.GetAWSDefaultServiceQuotaOutput, error) {
+	return s.GetAWSDefaultServiceQuotaBehavior.Invoke(input, func(input *servicequotas.GetAWSDefaultServiceQuotaInput) (*servicequotas.GetAWSDefaultServiceQuotaOutput, error) {
+		value, ok := DefaultServiceQuotas[aws.StringValue(input.QuotaCode)]
+		if !ok {
+			return nil, awserr.New(servicequotas.ErrCodeNoSuchResourceException, "The request failed because the specified resource does not exist.", nil)
+		}
+		return &servicequotas.GetAWSDefaultServiceQuotaOutput{Quota: &servicequotas.ServiceQuota{
+			ServiceCode: input.ServiceCode,
+			QuotaCode:   input.QuotaCode,
+			Value:       aws.Float64(value),
+		}}, nil
+	})
+}
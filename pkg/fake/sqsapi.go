@@ -16,6 +16,7 @@ package fake
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/request"
@@ -25,14 +26,16 @@ import (
 
 const (
 	dummyQueueURL = "https://sqs.us-west-2.amazonaws.com/000000000000/Karpenter-cluster-Queue"
+	dummyQueueARN = "arn:aws:sqs:us-west-2:000000000000:Karpenter-cluster-Queue"
 )
 
 // SQSBehavior must be reset between tests otherwise tests will
 // pollute each other.
 type SQSBehavior struct {
-	GetQueueURLBehavior    MockedFunction[sqs.GetQueueUrlInput, sqs.GetQueueUrlOutput]
-	ReceiveMessageBehavior MockedFunction[sqs.ReceiveMessageInput, sqs.ReceiveMessageOutput]
-	DeleteMessageBehavior  MockedFunction[sqs.DeleteMessageInput, sqs.DeleteMessageOutput]
+	GetQueueURLBehavior        MockedFunction[sqs.GetQueueUrlInput, sqs.GetQueueUrlOutput]
+	ReceiveMessageBehavior     MockedFunction[sqs.ReceiveMessageInput, sqs.ReceiveMessageOutput]
+	DeleteMessageBehavior      MockedFunction[sqs.DeleteMessageInput, sqs.DeleteMessageOutput]
+	GetQueueAttributesBehavior MockedFunction[sqs.GetQueueAttributesInput, sqs.GetQueueAttributesOutput]
 }
 
 type SQSAPI struct {
@@ -46,6 +49,7 @@ func (s *SQSAPI) Reset() {
 	s.GetQueueURLBehavior.Reset()
 	s.ReceiveMessageBehavior.Reset()
 	s.DeleteMessageBehavior.Reset()
+	s.GetQueueAttributesBehavior.Reset()
 }
 
 //nolint:revive,stylecheck
@@ -68,3 +72,14 @@ func (s *SQSAPI) DeleteMessageWithContext(_ context.Context, input *sqs.DeleteMe
 		return nil, nil
 	})
 }
+
+func (s *SQSAPI) GetQueueAttributesWithContext(_ context.Context, input *sqs.GetQueueAttributesInput, _ ...request.Option) (*sqs.GetQueueAttributesOutput, error) {
+	return s.GetQueueAttributesBehavior.Invoke(input, func(_ *sqs.GetQueueAttributesInput) (*sqs.GetQueueAttributesOutput, error) {
+		return &sqs.GetQueueAttributesOutput{
+			Attributes: map[string]*string{
+				sqs.QueueAttributeNameQueueArn: aws.String(dummyQueueARN),
+				sqs.QueueAttributeNamePolicy:   aws.String(fmt.Sprintf(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":["events.amazonaws.com","sqs.amazonaws.com"]},"Action":"sqs:SendMessage","Resource":"%s"}]}`, dummyQueueARN)),
+			},
+		}, nil
+	})
+}
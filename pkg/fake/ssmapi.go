@@ -26,6 +26,7 @@ import (
 
 	"github.com/aws/karpenter-provider-aws/pkg/providers/version"
 
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
@@ -35,6 +36,7 @@ type SSMAPI struct {
 	ssmiface.SSMAPI
 	Parameters                map[string]string
 	GetParametersByPathOutput *ssm.GetParametersByPathOutput
+	MaintenanceWindows        map[string]*ssm.GetMaintenanceWindowOutput
 	WantErr                   error
 
 	defaultParametersForPath map[string][]*ssm.Parameter
@@ -84,6 +86,28 @@ func (a SSMAPI) GetParametersByPathPagesWithContext(_ context.Context, input *ss
 	return fmt.Errorf("path %q does not exist", lo.FromPtr(input.Path))
 }
 
+func (a SSMAPI) GetParameterWithContext(_ context.Context, input *ssm.GetParameterInput, _ ...request.Option) (*ssm.GetParameterOutput, error) {
+	if a.WantErr != nil {
+		return nil, a.WantErr
+	}
+	value, ok := a.Parameters[lo.FromPtr(input.Name)]
+	if !ok {
+		return nil, awserr.New(ssm.ErrCodeParameterNotFound, fmt.Sprintf("parameter %q does not exist", lo.FromPtr(input.Name)), nil)
+	}
+	return &ssm.GetParameterOutput{Parameter: &ssm.Parameter{Name: input.Name, Value: lo.ToPtr(value)}}, nil
+}
+
+func (a SSMAPI) GetMaintenanceWindowWithContext(_ context.Context, input *ssm.GetMaintenanceWindowInput, _ ...request.Option) (*ssm.GetMaintenanceWindowOutput, error) {
+	if a.WantErr != nil {
+		return nil, a.WantErr
+	}
+	window, ok := a.MaintenanceWindows[lo.FromPtr(input.WindowId)]
+	if !ok {
+		return nil, awserr.New(ssm.ErrCodeDoesNotExistException, fmt.Sprintf("maintenance window %q does not exist", lo.FromPtr(input.WindowId)), nil)
+	}
+	return window, nil
+}
+
 func (a SSMAPI) getDefaultParametersForPath(path string) []*ssm.Parameter {
 	// If we've already generated default parameters, return the same parameters across calls. This ensures we don't
 	// drift due to different results from one call to the next.
@@ -132,5 +156,6 @@ func (a SSMAPI) getDefaultParametersForPath(path string) []*ssm.Parameter {
 func (a *SSMAPI) Reset() {
 	a.GetParametersByPathOutput = nil
 	a.Parameters = nil
+	a.MaintenanceWindows = nil
 	a.WantErr = nil
 }
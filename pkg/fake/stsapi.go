@@ -0,0 +1,75 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+)
+
+// STSBehavior must be reset between tests otherwise tests will
+// pollute each other.
+type STSBehavior struct {
+	AssumeRoleBehavior        MockedFunction[sts.AssumeRoleInput, sts.AssumeRoleOutput]
+	GetCallerIdentityBehavior MockedFunction[sts.GetCallerIdentityInput, sts.GetCallerIdentityOutput]
+}
+
+type STSAPI struct {
+	stsiface.STSAPI
+	STSBehavior
+}
+
+// Reset must be called between tests otherwise tests will pollute
+// each other.
+func (s *STSAPI) Reset() {
+	s.AssumeRoleBehavior.Reset()
+	s.GetCallerIdentityBehavior.Reset()
+}
+
+func (s *STSAPI) AssumeRoleWithContext(_ context.Context, input *sts.AssumeRoleInput, _ ...request.Option) (*sts.AssumeRoleOutput, error) {
+	return s.AssumeRoleBehavior.Invoke(input, func(input *sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+		// Derive the access key from the role name so that callers can tell which role signed a request
+		roleName := aws.StringValue(input.RoleArn)[strings.LastIndex(aws.StringValue(input.RoleArn), "/")+1:]
+		return &sts.AssumeRoleOutput{
+			AssumedRoleUser: &sts.AssumedRoleUser{
+				Arn:           aws.String(fmt.Sprintf("%s/%s", aws.StringValue(input.RoleArn), aws.StringValue(input.RoleSessionName))),
+				AssumedRoleId: aws.String(fmt.Sprintf("AROA%s", strings.ToUpper(roleName))),
+			},
+			Credentials: &sts.Credentials{
+				AccessKeyId:     aws.String(fmt.Sprintf("ASIA%s", strings.ToUpper(roleName))),
+				SecretAccessKey: aws.String("secret"),
+				SessionToken:    aws.String("token"),
+				Expiration:      aws.Time(time.Now().Add(time.Hour)),
+			},
+		}, nil
+	})
+}
+
+func (s *STSAPI) GetCallerIdentityWithContext(_ context.Context, input *sts.GetCallerIdentityInput, _ ...request.Option) (*sts.GetCallerIdentityOutput, error) {
+	return s.GetCallerIdentityBehavior.Invoke(input, func(*sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error) {
+		return &sts.GetCallerIdentityOutput{
+			Account: aws.String("123456789012"),
+			Arn:     aws.String("arn:aws:sts::123456789012:assumed-role/KarpenterControllerRole/karpenter"),
+			UserId:  aws.String("AROAKARPENTERCONTROLLERROLE:karpenter"),
+		}, nil
+	})
+}
@@ -0,0 +1,120 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/samber/lo"
+
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var ctx context.Context
+
+func TestFake(t *testing.T) {
+	ctx = context.Background()
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Fake")
+}
+
+var _ = Describe("Recording", func() {
+	// The fake APIs stand in for the real APIs that are recorded
+	var ec2api *fake.EC2API
+	var ssmapi *fake.SSMAPI
+	var pricingapi *fake.PricingAPI
+	var recording *fake.Recording
+	var path string
+
+	BeforeEach(func() {
+		ec2api = fake.NewEC2API()
+		ssmapi = fake.NewSSMAPI()
+		ssmapi.Parameters = map[string]string{"/aws/service/eks/optimized-ami/1.30/amazon-linux-2023/x86_64/standard/recommended/image_id": "ami-0a1b2c3d4e5f67890"}
+		pricingapi = &fake.PricingAPI{}
+		pricingapi.GetProductsOutput.Set(&pricing.GetProductsOutput{PriceList: []aws.JSONValue{fake.NewOnDemandPrice("m5.large", 0.096)}})
+		ec2api.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{SpotPriceHistory: []*ec2.SpotPrice{
+			{InstanceType: aws.String("m5.large"), AvailabilityZone: aws.String("test-zone-1a"), ProductDescription: aws.String("Linux/UNIX"), SpotPrice: aws.String("0.035"), Timestamp: aws.Time(time.Now())},
+			{InstanceType: aws.String("m5.large"), AvailabilityZone: aws.String("test-zone-1a"), ProductDescription: aws.String("Linux/UNIX"), SpotPrice: aws.String("0.041"), Timestamp: aws.Time(time.Now().Add(-time.Hour))},
+		}})
+		recording = fake.NewRecording("us-west-2")
+		path = filepath.Join(GinkgoT().TempDir(), "us-west-2.json.gz")
+	})
+
+	It("should replay the responses that were recorded", func() {
+		recordingEC2API := fake.NewRecordingEC2API(ec2api, recording)
+		var instanceTypes []*ec2.InstanceTypeInfo
+		Expect(recordingEC2API.DescribeInstanceTypesPagesWithContext(ctx, &ec2.DescribeInstanceTypesInput{}, func(out *ec2.DescribeInstanceTypesOutput, _ bool) bool {
+			instanceTypes = append(instanceTypes, out.InstanceTypes...)
+			return true
+		})).To(Succeed())
+		Expect(recordingEC2API.DescribeInstanceTypeOfferingsPagesWithContext(ctx, &ec2.DescribeInstanceTypeOfferingsInput{}, func(*ec2.DescribeInstanceTypeOfferingsOutput, bool) bool { return true })).To(Succeed())
+		Expect(recordingEC2API.DescribeSpotPriceHistoryPagesWithContext(ctx, &ec2.DescribeSpotPriceHistoryInput{}, func(*ec2.DescribeSpotPriceHistoryOutput, bool) bool { return true })).To(Succeed())
+		Expect(fake.NewRecordingSSMAPI(ssmapi, recording).GetParametersByPathPagesWithContext(ctx, &ssm.GetParametersByPathInput{
+			Path:      aws.String("/aws/service/eks/optimized-ami/1.30/amazon-linux-2023"),
+			Recursive: aws.Bool(true),
+		}, func(*ssm.GetParametersByPathOutput, bool) bool { return true })).To(Succeed())
+		Expect(fake.NewRecordingPricingAPI(pricingapi, recording).GetProductsPagesWithContext(ctx, &pricing.GetProductsInput{}, func(*pricing.GetProductsOutput, bool) bool { return true })).To(Succeed())
+		Expect(recording.Save(path)).To(Succeed())
+
+		replayed, err := fake.LoadRecording(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(replayed.Region).To(Equal("us-west-2"))
+		replayEC2API := fake.NewEC2API()
+		replayEC2API.Replay(replayed)
+		out, err := replayEC2API.DescribeInstanceTypesWithContext(ctx, &ec2.DescribeInstanceTypesInput{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lo.Map(out.InstanceTypes, func(i *ec2.InstanceTypeInfo, _ int) string { return aws.StringValue(i.InstanceType) })).To(ConsistOf(
+			lo.Map(instanceTypes, func(i *ec2.InstanceTypeInfo, _ int) string { return aws.StringValue(i.InstanceType) }),
+		))
+		offerings, err := replayEC2API.DescribeInstanceTypeOfferingsWithContext(ctx, &ec2.DescribeInstanceTypeOfferingsInput{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(offerings.InstanceTypeOfferings).ToNot(BeEmpty())
+
+		replaySSMAPI := fake.NewSSMAPI()
+		replaySSMAPI.Replay(replayed)
+		parameter, err := replaySSMAPI.GetParameterWithContext(ctx, &ssm.GetParameterInput{Name: aws.String("/aws/service/eks/optimized-ami/1.30/amazon-linux-2023/x86_64/standard/recommended/image_id")})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(aws.StringValue(parameter.Parameter.Value)).To(Equal("ami-0a1b2c3d4e5f67890"))
+
+		replayPricingAPI := &fake.PricingAPI{}
+		replayPricingAPI.Replay(replayed)
+		Expect(replayPricingAPI.GetProductsOutput.Clone().PriceList).To(HaveLen(1))
+	})
+	It("should only keep the latest spot price of each pool", func() {
+		Expect(fake.NewRecordingEC2API(ec2api, recording).DescribeSpotPriceHistoryPagesWithContext(ctx, &ec2.DescribeSpotPriceHistoryInput{}, func(*ec2.DescribeSpotPriceHistoryOutput, bool) bool { return true })).To(Succeed())
+		Expect(recording.Save(path)).To(Succeed())
+		replayed, err := fake.LoadRecording(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(replayed.SpotPriceHistory).To(HaveLen(1))
+		Expect(aws.StringValue(replayed.SpotPriceHistory[0].SpotPrice)).To(Equal("0.035"))
+	})
+	It("should not record the responses of failed calls", func() {
+		ec2api.NextError.Set(awserr.New("UnauthorizedOperation", "You are not authorized to perform this operation.", nil))
+		_, err := fake.NewRecordingEC2API(ec2api, recording).DescribeSubnetsWithContext(ctx, &ec2.DescribeSubnetsInput{})
+		Expect(err).To(HaveOccurred())
+		Expect(recording.Subnets).To(BeEmpty())
+	})
+})
@@ -17,6 +17,7 @@ package fake
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/Pallinder/go-randomdata"
@@ -64,6 +65,10 @@ func LaunchTemplateName() string {
 	return fmt.Sprintf("karpenter.k8s.aws/%s", randomdata.Alphanumeric(17))
 }
 
+func CapacityReservationID() string {
+	return fmt.Sprint(randomdata.Alphanumeric(17))
+}
+
 func LaunchTemplateID() string {
 	return fmt.Sprint(randomdata.Alphanumeric(17))
 }
@@ -88,16 +93,24 @@ func SubnetsFromFleetRequest(createFleetInput *ec2.CreateFleetInput) []string {
 // FilterDescribeSecurtyGroups filters the passed in security groups based on the filters passed in.
 // Filters are chained with a logical "AND"
 func FilterDescribeSecurtyGroups(sgs []*ec2.SecurityGroup, filters []*ec2.Filter) []*ec2.SecurityGroup {
+	vpcFilters, filters := lo.FilterReject(filters, func(filter *ec2.Filter, _ int) bool { return aws.StringValue(filter.Name) == "vpc-id" })
 	return lo.Filter(sgs, func(group *ec2.SecurityGroup, _ int) bool {
-		return Filter(filters, *group.GroupId, *group.GroupName, group.Tags)
+		return Filter(filters, *group.GroupId, *group.GroupName, group.Tags) && lo.EveryBy(vpcFilters, func(filter *ec2.Filter) bool {
+			return lo.Contains(aws.StringValueSlice(filter.Values), aws.StringValue(group.VpcId))
+		})
 	})
 }
 
 // FilterDescribeSubnets filters the passed in subnets based on the filters passed in.
 // Filters are chained with a logical "AND"
 func FilterDescribeSubnets(subnets []*ec2.Subnet, filters []*ec2.Filter) []*ec2.Subnet {
+	stateFilters, filters := lo.FilterReject(filters, func(filter *ec2.Filter, _ int) bool { return aws.StringValue(filter.Name) == "state" })
 	return lo.Filter(subnets, func(subnet *ec2.Subnet, _ int) bool {
-		return Filter(filters, *subnet.SubnetId, "", subnet.Tags)
+		// Subnets without a state are treated as available
+		state := lo.Ternary(subnet.State != nil, aws.StringValue(subnet.State), ec2.SubnetStateAvailable)
+		return Filter(filters, *subnet.SubnetId, "", subnet.Tags) && lo.EveryBy(stateFilters, func(filter *ec2.Filter) bool {
+			return lo.Contains(aws.StringValueSlice(filter.Values), state)
+		})
 	})
 }
 
@@ -107,6 +120,29 @@ func FilterDescribeImages(images []*ec2.Image, filters []*ec2.Filter) []*ec2.Ima
 	})
 }
 
+// FilterDescribeSnapshots filters the passed in snapshots based on the owners and filters passed in.
+// Filters are chained with a logical "AND"
+func FilterDescribeSnapshots(snapshots []*ec2.Snapshot, owners []*string, filters []*ec2.Filter) []*ec2.Snapshot {
+	statusFilters, filters := lo.FilterReject(filters, func(filter *ec2.Filter, _ int) bool { return aws.StringValue(filter.Name) == "status" })
+	return lo.Filter(snapshots, func(snapshot *ec2.Snapshot, _ int) bool {
+		return Filter(filters, *snapshot.SnapshotId, "", snapshot.Tags) &&
+			(len(owners) == 0 || lo.Contains(aws.StringValueSlice(owners), aws.StringValue(snapshot.OwnerId))) &&
+			lo.EveryBy(statusFilters, func(filter *ec2.Filter) bool {
+				return lo.Contains(aws.StringValueSlice(filter.Values), aws.StringValue(snapshot.State))
+			})
+	})
+}
+
+func FilterDescribeCapacityReservations(reservations []*ec2.CapacityReservation, filters []*ec2.Filter) []*ec2.CapacityReservation {
+	stateFilters, filters := lo.FilterReject(filters, func(filter *ec2.Filter, _ int) bool { return aws.StringValue(filter.Name) == "state" })
+	return lo.Filter(reservations, func(reservation *ec2.CapacityReservation, _ int) bool {
+		return Filter(filters, aws.StringValue(reservation.CapacityReservationId), "", reservation.Tags) &&
+			lo.EveryBy(stateFilters, func(filter *ec2.Filter) bool {
+				return lo.Contains(aws.StringValueSlice(filter.Values), aws.StringValue(reservation.State))
+			})
+	})
+}
+
 //nolint:gocyclo
 func Filter(filters []*ec2.Filter, id, name string, tags []*ec2.Tag) bool {
 	return lo.EveryBy(filters, func(filter *ec2.Filter) bool {
@@ -119,7 +155,7 @@ func Filter(filters []*ec2.Filter, id, name string, tags []*ec2.Tag) bool {
 			}
 		case filterName == "group-name" || filterName == "name":
 			for _, val := range filter.Values {
-				if name == aws.StringValue(val) {
+				if matchWildcard(aws.StringValue(val), name) {
 					return true
 				}
 			}
@@ -134,6 +170,12 @@ func Filter(filters []*ec2.Filter, id, name string, tags []*ec2.Tag) bool {
 	})
 }
 
+// matchWildcard matches a value against a filter value that may contain the '*' and '?' wildcards of EC2 filters
+func matchWildcard(pattern, value string) bool {
+	expr := strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(regexp.QuoteMeta(pattern))
+	return regexp.MustCompile("^" + expr + "$").MatchString(value)
+}
+
 // matchTags is a predicate that matches a slice of tags with a tag:<key> or tag-keys filter
 // nolint: gocyclo
 func matchTags(tags []*ec2.Tag, filter *ec2.Filter) bool {
@@ -34,6 +34,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			PhcSupport:                    aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("AWS"),
 				SupportedArchitectures: aws.StringSlice([]string{"arm64"}),
@@ -62,6 +63,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				MaximumNetworkInterfaces:     aws.Int64(3),
 				Ipv4AddressesPerInterface:    aws.Int64(10),
 				EncryptionInTransitSupported: aws.Bool(false),
+				EnaSupport:                   aws.String("required"),
 				DefaultNetworkCardIndex:      aws.Int64(0),
 				NetworkCards: []*ec2.NetworkCardInfo{
 					{
@@ -78,6 +80,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			PhcSupport:                    aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
 				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
@@ -124,6 +127,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				MaximumNetworkInterfaces:     aws.Int64(60),
 				Ipv4AddressesPerInterface:    aws.Int64(50),
 				EncryptionInTransitSupported: aws.Bool(true),
+				EnaSupport:                   aws.String("required"),
 				DefaultNetworkCardIndex:      aws.Int64(0),
 				NetworkCards: []*ec2.NetworkCardInfo{
 					{
@@ -152,6 +156,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			PhcSupport:                    aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("AMD"),
 				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
@@ -195,6 +200,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				MaximumNetworkInterfaces:     aws.Int64(8),
 				Ipv4AddressesPerInterface:    aws.Int64(30),
 				EncryptionInTransitSupported: aws.Bool(true),
+				EnaSupport:                   aws.String("required"),
 				DefaultNetworkCardIndex:      aws.Int64(0),
 				NetworkCards: []*ec2.NetworkCardInfo{
 					{
@@ -211,6 +217,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			PhcSupport:                    aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
 				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
@@ -257,6 +264,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				MaximumNetworkInterfaces:     aws.Int64(4),
 				Ipv4AddressesPerInterface:    aws.Int64(15),
 				EncryptionInTransitSupported: aws.Bool(true),
+				EnaSupport:                   aws.String("required"),
 				DefaultNetworkCardIndex:      aws.Int64(0),
 				NetworkCards: []*ec2.NetworkCardInfo{
 					{
@@ -273,6 +281,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			PhcSupport:                    aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
 				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
@@ -310,6 +319,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				MaximumNetworkInterfaces:     aws.Int64(4),
 				Ipv4AddressesPerInterface:    aws.Int64(10),
 				EncryptionInTransitSupported: aws.Bool(true),
+				EnaSupport:                   aws.String("required"),
 				DefaultNetworkCardIndex:      aws.Int64(0),
 				NetworkCards: []*ec2.NetworkCardInfo{
 					{
@@ -326,6 +336,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			PhcSupport:                    aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
 				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
@@ -363,6 +374,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				MaximumNetworkInterfaces:     aws.Int64(8),
 				Ipv4AddressesPerInterface:    aws.Int64(30),
 				EncryptionInTransitSupported: aws.Bool(true),
+				EnaSupport:                   aws.String("required"),
 				DefaultNetworkCardIndex:      aws.Int64(0),
 				NetworkCards: []*ec2.NetworkCardInfo{
 					{
@@ -379,6 +391,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			PhcSupport:                    aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
 				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
@@ -407,6 +420,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				MaximumNetworkInterfaces:     aws.Int64(3),
 				Ipv4AddressesPerInterface:    aws.Int64(10),
 				EncryptionInTransitSupported: aws.Bool(false),
+				EnaSupport:                   aws.String("required"),
 				DefaultNetworkCardIndex:      aws.Int64(0),
 				NetworkCards: []*ec2.NetworkCardInfo{
 					{
@@ -423,6 +437,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(true),
 			Hypervisor:                    aws.String(""),
+			PhcSupport:                    aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
 				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
@@ -451,6 +466,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				MaximumNetworkInterfaces:     aws.Int64(15),
 				Ipv4AddressesPerInterface:    aws.Int64(50),
 				EncryptionInTransitSupported: aws.Bool(false),
+				EnaSupport:                   aws.String("required"),
 				DefaultNetworkCardIndex:      aws.Int64(0),
 				NetworkCards: []*ec2.NetworkCardInfo{
 					{
@@ -467,6 +483,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			PhcSupport:                    aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
 				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
@@ -495,6 +512,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				MaximumNetworkInterfaces:     aws.Int64(4),
 				Ipv4AddressesPerInterface:    aws.Int64(15),
 				EncryptionInTransitSupported: aws.Bool(false),
+				EnaSupport:                   aws.String("required"),
 				DefaultNetworkCardIndex:      aws.Int64(0),
 				NetworkCards: []*ec2.NetworkCardInfo{
 					{
@@ -511,6 +529,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			PhcSupport:                    aws.String("supported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
 				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
@@ -545,6 +564,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				MaximumNetworkInterfaces:     aws.Int64(16),
 				Ipv4AddressesPerInterface:    aws.Int64(50),
 				EncryptionInTransitSupported: aws.Bool(true),
+				EnaSupport:                   aws.String("required"),
 				DefaultNetworkCardIndex:      aws.Int64(0),
 				NetworkCards: []*ec2.NetworkCardInfo{
 					{
@@ -565,6 +585,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("xen"),
+			PhcSupport:                    aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
 				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
@@ -605,6 +626,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				MaximumNetworkInterfaces:     aws.Int64(8),
 				Ipv4AddressesPerInterface:    aws.Int64(30),
 				EncryptionInTransitSupported: aws.Bool(false),
+				EnaSupport:                   aws.String("supported"),
 				DefaultNetworkCardIndex:      aws.Int64(0),
 				NetworkCards: []*ec2.NetworkCardInfo{
 					{
@@ -621,6 +643,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(true),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			PhcSupport:                    aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
 				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
@@ -649,6 +672,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				MaximumNetworkInterfaces:     aws.Int64(3),
 				Ipv4AddressesPerInterface:    aws.Int64(12),
 				EncryptionInTransitSupported: aws.Bool(false),
+				EnaSupport:                   aws.String("required"),
 				DefaultNetworkCardIndex:      aws.Int64(0),
 				NetworkCards: []*ec2.NetworkCardInfo{
 					{
@@ -665,6 +689,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(true),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			PhcSupport:                    aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("AWS"),
 				SupportedArchitectures: aws.StringSlice([]string{"arm64"}),
@@ -693,6 +718,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				MaximumNetworkInterfaces:     aws.Int64(3),
 				Ipv4AddressesPerInterface:    aws.Int64(6),
 				EncryptionInTransitSupported: aws.Bool(false),
+				EnaSupport:                   aws.String("required"),
 				DefaultNetworkCardIndex:      aws.Int64(0),
 				NetworkCards: []*ec2.NetworkCardInfo{
 					{
@@ -709,6 +735,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(true),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			PhcSupport:                    aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("AWS"),
 				SupportedArchitectures: aws.StringSlice([]string{"arm64"}),
@@ -737,6 +764,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				MaximumNetworkInterfaces:     aws.Int64(3),
 				Ipv4AddressesPerInterface:    aws.Int64(4),
 				EncryptionInTransitSupported: aws.Bool(false),
+				EnaSupport:                   aws.String("required"),
 				DefaultNetworkCardIndex:      aws.Int64(0),
 				NetworkCards: []*ec2.NetworkCardInfo{
 					{
@@ -753,6 +781,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(true),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			PhcSupport:                    aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("AWS"),
 				SupportedArchitectures: aws.StringSlice([]string{"arm64"}),
@@ -781,6 +810,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				MaximumNetworkInterfaces:     aws.Int64(4),
 				Ipv4AddressesPerInterface:    aws.Int64(15),
 				EncryptionInTransitSupported: aws.Bool(false),
+				EnaSupport:                   aws.String("required"),
 				DefaultNetworkCardIndex:      aws.Int64(0),
 				NetworkCards: []*ec2.NetworkCardInfo{
 					{
@@ -797,6 +827,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			PhcSupport:                    aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
 				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
@@ -828,6 +859,7 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 				MaximumNetworkInterfaces:     aws.Int64(4),
 				Ipv4AddressesPerInterface:    aws.Int64(15),
 				EncryptionInTransitSupported: aws.Bool(true),
+				EnaSupport:                   aws.String("required"),
 				DefaultNetworkCardIndex:      aws.Int64(0),
 				NetworkCards: []*ec2.NetworkCardInfo{
 					{
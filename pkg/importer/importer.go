@@ -0,0 +1,437 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package importer generates an EC2NodeClass and a NodePool that launch nodes equivalent to the nodes of an existing
+// launch template or auto scaling group, to ease the migration of node groups to Karpenter
+package importer
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/yaml"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+)
+
+const (
+	// DiscoveryTagKey is the tag of the subnets and security groups that are selected when the launch template or auto
+	// scaling group doesn't name them
+	DiscoveryTagKey = "karpenter.sh/discovery"
+
+	clusterAutoscalerTagPrefix = "k8s.io/cluster-autoscaler/"
+	nodeTemplateLabelTagPrefix = clusterAutoscalerTagPrefix + "node-template/label/"
+	nodeTemplateTaintTagPrefix = clusterAutoscalerTagPrefix + "node-template/taint/"
+)
+
+// Importer reads launch templates and auto scaling groups with the credentials that it's started with
+type Importer struct {
+	ec2api         ec2iface.EC2API
+	autoscalingapi autoscalingiface.AutoScalingAPI
+	clusterName    string
+}
+
+func New(ec2api ec2iface.EC2API, autoscalingapi autoscalingiface.AutoScalingAPI, clusterName string) *Importer {
+	return &Importer{
+		ec2api:         ec2api,
+		autoscalingapi: autoscalingapi,
+		clusterName:    clusterName,
+	}
+}
+
+// FromLaunchTemplate generates the EC2NodeClass and the NodePool for a version of a launch template, which is
+// referenced by its ID or name. The version is a version number, $Latest or $Default, which is the default.
+func (i *Importer) FromLaunchTemplate(ctx context.Context, launchTemplate, version, name string) (*v1.EC2NodeClass, *karpv1.NodePool, error) {
+	data, err := i.launchTemplateData(ctx, launchTemplate, version)
+	if err != nil {
+		return nil, nil, err
+	}
+	return i.generate(ctx, name, data)
+}
+
+// FromAutoScalingGroup generates the EC2NodeClass and the NodePool for the launch template of an auto scaling group.
+// The subnets, the instance types and the purchase options of the group take precedence over those of its launch
+// template, and the node-template tags of the cluster autoscaler are converted to the labels and taints of the NodePool.
+func (i *Importer) FromAutoScalingGroup(ctx context.Context, autoScalingGroup, name string) (*v1.EC2NodeClass, *karpv1.NodePool, error) {
+	out, err := i.autoscalingapi.DescribeAutoScalingGroupsWithContext(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: aws.StringSlice([]string{autoScalingGroup}),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("describing auto scaling group %q, %w", autoScalingGroup, err)
+	}
+	if len(out.AutoScalingGroups) == 0 {
+		return nil, nil, fmt.Errorf("auto scaling group %q not found", autoScalingGroup)
+	}
+	group := out.AutoScalingGroups[0]
+
+	spec := group.LaunchTemplate
+	var instanceTypes []string
+	capacityTypes := []string{karpv1.CapacityTypeOnDemand}
+	if policy := group.MixedInstancesPolicy; policy != nil && policy.LaunchTemplate != nil {
+		spec = policy.LaunchTemplate.LaunchTemplateSpecification
+		instanceTypes = lo.Uniq(lo.FilterMap(policy.LaunchTemplate.Overrides, func(o *autoscaling.LaunchTemplateOverrides, _ int) (string, bool) {
+			return aws.StringValue(o.InstanceType), o.InstanceType != nil
+		}))
+		capacityTypes = purchaseOptions(policy.InstancesDistribution)
+	}
+	if spec == nil {
+		return nil, nil, fmt.Errorf("auto scaling group %q uses a launch configuration, which must be migrated to a launch template first", autoScalingGroup)
+	}
+	data, err := i.launchTemplateData(ctx, lo.Ternary(spec.LaunchTemplateId != nil, aws.StringValue(spec.LaunchTemplateId), aws.StringValue(spec.LaunchTemplateName)), aws.StringValue(spec.Version))
+	if err != nil {
+		return nil, nil, err
+	}
+	nodeClass, nodePool, err := i.generate(ctx, name, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if subnets := lo.Compact(strings.Split(aws.StringValue(group.VPCZoneIdentifier), ",")); len(subnets) != 0 {
+		nodeClass.Spec.SubnetSelectorTerms = lo.Map(subnets, func(id string, _ int) v1.SubnetSelectorTerm { return v1.SubnetSelectorTerm{ID: strings.TrimSpace(id)} })
+	}
+	labels, taints := NodeTemplate(group.Tags)
+	nodePool.Spec.Template.Labels = lo.Assign(nodePool.Spec.Template.Labels, labels)
+	nodePool.Spec.Template.Spec.Taints = append(nodePool.Spec.Template.Spec.Taints, taints...)
+	for _, tag := range group.Tags {
+		if key := aws.StringValue(tag.Key); aws.BoolValue(tag.PropagateAtLaunch) && importableTag(key) {
+			nodeClass.Spec.Tags = lo.Assign(nodeClass.Spec.Tags, map[string]string{key: aws.StringValue(tag.Value)})
+		}
+	}
+	if len(instanceTypes) != 0 {
+		setRequirement(nodePool, corev1.LabelInstanceTypeStable, instanceTypes...)
+	}
+	if group.MixedInstancesPolicy != nil {
+		setRequirement(nodePool, karpv1.CapacityTypeLabelKey, capacityTypes...)
+	}
+	return nodeClass, nodePool, nil
+}
+
+// NodeTemplate returns the labels and the taints of the nodes of an auto scaling group, which are declared to the
+// cluster autoscaler with its node-template tags
+func NodeTemplate(tags []*autoscaling.TagDescription) (map[string]string, []corev1.Taint) {
+	labels := map[string]string{}
+	var taints []corev1.Taint
+	for _, tag := range tags {
+		key, value := aws.StringValue(tag.Key), aws.StringValue(tag.Value)
+		switch {
+		case strings.HasPrefix(key, nodeTemplateLabelTagPrefix):
+			labels[strings.TrimPrefix(key, nodeTemplateLabelTagPrefix)] = value
+		case strings.HasPrefix(key, nodeTemplateTaintTagPrefix):
+			taints = append(taints, parseTaint(strings.TrimPrefix(key, nodeTemplateTaintTagPrefix)+"="+value))
+		}
+	}
+	return labels, taints
+}
+
+// purchaseOptions returns the capacity types of the instances distribution of a mixed instances policy. On-demand
+// instances above the base capacity default to 100 percent.
+func purchaseOptions(distribution *autoscaling.InstancesDistribution) []string {
+	if distribution == nil || distribution.OnDemandPercentageAboveBaseCapacity == nil || aws.Int64Value(distribution.OnDemandPercentageAboveBaseCapacity) == 100 {
+		return []string{karpv1.CapacityTypeOnDemand}
+	}
+	if aws.Int64Value(distribution.OnDemandPercentageAboveBaseCapacity) == 0 && aws.Int64Value(distribution.OnDemandBaseCapacity) == 0 {
+		return []string{karpv1.CapacityTypeSpot}
+	}
+	return []string{karpv1.CapacityTypeSpot, karpv1.CapacityTypeOnDemand}
+}
+
+func (i *Importer) launchTemplateData(ctx context.Context, launchTemplate, version string) (*ec2.ResponseLaunchTemplateData, error) {
+	input := &ec2.DescribeLaunchTemplateVersionsInput{Versions: aws.StringSlice([]string{lo.Ternary(version == "", "$Default", version)})}
+	if strings.HasPrefix(launchTemplate, "lt-") {
+		input.LaunchTemplateId = aws.String(launchTemplate)
+	} else {
+		input.LaunchTemplateName = aws.String(launchTemplate)
+	}
+	out, err := i.ec2api.DescribeLaunchTemplateVersionsWithContext(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("describing launch template %q, %w", launchTemplate, err)
+	}
+	if len(out.LaunchTemplateVersions) == 0 || out.LaunchTemplateVersions[0].LaunchTemplateData == nil {
+		return nil, fmt.Errorf("launch template %q version %q not found", launchTemplate, aws.StringValue(input.Versions[0]))
+	}
+	return out.LaunchTemplateVersions[0].LaunchTemplateData, nil
+}
+
+func (i *Importer) generate(ctx context.Context, name string, data *ec2.ResponseLaunchTemplateData) (*v1.EC2NodeClass, *karpv1.NodePool, error) {
+	nodeClass := &v1.EC2NodeClass{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	nodeClass.SetGroupVersionKind(object.GVK(nodeClass))
+	nodePool := &karpv1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: karpv1.NodePoolSpec{
+			Template: karpv1.NodeClaimTemplate{
+				Spec: karpv1.NodeClaimSpec{
+					NodeClassRef: &karpv1.NodeClassReference{Group: object.GVK(nodeClass).Group, Kind: object.GVK(nodeClass).Kind, Name: name},
+					// The instances of launch templates and auto scaling groups aren't replaced as they age
+					ExpireAfter: karpv1.NillableDuration{},
+				},
+			},
+		},
+	}
+	nodePool.SetGroupVersionKind(object.GVK(nodePool))
+
+	family, err := i.resolveImage(ctx, nodeClass, nodePool, aws.StringValue(data.ImageId))
+	if err != nil {
+		return nil, nil, err
+	}
+	if data.UserData != nil {
+		userData, err := base64.StdEncoding.DecodeString(aws.StringValue(data.UserData))
+		if err != nil {
+			return nil, nil, fmt.Errorf("decoding user data, %w", err)
+		}
+		if err = splitUserData(family, string(userData), nodeClass, nodePool); err != nil {
+			return nil, nil, fmt.Errorf("splitting user data, %w", err)
+		}
+	}
+	if err = i.network(nodeClass, data); err != nil {
+		return nil, nil, err
+	}
+	if profile := data.IamInstanceProfile; profile != nil {
+		nodeClass.Spec.InstanceProfile = lo.ToPtr(instanceProfileName(profile))
+	}
+	nodeClass.Spec.BlockDeviceMappings = blockDeviceMappings(data.BlockDeviceMappings)
+	if options := data.MetadataOptions; options != nil {
+		nodeClass.Spec.MetadataOptions = &v1.MetadataOptions{
+			HTTPEndpoint:            options.HttpEndpoint,
+			HTTPProtocolIPv6:        options.HttpProtocolIpv6,
+			HTTPPutResponseHopLimit: options.HttpPutResponseHopLimit,
+			HTTPTokens:              options.HttpTokens,
+		}
+	}
+	if data.Monitoring != nil {
+		nodeClass.Spec.DetailedMonitoring = data.Monitoring.Enabled
+	}
+	for _, spec := range data.TagSpecifications {
+		if aws.StringValue(spec.ResourceType) != ec2.ResourceTypeInstance {
+			continue
+		}
+		for _, tag := range spec.Tags {
+			if importableTag(aws.StringValue(tag.Key)) {
+				nodeClass.Spec.Tags = lo.Assign(nodeClass.Spec.Tags, map[string]string{aws.StringValue(tag.Key): aws.StringValue(tag.Value)})
+			}
+		}
+	}
+
+	if data.InstanceType != nil {
+		setRequirement(nodePool, corev1.LabelInstanceTypeStable, aws.StringValue(data.InstanceType))
+	}
+	if options := data.InstanceMarketOptions; options != nil && aws.StringValue(options.MarketType) == ec2.MarketTypeSpot {
+		setRequirement(nodePool, karpv1.CapacityTypeLabelKey, karpv1.CapacityTypeSpot)
+	} else {
+		setRequirement(nodePool, karpv1.CapacityTypeLabelKey, karpv1.CapacityTypeOnDemand)
+	}
+	return nodeClass, nodePool, nil
+}
+
+var (
+	al2023ImageName       = regexp.MustCompile(`^amazon-eks-node-al2023-.*-(v\d{8})$`)
+	al2ImageName          = regexp.MustCompile(`^amazon-eks-(?:gpu-|arm64-)?node-[\d.]+-(v\d{8})$`)
+	bottlerocketImageName = regexp.MustCompile(`^bottlerocket-aws-k8s-.*-(v\d+\.\d+\.\d+)-[0-9a-f]+$`)
+	windowsImageName      = regexp.MustCompile(`^Windows_Server-(2019|2022)-English-(?:Core|Full)-EKS_Optimized-`)
+)
+
+// resolveImage selects the AMI of the launch template. EKS optimized AMIs are selected with an alias that is pinned to
+// their release, so that Karpenter generates their bootstrap configuration. Other AMIs are selected by their ID, and
+// their user data must bootstrap the node.
+func (i *Importer) resolveImage(ctx context.Context, nodeClass *v1.EC2NodeClass, nodePool *karpv1.NodePool, imageID string) (string, error) {
+	if imageID == "" {
+		return "", fmt.Errorf("launch template doesn't specify an AMI")
+	}
+	if parameter, ok := strings.CutPrefix(imageID, "resolve:ssm:"); ok {
+		family, ok := ssmParameterFamily(parameter)
+		if !ok {
+			return "", fmt.Errorf("AMI is resolved from SSM parameter %q, which isn't the parameter of an EKS optimized AMI", parameter)
+		}
+		nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: fmt.Sprintf("%s@latest", strings.ToLower(family))}}
+		return family, nil
+	}
+	out, err := i.ec2api.DescribeImagesWithContext(ctx, &ec2.DescribeImagesInput{ImageIds: aws.StringSlice([]string{imageID})})
+	if err != nil {
+		return "", fmt.Errorf("describing AMI %q, %w", imageID, err)
+	}
+	if len(out.Images) == 0 {
+		return "", fmt.Errorf("AMI %q not found", imageID)
+	}
+	image := out.Images[0]
+	switch aws.StringValue(image.Architecture) {
+	case ec2.ArchitectureValuesX8664:
+		setRequirement(nodePool, corev1.LabelArchStable, karpv1.ArchitectureAmd64)
+	case ec2.ArchitectureValuesArm64:
+		setRequirement(nodePool, corev1.LabelArchStable, karpv1.ArchitectureArm64)
+	}
+	family, version := imageRelease(aws.StringValue(image.Name))
+	if family == v1.AMIFamilyCustom {
+		nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{ID: imageID}}
+	} else {
+		nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: fmt.Sprintf("%s@%s", strings.ToLower(family), version)}}
+	}
+	return family, nil
+}
+
+// imageRelease returns the family and the version of an EKS optimized AMI from its name. The Windows families only
+// support the latest version.
+func imageRelease(name string) (string, string) {
+	if m := al2023ImageName.FindStringSubmatch(name); m != nil {
+		return v1.AMIFamilyAL2023, m[1]
+	}
+	if m := al2ImageName.FindStringSubmatch(name); m != nil {
+		return v1.AMIFamilyAL2, m[1]
+	}
+	if m := bottlerocketImageName.FindStringSubmatch(name); m != nil {
+		return v1.AMIFamilyBottlerocket, m[1]
+	}
+	if m := windowsImageName.FindStringSubmatch(name); m != nil {
+		return lo.Ternary(m[1] == "2019", v1.AMIFamilyWindows2019, v1.AMIFamilyWindows2022), "latest"
+	}
+	return v1.AMIFamilyCustom, ""
+}
+
+func ssmParameterFamily(parameter string) (string, bool) {
+	switch {
+	case strings.HasPrefix(parameter, "/aws/service/eks/optimized-ami/") && strings.Contains(parameter, "/amazon-linux-2023/"):
+		return v1.AMIFamilyAL2023, true
+	case strings.HasPrefix(parameter, "/aws/service/eks/optimized-ami/") && strings.Contains(parameter, "/amazon-linux-2"):
+		return v1.AMIFamilyAL2, true
+	case strings.HasPrefix(parameter, "/aws/service/bottlerocket/aws-k8s-"):
+		return v1.AMIFamilyBottlerocket, true
+	case strings.HasPrefix(parameter, "/aws/service/ami-windows-latest/Windows_Server-2019-"):
+		return v1.AMIFamilyWindows2019, true
+	case strings.HasPrefix(parameter, "/aws/service/ami-windows-latest/Windows_Server-2022-"):
+		return v1.AMIFamilyWindows2022, true
+	}
+	return "", false
+}
+
+// network selects the subnets and the security groups of the launch template, either directly or through its primary
+// network interface, and falls back to the discovery tag of the cluster
+func (i *Importer) network(nodeClass *v1.EC2NodeClass, data *ec2.ResponseLaunchTemplateData) error {
+	securityGroupIDs, securityGroupNames := aws.StringValueSlice(data.SecurityGroupIds), aws.StringValueSlice(data.SecurityGroups)
+	var subnetIDs []string
+	if eni, ok := lo.Find(data.NetworkInterfaces, func(eni *ec2.LaunchTemplateInstanceNetworkInterfaceSpecification) bool {
+		return aws.Int64Value(eni.DeviceIndex) == 0
+	}); ok {
+		securityGroupIDs = append(securityGroupIDs, aws.StringValueSlice(eni.Groups)...)
+		if eni.SubnetId != nil {
+			subnetIDs = append(subnetIDs, aws.StringValue(eni.SubnetId))
+		}
+		nodeClass.Spec.AssociatePublicIPAddress = eni.AssociatePublicIpAddress
+	}
+	nodeClass.Spec.SecurityGroupSelectorTerms = append(
+		lo.Map(lo.Uniq(securityGroupIDs), func(id string, _ int) v1.SecurityGroupSelectorTerm { return v1.SecurityGroupSelectorTerm{ID: id} }),
+		lo.Map(lo.Uniq(securityGroupNames), func(name string, _ int) v1.SecurityGroupSelectorTerm { return v1.SecurityGroupSelectorTerm{Name: name} })...,
+	)
+	nodeClass.Spec.SubnetSelectorTerms = lo.Map(subnetIDs, func(id string, _ int) v1.SubnetSelectorTerm { return v1.SubnetSelectorTerm{ID: id} })
+	if len(nodeClass.Spec.SecurityGroupSelectorTerms) != 0 && len(nodeClass.Spec.SubnetSelectorTerms) != 0 {
+		return nil
+	}
+	if i.clusterName == "" {
+		return fmt.Errorf("launch template doesn't specify its subnets or security groups, and no cluster name is set to discover them by the %s tag", DiscoveryTagKey)
+	}
+	if len(nodeClass.Spec.SecurityGroupSelectorTerms) == 0 {
+		nodeClass.Spec.SecurityGroupSelectorTerms = []v1.SecurityGroupSelectorTerm{{Tags: map[string]string{DiscoveryTagKey: i.clusterName}}}
+	}
+	if len(nodeClass.Spec.SubnetSelectorTerms) == 0 {
+		nodeClass.Spec.SubnetSelectorTerms = []v1.SubnetSelectorTerm{{Tags: map[string]string{DiscoveryTagKey: i.clusterName}}}
+	}
+	return nil
+}
+
+// instanceProfileName returns the name of the instance profile, which is the last segment of the resource of its ARN
+func instanceProfileName(profile *ec2.LaunchTemplateIamInstanceProfileSpecification) string {
+	if profile.Name != nil {
+		return aws.StringValue(profile.Name)
+	}
+	a, err := arn.Parse(aws.StringValue(profile.Arn))
+	if err != nil {
+		return aws.StringValue(profile.Arn)
+	}
+	return a.Resource[strings.LastIndex(a.Resource, "/")+1:]
+}
+
+func blockDeviceMappings(mappings []*ec2.LaunchTemplateBlockDeviceMapping) []*v1.BlockDeviceMapping {
+	// Instance store volumes and suppressed devices of the AMI aren't EBS volumes, which are the only block devices
+	// that an EC2NodeClass maps
+	return lo.FilterMap(mappings, func(m *ec2.LaunchTemplateBlockDeviceMapping, _ int) (*v1.BlockDeviceMapping, bool) {
+		if m.Ebs == nil {
+			return nil, false
+		}
+		return &v1.BlockDeviceMapping{
+			DeviceName: m.DeviceName,
+			EBS: &v1.BlockDevice{
+				DeleteOnTermination: m.Ebs.DeleteOnTermination,
+				Encrypted:           m.Ebs.Encrypted,
+				IOPS:                m.Ebs.Iops,
+				KMSKeyID:            m.Ebs.KmsKeyId,
+				SnapshotID:          m.Ebs.SnapshotId,
+				Throughput:          m.Ebs.Throughput,
+				VolumeSize:          lo.Ternary(m.Ebs.VolumeSize != nil, lo.ToPtr(resource.MustParse(fmt.Sprintf("%dGi", aws.Int64Value(m.Ebs.VolumeSize)))), nil),
+				VolumeType:          m.Ebs.VolumeType,
+			},
+		}, true
+	})
+}
+
+// importableTag returns false for the tags that AWS manages, the tags that Karpenter sets itself and the tags of the
+// cluster autoscaler, which don't apply to nodes that Karpenter launches
+func importableTag(key string) bool {
+	return !strings.HasPrefix(key, "aws:") &&
+		!strings.HasPrefix(key, clusterAutoscalerTagPrefix) &&
+		!strings.HasPrefix(key, "eks:") &&
+		!lo.ContainsBy(v1.RestrictedTagPatterns, func(r *regexp.Regexp) bool { return r.MatchString(key) })
+}
+
+func setRequirement(nodePool *karpv1.NodePool, key string, values ...string) {
+	nodePool.Spec.Template.Spec.Requirements = append(lo.Reject(nodePool.Spec.Template.Spec.Requirements, func(r karpv1.NodeSelectorRequirementWithMinValues, _ int) bool {
+		return r.Key == key
+	}), karpv1.NodeSelectorRequirementWithMinValues{
+		NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: key, Operator: corev1.NodeSelectorOpIn, Values: values},
+	})
+}
+
+// Marshal returns the objects as a stream of YAML documents, without their status and the fields that the API server
+// sets, so that they can be applied as is
+func Marshal(objects ...runtime.Object) ([]byte, error) {
+	var documents []string
+	for _, o := range objects {
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(o)
+		if err != nil {
+			return nil, fmt.Errorf("converting %s, %w", object.GVK(o).Kind, err)
+		}
+		unstructured.RemoveNestedField(u, "status")
+		unstructured.RemoveNestedField(u, "metadata", "creationTimestamp")
+		unstructured.RemoveNestedField(u, "spec", "template", "spec", "resources")
+		document, err := yaml.Marshal(u)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling %s, %w", object.GVK(o).Kind, err)
+		}
+		documents = append(documents, string(document))
+	}
+	return []byte(strings.Join(documents, "---\n")), nil
+}
@@ -0,0 +1,287 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importer_test
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/importer"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var ec2api *fake.EC2API
+var autoscalingapi *fake.AutoScalingAPI
+var imp *importer.Importer
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Importer")
+}
+
+const al2UserData = `#!/bin/bash
+set -o xtrace
+echo "configuring node"
+/etc/eks/bootstrap.sh test-cluster \
+  --kubelet-extra-args '--node-labels=team=ml,kubernetes.io/role=worker --register-with-taints=dedicated=ml:NoSchedule --max-pods=58'
+`
+
+func expectRequirement(nodePool *karpv1.NodePool, key string, values ...string) {
+	GinkgoHelper()
+	requirement, ok := lo.Find(nodePool.Spec.Template.Spec.Requirements, func(r karpv1.NodeSelectorRequirementWithMinValues) bool { return r.Key == key })
+	Expect(ok).To(BeTrue())
+	Expect(requirement.Operator).To(Equal(corev1.NodeSelectorOpIn))
+	Expect(requirement.Values).To(ConsistOf(values))
+}
+
+func launchTemplate(data *ec2.ResponseLaunchTemplateData) {
+	ec2api.DescribeLaunchTemplateVersionsBehavior.Output.Set(&ec2.DescribeLaunchTemplateVersionsOutput{
+		LaunchTemplateVersions: []*ec2.LaunchTemplateVersion{{LaunchTemplateData: data}},
+	})
+}
+
+var _ = BeforeEach(func() {
+	ec2api = fake.NewEC2API()
+	autoscalingapi = &fake.AutoScalingAPI{}
+	imp = importer.New(ec2api, autoscalingapi, "test-cluster")
+	ec2api.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []*ec2.Image{{
+		ImageId:      aws.String("ami-123"),
+		Name:         aws.String("amazon-eks-node-1.30-v20240807"),
+		Architecture: aws.String(ec2.ArchitectureValuesX8664),
+	}}})
+	launchTemplate(&ec2.ResponseLaunchTemplateData{
+		ImageId:            aws.String("ami-123"),
+		InstanceType:       aws.String("m5.large"),
+		IamInstanceProfile: &ec2.LaunchTemplateIamInstanceProfileSpecification{Arn: aws.String("arn:aws:iam::123456789012:instance-profile/nodes/NodeInstanceProfile")},
+		UserData:           aws.String(base64.StdEncoding.EncodeToString([]byte(al2UserData))),
+		NetworkInterfaces: []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecification{{
+			DeviceIndex:              aws.Int64(0),
+			SubnetId:                 aws.String("subnet-123"),
+			Groups:                   aws.StringSlice([]string{"sg-123", "sg-456"}),
+			AssociatePublicIpAddress: aws.Bool(false),
+		}},
+		BlockDeviceMappings: []*ec2.LaunchTemplateBlockDeviceMapping{
+			{DeviceName: aws.String("/dev/xvda"), Ebs: &ec2.LaunchTemplateEbsBlockDevice{VolumeSize: aws.Int64(100), VolumeType: aws.String("gp3"), Encrypted: aws.Bool(true), Iops: aws.Int64(3000)}},
+			{DeviceName: aws.String("/dev/sdb"), VirtualName: aws.String("ephemeral0")},
+		},
+		MetadataOptions: &ec2.LaunchTemplateInstanceMetadataOptions{HttpTokens: aws.String("required"), HttpPutResponseHopLimit: aws.Int64(1)},
+		Monitoring:      &ec2.LaunchTemplatesMonitoring{Enabled: aws.Bool(true)},
+		TagSpecifications: []*ec2.LaunchTemplateTagSpecification{
+			{ResourceType: aws.String(ec2.ResourceTypeInstance), Tags: []*ec2.Tag{
+				{Key: aws.String("team"), Value: aws.String("ml")},
+				{Key: aws.String("kubernetes.io/cluster/test-cluster"), Value: aws.String("owned")},
+				{Key: aws.String("k8s.io/cluster-autoscaler/enabled"), Value: aws.String("true")},
+			}},
+			{ResourceType: aws.String(ec2.ResourceTypeVolume), Tags: []*ec2.Tag{{Key: aws.String("backup"), Value: aws.String("daily")}}},
+		},
+	})
+})
+
+var _ = Describe("Importer", func() {
+	Context("Launch Templates", func() {
+		It("should select the launch template by its ID or name and version", func() {
+			_, _, err := imp.FromLaunchTemplate(ctx, "lt-123", "3", "ml")
+			Expect(err).ToNot(HaveOccurred())
+			input := ec2api.DescribeLaunchTemplateVersionsBehavior.CalledWithInput.Pop()
+			Expect(aws.StringValue(input.LaunchTemplateId)).To(Equal("lt-123"))
+			Expect(aws.StringValueSlice(input.Versions)).To(ConsistOf("3"))
+
+			_, _, err = imp.FromLaunchTemplate(ctx, "ml-nodes", "", "ml")
+			Expect(err).ToNot(HaveOccurred())
+			input = ec2api.DescribeLaunchTemplateVersionsBehavior.CalledWithInput.Pop()
+			Expect(aws.StringValue(input.LaunchTemplateName)).To(Equal("ml-nodes"))
+			Expect(aws.StringValueSlice(input.Versions)).To(ConsistOf("$Default"))
+		})
+		It("should pin the alias of an EKS optimized AMI to its release", func() {
+			for name, alias := range map[string]string{
+				"amazon-eks-node-1.30-v20240807":                        "al2@v20240807",
+				"amazon-eks-gpu-node-1.30-v20240807":                    "al2@v20240807",
+				"amazon-eks-node-al2023-x86_64-standard-1.30-v20240807": "al2023@v20240807",
+				"bottlerocket-aws-k8s-1.30-x86_64-v1.20.5-a3e8bda1":     "bottlerocket@v1.20.5",
+				"Windows_Server-2022-English-Core-EKS_Optimized-1.30":   "windows2022@latest",
+			} {
+				ec2api.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []*ec2.Image{{ImageId: aws.String("ami-123"), Name: aws.String(name)}}})
+				nodeClass, _, err := imp.FromLaunchTemplate(ctx, "lt-123", "", "ml")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(nodeClass.Spec.AMISelectorTerms).To(ConsistOf(v1.AMISelectorTerm{Alias: alias}))
+			}
+		})
+		It("should select a custom AMI by its ID and keep its user data", func() {
+			ec2api.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []*ec2.Image{{ImageId: aws.String("ami-123"), Name: aws.String("golden-image-2024")}}})
+			nodeClass, _, err := imp.FromLaunchTemplate(ctx, "lt-123", "", "ml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nodeClass.Spec.AMISelectorTerms).To(ConsistOf(v1.AMISelectorTerm{ID: "ami-123"}))
+			Expect(nodeClass.AMIFamily()).To(Equal(v1.AMIFamilyCustom))
+			Expect(lo.FromPtr(nodeClass.Spec.UserData)).To(Equal(al2UserData))
+		})
+		It("should select the latest release of an AMI that is resolved from an SSM parameter", func() {
+			launchTemplate(&ec2.ResponseLaunchTemplateData{
+				ImageId:          aws.String("resolve:ssm:/aws/service/eks/optimized-ami/1.30/amazon-linux-2023/x86_64/standard/recommended/image_id"),
+				SecurityGroupIds: aws.StringSlice([]string{"sg-123"}),
+			})
+			nodeClass, _, err := imp.FromLaunchTemplate(ctx, "lt-123", "", "ml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nodeClass.Spec.AMISelectorTerms).To(ConsistOf(v1.AMISelectorTerm{Alias: "al2023@latest"}))
+		})
+		It("should remove the call to the bootstrap script from AL2 user data and convert its kubelet flags", func() {
+			nodeClass, nodePool, err := imp.FromLaunchTemplate(ctx, "lt-123", "", "ml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lo.FromPtr(nodeClass.Spec.UserData)).To(Equal("#!/bin/bash\nset -o xtrace\necho \"configuring node\"\n"))
+			Expect(lo.FromPtr(nodeClass.Spec.Kubelet.MaxPods)).To(BeNumerically("==", 58))
+			// Labels in the restricted kubernetes.io domain are dropped
+			Expect(nodePool.Spec.Template.Labels).To(Equal(map[string]string{"team": "ml"}))
+			Expect(nodePool.Spec.Template.Spec.Taints).To(ConsistOf(corev1.Taint{Key: "dedicated", Value: "ml", Effect: corev1.TaintEffectNoSchedule}))
+		})
+		It("should remove the parts of MIME user data that only call the bootstrap script", func() {
+			launchTemplate(&ec2.ResponseLaunchTemplateData{
+				ImageId:          aws.String("ami-123"),
+				SecurityGroupIds: aws.StringSlice([]string{"sg-123"}),
+				UserData: aws.String(base64.StdEncoding.EncodeToString([]byte(`MIME-Version: 1.0
+Content-Type: multipart/mixed; boundary="BOUNDARY"
+
+--BOUNDARY
+Content-Type: text/x-shellscript; charset="us-ascii"
+
+#!/bin/bash
+yum install -y amazon-ssm-agent
+
+--BOUNDARY
+Content-Type: text/x-shellscript; charset="us-ascii"
+
+#!/bin/bash
+/etc/eks/bootstrap.sh test-cluster --kubelet-extra-args '--max-pods=110'
+
+--BOUNDARY--
+`))),
+			})
+			nodeClass, _, err := imp.FromLaunchTemplate(ctx, "lt-123", "", "ml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lo.FromPtr(nodeClass.Spec.UserData)).To(ContainSubstring("yum install -y amazon-ssm-agent"))
+			Expect(lo.FromPtr(nodeClass.Spec.UserData)).ToNot(ContainSubstring("bootstrap.sh"))
+			Expect(lo.FromPtr(nodeClass.Spec.Kubelet.MaxPods)).To(BeNumerically("==", 110))
+		})
+		It("should convert the network, instance profile, block devices, metadata options and tags", func() {
+			nodeClass, nodePool, err := imp.FromLaunchTemplate(ctx, "lt-123", "", "ml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nodeClass.Name).To(Equal("ml"))
+			Expect(nodeClass.Spec.SubnetSelectorTerms).To(ConsistOf(v1.SubnetSelectorTerm{ID: "subnet-123"}))
+			Expect(nodeClass.Spec.SecurityGroupSelectorTerms).To(ConsistOf(v1.SecurityGroupSelectorTerm{ID: "sg-123"}, v1.SecurityGroupSelectorTerm{ID: "sg-456"}))
+			Expect(lo.FromPtr(nodeClass.Spec.AssociatePublicIPAddress)).To(BeFalse())
+			Expect(lo.FromPtr(nodeClass.Spec.InstanceProfile)).To(Equal("NodeInstanceProfile"))
+			Expect(nodeClass.Spec.BlockDeviceMappings).To(HaveLen(1))
+			Expect(lo.FromPtr(nodeClass.Spec.BlockDeviceMappings[0].DeviceName)).To(Equal("/dev/xvda"))
+			Expect(nodeClass.Spec.BlockDeviceMappings[0].EBS.VolumeSize.Equal(resource.MustParse("100Gi"))).To(BeTrue())
+			Expect(lo.FromPtr(nodeClass.Spec.BlockDeviceMappings[0].EBS.VolumeType)).To(Equal("gp3"))
+			Expect(lo.FromPtr(nodeClass.Spec.MetadataOptions.HTTPTokens)).To(Equal("required"))
+			Expect(lo.FromPtr(nodeClass.Spec.MetadataOptions.HTTPPutResponseHopLimit)).To(BeNumerically("==", 1))
+			Expect(lo.FromPtr(nodeClass.Spec.DetailedMonitoring)).To(BeTrue())
+			Expect(nodeClass.Spec.Tags).To(Equal(map[string]string{"team": "ml"}))
+
+			Expect(nodePool.Spec.Template.Spec.NodeClassRef).To(Equal(&karpv1.NodeClassReference{Group: "karpenter.k8s.aws", Kind: "EC2NodeClass", Name: "ml"}))
+			expectRequirement(nodePool, corev1.LabelInstanceTypeStable, "m5.large")
+			expectRequirement(nodePool, corev1.LabelArchStable, karpv1.ArchitectureAmd64)
+			expectRequirement(nodePool, karpv1.CapacityTypeLabelKey, karpv1.CapacityTypeOnDemand)
+		})
+		It("should discover the subnets and security groups by the tag of the cluster when the launch template doesn't specify them", func() {
+			launchTemplate(&ec2.ResponseLaunchTemplateData{ImageId: aws.String("ami-123")})
+			nodeClass, _, err := imp.FromLaunchTemplate(ctx, "lt-123", "", "ml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nodeClass.Spec.SubnetSelectorTerms).To(ConsistOf(v1.SubnetSelectorTerm{Tags: map[string]string{importer.DiscoveryTagKey: "test-cluster"}}))
+			Expect(nodeClass.Spec.SecurityGroupSelectorTerms).To(ConsistOf(v1.SecurityGroupSelectorTerm{Tags: map[string]string{importer.DiscoveryTagKey: "test-cluster"}}))
+
+			_, _, err = importer.New(ec2api, autoscalingapi, "").FromLaunchTemplate(ctx, "lt-123", "", "ml")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when the launch template version doesn't exist", func() {
+			ec2api.DescribeLaunchTemplateVersionsBehavior.Output.Set(&ec2.DescribeLaunchTemplateVersionsOutput{})
+			_, _, err := imp.FromLaunchTemplate(ctx, "lt-123", "7", "ml")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Context("Auto Scaling Groups", func() {
+		It("should take the subnets, instance types, purchase options and node template of the group", func() {
+			autoscalingapi.DescribeAutoScalingGroupsBehavior.Output.Set(&autoscaling.DescribeAutoScalingGroupsOutput{AutoScalingGroups: []*autoscaling.Group{{
+				AutoScalingGroupName: aws.String("ml-nodes"),
+				VPCZoneIdentifier:    aws.String("subnet-a,subnet-b"),
+				MixedInstancesPolicy: &autoscaling.MixedInstancesPolicy{
+					LaunchTemplate: &autoscaling.LaunchTemplate{
+						LaunchTemplateSpecification: &autoscaling.LaunchTemplateSpecification{LaunchTemplateId: aws.String("lt-123"), Version: aws.String("$Latest")},
+						Overrides: []*autoscaling.LaunchTemplateOverrides{
+							{InstanceType: aws.String("m5.large")},
+							{InstanceType: aws.String("m5a.large")},
+						},
+					},
+					InstancesDistribution: &autoscaling.InstancesDistribution{OnDemandBaseCapacity: aws.Int64(1), OnDemandPercentageAboveBaseCapacity: aws.Int64(0)},
+				},
+				Tags: []*autoscaling.TagDescription{
+					{Key: aws.String("k8s.io/cluster-autoscaler/node-template/label/workload"), Value: aws.String("training")},
+					{Key: aws.String("k8s.io/cluster-autoscaler/node-template/taint/gpu"), Value: aws.String("true:NoExecute")},
+					{Key: aws.String("cost-center"), Value: aws.String("1234"), PropagateAtLaunch: aws.Bool(true)},
+					{Key: aws.String("owner"), Value: aws.String("ml-team"), PropagateAtLaunch: aws.Bool(false)},
+				},
+			}}})
+			nodeClass, nodePool, err := imp.FromAutoScalingGroup(ctx, "ml-nodes", "ml")
+			Expect(err).ToNot(HaveOccurred())
+			input := ec2api.DescribeLaunchTemplateVersionsBehavior.CalledWithInput.Pop()
+			Expect(aws.StringValue(input.LaunchTemplateId)).To(Equal("lt-123"))
+			Expect(aws.StringValueSlice(input.Versions)).To(ConsistOf("$Latest"))
+
+			Expect(nodeClass.Spec.SubnetSelectorTerms).To(ConsistOf(v1.SubnetSelectorTerm{ID: "subnet-a"}, v1.SubnetSelectorTerm{ID: "subnet-b"}))
+			Expect(nodeClass.Spec.Tags).To(Equal(map[string]string{"team": "ml", "cost-center": "1234"}))
+			expectRequirement(nodePool, corev1.LabelInstanceTypeStable, "m5.large", "m5a.large")
+			expectRequirement(nodePool, karpv1.CapacityTypeLabelKey, karpv1.CapacityTypeSpot, karpv1.CapacityTypeOnDemand)
+			Expect(nodePool.Spec.Template.Labels).To(HaveKeyWithValue("workload", "training"))
+			Expect(nodePool.Spec.Template.Spec.Taints).To(ContainElement(corev1.Taint{Key: "gpu", Value: "true", Effect: corev1.TaintEffectNoExecute}))
+		})
+		It("should fail when the group uses a launch configuration", func() {
+			autoscalingapi.DescribeAutoScalingGroupsBehavior.Output.Set(&autoscaling.DescribeAutoScalingGroupsOutput{AutoScalingGroups: []*autoscaling.Group{{
+				AutoScalingGroupName:    aws.String("ml-nodes"),
+				LaunchConfigurationName: aws.String("ml-nodes"),
+			}}})
+			_, _, err := imp.FromAutoScalingGroup(ctx, "ml-nodes", "ml")
+			Expect(err).To(MatchError(ContainSubstring("launch configuration")))
+		})
+		It("should fail when the group doesn't exist", func() {
+			_, _, err := imp.FromAutoScalingGroup(ctx, "ml-nodes", "ml")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	It("should marshal the objects without their status", func() {
+		nodeClass, nodePool, err := imp.FromLaunchTemplate(ctx, "lt-123", "", "ml")
+		Expect(err).ToNot(HaveOccurred())
+		out, err := importer.Marshal(nodeClass, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(out)).To(ContainSubstring("apiVersion: karpenter.k8s.aws/v1\nkind: EC2NodeClass\n"))
+		Expect(string(out)).To(ContainSubstring("---\napiVersion: karpenter.sh/v1\n"))
+		Expect(string(out)).ToNot(ContainSubstring("status:"))
+		Expect(string(out)).ToNot(ContainSubstring("creationTimestamp"))
+	})
+})
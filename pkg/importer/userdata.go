@@ -0,0 +1,126 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importer
+
+import (
+	"encoding/base64"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily/bootstrap/mime"
+)
+
+var kubeletExtraArgs = regexp.MustCompile(`--kubelet-extra-args[= ]+(?:'([^']*)'|"([^"]*)"|(\S+))`)
+
+// splitUserData sets the user data of the EC2NodeClass. Karpenter generates the call to the bootstrap script of the
+// AL2 AMIs itself, so the call is removed from the user data of AL2 launch templates and the kubelet flags that it
+// passes are converted to the labels and taints of the NodePool and to the kubelet configuration of the EC2NodeClass.
+// The user data of the other families is merged with the configuration that Karpenter generates, so it's kept as is.
+func splitUserData(family string, userData string, nodeClass *v1.EC2NodeClass, nodePool *karpv1.NodePool) error {
+	if family != v1.AMIFamilyAL2 {
+		nodeClass.Spec.UserData = lo.Ternary(userData != "", lo.ToPtr(userData), nil)
+		return nil
+	}
+	if !strings.HasPrefix(userData, "MIME-Version:") {
+		if script := stripBootstrap(userData, nodeClass, nodePool); script != "" {
+			nodeClass.Spec.UserData = lo.ToPtr(script)
+		}
+		return nil
+	}
+	archive, err := mime.NewArchive(userData)
+	if err != nil {
+		return err
+	}
+	archive = lo.FilterMap(archive, func(entry mime.Entry, _ int) (mime.Entry, bool) {
+		if !strings.HasPrefix(string(entry.ContentType), "text/x-shellscript") {
+			return entry, true
+		}
+		entry.Content = stripBootstrap(entry.Content, nodeClass, nodePool)
+		return entry, entry.Content != ""
+	})
+	if len(archive) == 0 {
+		return nil
+	}
+	serialized, err := archive.Serialize()
+	if err != nil {
+		return err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(serialized)
+	if err != nil {
+		return err
+	}
+	nodeClass.Spec.UserData = lo.ToPtr(string(decoded))
+	return nil
+}
+
+// stripBootstrap removes the calls to the bootstrap script from a shell script and returns the rest of the script, or
+// an empty string when nothing but comments remain
+func stripBootstrap(script string, nodeClass *v1.EC2NodeClass, nodePool *karpv1.NodePool) string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(script, "\\\n", " "), "\n") {
+		if !strings.Contains(line, "/etc/eks/bootstrap.sh") {
+			lines = append(lines, line)
+			continue
+		}
+		if m := kubeletExtraArgs.FindStringSubmatch(line); m != nil {
+			applyKubeletFlags(strings.Fields(m[1]+m[2]+m[3]), nodeClass, nodePool)
+		}
+	}
+	if lo.EveryBy(lines, func(line string) bool {
+		return strings.HasPrefix(strings.TrimSpace(line), "#") || strings.TrimSpace(line) == ""
+	}) {
+		return ""
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")) + "\n"
+}
+
+func applyKubeletFlags(args []string, nodeClass *v1.EC2NodeClass, nodePool *karpv1.NodePool) {
+	for i, arg := range args {
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok && i+1 < len(args) {
+			value = args[i+1]
+		}
+		switch name {
+		case "--node-labels":
+			for _, pair := range strings.Split(value, ",") {
+				if key, val, ok := strings.Cut(pair, "="); ok && karpv1.IsRestrictedLabel(key) == nil {
+					nodePool.Spec.Template.Labels = lo.Assign(nodePool.Spec.Template.Labels, map[string]string{key: val})
+				}
+			}
+		case "--register-with-taints":
+			for _, taint := range strings.Split(value, ",") {
+				nodePool.Spec.Template.Spec.Taints = append(nodePool.Spec.Template.Spec.Taints, parseTaint(taint))
+			}
+		case "--max-pods":
+			if maxPods, err := strconv.ParseInt(value, 10, 32); err == nil {
+				nodeClass.Spec.Kubelet = lo.Ternary(nodeClass.Spec.Kubelet != nil, nodeClass.Spec.Kubelet, &v1.KubeletConfiguration{})
+				nodeClass.Spec.Kubelet.MaxPods = lo.ToPtr(int32(maxPods))
+			}
+		}
+	}
+}
+
+// parseTaint parses a taint in the key=value:effect or key:effect format of the kubelet and the cluster autoscaler
+func parseTaint(s string) corev1.Taint {
+	rest, effect, _ := strings.Cut(s, ":")
+	key, value, _ := strings.Cut(rest, "=")
+	return corev1.Taint{Key: key, Value: value, Effect: corev1.TaintEffect(effect)}
+}
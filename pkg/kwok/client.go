@@ -0,0 +1,45 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kwok
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+)
+
+// Client labels the nodes that the kwok cloud provider creates with the labels that AWS nodes get from the offering
+// they're launched into, which the kwok cloud provider can't derive from the requirements of the instance type. The
+// kwok cloud provider creates its nodes itself, since they don't have a kubelet to register them.
+type Client struct {
+	client.Client
+	zoneIDs map[string]string
+}
+
+func NewClient(kubeClient client.Client, instanceTypes *InstanceTypes) *Client {
+	return &Client{Client: kubeClient, zoneIDs: instanceTypes.ZoneIDs}
+}
+
+func (c *Client) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if node, ok := obj.(*corev1.Node); ok && node.Labels != nil {
+		if zoneID, ok := c.zoneIDs[node.Labels[corev1.LabelTopologyZone]]; ok {
+			node.Labels[v1.LabelTopologyZoneID] = zoneID
+		}
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
@@ -0,0 +1,102 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kwok builds the instance types of the AWS cloud provider for the kwok cloud provider, so that scale tests
+// with kwok nodes schedule against the labels, capacities, offerings and prices that Karpenter sees on AWS
+package kwok
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+)
+
+// InstanceTypes are the instance types of a region, and the IDs of its zones, which the kwok nodes are labeled with
+type InstanceTypes struct {
+	InstanceTypes []*cloudprovider.InstanceType
+	ZoneIDs       map[string]string
+}
+
+// ConstructInstanceTypes returns the instance types of the AWS cloud provider in region. They're built from a recording
+// of the region's EC2 and Pricing APIs when recordingPath is set, which is captured with "make recording", or from the
+// instance types of the fake EC2 API and the static price list of the region when it isn't. Each zone of the region is
+// treated as though the EC2NodeClass had a subnet in it.
+func ConstructInstanceTypes(ctx context.Context, region string, recordingPath string) (*InstanceTypes, error) {
+	ec2api := fake.NewEC2API()
+	pricingapi := &fake.PricingAPI{}
+	if recordingPath != "" {
+		recording, err := fake.LoadRecording(recordingPath)
+		if err != nil {
+			return nil, err
+		}
+		region = recording.Region
+		ec2api.Replay(recording)
+		pricingapi.Replay(recording)
+	}
+	pricingProvider := pricing.NewDefaultProvider(ctx, pricingapi, ec2api, region)
+	// The fake APIs fail when nothing was recorded, in which case the static price list of the region is used
+	if err := pricingProvider.UpdateOnDemandPricing(ctx); err != nil {
+		log.FromContext(ctx).V(1).Info("using the static on-demand price list", "error", err)
+	}
+	if err := pricingProvider.UpdateSpotPricing(ctx); err != nil {
+		log.FromContext(ctx).V(1).Info("using the static spot price list", "error", err)
+	}
+	subnetProvider := subnet.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AvailableIPAddressTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AssociatePublicIPAddressTTL, awscache.DefaultCleanupInterval))
+	instanceTypeProvider := instancetype.NewDefaultProvider(region, cache.New(awscache.InstanceTypesAndZonesTTL, awscache.DefaultCleanupInterval), ec2api, subnetProvider, awscache.NewUnavailableOfferings(), pricingProvider)
+	if err := instanceTypeProvider.UpdateInstanceTypes(ctx); err != nil {
+		return nil, err
+	}
+	if err := instanceTypeProvider.UpdateInstanceTypeOfferings(ctx); err != nil {
+		return nil, err
+	}
+
+	zones, err := ec2api.DescribeAvailabilityZonesWithContext(ctx, &ec2.DescribeAvailabilityZonesInput{})
+	if err != nil {
+		return nil, fmt.Errorf("describing availability zones, %w", err)
+	}
+	// Local and wavelength zones are only offered to the subnets that are created in them
+	zoneIDs := lo.SliceToMap(lo.Filter(zones.AvailabilityZones, func(z *ec2.AvailabilityZone, _ int) bool {
+		return aws.StringValue(z.ZoneType) == "availability-zone"
+	}), func(z *ec2.AvailabilityZone) (string, string) {
+		return aws.StringValue(z.ZoneName), aws.StringValue(z.ZoneId)
+	})
+	nodeClass := &v1.EC2NodeClass{
+		Spec: v1.EC2NodeClassSpec{
+			AMISelectorTerms: []v1.AMISelectorTerm{{Alias: "al2023@latest"}},
+		},
+		Status: v1.EC2NodeClassStatus{
+			Subnets: lo.MapToSlice(zoneIDs, func(zone, zoneID string) v1.Subnet {
+				return v1.Subnet{ID: fmt.Sprintf("subnet-kwok-%s", zoneID), Zone: zone, ZoneID: zoneID}
+			}),
+		},
+	}
+	instanceTypes, err := instanceTypeProvider.List(ctx, nil, nodeClass)
+	if err != nil {
+		return nil, err
+	}
+	return &InstanceTypes{InstanceTypes: instanceTypes, ZoneIDs: zoneIDs}, nil
+}
@@ -0,0 +1,92 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kwok_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/kwok"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+
+func TestKWOK(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "KWOK")
+}
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+})
+
+var _ = Describe("KWOK", func() {
+	var instanceTypes *kwok.InstanceTypes
+	BeforeEach(func() {
+		var err error
+		instanceTypes, err = kwok.ConstructInstanceTypes(ctx, "us-west-2", "")
+		Expect(err).ToNot(HaveOccurred())
+	})
+	It("should construct the instance types with the requirements of the AWS cloud provider", func() {
+		it, ok := lo.Find(instanceTypes.InstanceTypes, func(it *cloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+		Expect(ok).To(BeTrue())
+		Expect(it.Requirements.Get(v1.LabelInstanceFamily).Values()).To(ConsistOf("m5"))
+		Expect(it.Requirements.Get(v1.LabelInstanceSize).Values()).To(ConsistOf("large"))
+		Expect(it.Requirements.Get(v1.LabelInstanceCPU).Values()).To(ConsistOf("2"))
+		Expect(it.Requirements.Get(v1.LabelTopologyZoneID).Values()).To(ConsistOf("tstz1-1a", "tstz1-1b", "tstz1-1c"))
+		// The capacity is limited by the ENIs of the instance type, rather than the 110 pods of kwok's instance types
+		Expect(it.Capacity.Pods().Value()).To(BeNumerically("==", 29))
+	})
+	It("should price the offerings with the price list of the region", func() {
+		it, ok := lo.Find(instanceTypes.InstanceTypes, func(it *cloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+		Expect(ok).To(BeTrue())
+		Expect(it.Offerings.Available()).ToNot(BeEmpty())
+		for _, o := range it.Offerings {
+			Expect(o.Price).To(BeNumerically(">", 0))
+		}
+	})
+	It("should label the nodes with the ID of their zone", func() {
+		kubeClient := kwok.NewClient(crfake.NewClientBuilder().Build(), instanceTypes)
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+			Name: "kwok-node",
+			Labels: map[string]string{
+				corev1.LabelTopologyZone:    "test-zone-1b",
+				karpv1.CapacityTypeLabelKey: karpv1.CapacityTypeSpot,
+			},
+		}}
+		Expect(kubeClient.Create(ctx, node)).To(Succeed())
+		Expect(kubeClient.Get(ctx, client.ObjectKeyFromObject(node), node)).To(Succeed())
+		Expect(node.Labels).To(HaveKeyWithValue(v1.LabelTopologyZoneID, "tstz1-1b"))
+	})
+})
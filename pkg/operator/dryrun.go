@@ -0,0 +1,158 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+)
+
+// readOnlyOperationPrefixes are the prefixes of the AWS API operations that don't create, modify or delete resources.
+// Every other operation is treated as a mutation in dry-run mode.
+var readOnlyOperationPrefixes = []string{"Describe", "Get", "List", "Simulate", "Receive", "Assume"}
+
+// DryRunHandler keeps AWS API requests that would create, modify or delete resources from being made. Operations that
+// have a DryRun parameter, like the EC2 ones, are sent with it set, so that AWS still checks their permissions and
+// responds with a DryRunOperation error. Every other mutation fails with a DryRunOperation error without being sent.
+var DryRunHandler = request.NamedHandler{Name: "karpenter.DryRunHandler", Fn: func(r *request.Request) {
+	if r.Operation == nil || lo.SomeBy(readOnlyOperationPrefixes, func(prefix string) bool { return strings.HasPrefix(r.Operation.Name, prefix) }) {
+		return
+	}
+	logger := log.FromContext(r.Context()).WithValues("service", r.ClientInfo.ServiceID, "operation", r.Operation.Name)
+	if logger.V(1).Enabled() {
+		logger = logger.WithValues("input", r.Params)
+	}
+	if dryRun := dryRunField(r.Params); dryRun.IsValid() {
+		dryRun.Set(reflect.ValueOf(aws.Bool(true)))
+		logger.Info("sending request with dry-run")
+		return
+	}
+	logger.Info("skipping request in dry-run mode")
+	r.Error = awserr.New("DryRunOperation", "Request would have succeeded, but dry-run mode is enabled.", nil)
+}}
+
+// dryRunField returns the settable DryRun parameter of the input of an operation, if it has one
+func dryRunField(params interface{}) reflect.Value {
+	v := reflect.ValueOf(params)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+	field := v.Elem().FieldByName("DryRun")
+	if !field.IsValid() || !field.CanSet() || field.Type() != reflect.TypeOf(aws.Bool(false)) {
+		return reflect.Value{}
+	}
+	return field
+}
+
+// WithDryRun keeps every client created from the AWS session from creating, modifying or deleting resources
+func WithDryRun(sess *session.Session) *session.Session {
+	sess.Handlers.Validate.PushBackNamed(DryRunHandler)
+	return sess
+}
+
+// DryRunClient keeps the controllers from acting on their launch and termination decisions in dry-run mode. NodeClaims
+// aren't created or deleted, nodes aren't cordoned, tainted or deleted, and pods aren't evicted or deleted, so that no
+// node is drained for an instance that can't be terminated. The skipped requests are logged, and every other request,
+// like a status update of an EC2NodeClass, is passed through.
+type DryRunClient struct {
+	client.Client
+}
+
+func NewDryRunClient(kubeClient client.Client) *DryRunClient {
+	return &DryRunClient{Client: kubeClient}
+}
+
+func (c *DryRunClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if _, ok := obj.(*karpv1.NodeClaim); ok {
+		logDryRun(ctx, "create", obj)
+		return fmt.Errorf("skipped creating nodeclaim in dry-run mode")
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *DryRunClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if isDryRunDeletion(obj) {
+		logDryRun(ctx, "delete", obj)
+		return nil
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c *DryRunClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	if isDryRunDeletion(obj) {
+		logDryRun(ctx, "delete all of", obj)
+		return nil
+	}
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+func (c *DryRunClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if _, ok := obj.(*corev1.Node); ok {
+		logDryRun(ctx, "update", obj)
+		return nil
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *DryRunClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if _, ok := obj.(*corev1.Node); ok {
+		logDryRun(ctx, "patch", obj)
+		return nil
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *DryRunClient) SubResource(subResource string) client.SubResourceClient {
+	if subResource == "eviction" {
+		return &dryRunEvictionClient{SubResourceClient: c.Client.SubResource(subResource)}
+	}
+	return c.Client.SubResource(subResource)
+}
+
+// dryRunEvictionClient skips the evictions of pods in dry-run mode
+type dryRunEvictionClient struct {
+	client.SubResourceClient
+}
+
+func (c *dryRunEvictionClient) Create(ctx context.Context, obj client.Object, _ client.Object, _ ...client.SubResourceCreateOption) error {
+	logDryRun(ctx, "evict", obj)
+	return nil
+}
+
+func isDryRunDeletion(obj client.Object) bool {
+	switch obj.(type) {
+	case *karpv1.NodeClaim, *corev1.Node, *corev1.Pod:
+		return true
+	}
+	return false
+}
+
+func logDryRun(ctx context.Context, verb string, obj client.Object) {
+	log.FromContext(ctx).WithValues(reflect.Indirect(reflect.ValueOf(obj)).Type().Name(), klog.KObj(obj)).Info(fmt.Sprintf("skipping %s in dry-run mode", verb))
+}
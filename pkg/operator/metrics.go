@@ -0,0 +1,100 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	awsAPISubsystem = "cloudprovider_aws_api"
+	serviceLabel    = "service"
+	operationLabel  = "operation"
+	errorCodeLabel  = "error_code"
+)
+
+var (
+	awsAPIErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: awsAPISubsystem,
+			Name:      "errors_total",
+			Help:      "The number of AWS API request attempts that returned an error, by service, operation, and error code.",
+		},
+		[]string{
+			serviceLabel,
+			operationLabel,
+			errorCodeLabel,
+		},
+	)
+	awsAPIThrottlesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: awsAPISubsystem,
+			Name:      "throttles_total",
+			Help:      "The number of AWS API request attempts that were throttled, by service and operation.",
+		},
+		[]string{
+			serviceLabel,
+			operationLabel,
+		},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(awsAPIErrorsTotal, awsAPIThrottlesTotal)
+}
+
+// ErrorMetricsHandler records the error code and throttling of every failed AWS API request attempt. Request counts
+// and latencies are already recorded by prometheusv1.WithPrometheusMetrics.
+var ErrorMetricsHandler = request.NamedHandler{Name: "karpenter.ErrorMetricsHandler", Fn: func(r *request.Request) {
+	if r.Error == nil {
+		return
+	}
+	code := "Unknown"
+	var awsErr awserr.Error
+	if errors.As(r.Error, &awsErr) {
+		code = awsErr.Code()
+	}
+	operation := ""
+	if r.Operation != nil {
+		operation = r.Operation.Name
+	}
+	awsAPIErrorsTotal.With(prometheus.Labels{
+		serviceLabel:   r.ClientInfo.ServiceID,
+		operationLabel: operation,
+		errorCodeLabel: code,
+	}).Inc()
+	if request.IsErrorThrottle(r.Error) {
+		awsAPIThrottlesTotal.With(prometheus.Labels{
+			serviceLabel:   r.ClientInfo.ServiceID,
+			operationLabel: operation,
+		}).Inc()
+	}
+}}
+
+// WithErrorMetrics adds error and throttling metrics for every client created from the AWS session
+func WithErrorMetrics(sess *session.Session) *session.Session {
+	sess.Handlers.CompleteAttempt.PushBackNamed(ErrorMetricsHandler)
+	return sess
+}
@@ -16,14 +16,20 @@ package operator
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	awsclient "github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
@@ -36,15 +42,22 @@ import (
 	"github.com/aws/aws-sdk-go/service/eks"
 	"github.com/aws/aws-sdk-go/service/eks/eksiface"
 	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/sns"
 	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/sts"
 	prometheusv1 "github.com/jonathan-innis/aws-sdk-go-prometheus/v1"
 	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
+	"golang.org/x/net/http/httpproxy"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/transport"
+	"knative.dev/pkg/system"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 
@@ -52,18 +65,24 @@ import (
 	karpv1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/operator"
 
+	"github.com/aws/karpenter-provider-aws/pkg/alerting"
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
 	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/launchjournal"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/secret"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/snapshot"
 	ssmp "github.com/aws/karpenter-provider-aws/pkg/providers/ssm"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/version"
+	"github.com/aws/karpenter-provider-aws/pkg/tracing"
 )
 
 func init() {
@@ -77,6 +96,11 @@ type Operator struct {
 
 	Session                   *session.Session
 	UnavailableOfferingsCache *awscache.UnavailableOfferings
+	ImpairedZonesCache        *awscache.ImpairedZones
+	SpotInterruptionHistory   *awscache.SpotInterruptionHistory
+	InterruptionWindows       *awscache.InterruptionWindows
+	AMICache                  *cache.Cache
+	LaunchTemplateCache       *cache.Cache
 	EC2API                    ec2iface.EC2API
 	SubnetProvider            subnet.Provider
 	SecurityGroupProvider     securitygroup.Provider
@@ -88,34 +112,72 @@ type Operator struct {
 	VersionProvider           version.Provider
 	InstanceTypesProvider     instancetype.Provider
 	InstanceProvider          instance.Provider
+	LaunchJournal             launchjournal.Provider
 	SSMProvider               ssmp.Provider
+	SecretProvider            secret.Provider
+	SnapshotProvider          snapshot.Provider
+	AlertTracker              *alerting.Tracker
+
+	kubeClient client.Client
 }
 
 func NewOperator(ctx context.Context, operator *operator.Operator) (context.Context, *Operator) {
-	config := &aws.Config{
-		STSRegionalEndpoint: endpoints.RegionalSTSEndpoint,
+	if endpoint := options.FromContext(ctx).TracingEndpoint; endpoint != "" {
+		tracerProvider := lo.Must(tracing.NewTracerProvider(ctx, endpoint))
+		go func() {
+			<-ctx.Done()
+			// Flush any buffered spans on shutdown, independent of the operator context that has already been cancelled
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := tracerProvider.Shutdown(shutdownCtx); err != nil {
+				log.FromContext(ctx).Error(err, "failed shutting down tracer provider")
+			}
+		}()
+		log.FromContext(ctx).WithValues("endpoint", endpoint).V(1).Info("exporting traces")
 	}
+	httpClient := lo.Must(NewHTTPClient(ctx))
+	config := WithEndpoints(ctx, &aws.Config{
+		STSRegionalEndpoint: endpoints.RegionalSTSEndpoint,
+		HTTPClient:          httpClient,
+	})
 
 	if assumeRoleARN := options.FromContext(ctx).AssumeRoleARN; assumeRoleARN != "" {
-		config.Credentials = stscreds.NewCredentials(session.Must(session.NewSession()), assumeRoleARN,
-			func(provider *stscreds.AssumeRoleProvider) { SetDurationAndExpiry(ctx, provider) })
+		config.Credentials = stscreds.NewCredentials(WithServiceName(session.Must(session.NewSession(WithEndpoints(ctx, &aws.Config{
+			STSRegionalEndpoint: endpoints.RegionalSTSEndpoint,
+			HTTPClient:          httpClient,
+		})))), assumeRoleARN,
+			func(provider *stscreds.AssumeRoleProvider) { AssumeRoleSession(ctx).Configure(provider, "") })
 	}
 
 	// prometheusv1.WithPrometheusMetrics is used until the upstream aws-sdk-go or aws-sdk-go-v2 supports
 	// Prometheus metrics for client-side metrics out-of-the-box
 	// See: https://github.com/aws/aws-sdk-go-v2/issues/1744
-	sess := prometheusv1.WithPrometheusMetrics(WithUserAgent(session.Must(session.NewSession(
+	sess := tracing.WithTracing(WithErrorMetrics(prometheusv1.WithPrometheusMetrics(WithServiceName(WithUserAgent(session.Must(session.NewSession(
 		request.WithRetryer(
 			config,
-			awsclient.DefaultRetryer{NumMaxRetries: awsclient.DefaultRetryerMaxNumRetries},
+			awsclient.DefaultRetryer{NumMaxRetries: options.FromContext(ctx).AWSMaxRetries},
 		),
-	))), crmetrics.Registry)
+	)))), crmetrics.Registry)))
 
 	if *sess.Config.Region == "" {
 		log.FromContext(ctx).V(1).Info("retrieving region from IMDS")
 		region, err := ec2metadata.New(sess).Region()
 		*sess.Config.Region = lo.Must(region, err, "failed to get region from metadata server")
 	}
+	// Roles are assumed with a copy of the session that is made before the assume role handler is added so
+	// that the STS calls are always signed with the credentials of the controller
+	if options.FromContext(ctx).VPCEndpointsPreflight {
+		if err := CheckServiceConnectivity(ctx, NewServiceChecks(ctx, sess)); err != nil {
+			log.FromContext(ctx).Error(err, "vpc endpoints preflight failed")
+			os.Exit(1)
+		}
+		log.FromContext(ctx).Info("vpc endpoints preflight succeeded")
+	}
+	if options.FromContext(ctx).DryRun {
+		log.FromContext(ctx).Info("dry-run mode is enabled, aws resources won't be created, modified or deleted")
+		sess = WithDryRun(sess)
+	}
+	sess = assumerole.WithAssumeRole(sess, assumerole.NewProvider(sts.New(sess.Copy()), AssumeRoleSession(ctx)))
 	ec2api := ec2.New(sess)
 	if err := CheckEC2Connectivity(ctx, ec2api); err != nil {
 		log.FromContext(ctx).Error(err, "ec2 api connectivity check failed")
@@ -140,9 +202,12 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 	}
 
 	unavailableOfferingsCache := awscache.NewUnavailableOfferings()
-	subnetProvider := subnet.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AvailableIPAddressTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AssociatePublicIPAddressTTL, awscache.DefaultCleanupInterval))
-	securityGroupProvider := securitygroup.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
-	instanceProfileProvider := instanceprofile.NewDefaultProvider(*sess.Config.Region, iam.New(sess), cache.New(awscache.InstanceProfileTTL, awscache.DefaultCleanupInterval))
+	impairedZonesCache := awscache.NewImpairedZones()
+	spotInterruptionHistory := awscache.NewSpotInterruptionHistory(operator.Clock)
+	interruptionWindows := awscache.NewInterruptionWindows(operator.Clock)
+	subnetProvider := subnet.NewDefaultProvider(ec2api, cache.New(options.FromContext(ctx).SubnetCacheTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AvailableIPAddressTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AssociatePublicIPAddressTTL, awscache.DefaultCleanupInterval))
+	securityGroupProvider := securitygroup.NewDefaultProvider(ec2api, cache.New(options.FromContext(ctx).SecurityGroupCacheTTL, awscache.DefaultCleanupInterval))
+	instanceProfileProvider := instanceprofile.NewDefaultProvider(*sess.Config.Region, iam.New(sess), cache.New(options.FromContext(ctx).InstanceProfileCacheTTL, awscache.DefaultCleanupInterval))
 	pricingProvider := pricing.NewDefaultProvider(
 		ctx,
 		pricing.NewAPI(sess, *sess.Config.Region),
@@ -151,20 +216,27 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 	)
 	versionProvider := version.NewDefaultProvider(operator.KubernetesInterface, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
 	ssmProvider := ssmp.NewDefaultProvider(ssm.New(sess), cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
-	amiProvider := amifamily.NewDefaultProvider(versionProvider, ssmProvider, ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+	secretProvider := secret.NewDefaultProvider(ssm.New(sess), secretsmanager.New(sess), cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+	snapshotProvider := snapshot.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+	amiCache := cache.New(options.FromContext(ctx).AMICacheTTL, awscache.DefaultCleanupInterval)
+	amiProvider := amifamily.NewDefaultProvider(versionProvider, ssmProvider, ec2api, kms.New(sess), amiCache)
 	amiResolver := amifamily.NewResolver(amiProvider)
+	launchTemplateCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
 	launchTemplateProvider := launchtemplate.NewDefaultProvider(
 		ctx,
-		cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval),
+		launchTemplateCache,
 		ec2api,
 		eks.New(sess),
 		amiResolver,
 		securityGroupProvider,
 		subnetProvider,
+		secretProvider,
+		snapshotProvider,
 		lo.Must(GetCABundle(ctx, operator.GetConfig())),
 		operator.Elected(),
 		kubeDNSIP,
 		clusterEndpoint,
+		cache.New(awscache.ClusterTTL, awscache.DefaultCleanupInterval),
 	)
 	instanceTypeProvider := instancetype.NewDefaultProvider(
 		*sess.Config.Region,
@@ -174,20 +246,34 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 		unavailableOfferingsCache,
 		pricingProvider,
 	)
+	launchJournal := launchjournal.NewDefaultProvider(operator.GetClient(), operator.GetAPIReader(), operator.Clock, system.Namespace())
 	instanceProvider := instance.NewDefaultProvider(
 		ctx,
 		aws.StringValue(sess.Config.Region),
 		ec2api,
 		unavailableOfferingsCache,
+		impairedZonesCache,
+		spotInterruptionHistory,
 		instanceTypeProvider,
 		subnetProvider,
 		launchTemplateProvider,
+		launchJournal,
 	)
+	alertTracker := alerting.NewTracker(operator.Clock, options.FromContext(ctx).AlertThreshold, NewAlertNotifiers(ctx, sess)...)
+	kubeClient := operator.GetClient()
+	if options.FromContext(ctx).DryRun {
+		kubeClient = NewDryRunClient(kubeClient)
+	}
 
 	return ctx, &Operator{
 		Operator:                  operator,
 		Session:                   sess,
 		UnavailableOfferingsCache: unavailableOfferingsCache,
+		ImpairedZonesCache:        impairedZonesCache,
+		SpotInterruptionHistory:   spotInterruptionHistory,
+		InterruptionWindows:       interruptionWindows,
+		AMICache:                  amiCache,
+		LaunchTemplateCache:       launchTemplateCache,
 		EC2API:                    ec2api,
 		SubnetProvider:            subnetProvider,
 		SecurityGroupProvider:     securityGroupProvider,
@@ -199,8 +285,32 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 		PricingProvider:           pricingProvider,
 		InstanceTypesProvider:     instanceTypeProvider,
 		InstanceProvider:          instanceProvider,
+		LaunchJournal:             launchJournal,
 		SSMProvider:               ssmProvider,
+		SecretProvider:            secretProvider,
+		SnapshotProvider:          snapshotProvider,
+		AlertTracker:              alertTracker,
+		kubeClient:                kubeClient,
+	}
+}
+
+// GetClient returns the Kubernetes client that the controllers are constructed with. In dry-run mode, it keeps them
+// from creating or deleting NodeClaims and from cordoning, draining or deleting nodes.
+func (o *Operator) GetClient() client.Client {
+	return o.kubeClient
+}
+
+// NewAlertNotifiers returns the notifiers that repeated launch and registration failures are reported to
+func NewAlertNotifiers(ctx context.Context, sess *session.Session) []alerting.Notifier {
+	var notifiers []alerting.Notifier
+	if webhookURL := options.FromContext(ctx).AlertWebhookURL; webhookURL != "" {
+		notifiers = append(notifiers, alerting.NewWebhookNotifier(webhookURL, &http.Client{}))
 	}
+	if topicARN := options.FromContext(ctx).AlertSNSTopicARN; topicARN != "" {
+		// The topic isn't necessarily in the region of the cluster. The ARN has already been validated with the options.
+		notifiers = append(notifiers, alerting.NewSNSNotifier(sns.New(sess, aws.NewConfig().WithRegion(lo.Must(arn.Parse(topicARN)).Region)), topicARN))
+	}
+	return notifiers
 }
 
 // WithUserAgent adds a karpenter specific user-agent string to AWS session
@@ -210,6 +320,91 @@ func WithUserAgent(sess *session.Session) *session.Session {
 	return sess
 }
 
+// NewHTTPClient returns the HTTP client used by all AWS service clients, bounding the per-request timeout and the number
+// of concurrent connections to each service endpoint when configured. Requests are sent through the configured proxy,
+// and the configured CA bundle is trusted in addition to the system roots. A nil client falls back to the SDK default.
+func NewHTTPClient(ctx context.Context) (*http.Client, error) {
+	opts := options.FromContext(ctx)
+	if opts.AWSRequestTimeout == 0 && opts.AWSMaxConcurrentRequests == 0 && opts.AWSProxyURL == "" && opts.AWSNoProxy == "" && opts.AWSCABundleFile == "" {
+		return nil, nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxConnsPerHost = opts.AWSMaxConcurrentRequests
+	if opts.AWSProxyURL != "" || opts.AWSNoProxy != "" {
+		transport.Proxy = NewProxyFunc(ctx)
+	}
+	if opts.AWSCABundleFile != "" {
+		caBundle, err := os.ReadFile(opts.AWSCABundleFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading aws ca bundle, %w", err)
+		}
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("loading system cert pool, %w", err)
+		}
+		if !rootCAs.AppendCertsFromPEM(caBundle) {
+			return nil, fmt.Errorf("no certificates found in aws ca bundle %s", opts.AWSCABundleFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}
+	}
+	return &http.Client{Timeout: opts.AWSRequestTimeout, Transport: transport}, nil
+}
+
+// NewProxyFunc returns the proxy function of the HTTP client used by all AWS service clients. The proxy is taken from
+// the environment unless aws-proxy-url is set, and requests to the services and hosts in aws-no-proxy bypass it.
+func NewProxyFunc(ctx context.Context) func(*http.Request) (*url.URL, error) {
+	config := httpproxy.FromEnvironment()
+	if proxyURL := options.FromContext(ctx).AWSProxyURL; proxyURL != "" {
+		config.HTTPProxy, config.HTTPSProxy = proxyURL, proxyURL
+	}
+	services, hosts := options.FromContext(ctx).NoProxy()
+	config.NoProxy = strings.Join(lo.Compact(append([]string{config.NoProxy}, hosts...)), ",")
+	proxy := config.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		if service, ok := req.Context().Value(serviceNameKey{}).(string); ok && lo.Contains(services, service) {
+			return nil, nil
+		}
+		return proxy(req.URL)
+	}
+}
+
+type serviceNameKey struct{}
+
+// WithServiceName adds the name of the AWS service to the context of each HTTP request, so that the proxy can be bypassed
+// per service. The names match the ones that are used by aws-endpoint-overrides and aws-no-proxy.
+func WithServiceName(sess *session.Session) *session.Session {
+	sess.Handlers.Send.PushFront(func(r *request.Request) {
+		// The service name of the pricing API is "api.pricing"
+		service := strings.TrimPrefix(r.ClientInfo.ServiceName, "api.")
+		r.HTTPRequest = r.HTTPRequest.WithContext(context.WithValue(r.HTTPRequest.Context(), serviceNameKey{}, service))
+	})
+	return sess
+}
+
+// WithEndpoints configures the endpoints that the AWS service clients are resolved to. FIPS and dual-stack endpoints
+// are used when enabled, and services that are overridden with aws-endpoint-overrides are resolved to their URL instead.
+func WithEndpoints(ctx context.Context, config *aws.Config) *aws.Config {
+	if options.FromContext(ctx).AWSUseFIPSEndpoint {
+		config.UseFIPSEndpoint = endpoints.FIPSEndpointStateEnabled
+	}
+	if options.FromContext(ctx).AWSUseDualStackEndpoint {
+		config.UseDualStackEndpoint = endpoints.DualStackEndpointStateEnabled
+	}
+	// The overrides have already been validated with the options
+	overrides := lo.Must(options.FromContext(ctx).EndpointOverrides())
+	if len(overrides) == 0 {
+		return config
+	}
+	config.EndpointResolver = endpoints.ResolverFunc(func(service, region string, opts ...func(*endpoints.Options)) (endpoints.ResolvedEndpoint, error) {
+		// The endpoints ID of the pricing API is "api.pricing"
+		if endpoint, ok := overrides[strings.TrimPrefix(service, "api.")]; ok {
+			return endpoints.ResolvedEndpoint{URL: endpoint, SigningRegion: region}, nil
+		}
+		return endpoints.DefaultResolver().EndpointFor(service, region, opts...)
+	})
+	return config
+}
+
 // CheckEC2Connectivity makes a dry-run call to DescribeInstanceTypes.  If it fails, we provide an early indicator that we
 // are having issues connecting to the EC2 API.
 func CheckEC2Connectivity(ctx context.Context, api ec2iface.EC2API) error {
@@ -269,7 +464,18 @@ func KubeDNSIP(ctx context.Context, kubernetesInterface kubernetes.Interface) (n
 	return kubeDNSIP, nil
 }
 
-func SetDurationAndExpiry(ctx context.Context, provider *stscreds.AssumeRoleProvider) {
-	provider.Duration = options.FromContext(ctx).AssumeRoleDuration
-	provider.ExpiryWindow = time.Duration(10) * time.Second
+// AssumeRoleSession returns the session that roles are assumed with. With assume-role-attribution, the sessions carry
+// the cluster name as their source identity and are tagged with the cluster and the NodePool that calls are made for.
+func AssumeRoleSession(ctx context.Context) assumerole.Session {
+	session := assumerole.Session{
+		Duration: options.FromContext(ctx).AssumeRoleDuration,
+		Tags:     lo.Must(options.FromContext(ctx).SessionTags()),
+	}
+	if options.FromContext(ctx).AssumeRoleAttribution {
+		session.Tags[assumerole.ClusterTagKey] = options.FromContext(ctx).ClusterName
+		session.NodePoolTagKey = karpv1.NodePoolLabelKey
+		// Source identities are limited to 64 characters, while cluster names can be up to 100 characters long
+		session.SourceIdentity = lo.Substring(options.FromContext(ctx).ClusterName, 0, 64)
+	}
+	return session
 }
@@ -19,12 +19,18 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"net/url"
 	"os"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	awsclient "github.com/aws/aws-sdk-go/aws/client"
+	"github.com/samber/lo"
 	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/utils/env"
 
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
 )
 
@@ -37,6 +43,8 @@ type optionsKey struct{}
 type Options struct {
 	AssumeRoleARN           string
 	AssumeRoleDuration      time.Duration
+	AssumeRoleSessionTags   string
+	AssumeRoleAttribution   bool
 	ClusterCABundle         string
 	ClusterName             string
 	ClusterEndpoint         string
@@ -44,11 +52,56 @@ type Options struct {
 	VMMemoryOverheadPercent float64
 	InterruptionQueue       string
 	ReservedENIs            int
+	SecurityGroupsForPods   bool
+
+	AWSRequestTimeout               time.Duration
+	AWSMaxRetries                   int
+	AWSMaxConcurrentRequests        int
+	AWSUseFIPSEndpoint              bool
+	AWSUseDualStackEndpoint         bool
+	AWSEndpointOverrides            string
+	AWSProxyURL                     string
+	AWSNoProxy                      string
+	AWSCABundleFile                 string
+	VPCEndpointsPreflight           bool
+	AMICacheTTL                     time.Duration
+	SubnetCacheTTL                  time.Duration
+	SecurityGroupCacheTTL           time.Duration
+	InstanceProfileCacheTTL         time.Duration
+	InstanceProfilePropagationDelay time.Duration
+	PricingUpdatePeriod             time.Duration
+	SpotPriceTrendWindow            time.Duration
+	SpotPriceRefreshPeriod          time.Duration
+	SpotDiversificationThreshold    int
+	TracingEndpoint                 string
+	DecisionAuditLog                bool
+	DryRun                          bool
+	EMFNamespace                    string
+	EMFExportPeriod                 time.Duration
+	DebugEndpointsPort              int
+	DebugEndpointsAddress           string
+	AlertWebhookURL                 string
+	AlertSNSTopicARN                string
+	AlertThreshold                  int
+	PermissionsCheckPeriod          time.Duration
+	PublicIPGuardrail               string
+	IdentityAgentSelector           string
+	NodeMetadataAnnotations         string
+
+	NodeRolePermissionsBoundary string
+	NodeRoleRequiredPolicies    string
+	EBSEncryptionPolicy         string
+	QuotaHeadroomPercent        int
+	ZonalShift                  string
+	DisabledControllers         string
+	SettingsConfigMap           string
 }
 
 func (o *Options) AddFlags(fs *coreoptions.FlagSet) {
 	fs.StringVar(&o.AssumeRoleARN, "assume-role-arn", env.WithDefaultString("ASSUME_ROLE_ARN", ""), "Role to assume for calling AWS services.")
-	fs.DurationVar(&o.AssumeRoleDuration, "assume-role-duration", env.WithDefaultDuration("ASSUME_ROLE_DURATION", 15*time.Minute), "Duration of assumed credentials in minutes. Default value is 15 minutes. Not used unless aws.assumeRole set.")
+	fs.DurationVar(&o.AssumeRoleDuration, "assume-role-duration", env.WithDefaultDuration("ASSUME_ROLE_DURATION", 15*time.Minute), "Duration of assumed credentials in minutes. Default value is 15 minutes. Not used unless aws.assumeRole or an EC2NodeClass assumeRoleARN is set.")
+	fs.StringVar(&o.AssumeRoleSessionTags, "assume-role-session-tags", env.WithDefaultString("ASSUME_ROLE_SESSION_TAGS", ""), "A comma separated list of key=value session tags that are attached to every assumed role session, e.g. team=platform,environment=prod. The trust policy of the assumed roles must allow sts:TagSession.")
+	fs.BoolVarWithEnv(&o.AssumeRoleAttribution, "assume-role-attribution", "ASSUME_ROLE_ATTRIBUTION", false, "If true, then assumed role sessions carry the cluster name as their source identity and the karpenter.k8s.aws/cluster and karpenter.sh/nodepool session tags, so that the AWS calls made with them can be attributed to a cluster and NodePool in CloudTrail. The trust policy of the assumed roles must allow sts:TagSession and sts:SetSourceIdentity.")
 	fs.StringVar(&o.ClusterCABundle, "cluster-ca-bundle", env.WithDefaultString("CLUSTER_CA_BUNDLE", ""), "Cluster CA bundle for nodes to use for TLS connections with the API server. If not set, this is taken from the controller's TLS configuration.")
 	fs.StringVar(&o.ClusterName, "cluster-name", env.WithDefaultString("CLUSTER_NAME", ""), "[REQUIRED] The kubernetes cluster name for resource discovery.")
 	fs.StringVar(&o.ClusterEndpoint, "cluster-endpoint", env.WithDefaultString("CLUSTER_ENDPOINT", ""), "The external kubernetes cluster endpoint for new nodes to connect with. If not specified, will discover the cluster endpoint using DescribeCluster API.")
@@ -56,6 +109,194 @@ func (o *Options) AddFlags(fs *coreoptions.FlagSet) {
 	fs.Float64Var(&o.VMMemoryOverheadPercent, "vm-memory-overhead-percent", utils.WithDefaultFloat64("VM_MEMORY_OVERHEAD_PERCENT", 0.075), "The VM memory overhead as a percent that will be subtracted from the total memory for all instance types.")
 	fs.StringVar(&o.InterruptionQueue, "interruption-queue", env.WithDefaultString("INTERRUPTION_QUEUE", ""), "Interruption queue is the name of the SQS queue used for processing interruption events from EC2. Interruption handling is disabled if not specified. Enabling interruption handling may require additional permissions on the controller service account. Additional permissions are outlined in the docs.")
 	fs.IntVar(&o.ReservedENIs, "reserved-enis", env.WithDefaultInt("RESERVED_ENIS", 0), "Reserved ENIs are not included in the calculations for max-pods or kube-reserved. This is most often used in the VPC CNI custom networking setup https://docs.aws.amazon.com/eks/latest/userguide/cni-custom-network.html.")
+	fs.BoolVarWithEnv(&o.SecurityGroupsForPods, "security-groups-for-pods", "SECURITY_GROUPS_FOR_PODS", false, "If true, then only instance types that support ENI trunking are launched, and the trunk network interface is not included in the calculations for max-pods or kube-reserved. This should be set when security groups for pods are enabled in the VPC CNI https://docs.aws.amazon.com/eks/latest/userguide/security-groups-for-pods.html.")
+	fs.DurationVar(&o.AWSRequestTimeout, "aws-request-timeout", env.WithDefaultDuration("AWS_REQUEST_TIMEOUT", 0), "The timeout applied to each HTTP request attempt made to AWS APIs. If not set, requests are only bounded by the caller's context.")
+	fs.IntVar(&o.AWSMaxRetries, "aws-max-retries", env.WithDefaultInt("AWS_MAX_RETRIES", awsclient.DefaultRetryerMaxNumRetries), "The maximum number of times a throttled or failed AWS API request is retried before returning an error.")
+	fs.IntVar(&o.AWSMaxConcurrentRequests, "aws-max-concurrent-requests", env.WithDefaultInt("AWS_MAX_CONCURRENT_REQUESTS", 0), "The maximum number of in-flight requests to each AWS service endpoint. A value of 0 means there is no limit.")
+	fs.BoolVarWithEnv(&o.AWSUseFIPSEndpoint, "aws-use-fips-endpoint", "AWS_USE_FIPS_ENDPOINT", false, "If true, then the FIPS endpoints of the AWS services are used by all AWS clients. Services without a FIPS endpoint, like the pricing API, need an endpoint override or isolated-vpc.")
+	fs.BoolVarWithEnv(&o.AWSUseDualStackEndpoint, "aws-use-dualstack-endpoint", "AWS_USE_DUALSTACK_ENDPOINT", false, "If true, then the dual-stack (IPv4 and IPv6) endpoints of the AWS services are used by all AWS clients.")
+	fs.StringVar(&o.AWSEndpointOverrides, "aws-endpoint-overrides", env.WithDefaultString("AWS_ENDPOINT_OVERRIDES", ""), "A comma separated list of service=URL pairs that override the endpoint of an AWS service, e.g. ec2=https://ec2.example.com,ssm=https://ssm.example.com. Supported services are "+strings.Join(AWSServices, ", ")+".")
+	fs.StringVar(&o.AWSProxyURL, "aws-proxy-url", env.WithDefaultString("AWS_PROXY_URL", ""), "The URL of the proxy that all requests to AWS APIs are sent through. If not set, the proxy is taken from the HTTPS_PROXY and HTTP_PROXY environment variables.")
+	fs.StringVar(&o.AWSNoProxy, "aws-no-proxy", env.WithDefaultString("AWS_NO_PROXY", ""), "A comma separated list of AWS services and hosts whose requests bypass the proxy, in addition to the NO_PROXY environment variable. Hosts are matched like NO_PROXY, e.g. ec2,sts,.vpce.amazonaws.com,10.0.0.0/8. Supported services are "+strings.Join(AWSServices, ", ")+".")
+	fs.StringVar(&o.AWSCABundleFile, "aws-ca-bundle-file", env.WithDefaultString("AWS_CA_BUNDLE_FILE", ""), "The path to a file with PEM encoded CA certificates that are trusted for TLS connections to AWS APIs in addition to the system roots, e.g. the CA of a TLS inspecting proxy.")
+	fs.BoolVarWithEnv(&o.VPCEndpointsPreflight, "vpc-endpoints-preflight", "VPC_ENDPOINTS_PREFLIGHT", false, "If true, then the AWS APIs that Karpenter requires are called on startup, and the controller exits with a list of the services that aren't reachable. This is most often used in private clusters that reach AWS through VPC endpoints.")
+	fs.DurationVar(&o.AMICacheTTL, "ami-cache-ttl", env.WithDefaultDuration("AMI_CACHE_TTL", awscache.DefaultTTL), "The amount of time that resolved AMIs are cached before describing them again.")
+	fs.DurationVar(&o.SubnetCacheTTL, "subnet-cache-ttl", env.WithDefaultDuration("SUBNET_CACHE_TTL", awscache.DefaultTTL), "The amount of time that discovered subnets are cached before describing them again.")
+	fs.DurationVar(&o.SecurityGroupCacheTTL, "security-group-cache-ttl", env.WithDefaultDuration("SECURITY_GROUP_CACHE_TTL", awscache.DefaultTTL), "The amount of time that discovered security groups are cached before describing them again.")
+	fs.DurationVar(&o.InstanceProfileCacheTTL, "instance-profile-cache-ttl", env.WithDefaultDuration("INSTANCE_PROFILE_CACHE_TTL", awscache.InstanceProfileTTL), "The amount of time that instance profiles are cached before getting them again.")
+	fs.DurationVar(&o.InstanceProfilePropagationDelay, "instance-profile-propagation-delay", env.WithDefaultDuration("INSTANCE_PROFILE_PROPAGATION_DELAY", 15*time.Second), "The amount of time that an instance profile isn't used to launch nodes after Karpenter creates it or changes its role, so that the change can propagate through IAM. Disabled if set to 0.")
+	fs.DurationVar(&o.PricingUpdatePeriod, "pricing-update-period", env.WithDefaultDuration("PRICING_UPDATE_PERIOD", 12*time.Hour), "The period at which on-demand and spot pricing information is refreshed from AWS.")
+	fs.DurationVar(&o.SpotPriceTrendWindow, "spot-price-trend-window", env.WithDefaultDuration("SPOT_PRICE_TREND_WINDOW", 0), "The window of spot price history that the prices of spot offerings are based on, rather than only their latest price. Offerings are priced at their average price over the window, and offerings whose price is rising at their latest price projected by the rise, so that consolidation avoids pools that are trending upward. Must be at most 90 days. Only the latest price is used if set to 0.")
+	fs.DurationVar(&o.SpotPriceRefreshPeriod, "spot-price-refresh-period", env.WithDefaultDuration("SPOT_PRICE_REFRESH_PERIOD", 0), "The period at which the spot prices of the instance types of launched spot NodeClaims are refreshed from AWS, in between the pricing-update-period. Keeps the prices of the pools that are in use accurate for consolidation. Disabled if set to 0.")
+	fs.IntVar(&o.SpotDiversificationThreshold, "spot-diversification-threshold", env.WithDefaultInt("SPOT_DIVERSIFICATION_THRESHOLD", 0), "The number of spot pools, the pairs of instance type and zone, that spot launches are expected to diversify across. A SpotDiversificationLow event is published to the NodeClaims of spot launches whose requirements leave them fewer pools, since launches that are constrained to few pools are interrupted more often. Warnings are disabled if set to 0.")
+	fs.StringVar(&o.TracingEndpoint, "tracing-endpoint", env.WithDefaultString("TRACING_ENDPOINT", ""), "The OTLP gRPC endpoint (host:port) that traces of the provisioning path are exported to. Tracing is disabled if not specified. The exporter can be further configured through the standard OTEL_EXPORTER_OTLP_* environment variables.")
+	fs.BoolVarWithEnv(&o.DecisionAuditLog, "decision-audit-log", "DECISION_AUDIT_LOG", false, "If true, then a structured log line is emitted for every launch and termination, containing the candidate offerings and their prices, the instance types that were filtered out and why, the chosen offering, and the CreateFleet errors.")
+	fs.BoolVarWithEnv(&o.DryRun, "dry-run", "DRY_RUN", false, "If true, then Karpenter computes and logs every AWS call that would create, modify or delete a resource, but doesn't make it. EC2 calls are made with DryRun set so that their permissions are still checked. NodeClaims aren't created or deleted, and nodes aren't cordoned, drained or deleted.")
+	fs.StringVar(&o.EMFNamespace, "emf-namespace", env.WithDefaultString("EMF_NAMESPACE", ""), "The CloudWatch namespace that key metrics are published to in CloudWatch Embedded Metric Format on stdout. The CloudWatch EMF exporter is disabled if not specified.")
+	fs.DurationVar(&o.EMFExportPeriod, "emf-export-period", env.WithDefaultDuration("EMF_EXPORT_PERIOD", time.Minute), "The period at which metrics are published in CloudWatch Embedded Metric Format. Not used unless emf-namespace is set.")
+	fs.IntVar(&o.DebugEndpointsPort, "debug-endpoints-port", env.WithDefaultInt("DEBUG_ENDPOINTS_PORT", 0), "The port the read-only debug endpoints bind to. The endpoints are only served by the leader. Debug endpoints are disabled if not specified.")
+	fs.StringVar(&o.DebugEndpointsAddress, "debug-endpoints-address", env.WithDefaultString("DEBUG_ENDPOINTS_ADDRESS", "127.0.0.1"), "The address the read-only debug endpoints bind to. The endpoints are only reachable through kubectl port-forward by default, since they aren't authenticated.")
+	fs.StringVar(&o.AlertWebhookURL, "alert-webhook-url", env.WithDefaultString("ALERT_WEBHOOK_URL", ""), "The URL that alerts are posted to as JSON when a NodePool reaches the alert-threshold of consecutive launch failures or unregistered nodes. Webhook alerts are disabled if not specified.")
+	fs.StringVar(&o.AlertSNSTopicARN, "alert-sns-topic-arn", env.WithDefaultString("ALERT_SNS_TOPIC_ARN", ""), "The ARN of the SNS topic that alerts are published to when a NodePool reaches the alert-threshold of consecutive launch failures or unregistered nodes. SNS alerts are disabled if not specified. Publishing requires the sns:Publish permission on the topic.")
+	fs.IntVar(&o.AlertThreshold, "alert-threshold", env.WithDefaultInt("ALERT_THRESHOLD", 5), "The number of consecutive launch failures or unregistered nodes for a NodePool after which an alert is sent. Not used unless alert-webhook-url or alert-sns-topic-arn is set.")
+	fs.DurationVar(&o.PermissionsCheckPeriod, "permissions-check-period", env.WithDefaultDuration("PERMISSIONS_CHECK_PERIOD", time.Hour), "The period at which the IAM permissions the controller requires are checked by simulating its policies. Missing permissions fail the readiness probe of the leader and are exported as metrics. The check is disabled if set to 0.")
+	fs.StringVar(&o.PublicIPGuardrail, "public-ip-guardrail", env.WithDefaultString("PUBLIC_IP_GUARDRAIL", PublicIPGuardrailDisabled), "Whether EC2NodeClasses whose instances would be assigned public IP addresses by subnets that route to an internet gateway are reported. One of Disabled, Warn or Enforce. Warn sets the PublicIPExposed condition, Enforce additionally marks the subnets of the EC2NodeClass as not ready. Setting associatePublicIPAddress explicitly on the EC2NodeClass allows public IP addresses.")
+	fs.StringVar(&o.IdentityAgentSelector, "identity-agent-selector", env.WithDefaultString("IDENTITY_AGENT_SELECTOR", "app.kubernetes.io/name=eks-pod-identity-agent"), "The label selector of the identity agent pods that must be ready on a node before the karpenter.k8s.aws/identity-not-ready startup taint is removed from it. Not used unless a NodePool sets the startup taint.")
+	fs.StringVar(&o.NodeMetadataAnnotations, "node-metadata-annotations", env.WithDefaultString("NODE_METADATA_ANNOTATIONS", ""), "A comma separated list of the EC2 attributes of instances that are added to their NodeClaims and nodes as karpenter.k8s.aws/<attribute> annotations, so that they don't need to be described per node. Supported attributes are "+strings.Join(NodeMetadataAttributes, ", ")+". No attributes are added if not specified.")
+	fs.StringVar(&o.NodeRolePermissionsBoundary, "node-role-permissions-boundary", env.WithDefaultString("NODE_ROLE_PERMISSIONS_BOUNDARY", ""), "The ARN of the managed policy that is set as the permissions boundary of the role of every EC2NodeClass that sets spec.role. The permissions boundary of the roles isn't changed if not specified.")
+	fs.StringVar(&o.NodeRoleRequiredPolicies, "node-role-required-policies", env.WithDefaultString("NODE_ROLE_REQUIRED_POLICIES", ""), "A comma separated list of the ARNs of managed policies that are attached to the role of every EC2NodeClass that sets spec.role, and reattached when they are detached.")
+	fs.StringVar(&o.EBSEncryptionPolicy, "ebs-encryption-policy", env.WithDefaultString("EBS_ENCRYPTION_POLICY", EBSEncryptionPolicyDisabled), "Whether EC2NodeClasses whose launches would create EBS volumes that aren't encrypted, taking the EBS encryption by default setting of the account into account, are reported. One of Disabled, Encrypted or CustomerManagedKey. Encrypted sets the EBSEncryptionPolicyViolated condition for unencrypted volumes, CustomerManagedKey additionally for volumes that are encrypted with the AWS managed key.")
+	fs.IntVar(&o.QuotaHeadroomPercent, "quota-headroom-percent", env.WithDefaultInt("QUOTA_HEADROOM_PERCENT", 0), "The percent of an account quota that must remain unused, below which the QuotaHeadroomLow condition is set on EC2NodeClasses. The spot vCPU, network interface, Elastic IP address and launch template quotas of the region are checked every 5 minutes. The check is disabled if set to 0.")
+	fs.StringVar(&o.ZonalShift, "zonal-shift", env.WithDefaultString("ZONAL_SHIFT", ZonalShiftDisabled), "Whether the zonal shifts and zonal autoshifts of the cluster in Route 53 Application Recovery Controller are followed. One of Disabled, Avoid or Drift. Avoid stops launching nodes into the zones that the cluster is shifted away from, Drift additionally drifts the nodes in these zones so that they're replaced in the other zones. Zonal shift must be enabled on the cluster.")
+	fs.StringVar(&o.DisabledControllers, "disabled-controllers", env.WithDefaultString("DISABLED_CONTROLLERS", ""), "A comma separated list of the controllers that aren't run, for concerns that are managed outside of Karpenter or whose permissions aren't granted. Supported controllers are "+strings.Join(Controllers, ", ")+".")
+	fs.StringVar(&o.SettingsConfigMap, "settings-configmap", env.WithDefaultString("SETTINGS_CONFIGMAP", ""), "The name of a ConfigMap in the namespace of the controller whose data overrides the reloadable settings at runtime, keyed by the names of their flags. Supported settings are "+strings.Join(ReloadableFlags, ", ")+". Settings aren't reloaded if not specified.")
+}
+
+const (
+	PublicIPGuardrailDisabled = "Disabled"
+	PublicIPGuardrailWarn     = "Warn"
+	PublicIPGuardrailEnforce  = "Enforce"
+)
+
+const (
+	EBSEncryptionPolicyDisabled           = "Disabled"
+	EBSEncryptionPolicyEncrypted          = "Encrypted"
+	EBSEncryptionPolicyCustomerManagedKey = "CustomerManagedKey"
+)
+
+const (
+	ZonalShiftDisabled = "Disabled"
+	ZonalShiftAvoid    = "Avoid"
+	ZonalShiftDrift    = "Drift"
+)
+
+const (
+	// ControllerInterruption handles the interruption events of the interruption-queue
+	ControllerInterruption = "interruption"
+	// ControllerPricing refreshes the on-demand and spot prices, which fall back to the static pricing data
+	ControllerPricing = "pricing"
+	// ControllerInstanceProfile creates and deletes the instance profiles of the EC2NodeClasses that set spec.role
+	ControllerInstanceProfile = "instance-profile"
+	// ControllerTagging tags the instances of the NodeClaims with the names of their NodeClaims and nodes
+	ControllerTagging = "tagging"
+	// ControllerGarbageCollection terminates the instances that were launched by Karpenter but don't have a NodeClaim
+	ControllerGarbageCollection = "garbage-collection"
+	// ControllerLaunchJournal terminates the instances of journaled launches whose NodeClaims were deleted before they were updated
+	ControllerLaunchJournal = "launch-journal"
+	// ControllerInstanceAdoption creates NodeClaims for the instances that are tagged to be adopted by a NodePool
+	ControllerInstanceAdoption = "instance-adoption"
+	// ControllerNodeGroupMigration drains and scales in the auto scaling groups and EKS managed node groups of the NodeGroupMigrations
+	ControllerNodeGroupMigration = "nodegroup-migration"
+	// ControllerCapacityReservation maintains the On-Demand Capacity Reservations of the capacity floors of the NodePools
+	ControllerCapacityReservation = "capacity-reservation"
+	// ControllerCapacitySchedule launches the nodes of the CapacitySchedules ahead of their windows
+	ControllerCapacitySchedule = "capacity-schedule"
+	// ControllerMaintenanceWindow blocks the voluntary disruption of the NodePools outside of their maintenance windows
+	ControllerMaintenanceWindow = "maintenance-window"
+	// ControllerTerminationProtection manages the termination protection of the instances of the NodePools that request it,
+	// and blocks the disruption of the instances that are protected outside of Karpenter
+	ControllerTerminationProtection = "termination-protection"
+)
+
+// Controllers are the controllers that can be disabled with disabled-controllers
+var Controllers = []string{ControllerInterruption, ControllerPricing, ControllerInstanceProfile, ControllerTagging, ControllerGarbageCollection, ControllerLaunchJournal, ControllerInstanceAdoption, ControllerNodeGroupMigration, ControllerCapacityReservation, ControllerCapacitySchedule, ControllerMaintenanceWindow, ControllerTerminationProtection}
+
+const (
+	// NodeMetadataUsageOperation is the billing code of the instance, which identifies the billing products of its AMI
+	NodeMetadataUsageOperation = "usage-operation"
+	// NodeMetadataPlacementGroup is the name of the placement group of the instance
+	NodeMetadataPlacementGroup = "placement-group"
+	// NodeMetadataHostID is the ID of the dedicated host of the instance
+	NodeMetadataHostID = "host-id"
+	// NodeMetadataCapacityReservationID is the ID of the capacity reservation that the instance was launched into
+	NodeMetadataCapacityReservationID = "capacity-reservation-id"
+	// NodeMetadataAMIName is the name of the AMI of the instance
+	NodeMetadataAMIName = "ami-name"
+)
+
+// NodeMetadataAttributes are the attributes of instances that can be added to their nodes with node-metadata-annotations
+var NodeMetadataAttributes = []string{NodeMetadataUsageOperation, NodeMetadataPlacementGroup, NodeMetadataHostID, NodeMetadataCapacityReservationID, NodeMetadataAMIName}
+
+// AWSServices are the services that Karpenter calls, which can be referenced by aws-endpoint-overrides and aws-no-proxy
+var AWSServices = []string{"arc-zonal-shift", "ec2", "eks", "iam", "pricing", "secretsmanager", "servicequotas", "sns", "sqs", "ssm", "sts"}
+
+// EndpointOverrides returns the endpoint URL for each service that is overridden with aws-endpoint-overrides
+func (o Options) EndpointOverrides() (map[string]string, error) {
+	overrides := map[string]string{}
+	if o.AWSEndpointOverrides == "" {
+		return overrides, nil
+	}
+	for _, pair := range strings.Split(o.AWSEndpointOverrides, ",") {
+		service, endpoint, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not a service=URL pair", pair)
+		}
+		if !lo.Contains(AWSServices, service) {
+			return nil, fmt.Errorf("%q is not a supported service", service)
+		}
+		u, err := url.Parse(endpoint)
+		if err != nil || !u.IsAbs() || u.Hostname() == "" {
+			return nil, fmt.Errorf("%q is not a valid endpoint URL for %s", endpoint, service)
+		}
+		overrides[service] = endpoint
+	}
+	return overrides, nil
+}
+
+// SessionTags returns the session tags in assume-role-session-tags
+func (o Options) SessionTags() (map[string]string, error) {
+	tags := map[string]string{}
+	if o.AssumeRoleSessionTags == "" {
+		return tags, nil
+	}
+	for _, pair := range strings.Split(o.AssumeRoleSessionTags, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%q is not a key=value pair", pair)
+		}
+		tags[key] = value
+	}
+	return tags, nil
+}
+
+// RequiredNodeRolePolicies returns the ARNs of the managed policies in node-role-required-policies
+func (o Options) RequiredNodeRolePolicies() []string {
+	return lo.Compact(lo.Map(strings.Split(o.NodeRoleRequiredPolicies, ","), func(policyARN string, _ int) string {
+		return strings.TrimSpace(policyARN)
+	}))
+}
+
+// ControllerEnabled returns false if the controller is in disabled-controllers
+func (o Options) ControllerEnabled(controller string) bool {
+	return !lo.Contains(o.disabledControllers(), controller)
+}
+
+func (o Options) disabledControllers() []string {
+	return lo.Compact(lo.Map(strings.Split(o.DisabledControllers, ","), func(controller string, _ int) string {
+		return strings.TrimSpace(controller)
+	}))
+}
+
+// NodeMetadata returns the attributes in node-metadata-annotations
+func (o Options) NodeMetadata() []string {
+	return lo.Compact(lo.Map(strings.Split(o.NodeMetadataAnnotations, ","), func(attribute string, _ int) string {
+		return strings.TrimSpace(attribute)
+	}))
+}
+
+// NoProxy returns the services and the hosts in aws-no-proxy whose requests bypass the proxy
+func (o Options) NoProxy() (services []string, hosts []string) {
+	for _, entry := range strings.Split(o.AWSNoProxy, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		if lo.Contains(AWSServices, entry) {
+			services = append(services, entry)
+		} else {
+			hosts = append(hosts, entry)
+		}
+	}
+	return services, hosts
 }
 
 func (o *Options) Parse(fs *coreoptions.FlagSet, args ...string) error {
@@ -76,13 +317,17 @@ func (o *Options) ToContext(ctx context.Context) context.Context {
 }
 
 func ToContext(ctx context.Context, opts *Options) context.Context {
-	return context.WithValue(ctx, optionsKey{}, opts)
+	active := &atomic.Pointer[Options]{}
+	active.Store(opts)
+	return context.WithValue(ctx, optionsKey{}, active)
 }
 
+// FromContext returns the options that are active. They must not be changed, since they are shared with every reader
+// of the context. Reload replaces them with a copy instead.
 func FromContext(ctx context.Context) *Options {
 	retval := ctx.Value(optionsKey{})
 	if retval == nil {
 		return nil
 	}
-	return retval.(*Options)
+	return retval.(*atomic.Pointer[Options]).Load()
 }
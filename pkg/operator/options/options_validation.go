@@ -15,11 +15,19 @@ limitations under the License.
 package options
 
 import (
+	"crypto/x509"
 	"fmt"
+	"net"
 	"net/url"
+	"os"
+	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/samber/lo"
 	"go.uber.org/multierr"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 func (o Options) Validate() error {
@@ -27,7 +35,27 @@ func (o Options) Validate() error {
 		o.validateEndpoint(),
 		o.validateVMMemoryOverheadPercent(),
 		o.validateAssumeRoleDuration(),
+		o.validateAssumeRoleSessionTags(),
 		o.validateReservedENIs(),
+		o.validateAWSClientSettings(),
+		o.validateCacheTTLs(),
+		o.validateSpotPriceTrendWindow(),
+		o.validateSpotPriceRefreshPeriod(),
+		o.validateInstanceProfilePropagationDelay(),
+		o.validateSpotDiversificationThreshold(),
+		o.validateEMFExportPeriod(),
+		o.validateDebugEndpointsPort(),
+		o.validateAlerting(),
+		o.validatePermissionsCheckPeriod(),
+		o.validatePublicIPGuardrail(),
+		o.validateIdentityAgentSelector(),
+		o.validateNodeMetadataAnnotations(),
+		o.validateNodeRolePolicies(),
+		o.validateEBSEncryptionPolicy(),
+		o.validateQuotaHeadroomPercent(),
+		o.validateZonalShift(),
+		o.validateDisabledControllers(),
+		o.validateSettingsConfigMap(),
 		o.validateRequiredFields(),
 	)
 }
@@ -39,6 +67,27 @@ func (o Options) validateAssumeRoleDuration() error {
 	return nil
 }
 
+func (o Options) validateAssumeRoleSessionTags() error {
+	tags, err := o.SessionTags()
+	if err != nil {
+		return fmt.Errorf("validating assume-role-session-tags, %w", err)
+	}
+	var errs error
+	// STS accepts at most 50 session tags, two of which are taken by the attribution tags
+	if len(tags) > 48 {
+		errs = multierr.Append(errs, fmt.Errorf("assume-role-session-tags cannot contain more than 48 tags"))
+	}
+	for key, value := range tags {
+		if len(key) > 128 || len(value) > 256 {
+			errs = multierr.Append(errs, fmt.Errorf("assume-role-session-tags key %q must be at most 128 characters and its value at most 256 characters", key))
+		}
+		if strings.HasPrefix(strings.ToLower(key), "aws:") || strings.HasPrefix(key, "karpenter.sh/") || strings.HasPrefix(key, "karpenter.k8s.aws/") {
+			errs = multierr.Append(errs, fmt.Errorf("assume-role-session-tags key %q uses a reserved prefix", key))
+		}
+	}
+	return errs
+}
+
 func (o Options) validateEndpoint() error {
 	if o.ClusterEndpoint == "" {
 		return nil
@@ -66,6 +115,204 @@ func (o Options) validateReservedENIs() error {
 	return nil
 }
 
+func (o Options) validateAWSClientSettings() error {
+	var errs error
+	if o.AWSRequestTimeout < 0 {
+		errs = multierr.Append(errs, fmt.Errorf("aws-request-timeout cannot be negative"))
+	}
+	if o.AWSMaxRetries < 0 {
+		errs = multierr.Append(errs, fmt.Errorf("aws-max-retries cannot be negative"))
+	}
+	if o.AWSMaxConcurrentRequests < 0 {
+		errs = multierr.Append(errs, fmt.Errorf("aws-max-concurrent-requests cannot be negative"))
+	}
+	if _, err := o.EndpointOverrides(); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("validating aws-endpoint-overrides, %w", err))
+	}
+	if o.AWSProxyURL != "" {
+		if u, err := url.Parse(o.AWSProxyURL); err != nil || !lo.Contains([]string{"http", "https", "socks5"}, u.Scheme) || u.Hostname() == "" {
+			errs = multierr.Append(errs, fmt.Errorf("aws-proxy-url must be a valid http, https or socks5 URL"))
+		}
+	}
+	if o.AWSCABundleFile != "" {
+		if caBundle, err := os.ReadFile(o.AWSCABundleFile); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("reading aws-ca-bundle-file, %w", err))
+		} else if !x509.NewCertPool().AppendCertsFromPEM(caBundle) {
+			errs = multierr.Append(errs, fmt.Errorf("aws-ca-bundle-file doesn't contain any PEM encoded certificates"))
+		}
+	}
+	return errs
+}
+
+func (o Options) validateCacheTTLs() error {
+	var errs error
+	for name, ttl := range map[string]time.Duration{
+		"ami-cache-ttl":              o.AMICacheTTL,
+		"subnet-cache-ttl":           o.SubnetCacheTTL,
+		"security-group-cache-ttl":   o.SecurityGroupCacheTTL,
+		"instance-profile-cache-ttl": o.InstanceProfileCacheTTL,
+		"pricing-update-period":      o.PricingUpdatePeriod,
+	} {
+		if ttl <= 0 {
+			errs = multierr.Append(errs, fmt.Errorf("%s must be greater than 0", name))
+		}
+	}
+	return errs
+}
+
+func (o Options) validateSpotPriceTrendWindow() error {
+	// EC2 keeps 90 days of spot price history
+	if o.SpotPriceTrendWindow < 0 || o.SpotPriceTrendWindow > 90*24*time.Hour {
+		return fmt.Errorf("spot-price-trend-window must be between 0 and 90 days")
+	}
+	return nil
+}
+
+func (o Options) validateSpotPriceRefreshPeriod() error {
+	if o.SpotPriceRefreshPeriod < 0 {
+		return fmt.Errorf("spot-price-refresh-period cannot be negative")
+	}
+	return nil
+}
+
+func (o Options) validateInstanceProfilePropagationDelay() error {
+	if o.InstanceProfilePropagationDelay < 0 {
+		return fmt.Errorf("instance-profile-propagation-delay cannot be negative")
+	}
+	return nil
+}
+
+func (o Options) validateSpotDiversificationThreshold() error {
+	if o.SpotDiversificationThreshold < 0 {
+		return fmt.Errorf("spot-diversification-threshold cannot be negative")
+	}
+	return nil
+}
+
+func (o Options) validateEMFExportPeriod() error {
+	if o.EMFExportPeriod <= 0 {
+		return fmt.Errorf("emf-export-period must be greater than 0")
+	}
+	return nil
+}
+
+func (o Options) validateDebugEndpointsPort() error {
+	if o.DebugEndpointsPort < 0 || o.DebugEndpointsPort > 65535 {
+		return fmt.Errorf("debug-endpoints-port must be between 0 and 65535")
+	}
+	if net.ParseIP(o.DebugEndpointsAddress) == nil {
+		return fmt.Errorf("debug-endpoints-address must be an IP address")
+	}
+	return nil
+}
+
+func (o Options) validateAlerting() error {
+	var errs error
+	if o.AlertWebhookURL != "" {
+		u, err := url.Parse(o.AlertWebhookURL)
+		if err != nil || !u.IsAbs() || u.Hostname() == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			errs = multierr.Append(errs, fmt.Errorf("%q is not a valid alert-webhook-url", o.AlertWebhookURL))
+		}
+	}
+	if o.AlertSNSTopicARN != "" {
+		if a, err := arn.Parse(o.AlertSNSTopicARN); err != nil || a.Service != "sns" {
+			errs = multierr.Append(errs, fmt.Errorf("%q is not a valid alert-sns-topic-arn", o.AlertSNSTopicARN))
+		}
+	}
+	if o.AlertThreshold <= 0 {
+		errs = multierr.Append(errs, fmt.Errorf("alert-threshold must be greater than 0"))
+	}
+	return errs
+}
+
+func (o Options) validatePermissionsCheckPeriod() error {
+	if o.PermissionsCheckPeriod < 0 {
+		return fmt.Errorf("permissions-check-period cannot be negative")
+	}
+	return nil
+}
+
+func (o Options) validatePublicIPGuardrail() error {
+	if !lo.Contains([]string{PublicIPGuardrailDisabled, PublicIPGuardrailWarn, PublicIPGuardrailEnforce}, o.PublicIPGuardrail) {
+		return fmt.Errorf("public-ip-guardrail must be one of %s, %s or %s", PublicIPGuardrailDisabled, PublicIPGuardrailWarn, PublicIPGuardrailEnforce)
+	}
+	return nil
+}
+
+func (o Options) validateIdentityAgentSelector() error {
+	if selector, err := labels.Parse(o.IdentityAgentSelector); err != nil || selector.Empty() {
+		return fmt.Errorf("identity-agent-selector must be a non-empty label selector")
+	}
+	return nil
+}
+
+func (o Options) validateNodeRolePolicies() error {
+	var errs error
+	if o.NodeRolePermissionsBoundary != "" && !isPolicyARN(o.NodeRolePermissionsBoundary) {
+		errs = multierr.Append(errs, fmt.Errorf("%q is not a valid node-role-permissions-boundary", o.NodeRolePermissionsBoundary))
+	}
+	for _, policyARN := range o.RequiredNodeRolePolicies() {
+		if !isPolicyARN(policyARN) {
+			errs = multierr.Append(errs, fmt.Errorf("%q is not a valid policy ARN in node-role-required-policies", policyARN))
+		}
+	}
+	return errs
+}
+
+func isPolicyARN(s string) bool {
+	a, err := arn.Parse(s)
+	return err == nil && a.Service == "iam" && strings.HasPrefix(a.Resource, "policy/")
+}
+
+func (o Options) validateEBSEncryptionPolicy() error {
+	if !lo.Contains([]string{EBSEncryptionPolicyDisabled, EBSEncryptionPolicyEncrypted, EBSEncryptionPolicyCustomerManagedKey}, o.EBSEncryptionPolicy) {
+		return fmt.Errorf("ebs-encryption-policy must be one of %s, %s or %s", EBSEncryptionPolicyDisabled, EBSEncryptionPolicyEncrypted, EBSEncryptionPolicyCustomerManagedKey)
+	}
+	return nil
+}
+
+func (o Options) validateQuotaHeadroomPercent() error {
+	if o.QuotaHeadroomPercent < 0 || o.QuotaHeadroomPercent >= 100 {
+		return fmt.Errorf("quota-headroom-percent must be between 0 and 99")
+	}
+	return nil
+}
+
+func (o Options) validateZonalShift() error {
+	if !lo.Contains([]string{ZonalShiftDisabled, ZonalShiftAvoid, ZonalShiftDrift}, o.ZonalShift) {
+		return fmt.Errorf("zonal-shift must be one of %s, %s or %s", ZonalShiftDisabled, ZonalShiftAvoid, ZonalShiftDrift)
+	}
+	return nil
+}
+
+func (o Options) validateNodeMetadataAnnotations() error {
+	for _, attribute := range o.NodeMetadata() {
+		if !lo.Contains(NodeMetadataAttributes, attribute) {
+			return fmt.Errorf("%q in node-metadata-annotations is not one of %s", attribute, strings.Join(NodeMetadataAttributes, ", "))
+		}
+	}
+	return nil
+}
+
+func (o Options) validateDisabledControllers() error {
+	for _, controller := range o.disabledControllers() {
+		if !lo.Contains(Controllers, controller) {
+			return fmt.Errorf("%q in disabled-controllers is not one of %s", controller, strings.Join(Controllers, ", "))
+		}
+	}
+	return nil
+}
+
+func (o Options) validateSettingsConfigMap() error {
+	if o.SettingsConfigMap == "" {
+		return nil
+	}
+	if errs := validation.IsDNS1123Subdomain(o.SettingsConfigMap); len(errs) != 0 {
+		return fmt.Errorf("%q is not a valid settings-configmap, %s", o.SettingsConfigMap, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
 func (o Options) validateRequiredFields() error {
 	if o.ClusterName == "" {
 		return fmt.Errorf("missing field, cluster-name")
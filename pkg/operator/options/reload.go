@@ -0,0 +1,89 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+
+	"sync/atomic"
+
+	"github.com/samber/lo"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+)
+
+// ReloadableFlags are the flags whose settings can be changed at runtime through the settings-configmap. Their values
+// are read from the options in the context on every use, so that a reload takes effect without a restart. Settings of
+// the core options, like the batch durations and feature gates, aren't reloadable since the core options are read in
+// place and can't be replaced while they're in use.
+var ReloadableFlags = []string{
+	"ami-cache-ttl",
+	"instance-profile-cache-ttl",
+	"pricing-update-period",
+	"reserved-enis",
+	"security-group-cache-ttl",
+	"subnet-cache-ttl",
+	"vm-memory-overhead-percent",
+}
+
+// Reload parses the settings, which are keyed by the names of ReloadableFlags, on top of the base options that the
+// controller was started with, and applies the reloadable settings to the options in the context. Settings that are
+// removed revert to their base value. The options in the context aren't changed if any setting is invalid.
+//
+// The options in the context are read concurrently, so they are never changed in place. The settings are applied to a
+// copy of them instead, which replaces them for the following calls of FromContext.
+func Reload(ctx context.Context, base *Options, settings map[string]string) error {
+	opts, err := parseSettings(base, settings)
+	if err != nil {
+		return err
+	}
+	active := ctx.Value(optionsKey{}).(*atomic.Pointer[Options])
+	dst := lo.ToPtr(*active.Load())
+	dst.AMICacheTTL = opts.AMICacheTTL
+	dst.SubnetCacheTTL = opts.SubnetCacheTTL
+	dst.SecurityGroupCacheTTL = opts.SecurityGroupCacheTTL
+	dst.InstanceProfileCacheTTL = opts.InstanceProfileCacheTTL
+	dst.PricingUpdatePeriod = opts.PricingUpdatePeriod
+	dst.ReservedENIs = opts.ReservedENIs
+	dst.VMMemoryOverheadPercent = opts.VMMemoryOverheadPercent
+	active.Store(dst)
+	return nil
+}
+
+func parseSettings(base *Options, settings map[string]string) (*Options, error) {
+	keys := lo.Keys(settings)
+	sort.Strings(keys)
+	var args []string
+	for _, key := range keys {
+		if !lo.Contains(ReloadableFlags, key) {
+			return nil, fmt.Errorf("%q is not a reloadable setting", key)
+		}
+		args = append(args, fmt.Sprintf("--%s=%s", key, settings[key]))
+	}
+	fs := &coreoptions.FlagSet{FlagSet: flag.NewFlagSet("settings", flag.ContinueOnError)}
+	fs.SetOutput(io.Discard)
+	opts := &Options{}
+	opts.AddFlags(fs)
+	// The flags are registered with defaults from the environment, which are replaced with the base options so that
+	// only the settings in the ConfigMap differ from the options that the controller was started with
+	*opts = *base
+	if err := opts.Parse(fs, args...); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
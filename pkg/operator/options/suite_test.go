@@ -17,7 +17,9 @@ package options_test
 import (
 	"context"
 	"flag"
+	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -43,12 +45,15 @@ func TestAPIs(t *testing.T) {
 var _ = Describe("Options", func() {
 	var fs *coreoptions.FlagSet
 	var opts *options.Options
+	var caBundleFile string
 
 	BeforeEach(func() {
 		fs = &coreoptions.FlagSet{
 			FlagSet: flag.NewFlagSet("karpenter", flag.ContinueOnError),
 		}
 		opts = &options.Options{}
+		caBundleFile = filepath.Join(GinkgoT().TempDir(), "ca-bundle.pem")
+		Expect(os.WriteFile(caBundleFile, test.CACertificate(), 0600)).To(Succeed())
 	})
 	AfterEach(func() {
 		os.Clearenv()
@@ -59,17 +64,62 @@ var _ = Describe("Options", func() {
 		err := opts.Parse(fs,
 			"--assume-role-arn", "env-role",
 			"--assume-role-duration", "20m",
+			"--assume-role-session-tags", "team=platform,environment=prod",
+			"--assume-role-attribution",
 			"--cluster-ca-bundle", "env-bundle",
 			"--cluster-name", "env-cluster",
 			"--cluster-endpoint", "https://env-cluster",
 			"--isolated-vpc",
 			"--vm-memory-overhead-percent", "0.1",
 			"--interruption-queue", "env-cluster",
-			"--reserved-enis", "10")
+			"--reserved-enis", "10",
+			"--security-groups-for-pods",
+			"--aws-request-timeout", "30s",
+			"--aws-max-retries", "5",
+			"--aws-max-concurrent-requests", "50",
+			"--aws-use-fips-endpoint",
+			"--aws-use-dualstack-endpoint",
+			"--aws-endpoint-overrides", "ec2=https://ec2.example.com,ssm=https://ssm.example.com",
+			"--aws-proxy-url", "http://proxy.example.com:3128",
+			"--aws-no-proxy", "sts,.vpce.amazonaws.com",
+			"--aws-ca-bundle-file", caBundleFile,
+			"--vpc-endpoints-preflight",
+			"--ami-cache-ttl", "2m",
+			"--subnet-cache-ttl", "3m",
+			"--security-group-cache-ttl", "4m",
+			"--instance-profile-cache-ttl", "30m",
+			"--instance-profile-propagation-delay", "30s",
+			"--pricing-update-period", "6h",
+			"--spot-price-trend-window", "24h",
+			"--spot-price-refresh-period", "10m",
+			"--spot-diversification-threshold", "10",
+			"--tracing-endpoint", "otel-collector:4317",
+			"--decision-audit-log",
+			"--dry-run",
+			"--emf-namespace", "Karpenter",
+			"--emf-export-period", "30s",
+			"--debug-endpoints-port", "8082",
+			"--debug-endpoints-address", "0.0.0.0",
+			"--alert-webhook-url", "https://alerts.example.com/karpenter",
+			"--alert-sns-topic-arn", "arn:aws:sns:us-west-2:000000000000:karpenter-alerts",
+			"--alert-threshold", "3",
+			"--permissions-check-period", "30m",
+			"--public-ip-guardrail", "Enforce",
+			"--identity-agent-selector", "app=identity-agent",
+			"--node-metadata-annotations", "placement-group,ami-name",
+			"--node-role-permissions-boundary", "arn:aws:iam::000000000000:policy/NodeBoundary",
+			"--node-role-required-policies", "arn:aws:iam::aws:policy/AmazonSSMManagedInstanceCore,arn:aws:iam::000000000000:policy/Logging",
+			"--ebs-encryption-policy", "CustomerManagedKey",
+			"--quota-headroom-percent", "20",
+			"--zonal-shift", "Drift",
+			"--disabled-controllers", "pricing,garbage-collection",
+			"--settings-configmap", "karpenter-settings")
 		Expect(err).ToNot(HaveOccurred())
 		expectOptionsEqual(opts, test.Options(test.OptionsFields{
 			AssumeRoleARN:           lo.ToPtr("env-role"),
 			AssumeRoleDuration:      lo.ToPtr(20 * time.Minute),
+			AssumeRoleSessionTags:   lo.ToPtr("team=platform,environment=prod"),
+			AssumeRoleAttribution:   lo.ToPtr(true),
 			ClusterCABundle:         lo.ToPtr("env-bundle"),
 			ClusterName:             lo.ToPtr("env-cluster"),
 			ClusterEndpoint:         lo.ToPtr("https://env-cluster"),
@@ -77,11 +127,56 @@ var _ = Describe("Options", func() {
 			VMMemoryOverheadPercent: lo.ToPtr[float64](0.1),
 			InterruptionQueue:       lo.ToPtr("env-cluster"),
 			ReservedENIs:            lo.ToPtr(10),
+			SecurityGroupsForPods:   lo.ToPtr(true),
+
+			AWSRequestTimeout:               lo.ToPtr(30 * time.Second),
+			AWSMaxRetries:                   lo.ToPtr(5),
+			AWSMaxConcurrentRequests:        lo.ToPtr(50),
+			AWSUseFIPSEndpoint:              lo.ToPtr(true),
+			AWSUseDualStackEndpoint:         lo.ToPtr(true),
+			AWSEndpointOverrides:            lo.ToPtr("ec2=https://ec2.example.com,ssm=https://ssm.example.com"),
+			AWSProxyURL:                     lo.ToPtr("http://proxy.example.com:3128"),
+			AWSNoProxy:                      lo.ToPtr("sts,.vpce.amazonaws.com"),
+			AWSCABundleFile:                 lo.ToPtr(caBundleFile),
+			VPCEndpointsPreflight:           lo.ToPtr(true),
+			AMICacheTTL:                     lo.ToPtr(2 * time.Minute),
+			SubnetCacheTTL:                  lo.ToPtr(3 * time.Minute),
+			SecurityGroupCacheTTL:           lo.ToPtr(4 * time.Minute),
+			InstanceProfileCacheTTL:         lo.ToPtr(30 * time.Minute),
+			InstanceProfilePropagationDelay: lo.ToPtr(30 * time.Second),
+			PricingUpdatePeriod:             lo.ToPtr(6 * time.Hour),
+			SpotPriceTrendWindow:            lo.ToPtr(24 * time.Hour),
+			SpotPriceRefreshPeriod:          lo.ToPtr(10 * time.Minute),
+			SpotDiversificationThreshold:    lo.ToPtr(10),
+			TracingEndpoint:                 lo.ToPtr("otel-collector:4317"),
+			DecisionAuditLog:                lo.ToPtr(true),
+			DryRun:                          lo.ToPtr(true),
+			EMFNamespace:                    lo.ToPtr("Karpenter"),
+			EMFExportPeriod:                 lo.ToPtr(30 * time.Second),
+			DebugEndpointsPort:              lo.ToPtr(8082),
+			DebugEndpointsAddress:           lo.ToPtr("0.0.0.0"),
+			AlertWebhookURL:                 lo.ToPtr("https://alerts.example.com/karpenter"),
+			AlertSNSTopicARN:                lo.ToPtr("arn:aws:sns:us-west-2:000000000000:karpenter-alerts"),
+			AlertThreshold:                  lo.ToPtr(3),
+			PermissionsCheckPeriod:          lo.ToPtr(30 * time.Minute),
+			PublicIPGuardrail:               lo.ToPtr("Enforce"),
+			IdentityAgentSelector:           lo.ToPtr("app=identity-agent"),
+			NodeMetadataAnnotations:         lo.ToPtr("placement-group,ami-name"),
+
+			NodeRolePermissionsBoundary: lo.ToPtr("arn:aws:iam::000000000000:policy/NodeBoundary"),
+			NodeRoleRequiredPolicies:    lo.ToPtr("arn:aws:iam::aws:policy/AmazonSSMManagedInstanceCore,arn:aws:iam::000000000000:policy/Logging"),
+			EBSEncryptionPolicy:         lo.ToPtr("CustomerManagedKey"),
+			QuotaHeadroomPercent:        lo.ToPtr(20),
+			ZonalShift:                  lo.ToPtr("Drift"),
+			DisabledControllers:         lo.ToPtr("pricing,garbage-collection"),
+			SettingsConfigMap:           lo.ToPtr("karpenter-settings"),
 		}))
 	})
 	It("should correctly fallback to env vars when CLI flags aren't set", func() {
 		os.Setenv("ASSUME_ROLE_ARN", "env-role")
 		os.Setenv("ASSUME_ROLE_DURATION", "20m")
+		os.Setenv("ASSUME_ROLE_SESSION_TAGS", "team=platform,environment=prod")
+		os.Setenv("ASSUME_ROLE_ATTRIBUTION", "true")
 		os.Setenv("CLUSTER_CA_BUNDLE", "env-bundle")
 		os.Setenv("CLUSTER_NAME", "env-cluster")
 		os.Setenv("CLUSTER_ENDPOINT", "https://env-cluster")
@@ -89,6 +184,47 @@ var _ = Describe("Options", func() {
 		os.Setenv("VM_MEMORY_OVERHEAD_PERCENT", "0.1")
 		os.Setenv("INTERRUPTION_QUEUE", "env-cluster")
 		os.Setenv("RESERVED_ENIS", "10")
+		os.Setenv("SECURITY_GROUPS_FOR_PODS", "true")
+		os.Setenv("AWS_REQUEST_TIMEOUT", "30s")
+		os.Setenv("AWS_MAX_RETRIES", "5")
+		os.Setenv("AWS_MAX_CONCURRENT_REQUESTS", "50")
+		os.Setenv("AWS_USE_FIPS_ENDPOINT", "true")
+		os.Setenv("AWS_USE_DUALSTACK_ENDPOINT", "true")
+		os.Setenv("AWS_ENDPOINT_OVERRIDES", "ec2=https://ec2.example.com,ssm=https://ssm.example.com")
+		os.Setenv("AWS_PROXY_URL", "http://proxy.example.com:3128")
+		os.Setenv("AWS_NO_PROXY", "sts,.vpce.amazonaws.com")
+		os.Setenv("AWS_CA_BUNDLE_FILE", caBundleFile)
+		os.Setenv("VPC_ENDPOINTS_PREFLIGHT", "true")
+		os.Setenv("AMI_CACHE_TTL", "2m")
+		os.Setenv("SUBNET_CACHE_TTL", "3m")
+		os.Setenv("SECURITY_GROUP_CACHE_TTL", "4m")
+		os.Setenv("INSTANCE_PROFILE_CACHE_TTL", "30m")
+		os.Setenv("INSTANCE_PROFILE_PROPAGATION_DELAY", "30s")
+		os.Setenv("PRICING_UPDATE_PERIOD", "6h")
+		os.Setenv("SPOT_PRICE_TREND_WINDOW", "24h")
+		os.Setenv("SPOT_PRICE_REFRESH_PERIOD", "10m")
+		os.Setenv("SPOT_DIVERSIFICATION_THRESHOLD", "10")
+		os.Setenv("TRACING_ENDPOINT", "otel-collector:4317")
+		os.Setenv("DECISION_AUDIT_LOG", "true")
+		os.Setenv("DRY_RUN", "true")
+		os.Setenv("EMF_NAMESPACE", "Karpenter")
+		os.Setenv("EMF_EXPORT_PERIOD", "30s")
+		os.Setenv("DEBUG_ENDPOINTS_PORT", "8082")
+		os.Setenv("DEBUG_ENDPOINTS_ADDRESS", "0.0.0.0")
+		os.Setenv("ALERT_WEBHOOK_URL", "https://alerts.example.com/karpenter")
+		os.Setenv("ALERT_SNS_TOPIC_ARN", "arn:aws:sns:us-west-2:000000000000:karpenter-alerts")
+		os.Setenv("ALERT_THRESHOLD", "3")
+		os.Setenv("PERMISSIONS_CHECK_PERIOD", "30m")
+		os.Setenv("PUBLIC_IP_GUARDRAIL", "Enforce")
+		os.Setenv("IDENTITY_AGENT_SELECTOR", "app=identity-agent")
+		os.Setenv("NODE_METADATA_ANNOTATIONS", "placement-group,ami-name")
+		os.Setenv("NODE_ROLE_PERMISSIONS_BOUNDARY", "arn:aws:iam::000000000000:policy/NodeBoundary")
+		os.Setenv("NODE_ROLE_REQUIRED_POLICIES", "arn:aws:iam::aws:policy/AmazonSSMManagedInstanceCore,arn:aws:iam::000000000000:policy/Logging")
+		os.Setenv("EBS_ENCRYPTION_POLICY", "CustomerManagedKey")
+		os.Setenv("QUOTA_HEADROOM_PERCENT", "20")
+		os.Setenv("ZONAL_SHIFT", "Drift")
+		os.Setenv("DISABLED_CONTROLLERS", "pricing,garbage-collection")
+		os.Setenv("SETTINGS_CONFIGMAP", "karpenter-settings")
 
 		// Add flags after we set the environment variables so that the parsing logic correctly refers
 		// to the new environment variable values
@@ -98,6 +234,8 @@ var _ = Describe("Options", func() {
 		expectOptionsEqual(opts, test.Options(test.OptionsFields{
 			AssumeRoleARN:           lo.ToPtr("env-role"),
 			AssumeRoleDuration:      lo.ToPtr(20 * time.Minute),
+			AssumeRoleSessionTags:   lo.ToPtr("team=platform,environment=prod"),
+			AssumeRoleAttribution:   lo.ToPtr(true),
 			ClusterCABundle:         lo.ToPtr("env-bundle"),
 			ClusterName:             lo.ToPtr("env-cluster"),
 			ClusterEndpoint:         lo.ToPtr("https://env-cluster"),
@@ -105,8 +243,57 @@ var _ = Describe("Options", func() {
 			VMMemoryOverheadPercent: lo.ToPtr[float64](0.1),
 			InterruptionQueue:       lo.ToPtr("env-cluster"),
 			ReservedENIs:            lo.ToPtr(10),
+			SecurityGroupsForPods:   lo.ToPtr(true),
+
+			AWSRequestTimeout:               lo.ToPtr(30 * time.Second),
+			AWSMaxRetries:                   lo.ToPtr(5),
+			AWSMaxConcurrentRequests:        lo.ToPtr(50),
+			AWSUseFIPSEndpoint:              lo.ToPtr(true),
+			AWSUseDualStackEndpoint:         lo.ToPtr(true),
+			AWSEndpointOverrides:            lo.ToPtr("ec2=https://ec2.example.com,ssm=https://ssm.example.com"),
+			AWSProxyURL:                     lo.ToPtr("http://proxy.example.com:3128"),
+			AWSNoProxy:                      lo.ToPtr("sts,.vpce.amazonaws.com"),
+			AWSCABundleFile:                 lo.ToPtr(caBundleFile),
+			VPCEndpointsPreflight:           lo.ToPtr(true),
+			AMICacheTTL:                     lo.ToPtr(2 * time.Minute),
+			SubnetCacheTTL:                  lo.ToPtr(3 * time.Minute),
+			SecurityGroupCacheTTL:           lo.ToPtr(4 * time.Minute),
+			InstanceProfileCacheTTL:         lo.ToPtr(30 * time.Minute),
+			InstanceProfilePropagationDelay: lo.ToPtr(30 * time.Second),
+			PricingUpdatePeriod:             lo.ToPtr(6 * time.Hour),
+			SpotPriceTrendWindow:            lo.ToPtr(24 * time.Hour),
+			SpotPriceRefreshPeriod:          lo.ToPtr(10 * time.Minute),
+			SpotDiversificationThreshold:    lo.ToPtr(10),
+			TracingEndpoint:                 lo.ToPtr("otel-collector:4317"),
+			DecisionAuditLog:                lo.ToPtr(true),
+			DryRun:                          lo.ToPtr(true),
+			EMFNamespace:                    lo.ToPtr("Karpenter"),
+			EMFExportPeriod:                 lo.ToPtr(30 * time.Second),
+			DebugEndpointsPort:              lo.ToPtr(8082),
+			DebugEndpointsAddress:           lo.ToPtr("0.0.0.0"),
+			AlertWebhookURL:                 lo.ToPtr("https://alerts.example.com/karpenter"),
+			AlertSNSTopicARN:                lo.ToPtr("arn:aws:sns:us-west-2:000000000000:karpenter-alerts"),
+			AlertThreshold:                  lo.ToPtr(3),
+			PermissionsCheckPeriod:          lo.ToPtr(30 * time.Minute),
+			PublicIPGuardrail:               lo.ToPtr("Enforce"),
+			IdentityAgentSelector:           lo.ToPtr("app=identity-agent"),
+			NodeMetadataAnnotations:         lo.ToPtr("placement-group,ami-name"),
+
+			NodeRolePermissionsBoundary: lo.ToPtr("arn:aws:iam::000000000000:policy/NodeBoundary"),
+			NodeRoleRequiredPolicies:    lo.ToPtr("arn:aws:iam::aws:policy/AmazonSSMManagedInstanceCore,arn:aws:iam::000000000000:policy/Logging"),
+			EBSEncryptionPolicy:         lo.ToPtr("CustomerManagedKey"),
+			QuotaHeadroomPercent:        lo.ToPtr(20),
+			ZonalShift:                  lo.ToPtr("Drift"),
+			DisabledControllers:         lo.ToPtr("pricing,garbage-collection"),
+			SettingsConfigMap:           lo.ToPtr("karpenter-settings"),
 		}))
 	})
+	It("should disable the controllers in disabledControllers", func() {
+		opts := test.Options(test.OptionsFields{DisabledControllers: lo.ToPtr(" pricing, garbage-collection,")})
+		Expect(opts.ControllerEnabled(options.ControllerPricing)).To(BeFalse())
+		Expect(opts.ControllerEnabled(options.ControllerGarbageCollection)).To(BeFalse())
+		Expect(opts.ControllerEnabled(options.ControllerInterruption)).To(BeTrue())
+	})
 
 	Context("Validation", func() {
 		BeforeEach(func() {
@@ -120,6 +307,16 @@ var _ = Describe("Options", func() {
 			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--assume-role-duration", "1s")
 			Expect(err).To(HaveOccurred())
 		})
+		It("should fail when assumeRoleSessionTags isn't a list of key=value pairs", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--assume-role-session-tags", "team")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when assumeRoleSessionTags uses a reserved key prefix", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--assume-role-session-tags", "karpenter.k8s.aws/cluster=other-cluster")
+			Expect(err).To(HaveOccurred())
+			err = opts.Parse(fs, "--cluster-name", "test-cluster", "--assume-role-session-tags", "aws:team=platform")
+			Expect(err).To(HaveOccurred())
+		})
 		It("should fail when clusterEndpoint is invalid (not absolute)", func() {
 			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--cluster-endpoint", "00000000000000000000000.gr7.us-west-2.eks.amazonaws.com")
 			Expect(err).To(HaveOccurred())
@@ -132,6 +329,221 @@ var _ = Describe("Options", func() {
 			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--reserved-enis", "-1")
 			Expect(err).To(HaveOccurred())
 		})
+		It("should fail when awsRequestTimeout is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--aws-request-timeout", "-1s")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when awsMaxRetries is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--aws-max-retries", "-1")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when awsMaxConcurrentRequests is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--aws-max-concurrent-requests", "-1")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when awsEndpointOverrides isn't a list of service=URL pairs", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--aws-endpoint-overrides", "https://ec2.example.com")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when awsEndpointOverrides overrides an unsupported service", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--aws-endpoint-overrides", "s3=https://s3.example.com")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when awsEndpointOverrides has an invalid URL", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--aws-endpoint-overrides", "ec2=ec2.example.com")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when awsProxyURL has an unsupported scheme", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--aws-proxy-url", "ftp://proxy.example.com")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when awsCABundleFile doesn't exist", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--aws-ca-bundle-file", filepath.Join(GinkgoT().TempDir(), "missing.pem"))
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when awsCABundleFile doesn't contain any certificates", func() {
+			Expect(os.WriteFile(caBundleFile, []byte("not a certificate"), 0600)).To(Succeed())
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--aws-ca-bundle-file", caBundleFile)
+			Expect(err).To(HaveOccurred())
+		})
+		DescribeTable("should fail when a cache ttl is not positive",
+			func(flag string) {
+				err := opts.Parse(fs, "--cluster-name", "test-cluster", flag, "0s")
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("ami-cache-ttl", "--ami-cache-ttl"),
+			Entry("subnet-cache-ttl", "--subnet-cache-ttl"),
+			Entry("security-group-cache-ttl", "--security-group-cache-ttl"),
+			Entry("instance-profile-cache-ttl", "--instance-profile-cache-ttl"),
+			Entry("pricing-update-period", "--pricing-update-period"),
+		)
+		It("should fail when emfExportPeriod is not positive", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--emf-export-period", "0s")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when debugEndpointsAddress is not an IP address", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--debug-endpoints-address", "localhost")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when debugEndpointsPort is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--debug-endpoints-port", "-1")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when alertWebhookURL is not an http(s) URL", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--alert-webhook-url", "alerts.example.com/karpenter")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when alertSNSTopicARN is not an SNS ARN", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--alert-sns-topic-arn", "arn:aws:sqs:us-west-2:000000000000:karpenter-alerts")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when alertThreshold is not positive", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--alert-threshold", "0")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when permissionsCheckPeriod is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--permissions-check-period", "-1m")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when publicIPGuardrail is not a supported mode", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--public-ip-guardrail", "Block")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when identityAgentSelector is not a label selector", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--identity-agent-selector", "app in identity-agent")
+			Expect(err).To(HaveOccurred())
+			err = opts.Parse(fs, "--cluster-name", "test-cluster", "--identity-agent-selector", "")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when nodeRolePermissionsBoundary is not a policy ARN", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--node-role-permissions-boundary", "arn:aws:iam::000000000000:role/NodeBoundary")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when nodeRoleRequiredPolicies contains an invalid policy ARN", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--node-role-required-policies", "arn:aws:iam::aws:policy/AmazonSSMManagedInstanceCore,Logging")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when ebsEncryptionPolicy is not a supported policy", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--ebs-encryption-policy", "Required")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when spotPriceTrendWindow is longer than the spot price history", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--spot-price-trend-window", "2200h")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when spotPriceRefreshPeriod is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--spot-price-refresh-period", "-1m")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when instanceProfilePropagationDelay is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--instance-profile-propagation-delay", "-1s")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when spotDiversificationThreshold is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--spot-diversification-threshold", "-1")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when quotaHeadroomPercent is not a percent", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--quota-headroom-percent", "100")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when zonalShift is not a supported mode", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--zonal-shift", "Enforce")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when nodeMetadataAnnotations contains an unsupported attribute", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--node-metadata-annotations", "placement-group,billing-products")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when disabledControllers contains an unsupported controller", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--disabled-controllers", "pricing,provisioner")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when settingsConfigMap is not a valid name", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--settings-configmap", "Karpenter_Settings")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("Reload", func() {
+	var base *options.Options
+
+	BeforeEach(func() {
+		base = test.Options()
+		ctx = options.ToContext(ctx, lo.ToPtr(*base))
+	})
+
+	It("should apply the reloadable settings to the options in the context", func() {
+		Expect(options.Reload(ctx, base, map[string]string{
+			"ami-cache-ttl":              "10m",
+			"subnet-cache-ttl":           "2m",
+			"security-group-cache-ttl":   "3m",
+			"instance-profile-cache-ttl": "4m",
+			"pricing-update-period":      "6h",
+			"reserved-enis":              "2",
+			"vm-memory-overhead-percent": "0.1",
+		})).To(Succeed())
+		Expect(options.FromContext(ctx).AMICacheTTL).To(Equal(10 * time.Minute))
+		Expect(options.FromContext(ctx).SubnetCacheTTL).To(Equal(2 * time.Minute))
+		Expect(options.FromContext(ctx).SecurityGroupCacheTTL).To(Equal(3 * time.Minute))
+		Expect(options.FromContext(ctx).InstanceProfileCacheTTL).To(Equal(4 * time.Minute))
+		Expect(options.FromContext(ctx).PricingUpdatePeriod).To(Equal(6 * time.Hour))
+		Expect(options.FromContext(ctx).ReservedENIs).To(Equal(2))
+		Expect(options.FromContext(ctx).VMMemoryOverheadPercent).To(Equal(0.1))
+		// Options that aren't reloadable are left untouched
+		Expect(options.FromContext(ctx).ClusterName).To(Equal(base.ClusterName))
+	})
+	It("should revert the settings that are removed to the base options", func() {
+		Expect(options.Reload(ctx, base, map[string]string{"reserved-enis": "2", "ami-cache-ttl": "10m"})).To(Succeed())
+		Expect(options.Reload(ctx, base, map[string]string{"ami-cache-ttl": "20m"})).To(Succeed())
+		Expect(options.FromContext(ctx).ReservedENIs).To(Equal(base.ReservedENIs))
+		Expect(options.FromContext(ctx).AMICacheTTL).To(Equal(20 * time.Minute))
+	})
+	It("should not change the options that were read before the reload", func() {
+		active := options.FromContext(ctx)
+		Expect(options.Reload(ctx, base, map[string]string{"reserved-enis": "2"})).To(Succeed())
+		Expect(active.ReservedENIs).To(Equal(base.ReservedENIs))
+		Expect(options.FromContext(ctx).ReservedENIs).To(Equal(2))
+	})
+	It("should reload the settings while the options are read concurrently", func() {
+		stop, done := make(chan struct{}), make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer close(done)
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					opts := options.FromContext(ctx)
+					Expect(opts.ReservedENIs).To(BeNumerically(">=", 0))
+					Expect(opts.AMICacheTTL).To(BeNumerically(">", 0))
+				}
+			}
+		}()
+		for i := range 100 {
+			Expect(options.Reload(ctx, base, map[string]string{"reserved-enis": fmt.Sprint(i % 4), "ami-cache-ttl": fmt.Sprintf("%dm", i+1)})).To(Succeed())
+		}
+		close(stop)
+		<-done
+	})
+	It("should fail when a setting isn't reloadable", func() {
+		Expect(options.Reload(ctx, base, map[string]string{"reserved-enis": "2", "cluster-name": "other-cluster"})).ToNot(Succeed())
+		Expect(options.FromContext(ctx).ReservedENIs).To(Equal(base.ReservedENIs))
+		Expect(options.FromContext(ctx).ClusterName).To(Equal(base.ClusterName))
+	})
+	It("should fail when a core setting is specified", func() {
+		Expect(options.Reload(ctx, base, map[string]string{"reserved-enis": "2", "batch-max-duration": "20s"})).ToNot(Succeed())
+		Expect(options.FromContext(ctx).ReservedENIs).To(Equal(base.ReservedENIs))
+	})
+	It("should fail when a setting can't be parsed", func() {
+		Expect(options.Reload(ctx, base, map[string]string{"reserved-enis": "2", "ami-cache-ttl": "soon"})).ToNot(Succeed())
+		Expect(options.FromContext(ctx).ReservedENIs).To(Equal(base.ReservedENIs))
+	})
+	It("should fail when a setting is invalid", func() {
+		Expect(options.Reload(ctx, base, map[string]string{"ami-cache-ttl": "20m", "reserved-enis": "-1"})).ToNot(Succeed())
+		Expect(options.FromContext(ctx).AMICacheTTL).To(Equal(base.AMICacheTTL))
 	})
 })
 
@@ -139,6 +551,8 @@ func expectOptionsEqual(optsA *options.Options, optsB *options.Options) {
 	GinkgoHelper()
 	Expect(optsA.AssumeRoleARN).To(Equal(optsB.AssumeRoleARN))
 	Expect(optsA.AssumeRoleDuration).To(Equal(optsB.AssumeRoleDuration))
+	Expect(optsA.AssumeRoleSessionTags).To(Equal(optsB.AssumeRoleSessionTags))
+	Expect(optsA.AssumeRoleAttribution).To(Equal(optsB.AssumeRoleAttribution))
 	Expect(optsA.ClusterCABundle).To(Equal(optsB.ClusterCABundle))
 	Expect(optsA.ClusterName).To(Equal(optsB.ClusterName))
 	Expect(optsA.ClusterEndpoint).To(Equal(optsB.ClusterEndpoint))
@@ -146,4 +560,45 @@ func expectOptionsEqual(optsA *options.Options, optsB *options.Options) {
 	Expect(optsA.VMMemoryOverheadPercent).To(Equal(optsB.VMMemoryOverheadPercent))
 	Expect(optsA.InterruptionQueue).To(Equal(optsB.InterruptionQueue))
 	Expect(optsA.ReservedENIs).To(Equal(optsB.ReservedENIs))
+	Expect(optsA.SecurityGroupsForPods).To(Equal(optsB.SecurityGroupsForPods))
+	Expect(optsA.AWSRequestTimeout).To(Equal(optsB.AWSRequestTimeout))
+	Expect(optsA.AWSMaxRetries).To(Equal(optsB.AWSMaxRetries))
+	Expect(optsA.AWSMaxConcurrentRequests).To(Equal(optsB.AWSMaxConcurrentRequests))
+	Expect(optsA.AWSUseFIPSEndpoint).To(Equal(optsB.AWSUseFIPSEndpoint))
+	Expect(optsA.AWSUseDualStackEndpoint).To(Equal(optsB.AWSUseDualStackEndpoint))
+	Expect(optsA.AWSEndpointOverrides).To(Equal(optsB.AWSEndpointOverrides))
+	Expect(optsA.AWSProxyURL).To(Equal(optsB.AWSProxyURL))
+	Expect(optsA.AWSNoProxy).To(Equal(optsB.AWSNoProxy))
+	Expect(optsA.AWSCABundleFile).To(Equal(optsB.AWSCABundleFile))
+	Expect(optsA.VPCEndpointsPreflight).To(Equal(optsB.VPCEndpointsPreflight))
+	Expect(optsA.AMICacheTTL).To(Equal(optsB.AMICacheTTL))
+	Expect(optsA.SubnetCacheTTL).To(Equal(optsB.SubnetCacheTTL))
+	Expect(optsA.SecurityGroupCacheTTL).To(Equal(optsB.SecurityGroupCacheTTL))
+	Expect(optsA.InstanceProfileCacheTTL).To(Equal(optsB.InstanceProfileCacheTTL))
+	Expect(optsA.InstanceProfilePropagationDelay).To(Equal(optsB.InstanceProfilePropagationDelay))
+	Expect(optsA.PricingUpdatePeriod).To(Equal(optsB.PricingUpdatePeriod))
+	Expect(optsA.SpotPriceTrendWindow).To(Equal(optsB.SpotPriceTrendWindow))
+	Expect(optsA.SpotPriceRefreshPeriod).To(Equal(optsB.SpotPriceRefreshPeriod))
+	Expect(optsA.SpotDiversificationThreshold).To(Equal(optsB.SpotDiversificationThreshold))
+	Expect(optsA.TracingEndpoint).To(Equal(optsB.TracingEndpoint))
+	Expect(optsA.DecisionAuditLog).To(Equal(optsB.DecisionAuditLog))
+	Expect(optsA.DryRun).To(Equal(optsB.DryRun))
+	Expect(optsA.EMFNamespace).To(Equal(optsB.EMFNamespace))
+	Expect(optsA.EMFExportPeriod).To(Equal(optsB.EMFExportPeriod))
+	Expect(optsA.DebugEndpointsPort).To(Equal(optsB.DebugEndpointsPort))
+	Expect(optsA.DebugEndpointsAddress).To(Equal(optsB.DebugEndpointsAddress))
+	Expect(optsA.AlertWebhookURL).To(Equal(optsB.AlertWebhookURL))
+	Expect(optsA.AlertSNSTopicARN).To(Equal(optsB.AlertSNSTopicARN))
+	Expect(optsA.AlertThreshold).To(Equal(optsB.AlertThreshold))
+	Expect(optsA.PermissionsCheckPeriod).To(Equal(optsB.PermissionsCheckPeriod))
+	Expect(optsA.PublicIPGuardrail).To(Equal(optsB.PublicIPGuardrail))
+	Expect(optsA.IdentityAgentSelector).To(Equal(optsB.IdentityAgentSelector))
+	Expect(optsA.NodeMetadataAnnotations).To(Equal(optsB.NodeMetadataAnnotations))
+	Expect(optsA.NodeRolePermissionsBoundary).To(Equal(optsB.NodeRolePermissionsBoundary))
+	Expect(optsA.NodeRoleRequiredPolicies).To(Equal(optsB.NodeRoleRequiredPolicies))
+	Expect(optsA.EBSEncryptionPolicy).To(Equal(optsB.EBSEncryptionPolicy))
+	Expect(optsA.QuotaHeadroomPercent).To(Equal(optsB.QuotaHeadroomPercent))
+	Expect(optsA.ZonalShift).To(Equal(optsB.ZonalShift))
+	Expect(optsA.DisabledControllers).To(Equal(optsB.DisabledControllers))
+	Expect(optsA.SettingsConfigMap).To(Equal(optsB.SettingsConfigMap))
 }
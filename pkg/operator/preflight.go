@@ -0,0 +1,134 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/eks"
+	awspricing "github.com/aws/aws-sdk-go/service/pricing"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+)
+
+// serviceCheckTimeout bounds each preflight call, since unreachable endpoints in private subnets usually time out
+// instead of refusing the connection
+const serviceCheckTimeout = 10 * time.Second
+
+// ServiceCheck is a call to an AWS service that is made during the preflight to verify that the service is reachable
+type ServiceCheck struct {
+	Service  string
+	Endpoint string
+	// Optional services are only reported, since Karpenter can operate without them
+	Optional bool
+	Call     func(context.Context) error
+}
+
+// NewServiceChecks returns the checks for the AWS services that Karpenter requires with the current options
+func NewServiceChecks(ctx context.Context, sess *session.Session) []ServiceCheck {
+	ec2api, ssmapi, eksapi, stsapi := ec2.New(sess), ssm.New(sess), eks.New(sess), sts.New(sess)
+	checks := []ServiceCheck{
+		{Service: "sts", Endpoint: stsapi.Endpoint, Call: func(ctx context.Context) error {
+			_, err := stsapi.GetCallerIdentityWithContext(ctx, &sts.GetCallerIdentityInput{})
+			return err
+		}},
+		{Service: "ec2", Endpoint: ec2api.Endpoint, Call: func(ctx context.Context) error {
+			_, err := ec2api.DescribeInstanceTypesWithContext(ctx, &ec2.DescribeInstanceTypesInput{DryRun: aws.Bool(true)})
+			return err
+		}},
+		{Service: "ssm", Endpoint: ssmapi.Endpoint, Call: func(ctx context.Context) error {
+			_, err := ssmapi.GetParameterWithContext(ctx, &ssm.GetParameterInput{Name: aws.String("/aws/service/global-infrastructure/current-region")})
+			return err
+		}},
+		{Service: "eks", Endpoint: eksapi.Endpoint, Call: func(ctx context.Context) error {
+			_, err := eksapi.DescribeClusterWithContext(ctx, &eks.DescribeClusterInput{Name: aws.String(options.FromContext(ctx).ClusterName)})
+			return err
+		}},
+	}
+	if queue := options.FromContext(ctx).InterruptionQueue; queue != "" && options.FromContext(ctx).ControllerEnabled(options.ControllerInterruption) {
+		sqsapi := sqs.New(sess)
+		checks = append(checks, ServiceCheck{Service: "sqs", Endpoint: sqsapi.Endpoint, Call: func(ctx context.Context) error {
+			_, err := sqsapi.GetQueueUrlWithContext(ctx, &sqs.GetQueueUrlInput{QueueName: aws.String(queue)})
+			return err
+		}})
+	}
+	// On-demand prices fall back to the static pricing data when the pricing API can't be reached
+	if !options.FromContext(ctx).IsolatedVPC && options.FromContext(ctx).ControllerEnabled(options.ControllerPricing) {
+		pricingapi := pricing.NewAPI(sess, *sess.Config.Region).(*awspricing.Pricing)
+		checks = append(checks, ServiceCheck{Service: "pricing", Endpoint: pricingapi.Endpoint, Optional: true, Call: func(ctx context.Context) error {
+			_, err := pricingapi.DescribeServicesWithContext(ctx, &awspricing.DescribeServicesInput{ServiceCode: aws.String("AmazonEC2"), MaxResults: aws.Int64(1)})
+			return err
+		}})
+	}
+	return checks
+}
+
+// CheckServiceConnectivity makes the call of each check and returns an error listing the required services that
+// can't be reached. Any response from a service, including authorization errors, means that it is reachable.
+func CheckServiceConnectivity(ctx context.Context, checks []ServiceCheck) error {
+	var missing []string
+	for _, check := range checks {
+		callCtx, cancel := context.WithTimeout(ctx, serviceCheckTimeout)
+		err := check.Call(callCtx)
+		cancel()
+		if !IsUnreachable(err) {
+			log.FromContext(ctx).WithValues("service", check.Service, "endpoint", check.Endpoint).V(1).Info("aws service is reachable")
+			continue
+		}
+		if check.Optional {
+			log.FromContext(ctx).WithValues("service", check.Service, "endpoint", check.Endpoint).Info(fmt.Sprintf("optional aws service isn't reachable, %s", err))
+			continue
+		}
+		log.FromContext(ctx).WithValues("service", check.Service, "endpoint", check.Endpoint).Error(err, "aws service isn't reachable")
+		missing = append(missing, fmt.Sprintf("%s (%s)", check.Service, check.Endpoint))
+	}
+	if len(missing) != 0 {
+		return fmt.Errorf("aws services aren't reachable, check the VPC endpoints for %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// IsUnreachable returns true if the request never received a response, because the connection to the service
+// couldn't be established or timed out
+func IsUnreachable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	// Credential errors wrap the error of the request that failed to retrieve them
+	for aerr, ok := lo.ErrorsAs[awserr.Error](err); ok; aerr, ok = lo.ErrorsAs[awserr.Error](aerr.OrigErr()) {
+		if lo.Contains([]string{request.ErrCodeRequestError, request.ErrCodeResponseTimeout, request.CanceledErrorCode}, aerr.Code()) {
+			return true
+		}
+	}
+	return false
+}
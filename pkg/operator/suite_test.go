@@ -16,17 +16,42 @@ package operator_test
 
 import (
 	"context"
+	"encoding/pem"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	coretest "sigs.k8s.io/karpenter/pkg/test"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
 	"github.com/aws/karpenter-provider-aws/pkg/fake"
 	awscontext "github.com/aws/karpenter-provider-aws/pkg/operator"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
@@ -103,4 +128,336 @@ var _ = Describe("Operator", func() {
 		_, err := awscontext.ResolveClusterEndpoint(ctx, fakeEKSAPI)
 		Expect(err).To(HaveOccurred())
 	})
+	Context("AWS Endpoints", func() {
+		newSession := func() *session.Session {
+			return session.Must(session.NewSession(awscontext.WithEndpoints(ctx, &aws.Config{Region: lo.ToPtr("us-west-2")})))
+		}
+		It("should use the default endpoints", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			Expect(ec2.New(newSession()).Endpoint).To(Equal("https://ec2.us-west-2.amazonaws.com"))
+		})
+		It("should use the FIPS endpoints", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				AWSUseFIPSEndpoint: lo.ToPtr(true),
+			}))
+			Expect(ec2.New(newSession()).Endpoint).To(Equal("https://ec2-fips.us-west-2.amazonaws.com"))
+		})
+		It("should use the dual-stack endpoints", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				AWSUseDualStackEndpoint: lo.ToPtr(true),
+			}))
+			Expect(ec2.New(newSession()).Endpoint).To(Equal("https://ec2.us-west-2.api.aws"))
+		})
+		It("should use the endpoint overrides", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				AWSUseFIPSEndpoint:   lo.ToPtr(true),
+				AWSEndpointOverrides: lo.ToPtr("ec2=https://ec2.example.com,pricing=https://pricing.example.com"),
+			}))
+			sess := newSession()
+			Expect(ec2.New(sess).Endpoint).To(Equal("https://ec2.example.com"))
+			Expect(pricing.New(sess).Endpoint).To(Equal("https://pricing.example.com"))
+			// Services that aren't overridden still use the FIPS endpoints
+			Expect(ssm.New(sess).Endpoint).To(Equal("https://ssm-fips.us-west-2.amazonaws.com"))
+		})
+	})
+	Context("Assume Role Session", func() {
+		It("should only set the duration by default", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			Expect(awscontext.AssumeRoleSession(ctx)).To(Equal(assumerole.Session{Duration: 15 * time.Minute, Tags: map[string]string{}}))
+		})
+		It("should attribute sessions to the cluster and NodePool", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				ClusterName:           lo.ToPtr(strings.Repeat("a", 100)),
+				AssumeRoleSessionTags: lo.ToPtr("team=platform"),
+				AssumeRoleAttribution: lo.ToPtr(true),
+			}))
+			Expect(awscontext.AssumeRoleSession(ctx)).To(Equal(assumerole.Session{
+				Duration:       15 * time.Minute,
+				Tags:           map[string]string{"team": "platform", "karpenter.k8s.aws/cluster": strings.Repeat("a", 100)},
+				SourceIdentity: strings.Repeat("a", 64),
+				NodePoolTagKey: "karpenter.sh/nodepool",
+			}))
+		})
+	})
+	Context("HTTP Client", func() {
+		It("should use the SDK default when nothing is configured", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			Expect(awscontext.NewHTTPClient(ctx)).To(BeNil())
+		})
+		It("should trust the CA bundle", func() {
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+			defer server.Close()
+			caBundleFile := filepath.Join(GinkgoT().TempDir(), "ca-bundle.pem")
+			Expect(os.WriteFile(caBundleFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600)).To(Succeed())
+
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{AWSRequestTimeout: lo.ToPtr(time.Second)}))
+			client := lo.Must(awscontext.NewHTTPClient(ctx))
+			_, err := client.Get(server.URL)
+			Expect(err).To(HaveOccurred())
+
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{AWSCABundleFile: lo.ToPtr(caBundleFile)}))
+			client = lo.Must(awscontext.NewHTTPClient(ctx))
+			resp, err := client.Get(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Body.Close()).To(Succeed())
+		})
+		It("should fail when the CA bundle doesn't contain any certificates", func() {
+			caBundleFile := filepath.Join(GinkgoT().TempDir(), "ca-bundle.pem")
+			Expect(os.WriteFile(caBundleFile, []byte("not a certificate"), 0600)).To(Succeed())
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{AWSCABundleFile: lo.ToPtr(caBundleFile)}))
+			_, err := awscontext.NewHTTPClient(ctx)
+			Expect(err).To(HaveOccurred())
+		})
+		Context("Proxy", func() {
+			// proxyFor returns the proxy that the request of a service client is sent through, or nil if it bypasses the proxy
+			proxyFor := func(newClient func(*session.Session) func() error) *url.URL {
+				var proxy *url.URL
+				// A CA bundle from the environment can't be loaded into a custom transport
+				GinkgoT().Setenv("AWS_CA_BUNDLE", "")
+				sess := awscontext.WithServiceName(session.Must(session.NewSession(awscontext.WithEndpoints(ctx, &aws.Config{
+					Region:      lo.ToPtr("us-west-2"),
+					Credentials: credentials.AnonymousCredentials,
+					MaxRetries:  lo.ToPtr(0),
+					HTTPClient: &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+						proxy = lo.Must(awscontext.NewProxyFunc(ctx)(req))
+						return nil, errors.New("request not sent")
+					})},
+				}))))
+				Expect(newClient(sess)()).To(HaveOccurred())
+				return proxy
+			}
+			ec2Request := func(sess *session.Session) func() error {
+				return func() error { _, err := ec2.New(sess).DescribeRegions(&ec2.DescribeRegionsInput{}); return err }
+			}
+			pricingRequest := func(sess *session.Session) func() error {
+				return func() error {
+					_, err := pricing.New(sess).DescribeServices(&pricing.DescribeServicesInput{})
+					return err
+				}
+			}
+			ssmRequest := func(sess *session.Session) func() error {
+				return func() error {
+					_, err := ssm.New(sess).GetParameter(&ssm.GetParameterInput{Name: lo.ToPtr("test")})
+					return err
+				}
+			}
+			It("should send requests through the proxy", func() {
+				ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+					AWSProxyURL: lo.ToPtr("http://proxy.example.com:3128"),
+				}))
+				Expect(proxyFor(ec2Request)).To(Equal(lo.Must(url.Parse("http://proxy.example.com:3128"))))
+				Expect(proxyFor(pricingRequest)).To(Equal(lo.Must(url.Parse("http://proxy.example.com:3128"))))
+			})
+			It("should bypass the proxy for services in aws-no-proxy", func() {
+				ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+					AWSProxyURL: lo.ToPtr("http://proxy.example.com:3128"),
+					AWSNoProxy:  lo.ToPtr("ec2,pricing"),
+				}))
+				Expect(proxyFor(ec2Request)).To(BeNil())
+				Expect(proxyFor(pricingRequest)).To(BeNil())
+				Expect(proxyFor(ssmRequest)).To(Equal(lo.Must(url.Parse("http://proxy.example.com:3128"))))
+			})
+			It("should bypass the proxy for hosts in aws-no-proxy", func() {
+				ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+					AWSProxyURL:          lo.ToPtr("http://proxy.example.com:3128"),
+					AWSNoProxy:           lo.ToPtr(".vpce.amazonaws.com"),
+					AWSEndpointOverrides: lo.ToPtr("ec2=https://vpce-0123456789abcdef0.ec2.us-west-2.vpce.amazonaws.com"),
+				}))
+				Expect(proxyFor(ec2Request)).To(BeNil())
+				Expect(proxyFor(ssmRequest)).To(Equal(lo.Must(url.Parse("http://proxy.example.com:3128"))))
+			})
+		})
+	})
+	Context("VPC Endpoints Preflight", func() {
+		var server *httptest.Server
+		BeforeEach(func() {
+			// Any response means that the service is reachable, even if the request isn't authorized
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+			}))
+		})
+		AfterEach(func() {
+			server.Close()
+		})
+		// endpointOverrides overrides the endpoint of each service to the test server, unless it's unreachable
+		endpointOverrides := func(unreachable ...string) string {
+			return strings.Join(lo.Map([]string{"ec2", "eks", "pricing", "sqs", "ssm", "sts"}, func(service string, _ int) string {
+				return fmt.Sprintf("%s=%s", service, lo.Ternary(lo.Contains(unreachable, service), "http://127.0.0.1:1", server.URL))
+			}), ",")
+		}
+		newSession := func() *session.Session {
+			return session.Must(session.NewSession(awscontext.WithEndpoints(ctx, &aws.Config{
+				Region:      lo.ToPtr("us-west-2"),
+				Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+				MaxRetries:  lo.ToPtr(0),
+			})))
+		}
+		checkedServices := func(checks []awscontext.ServiceCheck) []string {
+			return lo.Map(checks, func(check awscontext.ServiceCheck, _ int) string { return check.Service })
+		}
+		It("should succeed when all services are reachable", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				InterruptionQueue:    lo.ToPtr("test-queue"),
+				AWSEndpointOverrides: lo.ToPtr(endpointOverrides()),
+			}))
+			checks := awscontext.NewServiceChecks(ctx, newSession())
+			Expect(checkedServices(checks)).To(ConsistOf("ec2", "eks", "pricing", "sqs", "ssm", "sts"))
+			Expect(awscontext.CheckServiceConnectivity(ctx, checks)).To(Succeed())
+		})
+		It("should report the services that aren't reachable", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				InterruptionQueue:    lo.ToPtr("test-queue"),
+				AWSEndpointOverrides: lo.ToPtr(endpointOverrides("ssm", "sqs")),
+			}))
+			err := awscontext.CheckServiceConnectivity(ctx, awscontext.NewServiceChecks(ctx, newSession()))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("ssm (http://127.0.0.1:1)"))
+			Expect(err.Error()).To(ContainSubstring("sqs (http://127.0.0.1:1)"))
+			Expect(err.Error()).ToNot(ContainSubstring("ec2"))
+		})
+		It("should not fail when the pricing API isn't reachable", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				AWSEndpointOverrides: lo.ToPtr(endpointOverrides("pricing")),
+			}))
+			Expect(awscontext.CheckServiceConnectivity(ctx, awscontext.NewServiceChecks(ctx, newSession()))).To(Succeed())
+		})
+		It("should only check the services that are used", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				IsolatedVPC: lo.ToPtr(true),
+			}))
+			Expect(checkedServices(awscontext.NewServiceChecks(ctx, newSession()))).To(ConsistOf("ec2", "eks", "ssm", "sts"))
+		})
+		It("should treat timeouts and credential errors as unreachable", func() {
+			Expect(awscontext.IsUnreachable(awserr.New(request.CanceledErrorCode, "", context.DeadlineExceeded))).To(BeTrue())
+			Expect(awscontext.IsUnreachable(awserr.New("WebIdentityErr", "", awserr.New(request.ErrCodeRequestError, "", nil)))).To(BeTrue())
+			Expect(awscontext.IsUnreachable(awserr.New("AccessDeniedException", "", nil))).To(BeFalse())
+			Expect(awscontext.IsUnreachable(nil)).To(BeFalse())
+		})
+	})
+	Context("Dry-Run", func() {
+		newRequest := func(service, operation string, params interface{}) *request.Request {
+			return &request.Request{
+				ClientInfo: metadata.ClientInfo{ServiceID: service},
+				Operation:  &request.Operation{Name: operation},
+				Params:     params,
+			}
+		}
+		It("should not change read-only requests", func() {
+			input := &ec2.DescribeSubnetsInput{}
+			r := newRequest("EC2", "DescribeSubnets", input)
+			awscontext.DryRunHandler.Fn(r)
+			Expect(r.Error).ToNot(HaveOccurred())
+			Expect(input.DryRun).To(BeNil())
+		})
+		It("should send mutating requests with a DryRun parameter with it set", func() {
+			input := &ec2.CreateFleetInput{}
+			r := newRequest("EC2", "CreateFleet", input)
+			awscontext.DryRunHandler.Fn(r)
+			Expect(r.Error).ToNot(HaveOccurred())
+			Expect(aws.BoolValue(input.DryRun)).To(BeTrue())
+		})
+		It("should fail mutating requests without a DryRun parameter", func() {
+			r := newRequest("IAM", "CreateInstanceProfile", &iam.CreateInstanceProfileInput{})
+			awscontext.DryRunHandler.Fn(r)
+			Expect(awserrors.IsDryRun(r.Error)).To(BeTrue())
+		})
+		It("should add the handler to the clients created from the session", func() {
+			sess := awscontext.WithDryRun(session.Must(session.NewSession()))
+			Expect(ec2.New(sess).Handlers.Validate.Len()).To(BeNumerically(">", ec2.New(session.Must(session.NewSession())).Handlers.Validate.Len()))
+		})
+		Context("Kubernetes Client", func() {
+			var kubeClient *awscontext.DryRunClient
+			var nodeClaim *karpv1.NodeClaim
+			var node *corev1.Node
+			var pod *corev1.Pod
+			BeforeEach(func() {
+				kubeClient = awscontext.NewDryRunClient(env.Client)
+				nodeClaim = coretest.NodeClaim()
+				node = coretest.Node()
+				pod = coretest.Pod(coretest.PodOptions{NodeName: node.Name})
+				ExpectApplied(ctx, env.Client, nodeClaim, node, pod)
+			})
+			It("should not delete NodeClaims, nodes or pods", func() {
+				Expect(kubeClient.Delete(ctx, nodeClaim)).To(Succeed())
+				Expect(kubeClient.Delete(ctx, node)).To(Succeed())
+				Expect(kubeClient.Delete(ctx, pod)).To(Succeed())
+				Expect(kubeClient.DeleteAllOf(ctx, &karpv1.NodeClaim{})).To(Succeed())
+				ExpectExists(ctx, env.Client, nodeClaim)
+				ExpectExists(ctx, env.Client, node)
+				ExpectExists(ctx, env.Client, pod)
+			})
+			It("should not cordon or taint nodes", func() {
+				stored := node.DeepCopy()
+				node.Spec.Unschedulable = true
+				node.Spec.Taints = append(node.Spec.Taints, karpv1.DisruptionNoScheduleTaint)
+				Expect(kubeClient.Patch(ctx, node, client.MergeFrom(stored))).To(Succeed())
+				node = ExpectExists(ctx, env.Client, node)
+				Expect(node.Spec.Unschedulable).To(BeFalse())
+				Expect(node.Spec.Taints).ToNot(ContainElement(karpv1.DisruptionNoScheduleTaint))
+			})
+			It("should not evict pods", func() {
+				Expect(kubeClient.SubResource("eviction").Create(ctx, pod, &policyv1.Eviction{})).To(Succeed())
+				pod = ExpectExists(ctx, env.Client, pod)
+				Expect(pod.DeletionTimestamp.IsZero()).To(BeTrue())
+			})
+			It("should fail to create NodeClaims", func() {
+				Expect(kubeClient.Create(ctx, coretest.NodeClaim())).ToNot(Succeed())
+				Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			})
+			It("should pass other requests through", func() {
+				nodeClass := test.EC2NodeClass()
+				Expect(kubeClient.Create(ctx, nodeClass)).To(Succeed())
+				ExpectExists(ctx, env.Client, nodeClass)
+				Expect(kubeClient.Delete(ctx, nodeClass)).To(Succeed())
+				ExpectNotFound(ctx, env.Client, nodeClass)
+			})
+		})
+	})
+	Context("AWS API Metrics", func() {
+		newRequest := func(operation string, err error) *request.Request {
+			return &request.Request{
+				ClientInfo: metadata.ClientInfo{ServiceID: "EC2"},
+				Operation:  &request.Operation{Name: operation},
+				Error:      err,
+			}
+		}
+		It("should record the error code of failed requests", func() {
+			awscontext.ErrorMetricsHandler.Fn(newRequest("CreateFleet", awserr.New("UnauthorizedOperation", "", nil)))
+			metric, ok := FindMetricWithLabelValues("karpenter_cloudprovider_aws_api_errors_total", map[string]string{
+				"service":    "EC2",
+				"operation":  "CreateFleet",
+				"error_code": "UnauthorizedOperation",
+			})
+			Expect(ok).To(BeTrue())
+			Expect(metric.GetCounter().GetValue()).To(BeNumerically(">=", 1))
+			_, ok = FindMetricWithLabelValues("karpenter_cloudprovider_aws_api_throttles_total", map[string]string{
+				"service":   "EC2",
+				"operation": "CreateFleet",
+			})
+			Expect(ok).To(BeFalse())
+		})
+		It("should record throttled requests", func() {
+			awscontext.ErrorMetricsHandler.Fn(newRequest("DescribeInstances", awserr.New("RequestLimitExceeded", "", nil)))
+			metric, ok := FindMetricWithLabelValues("karpenter_cloudprovider_aws_api_throttles_total", map[string]string{
+				"service":   "EC2",
+				"operation": "DescribeInstances",
+			})
+			Expect(ok).To(BeTrue())
+			Expect(metric.GetCounter().GetValue()).To(BeNumerically(">=", 1))
+		})
+		It("should not record successful requests", func() {
+			awscontext.ErrorMetricsHandler.Fn(newRequest("DescribeSubnets", nil))
+			_, ok := FindMetricWithLabelValues("karpenter_cloudprovider_aws_api_errors_total", map[string]string{
+				"service":   "EC2",
+				"operation": "DescribeSubnets",
+			})
+			Expect(ok).To(BeFalse())
+		})
+	})
 })
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -100,7 +100,7 @@ func (a AL2) extractAMIVersion(versionStr string) (string, error) {
 // even if elements of those inputs are in differing orders,
 // guaranteeing it won't cause spurious hash differences.
 // AL2 userdata also works on Ubuntu
-func (a AL2) UserData(kubeletConfig *v1.KubeletConfiguration, taints []corev1.Taint, labels map[string]string, caBundle *string, _ []*cloudprovider.InstanceType, customUserData *string, instanceStorePolicy *v1.InstanceStorePolicy) bootstrap.Bootstrapper {
+func (a AL2) UserData(kubeletConfig *v1.KubeletConfiguration, taints []corev1.Taint, labels map[string]string, caBundle *string, _ []*cloudprovider.InstanceType, customUserData *string, instanceStorePolicy *v1.InstanceStorePolicy, imageFSDevice *string, containerRegistries []v1.ContainerRegistry, _ *v1.ContainerSnapshotter, swap *v1.Swap, _ *v1.WindowsConfiguration, hugepages []v1.Hugepages, kernelParameters *v1.KernelParameters, sysctls map[string]string, kernelModules []v1.KernelModule, ptpHardwareClock *bool, dcgmExporter *v1.DCGMExporter, neuron *v1.NeuronConfiguration, ssmAgent *v1.SSMAgent, cloudWatchAgent *v1.CloudWatchAgent) bootstrap.Bootstrapper {
 	return bootstrap.EKS{
 		Options: bootstrap.Options{
 			ClusterName:         a.Options.ClusterName,
@@ -111,6 +111,18 @@ func (a AL2) UserData(kubeletConfig *v1.KubeletConfiguration, taints []corev1.Ta
 			CABundle:            caBundle,
 			CustomUserData:      customUserData,
 			InstanceStorePolicy: instanceStorePolicy,
+			ImageFSDevice:       imageFSDevice,
+			ContainerRegistries: containerRegistries,
+			Swap:                swap,
+			Hugepages:           hugepages,
+			KernelParameters:    kernelParameters,
+			Sysctls:             sysctls,
+			KernelModules:       kernelModules,
+			PTPHardwareClock:    ptpHardwareClock,
+			DCGMExporter:        dcgmExporter,
+			Neuron:              neuron,
+			SSMAgent:            ssmAgent,
+			CloudWatchAgent:     cloudWatchAgent,
 		},
 	}
 }
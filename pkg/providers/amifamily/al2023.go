@@ -106,7 +106,7 @@ func (a AL2023) extractAMIVersion(versionStr string) (string, error) {
 	return matches[1], nil
 }
 
-func (a AL2023) UserData(kubeletConfig *v1.KubeletConfiguration, taints []corev1.Taint, labels map[string]string, caBundle *string, _ []*cloudprovider.InstanceType, customUserData *string, instanceStorePolicy *v1.InstanceStorePolicy) bootstrap.Bootstrapper {
+func (a AL2023) UserData(kubeletConfig *v1.KubeletConfiguration, taints []corev1.Taint, labels map[string]string, caBundle *string, _ []*cloudprovider.InstanceType, customUserData *string, instanceStorePolicy *v1.InstanceStorePolicy, imageFSDevice *string, containerRegistries []v1.ContainerRegistry, containerSnapshotter *v1.ContainerSnapshotter, swap *v1.Swap, _ *v1.WindowsConfiguration, hugepages []v1.Hugepages, kernelParameters *v1.KernelParameters, sysctls map[string]string, kernelModules []v1.KernelModule, ptpHardwareClock *bool, dcgmExporter *v1.DCGMExporter, neuron *v1.NeuronConfiguration, ssmAgent *v1.SSMAgent, cloudWatchAgent *v1.CloudWatchAgent) bootstrap.Bootstrapper {
 	return bootstrap.Nodeadm{
 		Options: bootstrap.Options{
 			ClusterName:             a.Options.ClusterName,
@@ -119,6 +119,19 @@ func (a AL2023) UserData(kubeletConfig *v1.KubeletConfiguration, taints []corev1
 			AWSENILimitedPodDensity: false,
 			CustomUserData:          customUserData,
 			InstanceStorePolicy:     instanceStorePolicy,
+			ImageFSDevice:           imageFSDevice,
+			ContainerRegistries:     containerRegistries,
+			ContainerSnapshotter:    containerSnapshotter,
+			Swap:                    swap,
+			Hugepages:               hugepages,
+			KernelParameters:        kernelParameters,
+			Sysctls:                 sysctls,
+			KernelModules:           kernelModules,
+			PTPHardwareClock:        ptpHardwareClock,
+			DCGMExporter:            dcgmExporter,
+			Neuron:                  neuron,
+			SSMAgent:                ssmAgent,
+			CloudWatchAgent:         cloudWatchAgent,
 		},
 	}
 }
@@ -16,19 +16,24 @@ package amifamily
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
 	"github.com/mitchellh/hashstructure/v2"
 	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/version"
 
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
@@ -46,15 +51,17 @@ type DefaultProvider struct {
 	sync.Mutex
 	cache           *cache.Cache
 	ec2api          ec2iface.EC2API
+	kmsapi          kmsiface.KMSAPI
 	cm              *pretty.ChangeMonitor
 	versionProvider version.Provider
 	ssmProvider     ssm.Provider
 }
 
-func NewDefaultProvider(versionProvider version.Provider, ssmProvider ssm.Provider, ec2api ec2iface.EC2API, cache *cache.Cache) *DefaultProvider {
+func NewDefaultProvider(versionProvider version.Provider, ssmProvider ssm.Provider, ec2api ec2iface.EC2API, kmsapi kmsiface.KMSAPI, cache *cache.Cache) *DefaultProvider {
 	return &DefaultProvider{
 		cache:           cache,
 		ec2api:          ec2api,
+		kmsapi:          kmsapi,
 		cm:              pretty.NewChangeMonitor(),
 		versionProvider: versionProvider,
 		ssmProvider:     ssmProvider,
@@ -69,7 +76,13 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass)
 	if err != nil {
 		return nil, fmt.Errorf("getting AMI queries, %w", err)
 	}
-	amis, err := p.amis(ctx, queries)
+	var publicKey *ecdsa.PublicKey
+	if nodeClass.Spec.AMIVerification != nil {
+		if publicKey, err = p.publicKey(ctx, nodeClass.Spec.AMIVerification); err != nil {
+			return nil, fmt.Errorf("getting ami verification key, %w", err)
+		}
+	}
+	amis, err := p.amis(ctx, queries, nodeClass.Spec.AMIVerification, publicKey)
 	if err != nil {
 		return nil, err
 	}
@@ -145,17 +158,18 @@ func (p *DefaultProvider) DescribeImageQueries(ctx context.Context, nodeClass *v
 }
 
 //nolint:gocyclo
-func (p *DefaultProvider) amis(ctx context.Context, queries []DescribeImageQuery) (AMIs, error) {
-	hash, err := hashstructure.Hash(queries, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+func (p *DefaultProvider) amis(ctx context.Context, queries []DescribeImageQuery, verification *v1.AMIVerification, publicKey *ecdsa.PublicKey) (AMIs, error) {
+	hash, err := hashstructure.Hash([]any{queries, verification}, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
 	if err != nil {
 		return nil, err
 	}
-	if images, ok := p.cache.Get(fmt.Sprintf("%d", hash)); ok {
+	if images, ok := p.cache.Get(assumerole.CacheKey(ctx, fmt.Sprintf("%d", hash))); ok {
 		// Ensure what's returned from this function is a deep-copy of AMIs so alterations
 		// to the data don't affect the original
 		return append(AMIs{}, images.(AMIs)...), nil
 	}
 	images := map[uint64]AMI{}
+	rejected := map[string]error{}
 	for _, query := range queries {
 		if err = p.ec2api.DescribeImagesPagesWithContext(ctx, query.DescribeImagesInput(), func(page *ec2.DescribeImagesOutput, _ bool) bool {
 			for _, image := range page.Images {
@@ -163,6 +177,13 @@ func (p *DefaultProvider) amis(ctx context.Context, queries []DescribeImageQuery
 				if !ok {
 					continue
 				}
+				// Images that fail verification are rejected, which falls back to the newest image that passes it
+				if publicKey != nil {
+					if err := verifySignature(publicKey, image); err != nil {
+						rejected[lo.FromPtr(image.ImageId)] = err
+						continue
+					}
+				}
 				// Each image may have multiple associated sets of requirements. For example, an image may be compatible with Neuron instances
 				// and GPU instances. In that case, we'll have a set of requirements for each, and will create one "image" for each.
 				for _, reqs := range query.RequirementsForImageWithArchitecture(lo.FromPtr(image.ImageId), arch) {
@@ -191,7 +212,18 @@ func (p *DefaultProvider) amis(ctx context.Context, queries []DescribeImageQuery
 			return nil, fmt.Errorf("describing images, %w", err)
 		}
 	}
-	p.cache.SetDefault(fmt.Sprintf("%d", hash), AMIs(lo.Values(images)))
+	if len(rejected) != 0 {
+		ids := lo.Keys(rejected)
+		sort.Strings(ids)
+		if p.cm.HasChanged(fmt.Sprintf("rejected-amis/%d", hash), ids) {
+			log.FromContext(ctx).WithValues("ids", ids, "error", rejected[ids[0]]).Info("rejected amis that failed verification")
+		}
+		// The failure isn't cached so that newly signed images are picked up on the next attempt
+		if len(images) == 0 {
+			return nil, VerificationError{fmt.Errorf("all %d discovered amis failed verification, verifying %s, %w", len(ids), ids[0], rejected[ids[0]])}
+		}
+	}
+	p.cache.Set(assumerole.CacheKey(ctx, fmt.Sprintf("%d", hash)), AMIs(lo.Values(images)), options.FromContext(ctx).AMICacheTTL)
 	return lo.Values(images), nil
 }
 
@@ -15,17 +15,29 @@ limitations under the License.
 package bootstrap
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
 	"fmt"
+	"net/url"
 	"sort"
 	"strings"
 
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 )
 
+// dcgmExporterImage is the image of the DCGM exporter that runs on nodes with NVIDIA GPUs, unless the EC2NodeClass
+// overrides it
+const dcgmExporterImage = "nvcr.io/nvidia/k8s/dcgm-exporter:3.3.7-3.5.0-ubuntu22.04"
+
+// maxUserDataSize is the limit of EC2 on the size of the user data of an instance, before it's base64 encoded
+const maxUserDataSize = 16 * 1024
+
 // Options is the node bootstrapping parameters passed from Karpenter to the provisioning node
 type Options struct {
 	ClusterName             string
@@ -39,6 +51,53 @@ type Options struct {
 	ContainerRuntime        *string
 	CustomUserData          *string
 	InstanceStorePolicy     *v1.InstanceStorePolicy
+	ImageFSDevice           *string
+	ContainerRegistries     []v1.ContainerRegistry
+	ContainerSnapshotter    *v1.ContainerSnapshotter
+	Swap                    *v1.Swap
+	WindowsConfiguration    *v1.WindowsConfiguration
+	Hugepages               []v1.Hugepages
+	KernelParameters        *v1.KernelParameters
+	Sysctls                 map[string]string `hash:"set"`
+	KernelModules           []v1.KernelModule
+	PTPHardwareClock        *bool
+	DCGMExporter            *v1.DCGMExporter
+	Neuron                  *v1.NeuronConfiguration
+	SSMAgent                *v1.SSMAgent
+	CloudWatchAgent         *v1.CloudWatchAgent
+}
+
+// limitUserDataSize returns the base64 encoded user data within the size limit of EC2. User data that's larger than the
+// limit is gzip compressed if the AMI decompresses it on boot, which cloud-init and nodeadm do. User data that's still
+// too large is rejected here since RunInstances only fails with an opaque error for it.
+func (o Options) limitUserDataSize(userData string, compressible bool) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(userData)
+	if err != nil {
+		return "", fmt.Errorf("decoding user data, %w", err)
+	}
+	if len(decoded) <= maxUserDataSize {
+		return userData, nil
+	}
+	size := len(decoded)
+	if compressible {
+		var compressed bytes.Buffer
+		w, err := gzip.NewWriterLevel(&compressed, gzip.BestCompression)
+		if err != nil {
+			return "", err
+		}
+		if _, err = w.Write(decoded); err != nil {
+			return "", fmt.Errorf("compressing user data, %w", err)
+		}
+		if err = w.Close(); err != nil {
+			return "", fmt.Errorf("compressing user data, %w", err)
+		}
+		if compressed.Len() <= maxUserDataSize {
+			return base64.StdEncoding.EncodeToString(compressed.Bytes()), nil
+		}
+		size = compressed.Len()
+	}
+	return "", fmt.Errorf("user data is %d bytes%s, exceeding the %d byte limit of EC2, %d of the %d bytes of the rendered user data are from spec.userData",
+		size, lo.Ternary(compressible, " after compression", ""), maxUserDataSize, len(lo.FromPtr(o.CustomUserData)), len(decoded))
 }
 
 func (o Options) kubeletExtraArgs() (args []string) {
@@ -76,24 +135,261 @@ func (o Options) kubeletExtraArgs() (args []string) {
 }
 
 func (o Options) nodeTaintArg() string {
-	var taintStrings []string
-	for _, taint := range o.Taints {
-		taintStrings = append(taintStrings, taint.ToString())
-	}
-	return fmt.Sprintf("--register-with-taints=%q", strings.Join(taintStrings, ","))
+	return fmt.Sprintf("--register-with-taints=%q", o.nodeTaints())
 }
 
 func (o Options) nodeLabelArg() string {
 	if len(o.Labels) == 0 {
 		return ""
 	}
+	return fmt.Sprintf("--node-labels=%q", o.nodeLabels())
+}
+
+// nodeTaints returns the taints that the node registers with in the format of kubelet's --register-with-taints flag
+func (o Options) nodeTaints() string {
+	var taintStrings []string
+	for _, taint := range o.Taints {
+		taintStrings = append(taintStrings, taint.ToString())
+	}
+	return strings.Join(taintStrings, ",")
+}
+
+// nodeLabels returns the labels that the node registers with in the format of kubelet's --node-labels flag
+func (o Options) nodeLabels() string {
 	var labelStrings []string
 	keys := lo.Keys(o.Labels)
 	sort.Strings(keys) // ensures this list is deterministic, for easy testing.
 	for _, key := range keys {
 		labelStrings = append(labelStrings, fmt.Sprintf("%s=%v", key, o.Labels[key]))
 	}
-	return fmt.Sprintf("--node-labels=%q", strings.Join(labelStrings, ","))
+	return strings.Join(labelStrings, ",")
+}
+
+func (o Options) isDualStack() bool {
+	return o.KubeletConfig != nil && lo.FromPtr(o.KubeletConfig.NodeIPFamily) == v1.NodeIPFamilyDualStack
+}
+
+// dualStackNodeIPScript returns the shell commands that set NODE_IP to the private IPv4 address and the IPv6 address
+// of the instance, which are only known once it's running. The address of the primary IP family comes first.
+func dualStackNodeIPScript(ipv6Primary bool) string {
+	var script strings.Builder
+	script.WriteString("IMDS_TOKEN=$(curl -s -X PUT -H 'X-aws-ec2-metadata-token-ttl-seconds: 60' http://169.254.169.254/latest/api/token)\n")
+	script.WriteString("IPV4_ADDRESS=$(curl -s -H \"X-aws-ec2-metadata-token: ${IMDS_TOKEN}\" http://169.254.169.254/latest/meta-data/local-ipv4)\n")
+	script.WriteString("IPV6_ADDRESS=$(curl -s -H \"X-aws-ec2-metadata-token: ${IMDS_TOKEN}\" http://169.254.169.254/latest/meta-data/ipv6)\n")
+	script.WriteString(lo.Ternary(ipv6Primary, "NODE_IP=\"${IPV6_ADDRESS},${IPV4_ADDRESS}\"\n", "NODE_IP=\"${IPV4_ADDRESS},${IPV6_ADDRESS}\"\n"))
+	return script.String()
+}
+
+// imageFSVolumeScript returns the shell commands that format the image filesystem volume, unless it already has a
+// filesystem, and mount it as the root dir of containerd. Kubelet detects the separate filesystem and reports it as
+// its image filesystem. containerd is stopped in case the AMI started it already, the bootstrap starts it again.
+func (o Options) imageFSVolumeScript() string {
+	var script strings.Builder
+	script.WriteString(fmt.Sprintf("IMAGE_FS_DEVICE='%s'\n", lo.FromPtr(o.ImageFSDevice)))
+	script.WriteString("for _ in $(seq 60); do [ -b \"${IMAGE_FS_DEVICE}\" ] && break; sleep 1; done\n")
+	script.WriteString("blkid \"${IMAGE_FS_DEVICE}\" || mkfs.xfs \"${IMAGE_FS_DEVICE}\"\n")
+	script.WriteString("systemctl stop containerd || true\n")
+	script.WriteString("mkdir -p /var/lib/containerd\n")
+	script.WriteString("echo \"UUID=$(blkid -s UUID -o value \"${IMAGE_FS_DEVICE}\") /var/lib/containerd xfs defaults,nofail 0 2\" >> /etc/fstab\n")
+	script.WriteString("mount /var/lib/containerd\n")
+	return script.String()
+}
+
+// swapScript returns the shell commands that provision the swap space and set the swappiness of the node. Instance
+// store disks are found through their NVMe model name, and a node without them is left without swap.
+func (o Options) swapScript() string {
+	var script strings.Builder
+	switch lo.FromPtr(o.Swap.Location) {
+	case v1.SwapLocationInstanceStore:
+		script.WriteString("for SWAP_DEVICE in $(realpath /dev/disk/by-id/nvme-Amazon_EC2_NVMe_Instance_Storage_* 2>/dev/null | sort -u); do\n")
+		script.WriteString("  mkswap \"${SWAP_DEVICE}\"\n")
+		script.WriteString("  swapon \"${SWAP_DEVICE}\"\n")
+		script.WriteString("done\n")
+	default:
+		script.WriteString(fmt.Sprintf("fallocate -l %d /swapfile\n", o.Swap.Size.Value()))
+		script.WriteString("chmod 600 /swapfile\n")
+		script.WriteString("mkswap /swapfile\n")
+		script.WriteString("swapon /swapfile\n")
+		script.WriteString("echo '/swapfile none swap defaults,nofail 0 0' >> /etc/fstab\n")
+	}
+	if o.Swap.Swappiness != nil {
+		script.WriteString(fmt.Sprintf("echo 'vm.swappiness = %d' > /etc/sysctl.d/99-swappiness.conf\n", lo.FromPtr(o.Swap.Swappiness)))
+		script.WriteString("sysctl -p /etc/sysctl.d/99-swappiness.conf\n")
+	}
+	return script.String()
+}
+
+// swapBehavior returns the memorySwap.swapBehavior of kubelet, which defaults to LimitedSwap so that pods can use the
+// swap space that was provisioned for them
+func (o Options) swapBehavior() string {
+	return lo.Ternary(lo.FromPtr(o.Swap.SwapBehavior) != "", lo.FromPtr(o.Swap.SwapBehavior), "LimitedSwap")
+}
+
+// hasKernelConfiguration returns whether the node has hugepages or a NUMA balancing that the kernel is configured with
+func (o Options) hasKernelConfiguration() bool {
+	return len(o.Hugepages) > 0 || (o.KernelParameters != nil && o.KernelParameters.NUMABalancing != nil)
+}
+
+// kernelScript returns the shell commands that allocate the hugepages and set the NUMA balancing of the node. Hugepages
+// are allocated through sysfs before kubelet starts, so that kubelet discovers them as allocatable resources.
+func (o Options) kernelScript() string {
+	var script strings.Builder
+	for _, h := range o.Hugepages {
+		size := resource.MustParse(h.Size)
+		script.WriteString(fmt.Sprintf("echo %d > /sys/kernel/mm/hugepages/hugepages-%dkB/nr_hugepages\n", h.Count, size.Value()/1024))
+	}
+	if o.KernelParameters != nil && o.KernelParameters.NUMABalancing != nil {
+		script.WriteString(fmt.Sprintf("echo 'kernel.numa_balancing = %d' > /etc/sysctl.d/99-numa-balancing.conf\n", lo.Ternary(*o.KernelParameters.NUMABalancing, 1, 0)))
+		script.WriteString("sysctl -p /etc/sysctl.d/99-numa-balancing.conf\n")
+	}
+	return script.String()
+}
+
+// sysctlScript returns the shell commands that load the kernel modules and set the sysctls of the node. Both are
+// persisted so that they're applied again when the node reboots, and the modules are loaded first, since they
+// register some of the sysctls.
+func (o Options) sysctlScript() string {
+	var script strings.Builder
+	if len(o.KernelModules) > 0 {
+		modules := lo.Map(o.KernelModules, func(m v1.KernelModule, _ int) string { return string(m) })
+		script.WriteString(fmt.Sprintf("printf '%%s\\n' %s > /etc/modules-load.d/99-karpenter.conf\n", strings.Join(modules, " ")))
+		script.WriteString(fmt.Sprintf("modprobe -a %s\n", strings.Join(modules, " ")))
+	}
+	if len(o.Sysctls) > 0 {
+		keys := lo.Keys(o.Sysctls)
+		sort.Strings(keys)
+		script.WriteString("cat << 'EOF' > /etc/sysctl.d/99-karpenter.conf\n")
+		for _, k := range keys {
+			script.WriteString(fmt.Sprintf("%s = %s\n", k, o.Sysctls[k]))
+		}
+		script.WriteString("EOF\n")
+		script.WriteString("sysctl -p /etc/sysctl.d/99-karpenter.conf\n")
+	}
+	return script.String()
+}
+
+// ptpHardwareClockScript returns the shell commands that make chrony prefer the PTP hardware clock of the ENA device.
+// The ENA driver only exposes the clock on instance types that support it, so nodes of other instance types keep the
+// time sources of the AMI.
+func (o Options) ptpHardwareClockScript() string {
+	return `if [ -e /dev/ptp_ena ]; then
+  CHRONY_CONF=/etc/chrony.conf
+  [ -f /etc/chrony/chrony.conf ] && CHRONY_CONF=/etc/chrony/chrony.conf
+  echo 'refclock PHC /dev/ptp_ena poll 0 delay 0.000010 prefer' >> "${CHRONY_CONF}"
+  systemctl restart chronyd
+fi
+`
+}
+
+// dcgmExporterScript returns the shell commands that run the DCGM exporter as a systemd service on nodes that have the
+// NVIDIA container runtime, which gives the exporter access to the GPUs. The image is pulled into a containerd namespace
+// of its own, so kubelet doesn't garbage collect it.
+func (o Options) dcgmExporterScript() string {
+	image := lo.FromPtrOr(o.DCGMExporter.Image, dcgmExporterImage)
+	return fmt.Sprintf(`if [ -x /usr/bin/nvidia-container-runtime ]; then
+cat > /etc/systemd/system/dcgm-exporter.service <<'EOF'
+[Unit]
+Description=NVIDIA DCGM exporter
+After=containerd.service
+Requires=containerd.service
+
+[Service]
+ExecStartPre=/usr/bin/ctr --namespace dcgm images pull %[1]s
+ExecStartPre=-/usr/bin/ctr --namespace dcgm tasks kill --signal SIGKILL dcgm-exporter
+ExecStartPre=-/usr/bin/ctr --namespace dcgm containers delete dcgm-exporter
+ExecStart=/usr/bin/ctr --namespace dcgm run --rm --net-host --privileged --runc-binary /usr/bin/nvidia-container-runtime --env NVIDIA_VISIBLE_DEVICES=all %[1]s dcgm-exporter
+Restart=always
+RestartSec=10
+
+[Install]
+WantedBy=multi-user.target
+EOF
+systemctl daemon-reload
+systemctl enable --now --no-block dcgm-exporter.service
+fi
+`, image)
+}
+
+// neuronScript returns the shell commands that set the environment of the Neuron runtime and tools on the host
+func (o Options) neuronScript() string {
+	return fmt.Sprintf("echo 'NEURON_LOGICAL_NC_CONFIG=%d' >> /etc/environment\n", lo.FromPtr(o.Neuron.LogicalNeuronCoreConfig))
+}
+
+// hasSSMAgentConfiguration returns whether the SSM agent of the AMI is disabled or registered with a hybrid activation
+func (o Options) hasSSMAgentConfiguration() bool {
+	return o.SSMAgent != nil && (!lo.FromPtrOr(o.SSMAgent.Enabled, true) || o.SSMAgent.HybridActivation != nil)
+}
+
+// hasSessionManager returns whether the nodes are prepared for Session Manager sessions
+func (o Options) hasSessionManager() bool {
+	return o.SSMAgent != nil && lo.FromPtr(o.SSMAgent.SessionManager)
+}
+
+// ssmAgentScript returns the shell commands that disable the SSM agent of the AMI, or register it with a hybrid
+// activation. AL2 and AL2023 install the agent as a systemd service, and Ubuntu installs it as a snap.
+func (o Options) ssmAgentScript() string {
+	if !lo.FromPtrOr(o.SSMAgent.Enabled, true) {
+		return "systemctl disable --now amazon-ssm-agent.service snap.amazon-ssm-agent.amazon-ssm-agent.service || true\n"
+	}
+	activation := o.SSMAgent.HybridActivation
+	return fmt.Sprintf(`SSM_AGENT_SERVICE=amazon-ssm-agent.service
+SSM_AGENT=/usr/bin/amazon-ssm-agent
+if [ -d /snap/amazon-ssm-agent ]; then
+  SSM_AGENT_SERVICE=snap.amazon-ssm-agent.amazon-ssm-agent.service
+  SSM_AGENT=/snap/amazon-ssm-agent/current/amazon-ssm-agent
+fi
+systemctl stop "${SSM_AGENT_SERVICE}"
+"${SSM_AGENT}" -register -id '%s' -code '%s' -region '%s' -y
+systemctl start "${SSM_AGENT_SERVICE}"
+`, activation.ID, activation.Code, activation.Region)
+}
+
+// containerRegistryScript returns the shell commands that write a hosts.toml file for each container registry into
+// the directory that containerd reads the hosts of registries from when it pulls images
+func (o Options) containerRegistryScript() string {
+	var script strings.Builder
+	for _, registry := range o.ContainerRegistries {
+		dir := fmt.Sprintf("/etc/containerd/certs.d/%s", registry.Host)
+		script.WriteString(fmt.Sprintf("mkdir -p '%s'\n", dir))
+		script.WriteString(fmt.Sprintf("cat > '%s/hosts.toml' <<'EOF'\n%sEOF\n", dir, o.hostsTOML(registry)))
+	}
+	return script.String()
+}
+
+// hostsTOML returns the containerd hosts configuration of a registry. Mirrors are tried in order before the registry
+// itself, and mirror URLs with a path, like the ones of ECR pull through caches, are used as the full API path.
+func (o Options) hostsTOML(registry v1.ContainerRegistry) string {
+	var hosts strings.Builder
+	hosts.WriteString(fmt.Sprintf("server = %q\n", lo.Ternary(registry.Host == "docker.io", "https://registry-1.docker.io", "https://"+registry.Host)))
+	if registry.Credentials != nil {
+		hosts.WriteString(fmt.Sprintf("\n[header]\n  Authorization = [%q]\n", basicAuthorization(registry.Credentials)))
+	}
+	for _, mirror := range registry.Mirrors {
+		hosts.WriteString(fmt.Sprintf("\n[host.%q]\n  capabilities = [\"pull\", \"resolve\"]\n", mirror))
+		u, err := url.Parse(mirror)
+		if err != nil {
+			continue
+		}
+		if strings.Trim(u.Path, "/") != "" {
+			hosts.WriteString("  override_path = true\n")
+		}
+		if credentials := o.registryCredentials(u.Host); credentials != nil {
+			hosts.WriteString(fmt.Sprintf("  [host.%q.header]\n    Authorization = [%q]\n", mirror, basicAuthorization(credentials)))
+		}
+	}
+	return hosts.String()
+}
+
+func (o Options) registryCredentials(host string) *v1.ContainerRegistryCredentials {
+	registry, ok := lo.Find(o.ContainerRegistries, func(r v1.ContainerRegistry) bool { return r.Host == host })
+	if !ok {
+		return nil
+	}
+	return registry.Credentials
+}
+
+func basicAuthorization(credentials *v1.ContainerRegistryCredentials) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials.Username+":"+credentials.Password))
 }
 
 // joinParameterArgs joins a map of keys and values by their separator. The separator will sit between the
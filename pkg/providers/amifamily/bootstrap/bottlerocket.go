@@ -16,13 +16,17 @@ package bootstrap
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/imdario/mergo"
 	"github.com/samber/lo"
 
 	"github.com/aws/aws-sdk-go/aws"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 )
 
 type Bottlerocket struct {
@@ -75,6 +79,38 @@ func (b Bottlerocket) Script() (string, error) {
 		}
 	}
 
+	if len(b.ContainerRegistries) > 0 {
+		b.mergeContainerRegistries(s)
+	}
+	if b.ContainerSnapshotter != nil {
+		if s.Settings.ContainerRuntime == nil {
+			s.Settings.ContainerRuntime = &BottlerocketContainerRuntime{}
+		}
+		s.Settings.ContainerRuntime.Snapshotter = lo.ToPtr(strings.ToLower(string(*b.ContainerSnapshotter)))
+	}
+
+	if len(b.Hugepages) > 0 || b.KernelParameters != nil {
+		b.mergeKernelParameters(s)
+	}
+	if len(b.Sysctls) > 0 || len(b.KernelModules) > 0 {
+		b.mergeSysctls(s)
+	}
+	if b.DCGMExporter != nil {
+		if s.Settings.HostContainers == nil {
+			s.Settings.HostContainers = map[string]BottlerocketHostContainer{}
+		}
+		s.Settings.HostContainers["dcgm-exporter"] = BottlerocketHostContainer{
+			Source:       lo.ToPtr(lo.FromPtrOr(b.DCGMExporter.Image, dcgmExporterImage)),
+			Enabled:      lo.ToPtr(true),
+			Superpowered: lo.ToPtr(true),
+		}
+	}
+	if b.hasSSMAgentConfiguration() || b.hasSessionManager() {
+		if err := b.mergeSSMAgent(s); err != nil {
+			return "", err
+		}
+	}
+
 	s.Settings.Kubernetes.NodeTaints = map[string][]string{}
 	for _, taint := range b.Taints {
 		s.Settings.Kubernetes.NodeTaints[taint.Key] = append(s.Settings.Kubernetes.NodeTaints[taint.Key], fmt.Sprintf("%s:%s", taint.Value, taint.Effect))
@@ -83,5 +119,132 @@ func (b Bottlerocket) Script() (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("constructing toml UserData %w", err)
 	}
-	return base64.StdEncoding.EncodeToString(script), nil
+	return b.limitUserDataSize(base64.StdEncoding.EncodeToString(script), false)
+}
+
+// mergeSSMAgent configures the control container, which runs the SSM agent on Bottlerocket. The hybrid activation is
+// passed to the control container through its user data. Session Manager sessions start in the control container, so
+// the admin container is enabled for them to reach the host.
+func (b Bottlerocket) mergeSSMAgent(s *BottlerocketConfig) error {
+	if s.Settings.HostContainers == nil {
+		s.Settings.HostContainers = map[string]BottlerocketHostContainer{}
+	}
+	control := s.Settings.HostContainers["control"]
+	control.Enabled = lo.ToPtr(lo.FromPtrOr(b.SSMAgent.Enabled, true))
+	if activation := b.SSMAgent.HybridActivation; activation != nil {
+		userData, err := json.Marshal(map[string]map[string]string{
+			"ssm": {
+				"activation-id":   activation.ID,
+				"activation-code": activation.Code,
+				"region":          activation.Region,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("marshaling control container user data, %w", err)
+		}
+		control.UserData = lo.ToPtr(base64.StdEncoding.EncodeToString(userData))
+	}
+	s.Settings.HostContainers["control"] = control
+	if b.hasSessionManager() {
+		admin := s.Settings.HostContainers["admin"]
+		admin.Enabled = lo.ToPtr(true)
+		s.Settings.HostContainers["admin"] = admin
+	}
+	return nil
+}
+
+// mergeKernelParameters allocates the hugepages and sets the NUMA balancing and CPU isolation of the node. Settings that
+// need the kernel command line are applied by Bottlerocket rebooting before the node joins the cluster. The kernel
+// parameters are sorted by name, so hugepages can't follow a hugepagesz, and only the default size can be allocated.
+// 1Gi hugepages become the default size through default_hugepagesz, and take precedence over 2Mi ones, which are
+// allocated through sysctl.
+func (b Bottlerocket) mergeKernelParameters(s *BottlerocketConfig) {
+	sysctl := map[string]string{}
+	kernelParameters := map[string][]string{}
+	if h, ok := lo.Find(b.Hugepages, func(h v1.Hugepages) bool { return h.Size == "1Gi" }); ok {
+		kernelParameters["default_hugepagesz"] = []string{"1G"}
+		kernelParameters["hugepages"] = []string{fmt.Sprint(h.Count)}
+	} else if h, ok := lo.Find(b.Hugepages, func(h v1.Hugepages) bool { return h.Size == "2Mi" }); ok {
+		sysctl["vm.nr_hugepages"] = fmt.Sprint(h.Count)
+	}
+	if b.KernelParameters != nil && b.KernelParameters.NUMABalancing != nil {
+		sysctl["kernel.numa_balancing"] = lo.Ternary(*b.KernelParameters.NUMABalancing, "1", "0")
+	}
+	if b.KernelParameters != nil && b.KernelParameters.IsolatedCPUs != nil {
+		for _, parameter := range []string{"isolcpus", "nohz_full", "rcu_nocbs"} {
+			kernelParameters[parameter] = []string{*b.KernelParameters.IsolatedCPUs}
+		}
+	}
+	if len(sysctl) > 0 {
+		if s.Settings.Kernel == nil {
+			s.Settings.Kernel = &BottlerocketKernel{}
+		}
+		if s.Settings.Kernel.Sysctl == nil {
+			s.Settings.Kernel.Sysctl = map[string]string{}
+		}
+		for k, v := range sysctl {
+			s.Settings.Kernel.Sysctl[k] = v
+		}
+	}
+	if len(kernelParameters) > 0 {
+		if s.Settings.Boot == nil {
+			s.Settings.Boot = &BottlerocketBoot{}
+		}
+		if s.Settings.Boot.KernelParameters == nil {
+			s.Settings.Boot.KernelParameters = map[string][]string{}
+		}
+		for k, v := range kernelParameters {
+			s.Settings.Boot.KernelParameters[k] = v
+		}
+		s.Settings.Boot.RebootToReconcile = lo.ToPtr(true)
+	}
+}
+
+// mergeSysctls adds the sysctls and kernel modules to the kernel settings, replacing the ones with the same names from
+// the custom UserData
+func (b Bottlerocket) mergeSysctls(s *BottlerocketConfig) {
+	if s.Settings.Kernel == nil {
+		s.Settings.Kernel = &BottlerocketKernel{}
+	}
+	if len(b.Sysctls) > 0 {
+		s.Settings.Kernel.Sysctl = lo.Assign(s.Settings.Kernel.Sysctl, b.Sysctls)
+	}
+	if len(b.KernelModules) > 0 {
+		if s.Settings.Kernel.Modules == nil {
+			s.Settings.Kernel.Modules = map[string]BottlerocketKernelModule{}
+		}
+		for _, m := range b.KernelModules {
+			s.Settings.Kernel.Modules[string(m)] = BottlerocketKernelModule{Allowed: lo.ToPtr(true), Autoload: lo.ToPtr(true)}
+		}
+	}
+}
+
+// mergeContainerRegistries adds the mirrors and credentials of the container registries to the settings, replacing
+// the ones for the same registries from the custom UserData
+func (b Bottlerocket) mergeContainerRegistries(s *BottlerocketConfig) {
+	if s.Settings.ContainerRegistry == nil {
+		s.Settings.ContainerRegistry = &BottlerocketContainerRegistry{}
+	}
+	hosts := lo.Map(b.ContainerRegistries, func(r v1.ContainerRegistry, _ int) string { return r.Host })
+	s.Settings.ContainerRegistry.Mirrors = lo.Reject(s.Settings.ContainerRegistry.Mirrors, func(m BottlerocketRegistryMirror, _ int) bool {
+		return lo.Contains(hosts, lo.FromPtr(m.Registry))
+	})
+	s.Settings.ContainerRegistry.Credentials = lo.Reject(s.Settings.ContainerRegistry.Credentials, func(c BottlerocketRegistryCredential, _ int) bool {
+		return lo.Contains(hosts, lo.FromPtr(c.Registry))
+	})
+	for _, registry := range b.ContainerRegistries {
+		if len(registry.Mirrors) > 0 {
+			s.Settings.ContainerRegistry.Mirrors = append(s.Settings.ContainerRegistry.Mirrors, BottlerocketRegistryMirror{
+				Registry: lo.ToPtr(registry.Host),
+				Endpoint: registry.Mirrors,
+			})
+		}
+		if registry.Credentials != nil {
+			s.Settings.ContainerRegistry.Credentials = append(s.Settings.ContainerRegistry.Credentials, BottlerocketRegistryCredential{
+				Registry: lo.ToPtr(registry.Host),
+				Username: lo.ToPtr(registry.Credentials.Username),
+				Password: lo.ToPtr(registry.Credentials.Password),
+			})
+		}
+	}
 }
@@ -38,7 +38,12 @@ type BottlerocketConfig struct {
 // BottlerocketSettings is a subset of all configuration in https://github.com/bottlerocket-os/bottlerocket/blob/d427c40931cba6e6bedc5b75e9c084a6e1818db9/sources/models/src/lib.rs#L260
 // These settings apply across all K8s versions that karpenter supports.
 type BottlerocketSettings struct {
-	Kubernetes BottlerocketKubernetes `toml:"kubernetes"`
+	Kubernetes        BottlerocketKubernetes               `toml:"kubernetes"`
+	ContainerRegistry *BottlerocketContainerRegistry       `toml:"container-registry,omitempty"`
+	ContainerRuntime  *BottlerocketContainerRuntime        `toml:"container-runtime,omitempty"`
+	Kernel            *BottlerocketKernel                  `toml:"kernel,omitempty"`
+	Boot              *BottlerocketBoot                    `toml:"boot,omitempty"`
+	HostContainers    map[string]BottlerocketHostContainer `toml:"host-containers,omitempty"`
 }
 
 // BottlerocketKubernetes is k8s specific configuration for bottlerocket api
@@ -94,6 +99,63 @@ type BottlerocketCredentialProvider struct {
 	Environment   map[string]string `toml:"environment,omitempty"`
 }
 
+// BottlerocketContainerRegistry is the configuration of the mirrors and credentials of container registries
+type BottlerocketContainerRegistry struct {
+	Mirrors     []BottlerocketRegistryMirror     `toml:"mirrors,omitempty"`
+	Credentials []BottlerocketRegistryCredential `toml:"credentials,omitempty"`
+}
+
+type BottlerocketRegistryMirror struct {
+	Registry *string  `toml:"registry,omitempty"`
+	Endpoint []string `toml:"endpoint,omitempty"`
+}
+
+type BottlerocketRegistryCredential struct {
+	Registry      *string `toml:"registry,omitempty"`
+	Username      *string `toml:"username,omitempty"`
+	Password      *string `toml:"password,omitempty"`
+	Auth          *string `toml:"auth,omitempty"`
+	IdentityToken *string `toml:"identitytoken,omitempty"`
+}
+
+// BottlerocketContainerRuntime is the configuration of containerd
+type BottlerocketContainerRuntime struct {
+	EnableUnprivilegedICMP  *bool   `toml:"enable-unprivileged-icmp,omitempty"`
+	EnableUnprivilegedPorts *bool   `toml:"enable-unprivileged-ports,omitempty"`
+	MaxConcurrentDownloads  *int    `toml:"max-concurrent-downloads,omitempty"`
+	MaxContainerLogLineSize *int    `toml:"max-container-log-line-size,omitempty"`
+	Snapshotter             *string `toml:"snapshotter,omitempty"`
+}
+
+// BottlerocketKernel is the configuration of the kernel at runtime
+type BottlerocketKernel struct {
+	Lockdown *string                             `toml:"lockdown,omitempty"`
+	Modules  map[string]BottlerocketKernelModule `toml:"modules,omitempty"`
+	Sysctl   map[string]string                   `toml:"sysctl,omitempty"`
+}
+
+type BottlerocketKernelModule struct {
+	Allowed  *bool `toml:"allowed,omitempty"`
+	Autoload *bool `toml:"autoload,omitempty"`
+}
+
+// BottlerocketBoot is the configuration of the kernel command line, which is applied by rebooting the node when
+// RebootToReconcile is set
+type BottlerocketBoot struct {
+	RebootToReconcile *bool               `toml:"reboot-to-reconcile,omitempty"`
+	KernelParameters  map[string][]string `toml:"kernel-parameters,omitempty"`
+	InitParameters    map[string][]string `toml:"init-parameters,omitempty"`
+}
+
+// BottlerocketHostContainer is a container that runs outside of Kubernetes, in the host-containerd of Bottlerocket.
+// Superpowered host containers have full access to the host.
+type BottlerocketHostContainer struct {
+	Source       *string `toml:"source,omitempty"`
+	Enabled      *bool   `toml:"enabled,omitempty"`
+	Superpowered *bool   `toml:"superpowered,omitempty"`
+	UserData     *string `toml:"user-data,omitempty"`
+}
+
 func (c *BottlerocketConfig) UnmarshalTOML(data []byte) error {
 	// unmarshal known settings
 	s := struct {
@@ -115,5 +177,20 @@ func (c *BottlerocketConfig) MarshalTOML() ([]byte, error) {
 		c.SettingsRaw = map[string]interface{}{}
 	}
 	c.SettingsRaw["kubernetes"] = c.Settings.Kubernetes
+	if c.Settings.ContainerRegistry != nil {
+		c.SettingsRaw["container-registry"] = c.Settings.ContainerRegistry
+	}
+	if c.Settings.ContainerRuntime != nil {
+		c.SettingsRaw["container-runtime"] = c.Settings.ContainerRuntime
+	}
+	if c.Settings.Kernel != nil {
+		c.SettingsRaw["kernel"] = c.Settings.Kernel
+	}
+	if c.Settings.Boot != nil {
+		c.SettingsRaw["boot"] = c.Settings.Boot
+	}
+	if c.Settings.HostContainers != nil {
+		c.SettingsRaw["host-containers"] = c.Settings.HostContainers
+	}
 	return toml.Marshal(c)
 }
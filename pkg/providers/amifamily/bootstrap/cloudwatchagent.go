@@ -0,0 +1,108 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/samber/lo"
+)
+
+const cloudWatchAgentConfigPath = "/opt/aws/amazon-cloudwatch-agent/etc/amazon-cloudwatch-agent.json"
+
+// cloudWatchAgentLogFiles are the system logs that the CloudWatch agent ships. The agent skips the files that don't
+// exist on the AMI, like /var/log/messages on Ubuntu or /var/log/syslog on AL2.
+var cloudWatchAgentLogFiles = map[string]string{
+	"messages":          "/var/log/messages",
+	"syslog":            "/var/log/syslog",
+	"cloud-init":        "/var/log/cloud-init.log",
+	"cloud-init-output": "/var/log/cloud-init-output.log",
+	"user-data":         "/var/log/user-data.log",
+	"ipamd":             "/var/log/aws-routed-eni/ipamd.log",
+	"cni-plugin":        "/var/log/aws-routed-eni/plugin.log",
+}
+
+// cloudWatchAgentScript returns the shell commands that install the CloudWatch agent from the package repositories of
+// AL2 and AL2023, or from the package that Amazon publishes for Ubuntu, and start it with the configuration of the
+// EC2NodeClass
+func (o Options) cloudWatchAgentScript() string {
+	// The configuration only has strings, booleans and slices of them, so marshaling it can't fail
+	config := lo.Must(json.MarshalIndent(o.cloudWatchAgentConfig(), "", "  "))
+	return fmt.Sprintf(`if command -v dnf >/dev/null 2>&1; then
+  dnf install -y amazon-cloudwatch-agent
+elif command -v yum >/dev/null 2>&1; then
+  yum install -y amazon-cloudwatch-agent
+else
+  curl -sSfL -o /tmp/amazon-cloudwatch-agent.deb "https://amazoncloudwatch-agent.s3.amazonaws.com/ubuntu/$(dpkg --print-architecture)/latest/amazon-cloudwatch-agent.deb"
+  dpkg -i -E /tmp/amazon-cloudwatch-agent.deb
+  rm -f /tmp/amazon-cloudwatch-agent.deb
+fi
+cat > %[1]s <<'EOF'
+%[2]s
+EOF
+/opt/aws/amazon-cloudwatch-agent/bin/amazon-cloudwatch-agent-ctl -a fetch-config -m ec2 -s -c file:%[1]s
+`, cloudWatchAgentConfigPath, config)
+}
+
+// cloudWatchAgentConfig returns the configuration of the CloudWatch agent, with a log stream per instance and log file
+// in the log group of the EC2NodeClass, and the CPU, memory and disk metrics of the node in the CWAgent namespace
+func (o Options) cloudWatchAgentConfig() map[string]any {
+	logGroupName := lo.FromPtrOr(o.CloudWatchAgent.LogGroupName, fmt.Sprintf("/aws/karpenter/%s/nodes", o.ClusterName))
+	// The log files are sorted, so that the user data of equivalent launch templates is the same
+	names := lo.Keys(cloudWatchAgentLogFiles)
+	sort.Strings(names)
+	config := map[string]any{
+		"agent": map[string]any{
+			"run_as_user": "root",
+		},
+		"logs": map[string]any{
+			"logs_collected": map[string]any{
+				"files": map[string]any{
+					"collect_list": lo.Map(names, func(name string, _ int) map[string]any {
+						return map[string]any{
+							"file_path":       cloudWatchAgentLogFiles[name],
+							"log_group_name":  logGroupName,
+							"log_stream_name": fmt.Sprintf("{instance_id}/%s", name),
+						}
+					}),
+				},
+			},
+		},
+	}
+	if lo.FromPtrOr(o.CloudWatchAgent.Metrics, true) {
+		config["metrics"] = map[string]any{
+			"append_dimensions": map[string]any{
+				"InstanceId":   "${aws:InstanceId}",
+				"InstanceType": "${aws:InstanceType}",
+			},
+			"metrics_collected": map[string]any{
+				"cpu": map[string]any{
+					"measurement": []string{"usage_active", "usage_iowait"},
+					"totalcpu":    true,
+				},
+				"mem": map[string]any{
+					"measurement": []string{"mem_used_percent"},
+				},
+				"disk": map[string]any{
+					"measurement": []string{"used_percent"},
+					"resources":   []string{"/"},
+				},
+			},
+		}
+	}
+	return config
+}
@@ -16,14 +16,28 @@ package bootstrap
 
 import (
 	"encoding/base64"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
 )
 
+const (
+	// CustomNodeLabelsVariable is replaced in the userData of the Custom AMIFamily by the labels that the node
+	// registers with, in the format of kubelet's --node-labels flag
+	CustomNodeLabelsVariable = "${KARPENTER_NODE_LABELS}"
+	// CustomNodeTaintsVariable is replaced in the userData of the Custom AMIFamily by the taints that the node
+	// registers with, in the format of kubelet's --register-with-taints flag
+	CustomNodeTaintsVariable = "${KARPENTER_NODE_TAINTS}"
+)
+
 type Custom struct {
 	Options
 }
 
 func (e Custom) Script() (string, error) {
-	return base64.StdEncoding.EncodeToString([]byte(aws.StringValue(e.Options.CustomUserData))), nil
+	userData := strings.NewReplacer(
+		CustomNodeLabelsVariable, e.nodeLabels(),
+		CustomNodeTaintsVariable, e.nodeTaints(),
+	).Replace(aws.StringValue(e.Options.CustomUserData))
+	return e.limitUserDataSize(base64.StdEncoding.EncodeToString([]byte(userData)), false)
 }
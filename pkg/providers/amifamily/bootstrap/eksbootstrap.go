@@ -41,6 +41,8 @@ const (
 	Boundary                      = "//"
 	MIMEVersionHeader             = "MIME-Version: 1.0"
 	MIMEContentTypeHeaderTemplate = "Content-Type: multipart/mixed; boundary=\"%s\""
+
+	eksKubeletConfigPath = "/etc/kubernetes/kubelet/kubelet-config.json"
 )
 
 func (e EKS) Script() (string, error) {
@@ -50,7 +52,7 @@ func (e EKS) Script() (string, error) {
 	}
 	// The mime/multipart package adds carriage returns, while the rest of our logic does not. Remove all
 	// carriage returns for consistency.
-	return base64.StdEncoding.EncodeToString([]byte(strings.ReplaceAll(userData, "\r", ""))), nil
+	return e.limitUserDataSize(base64.StdEncoding.EncodeToString([]byte(strings.ReplaceAll(userData, "\r", ""))), true)
 }
 
 //nolint:gocyclo
@@ -62,6 +64,41 @@ func (e EKS) eksBootstrapScript() string {
 	var userData bytes.Buffer
 	userData.WriteString("#!/bin/bash -xe\n")
 	userData.WriteString("exec > >(tee /var/log/user-data.log|logger -t user-data -s 2>/dev/console) 2>&1\n")
+	if e.ImageFSDevice != nil {
+		userData.WriteString(e.imageFSVolumeScript())
+	}
+	if len(e.ContainerRegistries) > 0 {
+		userData.WriteString(e.containerRegistryScript())
+	}
+	if e.Swap != nil {
+		userData.WriteString(e.swapScript())
+		// bootstrap.sh updates the kubelet config file in place, so the swap settings are kept
+		userData.WriteString(fmt.Sprintf("echo \"$(jq '.failSwapOn = false | .memorySwap.swapBehavior = \"%s\"' %s)\" > %s\n", e.swapBehavior(), eksKubeletConfigPath, eksKubeletConfigPath))
+	}
+	if e.hasKernelConfiguration() {
+		userData.WriteString(e.kernelScript())
+	}
+	if len(e.Sysctls) > 0 || len(e.KernelModules) > 0 {
+		userData.WriteString(e.sysctlScript())
+	}
+	if lo.FromPtr(e.PTPHardwareClock) {
+		userData.WriteString(e.ptpHardwareClockScript())
+	}
+	if e.DCGMExporter != nil {
+		userData.WriteString(e.dcgmExporterScript())
+	}
+	if e.Neuron != nil && e.Neuron.LogicalNeuronCoreConfig != nil {
+		userData.WriteString(e.neuronScript())
+	}
+	if e.hasSSMAgentConfiguration() {
+		userData.WriteString(e.ssmAgentScript())
+	}
+	if e.CloudWatchAgent != nil && lo.FromPtr(e.CloudWatchAgent.Enabled) {
+		userData.WriteString(e.cloudWatchAgentScript())
+	}
+	if e.isDualStack() {
+		userData.WriteString(dualStackNodeIPScript(e.isIPv6()))
+	}
 	// Due to the way bootstrap.sh is written, parameters should not be passed to it with an equal sign
 	userData.WriteString(fmt.Sprintf("/etc/eks/bootstrap.sh '%s' --apiserver-endpoint '%s' %s", e.ClusterName, e.ClusterEndpoint, caBundleArg))
 
@@ -76,6 +113,10 @@ func (e EKS) eksBootstrapScript() string {
 	}
 	if args := e.kubeletExtraArgs(); len(args) > 0 {
 		userData.WriteString(fmt.Sprintf(" \\\n--kubelet-extra-args '%s'", strings.Join(args, " ")))
+		// The node IPs are concatenated outside of the single quotes so that the shell expands them
+		if e.isDualStack() {
+			userData.WriteString(`" --node-ip=${NODE_IP}"`)
+		}
 	}
 	if lo.FromPtr(e.InstanceStorePolicy) == v1.InstanceStorePolicyRAID0 {
 		userData.WriteString(" \\\n--local-disks raid0")
@@ -87,6 +128,9 @@ func (e EKS) eksBootstrapScript() string {
 // If this argument is explicitly disabled, then set the max-pods value on the kubelet to the static value of 110
 func (e EKS) kubeletExtraArgs() []string {
 	args := e.Options.kubeletExtraArgs()
+	if e.KubeletConfig != nil && e.KubeletConfig.ResolvConf != nil {
+		args = append(args, fmt.Sprintf("--resolv-conf=%s", lo.FromPtr(e.KubeletConfig.ResolvConf)))
+	}
 	// Set the static value for --max-pods to 110 when AWSENILimitedPodDensity is explicitly disabled and the value isn't set
 	if !e.AWSENILimitedPodDensity && (e.KubeletConfig == nil || e.KubeletConfig.MaxPods == nil) {
 		args = append(args, "--max-pods=110")
@@ -116,7 +160,14 @@ func (e EKS) mergeCustomUserData(userDatas ...string) (string, error) {
 }
 
 func (e EKS) isIPv6() bool {
-	if e.KubeletConfig == nil || len(e.KubeletConfig.ClusterDNS) == 0 {
+	if e.KubeletConfig == nil {
+		return false
+	}
+	// Dual-stack nodes use the IP family of the cluster as their primary IP family
+	if e.KubeletConfig.NodeIPFamily != nil && !e.isDualStack() {
+		return lo.FromPtr(e.KubeletConfig.NodeIPFamily) == v1.NodeIPFamilyIPv6
+	}
+	if len(e.KubeletConfig.ClusterDNS) == 0 {
 		return false
 	}
 	return net.ParseIP(e.KubeletConfig.ClusterDNS[0]).To4() == nil
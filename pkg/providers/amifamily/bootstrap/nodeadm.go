@@ -32,6 +32,22 @@ import (
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily/bootstrap/mime"
 )
 
+const (
+	nodeadmKubeletEnvironmentFilePath = "/etc/eks/kubelet/environment"
+	nodeadmKubeletArgsEnvironmentName = "NODEADM_KUBELET_ARGS"
+)
+
+// sociContainerdConfig registers the SOCI snapshotter as a proxy plugin of containerd and makes the CRI plugin unpack
+// images with it. Snapshot annotations have to stay enabled, since SOCI finds the index of an image through them.
+const sociContainerdConfig = `[proxy_plugins.soci]
+type = "snapshot"
+address = "/run/soci-snapshotter-grpc/soci-snapshotter-grpc.sock"
+
+[plugins."io.containerd.grpc.v1.cri".containerd]
+snapshotter = "soci"
+disable_snapshot_annotations = false
+`
+
 type Nodeadm struct {
 	Options
 }
@@ -45,15 +61,90 @@ func (n Nodeadm) Script() (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("parsing custom UserData, %w", err)
 	}
-	mimeArchive := mime.Archive(append([]mime.Entry{{
+	entries := []mime.Entry{{
 		ContentType: mime.ContentTypeNodeConfig,
 		Content:     nodeConfigYAML,
-	}}, customEntries...))
+	}}
+	// containerd is started by nodeadm after the shell scripts ran, so the image filesystem is mounted before it's used
+	if n.ImageFSDevice != nil {
+		entries = append(entries, mime.Entry{
+			ContentType: mime.ContentTypeShellScript,
+			Content:     "#!/bin/bash\nset -e\n" + n.imageFSVolumeScript(),
+		})
+	}
+	if len(n.ContainerRegistries) > 0 {
+		entries = append(entries, mime.Entry{
+			ContentType: mime.ContentTypeShellScript,
+			Content:     "#!/bin/bash\nset -e\n" + n.containerRegistryScript(),
+		})
+	}
+	if n.Swap != nil {
+		entries = append(entries, mime.Entry{
+			ContentType: mime.ContentTypeShellScript,
+			Content:     "#!/bin/bash\nset -e\n" + n.swapScript(),
+		})
+	}
+	if n.hasKernelConfiguration() {
+		entries = append(entries, mime.Entry{
+			ContentType: mime.ContentTypeShellScript,
+			Content:     "#!/bin/bash\nset -e\n" + n.kernelScript(),
+		})
+	}
+	if len(n.Sysctls) > 0 || len(n.KernelModules) > 0 {
+		entries = append(entries, mime.Entry{
+			ContentType: mime.ContentTypeShellScript,
+			Content:     "#!/bin/bash\nset -e\n" + n.sysctlScript(),
+		})
+	}
+	if lo.FromPtr(n.PTPHardwareClock) {
+		entries = append(entries, mime.Entry{
+			ContentType: mime.ContentTypeShellScript,
+			Content:     "#!/bin/bash\nset -e\n" + n.ptpHardwareClockScript(),
+		})
+	}
+	if n.DCGMExporter != nil {
+		entries = append(entries, mime.Entry{
+			ContentType: mime.ContentTypeShellScript,
+			Content:     "#!/bin/bash\nset -e\n" + n.dcgmExporterScript(),
+		})
+	}
+	if n.Neuron != nil && n.Neuron.LogicalNeuronCoreConfig != nil {
+		entries = append(entries, mime.Entry{
+			ContentType: mime.ContentTypeShellScript,
+			Content:     "#!/bin/bash\nset -e\n" + n.neuronScript(),
+		})
+	}
+	if n.hasSSMAgentConfiguration() {
+		entries = append(entries, mime.Entry{
+			ContentType: mime.ContentTypeShellScript,
+			Content:     "#!/bin/bash\nset -e\n" + n.ssmAgentScript(),
+		})
+	}
+	if n.CloudWatchAgent != nil && lo.FromPtr(n.CloudWatchAgent.Enabled) {
+		entries = append(entries, mime.Entry{
+			ContentType: mime.ContentTypeShellScript,
+			Content:     "#!/bin/bash\nset -e\n" + n.cloudWatchAgentScript(),
+		})
+	}
+	// The snapshotter has to be running before nodeadm starts containerd, or pulls fail until it comes up
+	if lo.FromPtr(n.ContainerSnapshotter) == v1.ContainerSnapshotterSOCI {
+		entries = append(entries, mime.Entry{
+			ContentType: mime.ContentTypeShellScript,
+			Content:     "#!/bin/bash\nset -e\nsystemctl enable --now soci-snapshotter.service\n",
+		})
+	}
+	if n.isDualStack() {
+		entries = append(entries, mime.Entry{
+			ContentType: mime.ContentTypeShellScript,
+			Content:     n.dualStackNodeIPScript(),
+		})
+	}
+	mimeArchive := mime.Archive(append(entries, customEntries...))
 	userData, err := mimeArchive.Serialize()
 	if err != nil {
 		return "", err
 	}
-	return userData, nil
+	return n.limitUserDataSize(userData, true)
 }
 
 // getNodeConfigYAML returns the Karpenter generated NodeConfig YAML object serialized as a string
@@ -86,14 +177,15 @@ func (n Nodeadm) getNodeConfigYAML() (string, error) {
 	if lo.FromPtr(n.InstanceStorePolicy) == v1.InstanceStorePolicyRAID0 {
 		config.Spec.Instance.LocalStorage.Strategy = admv1alpha1.LocalStorageRAID0
 	}
+	if lo.FromPtr(n.ContainerSnapshotter) == v1.ContainerSnapshotterSOCI {
+		config.Spec.Containerd.Config = sociContainerdConfig
+	}
 	inlineConfig, err := n.generateInlineKubeletConfiguration()
 	if err != nil {
 		return "", err
 	}
 	config.Spec.Kubelet.Config = inlineConfig
-	if arg := n.nodeLabelArg(); arg != "" {
-		config.Spec.Kubelet.Flags = []string{arg}
-	}
+	config.Spec.Kubelet.Flags = n.kubeletFlags()
 
 	// Convert to YAML at the end for improved legibility.
 	configYAML, err := yaml.Marshal(config)
@@ -115,12 +207,46 @@ func (n Nodeadm) generateInlineKubeletConfiguration() (map[string]runtime.RawExt
 	if err != nil {
 		return nil, err
 	}
+	// The node IP isn't part of the kubelet configuration file, it's passed as a flag instead
+	delete(kubeConfigMap, "nodeIPFamily")
 	kubeConfigMap["registerWithTaints"] = runtime.RawExtension{
 		Raw: lo.Must(json.Marshal(n.Taints)),
 	}
+	if n.Swap != nil {
+		kubeConfigMap["failSwapOn"] = runtime.RawExtension{Raw: lo.Must(json.Marshal(false))}
+		kubeConfigMap["memorySwap"] = runtime.RawExtension{
+			Raw: lo.Must(json.Marshal(map[string]string{"swapBehavior": n.swapBehavior()})),
+		}
+	}
 	return kubeConfigMap, nil
 }
 
+// kubeletFlags returns the flags that are passed to kubelet in addition to the ones set by nodeadm. An unspecified
+// node IP makes kubelet register the default address of the IP family rather than the one nodeadm chose.
+func (n Nodeadm) kubeletFlags() []string {
+	var flags []string
+	if arg := n.nodeLabelArg(); arg != "" {
+		flags = append(flags, arg)
+	}
+	if n.KubeletConfig != nil {
+		switch lo.FromPtr(n.KubeletConfig.NodeIPFamily) {
+		case v1.NodeIPFamilyIPv4:
+			flags = append(flags, "--node-ip=0.0.0.0")
+		case v1.NodeIPFamilyIPv6:
+			flags = append(flags, "--node-ip=::")
+		}
+	}
+	return flags
+}
+
+// dualStackNodeIPScript returns a shell script that adds the node IPs of both IP families to the kubelet flags that
+// nodeadm generates. Shell scripts run after nodeadm has written the kubelet environment, but before kubelet starts.
+func (n Nodeadm) dualStackNodeIPScript() string {
+	return fmt.Sprintf("#!/bin/bash\n%ssed -i 's|^%s=\"\\(.*\\)\"$|%s=\"\\1 --node-ip='\"${NODE_IP}\"'\"|' %s\n",
+		dualStackNodeIPScript(strings.Contains(lo.FromPtr(n.ClusterCIDR), ":")),
+		nodeadmKubeletArgsEnvironmentName, nodeadmKubeletArgsEnvironmentName, nodeadmKubeletEnvironmentFilePath)
+}
+
 // parseUserData returns a slice of MIMEEntrys corresponding to each entry in the custom UserData. If the custom
 // UserData is not a MIME multi-part archive, the content type will be detected (NodeConfig or shell) and an entry
 // will be created.
@@ -23,6 +23,10 @@ import (
 	"github.com/samber/lo"
 )
 
+// gmsaPluginCLSID is the COM class of the AWS plugin of Container Credential Guard, which fetches the credentials of
+// gMSA accounts for containers on nodes that aren't joined to a domain
+const gmsaPluginCLSID = "{859E1386-BDB4-49E8-85C7-3070B13920E1}"
+
 type Windows struct {
 	Options
 }
@@ -37,6 +41,12 @@ func (w Windows) Script() (string, error) {
 		userData.WriteString(customUserData + "\n")
 	}
 
+	if w.WindowsConfiguration != nil {
+		userData.WriteString(w.windowsComponentsScript())
+	}
+	if w.hasSSMAgentConfiguration() {
+		userData.WriteString(w.ssmAgentScript())
+	}
 	userData.WriteString("[string]$EKSBootstrapScriptFile = \"$env:ProgramFiles\\Amazon\\EKS\\Start-EKSBootstrap.ps1\"\n")
 	userData.WriteString(fmt.Sprintf(`& $EKSBootstrapScriptFile -EKSClusterName '%s' -APIServerEndpoint '%s'`, w.ClusterName, w.ClusterEndpoint))
 	if w.CABundle != nil {
@@ -48,6 +58,35 @@ func (w Windows) Script() (string, error) {
 	if w.KubeletConfig != nil && len(w.KubeletConfig.ClusterDNS) > 0 {
 		userData.WriteString(fmt.Sprintf(` -DNSClusterIP '%s'`, w.KubeletConfig.ClusterDNS[0]))
 	}
+	if w.ContainerRuntime != nil {
+		userData.WriteString(fmt.Sprintf(` -ContainerRuntime '%s'`, *w.ContainerRuntime))
+	}
 	userData.WriteString("\n</powershell>")
-	return base64.StdEncoding.EncodeToString(userData.Bytes()), nil
+	return w.limitUserDataSize(base64.StdEncoding.EncodeToString(userData.Bytes()), false)
+}
+
+// ssmAgentScript returns the PowerShell commands that disable the AmazonSSMAgent service of the AMI, or register it
+// with a hybrid activation
+func (w Windows) ssmAgentScript() string {
+	if !lo.FromPtrOr(w.SSMAgent.Enabled, true) {
+		return "Stop-Service -Name 'AmazonSSMAgent'\nSet-Service -Name 'AmazonSSMAgent' -StartupType Disabled\n"
+	}
+	activation := w.SSMAgent.HybridActivation
+	return fmt.Sprintf("Stop-Service -Name 'AmazonSSMAgent'\n"+
+		"& \"$env:ProgramFiles\\Amazon\\SSM\\amazon-ssm-agent.exe\" -register -id '%s' -code '%s' -region '%s' -y\n"+
+		"Start-Service -Name 'AmazonSSMAgent'\n", activation.ID, activation.Code, activation.Region)
+}
+
+// windowsComponentsScript returns the PowerShell commands that set up csi-proxy and the gMSA plugin of the node. They
+// run before the bootstrap script, so both are available by the time kubelet starts pods.
+func (w Windows) windowsComponentsScript() string {
+	var script strings.Builder
+	if lo.FromPtr(w.WindowsConfiguration.CSIProxy) {
+		script.WriteString("New-Service -Name 'csiproxy' -StartupType Automatic -BinaryPathName \"`\"$env:ProgramFiles\\Amazon\\EKS\\bin\\csi-proxy.exe`\" -windows-service -log_file=`\"$env:ProgramData\\Amazon\\EKS\\logs\\csi-proxy.log`\" -logtostderr=false\"\n")
+		script.WriteString("Start-Service -Name 'csiproxy'\n")
+	}
+	if lo.FromPtr(w.WindowsConfiguration.GMSA) {
+		script.WriteString(fmt.Sprintf("New-Item -Path 'HKLM:\\SYSTEM\\CurrentControlSet\\Control\\CCG\\COMClasses\\%s' -Force | Out-Null\n", gmsaPluginCLSID))
+	}
+	return script.String()
 }
@@ -17,6 +17,7 @@ package amifamily
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/samber/lo"
@@ -36,6 +37,13 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 )
 
+var (
+	// bottlerocketAMIVersion matches the version of AMI names like bottlerocket-aws-k8s-1.30-x86_64-v1.20.3-5d9ac849
+	bottlerocketAMIVersion = regexp.MustCompile(`^bottlerocket-.*-v(\d+\.\d+)\.\d+-[0-9a-f]+$`)
+	// bottlerocketOSImageVersion matches the version of the OS image of nodes like Bottlerocket OS 1.20.3 (aws-k8s-1.30)
+	bottlerocketOSImageVersion = regexp.MustCompile(`^Bottlerocket OS (\d+\.\d+)\.\d+`)
+)
+
 type Bottlerocket struct {
 	DefaultFamily
 	*Options
@@ -81,16 +89,24 @@ func (b Bottlerocket) DescribeImageQuery(ctx context.Context, ssmProvider ssm.Pr
 }
 
 // UserData returns the default userdata script for the AMI Family
-func (b Bottlerocket) UserData(kubeletConfig *v1.KubeletConfiguration, taints []corev1.Taint, labels map[string]string, caBundle *string, _ []*cloudprovider.InstanceType, customUserData *string, _ *v1.InstanceStorePolicy) bootstrap.Bootstrapper {
+func (b Bottlerocket) UserData(kubeletConfig *v1.KubeletConfiguration, taints []corev1.Taint, labels map[string]string, caBundle *string, _ []*cloudprovider.InstanceType, customUserData *string, _ *v1.InstanceStorePolicy, _ *string, containerRegistries []v1.ContainerRegistry, containerSnapshotter *v1.ContainerSnapshotter, _ *v1.Swap, _ *v1.WindowsConfiguration, hugepages []v1.Hugepages, kernelParameters *v1.KernelParameters, sysctls map[string]string, kernelModules []v1.KernelModule, _ *bool, dcgmExporter *v1.DCGMExporter, _ *v1.NeuronConfiguration, ssmAgent *v1.SSMAgent, _ *v1.CloudWatchAgent) bootstrap.Bootstrapper {
 	return bootstrap.Bottlerocket{
 		Options: bootstrap.Options{
-			ClusterName:     b.Options.ClusterName,
-			ClusterEndpoint: b.Options.ClusterEndpoint,
-			KubeletConfig:   kubeletConfig,
-			Taints:          taints,
-			Labels:          labels,
-			CABundle:        caBundle,
-			CustomUserData:  customUserData,
+			ClusterName:          b.Options.ClusterName,
+			ClusterEndpoint:      b.Options.ClusterEndpoint,
+			KubeletConfig:        kubeletConfig,
+			Taints:               taints,
+			Labels:               labels,
+			CABundle:             caBundle,
+			CustomUserData:       customUserData,
+			ContainerRegistries:  containerRegistries,
+			ContainerSnapshotter: containerSnapshotter,
+			Hugepages:            hugepages,
+			KernelParameters:     kernelParameters,
+			Sysctls:              sysctls,
+			KernelModules:        kernelModules,
+			DCGMExporter:         dcgmExporter,
+			SSMAgent:             ssmAgent,
 		},
 	}
 }
@@ -129,9 +145,24 @@ func (b Bottlerocket) EphemeralBlockDevice() *string {
 
 func (b Bottlerocket) FeatureFlags() FeatureFlags {
 	return FeatureFlags{
-		UsesENILimitedMemoryOverhead: false,
-		PodsPerCoreEnabled:           false,
-		EvictionSoftEnabled:          false,
-		SupportsENILimitedPodDensity: true,
+		UsesENILimitedMemoryOverhead:  false,
+		PodsPerCoreEnabled:            false,
+		EvictionSoftEnabled:           false,
+		SupportsENILimitedPodDensity:  true,
+		SupportsImageFSVolume:         false,
+		SupportsHugepages:             true,
+		SupportsMultipleHugepageSizes: false,
+	}
+}
+
+// BottlerocketMinorVersion returns the major and minor version of a Bottlerocket AMI name, or of the OS image that a
+// Bottlerocket node reports, which is updated when the node is updated in place. Patch versions of the same minor
+// version are updated in place by the Bottlerocket update operator.
+func BottlerocketMinorVersion(name string) (string, bool) {
+	for _, r := range []*regexp.Regexp{bottlerocketAMIVersion, bottlerocketOSImageVersion} {
+		if matches := r.FindStringSubmatch(name); matches != nil {
+			return matches[1], true
+		}
 	}
+	return "", false
 }
@@ -16,7 +16,9 @@ package amifamily
 
 import (
 	"context"
+	"strings"
 
+	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
@@ -32,13 +34,19 @@ type Custom struct {
 	*Options
 }
 
-// UserData returns the default userdata script for the AMI Family
-func (c Custom) UserData(_ *v1.KubeletConfiguration, _ []corev1.Taint, _ map[string]string, _ *string, _ []*cloudprovider.InstanceType, customUserData *string, _ *v1.InstanceStorePolicy) bootstrap.Bootstrapper {
-	return bootstrap.Custom{
-		Options: bootstrap.Options{
-			CustomUserData: customUserData,
-		},
+// UserData returns the default userdata script for the AMI Family. The labels and taints of the node are only rendered
+// into userData that references them, so that the launch templates of other userData don't vary with them.
+func (c Custom) UserData(_ *v1.KubeletConfiguration, taints []corev1.Taint, labels map[string]string, _ *string, _ []*cloudprovider.InstanceType, customUserData *string, _ *v1.InstanceStorePolicy, _ *string, _ []v1.ContainerRegistry, _ *v1.ContainerSnapshotter, _ *v1.Swap, _ *v1.WindowsConfiguration, _ []v1.Hugepages, _ *v1.KernelParameters, _ map[string]string, _ []v1.KernelModule, _ *bool, _ *v1.DCGMExporter, _ *v1.NeuronConfiguration, _ *v1.SSMAgent, _ *v1.CloudWatchAgent) bootstrap.Bootstrapper {
+	options := bootstrap.Options{
+		CustomUserData: customUserData,
 	}
+	if strings.Contains(lo.FromPtr(customUserData), bootstrap.CustomNodeLabelsVariable) {
+		options.Labels = labels
+	}
+	if strings.Contains(lo.FromPtr(customUserData), bootstrap.CustomNodeTaintsVariable) {
+		options.Taints = taints
+	}
+	return bootstrap.Custom{Options: options}
 }
 
 func (c Custom) DescribeImageQuery(_ context.Context, _ ssm.Provider, _ string, _ string) (DescribeImageQuery, error) {
@@ -61,6 +61,8 @@ type Options struct {
 	Labels                   map[string]string `hash:"ignore"`
 	KubeDNSIP                net.IP
 	AssociatePublicIPAddress *bool
+	PrivateDNSNameOptions    *v1.PrivateDNSNameOptions
+	PrimaryNetworkInterface  *v1.PrimaryNetworkInterface
 	NodeClassName            string
 }
 
@@ -73,14 +75,19 @@ type LaunchTemplate struct {
 	AMIID               string
 	InstanceTypes       []*cloudprovider.InstanceType `hash:"ignore"`
 	DetailedMonitoring  bool
+	EBSOptimized        *bool
+	KeyName             *string
+	CreditSpecification *v1.CreditSpecification
 	EFACount            int
 	CapacityType        string
+	// IPv6AddressCount is derived from the kubelet configuration, which is hashed as part of the UserData
+	IPv6AddressCount int64 `hash:"ignore"`
 }
 
 // AMIFamily can be implemented to override the default logic for generating dynamic launch template parameters
 type AMIFamily interface {
 	DescribeImageQuery(ctx context.Context, ssmProvider ssm.Provider, k8sVersion string, amiVersion string) (DescribeImageQuery, error)
-	UserData(kubeletConfig *v1.KubeletConfiguration, taints []corev1.Taint, labels map[string]string, caBundle *string, instanceTypes []*cloudprovider.InstanceType, customUserData *string, instanceStorePolicy *v1.InstanceStorePolicy) bootstrap.Bootstrapper
+	UserData(kubeletConfig *v1.KubeletConfiguration, taints []corev1.Taint, labels map[string]string, caBundle *string, instanceTypes []*cloudprovider.InstanceType, customUserData *string, instanceStorePolicy *v1.InstanceStorePolicy, imageFSDevice *string, containerRegistries []v1.ContainerRegistry, containerSnapshotter *v1.ContainerSnapshotter, swap *v1.Swap, windowsConfiguration *v1.WindowsConfiguration, hugepages []v1.Hugepages, kernelParameters *v1.KernelParameters, sysctls map[string]string, kernelModules []v1.KernelModule, ptpHardwareClock *bool, dcgmExporter *v1.DCGMExporter, neuron *v1.NeuronConfiguration, ssmAgent *v1.SSMAgent, cloudWatchAgent *v1.CloudWatchAgent) bootstrap.Bootstrapper
 	DefaultBlockDeviceMappings() []*v1.BlockDeviceMapping
 	DefaultMetadataOptions() *v1.MetadataOptions
 	EphemeralBlockDevice() *string
@@ -94,10 +101,13 @@ type DefaultAMIOutput struct {
 
 // FeatureFlags describes whether the features below are enabled for a given AMIFamily
 type FeatureFlags struct {
-	UsesENILimitedMemoryOverhead bool
-	PodsPerCoreEnabled           bool
-	EvictionSoftEnabled          bool
-	SupportsENILimitedPodDensity bool
+	UsesENILimitedMemoryOverhead  bool
+	PodsPerCoreEnabled            bool
+	EvictionSoftEnabled           bool
+	SupportsENILimitedPodDensity  bool
+	SupportsImageFSVolume         bool
+	SupportsHugepages             bool
+	SupportsMultipleHugepageSizes bool
 }
 
 // DefaultFamily provides default values for AMIFamilies that compose it
@@ -105,10 +115,13 @@ type DefaultFamily struct{}
 
 func (d DefaultFamily) FeatureFlags() FeatureFlags {
 	return FeatureFlags{
-		UsesENILimitedMemoryOverhead: true,
-		PodsPerCoreEnabled:           true,
-		EvictionSoftEnabled:          true,
-		SupportsENILimitedPodDensity: true,
+		UsesENILimitedMemoryOverhead:  true,
+		PodsPerCoreEnabled:            true,
+		EvictionSoftEnabled:           true,
+		SupportsENILimitedPodDensity:  true,
+		SupportsImageFSVolume:         true,
+		SupportsHugepages:             true,
+		SupportsMultipleHugepageSizes: true,
 	}
 }
 
@@ -130,16 +143,25 @@ func (r Resolver) Resolve(nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClai
 	if len(mappedAMIs) == 0 {
 		return nil, fmt.Errorf("no instance types satisfy requirements of amis %v", lo.Uniq(lo.Map(nodeClass.Status.AMIs, func(a v1.AMI, _ int) string { return a.ID })))
 	}
+	kubeletConfig, err := utils.GetKubeletConfigurationWithNodeClaim(nodeClaim, nodeClass)
+	if err != nil {
+		return nil, fmt.Errorf("resolving kubelet configuration, %w", err)
+	}
 	var resolvedTemplates []*LaunchTemplate
 	for amiID, instanceTypes := range mappedAMIs {
 		// In order to support reserved ENIs for CNI custom networking setups,
 		// we need to pass down the max-pods calculation to the kubelet.
 		// This requires that we resolve a unique launch template per max-pods value.
 		// Similarly, instance types configured with EfAs require unique launch templates depending on the number of
-		// EFAs they support.
+		// EFAs they support, and instance types with different reserved resources when they're sized by instance type.
+		// Burstable instance types are split from the others when the EC2NodeClass sets their credit specification,
+		// which can't be set in the launch templates of instance types that aren't burstable.
 		type launchTemplateParams struct {
-			efaCount int
-			maxPods  int
+			efaCount       int
+			maxPods        int
+			kubeReserved   string
+			systemReserved string
+			burstable      bool
 		}
 		paramsToInstanceTypes := lo.GroupBy(instanceTypes, func(instanceType *cloudprovider.InstanceType) launchTemplateParams {
 			return launchTemplateParams{
@@ -149,6 +171,11 @@ func (r Resolver) Resolve(nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClai
 					0,
 				),
 				maxPods: int(instanceType.Capacity.Pods().Value()),
+				kubeReserved: lo.Ternary(kubeletConfig != nil && kubeletConfig.ReservedSizing != nil,
+					fmt.Sprint(reservedResources(instanceType.Overhead.KubeReserved)), ""),
+				systemReserved: lo.Ternary(kubeletConfig != nil && kubeletConfig.ReservedSizing != nil,
+					fmt.Sprint(reservedResources(instanceType.Overhead.SystemReserved)), ""),
+				burstable: nodeClass.Spec.CreditSpecification != nil && instanceType.Requirements.Get(v1.LabelInstanceBurstable).Has("true"),
 			}
 		})
 		for params, instanceTypes := range paramsToInstanceTypes {
@@ -156,6 +183,9 @@ func (r Resolver) Resolve(nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClai
 			if err != nil {
 				return nil, err
 			}
+			if params.burstable {
+				resolved.CreditSpecification = nodeClass.Spec.CreditSpecification
+			}
 			resolvedTemplates = append(resolvedTemplates, resolved)
 		}
 	}
@@ -188,6 +218,16 @@ func (o Options) DefaultMetadataOptions() *v1.MetadataOptions {
 	}
 }
 
+// reservedResources formats the reserved resources of an instance type as the kubelet flags expect them
+func reservedResources(resources corev1.ResourceList) map[string]string {
+	if len(resources) == 0 {
+		return nil
+	}
+	return lo.MapEntries(resources, func(k corev1.ResourceName, v resource.Quantity) (string, string) {
+		return string(k), v.String()
+	})
+}
+
 func (r Resolver) defaultClusterDNS(opts *Options, kubeletConfig *v1.KubeletConfiguration) *v1.KubeletConfiguration {
 	if opts.KubeDNSIP == nil {
 		return kubeletConfig
@@ -217,6 +257,12 @@ func (r Resolver) resolveLaunchTemplate(nodeClass *v1.EC2NodeClass, nodeClaim *k
 	if kubeletConfig.MaxPods == nil {
 		kubeletConfig.MaxPods = lo.ToPtr(int32(maxPods))
 	}
+	if kubeletConfig.ReservedSizing != nil {
+		// The reserved resources that are sized by instance type are the same for all instance types of the launch template
+		kubeletConfig = kubeletConfig.DeepCopy()
+		kubeletConfig.KubeReserved = reservedResources(instanceTypes[0].Overhead.KubeReserved)
+		kubeletConfig.SystemReserved = reservedResources(instanceTypes[0].Overhead.SystemReserved)
+	}
 	taints := lo.Flatten([][]corev1.Taint{
 		nodeClaim.Spec.Taints,
 		nodeClaim.Spec.StartupTaints,
@@ -227,30 +273,104 @@ func (r Resolver) resolveLaunchTemplate(nodeClass *v1.EC2NodeClass, nodeClaim *k
 		taints = append(taints, corev1.Taint{Key: "karpenter.sh/unregistered", Effect: corev1.TaintEffectNoExecute})
 	}
 
+	exporter := dcgmExporter(nodeClass, instanceTypes)
+	neuron := neuronConfiguration(nodeClass, instanceTypes)
+	labels := options.Labels
+	if exporter != nil {
+		labels = lo.Assign(labels, map[string]string{v1.LabelDCGMExporter: "true"})
+	}
+	if neuron != nil && neuron.LogicalNeuronCoreConfig != nil {
+		labels = lo.Assign(labels, map[string]string{v1.LabelNeuronLogicalCoreConfig: fmt.Sprint(*neuron.LogicalNeuronCoreConfig)})
+	}
+
 	resolved := &LaunchTemplate{
 		Options: options,
 		UserData: amiFamily.UserData(
 			r.defaultClusterDNS(options, kubeletConfig),
 			taints,
-			options.Labels,
+			labels,
 			options.CABundle,
 			instanceTypes,
 			nodeClass.Spec.UserData,
 			options.InstanceStorePolicy,
+			imageFSDevice(nodeClass, amiFamily),
+			nodeClass.Spec.ContainerRegistries,
+			nodeClass.Spec.ContainerSnapshotter,
+			nodeClass.Spec.Swap,
+			nodeClass.Spec.Windows,
+			nodeClass.Spec.Hugepages,
+			nodeClass.Spec.KernelParameters,
+			nodeClass.Spec.Sysctls,
+			nodeClass.Spec.KernelModules,
+			nodeClass.Spec.PTPHardwareClock,
+			exporter,
+			neuron,
+			nodeClass.Spec.SSMAgent,
+			nodeClass.Spec.CloudWatchAgent,
 		),
 		BlockDeviceMappings: nodeClass.Spec.BlockDeviceMappings,
 		MetadataOptions:     nodeClass.Spec.MetadataOptions,
 		DetailedMonitoring:  aws.BoolValue(nodeClass.Spec.DetailedMonitoring),
+		EBSOptimized:        nodeClass.Spec.EBSOptimized,
+		KeyName:             nodeClass.Spec.KeyName,
 		AMIID:               amiID,
 		InstanceTypes:       instanceTypes,
 		EFACount:            efaCount,
 		CapacityType:        capacityType,
 	}
+	// Nodes that register an IPv6 node IP need an IPv6 address, even if the subnet doesn't assign one automatically
+	if lo.Contains([]string{v1.NodeIPFamilyIPv6, v1.NodeIPFamilyDualStack}, lo.FromPtr(kubeletConfig.NodeIPFamily)) {
+		resolved.IPv6AddressCount = 1
+	}
 	if len(resolved.BlockDeviceMappings) == 0 {
 		resolved.BlockDeviceMappings = amiFamily.DefaultBlockDeviceMappings()
 	}
 	if resolved.MetadataOptions == nil {
 		resolved.MetadataOptions = amiFamily.DefaultMetadataOptions()
 	}
+	// The hop limit is adjusted on a copy, since the metadata options are shared with the EC2NodeClass
+	if nodeClass.Spec.PodMetadataAccess != nil {
+		metadataOptions := *resolved.MetadataOptions
+		// EC2 defaults the hop limit to 1
+		metadataOptions.HTTPPutResponseHopLimit = lo.ToPtr(nodeClass.MetadataHopLimit(lo.FromPtrOr(metadataOptions.HTTPPutResponseHopLimit, 1)))
+		resolved.MetadataOptions = &metadataOptions
+	}
 	return resolved, nil
 }
+
+// imageFSDevice returns the device name of the block device mapping that's mounted as the image filesystem, if the
+// AMIFamily supports one
+func imageFSDevice(nodeClass *v1.EC2NodeClass, amiFamily AMIFamily) *string {
+	if !amiFamily.FeatureFlags().SupportsImageFSVolume {
+		return nil
+	}
+	if bdm, ok := lo.Find(nodeClass.Spec.BlockDeviceMappings, func(bdm *v1.BlockDeviceMapping) bool { return bdm.ImageFSVolume }); ok {
+		return bdm.DeviceName
+	}
+	return nil
+}
+
+// dcgmExporter returns the DCGM exporter configuration of the EC2NodeClass, if it's enabled and the launch template
+// launches instance types with NVIDIA GPUs
+func dcgmExporter(nodeClass *v1.EC2NodeClass, instanceTypes []*cloudprovider.InstanceType) *v1.DCGMExporter {
+	if nodeClass.Spec.DCGMExporter == nil || !lo.FromPtr(nodeClass.Spec.DCGMExporter.Enabled) {
+		return nil
+	}
+	if !lo.SomeBy(instanceTypes, func(it *cloudprovider.InstanceType) bool {
+		return !it.Capacity.Name(v1.ResourceNVIDIAGPU, resource.DecimalSI).IsZero()
+	}) {
+		return nil
+	}
+	return nodeClass.Spec.DCGMExporter
+}
+
+// neuronConfiguration returns the Neuron configuration of the EC2NodeClass, if the launch template launches instance
+// types with Neuron devices
+func neuronConfiguration(nodeClass *v1.EC2NodeClass, instanceTypes []*cloudprovider.InstanceType) *v1.NeuronConfiguration {
+	if !lo.SomeBy(instanceTypes, func(it *cloudprovider.InstanceType) bool {
+		return !it.Capacity.Name(v1.ResourceAWSNeuron, resource.DecimalSI).IsZero()
+	}) {
+		return nil
+	}
+	return nodeClass.Spec.Neuron
+}
@@ -16,6 +16,13 @@ package amifamily_test
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
 	"fmt"
 	"sort"
 	"sync"
@@ -554,6 +561,110 @@ var _ = Describe("AMIProvider", func() {
 			))
 		})
 	})
+	Context("AMI Verification", func() {
+		var key *ecdsa.PrivateKey
+		var signedImage, unsignedImage *ec2.Image
+		sign := func(key *ecdsa.PrivateKey, message string) *ec2.Tag {
+			digest := sha256.Sum256([]byte(message))
+			return &ec2.Tag{Key: aws.String(v1.TagAMISignature), Value: aws.String(base64.StdEncoding.EncodeToString(lo.Must(ecdsa.SignASN1(rand.Reader, key, digest[:]))))}
+		}
+		publicKeyPEM := func(key *ecdsa.PrivateKey) string {
+			return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: lo.Must(x509.MarshalPKIXPublicKey(&key.PublicKey))}))
+		}
+		BeforeEach(func() {
+			key = lo.Must(ecdsa.GenerateKey(elliptic.P256(), rand.Reader))
+			signedImage = &ec2.Image{
+				Name:         aws.String("signed-ami"),
+				ImageId:      aws.String("ami-signed"),
+				CreationDate: aws.String(time.Time{}.Format(time.RFC3339)),
+				Architecture: aws.String("x86_64"),
+				Tags:         []*ec2.Tag{sign(key, "ami-signed")},
+			}
+			// The unsigned image is newer, so it would be used if it passed verification
+			unsignedImage = &ec2.Image{
+				Name:         aws.String("unsigned-ami"),
+				ImageId:      aws.String("ami-unsigned"),
+				CreationDate: aws.String(time.Time{}.Add(time.Minute).Format(time.RFC3339)),
+				Architecture: aws.String("x86_64"),
+			}
+			awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []*ec2.Image{signedImage, unsignedImage}})
+			nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Tags: map[string]string{"*": "*"}}}
+		})
+		It("should use the newest AMI when verification isn't configured", func() {
+			amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(amis).To(HaveLen(1))
+			Expect(amis[0].AmiID).To(Equal("ami-unsigned"))
+		})
+		It("should reject AMIs without a signature", func() {
+			nodeClass.Spec.AMIVerification = &v1.AMIVerification{PublicKey: lo.ToPtr(publicKeyPEM(key))}
+			amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(amis).To(HaveLen(1))
+			Expect(amis[0].AmiID).To(Equal("ami-signed"))
+		})
+		It("should reject AMIs that are signed with another key", func() {
+			unsignedImage.Tags = []*ec2.Tag{sign(lo.Must(ecdsa.GenerateKey(elliptic.P256(), rand.Reader)), "ami-unsigned")}
+			nodeClass.Spec.AMIVerification = &v1.AMIVerification{PublicKey: lo.ToPtr(publicKeyPEM(key))}
+			amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(amis).To(HaveLen(1))
+			Expect(amis[0].AmiID).To(Equal("ami-signed"))
+		})
+		It("should reject AMIs whose signature is copied from another AMI", func() {
+			unsignedImage.Tags = signedImage.Tags
+			nodeClass.Spec.AMIVerification = &v1.AMIVerification{PublicKey: lo.ToPtr(publicKeyPEM(key))}
+			amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(amis).To(HaveLen(1))
+			Expect(amis[0].AmiID).To(Equal("ami-signed"))
+		})
+		It("should verify the signature over the AMI ID and recipe", func() {
+			unsignedImage.Tags = []*ec2.Tag{
+				{Key: aws.String(v1.TagAMIRecipe), Value: aws.String("arn:aws:imagebuilder:us-west-2:123456789012:image-recipe/al2023/1.0.0")},
+				sign(key, "ami-unsigned\narn:aws:imagebuilder:us-west-2:123456789012:image-recipe/al2023/1.0.0"),
+			}
+			nodeClass.Spec.AMIVerification = &v1.AMIVerification{PublicKey: lo.ToPtr(publicKeyPEM(key))}
+			amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(amis).To(HaveLen(1))
+			Expect(amis[0].AmiID).To(Equal("ami-unsigned"))
+
+			// Changing the recipe invalidates the signature
+			awsEnv.EC2Cache.Flush()
+			unsignedImage.Tags[0].Value = aws.String("arn:aws:imagebuilder:us-west-2:123456789012:image-recipe/al2023/2.0.0")
+			amis, err = awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(amis).To(HaveLen(1))
+			Expect(amis[0].AmiID).To(Equal("ami-signed"))
+		})
+		It("should verify signatures with the public key of a KMS key", func() {
+			awsEnv.KMSAPI.PublicKeys["alias/ami-signing"] = lo.Must(x509.MarshalPKIXPublicKey(&key.PublicKey))
+			nodeClass.Spec.AMIVerification = &v1.AMIVerification{KMSKeyID: lo.ToPtr("alias/ami-signing")}
+			amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(amis).To(HaveLen(1))
+			Expect(amis[0].AmiID).To(Equal("ami-signed"))
+
+			// The public key is cached
+			_, err = awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.KMSAPI.GetPublicKeyBehavior.Calls()).To(Equal(1))
+		})
+		It("should fail when the KMS key can't be found", func() {
+			nodeClass.Spec.AMIVerification = &v1.AMIVerification{KMSKeyID: lo.ToPtr("alias/missing")}
+			_, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).To(HaveOccurred())
+			Expect(amifamily.IsVerificationError(err)).To(BeFalse())
+		})
+		It("should return a verification error when all AMIs fail verification", func() {
+			signedImage.Tags = nil
+			nodeClass.Spec.AMIVerification = &v1.AMIVerification{PublicKey: lo.ToPtr(publicKeyPEM(key))}
+			_, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).To(HaveOccurred())
+			Expect(amifamily.IsVerificationError(err)).To(BeTrue())
+		})
+	})
 })
 
 func ExpectConsistsOfAMIQueries(expected, actual []amifamily.DescribeImageQuery) {
@@ -53,16 +53,27 @@ func (u Ubuntu) DefaultAMIs(version string) []DefaultAMIOutput {
 }
 
 // UserData returns the default userdata script for the AMI Family
-func (u Ubuntu) UserData(kubeletConfig *v1.KubeletConfiguration, taints []corev1.Taint, labels map[string]string, caBundle *string, _ []*cloudprovider.InstanceType, customUserData *string, _ *v1.InstanceStorePolicy) bootstrap.Bootstrapper {
+func (u Ubuntu) UserData(kubeletConfig *v1.KubeletConfiguration, taints []corev1.Taint, labels map[string]string, caBundle *string, _ []*cloudprovider.InstanceType, customUserData *string, _ *v1.InstanceStorePolicy, imageFSDevice *string, containerRegistries []v1.ContainerRegistry, _ *v1.ContainerSnapshotter, swap *v1.Swap, _ *v1.WindowsConfiguration, hugepages []v1.Hugepages, kernelParameters *v1.KernelParameters, sysctls map[string]string, kernelModules []v1.KernelModule, ptpHardwareClock *bool, dcgmExporter *v1.DCGMExporter, _ *v1.NeuronConfiguration, ssmAgent *v1.SSMAgent, cloudWatchAgent *v1.CloudWatchAgent) bootstrap.Bootstrapper {
 	return bootstrap.EKS{
 		Options: bootstrap.Options{
-			ClusterName:     u.Options.ClusterName,
-			ClusterEndpoint: u.Options.ClusterEndpoint,
-			KubeletConfig:   kubeletConfig,
-			Taints:          taints,
-			Labels:          labels,
-			CABundle:        caBundle,
-			CustomUserData:  customUserData,
+			ClusterName:         u.Options.ClusterName,
+			ClusterEndpoint:     u.Options.ClusterEndpoint,
+			KubeletConfig:       kubeletConfig,
+			Taints:              taints,
+			Labels:              labels,
+			CABundle:            caBundle,
+			CustomUserData:      customUserData,
+			ImageFSDevice:       imageFSDevice,
+			ContainerRegistries: containerRegistries,
+			Swap:                swap,
+			Hugepages:           hugepages,
+			KernelParameters:    kernelParameters,
+			Sysctls:             sysctls,
+			KernelModules:       kernelModules,
+			PTPHardwareClock:    ptpHardwareClock,
+			DCGMExporter:        dcgmExporter,
+			SSMAgent:            ssmAgent,
+			CloudWatchAgent:     cloudWatchAgent,
 		},
 	}
 }
@@ -0,0 +1,118 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package amifamily
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/samber/lo"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
+)
+
+// VerificationError is returned when every AMI that was discovered for an EC2NodeClass failed verification
+type VerificationError struct {
+	error
+}
+
+func IsVerificationError(err error) bool {
+	return errors.As(err, &VerificationError{})
+}
+
+// publicKey returns the key that the signatures of AMIs are verified with. The public keys of KMS keys are cached,
+// since they never change.
+func (p *DefaultProvider) publicKey(ctx context.Context, verification *v1.AMIVerification) (*ecdsa.PublicKey, error) {
+	if verification.PublicKey != nil {
+		block, _ := pem.Decode([]byte(*verification.PublicKey))
+		if block == nil {
+			return nil, fmt.Errorf("decoding public key, no PEM data found")
+		}
+		return parseECDSAPublicKey(block.Bytes)
+	}
+	cacheKey := assumerole.CacheKey(ctx, fmt.Sprintf("kms-public-key/%s", lo.FromPtr(verification.KMSKeyID)))
+	if publicKey, ok := p.cache.Get(cacheKey); ok {
+		return publicKey.(*ecdsa.PublicKey), nil
+	}
+	out, err := p.kmsapi.GetPublicKeyWithContext(ctx, &kms.GetPublicKeyInput{KeyId: verification.KMSKeyID})
+	if err != nil {
+		return nil, fmt.Errorf("getting public key of kms key %s, %w", lo.FromPtr(verification.KMSKeyID), err)
+	}
+	publicKey, err := parseECDSAPublicKey(out.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("kms key %s, %w", lo.FromPtr(verification.KMSKeyID), err)
+	}
+	p.cache.SetDefault(cacheKey, publicKey)
+	return publicKey, nil
+}
+
+func parseECDSAPublicKey(der []byte) (*ecdsa.PublicKey, error) {
+	publicKey, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key, %w", err)
+	}
+	ecdsaPublicKey, ok := publicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key of type %T isn't an ECDSA key", publicKey)
+	}
+	return ecdsaPublicKey, nil
+}
+
+// verifySignature verifies the signature tag of an image, which is over the image ID and, if the image has one,
+// the recipe tag separated by a newline. The message is hashed with the digest that KMS uses for the curve of the key.
+func verifySignature(publicKey *ecdsa.PublicKey, image *ec2.Image) error {
+	tags := lo.SliceToMap(image.Tags, func(tag *ec2.Tag) (string, string) { return lo.FromPtr(tag.Key), lo.FromPtr(tag.Value) })
+	signature, ok := tags[v1.TagAMISignature]
+	if !ok {
+		return fmt.Errorf("missing %s tag", v1.TagAMISignature)
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("decoding signature, %w", err)
+	}
+	message := lo.FromPtr(image.ImageId)
+	if recipe, ok := tags[v1.TagAMIRecipe]; ok {
+		message = fmt.Sprintf("%s\n%s", message, recipe)
+	}
+	if !ecdsa.VerifyASN1(publicKey, digest(publicKey.Curve, []byte(message)), sig) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+func digest(curve elliptic.Curve, message []byte) []byte {
+	switch curve.Params().BitSize {
+	case 384:
+		sum := sha512.Sum384(message)
+		return sum[:]
+	case 521:
+		sum := sha512.Sum512(message)
+		return sum[:]
+	default:
+		sum := sha256.Sum256(message)
+		return sum[:]
+	}
+}
@@ -89,16 +89,24 @@ func (w Windows) DescribeImageQuery(ctx context.Context, ssmProvider ssm.Provide
 }
 
 // UserData returns the default userdata script for the AMI Family
-func (w Windows) UserData(kubeletConfig *v1.KubeletConfiguration, taints []corev1.Taint, labels map[string]string, caBundle *string, _ []*cloudprovider.InstanceType, customUserData *string, _ *v1.InstanceStorePolicy) bootstrap.Bootstrapper {
+func (w Windows) UserData(kubeletConfig *v1.KubeletConfiguration, taints []corev1.Taint, labels map[string]string, caBundle *string, _ []*cloudprovider.InstanceType, customUserData *string, _ *v1.InstanceStorePolicy, _ *string, _ []v1.ContainerRegistry, _ *v1.ContainerSnapshotter, _ *v1.Swap, windowsConfiguration *v1.WindowsConfiguration, _ []v1.Hugepages, _ *v1.KernelParameters, _ map[string]string, _ []v1.KernelModule, _ *bool, _ *v1.DCGMExporter, _ *v1.NeuronConfiguration, ssmAgent *v1.SSMAgent, _ *v1.CloudWatchAgent) bootstrap.Bootstrapper {
+	var containerRuntime *string
+	// HostProcess containers are only supported by containerd
+	if windowsConfiguration != nil && lo.FromPtr(windowsConfiguration.HostProcessContainers) {
+		containerRuntime = lo.ToPtr("containerd")
+	}
 	return bootstrap.Windows{
 		Options: bootstrap.Options{
-			ClusterName:     w.Options.ClusterName,
-			ClusterEndpoint: w.Options.ClusterEndpoint,
-			KubeletConfig:   kubeletConfig,
-			Taints:          taints,
-			Labels:          labels,
-			CABundle:        caBundle,
-			CustomUserData:  customUserData,
+			ClusterName:          w.Options.ClusterName,
+			ClusterEndpoint:      w.Options.ClusterEndpoint,
+			KubeletConfig:        kubeletConfig,
+			Taints:               taints,
+			Labels:               labels,
+			CABundle:             caBundle,
+			CustomUserData:       customUserData,
+			ContainerRuntime:     containerRuntime,
+			WindowsConfiguration: windowsConfiguration,
+			SSMAgent:             ssmAgent,
 		},
 	}
 }
@@ -119,9 +127,12 @@ func (w Windows) EphemeralBlockDevice() *string {
 
 func (w Windows) FeatureFlags() FeatureFlags {
 	return FeatureFlags{
-		UsesENILimitedMemoryOverhead: false,
-		PodsPerCoreEnabled:           true,
-		EvictionSoftEnabled:          true,
-		SupportsENILimitedPodDensity: false,
+		UsesENILimitedMemoryOverhead:  false,
+		PodsPerCoreEnabled:            true,
+		EvictionSoftEnabled:           true,
+		SupportsENILimitedPodDensity:  false,
+		SupportsImageFSVolume:         false,
+		SupportsHugepages:             false,
+		SupportsMultipleHugepageSizes: false,
 	}
 }
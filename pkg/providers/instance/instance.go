@@ -20,12 +20,15 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	gocache "github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
 	corev1 "k8s.io/api/core/v1"
@@ -36,13 +39,16 @@ import (
 	"sigs.k8s.io/karpenter/pkg/utils/resources"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/audit"
 	"github.com/aws/karpenter-provider-aws/pkg/batcher"
 	"github.com/aws/karpenter-provider-aws/pkg/cache"
 	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/launchjournal"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+	"github.com/aws/karpenter-provider-aws/pkg/tracing"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
 
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
@@ -52,6 +58,13 @@ import (
 const (
 	instanceTypeFlexibilityThreshold = 5 // falling back to on-demand without flexibility risks insufficient capacity errors
 	maxInstanceTypes                 = 60
+	// launchConsistencyWindow is how long after a launch an instance that isn't found by the EC2 API is assumed to not
+	// be visible yet rather than to be gone
+	launchConsistencyWindow = 2 * time.Minute
+	// launchConsistencyRetries is how many times an instance of a recent launch that isn't found is described again
+	// before a retryable error is returned
+	launchConsistencyRetries    = 3
+	launchConsistencyRetryDelay = 200 * time.Millisecond
 )
 
 var (
@@ -67,60 +80,217 @@ type Provider interface {
 	List(context.Context) ([]*Instance, error)
 	Delete(context.Context, string) error
 	CreateTags(context.Context, string, map[string]string) error
+	DisableSourceDestCheck(context.Context, string) error
+	IsTerminationProtected(context.Context, string) (bool, error)
+	SetTerminationProtection(context.Context, string, bool) error
 }
 
 type DefaultProvider struct {
 	region                 string
 	ec2api                 ec2iface.EC2API
 	unavailableOfferings   *cache.UnavailableOfferings
+	impairedZones          *cache.ImpairedZones
+	spotInterruptions      *cache.SpotInterruptionHistory
 	instanceTypeProvider   instancetype.Provider
 	subnetProvider         subnet.Provider
 	launchTemplateProvider launchtemplate.Provider
+	launchJournal          launchjournal.Provider
 	ec2Batcher             *batcher.EC2API
+	// launched holds the IDs of the instances that were launched within the launch consistency window
+	launched *gocache.Cache
 }
 
 func NewDefaultProvider(ctx context.Context, region string, ec2api ec2iface.EC2API, unavailableOfferings *cache.UnavailableOfferings,
-	instanceTypeProvider instancetype.Provider, subnetProvider subnet.Provider, launchTemplateProvider launchtemplate.Provider) *DefaultProvider {
+	impairedZones *cache.ImpairedZones, spotInterruptions *cache.SpotInterruptionHistory, instanceTypeProvider instancetype.Provider, subnetProvider subnet.Provider,
+	launchTemplateProvider launchtemplate.Provider, launchJournal launchjournal.Provider) *DefaultProvider {
 	return &DefaultProvider{
 		region:                 region,
 		ec2api:                 ec2api,
 		unavailableOfferings:   unavailableOfferings,
+		impairedZones:          impairedZones,
+		spotInterruptions:      spotInterruptions,
 		instanceTypeProvider:   instanceTypeProvider,
 		subnetProvider:         subnetProvider,
 		launchTemplateProvider: launchTemplateProvider,
+		launchJournal:          launchJournal,
 		ec2Batcher:             batcher.EC2(ctx, ec2api),
+		launched:               gocache.New(launchConsistencyWindow, cache.DefaultCleanupInterval),
 	}
 }
 
-func (p *DefaultProvider) Create(ctx context.Context, nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) (*Instance, error) {
+func (p *DefaultProvider) Create(ctx context.Context, nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) (_ *Instance, err error) {
+	ctx, span := tracing.Start(ctx, "InstanceProvider.Create", tracing.AttributeNodeClaim.String(nodeClaim.Name), tracing.AttributeNodeClass.String(nodeClass.Name))
+	defer func() { tracing.End(span, err) }()
+
+	// NodeClaims that are created for instances that were launched outside of Karpenter are linked to their instance
+	if instanceID, ok := nodeClaim.Annotations[v1.AnnotationAdoptedInstance]; ok {
+		return p.adoptExternal(ctx, nodeClass, nodeClaim, instanceID)
+	}
+	// The instance of a launch whose NodeClaim wasn't updated before the controller restarted is adopted rather than
+	// launching another one
+	launch, err := p.launchJournal.Get(ctx, nodeClaim)
+	if err != nil {
+		return nil, fmt.Errorf("getting journaled launch, %w", err)
+	}
+	if instance, err := p.adopt(ctx, launch); err != nil || instance != nil {
+		return instance, err
+	}
+	decision := audit.NewLaunchDecision(nodeClaim, nodeClass.Name)
+	defer func() { audit.RecordLaunch(ctx, decision, err) }()
+
+	if nodeClaim, err = applyLaunchOverrides(ctx, nodeClaim); err != nil {
+		return nil, fmt.Errorf("applying launch overrides, %w", err)
+	}
+	overriddenInstanceTypes := filterLaunchOverrides(nodeClaim, instanceTypes)
+	decision.Filter(instanceTypes, overriddenInstanceTypes, audit.ReasonLaunchOverride)
+	if len(overriddenInstanceTypes) == 0 {
+		return nil, cloudprovider.NewInsufficientCapacityError(fmt.Errorf("no instance type has an available offering that matches the launch overrides"))
+	}
+	instanceTypes = overriddenInstanceTypes
 	schedulingRequirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
 	// Only filter the instances if there are no minValues in the requirement.
 	if !schedulingRequirements.HasMinValues() {
-		instanceTypes = p.filterInstanceTypes(nodeClaim, instanceTypes)
+		instanceTypes = p.filterInstanceTypes(ctx, nodeClaim, instanceTypes, decision)
+	}
+	// Instance types are excluded for pod density regardless of minValues, since their pods would never start
+	supportedInstanceTypes, err := p.filterPodDensity(ctx, nodeClass, instanceTypes)
+	if err != nil {
+		return nil, err
+	}
+	decision.Filter(instanceTypes, supportedInstanceTypes, audit.ReasonPodDensity)
+	if len(supportedInstanceTypes) == 0 {
+		return nil, cloudprovider.NewInsufficientCapacityError(fmt.Errorf("no subnet has enough free IP addresses for the max pods of the instance types"))
 	}
-	instanceTypes, err := cloudprovider.InstanceTypes(instanceTypes).Truncate(schedulingRequirements, maxInstanceTypes)
+	instanceTypes = supportedInstanceTypes
+	truncatedInstanceTypes, err := cloudprovider.InstanceTypes(instanceTypes).Truncate(schedulingRequirements, maxInstanceTypes)
 	if err != nil {
 		return nil, fmt.Errorf("truncating instance types, %w", err)
 	}
-	tags := getTags(ctx, nodeClass, nodeClaim)
-	fleetInstance, err := p.launchInstance(ctx, nodeClass, nodeClaim, instanceTypes, tags)
+	decision.Filter(instanceTypes, truncatedInstanceTypes, audit.ReasonTruncated)
+	instanceTypes = truncatedInstanceTypes
+	decision.Consider(instanceTypes)
+	tags, err := Tags(ctx, nodeClass, nodeClaim)
+	if err != nil {
+		return nil, fmt.Errorf("resolving tags, %w", err)
+	}
+	clientToken, err := p.beginLaunch(ctx, nodeClaim, launch)
+	if err != nil {
+		return nil, err
+	}
+	fleetInstance, spotPools, err := p.launchInstance(ctx, nodeClass, nodeClaim, instanceTypes, tags, clientToken, decision)
 	if awserrors.IsLaunchTemplateNotFound(err) {
 		// retry once if launch template is not found. This allows karpenter to generate a new LT if the
 		// cache was out-of-sync on the first try
-		fleetInstance, err = p.launchInstance(ctx, nodeClass, nodeClaim, instanceTypes, tags)
+		fleetInstance, spotPools, err = p.launchInstance(ctx, nodeClass, nodeClaim, instanceTypes, tags, clientToken, decision)
+	}
+	if awserrors.IsIdempotentParameterMismatch(err) {
+		// The launch that was issued again after a restart resolved to different parameters, so the instance that it
+		// may have launched can't be adopted and is left to garbage collection. The next attempt uses a new token.
+		if err := p.launchJournal.Remove(ctx, nodeClaim.Name); err != nil {
+			log.FromContext(ctx).Error(err, "failed removing journaled launch")
+		}
 	}
 	if err != nil {
 		return nil, err
 	}
 	efaEnabled := lo.Contains(lo.Keys(nodeClaim.Spec.Resources.Requests), v1.ResourceEFA)
-	return NewInstanceFromFleet(fleetInstance, tags, efaEnabled), nil
+	instance := NewInstanceFromFleet(fleetInstance, tags, efaEnabled)
+	p.launched.SetDefault(instance.ID, nil)
+	instance.SpotPools = spotPools
+	// The source/destination check can't be disabled by the launch template, so it's disabled as soon as the instance
+	// is launched. Failing to disable it doesn't fail the launch, since it's disabled again when the instance is tagged.
+	if nodeClass.Spec.PrimaryNetworkInterface != nil && !lo.FromPtrOr(nodeClass.Spec.PrimaryNetworkInterface.SourceDestCheck, true) {
+		if err := p.DisableSourceDestCheck(ctx, instance.ID); err != nil {
+			log.FromContext(ctx).WithValues("instance-id", instance.ID).Error(err, "failed disabling source/destination check")
+		} else {
+			instance.SourceDestCheck = false
+		}
+	}
+	decision.Choose(instance.ID, instance.Type, instance.Zone, instance.CapacityType)
+	// The launch is journaled until the NodeClaim is updated with the instance. Failing to journal it doesn't fail the
+	// launch, since the instance would be leaked until it's garbage collected.
+	if err = p.launchJournal.Record(ctx, nodeClaim, instance.ID); err != nil {
+		log.FromContext(ctx).WithValues("instance-id", instance.ID).Error(err, "failed journaling launch")
+	}
+	return instance, nil
 }
 
-func (p *DefaultProvider) Get(ctx context.Context, id string) (*Instance, error) {
-	out, err := p.ec2Batcher.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
-		InstanceIds: aws.StringSlice([]string{id}),
-		Filters:     []*ec2.Filter{instanceStateFilter},
+// adopt returns the instance of the journaled launch of the NodeClaim, if it's still running
+func (p *DefaultProvider) adopt(ctx context.Context, launch *launchjournal.Launch) (*Instance, error) {
+	if launch == nil || launch.InstanceID == "" {
+		return nil, nil
+	}
+	instance, err := p.getRunning(ctx, launch.InstanceID, launch.LaunchTime)
+	// The NodeClaim is launched again if the instance was terminated
+	if err != nil || instance == nil {
+		return nil, err
+	}
+	log.FromContext(ctx).WithValues("instance-id", instance.ID).Info("adopted instance of an interrupted launch")
+	return instance, nil
+}
+
+// beginLaunch journals the launch of the NodeClaim before its CreateFleet request is issued and returns the client
+// token of the request. A launch whose instance wasn't journaled before the controller restarted is issued again with
+// its token, so that EC2 returns the instance that it launched rather than launching another one.
+func (p *DefaultProvider) beginLaunch(ctx context.Context, nodeClaim *karpv1.NodeClaim, launch *launchjournal.Launch) (string, error) {
+	if launch != nil && launch.InstanceID == "" && launch.ClientToken != "" {
+		log.FromContext(ctx).WithValues("client-token", launch.ClientToken).Info("issuing interrupted launch again")
+		return launch.ClientToken, nil
+	}
+	// Tokens are unique per attempt, since EC2 returns the response of the first request for every request with the
+	// same token
+	clientToken := fmt.Sprintf("%s-%d", nodeClaim.UID, time.Now().UnixNano())
+	if err := p.launchJournal.Begin(ctx, nodeClaim, clientToken); err != nil {
+		return "", fmt.Errorf("journaling launch, %w", err)
+	}
+	return clientToken, nil
+}
+
+// adoptExternal returns an instance that was launched outside of Karpenter for the NodeClaim that was created for it,
+// and tags it like the instances that Karpenter launches so that it's listed and garbage collected with them
+func (p *DefaultProvider) adoptExternal(ctx context.Context, nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim, instanceID string) (*Instance, error) {
+	instance, err := p.getRunning(ctx, instanceID, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	if instance == nil {
+		// The NodeClaim is deleted rather than launching an instance in place of the one that it adopts
+		return nil, cloudprovider.NewInsufficientCapacityError(fmt.Errorf("adopted instance %s isn't running", instanceID))
+	}
+	tags, err := Tags(ctx, nodeClass, nodeClaim)
+	if err != nil {
+		return nil, fmt.Errorf("resolving tags, %w", err)
+	}
+	if err = p.CreateTags(ctx, instance.ID, tags); err != nil {
+		return nil, err
+	}
+	instance.Tags = lo.Assign(instance.Tags, tags)
+	log.FromContext(ctx).WithValues("instance-id", instance.ID).Info("adopted instance")
+	return instance, nil
+}
+
+// getRunning returns the instance if it's pending or running. An instance that isn't found within the launch
+// consistency window of its launch time returns an error, so that it isn't launched again while it's not visible yet.
+func (p *DefaultProvider) getRunning(ctx context.Context, id string, launchTime time.Time) (*Instance, error) {
+	out, err := p.describeInstance(ctx, id, launchTime, &ec2.Filter{
+		Name:   aws.String("instance-state-name"),
+		Values: aws.StringSlice([]string{ec2.InstanceStateNamePending, ec2.InstanceStateNameRunning}),
 	})
+	if awserrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("describing instance %s, %w", id, err)
+	}
+	instances, err := instancesFromOutput(out)
+	if err != nil || len(instances) != 1 {
+		return nil, nil
+	}
+	return instances[0], nil
+}
+
+func (p *DefaultProvider) Get(ctx context.Context, id string) (*Instance, error) {
+	out, err := p.describeInstance(ctx, id, time.Time{}, instanceStateFilter)
 	if awserrors.IsNotFound(err) {
 		return nil, cloudprovider.NewNodeClaimNotFoundError(err)
 	}
@@ -137,6 +307,41 @@ func (p *DefaultProvider) Get(ctx context.Context, id string) (*Instance, error)
 	return instances[0], nil
 }
 
+// describeInstance describes an instance. The EC2 API is eventually consistent, so an instance isn't always found right
+// after CreateFleet returned it. Instances that were launched within the launch consistency window are described again
+// a bounded number of times when they aren't found, and an error that isn't a not found error is returned if they still
+// aren't, so that a freshly launched instance isn't considered gone and leaked.
+func (p *DefaultProvider) describeInstance(ctx context.Context, id string, launchTime time.Time, filter *ec2.Filter) (*ec2.DescribeInstancesOutput, error) {
+	input := &ec2.DescribeInstancesInput{
+		InstanceIds: aws.StringSlice([]string{id}),
+		Filters:     []*ec2.Filter{filter},
+	}
+	out, err := p.ec2Batcher.DescribeInstances(ctx, input)
+	if !awserrors.IsNotFound(err) || !p.recentlyLaunched(id, launchTime) {
+		return out, err
+	}
+	for attempt := 1; attempt <= launchConsistencyRetries && awserrors.IsNotFound(err); attempt++ {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Duration(attempt) * launchConsistencyRetryDelay):
+		}
+		out, err = p.ec2Batcher.DescribeInstances(ctx, input)
+	}
+	if awserrors.IsNotFound(err) {
+		return nil, fmt.Errorf("instance %s of a recent launch isn't visible yet, %s", id, err)
+	}
+	return out, err
+}
+
+// recentlyLaunched returns true if the instance was launched within the launch consistency window
+func (p *DefaultProvider) recentlyLaunched(id string, launchTime time.Time) bool {
+	if _, ok := p.launched.Get(id); ok {
+		return true
+	}
+	return time.Since(launchTime) < launchConsistencyWindow
+}
+
 func (p *DefaultProvider) List(ctx context.Context) ([]*Instance, error) {
 	var out = &ec2.DescribeInstancesOutput{}
 	err := p.ec2api.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{
@@ -171,6 +376,10 @@ func (p *DefaultProvider) Delete(ctx context.Context, id string) error {
 		InstanceIds: []*string{aws.String(id)},
 	}); err != nil {
 		if awserrors.IsNotFound(err) {
+			// The instance of a recent launch may not be visible yet, so it's terminated again rather than leaked
+			if p.recentlyLaunched(id, time.Time{}) {
+				return fmt.Errorf("terminating instance %s of a recent launch that isn't visible yet, %w", id, err)
+			}
 			return cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("instance already terminated"))
 		}
 		if _, e := p.Get(ctx, id); e != nil {
@@ -200,17 +409,72 @@ func (p *DefaultProvider) CreateTags(ctx context.Context, id string, tags map[st
 	return nil
 }
 
-func (p *DefaultProvider) launchInstance(ctx context.Context, nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType, tags map[string]string) (*ec2.CreateFleetInstance, error) {
+// DisableSourceDestCheck lets the instance send and receive the traffic that it isn't the source or destination of
+func (p *DefaultProvider) DisableSourceDestCheck(ctx context.Context, id string) error {
+	if _, err := p.ec2api.ModifyInstanceAttributeWithContext(ctx, &ec2.ModifyInstanceAttributeInput{
+		InstanceId:      aws.String(id),
+		SourceDestCheck: &ec2.AttributeBooleanValue{Value: aws.Bool(false)},
+	}); err != nil {
+		if awserrors.IsNotFound(err) {
+			return cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("disabling source/destination check, %w", err))
+		}
+		return fmt.Errorf("disabling source/destination check, %w", err)
+	}
+	return nil
+}
+
+// IsTerminationProtected returns whether the instance can't be terminated until its disableApiTermination attribute
+// is cleared
+func (p *DefaultProvider) IsTerminationProtected(ctx context.Context, id string) (bool, error) {
+	out, err := p.ec2api.DescribeInstanceAttributeWithContext(ctx, &ec2.DescribeInstanceAttributeInput{
+		InstanceId: aws.String(id),
+		Attribute:  aws.String(ec2.InstanceAttributeNameDisableApiTermination),
+	})
+	if err != nil {
+		if awserrors.IsNotFound(err) {
+			return false, cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("describing termination protection, %w", err))
+		}
+		return false, fmt.Errorf("describing termination protection, %w", err)
+	}
+	return out.DisableApiTermination != nil && aws.BoolValue(out.DisableApiTermination.Value), nil
+}
+
+// SetTerminationProtection sets the disableApiTermination attribute of the instance, which stops it from being
+// terminated through the API, e.g. by TerminateInstances, while it's set
+func (p *DefaultProvider) SetTerminationProtection(ctx context.Context, id string, protected bool) error {
+	if _, err := p.ec2api.ModifyInstanceAttributeWithContext(ctx, &ec2.ModifyInstanceAttributeInput{
+		InstanceId:            aws.String(id),
+		DisableApiTermination: &ec2.AttributeBooleanValue{Value: aws.Bool(protected)},
+	}); err != nil {
+		if awserrors.IsNotFound(err) {
+			return cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("setting termination protection, %w", err))
+		}
+		return fmt.Errorf("setting termination protection, %w", err)
+	}
+	return nil
+}
+
+func (p *DefaultProvider) launchInstance(ctx context.Context, nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType, tags map[string]string, clientToken string, decision *audit.LaunchDecision) (_ *ec2.CreateFleetInstance, spotPools int, err error) {
+	ctx, span := tracing.Start(ctx, "InstanceProvider.launchInstance")
+	defer func() { tracing.End(span, err) }()
+
 	capacityType := p.getCapacityType(nodeClaim, instanceTypes)
+	decision.CapacityType = capacityType
+	span.SetAttributes(tracing.AttributeCapacityType.String(capacityType), tracing.AttributeInstanceTypeCount.Int(len(instanceTypes)))
 	zonalSubnets, err := p.subnetProvider.ZonalSubnetsForLaunch(ctx, nodeClass, instanceTypes, capacityType)
 	if err != nil {
-		return nil, fmt.Errorf("getting subnets, %w", err)
+		return nil, 0, fmt.Errorf("getting subnets, %w", err)
 	}
+	zonalSubnets = p.avoidImpairedZones(nodeClaim, instanceTypes, zonalSubnets, capacityType)
 
 	// Get Launch Template Configs, which may differ due to GPU or Architecture requirements
 	launchTemplateConfigs, err := p.getLaunchTemplateConfigs(ctx, nodeClass, nodeClaim, instanceTypes, zonalSubnets, capacityType, tags)
 	if err != nil {
-		return nil, fmt.Errorf("getting launch template configs, %w", err)
+		return nil, 0, fmt.Errorf("getting launch template configs, %w", err)
+	}
+	if capacityType == karpv1.CapacityTypeSpot {
+		launchTemplateConfigs = p.avoidInterruptedPools(ctx, nodeClaim, launchTemplateConfigs)
+		spotPools = countPools(launchTemplateConfigs)
 	}
 	if err := p.checkODFallback(nodeClaim, instanceTypes, launchTemplateConfigs); err != nil {
 		log.FromContext(ctx).Error(err, "failed while checking on-demand fallback")
@@ -218,6 +482,7 @@ func (p *DefaultProvider) launchInstance(ctx context.Context, nodeClass *v1.EC2N
 	// Create fleet
 	createFleetInput := &ec2.CreateFleetInput{
 		Type:                  aws.String(ec2.FleetTypeInstant),
+		ClientToken:           aws.String(clientToken),
 		Context:               nodeClass.Spec.Context,
 		LaunchTemplateConfigs: launchTemplateConfigs,
 		TargetCapacitySpecification: &ec2.TargetCapacitySpecificationRequest{
@@ -233,39 +498,77 @@ func (p *DefaultProvider) launchInstance(ctx context.Context, nodeClass *v1.EC2N
 	if capacityType == karpv1.CapacityTypeSpot {
 		createFleetInput.SpotOptions = &ec2.SpotOptionsRequest{AllocationStrategy: aws.String(ec2.SpotAllocationStrategyPriceCapacityOptimized)}
 	} else {
-		createFleetInput.OnDemandOptions = &ec2.OnDemandOptionsRequest{AllocationStrategy: aws.String(ec2.FleetOnDemandAllocationStrategyLowestPrice)}
+		createFleetInput.OnDemandOptions = &ec2.OnDemandOptionsRequest{
+			AllocationStrategy: aws.String(ec2.FleetOnDemandAllocationStrategyLowestPrice),
+			// Unused open capacity reservations, like the ones that are maintained for the capacity floors of NodePools,
+			// are launched into before the lowest priced pools
+			CapacityReservationOptions: &ec2.CapacityReservationOptionsRequest{
+				UsageStrategy: aws.String(ec2.FleetCapacityReservationUsageStrategyUseCapacityReservationsFirst),
+			},
+		}
 	}
 
+	start := time.Now()
 	createFleetOutput, err := p.ec2Batcher.CreateFleet(ctx, createFleetInput)
+	createFleetDuration := time.Since(start)
 	p.subnetProvider.UpdateInflightIPs(createFleetInput, createFleetOutput, instanceTypes, lo.Values(zonalSubnets), capacityType)
 	if err != nil {
 		if awserrors.IsLaunchTemplateNotFound(err) {
 			for _, lt := range launchTemplateConfigs {
 				p.launchTemplateProvider.InvalidateCache(ctx, aws.StringValue(lt.LaunchTemplateSpecification.LaunchTemplateName), aws.StringValue(lt.LaunchTemplateSpecification.LaunchTemplateId))
 			}
-			return nil, fmt.Errorf("creating fleet %w", err)
+			return nil, 0, fmt.Errorf("creating fleet %w", err)
 		}
 		var reqFailure awserr.RequestFailure
 		if errors.As(err, &reqFailure) {
-			return nil, fmt.Errorf("creating fleet %w (%s)", err, reqFailure.RequestID())
+			return nil, 0, fmt.Errorf("creating fleet %w (%s)", err, reqFailure.RequestID())
 		}
-		return nil, fmt.Errorf("creating fleet %w", err)
+		return nil, 0, fmt.Errorf("creating fleet %w", err)
 	}
 	p.updateUnavailableOfferingsCache(ctx, createFleetOutput.Errors, capacityType)
+	recordFleetErrors(createFleetOutput.Errors, capacityType)
+	decision.FleetResponse(createFleetOutput.Errors)
 	if len(createFleetOutput.Instances) == 0 || len(createFleetOutput.Instances[0].InstanceIds) == 0 {
-		return nil, combineFleetErrors(createFleetOutput.Errors)
+		// EC2 returns the same response for the token, so the launch is journaled again with a new one when it's retried
+		if err := p.launchJournal.Remove(ctx, nodeClaim.Name); err != nil {
+			log.FromContext(ctx).Error(err, "failed removing journaled launch")
+		}
+		return nil, 0, combineFleetErrors(createFleetOutput.Errors)
+	}
+	fleetInstance := createFleetOutput.Instances[0]
+	var zone string
+	if fleetInstance.LaunchTemplateAndOverrides != nil && fleetInstance.LaunchTemplateAndOverrides.Overrides != nil {
+		zone = aws.StringValue(fleetInstance.LaunchTemplateAndOverrides.Overrides.AvailabilityZone)
+	}
+	LaunchPhaseDurationSeconds.With(LaunchPhaseLabels(
+		LaunchPhaseCreateFleet,
+		nodeClass.AMIFamily(),
+		strings.Split(aws.StringValue(fleetInstance.InstanceType), ".")[0],
+		zone,
+	)).Observe(createFleetDuration.Seconds())
+	if capacityType == karpv1.CapacityTypeSpot {
+		SpotPools.With(SpotPoolsLabels(nodeClaim.Labels[karpv1.NodePoolLabelKey])).Observe(float64(spotPools))
 	}
-	return createFleetOutput.Instances[0], nil
+	return fleetInstance, spotPools, nil
 }
 
-func getTags(ctx context.Context, nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim) map[string]string {
+// Tags returns the tags of the instance and launch templates of a NodeClaim. The NodePool of the NodeClaim is taken from
+// the context to resolve templated tags.
+func Tags(ctx context.Context, nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim) (map[string]string, error) {
 	staticTags := map[string]string{
 		fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName): "owned",
 		karpv1.NodePoolLabelKey:       nodeClaim.Labels[karpv1.NodePoolLabelKey],
 		karpv1.ManagedByAnnotationKey: options.FromContext(ctx).ClusterName,
 		v1.LabelNodeClass:             nodeClass.Name,
 	}
-	return lo.Assign(nodeClass.Spec.Tags, staticTags)
+	if nodeClass.Spec.SSMAgent != nil && lo.FromPtr(nodeClass.Spec.SSMAgent.SessionManager) {
+		staticTags[v1.TagSessionManager] = "true"
+	}
+	tags, err := resolveTags(nodeClass.Spec.Tags, nodePoolFromContext(ctx), nodeClaim)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Assign(tags, staticTags), nil
 }
 
 func (p *DefaultProvider) checkODFallback(nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType, launchTemplateConfigs []*ec2.FleetLaunchTemplateConfigRequest) error {
@@ -358,6 +661,62 @@ func (p *DefaultProvider) getOverrides(instanceTypes []*cloudprovider.InstanceTy
 	return overrides
 }
 
+// avoidImpairedZones drops the subnets in impaired zones from the launch, as long as one of the instance types can still
+// be launched into a zone that isn't impaired. Impaired zones are only de-prioritized, so they're still used when
+// they're the only zones that can satisfy the NodeClaim.
+func (p *DefaultProvider) avoidImpairedZones(nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType,
+	zonalSubnets map[string]*subnet.Subnet, capacityType string) map[string]*subnet.Subnet {
+	healthy := lo.OmitBy(zonalSubnets, func(zone string, _ *subnet.Subnet) bool { return p.impairedZones.IsImpaired(zone) })
+	if len(healthy) == len(zonalSubnets) {
+		return zonalSubnets
+	}
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	requirements[karpv1.CapacityTypeLabelKey] = scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, capacityType)
+	launchable := lo.SomeBy(instanceTypes, func(it *cloudprovider.InstanceType) bool {
+		return lo.SomeBy(it.Offerings.Available(), func(of cloudprovider.Offering) bool {
+			_, ok := healthy[of.Requirements.Get(corev1.LabelTopologyZone).Any()]
+			return ok && requirements.Compatible(of.Requirements, scheduling.AllowUndefinedWellKnownLabels) == nil
+		})
+	})
+	if !launchable {
+		return zonalSubnets
+	}
+	return healthy
+}
+
+// avoidInterruptedPools drops the spot pools whose recent interruptions weigh at least the spot-interruption-threshold
+// of the NodePool from the launch. NodePools opt in by setting the annotation in their template. Like impaired zones,
+// interrupted pools are only de-prioritized, so they're still used when they're the only pools that can be launched.
+func (p *DefaultProvider) avoidInterruptedPools(ctx context.Context, nodeClaim *karpv1.NodeClaim,
+	launchTemplateConfigs []*ec2.FleetLaunchTemplateConfigRequest) []*ec2.FleetLaunchTemplateConfigRequest {
+	value, ok := nodeClaim.Annotations[v1.AnnotationSpotInterruptionThreshold]
+	if !ok {
+		return launchTemplateConfigs
+	}
+	threshold, err := strconv.ParseFloat(value, 64)
+	if err != nil || threshold <= 0 {
+		log.FromContext(ctx).Error(fmt.Errorf("%q is not a positive number", value), fmt.Sprintf("ignoring %s", v1.AnnotationSpotInterruptionThreshold))
+		return launchTemplateConfigs
+	}
+	var avoided []string
+	healthy := lo.FilterMap(launchTemplateConfigs, func(config *ec2.FleetLaunchTemplateConfigRequest, _ int) (*ec2.FleetLaunchTemplateConfigRequest, bool) {
+		overrides := lo.Filter(config.Overrides, func(override *ec2.FleetLaunchTemplateOverridesRequest, _ int) bool {
+			instanceType, zone := aws.StringValue(override.InstanceType), aws.StringValue(override.AvailabilityZone)
+			if p.spotInterruptions.Weight(instanceType, zone) < threshold {
+				return true
+			}
+			avoided = append(avoided, fmt.Sprintf("%s/%s", instanceType, zone))
+			return false
+		})
+		return &ec2.FleetLaunchTemplateConfigRequest{LaunchTemplateSpecification: config.LaunchTemplateSpecification, Overrides: overrides}, len(overrides) != 0
+	})
+	if len(avoided) == 0 || len(healthy) == 0 {
+		return launchTemplateConfigs
+	}
+	log.FromContext(ctx).WithValues("pools", utils.PrettySlice(lo.Uniq(avoided), 5)).V(1).Info("avoiding interrupted spot pools")
+	return healthy
+}
+
 func (p *DefaultProvider) updateUnavailableOfferingsCache(ctx context.Context, errors []*ec2.CreateFleetError, capacityType string) {
 	for _, err := range errors {
 		if awserrors.IsUnfulfillableCapacity(err) {
@@ -366,6 +725,29 @@ func (p *DefaultProvider) updateUnavailableOfferingsCache(ctx context.Context, e
 	}
 }
 
+// recordFleetErrors counts the errors of the pools that CreateFleet couldn't launch into, so that insufficient capacity,
+// quota and constraint errors can be told apart over time
+func recordFleetErrors(errors []*ec2.CreateFleetError, capacityType string) {
+	for _, err := range errors {
+		var instanceType, zone string
+		if err.LaunchTemplateAndOverrides != nil && err.LaunchTemplateAndOverrides.Overrides != nil {
+			instanceType = aws.StringValue(err.LaunchTemplateAndOverrides.Overrides.InstanceType)
+			zone = aws.StringValue(err.LaunchTemplateAndOverrides.Overrides.AvailabilityZone)
+		}
+		FleetErrorsTotal.With(FleetErrorLabels(aws.StringValue(err.ErrorCode), instanceType, zone, capacityType)).Inc()
+	}
+}
+
+// countPools returns the number of distinct pools, the pairs of instance type and zone, of the overrides of a launch.
+// Subnets in the same zone launch into the same pool.
+func countPools(launchTemplateConfigs []*ec2.FleetLaunchTemplateConfigRequest) int {
+	return len(lo.Uniq(lo.FlatMap(launchTemplateConfigs, func(config *ec2.FleetLaunchTemplateConfigRequest, _ int) []string {
+		return lo.Map(config.Overrides, func(override *ec2.FleetLaunchTemplateOverridesRequest, _ int) string {
+			return fmt.Sprintf("%s/%s", aws.StringValue(override.InstanceType), aws.StringValue(override.AvailabilityZone))
+		})
+	})))
+}
+
 // getCapacityType selects spot if both constraints are flexible and there is an
 // available offering. The AWS Cloud Provider defaults to [ on-demand ], so spot
 // must be explicitly included in capacity type requirements.
@@ -386,12 +768,19 @@ func (p *DefaultProvider) getCapacityType(nodeClaim *karpv1.NodeClaim, instanceT
 
 // filterInstanceTypes is used to provide filtering on the list of potential instance types to further limit it to those
 // that make the most sense given our specific AWS cloudprovider.
-func (p *DefaultProvider) filterInstanceTypes(nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
-	instanceTypes = filterExoticInstanceTypes(instanceTypes)
+func (p *DefaultProvider) filterInstanceTypes(ctx context.Context, nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType, decision *audit.LaunchDecision) []*cloudprovider.InstanceType {
+	genericInstanceTypes := filterExoticInstanceTypes(instanceTypes)
+	decision.Filter(instanceTypes, genericInstanceTypes, audit.ReasonExoticInstanceType)
+	instanceTypes = genericInstanceTypes
+	preferredInstanceTypes := filterPreferredArchitecture(ctx, nodeClaim, instanceTypes)
+	decision.Filter(instanceTypes, preferredInstanceTypes, audit.ReasonArchitecture)
+	instanceTypes = preferredInstanceTypes
 	// If we could potentially launch either a spot or on-demand node, we want to filter out the spot instance types that
 	// are more expensive than the cheapest on-demand type.
 	if p.isMixedCapacityLaunch(nodeClaim, instanceTypes) {
-		instanceTypes = filterUnwantedSpot(instanceTypes)
+		wantedInstanceTypes := filterUnwantedSpot(instanceTypes)
+		decision.Filter(instanceTypes, wantedInstanceTypes, audit.ReasonUnwantedSpot)
+		instanceTypes = wantedInstanceTypes
 	}
 	return instanceTypes
 }
@@ -450,6 +839,73 @@ func filterUnwantedSpot(instanceTypes []*cloudprovider.InstanceType) []*cloudpro
 	return instanceTypes
 }
 
+// filterPodDensity excludes the instance types whose max pods needs more IP addresses than the subnet of the EC2NodeClass
+// with the most free IP addresses has, when the EC2NodeClass describes its VPC CNI. Each pod is assigned an address in
+// addition to the primary address of the node, or a prefix of 16 addresses is assigned for every 16 pods with prefix
+// delegation. Pods on IPv6 nodes are assigned addresses from a prefix of the node, so they aren't limited.
+func (p *DefaultProvider) filterPodDensity(ctx context.Context, nodeClass *v1.EC2NodeClass, instanceTypes []*cloudprovider.InstanceType) ([]*cloudprovider.InstanceType, error) {
+	if nodeClass.Spec.VPCCNI == nil || lo.FromPtr(lo.FromPtr(nodeClass.Spec.Kubelet).NodeIPFamily) == v1.NodeIPFamilyIPv6 {
+		return instanceTypes, nil
+	}
+	subnets, err := p.subnetProvider.List(ctx, nodeClass)
+	if err != nil {
+		return nil, fmt.Errorf("listing subnets, %w", err)
+	}
+	if len(subnets) == 0 {
+		return instanceTypes, nil
+	}
+	maxAvailable := lo.Max(lo.Map(subnets, func(s *ec2.Subnet, _ int) int64 { return lo.FromPtr(s.AvailableIpAddressCount) }))
+	return lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		pods := it.Capacity.Pods().Value()
+		if lo.FromPtr(nodeClass.Spec.VPCCNI.PrefixDelegation) {
+			pods = (pods + 15) / 16 * 16
+		}
+		return pods+1 <= maxAvailable
+	}), nil
+}
+
+// filterPreferredArchitecture keeps the instance types of the first architecture in the architecture-preference of the
+// NodePool that any of the instance types have. NodePools opt in by setting the annotation in their template, and pods
+// opt in by declaring the architectures that their images support with a kubernetes.io/arch requirement. Karpenter
+// can't tell which architectures an image is built for, so the preference isn't applied to NodeClaims whose pods don't
+// constrain the architecture. The instance types are already compatible with the NodeClaim, so the preference only
+// chooses between the architectures that its pods can run on. Like exotic instance types, the other architectures are
+// only de-prioritized, so they're used when they're all that's left, e.g. once the offerings of the preferred
+// architecture are unavailable.
+func filterPreferredArchitecture(ctx context.Context, nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
+	value, ok := nodeClaim.Annotations[v1.AnnotationArchitecturePreference]
+	if !ok || !podsConstrainArchitecture(ctx, nodeClaim) {
+		return instanceTypes
+	}
+	architectures := lo.Map(strings.Split(value, ","), func(architecture string, _ int) string { return strings.TrimSpace(architecture) })
+	if unknown, ok := lo.Find(architectures, func(architecture string) bool {
+		return architecture != karpv1.ArchitectureAmd64 && architecture != karpv1.ArchitectureArm64
+	}); ok {
+		log.FromContext(ctx).Error(fmt.Errorf("%q is not a supported architecture", unknown), fmt.Sprintf("ignoring %s", v1.AnnotationArchitecturePreference))
+		return instanceTypes
+	}
+	for _, architecture := range architectures {
+		preferred := lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+			return it.Requirements.Get(corev1.LabelArchStable).Has(architecture)
+		})
+		if len(preferred) != 0 {
+			return preferred
+		}
+	}
+	return instanceTypes
+}
+
+// podsConstrainArchitecture returns true if the kubernetes.io/arch requirement of the NodeClaim comes from its pods. The
+// requirements of the NodeClaim merge the requirements of its NodePool with the ones of its pods, so the requirement is
+// only attributed to the pods when the NodePool doesn't have one.
+func podsConstrainArchitecture(ctx context.Context, nodeClaim *karpv1.NodeClaim) bool {
+	if nodePool := nodePoolFromContext(ctx); nodePool != nil &&
+		scheduling.NewNodeSelectorRequirementsWithMinValues(nodePool.Spec.Template.Spec.Requirements...).Has(corev1.LabelArchStable) {
+		return false
+	}
+	return scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...).Has(corev1.LabelArchStable)
+}
+
 // filterExoticInstanceTypes is used to eliminate less desirable instance types (like GPUs) from the list of possible instance types when
 // a set of more appropriate instance types would work. If a set of more desirable instance types is not found, then the original slice
 // of instance types are returned.
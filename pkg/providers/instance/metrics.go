@@ -0,0 +1,97 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	launchSubsystem = "cloudprovider_launch"
+
+	phaseLabel          = "phase"
+	amiFamilyLabel      = "ami_family"
+	instanceFamilyLabel = "instance_family"
+	zoneLabel           = "zone"
+	errorCodeLabel      = "error_code"
+	instanceTypeLabel   = "instance_type"
+	capacityTypeLabel   = "capacity_type"
+
+	// LaunchPhaseCreateFleet is the time spent in the (batched) CreateFleet call
+	LaunchPhaseCreateFleet = "create_fleet"
+	// LaunchPhaseRunning is the time from launch until the instance is reported as running by EC2
+	LaunchPhaseRunning = "running"
+	// LaunchPhaseRegistered is the time from launch until the kubelet registered the node
+	LaunchPhaseRegistered = "registered"
+	// LaunchPhaseReady is the time from launch until the node is initialized and ready for pods
+	LaunchPhaseReady = "ready"
+)
+
+var (
+	LaunchPhaseDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: launchSubsystem,
+		Name:      "phase_duration_seconds",
+		Help:      "Duration of each phase of a node launch in seconds. Phases other than create_fleet are measured from the time the instance was launched.",
+		Buckets:   metrics.DurationBuckets(),
+	}, []string{phaseLabel, amiFamilyLabel, instanceFamilyLabel, zoneLabel})
+	FleetErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: launchSubsystem,
+		Name:      "fleet_errors_total",
+		Help:      "Number of errors that CreateFleet returned for the pools that it couldn't launch into, by error code and pool. Errors are counted for launches that partially fail as well as for launches that fail.",
+	}, []string{errorCodeLabel, instanceTypeLabel, zoneLabel, capacityTypeLabel})
+	SpotPools = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: launchSubsystem,
+		Name:      "spot_pools",
+		Help:      "Number of distinct spot pools, the pairs of instance type and zone, that spot launches diversified across, by NodePool. Launches that are constrained to few pools are interrupted more often.",
+		Buckets:   []float64{1, 2, 3, 5, 10, 15, 20, 30, 50, 100},
+	}, []string{metrics.NodePoolLabel})
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(LaunchPhaseDurationSeconds, FleetErrorsTotal, SpotPools)
+}
+
+// LaunchPhaseLabels returns the labels of the launch phase duration metric
+func LaunchPhaseLabels(phase, amiFamily, instanceFamily, zone string) prometheus.Labels {
+	return prometheus.Labels{
+		phaseLabel:          phase,
+		amiFamilyLabel:      amiFamily,
+		instanceFamilyLabel: instanceFamily,
+		zoneLabel:           zone,
+	}
+}
+
+// FleetErrorLabels returns the labels of the fleet errors metric
+func FleetErrorLabels(errorCode, instanceType, zone, capacityType string) prometheus.Labels {
+	return prometheus.Labels{
+		errorCodeLabel:    errorCode,
+		instanceTypeLabel: instanceType,
+		zoneLabel:         zone,
+		capacityTypeLabel: capacityType,
+	}
+}
+
+// SpotPoolsLabels returns the labels of the spot pools metric
+func SpotPoolsLabels(nodePool string) prometheus.Labels {
+	return prometheus.Labels{
+		metrics.NodePoolLabel: nodePool,
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+)
+
+// launchOverrides are the annotations that force the capacity type, zone or instance family of the launch of a
+// NodeClaim, mapped to the requirement that they narrow
+var launchOverrides = []struct{ annotation, key string }{
+	{annotation: v1.AnnotationLaunchCapacityType, key: karpv1.CapacityTypeLabelKey},
+	{annotation: v1.AnnotationLaunchZone, key: corev1.LabelTopologyZone},
+	{annotation: v1.AnnotationLaunchInstanceFamily, key: v1.LabelInstanceFamily},
+}
+
+// applyLaunchOverrides narrows the requirements of a NodeClaim to the launch override annotations that it's created
+// with, for manual provisioning when the NodeClaim needs to land on a specific pool, e.g. while capacity elsewhere is
+// impaired. Overrides can only narrow the NodeClaim within what its NodePool and its own requirements allow, so a
+// NodeClaim can't be forced onto capacity that its NodePool excludes. The returned NodeClaim is a copy when any
+// override is set.
+func applyLaunchOverrides(ctx context.Context, nodeClaim *karpv1.NodeClaim) (*karpv1.NodeClaim, error) {
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	var nodePoolRequirements scheduling.Requirements
+	if nodePool := nodePoolFromContext(ctx); nodePool != nil {
+		nodePoolRequirements = scheduling.NewNodeSelectorRequirementsWithMinValues(nodePool.Spec.Template.Spec.Requirements...)
+	}
+	overridden := false
+	for _, o := range launchOverrides {
+		value, ok := nodeClaim.Annotations[o.annotation]
+		if !ok {
+			continue
+		}
+		overridden = true
+		if nodePoolRequirements != nil && !nodePoolRequirements.Get(o.key).Has(value) {
+			return nil, fmt.Errorf("%s %q isn't allowed by the requirements of the NodePool", o.annotation, value)
+		}
+		if !requirements.Get(o.key).Has(value) {
+			return nil, fmt.Errorf("%s %q isn't allowed by the requirements of the NodeClaim", o.annotation, value)
+		}
+		requirements.Add(scheduling.NewRequirement(o.key, corev1.NodeSelectorOpIn, value))
+	}
+	if !overridden {
+		return nodeClaim, nil
+	}
+	nodeClaim = nodeClaim.DeepCopy()
+	nodeClaim.Spec.Requirements = requirements.NodeSelectorRequirements()
+	return nodeClaim, nil
+}
+
+// filterLaunchOverrides keeps the instance types that have an available offering in the requirements of the NodeClaim
+// once its launch overrides are applied
+func filterLaunchOverrides(nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	return lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		if !it.Requirements.IsCompatible(requirements, scheduling.AllowUndefinedWellKnownLabels) {
+			return false
+		}
+		return it.Offerings.Available().HasCompatible(requirements)
+	})
+}
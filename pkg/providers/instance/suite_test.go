@@ -23,9 +23,11 @@ import (
 	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/awslabs/operatorpkg/object"
 	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
@@ -66,7 +68,7 @@ var _ = BeforeSuite(func() {
 	ctx = options.ToContext(ctx, test.Options())
 	awsEnv = test.NewEnvironment(ctx, env)
 	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
-		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
+		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider, awsEnv.AlertTracker, awsEnv.UnavailableOfferingsCache)
 })
 
 var _ = AfterSuite(func() {
@@ -137,6 +139,667 @@ var _ = Describe("InstanceProvider", func() {
 		Expect(corecloudprovider.IsInsufficientCapacityError(err)).To(BeTrue())
 		Expect(instance).To(BeNil())
 	})
+	It("should count the CreateFleet errors of each pool", func() {
+		nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+			NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeOnDemand}},
+		})
+		ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		awsEnv.EC2API.InsufficientCapacityPools.Set([]fake.CapacityPool{
+			{CapacityType: karpv1.CapacityTypeOnDemand, InstanceType: "m5.xlarge", Zone: "test-zone-1a"},
+		})
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool { return i.Name == "m5.xlarge" })
+
+		instance.FleetErrorsTotal.Reset()
+		_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+		Expect(err).ToNot(HaveOccurred())
+		metric, ok := FindMetricWithLabelValues("karpenter_cloudprovider_launch_fleet_errors_total", map[string]string{
+			"error_code":    "InsufficientInstanceCapacity",
+			"instance_type": "m5.xlarge",
+			"zone":          "test-zone-1a",
+			"capacity_type": karpv1.CapacityTypeOnDemand,
+		})
+		Expect(ok).To(BeTrue())
+		Expect(metric.GetCounter().GetValue()).To(BeNumerically("==", 1))
+	})
+	It("should observe the duration of the CreateFleet call", func() {
+		ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool { return i.Name == "m5.xlarge" })
+
+		instance.LaunchPhaseDurationSeconds.Reset()
+		inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+		Expect(err).ToNot(HaveOccurred())
+		metric, ok := FindMetricWithLabelValues("karpenter_cloudprovider_launch_phase_duration_seconds", map[string]string{
+			"phase":           instance.LaunchPhaseCreateFleet,
+			"ami_family":      nodeClass.AMIFamily(),
+			"instance_family": "m5",
+			"zone":            inst.Zone,
+		})
+		Expect(ok).To(BeTrue())
+		Expect(metric.GetHistogram().GetSampleCount()).To(BeNumerically("==", 1))
+	})
+	It("should observe the spot pools that spot launches diversified across", func() {
+		nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+			NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeSpot}},
+		})
+		ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool {
+			return i.Name == "m5.xlarge" || i.Name == "m5.large"
+		})
+
+		instance.SpotPools.Reset()
+		inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+		Expect(err).ToNot(HaveOccurred())
+		input := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+		pools := sets.New[string]()
+		for _, config := range input.LaunchTemplateConfigs {
+			for _, override := range config.Overrides {
+				pools.Insert(aws.StringValue(override.InstanceType) + "/" + aws.StringValue(override.AvailabilityZone))
+			}
+		}
+		Expect(pools.Len()).To(BeNumerically(">", 1))
+		Expect(inst.SpotPools).To(Equal(pools.Len()))
+		metric, ok := FindMetricWithLabelValues("karpenter_cloudprovider_launch_spot_pools", map[string]string{
+			"nodepool": nodePool.Name,
+		})
+		Expect(ok).To(BeTrue())
+		Expect(metric.GetHistogram().GetSampleCount()).To(BeNumerically("==", 1))
+		Expect(metric.GetHistogram().GetSampleSum()).To(BeNumerically("==", pools.Len()))
+	})
+	It("should not count the spot pools of on-demand launches", func() {
+		nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+			NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeOnDemand}},
+		})
+		ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+
+		instance.SpotPools.Reset()
+		inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(inst.SpotPools).To(BeZero())
+		_, ok := FindMetricWithLabelValues("karpenter_cloudprovider_launch_spot_pools", map[string]string{
+			"nodepool": nodePool.Name,
+		})
+		Expect(ok).To(BeFalse())
+	})
+	It("should launch on-demand instances into unused capacity reservations first", func() {
+		nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+			NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeOnDemand}},
+		})
+		ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+		Expect(err).ToNot(HaveOccurred())
+		input := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+		Expect(input.OnDemandOptions.CapacityReservationOptions).ToNot(BeNil())
+		Expect(aws.StringValue(input.OnDemandOptions.CapacityReservationOptions.UsageStrategy)).To(Equal(ec2.FleetCapacityReservationUsageStrategyUseCapacityReservationsFirst))
+	})
+	Context("Launch Journal", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		BeforeEach(func() {
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		It("should journal the instance of the launch", func() {
+			inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			launch, err := awsEnv.LaunchJournal.Get(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(launch.InstanceID).To(Equal(inst.ID))
+		})
+		It("should adopt the running instance of a journaled launch", func() {
+			awsEnv.EC2API.Instances.Store("i-0123456789", &ec2.Instance{
+				InstanceId:   aws.String("i-0123456789"),
+				InstanceType: aws.String("m5.xlarge"),
+				Placement:    &ec2.Placement{AvailabilityZone: aws.String("test-zone-1a")},
+				State:        &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)},
+			})
+			Expect(awsEnv.LaunchJournal.Record(ctx, nodeClaim, "i-0123456789")).To(Succeed())
+
+			inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(inst.ID).To(Equal("i-0123456789"))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(BeZero())
+		})
+		It("should journal the launch before the instance is launched", func() {
+			awsEnv.EC2API.CreateFleetBehavior.Error.Set(fmt.Errorf("connection reset"))
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).To(HaveOccurred())
+
+			launch, err := awsEnv.LaunchJournal.Get(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(launch.InstanceID).To(BeEmpty())
+			Expect(launch.ClientToken).To(HavePrefix(string(nodeClaim.UID)))
+
+			// The launch is issued again with the client token of the journal
+			_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(aws.StringValue(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop().ClientToken)).To(Equal(launch.ClientToken))
+		})
+		It("should adopt the instance of an interrupted launch by issuing it again with its client token", func() {
+			Expect(awsEnv.LaunchJournal.Begin(ctx, nodeClaim, "nodeclaim-uid-1")).To(Succeed())
+			inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			// The controller restarted before the instance was journaled
+			Expect(awsEnv.LaunchJournal.Begin(ctx, nodeClaim, "nodeclaim-uid-1")).To(Succeed())
+
+			adopted, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(adopted.ID).To(Equal(inst.ID))
+			Expect(aws.StringValue(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop().ClientToken)).To(Equal("nodeclaim-uid-1"))
+		})
+		It("should launch with a new client token when the interrupted launch was issued with other parameters", func() {
+			Expect(awsEnv.LaunchJournal.Begin(ctx, nodeClaim, "nodeclaim-uid-1")).To(Succeed())
+			awsEnv.EC2API.CreateFleetBehavior.Error.Set(awserr.New("IdempotentParameterMismatch", "", nil), fake.MaxCalls(1))
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).To(HaveOccurred())
+
+			_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(aws.StringValue(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop().ClientToken)).ToNot(Equal("nodeclaim-uid-1"))
+		})
+		It("should launch again when the instance of a journaled launch was terminated", func() {
+			awsEnv.EC2API.Instances.Store("i-0123456789", &ec2.Instance{
+				InstanceId:   aws.String("i-0123456789"),
+				InstanceType: aws.String("m5.xlarge"),
+				Placement:    &ec2.Placement{AvailabilityZone: aws.String("test-zone-1a")},
+				State:        &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameTerminated)},
+			})
+			Expect(awsEnv.LaunchJournal.Record(ctx, nodeClaim, "i-0123456789")).To(Succeed())
+
+			inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(inst.ID).ToNot(Equal("i-0123456789"))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(1))
+		})
+	})
+	Context("Eventual Consistency", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		notFound := awserr.New("InvalidInstanceID.NotFound", "The instance ID does not exist", nil)
+		BeforeEach(func() {
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		It("should describe a launched instance again when it isn't found yet", func() {
+			inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			awsEnv.EC2API.DescribeInstancesBehavior.Error.Set(notFound, fake.MaxCalls(1))
+
+			got, err := awsEnv.InstanceProvider.Get(ctx, inst.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(got.ID).To(Equal(inst.ID))
+		})
+		It("should not return a not found error for a launched instance that isn't visible yet", func() {
+			inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			awsEnv.EC2API.DescribeInstancesBehavior.Error.Set(notFound, fake.MaxCalls(0))
+
+			_, err = awsEnv.InstanceProvider.Get(ctx, inst.ID)
+			Expect(err).To(HaveOccurred())
+			Expect(corecloudprovider.IsNodeClaimNotFoundError(err)).To(BeFalse())
+		})
+		It("should return a not found error for instances that weren't launched recently", func() {
+			awsEnv.EC2API.DescribeInstancesBehavior.Error.Set(notFound)
+
+			_, err := awsEnv.InstanceProvider.Get(ctx, "i-0123456789")
+			Expect(corecloudprovider.IsNodeClaimNotFoundError(err)).To(BeTrue())
+		})
+		It("should not launch again when the instance of a recent journaled launch isn't visible yet", func() {
+			Expect(awsEnv.LaunchJournal.Record(ctx, nodeClaim, "i-0123456789")).To(Succeed())
+			awsEnv.EC2API.DescribeInstancesBehavior.Error.Set(notFound, fake.MaxCalls(0))
+
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).To(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(BeZero())
+		})
+		It("should not consider a launched instance terminated when it isn't visible yet", func() {
+			inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			awsEnv.EC2API.TerminateInstancesBehavior.Error.Set(notFound, fake.MaxCalls(0))
+
+			err = awsEnv.InstanceProvider.Delete(ctx, inst.ID)
+			Expect(err).To(HaveOccurred())
+			Expect(corecloudprovider.IsNodeClaimNotFoundError(err)).To(BeFalse())
+		})
+	})
+	Context("Adopted Instances", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		BeforeEach(func() {
+			nodeClaim.Annotations = map[string]string{v1.AnnotationAdoptedInstance: "i-0123456789"}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		It("should tag and return the adopted instance rather than launching one", func() {
+			awsEnv.EC2API.Instances.Store("i-0123456789", &ec2.Instance{
+				InstanceId:   aws.String("i-0123456789"),
+				InstanceType: aws.String("m5.xlarge"),
+				Placement:    &ec2.Placement{AvailabilityZone: aws.String("test-zone-1a")},
+				State:        &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)},
+			})
+			inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(inst.ID).To(Equal("i-0123456789"))
+			Expect(inst.Tags).To(HaveKeyWithValue(karpv1.NodePoolLabelKey, nodePool.Name))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(BeZero())
+			Expect(awsEnv.EC2API.CreateTagsBehavior.Calls()).To(Equal(1))
+		})
+		It("should return an insufficient capacity error when the adopted instance isn't running", func() {
+			awsEnv.EC2API.Instances.Store("i-0123456789", &ec2.Instance{
+				InstanceId:   aws.String("i-0123456789"),
+				InstanceType: aws.String("m5.xlarge"),
+				Placement:    &ec2.Placement{AvailabilityZone: aws.String("test-zone-1a")},
+				State:        &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameTerminated)},
+			})
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(corecloudprovider.IsInsufficientCapacityError(err)).To(BeTrue())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(BeZero())
+		})
+	})
+	Context("Templated Tags", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		BeforeEach(func() {
+			nodePool.Spec.Template.Labels = map[string]string{"team": "payments"}
+			nodeClaim.Labels["cost-center"] = "1234"
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		It("should resolve the tags from the labels of the NodePool and NodeClaim", func() {
+			nodeClass.Spec.Tags = map[string]string{
+				"team":        `{{ .NodePoolLabel "team" }}`,
+				"cost-center": `cc-{{ .NodeClaimLabel "cost-center" }}`,
+				"env":         "prod",
+			}
+			_, err := awsEnv.InstanceProvider.Create(instance.WithNodePool(ctx, nodePool), nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			input := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			tagSpec, ok := lo.Find(input.TagSpecifications, func(t *ec2.TagSpecification) bool {
+				return aws.StringValue(t.ResourceType) == ec2.ResourceTypeInstance
+			})
+			Expect(ok).To(BeTrue())
+			tags := lo.SliceToMap(tagSpec.Tags, func(t *ec2.Tag) (string, string) { return aws.StringValue(t.Key), aws.StringValue(t.Value) })
+			Expect(tags).To(HaveKeyWithValue("team", "payments"))
+			Expect(tags).To(HaveKeyWithValue("cost-center", "cc-1234"))
+			Expect(tags).To(HaveKeyWithValue("env", "prod"))
+		})
+		It("should drop tags that resolve to an empty value", func() {
+			nodeClass.Spec.Tags = map[string]string{"owner": `{{ .NodePoolLabel "owner" }}`}
+			_, err := awsEnv.InstanceProvider.Create(instance.WithNodePool(ctx, nodePool), nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			input := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			for _, tagSpec := range input.TagSpecifications {
+				Expect(lo.Map(tagSpec.Tags, func(t *ec2.Tag, _ int) string { return aws.StringValue(t.Key) })).ToNot(ContainElement("owner"))
+			}
+		})
+		It("should tag the instance for Session Manager", func() {
+			nodeClass.Spec.SSMAgent = &v1.SSMAgent{SessionManager: lo.ToPtr(true)}
+			_, err := awsEnv.InstanceProvider.Create(instance.WithNodePool(ctx, nodePool), nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			input := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			tagSpec, ok := lo.Find(input.TagSpecifications, func(t *ec2.TagSpecification) bool {
+				return aws.StringValue(t.ResourceType) == ec2.ResourceTypeInstance
+			})
+			Expect(ok).To(BeTrue())
+			tags := lo.SliceToMap(tagSpec.Tags, func(t *ec2.Tag) (string, string) { return aws.StringValue(t.Key), aws.StringValue(t.Value) })
+			Expect(tags).To(HaveKeyWithValue(v1.TagSessionManager, "true"))
+		})
+		It("should fail the launch when a tag isn't a valid template", func() {
+			nodeClass.Spec.Tags = map[string]string{"team": `{{ .NodePoolLabel "team" `}
+			_, err := awsEnv.InstanceProvider.Create(instance.WithNodePool(ctx, nodePool), nodeClass, nodeClaim, instanceTypes)
+			Expect(err).To(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(BeZero())
+		})
+		It("should leave templated tags off of the instance profile", func() {
+			nodeClass.Spec.Tags = map[string]string{"team": `{{ .NodePoolLabel "team" }}`, "env": "prod"}
+			tags := nodeClass.InstanceProfileTags("test-cluster")
+			Expect(tags).ToNot(HaveKey("team"))
+			Expect(tags).To(HaveKeyWithValue("env", "prod"))
+		})
+	})
+	Context("Impaired Zones", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		BeforeEach(func() {
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool { return i.Name == "m5.xlarge" })
+		})
+		overrideZones := func() []string {
+			input := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			return lo.Uniq(lo.FlatMap(input.LaunchTemplateConfigs, func(c *ec2.FleetLaunchTemplateConfigRequest, _ int) []string {
+				return lo.Map(c.Overrides, func(o *ec2.FleetLaunchTemplateOverridesRequest, _ int) string {
+					return aws.StringValue(o.AvailabilityZone)
+				})
+			}))
+		}
+		It("should avoid impaired zones when launching", func() {
+			awsEnv.ImpairedZonesCache.MarkImpaired(ctx, "AWS_EC2_OPERATIONAL_ISSUE", "test-zone-1a", time.Time{})
+			inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(inst.Zone).ToNot(Equal("test-zone-1a"))
+			zones := overrideZones()
+			Expect(zones).ToNot(BeEmpty())
+			Expect(zones).ToNot(ContainElement("test-zone-1a"))
+		})
+		It("should launch into an impaired zone when no other zone can satisfy the NodeClaim", func() {
+			nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-1a"}},
+			})
+			awsEnv.ImpairedZonesCache.MarkImpaired(ctx, "AWS_EC2_OPERATIONAL_ISSUE", "test-zone-1a", time.Time{})
+			inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(inst.Zone).To(Equal("test-zone-1a"))
+			Expect(overrideZones()).To(ConsistOf("test-zone-1a"))
+		})
+	})
+	Context("Spot Interruption History", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		BeforeEach(func() {
+			nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeSpot}},
+			})
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool { return i.Name == "m5.xlarge" })
+			awsEnv.SpotInterruptionHistory.Record(ctx, "m5.xlarge", "test-zone-1a")
+			awsEnv.SpotInterruptionHistory.Record(ctx, "m5.xlarge", "test-zone-1a")
+		})
+		overrideZones := func() []string {
+			input := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			return lo.Uniq(lo.FlatMap(input.LaunchTemplateConfigs, func(c *ec2.FleetLaunchTemplateConfigRequest, _ int) []string {
+				return lo.Map(c.Overrides, func(o *ec2.FleetLaunchTemplateOverridesRequest, _ int) string {
+					return aws.StringValue(o.AvailabilityZone)
+				})
+			}))
+		}
+		It("should avoid spot pools whose interruptions reach the threshold of the NodePool", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationSpotInterruptionThreshold: "1.5"})
+			inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(inst.Zone).ToNot(Equal("test-zone-1a"))
+			zones := overrideZones()
+			Expect(zones).ToNot(BeEmpty())
+			Expect(zones).ToNot(ContainElement("test-zone-1a"))
+		})
+		It("should use spot pools whose interruptions are below the threshold of the NodePool", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationSpotInterruptionThreshold: "2.5"})
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(overrideZones()).To(ContainElement("test-zone-1a"))
+		})
+		It("should not avoid interrupted spot pools when the NodePool doesn't set a threshold", func() {
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(overrideZones()).To(ContainElement("test-zone-1a"))
+		})
+		It("should launch into an interrupted spot pool when no other pool can satisfy the NodeClaim", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationSpotInterruptionThreshold: "1"})
+			nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-1a"}},
+			})
+			inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(inst.Zone).To(Equal("test-zone-1a"))
+		})
+	})
+	Context("Architecture Preference", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		BeforeEach(func() {
+			awsEnv.EC2API.DescribeInstanceTypeOfferingsOutput.Set(&ec2.DescribeInstanceTypeOfferingsOutput{
+				InstanceTypeOfferings: lo.FlatMap([]string{"m5.xlarge", "t4g.xlarge"}, func(instanceType string, _ int) []*ec2.InstanceTypeOffering {
+					return []*ec2.InstanceTypeOffering{
+						{InstanceType: aws.String(instanceType), Location: aws.String("test-zone-1a")},
+						{InstanceType: aws.String(instanceType), Location: aws.String("test-zone-1b")},
+					}
+				}),
+			})
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool {
+				return i.Name == "m5.xlarge" || i.Name == "t4g.xlarge"
+			})
+			nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements,
+				karpv1.NodeSelectorRequirementWithMinValues{
+					NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeOnDemand}},
+				},
+				karpv1.NodeSelectorRequirementWithMinValues{
+					NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelArchStable, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.ArchitectureAmd64, karpv1.ArchitectureArm64}},
+				},
+			)
+		})
+		overrideInstanceTypes := func() []string {
+			input := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			return lo.Uniq(lo.FlatMap(input.LaunchTemplateConfigs, func(c *ec2.FleetLaunchTemplateConfigRequest, _ int) []string {
+				return lo.Map(c.Overrides, func(o *ec2.FleetLaunchTemplateOverridesRequest, _ int) string {
+					return aws.StringValue(o.InstanceType)
+				})
+			}))
+		}
+		It("should only launch instance types of the architecture preferred by the NodePool", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationArchitecturePreference: "arm64,amd64"})
+			inst, err := awsEnv.InstanceProvider.Create(instance.WithNodePool(ctx, nodePool), nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(inst.Type).To(Equal("t4g.xlarge"))
+			Expect(overrideInstanceTypes()).To(ConsistOf("t4g.xlarge"))
+		})
+		It("should fall back to the next architecture when the NodeClaim doesn't allow the preferred architecture", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationArchitecturePreference: "arm64,amd64"})
+			instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool {
+				return i.Requirements.Get(corev1.LabelArchStable).Has(karpv1.ArchitectureAmd64)
+			})
+			inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(inst.Type).To(Equal("m5.xlarge"))
+		})
+		It("should launch instance types of every architecture when the NodePool doesn't set a preference", func() {
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(overrideInstanceTypes()).To(ConsistOf("m5.xlarge", "t4g.xlarge"))
+		})
+		It("should launch instance types of every architecture when the pods don't constrain the architecture", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationArchitecturePreference: "arm64,amd64"})
+			nodeClaim.Spec.Requirements = lo.Reject(nodeClaim.Spec.Requirements, func(r karpv1.NodeSelectorRequirementWithMinValues, _ int) bool {
+				return r.Key == corev1.LabelArchStable
+			})
+			_, err := awsEnv.InstanceProvider.Create(instance.WithNodePool(ctx, nodePool), nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(overrideInstanceTypes()).To(ConsistOf("m5.xlarge", "t4g.xlarge"))
+		})
+		It("should launch instance types of every architecture when the NodePool constrains the architecture", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationArchitecturePreference: "arm64,amd64"})
+			nodePool.Spec.Template.Spec.Requirements = append(nodePool.Spec.Template.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelArchStable, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.ArchitectureAmd64, karpv1.ArchitectureArm64}},
+			})
+			_, err := awsEnv.InstanceProvider.Create(instance.WithNodePool(ctx, nodePool), nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(overrideInstanceTypes()).To(ConsistOf("m5.xlarge", "t4g.xlarge"))
+		})
+		It("should ignore a preference with an unsupported architecture", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationArchitecturePreference: "arm64,riscv64"})
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(overrideInstanceTypes()).To(ConsistOf("m5.xlarge", "t4g.xlarge"))
+		})
+	})
+	Context("Source/Destination Check", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		BeforeEach(func() {
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		It("should disable the source/destination check of the instance when it's launched", func() {
+			nodeClass.Spec.PrimaryNetworkInterface = &v1.PrimaryNetworkInterface{SourceDestCheck: lo.ToPtr(false)}
+			inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(inst.SourceDestCheck).To(BeFalse())
+			input := awsEnv.EC2API.ModifyInstanceAttributeBehavior.CalledWithInput.Pop()
+			Expect(aws.StringValue(input.InstanceId)).To(Equal(inst.ID))
+			Expect(aws.BoolValue(input.SourceDestCheck.Value)).To(BeFalse())
+		})
+		It("should launch the instance when the source/destination check can't be disabled", func() {
+			nodeClass.Spec.PrimaryNetworkInterface = &v1.PrimaryNetworkInterface{SourceDestCheck: lo.ToPtr(false)}
+			awsEnv.EC2API.ModifyInstanceAttributeBehavior.Error.Set(fmt.Errorf("unauthorized"))
+			inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(inst.SourceDestCheck).To(BeTrue())
+		})
+		It("should keep the source/destination check when the EC2NodeClass doesn't disable it", func() {
+			inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(inst.SourceDestCheck).To(BeTrue())
+			Expect(awsEnv.EC2API.ModifyInstanceAttributeBehavior.Calls()).To(Equal(0))
+		})
+	})
+	Context("Launch Overrides", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		BeforeEach(func() {
+			awsEnv.EC2API.DescribeInstanceTypeOfferingsOutput.Set(&ec2.DescribeInstanceTypeOfferingsOutput{
+				InstanceTypeOfferings: lo.FlatMap([]string{"m5.xlarge", "t3.large"}, func(instanceType string, _ int) []*ec2.InstanceTypeOffering {
+					return []*ec2.InstanceTypeOffering{
+						{InstanceType: aws.String(instanceType), Location: aws.String("test-zone-1a")},
+						{InstanceType: aws.String(instanceType), Location: aws.String("test-zone-1b")},
+					}
+				}),
+			})
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool {
+				return i.Name == "m5.xlarge" || i.Name == "t3.large"
+			})
+		})
+		It("should launch into the capacity type, zone and instance family of the overrides", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+				v1.AnnotationLaunchCapacityType:   karpv1.CapacityTypeOnDemand,
+				v1.AnnotationLaunchZone:           "test-zone-1b",
+				v1.AnnotationLaunchInstanceFamily: "t3",
+			})
+			inst, err := awsEnv.InstanceProvider.Create(instance.WithNodePool(ctx, nodePool), nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(inst.Type).To(Equal("t3.large"))
+			Expect(inst.Zone).To(Equal("test-zone-1b"))
+			Expect(inst.CapacityType).To(Equal(karpv1.CapacityTypeOnDemand))
+		})
+		It("should fail the launch when the NodePool doesn't allow an override", func() {
+			nodePool.Spec.Template.Spec.Requirements = append(nodePool.Spec.Template.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeOnDemand}},
+			})
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationLaunchCapacityType: karpv1.CapacityTypeSpot})
+			_, err := awsEnv.InstanceProvider.Create(instance.WithNodePool(ctx, nodePool), nodeClass, nodeClaim, instanceTypes)
+			Expect(err).To(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(0))
+		})
+		It("should fail the launch when the requirements of the NodeClaim don't allow an override", func() {
+			nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-1a"}},
+			})
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationLaunchZone: "test-zone-1b"})
+			_, err := awsEnv.InstanceProvider.Create(instance.WithNodePool(ctx, nodePool), nodeClass, nodeClaim, instanceTypes)
+			Expect(err).To(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(0))
+		})
+		It("should fail with insufficient capacity when no instance type matches the overrides", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationLaunchInstanceFamily: "r5"})
+			_, err := awsEnv.InstanceProvider.Create(instance.WithNodePool(ctx, nodePool), nodeClass, nodeClaim, instanceTypes)
+			Expect(corecloudprovider.IsInsufficientCapacityError(err)).To(BeTrue())
+		})
+	})
+	Context("Pod Density", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		BeforeEach(func() {
+			// m5.large needs 30 IP addresses for its 29 pods, and m5.xlarge needs 59 for its 58 pods
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: lo.Map([]string{"a", "b", "c"}, func(zone string, i int) *ec2.Subnet {
+				return &ec2.Subnet{
+					SubnetId:                aws.String(fmt.Sprintf("subnet-test%d", i+1)),
+					AvailabilityZone:        aws.String("test-zone-1" + zone),
+					AvailabilityZoneId:      aws.String("tstz1-1" + zone),
+					AvailableIpAddressCount: aws.Int64(40),
+					Tags:                    []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String(fmt.Sprintf("test-subnet-%d", i+1))}},
+				}
+			})})
+			awsEnv.SubnetCache.Flush()
+			// m5.xlarge spot is more expensive than m5.large on-demand, so mixed capacity launches would leave it out
+			nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeOnDemand}},
+			})
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool {
+				return i.Name == "m5.large" || i.Name == "m5.xlarge"
+			})
+		})
+		It("should exclude instance types whose max pods needs more IP addresses than the subnets have", func() {
+			nodeClass.Spec.VPCCNI = &v1.VPCCNIConfiguration{}
+			inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(inst.Type).To(Equal("m5.large"))
+		})
+		It("should fail with insufficient capacity when no instance type fits the subnets", func() {
+			nodeClass.Spec.VPCCNI = &v1.VPCCNIConfiguration{}
+			instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool { return i.Name == "m5.xlarge" })
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(corecloudprovider.IsInsufficientCapacityError(err)).To(BeTrue())
+		})
+		It("should count the prefixes that are assigned with prefix delegation", func() {
+			// m5.large needs 2 prefixes of 16 addresses and the primary address of the node
+			nodeClass.Spec.VPCCNI = &v1.VPCCNIConfiguration{PrefixDelegation: lo.ToPtr(true)}
+			inst, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(inst.Type).To(Equal("m5.large"))
+		})
+		It("should not exclude instance types when the EC2NodeClass doesn't describe its VPC CNI", func() {
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			input := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(lo.Uniq(lo.FlatMap(input.LaunchTemplateConfigs, func(c *ec2.FleetLaunchTemplateConfigRequest, _ int) []string {
+				return lo.Map(c.Overrides, func(o *ec2.FleetLaunchTemplateOverridesRequest, _ int) string { return aws.StringValue(o.InstanceType) })
+			}))).To(ConsistOf("m5.large", "m5.xlarge"))
+		})
+	})
 	It("should return all NodePool-owned instances from List", func() {
 		ids := sets.New[string]()
 		// Provision instances that have the karpenter.sh/nodepool key
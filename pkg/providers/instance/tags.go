@@ -0,0 +1,85 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+)
+
+type nodePoolKey struct{}
+
+// WithNodePool returns a context that resolves the templated tags of instances launched with it against the passed
+// NodePool. NodeClaims that aren't owned by a NodePool leave it nil.
+func WithNodePool(ctx context.Context, nodePool *karpv1.NodePool) context.Context {
+	return context.WithValue(ctx, nodePoolKey{}, nodePool)
+}
+
+func nodePoolFromContext(ctx context.Context) *karpv1.NodePool {
+	nodePool, _ := ctx.Value(nodePoolKey{}).(*karpv1.NodePool)
+	return nodePool
+}
+
+// TagTemplateData is what the templated tag values of an EC2NodeClass are executed against, e.g.
+// `{{ .NodePoolLabel "team" }}`. Labels that aren't set resolve to an empty string.
+type TagTemplateData struct {
+	nodePool  *karpv1.NodePool
+	nodeClaim *karpv1.NodeClaim
+}
+
+// NodePoolLabel returns the value of a label on the template of the NodePool of the launched NodeClaim
+func (d TagTemplateData) NodePoolLabel(key string) string {
+	if d.nodePool == nil {
+		return ""
+	}
+	return d.nodePool.Spec.Template.Labels[key]
+}
+
+// NodeClaimLabel returns the value of a label on the launched NodeClaim
+func (d TagTemplateData) NodeClaimLabel(key string) string {
+	return d.nodeClaim.Labels[key]
+}
+
+// resolveTags executes the templated tag values against the NodePool and NodeClaim that are launched. Tags whose
+// values resolve to an empty string are dropped, so that instances of NodePools without the label aren't tagged
+// with an empty cost allocation key.
+func resolveTags(tags map[string]string, nodePool *karpv1.NodePool, nodeClaim *karpv1.NodeClaim) (map[string]string, error) {
+	data := TagTemplateData{nodePool: nodePool, nodeClaim: nodeClaim}
+	resolved := make(map[string]string, len(tags))
+	for k, v := range tags {
+		if !v1.IsTagTemplate(v) {
+			resolved[k] = v
+			continue
+		}
+		tmpl, err := template.New(k).Option("missingkey=zero").Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("parsing template of tag %q, %w", k, err)
+		}
+		var b strings.Builder
+		if err = tmpl.Execute(&b, data); err != nil {
+			return nil, fmt.Errorf("executing template of tag %q, %w", k, err)
+		}
+		if b.Len() > 0 {
+			resolved[k] = b.String()
+		}
+	}
+	return resolved, nil
+}
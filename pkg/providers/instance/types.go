@@ -38,6 +38,15 @@ type Instance struct {
 	SubnetID         string
 	Tags             map[string]string
 	EFAEnabled       bool
+	SourceDestCheck  bool
+	// UsageOperation is the billing code of the instance, which identifies the billing products of its AMI
+	UsageOperation        string
+	PlacementGroup        string
+	HostID                string
+	CapacityReservationID string
+	// SpotPools is the number of spot pools, the pairs of instance type and zone, that the launch of the instance
+	// diversified across. It's only set for instances that were launched as spot.
+	SpotPools int
 }
 
 func NewInstance(out *ec2.Instance) *Instance {
@@ -57,21 +66,28 @@ func NewInstance(out *ec2.Instance) *Instance {
 		EFAEnabled: lo.ContainsBy(out.NetworkInterfaces, func(ni *ec2.InstanceNetworkInterface) bool {
 			return ni != nil && lo.FromPtr(ni.InterfaceType) == ec2.NetworkInterfaceTypeEfa
 		}),
+		// Source/destination checking is enabled unless it's been disabled
+		SourceDestCheck:       lo.FromPtrOr(out.SourceDestCheck, true),
+		UsageOperation:        aws.StringValue(out.UsageOperation),
+		PlacementGroup:        aws.StringValue(out.Placement.GroupName),
+		HostID:                aws.StringValue(out.Placement.HostId),
+		CapacityReservationID: aws.StringValue(out.CapacityReservationId),
 	}
 
 }
 
 func NewInstanceFromFleet(out *ec2.CreateFleetInstance, tags map[string]string, efaEnabled bool) *Instance {
 	return &Instance{
-		LaunchTime:   time.Now(), // estimate the launch time since we just launched
-		State:        ec2.StatePending,
-		ID:           aws.StringValue(out.InstanceIds[0]),
-		ImageID:      aws.StringValue(out.LaunchTemplateAndOverrides.Overrides.ImageId),
-		Type:         aws.StringValue(out.InstanceType),
-		Zone:         aws.StringValue(out.LaunchTemplateAndOverrides.Overrides.AvailabilityZone),
-		CapacityType: aws.StringValue(out.Lifecycle),
-		SubnetID:     aws.StringValue(out.LaunchTemplateAndOverrides.Overrides.SubnetId),
-		Tags:         tags,
-		EFAEnabled:   efaEnabled,
+		LaunchTime:      time.Now(), // estimate the launch time since we just launched
+		State:           ec2.StatePending,
+		ID:              aws.StringValue(out.InstanceIds[0]),
+		ImageID:         aws.StringValue(out.LaunchTemplateAndOverrides.Overrides.ImageId),
+		Type:            aws.StringValue(out.InstanceType),
+		Zone:            aws.StringValue(out.LaunchTemplateAndOverrides.Overrides.AvailabilityZone),
+		CapacityType:    aws.StringValue(out.Lifecycle),
+		SubnetID:        aws.StringValue(out.LaunchTemplateAndOverrides.Overrides.SubnetId),
+		Tags:            tags,
+		EFAEnabled:      efaEnabled,
+		SourceDestCheck: true,
 	}
 }
@@ -17,6 +17,7 @@ package instanceprofile
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/iam"
@@ -25,7 +26,9 @@ import (
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
 	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 )
@@ -41,6 +44,8 @@ type ResourceOwner interface {
 type Provider interface {
 	Create(context.Context, ResourceOwner) (string, error)
 	Delete(context.Context, ResourceOwner) error
+	ReconcileRole(context.Context, ResourceOwner) error
+	Propagating(context.Context, ResourceOwner) time.Duration
 }
 
 type DefaultProvider struct {
@@ -77,7 +82,12 @@ func (p *DefaultProvider) Create(ctx context.Context, m ResourceOwner) (string,
 			Tags:                lo.MapToSlice(tags, func(k, v string) *iam.Tag { return &iam.Tag{Key: aws.String(k), Value: aws.String(v)} }),
 		})
 		if err != nil {
-			return "", fmt.Errorf("creating instance profile %q, %w", profileName, err)
+			if !awserrors.IsDryRun(err) {
+				return "", fmt.Errorf("creating instance profile %q, %w", profileName, err)
+			}
+			// In dry-run mode, the instance profile is resolved as if it had been created so that the EC2NodeClass can
+			// still be used to compute launches
+			return profileName, nil
 		}
 		instanceProfile = o.InstanceProfile
 	} else {
@@ -92,7 +102,7 @@ func (p *DefaultProvider) Create(ctx context.Context, m ResourceOwner) (string,
 		if _, err = p.iamapi.RemoveRoleFromInstanceProfileWithContext(ctx, &iam.RemoveRoleFromInstanceProfileInput{
 			InstanceProfileName: aws.String(profileName),
 			RoleName:            instanceProfile.Roles[0].RoleName,
-		}); err != nil {
+		}); awserrors.IgnoreDryRun(err) != nil {
 			return "", fmt.Errorf("removing role %q for instance profile %q, %w", aws.StringValue(instanceProfile.Roles[0].RoleName), profileName, err)
 		}
 	}
@@ -100,12 +110,90 @@ func (p *DefaultProvider) Create(ctx context.Context, m ResourceOwner) (string,
 		InstanceProfileName: aws.String(profileName),
 		RoleName:            aws.String(m.InstanceProfileRole()),
 	}); err != nil {
+		if awserrors.IsDryRun(err) {
+			return profileName, nil
+		}
 		return "", fmt.Errorf("adding role %q to instance profile %q, %w", m.InstanceProfileRole(), profileName, err)
 	}
-	p.cache.SetDefault(string(m.GetUID()), nil)
+	if delay := options.FromContext(ctx).InstanceProfilePropagationDelay; delay > 0 {
+		p.cache.Set(propagationKey(m), time.Now().Add(delay), delay)
+	}
+	p.cache.Set(string(m.GetUID()), nil, options.FromContext(ctx).InstanceProfileCacheTTL)
 	return aws.StringValue(instanceProfile.InstanceProfileName), nil
 }
 
+// Propagating returns how long the instance profile is still expected to take to propagate through IAM after Karpenter
+// created it or changed its role. EC2 rejects instance profiles that haven't propagated, so launching with the instance
+// profile before then intermittently fails.
+func (p *DefaultProvider) Propagating(_ context.Context, m ResourceOwner) time.Duration {
+	propagated, ok := p.cache.Get(propagationKey(m))
+	if !ok {
+		return 0
+	}
+	return max(time.Until(propagated.(time.Time)), 0)
+}
+
+func propagationKey(m ResourceOwner) string {
+	return fmt.Sprintf("propagation/%s", m.GetUID())
+}
+
+// ReconcileRole sets the permissions boundary in node-role-permissions-boundary on the role of the instance profile and
+// attaches the policies in node-role-required-policies to it when they are missing
+func (p *DefaultProvider) ReconcileRole(ctx context.Context, m ResourceOwner) error {
+	boundary := options.FromContext(ctx).NodeRolePermissionsBoundary
+	requiredPolicies := options.FromContext(ctx).RequiredNodeRolePolicies()
+	if boundary == "" && len(requiredPolicies) == 0 {
+		return nil
+	}
+	roleName := m.InstanceProfileRole()
+	// The role was reconciled recently
+	key := assumerole.CacheKey(ctx, fmt.Sprintf("role/%s", roleName))
+	if _, ok := p.cache.Get(key); ok {
+		return nil
+	}
+	if boundary != "" {
+		out, err := p.iamapi.GetRoleWithContext(ctx, &iam.GetRoleInput{RoleName: aws.String(roleName)})
+		if err != nil {
+			return fmt.Errorf("getting role %q, %w", roleName, err)
+		}
+		if out.Role.PermissionsBoundary == nil || aws.StringValue(out.Role.PermissionsBoundary.PermissionsBoundaryArn) != boundary {
+			_, err := p.iamapi.PutRolePermissionsBoundaryWithContext(ctx, &iam.PutRolePermissionsBoundaryInput{
+				RoleName:            aws.String(roleName),
+				PermissionsBoundary: aws.String(boundary),
+			})
+			if awserrors.IgnoreDryRun(err) != nil {
+				return fmt.Errorf("putting permissions boundary %q on role %q, %w", boundary, roleName, err)
+			}
+			if err == nil {
+				log.FromContext(ctx).WithValues("role", roleName, "permissions-boundary", boundary).Info("set permissions boundary on role")
+			}
+		}
+	}
+	if len(requiredPolicies) > 0 {
+		var attached []string
+		if err := p.iamapi.ListAttachedRolePoliciesPagesWithContext(ctx, &iam.ListAttachedRolePoliciesInput{RoleName: aws.String(roleName)}, func(out *iam.ListAttachedRolePoliciesOutput, _ bool) bool {
+			attached = append(attached, lo.Map(out.AttachedPolicies, func(p *iam.AttachedPolicy, _ int) string { return aws.StringValue(p.PolicyArn) })...)
+			return true
+		}); err != nil {
+			return fmt.Errorf("listing attached policies of role %q, %w", roleName, err)
+		}
+		for _, policyARN := range lo.Without(requiredPolicies, attached...) {
+			_, err := p.iamapi.AttachRolePolicyWithContext(ctx, &iam.AttachRolePolicyInput{
+				RoleName:  aws.String(roleName),
+				PolicyArn: aws.String(policyARN),
+			})
+			if awserrors.IgnoreDryRun(err) != nil {
+				return fmt.Errorf("attaching policy %q to role %q, %w", policyARN, roleName, err)
+			}
+			if err == nil {
+				log.FromContext(ctx).WithValues("role", roleName, "policy", policyARN).Info("attached required policy to role")
+			}
+		}
+	}
+	p.cache.Set(key, nil, options.FromContext(ctx).InstanceProfileCacheTTL)
+	return nil
+}
+
 func (p *DefaultProvider) Delete(ctx context.Context, m ResourceOwner) error {
 	profileName := m.InstanceProfileName(options.FromContext(ctx).ClusterName, p.region)
 	out, err := p.iamapi.GetInstanceProfileWithContext(ctx, &iam.GetInstanceProfileInput{
@@ -0,0 +1,50 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancetype
+
+// InstanceTypeBaselineCPUMillicores is the baseline CPU utilization of the burstable performance instance types, in
+// millicores across all of their vCPUs, which they can sustain without spending CPU credits. DescribeInstanceTypes
+// doesn't return the baseline, so it's maintained from
+// https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/burstable-credits-baseline-concepts.html
+var InstanceTypeBaselineCPUMillicores = map[string]int64{
+	"t2.nano":     50,
+	"t2.micro":    100,
+	"t2.small":    200,
+	"t2.medium":   400,
+	"t2.large":    600,
+	"t2.xlarge":   900,
+	"t2.2xlarge":  1350,
+	"t3.nano":     100,
+	"t3.micro":    200,
+	"t3.small":    400,
+	"t3.medium":   400,
+	"t3.large":    600,
+	"t3.xlarge":   1600,
+	"t3.2xlarge":  3200,
+	"t3a.nano":    100,
+	"t3a.micro":   200,
+	"t3a.small":   400,
+	"t3a.medium":  400,
+	"t3a.large":   600,
+	"t3a.xlarge":  1600,
+	"t3a.2xlarge": 3200,
+	"t4g.nano":    100,
+	"t4g.micro":   200,
+	"t4g.small":   400,
+	"t4g.medium":  400,
+	"t4g.large":   600,
+	"t4g.xlarge":  1600,
+	"t4g.2xlarge": 3200,
+}
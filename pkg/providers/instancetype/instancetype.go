@@ -31,6 +31,7 @@ import (
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
@@ -52,6 +53,7 @@ type Provider interface {
 	List(context.Context, *v1.KubeletConfiguration, *v1.EC2NodeClass) ([]*cloudprovider.InstanceType, error)
 	UpdateInstanceTypes(ctx context.Context) error
 	UpdateInstanceTypeOfferings(ctx context.Context) error
+	LatestSpotPrice(*v1.EC2NodeClass, string, string) (float64, bool)
 }
 
 type DefaultProvider struct {
@@ -70,6 +72,10 @@ type DefaultProvider struct {
 
 	muInstanceTypeOfferings sync.RWMutex
 	instanceTypeOfferings   map[string]sets.Set[string]
+	// zoneNames maps zone IDs to the zone names of the controller's account. Zone names are assigned to physical zones
+	// independently for each account, so the zones of subnets in other accounts are translated through their zone ID
+	// since offerings and prices are always discovered in the controller's account.
+	zoneNames map[string]string
 
 	instanceTypesCache *cache.Cache
 
@@ -90,6 +96,7 @@ func NewDefaultProvider(region string, instanceTypesCache *cache.Cache, ec2api e
 		pricingProvider:       pricingProvider,
 		instanceTypesInfo:     []*ec2.InstanceTypeInfo{},
 		instanceTypeOfferings: map[string]sets.Set[string]{},
+		zoneNames:             map[string]string{},
 		instanceTypesCache:    instanceTypesCache,
 		unavailableOfferings:  unavailableOfferingsCache,
 		cm:                    pretty.NewChangeMonitor(),
@@ -116,23 +123,35 @@ func (p *DefaultProvider) List(ctx context.Context, kc *v1.KubeletConfiguration,
 		return nil, fmt.Errorf("no subnets found")
 	}
 
-	subnetZones := sets.New(lo.Map(nodeClass.Status.Subnets, func(s v1.Subnet, _ int) string {
-		return aws.StringValue(&s.Zone)
-	})...)
+	// Maps the zones of the subnets to the zones that their offerings are discovered in
+	subnetZones := lo.SliceToMap(nodeClass.Status.Subnets, func(s v1.Subnet) (string, string) {
+		return s.Zone, p.offeringZone(nodeClass, s)
+	})
 
 	// Compute fully initialized instance types hash key
 	subnetZonesHash, _ := hashstructure.Hash(subnetZones, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
 	kcHash, _ := hashstructure.Hash(kc, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
 	blockDeviceMappingsHash, _ := hashstructure.Hash(nodeClass.Spec.BlockDeviceMappings, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
-	key := fmt.Sprintf("%d-%d-%d-%016x-%016x-%016x-%s-%s",
+	hugepagesHash, _ := hashstructure.Hash(nodeClass.Spec.Hugepages, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+	neuronHash, _ := hashstructure.Hash(nodeClass.Spec.Neuron, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+	vpcCNIHash, _ := hashstructure.Hash(nodeClass.Spec.VPCCNI, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+	// The options that the instance types are computed with are part of the key, since they can be reloaded at runtime
+	key := fmt.Sprintf("%d-%d-%d-%016x-%016x-%016x-%016x-%016x-%016x-%s-%s-%s-%s-%v-%d",
 		p.instanceTypesSeqNum,
 		p.instanceTypeOfferingsSeqNum,
 		p.unavailableOfferings.SeqNum,
 		subnetZonesHash,
 		kcHash,
 		blockDeviceMappingsHash,
+		hugepagesHash,
+		neuronHash,
+		vpcCNIHash,
 		lo.FromPtr((*string)(nodeClass.Spec.InstanceStorePolicy)),
+		lo.FromPtr((*string)(primaryNetworkInterfaceType(nodeClass))),
+		lo.Ternary(nodeClass.Spec.EBSOptimized != nil, fmt.Sprint(lo.FromPtr(nodeClass.Spec.EBSOptimized)), ""),
 		nodeClass.AMIFamily(),
+		options.FromContext(ctx).VMMemoryOverheadPercent,
+		options.FromContext(ctx).ReservedENIs,
 	)
 	if item, ok := p.instanceTypesCache.Get(key); ok {
 		// Ensure what's returned from this function is a shallow-copy of the slice (not a deep-copy of the data itself)
@@ -151,8 +170,35 @@ func (p *DefaultProvider) List(ctx context.Context, kc *v1.KubeletConfiguration,
 	if p.cm.HasChanged("zones", allZones) {
 		log.FromContext(ctx).WithValues("zones", allZones.UnsortedList()).V(1).Info("discovered zones")
 	}
+	// Subnets in another account may name a zone differently, in which case the zone is offered under the subnet's name
+	translated := lo.PickBy(subnetZones, func(zone, offeringZone string) bool { return zone != offeringZone })
+	allZones = allZones.Delete(lo.Values(translated)...).Insert(lo.Keys(translated)...)
 	amiFamily := amifamily.GetAMIFamily(lo.ToPtr(nodeClass.AMIFamily()), &amifamily.Options{})
-	result := lo.Map(p.instanceTypesInfo, func(i *ec2.InstanceTypeInfo, _ int) *cloudprovider.InstanceType {
+	instanceTypesInfo := p.instanceTypesInfo
+	if options.FromContext(ctx).SecurityGroupsForPods {
+		// Pods with security groups can only be scheduled to instance types that branch network interfaces can be attached to
+		instanceTypesInfo = lo.Filter(instanceTypesInfo, func(i *ec2.InstanceTypeInfo, _ int) bool {
+			return IsTrunkingCompatible(aws.StringValue(i.InstanceType))
+		})
+	}
+	if lo.FromPtr(primaryNetworkInterfaceType(nodeClass)) == v1.NetworkInterfaceTypeEFA {
+		// Instances can only be launched with an EFA primary network interface by instance types that support EFA
+		instanceTypesInfo = lo.Filter(instanceTypesInfo, func(i *ec2.InstanceTypeInfo, _ int) bool {
+			return i.NetworkInfo != nil && i.NetworkInfo.EfaInfo != nil
+		})
+	}
+	if nodeClass.Spec.EBSOptimized != nil {
+		// Instances can only be launched with the EBS optimization of the EC2NodeClass by instance types that support it
+		instanceTypesInfo = lo.Filter(instanceTypesInfo, func(i *ec2.InstanceTypeInfo, _ int) bool {
+			return supportsEBSOptimized(i, lo.FromPtr(nodeClass.Spec.EBSOptimized))
+		})
+	}
+	maxPods := kc.MaxPods
+	if maxPods == nil && lo.FromPtr(kc.NodeIPFamily) == v1.NodeIPFamilyIPv6 {
+		// Pods on IPv6 nodes are assigned addresses from a prefix, so pod density isn't limited by the ENIs of the instance
+		maxPods = lo.ToPtr[int32](110)
+	}
+	result := lo.Map(instanceTypesInfo, func(i *ec2.InstanceTypeInfo, _ int) *cloudprovider.InstanceType {
 		instanceTypeVCPU.With(prometheus.Labels{
 			instanceTypeLabel: *i.InstanceType,
 		}).Set(float64(aws.Int64Value(i.VCpuInfo.DefaultVCpus)))
@@ -165,15 +211,45 @@ func (p *DefaultProvider) List(ctx context.Context, kc *v1.KubeletConfiguration,
 		// so that Karpenter is able to cache the set of InstanceTypes based on values that alter the set of instance types
 		// !!! Important !!!
 		return NewInstanceType(ctx, i, p.region,
-			nodeClass.Spec.BlockDeviceMappings, nodeClass.Spec.InstanceStorePolicy,
-			kc.MaxPods, kc.PodsPerCore, kc.KubeReserved, kc.SystemReserved, kc.EvictionHard, kc.EvictionSoft,
-			amiFamily, p.createOfferings(ctx, i, allZones, p.instanceTypeOfferings[aws.StringValue(i.InstanceType)], nodeClass.Status.Subnets),
+			nodeClass.Spec.BlockDeviceMappings, nodeClass.Spec.InstanceStorePolicy, nodeClass.Spec.Hugepages, nodeClass.Spec.Neuron,
+			nodeClass.Spec.VPCCNI, maxPods, kc.PodsPerCore, kc.KubeReserved, kc.SystemReserved, kc.EvictionHard, kc.EvictionSoft,
+			kc.ReservedSizing,
+			amiFamily, p.createOfferings(ctx, i, allZones, p.instanceTypeOfferings[aws.StringValue(i.InstanceType)], nodeClass.Status.Subnets, subnetZones),
 		)
 	})
+	if nodeClass.Spec.VPCCNI != nil && lo.FromPtr(kc.NodeIPFamily) != v1.NodeIPFamilyIPv6 {
+		result = p.filterPodDensity(ctx, nodeClass, instanceTypesInfo, result)
+	}
 	p.instanceTypesCache.SetDefault(key, result)
 	return result, nil
 }
 
+func primaryNetworkInterfaceType(nodeClass *v1.EC2NodeClass) *v1.NetworkInterfaceType {
+	if nodeClass.Spec.PrimaryNetworkInterface == nil {
+		return nil
+	}
+	return nodeClass.Spec.PrimaryNetworkInterface.InterfaceType
+}
+
+// filterPodDensity excludes the instance types whose max pods is more than the VPC CNI of the EC2NodeClass can assign IP
+// addresses to, since the pods that are scheduled to them beyond that would never start
+func (p *DefaultProvider) filterPodDensity(ctx context.Context, nodeClass *v1.EC2NodeClass, instanceTypesInfo []*ec2.InstanceTypeInfo,
+	instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
+	var excluded []string
+	supported := lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, i int) bool {
+		if it.Capacity.Pods().Value() <= MaxPodsSupported(ctx, instanceTypesInfo[i], nodeClass.Spec.VPCCNI) {
+			return true
+		}
+		excluded = append(excluded, it.Name)
+		return false
+	})
+	if len(excluded) != 0 && p.cm.HasChanged(fmt.Sprintf("pod-density-%s", nodeClass.Name), excluded) {
+		log.FromContext(ctx).WithValues("EC2NodeClass", nodeClass.Name, "instance-types", excluded).
+			Info("excluded instance types whose max pods exceeds the pod IP capacity of the VPC CNI")
+	}
+	return supported
+}
+
 func (p *DefaultProvider) LivenessProbe(req *http.Request) error {
 	if err := p.subnetProvider.LivenessProbe(req); err != nil {
 		return err
@@ -242,6 +318,13 @@ func (p *DefaultProvider) UpdateInstanceTypeOfferings(ctx context.Context) error
 		}); err != nil {
 		return fmt.Errorf("describing instance type zone offerings, %w", err)
 	}
+	zones, err := p.ec2api.DescribeAvailabilityZonesWithContext(ctx, &ec2.DescribeAvailabilityZonesInput{})
+	if err != nil {
+		return fmt.Errorf("describing availability zones, %w", err)
+	}
+	p.zoneNames = lo.SliceToMap(zones.AvailabilityZones, func(zone *ec2.AvailabilityZone) (string, string) {
+		return aws.StringValue(zone.ZoneId), aws.StringValue(zone.ZoneName)
+	})
 	if p.cm.HasChanged("instance-type-offering", instanceTypeOfferings) {
 		// Only update instanceTypesSeqNun with the instance type offerings  have been changed
 		// This is to not create new keys with duplicate instance type offerings option
@@ -261,9 +344,13 @@ func (p *DefaultProvider) UpdateInstanceTypeOfferings(ctx context.Context) error
 // offering, you can do the following thanks to this invariant:
 //
 //	offering.Requirements.Get(v1.TopologyLabelZone).Any()
-func (p *DefaultProvider) createOfferings(ctx context.Context, instanceType *ec2.InstanceTypeInfo, zones, instanceTypeZones sets.Set[string], subnets []v1.Subnet) []cloudprovider.Offering {
+func (p *DefaultProvider) createOfferings(ctx context.Context, instanceType *ec2.InstanceTypeInfo, zones, instanceTypeZones sets.Set[string], subnets []v1.Subnet, subnetZones map[string]string) []cloudprovider.Offering {
 	var offerings []cloudprovider.Offering
 	for zone := range zones {
+		subnet, hasSubnet := lo.Find(subnets, func(s v1.Subnet) bool {
+			return s.Zone == zone
+		})
+		offeringZone := lo.ValueOr(subnetZones, zone, zone)
 		// while usage classes should be a distinct set, there's no guarantee of that
 		for capacityType := range sets.NewString(aws.StringValueSlice(instanceType.SupportedUsageClasses)...) {
 			// exclude any offerings that have recently seen an insufficient capacity error from EC2
@@ -272,7 +359,7 @@ func (p *DefaultProvider) createOfferings(ctx context.Context, instanceType *ec2
 			var ok bool
 			switch capacityType {
 			case ec2.UsageClassTypeSpot:
-				price, ok = p.pricingProvider.SpotPrice(*instanceType.InstanceType, zone)
+				price, ok = p.pricingProvider.SpotPrice(*instanceType.InstanceType, offeringZone)
 			case ec2.UsageClassTypeOnDemand:
 				price, ok = p.pricingProvider.OnDemandPrice(*instanceType.InstanceType)
 			case "capacity-block":
@@ -283,10 +370,7 @@ func (p *DefaultProvider) createOfferings(ctx context.Context, instanceType *ec2
 				continue
 			}
 
-			subnet, hasSubnet := lo.Find(subnets, func(s v1.Subnet) bool {
-				return s.Zone == zone
-			})
-			available := !isUnavailable && ok && instanceTypeZones.Has(zone) && hasSubnet
+			available := !isUnavailable && ok && instanceTypeZones.Has(offeringZone) && hasSubnet
 			offering := cloudprovider.Offering{
 				Requirements: scheduling.NewRequirements(
 					scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, capacityType),
@@ -314,8 +398,46 @@ func (p *DefaultProvider) createOfferings(ctx context.Context, instanceType *ec2
 	return offerings
 }
 
+// offeringZone returns the name that the controller's account uses for the zone of the subnet. Only the subnets of
+// EC2NodeClasses that assume a role can belong to another account, the zones of all other subnets are used as-is.
+func (p *DefaultProvider) offeringZone(nodeClass *v1.EC2NodeClass, subnet v1.Subnet) string {
+	if nodeClass.Spec.AssumeRoleARN == nil {
+		return subnet.Zone
+	}
+	if zone, ok := p.zoneNames[subnet.ZoneID]; ok {
+		return zone
+	}
+	return subnet.Zone
+}
+
+// LatestSpotPrice returns the latest spot price of the instance type in a zone of the EC2NodeClass, which the price of
+// its offering is adjusted from by the trend of the spot price
+func (p *DefaultProvider) LatestSpotPrice(nodeClass *v1.EC2NodeClass, instanceType string, zone string) (float64, bool) {
+	p.muInstanceTypeOfferings.RLock()
+	defer p.muInstanceTypeOfferings.RUnlock()
+	if subnet, ok := lo.Find(nodeClass.Status.Subnets, func(s v1.Subnet) bool { return s.Zone == zone }); ok {
+		zone = p.offeringZone(nodeClass, subnet)
+	}
+	return p.pricingProvider.LatestSpotPrice(instanceType, zone)
+}
+
 func (p *DefaultProvider) Reset() {
 	p.instanceTypesInfo = []*ec2.InstanceTypeInfo{}
 	p.instanceTypeOfferings = map[string]sets.Set[string]{}
+	p.zoneNames = map[string]string{}
 	p.instanceTypesCache.Flush()
 }
+
+// supportsEBSOptimized returns whether instances of the instance type can be launched with the EBS optimization.
+// Instance types that are EBS-optimized by default can't turn it off, and the others can only turn it on if they
+// support it.
+func supportsEBSOptimized(info *ec2.InstanceTypeInfo, ebsOptimized bool) bool {
+	support := ec2.EbsOptimizedSupportUnsupported
+	if info.EbsInfo != nil {
+		support = aws.StringValue(info.EbsInfo.EbsOptimizedSupport)
+	}
+	if ebsOptimized {
+		return support != ec2.EbsOptimizedSupportUnsupported
+	}
+	return support != ec2.EbsOptimizedSupportDefault
+}
@@ -87,7 +87,7 @@ var _ = BeforeSuite(func() {
 	awsEnv = test.NewEnvironment(ctx, env)
 	fakeClock = &clock.FakeClock{}
 	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
-		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
+		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider, awsEnv.AlertTracker, awsEnv.UnavailableOfferingsCache)
 	cluster = state.NewCluster(fakeClock, env.Client)
 	prov = provisioning.NewProvisioner(env.Client, events.NewRecorder(&record.FakeRecorder{}), cloudProvider, cluster)
 })
@@ -230,12 +230,19 @@ var _ = Describe("InstanceTypeProvider", func() {
 			// Well Known to AWS
 			v1.LabelInstanceHypervisor:                   "nitro",
 			v1.LabelInstanceEncryptionInTransitSupported: "true",
+			v1.LabelInstancePTPSupported:                 "false",
+			v1.LabelInstanceTrunkingCompatible:           "true",
+			v1.LabelInstanceNitro:                        "true",
+			v1.LabelInstanceEBSNVMe:                      "required",
+			v1.LabelInstanceENASupport:                   "required",
 			v1.LabelInstanceCategory:                     "g",
 			v1.LabelInstanceGeneration:                   "4",
 			v1.LabelInstanceFamily:                       "g4dn",
 			v1.LabelInstanceSize:                         "8xlarge",
 			v1.LabelInstanceCPU:                          "32",
 			v1.LabelInstanceCPUManufacturer:              "intel",
+			v1.LabelInstanceCPUBaseline:                  "600",
+			v1.LabelInstanceBurstable:                    "false",
 			v1.LabelInstanceMemory:                       "131072",
 			v1.LabelInstanceEBSBandwidth:                 "9500",
 			v1.LabelInstanceNetworkBandwidth:             "50000",
@@ -270,6 +277,14 @@ var _ = Describe("InstanceTypeProvider", func() {
 			ExpectScheduled(ctx, env.Client, pod)
 		}
 	})
+	It("should launch instance types with a PTP hardware clock for pods that select them", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{v1.LabelInstancePTPSupported: "true"}})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "m6idn.32xlarge"))
+		Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstancePTPSupported, "true"))
+	})
 	It("should support combined instance type labels", func() {
 		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
 
@@ -285,12 +300,18 @@ var _ = Describe("InstanceTypeProvider", func() {
 			// Well Known to AWS
 			v1.LabelInstanceHypervisor:                   "nitro",
 			v1.LabelInstanceEncryptionInTransitSupported: "true",
+			v1.LabelInstancePTPSupported:                 "false",
+			v1.LabelInstanceTrunkingCompatible:           "true",
+			v1.LabelInstanceNitro:                        "true",
+			v1.LabelInstanceEBSNVMe:                      "required",
+			v1.LabelInstanceENASupport:                   "required",
 			v1.LabelInstanceCategory:                     "g",
 			v1.LabelInstanceGeneration:                   "4",
 			v1.LabelInstanceFamily:                       "g4dn",
 			v1.LabelInstanceSize:                         "8xlarge",
 			v1.LabelInstanceCPU:                          "32",
 			v1.LabelInstanceCPUManufacturer:              "intel",
+			v1.LabelInstanceBurstable:                    "false",
 			v1.LabelInstanceMemory:                       "131072",
 			v1.LabelInstanceEBSBandwidth:                 "9500",
 			v1.LabelInstanceNetworkBandwidth:             "50000",
@@ -316,6 +337,7 @@ var _ = Describe("InstanceTypeProvider", func() {
 					v1.LabelInstanceAcceleratorCount,
 					v1.LabelInstanceAcceleratorName,
 					v1.LabelInstanceAcceleratorManufacturer,
+					v1.LabelInstanceCPUBaseline,
 					corev1.LabelWindowsBuild,
 				)).UnsortedList(), lo.Keys(karpv1.NormalizedLabels)...)))
 
@@ -338,12 +360,18 @@ var _ = Describe("InstanceTypeProvider", func() {
 			// Well Known to AWS
 			v1.LabelInstanceHypervisor:                   "nitro",
 			v1.LabelInstanceEncryptionInTransitSupported: "true",
+			v1.LabelInstancePTPSupported:                 "false",
+			v1.LabelInstanceTrunkingCompatible:           "true",
+			v1.LabelInstanceNitro:                        "true",
+			v1.LabelInstanceEBSNVMe:                      "required",
+			v1.LabelInstanceENASupport:                   "required",
 			v1.LabelInstanceCategory:                     "inf",
 			v1.LabelInstanceGeneration:                   "1",
 			v1.LabelInstanceFamily:                       "inf1",
 			v1.LabelInstanceSize:                         "2xlarge",
 			v1.LabelInstanceCPU:                          "8",
 			v1.LabelInstanceCPUManufacturer:              "intel",
+			v1.LabelInstanceBurstable:                    "false",
 			v1.LabelInstanceMemory:                       "16384",
 			v1.LabelInstanceEBSBandwidth:                 "4750",
 			v1.LabelInstanceNetworkBandwidth:             "5000",
@@ -367,6 +395,7 @@ var _ = Describe("InstanceTypeProvider", func() {
 			v1.LabelInstanceGPUManufacturer,
 			v1.LabelInstanceGPUMemory,
 			v1.LabelInstanceLocalNVME,
+			v1.LabelInstanceCPUBaseline,
 			corev1.LabelWindowsBuild,
 		)).UnsortedList(), lo.Keys(karpv1.NormalizedLabels)...)
 		Expect(lo.Keys(nodeSelector)).To(ContainElements(expectedLabels))
@@ -619,6 +648,97 @@ var _ = Describe("InstanceTypeProvider", func() {
 		}
 		Expect(supportsPodENI()).To(Equal(true))
 	})
+	It("should only return trunking compatible instance types when security groups for pods are enabled", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			SecurityGroupsForPods: lo.ToPtr(true),
+		}))
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass.Spec.Kubelet, nodeClass)
+		Expect(err).To(BeNil())
+		Expect(instanceTypes).ToNot(BeEmpty())
+		for _, it := range instanceTypes {
+			Expect(it.Requirements.Get(v1.LabelInstanceTrunkingCompatible).Any()).To(Equal("true"))
+			Expect(it.Capacity).To(HaveKey(v1.ResourceAWSPodENI))
+			Expect(it.Capacity.Name(v1.ResourceAWSPodENI, resource.DecimalSI).Value()).To(BeNumerically(">", 0))
+		}
+		Expect(lo.Map(instanceTypes, func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })).ToNot(ContainElement("t3.large"))
+	})
+	It("should label instance types that aren't trunking compatible", func() {
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass.Spec.Kubelet, nodeClass)
+		Expect(err).To(BeNil())
+		t3Large, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "t3.large" })
+		Expect(ok).To(BeTrue())
+		Expect(t3Large.Requirements.Get(v1.LabelInstanceTrunkingCompatible).Any()).To(Equal("false"))
+	})
+	It("should label burstable instance types with their baseline CPU utilization", func() {
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass.Spec.Kubelet, nodeClass)
+		Expect(err).To(BeNil())
+		t3Large, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "t3.large" })
+		Expect(ok).To(BeTrue())
+		Expect(t3Large.Requirements.Get(v1.LabelInstanceBurstable).Any()).To(Equal("true"))
+		Expect(t3Large.Requirements.Get(v1.LabelInstanceCPUBaseline).Any()).To(Equal("600"))
+		m5Large, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+		Expect(ok).To(BeTrue())
+		Expect(m5Large.Requirements.Get(v1.LabelInstanceBurstable).Any()).To(Equal("false"))
+		Expect(m5Large.Requirements.Has(v1.LabelInstanceCPUBaseline)).To(BeTrue())
+		Expect(m5Large.Requirements.Get(v1.LabelInstanceCPUBaseline).Operator()).To(Equal(corev1.NodeSelectorOpDoesNotExist))
+	})
+	Context("EBS Optimized", func() {
+		BeforeEach(func() {
+			ebsOptimizedSupport := map[string]string{
+				"m5.large":  ec2.EbsOptimizedSupportDefault,
+				"t3.large":  ec2.EbsOptimizedSupportSupported,
+				"c6g.large": ec2.EbsOptimizedSupportUnsupported,
+			}
+			out, err := awsEnv.EC2API.DescribeInstanceTypesWithContext(ctx, &ec2.DescribeInstanceTypesInput{})
+			Expect(err).To(BeNil())
+			var instanceTypes []*ec2.InstanceTypeInfo
+			for _, info := range out.InstanceTypes {
+				support, ok := ebsOptimizedSupport[aws.StringValue(info.InstanceType)]
+				if !ok {
+					continue
+				}
+				info = lo.ToPtr(*info)
+				info.EbsInfo = lo.ToPtr(*info.EbsInfo)
+				info.EbsInfo.EbsOptimizedSupport = aws.String(support)
+				instanceTypes = append(instanceTypes, info)
+			}
+			Expect(instanceTypes).To(HaveLen(len(ebsOptimizedSupport)))
+			awsEnv.EC2API.DescribeInstanceTypesOutput.Set(&ec2.DescribeInstanceTypesOutput{InstanceTypes: instanceTypes})
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+		})
+		It("should return all instance types when the EBS optimization isn't set", func() {
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass.Spec.Kubelet, nodeClass)
+			Expect(err).To(BeNil())
+			Expect(lo.Map(instanceTypes, func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })).To(ConsistOf("m5.large", "t3.large", "c6g.large"))
+		})
+		It("should exclude instance types that don't support EBS optimization when it's enabled", func() {
+			nodeClass.Spec.EBSOptimized = lo.ToPtr(true)
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass.Spec.Kubelet, nodeClass)
+			Expect(err).To(BeNil())
+			Expect(lo.Map(instanceTypes, func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })).To(ConsistOf("m5.large", "t3.large"))
+		})
+		It("should exclude instance types that are always EBS-optimized when it's disabled", func() {
+			nodeClass.Spec.EBSOptimized = lo.ToPtr(false)
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass.Spec.Kubelet, nodeClass)
+			Expect(err).To(BeNil())
+			Expect(lo.Map(instanceTypes, func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })).To(ConsistOf("t3.large", "c6g.large"))
+		})
+	})
+	It("should label instance types with their nitro, EBS NVMe and ENA support", func() {
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass.Spec.Kubelet, nodeClass)
+		Expect(err).To(BeNil())
+		p3, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "p3.8xlarge" })
+		Expect(ok).To(BeTrue())
+		Expect(p3.Requirements.Get(v1.LabelInstanceHypervisor).Any()).To(Equal("xen"))
+		Expect(p3.Requirements.Get(v1.LabelInstanceNitro).Any()).To(Equal("false"))
+		Expect(p3.Requirements.Get(v1.LabelInstanceEBSNVMe).Any()).To(Equal("unsupported"))
+		Expect(p3.Requirements.Get(v1.LabelInstanceENASupport).Any()).To(Equal("supported"))
+		m5Metal, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.metal" })
+		Expect(ok).To(BeTrue())
+		Expect(m5Metal.Requirements.Get(v1.LabelInstanceNitro).Any()).To(Equal("true"))
+		Expect(m5Metal.Requirements.Get(v1.LabelInstanceEBSNVMe).Any()).To(Equal("required"))
+		Expect(m5Metal.Requirements.Get(v1.LabelInstanceENASupport).Any()).To(Equal("required"))
+	})
 	It("should launch vpc.amazonaws.com/PrivateIPv4Address on a compatible instance type", func() {
 		nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "windows2022@latest"}}
 		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
@@ -918,12 +1038,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 				fake.DefaultRegion,
 				nodeClass.Spec.BlockDeviceMappings,
 				nodeClass.Spec.InstanceStorePolicy,
+				nodeClass.Spec.Hugepages,
+				nodeClass.Spec.Neuron,
+				nodeClass.Spec.VPCCNI,
 				nodeClass.Spec.Kubelet.MaxPods,
 				nodeClass.Spec.Kubelet.PodsPerCore,
 				nodeClass.Spec.Kubelet.KubeReserved,
 				nodeClass.Spec.Kubelet.SystemReserved,
 				nodeClass.Spec.Kubelet.EvictionHard,
 				nodeClass.Spec.Kubelet.EvictionSoft,
+				nodeClass.Spec.Kubelet.ReservedSizing,
 				amiFamily,
 				nil,
 			)
@@ -941,18 +1065,142 @@ var _ = Describe("InstanceTypeProvider", func() {
 				fake.DefaultRegion,
 				windowsNodeClass.Spec.BlockDeviceMappings,
 				windowsNodeClass.Spec.InstanceStorePolicy,
+				windowsNodeClass.Spec.Hugepages,
+				windowsNodeClass.Spec.Neuron,
+				windowsNodeClass.Spec.VPCCNI,
 				nodeClass.Spec.Kubelet.MaxPods,
 				nodeClass.Spec.Kubelet.PodsPerCore,
 				nodeClass.Spec.Kubelet.KubeReserved,
 				nodeClass.Spec.Kubelet.SystemReserved,
 				nodeClass.Spec.Kubelet.EvictionHard,
 				nodeClass.Spec.Kubelet.EvictionSoft,
+				nodeClass.Spec.Kubelet.ReservedSizing,
+				amiFamily,
+				nil,
+			)
+			Expect(it.Capacity.Pods().Value()).To(BeNumerically("==", 110))
+		}
+	})
+	It("should advertise hugepages and take them out of the memory capacity", func() {
+		withoutHugepages, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass.Spec.Kubelet, nodeClass)
+		Expect(err).To(BeNil())
+		nodeClass.Spec.Hugepages = []v1.Hugepages{{Size: "2Mi", Count: 512}, {Size: "1Gi", Count: 2}}
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass.Spec.Kubelet, nodeClass)
+		Expect(err).To(BeNil())
+		Expect(instanceTypes).ToNot(BeEmpty())
+		for _, it := range instanceTypes {
+			Expect(it.Capacity.Name("hugepages-2Mi", resource.BinarySI).Value()).To(BeNumerically("==", 1<<30))
+			Expect(it.Capacity.Name("hugepages-1Gi", resource.BinarySI).Value()).To(BeNumerically("==", 2<<30))
+			original, ok := lo.Find(withoutHugepages, func(i *corecloudprovider.InstanceType) bool { return i.Name == it.Name })
+			Expect(ok).To(BeTrue())
+			expected := original.Capacity.Memory().DeepCopy()
+			expected.Sub(resource.MustParse("3Gi"))
+			if expected.Sign() < 0 {
+				expected = resource.Quantity{}
+			}
+			Expect(it.Capacity.Memory().Value()).To(Equal(expected.Value()))
+		}
+	})
+	It("should not advertise hugepages if the AMI Family doesn't allocate them", func() {
+		windowsNodeClass.Spec.Hugepages = []v1.Hugepages{{Size: "2Mi", Count: 512}}
+		instanceInfo, err := awsEnv.EC2API.DescribeInstanceTypesWithContext(ctx, &ec2.DescribeInstanceTypesInput{})
+		Expect(err).To(BeNil())
+		for _, info := range instanceInfo.InstanceTypes {
+			amiFamily := amifamily.GetAMIFamily(lo.ToPtr(windowsNodeClass.AMIFamily()), &amifamily.Options{})
+			it := instancetype.NewInstanceType(ctx,
+				info,
+				fake.DefaultRegion,
+				windowsNodeClass.Spec.BlockDeviceMappings,
+				windowsNodeClass.Spec.InstanceStorePolicy,
+				windowsNodeClass.Spec.Hugepages,
+				windowsNodeClass.Spec.Neuron,
+				windowsNodeClass.Spec.VPCCNI,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
 				amiFamily,
 				nil,
 			)
+			Expect(it.Capacity).ToNot(HaveKey(corev1.ResourceName("hugepages-2Mi")))
+		}
+	})
+	It("should exclude instance types whose maxPods exceeds the pod IP capacity of the VPC CNI", func() {
+		nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{MaxPods: lo.ToPtr[int32](110)}
+		nodeClass.Spec.VPCCNI = &v1.VPCCNIConfiguration{}
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass.Spec.Kubelet, nodeClass)
+		Expect(err).To(BeNil())
+		names := lo.Map(instanceTypes, func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })
+		// t3.large can assign 3 * (12 - 1) IP addresses to pods, and m5.metal 15 * (50 - 1)
+		Expect(names).ToNot(ContainElement("t3.large"))
+		Expect(names).To(ContainElement("m5.metal"))
+	})
+	It("should not exclude instance types whose pod IP capacity fits maxPods with prefix delegation", func() {
+		nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{MaxPods: lo.ToPtr[int32](110)}
+		nodeClass.Spec.VPCCNI = &v1.VPCCNIConfiguration{PrefixDelegation: lo.ToPtr(true)}
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass.Spec.Kubelet, nodeClass)
+		Expect(err).To(BeNil())
+		Expect(lo.Map(instanceTypes, func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })).To(ContainElement("t3.large"))
+	})
+	It("should not exclude instance types for pod density when the EC2NodeClass doesn't describe its VPC CNI", func() {
+		nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{MaxPods: lo.ToPtr[int32](110)}
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass.Spec.Kubelet, nodeClass)
+		Expect(err).To(BeNil())
+		Expect(lo.Map(instanceTypes, func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })).To(ContainElement("t3.large"))
+	})
+	It("should advertise the NeuronCores of Neuron instance types", func() {
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass.Spec.Kubelet, nodeClass)
+		Expect(err).To(BeNil())
+		neuronCores := lo.SliceToMap(instanceTypes, func(it *corecloudprovider.InstanceType) (string, int64) {
+			return it.Name, it.Capacity.Name(v1.ResourceAWSNeuronCore, resource.DecimalSI).Value()
+		})
+		Expect(neuronCores).To(HaveKeyWithValue("inf1.2xlarge", int64(4)))
+		Expect(neuronCores).To(HaveKeyWithValue("inf1.6xlarge", int64(16)))
+		Expect(neuronCores).To(HaveKeyWithValue("trn1.2xlarge", int64(2)))
+		Expect(neuronCores).To(HaveKeyWithValue("m5.large", int64(0)))
+	})
+	It("should advertise logical NeuronCores when the Neuron runtime groups them", func() {
+		nodeClass.Spec.Neuron = &v1.NeuronConfiguration{LogicalNeuronCoreConfig: lo.ToPtr[int32](2)}
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass.Spec.Kubelet, nodeClass)
+		Expect(err).To(BeNil())
+		neuronCores := lo.SliceToMap(instanceTypes, func(it *corecloudprovider.InstanceType) (string, int64) {
+			return it.Name, it.Capacity.Name(v1.ResourceAWSNeuronCore, resource.DecimalSI).Value()
+		})
+		Expect(neuronCores).To(HaveKeyWithValue("inf1.6xlarge", int64(8)))
+		Expect(neuronCores).To(HaveKeyWithValue("trn1.2xlarge", int64(1)))
+	})
+	It("should launch instances for aws.amazon.com/neuroncore resource requests", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		pod := coretest.UnschedulablePod(coretest.PodOptions{
+			ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{v1.ResourceAWSNeuronCore: resource.MustParse("8")},
+				Limits:   corev1.ResourceList{v1.ResourceAWSNeuronCore: resource.MustParse("8")},
+			},
+		})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "inf1.6xlarge"))
+	})
+	It("should set pods to 110 if the node IP family is IPv6", func() {
+		nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{NodeIPFamily: lo.ToPtr(v1.NodeIPFamilyIPv6)}
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass.Spec.Kubelet, nodeClass)
+		Expect(err).To(BeNil())
+		Expect(instanceTypes).ToNot(BeEmpty())
+		for _, it := range instanceTypes {
 			Expect(it.Capacity.Pods().Value()).To(BeNumerically("==", 110))
 		}
 	})
+	It("should use ENI-based pod density if the node IP family is DualStack", func() {
+		nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{NodeIPFamily: lo.ToPtr(v1.NodeIPFamilyDualStack)}
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass.Spec.Kubelet, nodeClass)
+		Expect(err).To(BeNil())
+		m5Large, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+		Expect(ok).To(BeTrue())
+		Expect(m5Large.Capacity.Pods().Value()).To(BeNumerically("==", 29))
+	})
 	Context("Metrics", func() {
 		It("should expose vcpu metrics for instance types", func() {
 			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass.Spec.Kubelet, nodeClass)
@@ -1037,6 +1285,33 @@ var _ = Describe("InstanceTypeProvider", func() {
 		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
 		ExpectScheduled(ctx, env.Client, pod)
 	})
+	It("should translate the zones of subnets in another account through their zone ID", func() {
+		// The controller's account names the physical zones tstz1-1a and tstz1-1b the other way around
+		awsEnv.EC2API.DescribeAvailabilityZonesOutput.Set(&ec2.DescribeAvailabilityZonesOutput{AvailabilityZones: []*ec2.AvailabilityZone{
+			{ZoneName: aws.String("test-zone-1a"), ZoneId: aws.String("tstz1-1b"), ZoneType: aws.String("availability-zone")},
+			{ZoneName: aws.String("test-zone-1b"), ZoneId: aws.String("tstz1-1a"), ZoneType: aws.String("availability-zone")},
+			{ZoneName: aws.String("test-zone-1c"), ZoneId: aws.String("tstz1-1c"), ZoneType: aws.String("availability-zone")},
+		}})
+		awsEnv.EC2API.DescribeInstanceTypeOfferingsOutput.Set(&ec2.DescribeInstanceTypeOfferingsOutput{
+			InstanceTypeOfferings: []*ec2.InstanceTypeOffering{
+				{InstanceType: aws.String("m5.large"), Location: aws.String("test-zone-1b")},
+			},
+		})
+		Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+		nodeClass.Spec.AssumeRoleARN = lo.ToPtr("arn:aws:iam::111122223333:role/KarpenterWorkloadRole")
+		nodeClass.Status.Subnets = []v1.Subnet{{ID: "subnet-test1", Zone: "test-zone-1a", ZoneID: "tstz1-1a"}}
+
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass.Spec.Kubelet, nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		instanceType, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+		Expect(ok).To(BeTrue())
+		available := instanceType.Offerings.Available()
+		Expect(available).ToNot(BeEmpty())
+		for _, offering := range available {
+			Expect(offering.Requirements.Get(corev1.LabelTopologyZone).Any()).To(Equal("test-zone-1a"))
+			Expect(offering.Requirements.Get(v1.LabelTopologyZoneID).Any()).To(Equal("tstz1-1a"))
+		}
+	})
 	Context("Overhead", func() {
 		var info *ec2.InstanceTypeInfo
 		BeforeEach(func() {
@@ -1061,12 +1336,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.Hugepages,
+					nodeClass.Spec.Neuron,
+					nodeClass.Spec.VPCCNI,
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
 					nodeClass.Spec.Kubelet.SystemReserved,
 					nodeClass.Spec.Kubelet.EvictionHard,
 					nodeClass.Spec.Kubelet.EvictionSoft,
+					nodeClass.Spec.Kubelet.ReservedSizing,
 					amiFamily,
 					nil,
 				)
@@ -1088,12 +1367,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.Hugepages,
+					nodeClass.Spec.Neuron,
+					nodeClass.Spec.VPCCNI,
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
 					nodeClass.Spec.Kubelet.SystemReserved,
 					nodeClass.Spec.Kubelet.EvictionHard,
 					nodeClass.Spec.Kubelet.EvictionSoft,
+					nodeClass.Spec.Kubelet.ReservedSizing,
 					amiFamily,
 					nil,
 				)
@@ -1101,6 +1384,81 @@ var _ = Describe("InstanceTypeProvider", func() {
 				Expect(it.Overhead.SystemReserved.Memory().String()).To(Equal("20Gi"))
 				Expect(it.Overhead.SystemReserved.StorageEphemeral().String()).To(Equal("10Gi"))
 			})
+			It("should size system reserved by the curve of reservedSizing", func() {
+				nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{
+					ReservedSizing: &v1.ReservedSizing{
+						SystemReserved: &v1.ReservedCurve{
+							CPU: []v1.ReservedRange{
+								{UpTo: lo.ToPtr("1"), Percentage: "10%"},
+								{Percentage: "5%"},
+							},
+							Memory: []v1.ReservedRange{
+								{UpTo: lo.ToPtr("4Gi"), Percentage: "25%"},
+								{UpTo: lo.ToPtr("8Gi"), Percentage: "20%"},
+							},
+						},
+					},
+				}
+				amiFamily := amifamily.GetAMIFamily(lo.ToPtr(nodeClass.AMIFamily()), &amifamily.Options{})
+				it := instancetype.NewInstanceType(ctx,
+					info,
+					fake.DefaultRegion,
+					nodeClass.Spec.BlockDeviceMappings,
+					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.Hugepages,
+					nodeClass.Spec.Neuron,
+					nodeClass.Spec.VPCCNI,
+					nodeClass.Spec.Kubelet.MaxPods,
+					nodeClass.Spec.Kubelet.PodsPerCore,
+					nodeClass.Spec.Kubelet.KubeReserved,
+					nodeClass.Spec.Kubelet.SystemReserved,
+					nodeClass.Spec.Kubelet.EvictionHard,
+					nodeClass.Spec.Kubelet.EvictionSoft,
+					nodeClass.Spec.Kubelet.ReservedSizing,
+					amiFamily,
+					nil,
+				)
+				// m5.xlarge
+				// cpu = 10% of 1000m + 5% of 3000m = 250m
+				// memory = 25% of 4096Mi + 20% of 4096Mi = 1843.2Mi
+				Expect(it.Overhead.SystemReserved.Cpu().String()).To(Equal("250m"))
+				Expect(it.Overhead.SystemReserved.Memory().String()).To(Equal("1844Mi"))
+				Expect(it.Overhead.SystemReserved.StorageEphemeral().String()).To(Equal("0"))
+			})
+			It("should override the system reserved of reservedSizing when specified", func() {
+				nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{
+					SystemReserved: map[string]string{
+						string(corev1.ResourceMemory): "1Gi",
+					},
+					ReservedSizing: &v1.ReservedSizing{
+						SystemReserved: &v1.ReservedCurve{
+							CPU:          []v1.ReservedRange{{Percentage: "10%"}},
+							MemoryPerPod: lo.ToPtr("10Mi"),
+						},
+					},
+				}
+				amiFamily := amifamily.GetAMIFamily(lo.ToPtr(nodeClass.AMIFamily()), &amifamily.Options{})
+				it := instancetype.NewInstanceType(ctx,
+					info,
+					fake.DefaultRegion,
+					nodeClass.Spec.BlockDeviceMappings,
+					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.Hugepages,
+					nodeClass.Spec.Neuron,
+					nodeClass.Spec.VPCCNI,
+					nodeClass.Spec.Kubelet.MaxPods,
+					nodeClass.Spec.Kubelet.PodsPerCore,
+					nodeClass.Spec.Kubelet.KubeReserved,
+					nodeClass.Spec.Kubelet.SystemReserved,
+					nodeClass.Spec.Kubelet.EvictionHard,
+					nodeClass.Spec.Kubelet.EvictionSoft,
+					nodeClass.Spec.Kubelet.ReservedSizing,
+					amiFamily,
+					nil,
+				)
+				Expect(it.Overhead.SystemReserved.Cpu().String()).To(Equal("400m"))
+				Expect(it.Overhead.SystemReserved.Memory().String()).To(Equal("1Gi"))
+			})
 		})
 		Context("Kube Reserved Resources", func() {
 			It("should use defaults when no kubelet is specified", func() {
@@ -1111,12 +1469,43 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.Hugepages,
+					nodeClass.Spec.Neuron,
+					nodeClass.Spec.VPCCNI,
+					nodeClass.Spec.Kubelet.MaxPods,
+					nodeClass.Spec.Kubelet.PodsPerCore,
+					nodeClass.Spec.Kubelet.KubeReserved,
+					nodeClass.Spec.Kubelet.SystemReserved,
+					nodeClass.Spec.Kubelet.EvictionHard,
+					nodeClass.Spec.Kubelet.EvictionSoft,
+					nodeClass.Spec.Kubelet.ReservedSizing,
+					amiFamily,
+					nil,
+				)
+				Expect(it.Overhead.KubeReserved.Cpu().String()).To(Equal("80m"))
+				Expect(it.Overhead.KubeReserved.Memory().String()).To(Equal("893Mi"))
+				Expect(it.Overhead.KubeReserved.StorageEphemeral().String()).To(Equal("1Gi"))
+			})
+			It("should use defaults when reservedSizing doesn't specify a kube reserved curve", func() {
+				nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{
+					ReservedSizing: &v1.ReservedSizing{},
+				}
+				amiFamily := amifamily.GetAMIFamily(lo.ToPtr(nodeClass.AMIFamily()), &amifamily.Options{})
+				it := instancetype.NewInstanceType(ctx,
+					info,
+					fake.DefaultRegion,
+					nodeClass.Spec.BlockDeviceMappings,
+					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.Hugepages,
+					nodeClass.Spec.Neuron,
+					nodeClass.Spec.VPCCNI,
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
 					nodeClass.Spec.Kubelet.SystemReserved,
 					nodeClass.Spec.Kubelet.EvictionHard,
 					nodeClass.Spec.Kubelet.EvictionSoft,
+					nodeClass.Spec.Kubelet.ReservedSizing,
 					amiFamily,
 					nil,
 				)
@@ -1124,6 +1513,41 @@ var _ = Describe("InstanceTypeProvider", func() {
 				Expect(it.Overhead.KubeReserved.Memory().String()).To(Equal("893Mi"))
 				Expect(it.Overhead.KubeReserved.StorageEphemeral().String()).To(Equal("1Gi"))
 			})
+			It("should size kube reserved by the curve of reservedSizing", func() {
+				nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{
+					ReservedSizing: &v1.ReservedSizing{
+						KubeReserved: &v1.ReservedCurve{
+							CPU:          []v1.ReservedRange{{Percentage: "1%"}},
+							MemoryPerPod: lo.ToPtr("20Mi"),
+						},
+					},
+				}
+				amiFamily := amifamily.GetAMIFamily(lo.ToPtr(nodeClass.AMIFamily()), &amifamily.Options{})
+				it := instancetype.NewInstanceType(ctx,
+					info,
+					fake.DefaultRegion,
+					nodeClass.Spec.BlockDeviceMappings,
+					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.Hugepages,
+					nodeClass.Spec.Neuron,
+					nodeClass.Spec.VPCCNI,
+					nodeClass.Spec.Kubelet.MaxPods,
+					nodeClass.Spec.Kubelet.PodsPerCore,
+					nodeClass.Spec.Kubelet.KubeReserved,
+					nodeClass.Spec.Kubelet.SystemReserved,
+					nodeClass.Spec.Kubelet.EvictionHard,
+					nodeClass.Spec.Kubelet.EvictionSoft,
+					nodeClass.Spec.Kubelet.ReservedSizing,
+					amiFamily,
+					nil,
+				)
+				// m5.xlarge
+				// cpu = 1% of 4000m = 40m
+				// memory = 58 pods * 20Mi = 1160Mi
+				Expect(it.Overhead.KubeReserved.Cpu().String()).To(Equal("40m"))
+				Expect(it.Overhead.KubeReserved.Memory().String()).To(Equal("1160Mi"))
+				Expect(it.Overhead.KubeReserved.StorageEphemeral().String()).To(Equal("1Gi"))
+			})
 			It("should override kube reserved when specified", func() {
 				nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{
 					SystemReserved: map[string]string{
@@ -1143,12 +1567,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.Hugepages,
+					nodeClass.Spec.Neuron,
+					nodeClass.Spec.VPCCNI,
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
 					nodeClass.Spec.Kubelet.SystemReserved,
 					nodeClass.Spec.Kubelet.EvictionHard,
 					nodeClass.Spec.Kubelet.EvictionSoft,
+					nodeClass.Spec.Kubelet.ReservedSizing,
 					amiFamily,
 					nil,
 				)
@@ -1182,12 +1610,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.Hugepages,
+						nodeClass.Spec.Neuron,
+						nodeClass.Spec.VPCCNI,
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
 						nodeClass.Spec.Kubelet.SystemReserved,
 						nodeClass.Spec.Kubelet.EvictionHard,
 						nodeClass.Spec.Kubelet.EvictionSoft,
+						nodeClass.Spec.Kubelet.ReservedSizing,
 						amiFamily,
 						nil,
 					)
@@ -1211,12 +1643,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.Hugepages,
+						nodeClass.Spec.Neuron,
+						nodeClass.Spec.VPCCNI,
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
 						nodeClass.Spec.Kubelet.SystemReserved,
 						nodeClass.Spec.Kubelet.EvictionHard,
 						nodeClass.Spec.Kubelet.EvictionSoft,
+						nodeClass.Spec.Kubelet.ReservedSizing,
 						amiFamily,
 						nil,
 					)
@@ -1240,12 +1676,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.Hugepages,
+						nodeClass.Spec.Neuron,
+						nodeClass.Spec.VPCCNI,
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
 						nodeClass.Spec.Kubelet.SystemReserved,
 						nodeClass.Spec.Kubelet.EvictionHard,
 						nodeClass.Spec.Kubelet.EvictionSoft,
+						nodeClass.Spec.Kubelet.ReservedSizing,
 						amiFamily,
 						nil,
 					)
@@ -1269,12 +1709,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.Hugepages,
+						nodeClass.Spec.Neuron,
+						nodeClass.Spec.VPCCNI,
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
 						nodeClass.Spec.Kubelet.SystemReserved,
 						nodeClass.Spec.Kubelet.EvictionHard,
 						nodeClass.Spec.Kubelet.EvictionSoft,
+						nodeClass.Spec.Kubelet.ReservedSizing,
 						amiFamily,
 						nil,
 					)
@@ -1300,12 +1744,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.Hugepages,
+						nodeClass.Spec.Neuron,
+						nodeClass.Spec.VPCCNI,
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
 						nodeClass.Spec.Kubelet.SystemReserved,
 						nodeClass.Spec.Kubelet.EvictionHard,
 						nodeClass.Spec.Kubelet.EvictionSoft,
+						nodeClass.Spec.Kubelet.ReservedSizing,
 						amiFamily,
 						nil,
 					)
@@ -1332,12 +1780,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.Hugepages,
+						nodeClass.Spec.Neuron,
+						nodeClass.Spec.VPCCNI,
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
 						nodeClass.Spec.Kubelet.SystemReserved,
 						nodeClass.Spec.Kubelet.EvictionHard,
 						nodeClass.Spec.Kubelet.EvictionSoft,
+						nodeClass.Spec.Kubelet.ReservedSizing,
 						amiFamily,
 						nil,
 					)
@@ -1361,12 +1813,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.Hugepages,
+						nodeClass.Spec.Neuron,
+						nodeClass.Spec.VPCCNI,
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
 						nodeClass.Spec.Kubelet.SystemReserved,
 						nodeClass.Spec.Kubelet.EvictionHard,
 						nodeClass.Spec.Kubelet.EvictionSoft,
+						nodeClass.Spec.Kubelet.ReservedSizing,
 						amiFamily,
 						nil,
 					)
@@ -1394,12 +1850,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.Hugepages,
+						nodeClass.Spec.Neuron,
+						nodeClass.Spec.VPCCNI,
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
 						nodeClass.Spec.Kubelet.SystemReserved,
 						nodeClass.Spec.Kubelet.EvictionHard,
 						nodeClass.Spec.Kubelet.EvictionSoft,
+						nodeClass.Spec.Kubelet.ReservedSizing,
 						amiFamily,
 						nil,
 					)
@@ -1414,12 +1874,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.Hugepages,
+					nodeClass.Spec.Neuron,
+					nodeClass.Spec.VPCCNI,
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
 					nodeClass.Spec.Kubelet.SystemReserved,
 					nodeClass.Spec.Kubelet.EvictionHard,
 					nodeClass.Spec.Kubelet.EvictionSoft,
+					nodeClass.Spec.Kubelet.ReservedSizing,
 					amiFamily,
 					nil,
 				)
@@ -1448,12 +1912,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.Hugepages,
+					nodeClass.Spec.Neuron,
+					nodeClass.Spec.VPCCNI,
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
 					nodeClass.Spec.Kubelet.SystemReserved,
 					nodeClass.Spec.Kubelet.EvictionHard,
 					nodeClass.Spec.Kubelet.EvictionSoft,
+					nodeClass.Spec.Kubelet.ReservedSizing,
 					amiFamily,
 					nil,
 				)
@@ -1480,12 +1948,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.Hugepages,
+					nodeClass.Spec.Neuron,
+					nodeClass.Spec.VPCCNI,
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
 					nodeClass.Spec.Kubelet.SystemReserved,
 					nodeClass.Spec.Kubelet.EvictionHard,
 					nodeClass.Spec.Kubelet.EvictionSoft,
+					nodeClass.Spec.Kubelet.ReservedSizing,
 					amiFamily,
 					nil,
 				)
@@ -1512,12 +1984,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.Hugepages,
+					nodeClass.Spec.Neuron,
+					nodeClass.Spec.VPCCNI,
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
 					nodeClass.Spec.Kubelet.SystemReserved,
 					nodeClass.Spec.Kubelet.EvictionHard,
 					nodeClass.Spec.Kubelet.EvictionSoft,
+					nodeClass.Spec.Kubelet.ReservedSizing,
 					amiFamily,
 					nil,
 				)
@@ -1536,12 +2012,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.Hugepages,
+						nodeClass.Spec.Neuron,
+						nodeClass.Spec.VPCCNI,
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
 						nodeClass.Spec.Kubelet.SystemReserved,
 						nodeClass.Spec.Kubelet.EvictionHard,
 						nodeClass.Spec.Kubelet.EvictionSoft,
+						nodeClass.Spec.Kubelet.ReservedSizing,
 						amiFamily,
 						nil,
 					)
@@ -1554,12 +2034,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.Hugepages,
+						nodeClass.Spec.Neuron,
+						nodeClass.Spec.VPCCNI,
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
 						nodeClass.Spec.Kubelet.SystemReserved,
 						nodeClass.Spec.Kubelet.EvictionHard,
 						nodeClass.Spec.Kubelet.EvictionSoft,
+						nodeClass.Spec.Kubelet.ReservedSizing,
 						amiFamily,
 						nil,
 					)
@@ -1580,12 +2064,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.Hugepages,
+					nodeClass.Spec.Neuron,
+					nodeClass.Spec.VPCCNI,
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
 					nodeClass.Spec.Kubelet.SystemReserved,
 					nodeClass.Spec.Kubelet.EvictionHard,
 					nodeClass.Spec.Kubelet.EvictionSoft,
+					nodeClass.Spec.Kubelet.ReservedSizing,
 					amiFamily,
 					nil,
 				)
@@ -1605,12 +2093,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.Hugepages,
+					nodeClass.Spec.Neuron,
+					nodeClass.Spec.VPCCNI,
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
 					nodeClass.Spec.Kubelet.SystemReserved,
 					nodeClass.Spec.Kubelet.EvictionHard,
 					nodeClass.Spec.Kubelet.EvictionSoft,
+					nodeClass.Spec.Kubelet.ReservedSizing,
 					amiFamily,
 					nil,
 				)
@@ -1635,12 +2127,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 				fake.DefaultRegion,
 				nodeClass.Spec.BlockDeviceMappings,
 				nodeClass.Spec.InstanceStorePolicy,
+				nodeClass.Spec.Hugepages,
+				nodeClass.Spec.Neuron,
+				nodeClass.Spec.VPCCNI,
 				nodeClass.Spec.Kubelet.MaxPods,
 				nodeClass.Spec.Kubelet.PodsPerCore,
 				nodeClass.Spec.Kubelet.KubeReserved,
 				nodeClass.Spec.Kubelet.SystemReserved,
 				nodeClass.Spec.Kubelet.EvictionHard,
 				nodeClass.Spec.Kubelet.EvictionSoft,
+				nodeClass.Spec.Kubelet.ReservedSizing,
 				amiFamily,
 				nil,
 			)
@@ -1652,6 +2148,46 @@ var _ = Describe("InstanceTypeProvider", func() {
 			maxPods := 24
 			Expect(it.Capacity.Pods().Value()).To(BeNumerically("==", maxPods))
 		})
+		It("should reserve the trunk ENI in max-pods calculation when security groups for pods are enabled", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				SecurityGroupsForPods: lo.ToPtr(true),
+			}))
+
+			instanceInfo, err := awsEnv.EC2API.DescribeInstanceTypesWithContext(ctx, &ec2.DescribeInstanceTypesInput{})
+			Expect(err).To(BeNil())
+			m5Large, ok := lo.Find(instanceInfo.InstanceTypes, func(info *ec2.InstanceTypeInfo) bool {
+				return *info.InstanceType == "m5.large"
+			})
+			Expect(ok).To(Equal(true))
+			amiFamily := amifamily.GetAMIFamily(lo.ToPtr(nodeClass.AMIFamily()), &amifamily.Options{})
+			nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{}
+			it := instancetype.NewInstanceType(ctx,
+				m5Large,
+				fake.DefaultRegion,
+				nodeClass.Spec.BlockDeviceMappings,
+				nodeClass.Spec.InstanceStorePolicy,
+				nodeClass.Spec.Hugepages,
+				nodeClass.Spec.Neuron,
+				nodeClass.Spec.VPCCNI,
+				nodeClass.Spec.Kubelet.MaxPods,
+				nodeClass.Spec.Kubelet.PodsPerCore,
+				nodeClass.Spec.Kubelet.KubeReserved,
+				nodeClass.Spec.Kubelet.SystemReserved,
+				nodeClass.Spec.Kubelet.EvictionHard,
+				nodeClass.Spec.Kubelet.EvictionSoft,
+				nodeClass.Spec.Kubelet.ReservedSizing,
+				amiFamily,
+				nil,
+			)
+			// m5.large
+			// maxInterfaces = 3
+			// maxIPv4PerInterface = 10
+			// trunk ENI = 1
+			// (3 - 1) * (10 - 1) + 2 = 20
+			maxPods := 20
+			Expect(it.Capacity.Pods().Value()).To(BeNumerically("==", maxPods))
+			Expect(it.Capacity.Name(v1.ResourceAWSPodENI, resource.DecimalSI).Value()).To(BeNumerically("==", 9))
+		})
 		It("should reserve ENIs when aws.reservedENIs is set and not go below 0 ENIs in max-pods calculation", func() {
 			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
 				ReservedENIs: lo.ToPtr(1_000_000),
@@ -1670,12 +2206,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 				fake.DefaultRegion,
 				nodeClass.Spec.BlockDeviceMappings,
 				nodeClass.Spec.InstanceStorePolicy,
+				nodeClass.Spec.Hugepages,
+				nodeClass.Spec.Neuron,
+				nodeClass.Spec.VPCCNI,
 				nodeClass.Spec.Kubelet.MaxPods,
 				nodeClass.Spec.Kubelet.PodsPerCore,
 				nodeClass.Spec.Kubelet.KubeReserved,
 				nodeClass.Spec.Kubelet.SystemReserved,
 				nodeClass.Spec.Kubelet.EvictionHard,
 				nodeClass.Spec.Kubelet.EvictionSoft,
+				nodeClass.Spec.Kubelet.ReservedSizing,
 				amiFamily,
 				nil,
 			)
@@ -1688,6 +2228,118 @@ var _ = Describe("InstanceTypeProvider", func() {
 			maxPods := 0
 			Expect(it.Capacity.Pods().Value()).To(BeNumerically("==", maxPods))
 		})
+		It("should use the reserved ENIs of the EC2NodeClass over aws.reservedENIs in max-pods calculation", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				ReservedENIs: lo.ToPtr(1),
+			}))
+
+			instanceInfo, err := awsEnv.EC2API.DescribeInstanceTypesWithContext(ctx, &ec2.DescribeInstanceTypesInput{})
+			Expect(err).To(BeNil())
+			t3Large, ok := lo.Find(instanceInfo.InstanceTypes, func(info *ec2.InstanceTypeInfo) bool {
+				return *info.InstanceType == "t3.large"
+			})
+			Expect(ok).To(Equal(true))
+			amiFamily := amifamily.GetAMIFamily(lo.ToPtr(nodeClass.AMIFamily()), &amifamily.Options{})
+			nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{}
+			nodeClass.Spec.VPCCNI = &v1.VPCCNIConfiguration{ReservedENIs: lo.ToPtr[int32](0)}
+			it := instancetype.NewInstanceType(ctx,
+				t3Large,
+				fake.DefaultRegion,
+				nodeClass.Spec.BlockDeviceMappings,
+				nodeClass.Spec.InstanceStorePolicy,
+				nodeClass.Spec.Hugepages,
+				nodeClass.Spec.Neuron,
+				nodeClass.Spec.VPCCNI,
+				nodeClass.Spec.Kubelet.MaxPods,
+				nodeClass.Spec.Kubelet.PodsPerCore,
+				nodeClass.Spec.Kubelet.KubeReserved,
+				nodeClass.Spec.Kubelet.SystemReserved,
+				nodeClass.Spec.Kubelet.EvictionHard,
+				nodeClass.Spec.Kubelet.EvictionSoft,
+				nodeClass.Spec.Kubelet.ReservedSizing,
+				amiFamily,
+				nil,
+			)
+			// t3.large
+			// maxInterfaces = 3
+			// maxIPv4PerInterface = 12
+			// reservedENIs = 0
+			// 3 * (12 - 1) + 2 = 35
+			maxPods := 35
+			Expect(it.Capacity.Pods().Value()).To(BeNumerically("==", maxPods))
+		})
+		It("should reserve IPs of each ENI when the EC2NodeClass sets reservedIPsPerENI in max-pods calculation", func() {
+			instanceInfo, err := awsEnv.EC2API.DescribeInstanceTypesWithContext(ctx, &ec2.DescribeInstanceTypesInput{})
+			Expect(err).To(BeNil())
+			t3Large, ok := lo.Find(instanceInfo.InstanceTypes, func(info *ec2.InstanceTypeInfo) bool {
+				return *info.InstanceType == "t3.large"
+			})
+			Expect(ok).To(Equal(true))
+			amiFamily := amifamily.GetAMIFamily(lo.ToPtr(nodeClass.AMIFamily()), &amifamily.Options{})
+			nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{}
+			nodeClass.Spec.VPCCNI = &v1.VPCCNIConfiguration{ReservedIPsPerENI: lo.ToPtr[int32](2)}
+			it := instancetype.NewInstanceType(ctx,
+				t3Large,
+				fake.DefaultRegion,
+				nodeClass.Spec.BlockDeviceMappings,
+				nodeClass.Spec.InstanceStorePolicy,
+				nodeClass.Spec.Hugepages,
+				nodeClass.Spec.Neuron,
+				nodeClass.Spec.VPCCNI,
+				nodeClass.Spec.Kubelet.MaxPods,
+				nodeClass.Spec.Kubelet.PodsPerCore,
+				nodeClass.Spec.Kubelet.KubeReserved,
+				nodeClass.Spec.Kubelet.SystemReserved,
+				nodeClass.Spec.Kubelet.EvictionHard,
+				nodeClass.Spec.Kubelet.EvictionSoft,
+				nodeClass.Spec.Kubelet.ReservedSizing,
+				amiFamily,
+				nil,
+			)
+			// t3.large
+			// maxInterfaces = 3
+			// maxIPv4PerInterface = 12
+			// reservedIPsPerENI = 2
+			// 3 * (12 - 1 - 2) + 2 = 29
+			maxPods := 29
+			Expect(it.Capacity.Pods().Value()).To(BeNumerically("==", maxPods))
+		})
+		It("should reserve the primary ENI when the EC2NodeClass enables custom networking in max-pods calculation", func() {
+			instanceInfo, err := awsEnv.EC2API.DescribeInstanceTypesWithContext(ctx, &ec2.DescribeInstanceTypesInput{})
+			Expect(err).To(BeNil())
+			t3Large, ok := lo.Find(instanceInfo.InstanceTypes, func(info *ec2.InstanceTypeInfo) bool {
+				return *info.InstanceType == "t3.large"
+			})
+			Expect(ok).To(Equal(true))
+			amiFamily := amifamily.GetAMIFamily(lo.ToPtr(nodeClass.AMIFamily()), &amifamily.Options{})
+			nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{}
+			nodeClass.Spec.VPCCNI = &v1.VPCCNIConfiguration{CustomNetworking: lo.ToPtr(true)}
+			it := instancetype.NewInstanceType(ctx,
+				t3Large,
+				fake.DefaultRegion,
+				nodeClass.Spec.BlockDeviceMappings,
+				nodeClass.Spec.InstanceStorePolicy,
+				nodeClass.Spec.Hugepages,
+				nodeClass.Spec.Neuron,
+				nodeClass.Spec.VPCCNI,
+				nodeClass.Spec.Kubelet.MaxPods,
+				nodeClass.Spec.Kubelet.PodsPerCore,
+				nodeClass.Spec.Kubelet.KubeReserved,
+				nodeClass.Spec.Kubelet.SystemReserved,
+				nodeClass.Spec.Kubelet.EvictionHard,
+				nodeClass.Spec.Kubelet.EvictionSoft,
+				nodeClass.Spec.Kubelet.ReservedSizing,
+				amiFamily,
+				nil,
+			)
+			// t3.large
+			// maxInterfaces = 3
+			// maxIPv4PerInterface = 12
+			// primary ENI = 1
+			// (3 - 1) * (12 - 1) + 2 = 24
+			maxPods := 24
+			Expect(it.Capacity.Pods().Value()).To(BeNumerically("==", maxPods))
+		})
 		It("should override pods-per-core value", func() {
 			instanceInfo, err := awsEnv.EC2API.DescribeInstanceTypesWithContext(ctx, &ec2.DescribeInstanceTypesInput{})
 			Expect(err).To(BeNil())
@@ -1701,12 +2353,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.Hugepages,
+					nodeClass.Spec.Neuron,
+					nodeClass.Spec.VPCCNI,
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
 					nodeClass.Spec.Kubelet.SystemReserved,
 					nodeClass.Spec.Kubelet.EvictionHard,
 					nodeClass.Spec.Kubelet.EvictionSoft,
+					nodeClass.Spec.Kubelet.ReservedSizing,
 					amiFamily,
 					nil,
 				)
@@ -1727,12 +2383,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.Hugepages,
+					nodeClass.Spec.Neuron,
+					nodeClass.Spec.VPCCNI,
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
 					nodeClass.Spec.Kubelet.SystemReserved,
 					nodeClass.Spec.Kubelet.EvictionHard,
 					nodeClass.Spec.Kubelet.EvictionSoft,
+					nodeClass.Spec.Kubelet.ReservedSizing,
 					amiFamily,
 					nil,
 				)
@@ -1753,16 +2413,20 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.Hugepages,
+					nodeClass.Spec.Neuron,
+					nodeClass.Spec.VPCCNI,
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
 					nodeClass.Spec.Kubelet.SystemReserved,
 					nodeClass.Spec.Kubelet.EvictionHard,
 					nodeClass.Spec.Kubelet.EvictionSoft,
+					nodeClass.Spec.Kubelet.ReservedSizing,
 					amiFamily,
 					nil,
 				)
-				limitedPods := instancetype.ENILimitedPods(ctx, info)
+				limitedPods := instancetype.ENILimitedPods(ctx, info, nodeClass.Spec.VPCCNI)
 				Expect(it.Capacity.Pods().Value()).To(BeNumerically("==", limitedPods.Value()))
 			}
 		})
@@ -1780,12 +2444,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.Hugepages,
+						nodeClass.Spec.Neuron,
+						nodeClass.Spec.VPCCNI,
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
 						nodeClass.Spec.Kubelet.SystemReserved,
 						nodeClass.Spec.Kubelet.EvictionHard,
 						nodeClass.Spec.Kubelet.EvictionSoft,
+						nodeClass.Spec.Kubelet.ReservedSizing,
 						amiFamily,
 						nil,
 					)
@@ -1798,12 +2466,16 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.Hugepages,
+						nodeClass.Spec.Neuron,
+						nodeClass.Spec.VPCCNI,
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
 						nodeClass.Spec.Kubelet.SystemReserved,
 						nodeClass.Spec.Kubelet.EvictionHard,
 						nodeClass.Spec.Kubelet.EvictionSoft,
+						nodeClass.Spec.Kubelet.ReservedSizing,
 						amiFamily,
 						nil,
 					)
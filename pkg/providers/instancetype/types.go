@@ -49,18 +49,19 @@ var (
 )
 
 func NewInstanceType(ctx context.Context, info *ec2.InstanceTypeInfo, region string,
-	blockDeviceMappings []*v1.BlockDeviceMapping, instanceStorePolicy *v1.InstanceStorePolicy, maxPods *int32, podsPerCore *int32,
+	blockDeviceMappings []*v1.BlockDeviceMapping, instanceStorePolicy *v1.InstanceStorePolicy, hugepages []v1.Hugepages, neuron *v1.NeuronConfiguration,
+	vpcCNI *v1.VPCCNIConfiguration, maxPods *int32, podsPerCore *int32,
 	kubeReserved map[string]string, systemReserved map[string]string, evictionHard map[string]string, evictionSoft map[string]string,
-	amiFamily amifamily.AMIFamily, offerings cloudprovider.Offerings) *cloudprovider.InstanceType {
+	reservedSizing *v1.ReservedSizing, amiFamily amifamily.AMIFamily, offerings cloudprovider.Offerings) *cloudprovider.InstanceType {
 
 	it := &cloudprovider.InstanceType{
 		Name:         aws.StringValue(info.InstanceType),
 		Requirements: computeRequirements(info, offerings, region, amiFamily),
 		Offerings:    offerings,
-		Capacity:     computeCapacity(ctx, info, amiFamily, blockDeviceMappings, instanceStorePolicy, maxPods, podsPerCore),
+		Capacity:     computeCapacity(ctx, info, amiFamily, blockDeviceMappings, instanceStorePolicy, hugepages, neuron, vpcCNI, maxPods, podsPerCore),
 		Overhead: &cloudprovider.InstanceTypeOverhead{
-			KubeReserved:      kubeReservedResources(cpu(info), pods(ctx, info, amiFamily, maxPods, podsPerCore), ENILimitedPods(ctx, info), amiFamily, kubeReserved),
-			SystemReserved:    systemReservedResources(systemReserved),
+			KubeReserved:      kubeReservedResources(cpu(info), memory(ctx, info), pods(ctx, info, amiFamily, vpcCNI, maxPods, podsPerCore), ENILimitedPods(ctx, info, vpcCNI), amiFamily, kubeReserved, reservedSizing),
+			SystemReserved:    systemReservedResources(cpu(info), memory(ctx, info), pods(ctx, info, amiFamily, vpcCNI, maxPods, podsPerCore), systemReserved, reservedSizing),
 			EvictionThreshold: evictionThreshold(memory(ctx, info), ephemeralStorage(info, amiFamily, blockDeviceMappings, instanceStorePolicy), amiFamily, evictionHard, evictionSoft),
 		},
 	}
@@ -89,6 +90,8 @@ func computeRequirements(info *ec2.InstanceTypeInfo, offerings cloudprovider.Off
 		// Well Known to AWS
 		scheduling.NewRequirement(v1.LabelInstanceCPU, corev1.NodeSelectorOpIn, fmt.Sprint(aws.Int64Value(info.VCpuInfo.DefaultVCpus))),
 		scheduling.NewRequirement(v1.LabelInstanceCPUManufacturer, corev1.NodeSelectorOpDoesNotExist),
+		scheduling.NewRequirement(v1.LabelInstanceCPUBaseline, corev1.NodeSelectorOpDoesNotExist),
+		scheduling.NewRequirement(v1.LabelInstanceBurstable, corev1.NodeSelectorOpIn, fmt.Sprint(aws.BoolValue(info.BurstablePerformanceSupported))),
 		scheduling.NewRequirement(v1.LabelInstanceMemory, corev1.NodeSelectorOpIn, fmt.Sprint(aws.Int64Value(info.MemoryInfo.SizeInMiB))),
 		scheduling.NewRequirement(v1.LabelInstanceEBSBandwidth, corev1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1.LabelInstanceNetworkBandwidth, corev1.NodeSelectorOpDoesNotExist),
@@ -106,6 +109,12 @@ func computeRequirements(info *ec2.InstanceTypeInfo, offerings cloudprovider.Off
 		scheduling.NewRequirement(v1.LabelInstanceAcceleratorCount, corev1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1.LabelInstanceHypervisor, corev1.NodeSelectorOpIn, aws.StringValue(info.Hypervisor)),
 		scheduling.NewRequirement(v1.LabelInstanceEncryptionInTransitSupported, corev1.NodeSelectorOpIn, fmt.Sprint(aws.BoolValue(info.NetworkInfo.EncryptionInTransitSupported))),
+		scheduling.NewRequirement(v1.LabelInstancePTPSupported, corev1.NodeSelectorOpIn, fmt.Sprint(aws.StringValue(info.PhcSupport) == ec2.PhcSupportSupported)),
+		scheduling.NewRequirement(v1.LabelInstanceTrunkingCompatible, corev1.NodeSelectorOpIn, fmt.Sprint(IsTrunkingCompatible(aws.StringValue(info.InstanceType)))),
+		// Bare metal instances don't report a hypervisor, but they're built on the Nitro System as well
+		scheduling.NewRequirement(v1.LabelInstanceNitro, corev1.NodeSelectorOpIn, fmt.Sprint(aws.StringValue(info.Hypervisor) == ec2.InstanceTypeHypervisorNitro || aws.BoolValue(info.BareMetal))),
+		scheduling.NewRequirement(v1.LabelInstanceEBSNVMe, corev1.NodeSelectorOpDoesNotExist),
+		scheduling.NewRequirement(v1.LabelInstanceENASupport, corev1.NodeSelectorOpDoesNotExist),
 	)
 	// Only add zone-id label when available in offerings. It may not be available if a user has upgraded from a
 	// previous version of Karpenter w/o zone-id support and the nodeclass subnet status has not yet updated.
@@ -129,10 +138,23 @@ func computeRequirements(info *ec2.InstanceTypeInfo, offerings cloudprovider.Off
 	if info.InstanceStorageInfo != nil && aws.StringValue(info.InstanceStorageInfo.NvmeSupport) != ec2.EphemeralNvmeSupportUnsupported {
 		requirements[v1.LabelInstanceLocalNVME].Insert(fmt.Sprint(aws.Int64Value(info.InstanceStorageInfo.TotalSizeInGB)))
 	}
+	// EBS volumes are attached as NVMe block devices when it's supported, which needs the NVMe driver
+	if info.EbsInfo != nil && info.EbsInfo.NvmeSupport != nil {
+		requirements[v1.LabelInstanceEBSNVMe].Insert(aws.StringValue(info.EbsInfo.NvmeSupport))
+	}
+	// Instance types that require ENA can't launch AMIs without the ENA driver
+	if info.NetworkInfo != nil && info.NetworkInfo.EnaSupport != nil {
+		requirements[v1.LabelInstanceENASupport].Insert(aws.StringValue(info.NetworkInfo.EnaSupport))
+	}
 	// Network bandwidth
 	if bandwidth, ok := InstanceTypeBandwidthMegabits[aws.StringValue(info.InstanceType)]; ok {
 		requirements[v1.LabelInstanceNetworkBandwidth].Insert(fmt.Sprint(bandwidth))
 	}
+	// Burstable performance instance types can only sustain their baseline CPU utilization, in millicores, without
+	// spending CPU credits
+	if baseline, ok := InstanceTypeBaselineCPUMillicores[aws.StringValue(info.InstanceType)]; ok {
+		requirements[v1.LabelInstanceCPUBaseline].Insert(fmt.Sprint(baseline))
+	}
 	// GPU Labels
 	if info.GpuInfo != nil && len(info.GpuInfo.Gpus) == 1 {
 		gpu := info.GpuInfo.Gpus[0]
@@ -191,21 +213,46 @@ func getArchitecture(info *ec2.InstanceTypeInfo) string {
 }
 
 func computeCapacity(ctx context.Context, info *ec2.InstanceTypeInfo, amiFamily amifamily.AMIFamily,
-	blockDeviceMapping []*v1.BlockDeviceMapping, instanceStorePolicy *v1.InstanceStorePolicy,
-	maxPods *int32, podsPerCore *int32) corev1.ResourceList {
+	blockDeviceMapping []*v1.BlockDeviceMapping, instanceStorePolicy *v1.InstanceStorePolicy, hugepages []v1.Hugepages,
+	neuron *v1.NeuronConfiguration, vpcCNI *v1.VPCCNIConfiguration, maxPods *int32, podsPerCore *int32) corev1.ResourceList {
 
 	resourceList := corev1.ResourceList{
 		corev1.ResourceCPU:              *cpu(info),
 		corev1.ResourceMemory:           *memory(ctx, info),
 		corev1.ResourceEphemeralStorage: *ephemeralStorage(info, amiFamily, blockDeviceMapping, instanceStorePolicy),
-		corev1.ResourcePods:             *pods(ctx, info, amiFamily, maxPods, podsPerCore),
+		corev1.ResourcePods:             *pods(ctx, info, amiFamily, vpcCNI, maxPods, podsPerCore),
 		v1.ResourceAWSPodENI:            *awsPodENI(aws.StringValue(info.InstanceType)),
 		v1.ResourceNVIDIAGPU:            *nvidiaGPUs(info),
 		v1.ResourceAMDGPU:               *amdGPUs(info),
 		v1.ResourceAWSNeuron:            *awsNeurons(info),
+		v1.ResourceAWSNeuronCore:        *awsNeuronCores(info, neuron),
 		v1.ResourceHabanaGaudi:          *habanaGaudis(info),
 		v1.ResourceEFA:                  *efas(info),
 	}
+	if amiFamily.FeatureFlags().SupportsHugepages {
+		if !amiFamily.FeatureFlags().SupportsMultipleHugepageSizes {
+			hugepages = lo.Filter(hugepages, func(h v1.Hugepages, _ int) bool {
+				return len(hugepages) == 1 || h.Size == "1Gi"
+			})
+		}
+		// Pre-allocated hugepages can't be used as regular memory, so kubelet takes them out of the allocatable memory
+		for name, quantity := range hugepagesCapacity(hugepages) {
+			resourceList[name] = quantity
+			mem := resourceList[corev1.ResourceMemory]
+			mem.Sub(quantity)
+			resourceList[corev1.ResourceMemory] = lo.Ternary(mem.Sign() < 0, resource.Quantity{}, mem)
+		}
+	}
+	return resourceList
+}
+
+// hugepagesCapacity returns the hugepages-<size> resources of the hugepages that are allocated on the node
+func hugepagesCapacity(hugepages []v1.Hugepages) corev1.ResourceList {
+	resourceList := corev1.ResourceList{}
+	for _, h := range hugepages {
+		size := resource.MustParse(h.Size)
+		resourceList[corev1.ResourceName(corev1.ResourceHugePagesPrefix+h.Size)] = *resource.NewQuantity(size.Value()*int64(h.Count), resource.BinarySI)
+	}
 	return resourceList
 }
 
@@ -233,6 +280,10 @@ func ephemeralStorage(info *ec2.InstanceTypeInfo, amiFamily amifamily.AMIFamily,
 			return resources.Quantity(fmt.Sprintf("%dG", *info.InstanceStorageInfo.TotalSizeInGB))
 		}
 	}
+	// The image filesystem volume only stores container images, so it doesn't add to the node's ephemeral-storage
+	if amiFamily.FeatureFlags().SupportsImageFSVolume {
+		blockDeviceMappings = lo.Reject(blockDeviceMappings, func(bdm *v1.BlockDeviceMapping, _ int) bool { return bdm.ImageFSVolume })
+	}
 	if len(blockDeviceMappings) != 0 {
 		// First check if there's a root volume configured in blockDeviceMappings.
 		if blockDeviceMapping, ok := lo.Find(blockDeviceMappings, func(bdm *v1.BlockDeviceMapping) bool {
@@ -266,13 +317,39 @@ func ephemeralStorage(info *ec2.InstanceTypeInfo, amiFamily amifamily.AMIFamily,
 // awsPodENI relies on the VPC resource controller to populate the vpc.amazonaws.com/pod-eni resource
 func awsPodENI(instanceTypeName string) *resource.Quantity {
 	// https://docs.aws.amazon.com/eks/latest/userguide/security-groups-for-pods.html#supported-instance-types
-	limits, ok := Limits[instanceTypeName]
-	if ok && limits.IsTrunkingCompatible {
-		return resources.Quantity(fmt.Sprint(limits.BranchInterface))
+	if IsTrunkingCompatible(instanceTypeName) {
+		return resources.Quantity(fmt.Sprint(Limits[instanceTypeName].BranchInterface))
 	}
 	return resources.Quantity("0")
 }
 
+// IsTrunkingCompatible returns whether a trunk network interface can be attached to the instance type, which is
+// required for the branch network interfaces that security groups for pods are assigned through
+func IsTrunkingCompatible(instanceTypeName string) bool {
+	limits, ok := Limits[instanceTypeName]
+	return ok && limits.IsTrunkingCompatible
+}
+
+// ReservedENIs returns the number of network interfaces of the instance type that the VPC CNI doesn't assign pod IP
+// addresses from. The reserved-enis setting applies unless the EC2NodeClass configures the VPC CNI, and custom
+// networking reserves the primary network interface. With security groups for pods, the trunk network interface takes
+// up one of the network interfaces of the instance.
+func ReservedENIs(ctx context.Context, instanceTypeName string, vpcCNI *v1.VPCCNIConfiguration) int {
+	reserved := options.FromContext(ctx).ReservedENIs
+	if vpcCNI != nil {
+		if vpcCNI.ReservedENIs != nil {
+			reserved = int(lo.FromPtr(vpcCNI.ReservedENIs))
+		}
+		if lo.FromPtr(vpcCNI.CustomNetworking) {
+			reserved++
+		}
+	}
+	if options.FromContext(ctx).SecurityGroupsForPods && IsTrunkingCompatible(instanceTypeName) {
+		reserved++
+	}
+	return reserved
+}
+
 func nvidiaGPUs(info *ec2.InstanceTypeInfo) *resource.Quantity {
 	count := int64(0)
 	if info.GpuInfo != nil {
@@ -315,6 +392,32 @@ func awsNeurons(info *ec2.InstanceTypeInfo) *resource.Quantity {
 	return resources.Quantity(fmt.Sprint(count))
 }
 
+// neuronCoresPerDevice is the number of physical NeuronCores of each Neuron device of an instance family, for instance
+// types that DescribeInstanceTypes doesn't return the Neuron devices of
+var neuronCoresPerDevice = map[string]int64{
+	"inf1":  4,
+	"inf2":  2,
+	"trn1":  2,
+	"trn1n": 2,
+	"trn2":  8,
+}
+
+// awsNeuronCores returns the number of logical NeuronCores that the Neuron device plugin advertises, which are the
+// physical NeuronCores of the instance type grouped by the logical NeuronCore config of the Neuron runtime
+func awsNeuronCores(info *ec2.InstanceTypeInfo, neuron *v1.NeuronConfiguration) *resource.Quantity {
+	count := int64(0)
+	if info.NeuronInfo != nil {
+		for _, device := range info.NeuronInfo.NeuronDevices {
+			if device.CoreInfo != nil {
+				count += aws.Int64Value(device.Count) * aws.Int64Value(device.CoreInfo.Count)
+			}
+		}
+	} else {
+		count = awsNeurons(info).Value() * neuronCoresPerDevice[strings.Split(aws.StringValue(info.InstanceType), ".")[0]]
+	}
+	return resources.Quantity(fmt.Sprint(count / int64(lo.FromPtrOr(lo.FromPtr(neuron).LogicalNeuronCoreConfig, 1))))
+}
+
 func habanaGaudis(info *ec2.InstanceTypeInfo) *resource.Quantity {
 	count := int64(0)
 	if info.GpuInfo != nil {
@@ -335,7 +438,16 @@ func efas(info *ec2.InstanceTypeInfo) *resource.Quantity {
 	return resources.Quantity(fmt.Sprint(count))
 }
 
-func ENILimitedPods(ctx context.Context, info *ec2.InstanceTypeInfo) *resource.Quantity {
+// ReservedIPsPerENI returns the number of secondary IP addresses of each network interface that the VPC CNI doesn't
+// assign to pods
+func ReservedIPsPerENI(vpcCNI *v1.VPCCNIConfiguration) int64 {
+	if vpcCNI == nil {
+		return 0
+	}
+	return int64(lo.FromPtr(vpcCNI.ReservedIPsPerENI))
+}
+
+func ENILimitedPods(ctx context.Context, info *ec2.InstanceTypeInfo, vpcCNI *v1.VPCCNIConfiguration) *resource.Quantity {
 	// The number of pods per node is calculated using the formula:
 	// max number of ENIs * (IPv4 Addresses per ENI -1) + 2
 	// https://github.com/awslabs/amazon-eks-ami/blob/main/templates/shared/runtime/eni-max-pods.txt
@@ -343,12 +455,23 @@ func ENILimitedPods(ctx context.Context, info *ec2.InstanceTypeInfo) *resource.Q
 	// VPC CNI only uses the default network interface
 	// https://github.com/aws/amazon-vpc-cni-k8s/blob/3294231c0dce52cfe473bf6c62f47956a3b333b6/scripts/gen_vpc_ip_limits.go#L162
 	networkInterfaces := *info.NetworkInfo.NetworkCards[*info.NetworkInfo.DefaultNetworkCardIndex].MaximumNetworkInterfaces
-	usableNetworkInterfaces := lo.Max([]int64{networkInterfaces - int64(options.FromContext(ctx).ReservedENIs), 0})
+	usableNetworkInterfaces := lo.Max([]int64{networkInterfaces - int64(ReservedENIs(ctx, aws.StringValue(info.InstanceType), vpcCNI)), 0})
 	if usableNetworkInterfaces == 0 {
 		return resource.NewQuantity(0, resource.DecimalSI)
 	}
-	addressesPerInterface := *info.NetworkInfo.Ipv4AddressesPerInterface
-	return resources.Quantity(fmt.Sprint(usableNetworkInterfaces*(addressesPerInterface-1) + 2))
+	addressesPerInterface := lo.Max([]int64{*info.NetworkInfo.Ipv4AddressesPerInterface - 1 - ReservedIPsPerENI(vpcCNI), 0})
+	return resources.Quantity(fmt.Sprint(usableNetworkInterfaces*addressesPerInterface + 2))
+}
+
+// MaxPodsSupported returns the number of pods that the VPC CNI can assign IP addresses to on the instance type, including
+// the 2 host network pods that ENI limited pod density accounts for. Each secondary address slot of the network
+// interfaces is a prefix of 16 addresses with prefix delegation.
+func MaxPodsSupported(ctx context.Context, info *ec2.InstanceTypeInfo, vpcCNI *v1.VPCCNIConfiguration) int64 {
+	pods := ENILimitedPods(ctx, info, vpcCNI).Value()
+	if pods == 0 || vpcCNI == nil || !lo.FromPtr(vpcCNI.PrefixDelegation) {
+		return pods
+	}
+	return (pods-2)*16 + 2
 }
 
 func privateIPv4Address(instanceTypeName string) *resource.Quantity {
@@ -360,16 +483,29 @@ func privateIPv4Address(instanceTypeName string) *resource.Quantity {
 	return resources.Quantity(fmt.Sprint(limits.IPv4PerInterface - 1))
 }
 
-func systemReservedResources(systemReserved map[string]string) corev1.ResourceList {
-	return lo.MapEntries(systemReserved, func(k string, v string) (corev1.ResourceName, resource.Quantity) {
+func systemReservedResources(cpus, memory, pods *resource.Quantity, systemReserved map[string]string, reservedSizing *v1.ReservedSizing) corev1.ResourceList {
+	resources := corev1.ResourceList{}
+	if reservedSizing != nil && reservedSizing.SystemReserved != nil {
+		resources = reservedFromCurve(cpus, memory, pods, reservedSizing.SystemReserved)
+	}
+	return lo.Assign(resources, lo.MapEntries(systemReserved, func(k string, v string) (corev1.ResourceName, resource.Quantity) {
 		return corev1.ResourceName(k), resource.MustParse(v)
-	})
+	}))
 }
 
-func kubeReservedResources(cpus, pods, eniLimitedPods *resource.Quantity, amiFamily amifamily.AMIFamily, kubeReserved map[string]string) corev1.ResourceList {
+func kubeReservedResources(cpus, memory, pods, eniLimitedPods *resource.Quantity, amiFamily amifamily.AMIFamily, kubeReserved map[string]string,
+	reservedSizing *v1.ReservedSizing) corev1.ResourceList {
 	if amiFamily.FeatureFlags().UsesENILimitedMemoryOverhead {
 		pods = eniLimitedPods
 	}
+	if reservedSizing != nil && reservedSizing.KubeReserved != nil {
+		resources := lo.Assign(corev1.ResourceList{
+			corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"), // default kube-reserved ephemeral-storage
+		}, reservedFromCurve(cpus, memory, pods, reservedSizing.KubeReserved))
+		return lo.Assign(resources, lo.MapEntries(kubeReserved, func(k string, v string) (corev1.ResourceName, resource.Quantity) {
+			return corev1.ResourceName(k), resource.MustParse(v)
+		}))
+	}
 	resources := corev1.ResourceList{
 		corev1.ResourceMemory:           resource.MustParse(fmt.Sprintf("%dMi", (11*pods.Value())+255)),
 		corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"), // default kube-reserved ephemeral-storage
@@ -401,6 +537,50 @@ func kubeReservedResources(cpus, pods, eniLimitedPods *resource.Quantity, amiFam
 	}))
 }
 
+// reservedFromCurve computes the CPU and memory that a curve reserves on an instance type. Each range of the curve
+// reserves its percentage of the part of the capacity that falls into the range, and memory is also reserved for each
+// pod.
+func reservedFromCurve(cpus, memory, pods *resource.Quantity, curve *v1.ReservedCurve) corev1.ResourceList {
+	resources := corev1.ResourceList{}
+	if len(curve.CPU) != 0 {
+		milliCPU := reservedFromRanges(cpus.MilliValue(), curve.CPU, func(q resource.Quantity) int64 { return q.MilliValue() })
+		resources[corev1.ResourceCPU] = *resource.NewMilliQuantity(milliCPU, resource.DecimalSI)
+	}
+	if len(curve.Memory) != 0 || curve.MemoryPerPod != nil {
+		mebibytes := reservedFromRanges(memory.Value()/(1<<20), curve.Memory, func(q resource.Quantity) int64 { return q.Value() / (1 << 20) })
+		if curve.MemoryPerPod != nil {
+			perPod := resource.MustParse(lo.FromPtr(curve.MemoryPerPod))
+			mebibytes += int64(math.Ceil(float64(perPod.Value()*pods.Value()) / (1 << 20)))
+		}
+		resources[corev1.ResourceMemory] = resource.MustParse(fmt.Sprintf("%dMi", mebibytes))
+	}
+	return resources
+}
+
+func reservedFromRanges(capacity int64, ranges []v1.ReservedRange, value func(resource.Quantity) int64) int64 {
+	var reserved float64
+	var start int64
+	for _, r := range ranges {
+		if capacity <= start {
+			break
+		}
+		end := capacity
+		if r.UpTo != nil {
+			end = lo.Min([]int64{value(resource.MustParse(lo.FromPtr(r.UpTo))), capacity})
+		}
+		if end <= start {
+			continue
+		}
+		percentage, err := strconv.ParseFloat(strings.TrimSuffix(r.Percentage, "%"), 64)
+		if err != nil {
+			panic(fmt.Sprintf("expected percentage value to be a float but got %s, %v", r.Percentage, err))
+		}
+		reserved += float64(end-start) * percentage / 100
+		start = end
+	}
+	return int64(math.Ceil(reserved))
+}
+
 func evictionThreshold(memory *resource.Quantity, storage *resource.Quantity, amiFamily amifamily.AMIFamily, evictionHard map[string]string, evictionSoft map[string]string) corev1.ResourceList {
 	overhead := corev1.ResourceList{
 		corev1.ResourceMemory:           resource.MustParse("100Mi"),
@@ -430,13 +610,13 @@ func evictionThreshold(memory *resource.Quantity, storage *resource.Quantity, am
 	return lo.Assign(overhead, override)
 }
 
-func pods(ctx context.Context, info *ec2.InstanceTypeInfo, amiFamily amifamily.AMIFamily, maxPods *int32, podsPerCore *int32) *resource.Quantity {
+func pods(ctx context.Context, info *ec2.InstanceTypeInfo, amiFamily amifamily.AMIFamily, vpcCNI *v1.VPCCNIConfiguration, maxPods *int32, podsPerCore *int32) *resource.Quantity {
 	var count int64
 	switch {
 	case maxPods != nil:
 		count = int64(lo.FromPtr(maxPods))
 	case amiFamily.FeatureFlags().SupportsENILimitedPodDensity:
-		count = ENILimitedPods(ctx, info).Value()
+		count = ENILimitedPods(ctx, info, vpcCNI).Value()
 	default:
 		count = 110
 
@@ -0,0 +1,212 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package launchjournal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
+)
+
+// ConfigMapName is the name of the ConfigMap in the namespace of Karpenter that launches are journaled in
+const ConfigMapName = "karpenter-launch-journal"
+
+// Launch is an instance that was launched for a NodeClaim whose status may not have been updated with it yet
+type Launch struct {
+	NodeClaimUID types.UID `json:"nodeClaimUID"`
+	// InstanceID is empty while the CreateFleet request of the launch is in flight
+	InstanceID string `json:"instanceID,omitempty"`
+	// ClientToken is the idempotency token of the CreateFleet request of a launch that is in flight
+	ClientToken string    `json:"clientToken,omitempty"`
+	LaunchTime  time.Time `json:"launchTime"`
+	// RoleARN is the role that the instance was launched with, if the EC2NodeClass assumes one
+	RoleARN string `json:"roleARN,omitempty"`
+}
+
+type Provider interface {
+	Begin(context.Context, *karpv1.NodeClaim, string) error
+	Record(context.Context, *karpv1.NodeClaim, string) error
+	Get(context.Context, *karpv1.NodeClaim) (*Launch, error)
+	List(context.Context) (map[string]Launch, error)
+	Remove(context.Context, ...string) error
+}
+
+// DefaultProvider journals launches in a ConfigMap, keyed by the name of the NodeClaim. Entries are written with merge
+// patches of their own keys, so concurrent launches don't conflict with each other. The ConfigMap is read once with an
+// uncached reader, since the cache of the manager isn't scoped to the namespace of Karpenter, and is then served from
+// memory, since the leader is the only writer of the journal.
+type DefaultProvider struct {
+	kubeClient client.Client
+	reader     client.Reader
+	clk        clock.Clock
+	namespace  string
+
+	mu       sync.Mutex
+	launches map[string]Launch
+}
+
+func NewDefaultProvider(kubeClient client.Client, reader client.Reader, clk clock.Clock, namespace string) *DefaultProvider {
+	return &DefaultProvider{
+		kubeClient: kubeClient,
+		reader:     reader,
+		clk:        clk,
+		namespace:  namespace,
+	}
+}
+
+// Begin journals the launch of the NodeClaim before its CreateFleet request is issued with the client token
+func (p *DefaultProvider) Begin(ctx context.Context, nodeClaim *karpv1.NodeClaim, clientToken string) error {
+	if err := p.write(ctx, nodeClaim.Name, Launch{
+		NodeClaimUID: nodeClaim.UID,
+		ClientToken:  clientToken,
+		LaunchTime:   p.clk.Now().UTC(),
+		RoleARN:      assumerole.FromContext(ctx),
+	}); err != nil {
+		return fmt.Errorf("beginning launch, %w", err)
+	}
+	return nil
+}
+
+// Record journals the instance that was launched for the NodeClaim, creating the ConfigMap if it doesn't exist
+func (p *DefaultProvider) Record(ctx context.Context, nodeClaim *karpv1.NodeClaim, instanceID string) error {
+	if err := p.write(ctx, nodeClaim.Name, Launch{
+		NodeClaimUID: nodeClaim.UID,
+		InstanceID:   instanceID,
+		LaunchTime:   p.clk.Now().UTC(),
+		RoleARN:      assumerole.FromContext(ctx),
+	}); err != nil {
+		return fmt.Errorf("recording launch of %s, %w", instanceID, err)
+	}
+	return nil
+}
+
+// Get returns the journaled launch of the NodeClaim, if any. Launches that were recorded for a previous NodeClaim
+// with the same name aren't returned.
+func (p *DefaultProvider) Get(ctx context.Context, nodeClaim *karpv1.NodeClaim) (*Launch, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.load(ctx); err != nil {
+		return nil, err
+	}
+	launch, ok := p.launches[nodeClaim.Name]
+	if !ok || nodeClaim.UID == "" || launch.NodeClaimUID != nodeClaim.UID {
+		return nil, nil
+	}
+	return &launch, nil
+}
+
+// List returns the journaled launches by the name of their NodeClaims
+func (p *DefaultProvider) List(ctx context.Context) (map[string]Launch, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.load(ctx); err != nil {
+		return nil, err
+	}
+	return lo.Assign(p.launches), nil
+}
+
+// Remove removes the journaled launches of the NodeClaims
+func (p *DefaultProvider) Remove(ctx context.Context, names ...string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.patch(ctx, lo.SliceToMap(names, func(name string) (string, *string) { return name, nil })); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("removing launches, %w", err)
+	}
+	if p.launches != nil {
+		for _, name := range names {
+			delete(p.launches, name)
+		}
+	}
+	return nil
+}
+
+// load reads the journaled launches from the ConfigMap if they weren't read yet. Entries that can't be parsed are
+// skipped.
+func (p *DefaultProvider) load(ctx context.Context) error {
+	if p.launches != nil {
+		return nil
+	}
+	configMap := &corev1.ConfigMap{}
+	if err := p.reader.Get(ctx, types.NamespacedName{Namespace: p.namespace, Name: ConfigMapName}, configMap); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("getting launch journal, %w", err)
+	}
+	launches := map[string]Launch{}
+	for name, data := range configMap.Data {
+		launch := Launch{}
+		if err := json.Unmarshal([]byte(data), &launch); err != nil {
+			log.FromContext(ctx).WithValues("NodeClaim", klog.KRef("", name)).Error(err, "failed parsing journaled launch")
+			continue
+		}
+		launches[name] = launch
+	}
+	p.launches = launches
+	return nil
+}
+
+// write journals the launch of the NodeClaim, creating the ConfigMap if it doesn't exist
+func (p *DefaultProvider) write(ctx context.Context, name string, launch Launch) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// The journal is loaded before it's written, so that the launches of a previous leader are kept in memory
+	if err := p.load(ctx); err != nil {
+		return err
+	}
+	data, err := json.Marshal(launch)
+	if err != nil {
+		return fmt.Errorf("marshaling launch, %w", err)
+	}
+	if err = p.patch(ctx, map[string]*string{name: lo.ToPtr(string(data))}); errors.IsNotFound(err) {
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: p.namespace},
+			Data:       map[string]string{name: string(data)},
+		}
+		if err = p.kubeClient.Create(ctx, configMap); errors.IsAlreadyExists(err) {
+			err = p.patch(ctx, map[string]*string{name: lo.ToPtr(string(data))})
+		}
+	}
+	if err != nil {
+		return err
+	}
+	p.launches[name] = launch
+	return nil
+}
+
+// patch merges the data into the ConfigMap. Nil values remove their keys.
+func (p *DefaultProvider) patch(ctx context.Context, data map[string]*string) error {
+	patch, err := json.Marshal(map[string]any{"data": data})
+	if err != nil {
+		return err
+	}
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: p.namespace}}
+	return p.kubeClient.Patch(ctx, configMap, client.RawPatch(types.MergePatchType, patch))
+}
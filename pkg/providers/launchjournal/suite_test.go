@@ -0,0 +1,160 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package launchjournal_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/launchjournal"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var kubeClient client.Client
+var fakeClock *clock.FakeClock
+var journal *launchjournal.DefaultProvider
+
+func TestLaunchJournal(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "LaunchJournal")
+}
+
+var _ = BeforeEach(func() {
+	kubeClient = crfake.NewClientBuilder().Build()
+	fakeClock = clock.NewFakeClock(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	journal = launchjournal.NewDefaultProvider(kubeClient, kubeClient, fakeClock, "karpenter")
+})
+
+var _ = Describe("LaunchJournal", func() {
+	var nodeClaim *karpv1.NodeClaim
+	BeforeEach(func() {
+		nodeClaim = coretest.NodeClaim(karpv1.NodeClaim{ObjectMeta: metav1.ObjectMeta{UID: types.UID("nodeclaim-uid")}})
+	})
+	It("should create the ConfigMap with the first launch", func() {
+		Expect(journal.Record(ctx, nodeClaim, "i-0123456789")).To(Succeed())
+		configMap := &corev1.ConfigMap{}
+		Expect(kubeClient.Get(ctx, types.NamespacedName{Namespace: "karpenter", Name: launchjournal.ConfigMapName}, configMap)).To(Succeed())
+		Expect(configMap.Data).To(HaveKey(nodeClaim.Name))
+
+		launch, err := journal.Get(ctx, nodeClaim)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(launch).To(Equal(&launchjournal.Launch{NodeClaimUID: nodeClaim.UID, InstanceID: "i-0123456789", LaunchTime: fakeClock.Now()}))
+	})
+	It("should add launches to the existing ConfigMap", func() {
+		other := coretest.NodeClaim(karpv1.NodeClaim{ObjectMeta: metav1.ObjectMeta{UID: types.UID("other-uid")}})
+		Expect(journal.Record(ctx, nodeClaim, "i-0123456789")).To(Succeed())
+		Expect(journal.Record(ctx, other, "i-9876543210")).To(Succeed())
+
+		launches, err := journal.List(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(launches).To(HaveLen(2))
+		Expect(launches[nodeClaim.Name].InstanceID).To(Equal("i-0123456789"))
+		Expect(launches[other.Name].InstanceID).To(Equal("i-9876543210"))
+	})
+	It("should record the role that the instance was launched with", func() {
+		Expect(journal.Record(assumerole.WithRole(ctx, "arn:aws:iam::123456789012:role/team-a"), nodeClaim, "i-0123456789")).To(Succeed())
+		launch, err := journal.Get(ctx, nodeClaim)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(launch.RoleARN).To(Equal("arn:aws:iam::123456789012:role/team-a"))
+	})
+	It("should not return the launch of a previous NodeClaim with the same name", func() {
+		Expect(journal.Record(ctx, nodeClaim, "i-0123456789")).To(Succeed())
+		nodeClaim.UID = "replacement-uid"
+		launch, err := journal.Get(ctx, nodeClaim)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(launch).To(BeNil())
+	})
+	It("should journal launches in flight with their client token", func() {
+		Expect(journal.Begin(ctx, nodeClaim, "nodeclaim-uid-1")).To(Succeed())
+		launch, err := journal.Get(ctx, nodeClaim)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(launch).To(Equal(&launchjournal.Launch{NodeClaimUID: nodeClaim.UID, ClientToken: "nodeclaim-uid-1", LaunchTime: fakeClock.Now()}))
+
+		Expect(journal.Record(ctx, nodeClaim, "i-0123456789")).To(Succeed())
+		launch, err = journal.Get(ctx, nodeClaim)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(launch.ClientToken).To(BeEmpty())
+		Expect(launch.InstanceID).To(Equal("i-0123456789"))
+	})
+	It("should read the ConfigMap once", func() {
+		Expect(journal.Record(ctx, nodeClaim, "i-0123456789")).To(Succeed())
+		reader := &countingReader{Reader: kubeClient}
+		journal = launchjournal.NewDefaultProvider(kubeClient, reader, fakeClock, "karpenter")
+		for range 3 {
+			launch, err := journal.Get(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(launch.InstanceID).To(Equal("i-0123456789"))
+		}
+		Expect(journal.Remove(ctx, nodeClaim.Name)).To(Succeed())
+		Expect(lo.Must(journal.List(ctx))).To(BeEmpty())
+		Expect(reader.gets).To(Equal(1))
+	})
+	It("should remove launches", func() {
+		other := coretest.NodeClaim(karpv1.NodeClaim{ObjectMeta: metav1.ObjectMeta{UID: types.UID("other-uid")}})
+		Expect(journal.Record(ctx, nodeClaim, "i-0123456789")).To(Succeed())
+		Expect(journal.Record(ctx, other, "i-9876543210")).To(Succeed())
+		Expect(journal.Remove(ctx, nodeClaim.Name)).To(Succeed())
+
+		launches, err := journal.List(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(launches).To(HaveLen(1))
+		Expect(launches).To(HaveKey(other.Name))
+	})
+	It("should return no launches when the ConfigMap doesn't exist", func() {
+		launches, err := journal.List(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(launches).To(BeEmpty())
+		Expect(journal.Remove(ctx, nodeClaim.Name)).To(Succeed())
+	})
+	It("should skip launches that can't be parsed", func() {
+		Expect(kubeClient.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "karpenter", Name: launchjournal.ConfigMapName},
+			Data:       map[string]string{"invalid": "{"},
+		})).To(Succeed())
+		Expect(journal.Record(ctx, nodeClaim, "i-0123456789")).To(Succeed())
+
+		launches, err := journal.List(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(launches).To(HaveLen(1))
+		Expect(launches).To(HaveKey(nodeClaim.Name))
+	})
+})
+
+type countingReader struct {
+	client.Reader
+	gets int
+}
+
+func (r *countingReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	r.gets++
+	return r.Reader.Get(ctx, key, obj, opts...)
+}
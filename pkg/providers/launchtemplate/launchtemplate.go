@@ -16,10 +16,13 @@ package launchtemplate
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"math"
 	"net"
+	"regexp"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -42,23 +45,33 @@ import (
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
 	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/secret"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/snapshot"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+	"github.com/aws/karpenter-provider-aws/pkg/tracing"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
 
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 )
 
+// activationCodePattern matches the codes of SSM hybrid activations
+var activationCodePattern = regexp.MustCompile(`^[a-zA-Z0-9+=/]{20,250}$`)
+
 type Provider interface {
 	EnsureAll(context.Context, *v1.EC2NodeClass, *karpv1.NodeClaim,
 		[]*cloudprovider.InstanceType, string, map[string]string) ([]*LaunchTemplate, error)
+	Render(context.Context, *v1.EC2NodeClass, *karpv1.NodeClaim,
+		[]*cloudprovider.InstanceType, string, map[string]string) ([]*RenderedLaunchTemplate, error)
 	DeleteAll(context.Context, *v1.EC2NodeClass) error
 	InvalidateCache(context.Context, string, string)
 	ResolveClusterCIDR(context.Context) error
+	ResolveCluster(context.Context) (*v1.Cluster, []string, error)
 }
 
 type LaunchTemplate struct {
@@ -67,6 +80,15 @@ type LaunchTemplate struct {
 	ImageID       string
 }
 
+// RenderedLaunchTemplate is the data of a launch template that would be created for a launch, with the user data
+// replaced by its hash since it contains the values of secrets
+type RenderedLaunchTemplate struct {
+	Name          string                         `json:"name"`
+	InstanceTypes []string                       `json:"instanceTypes"`
+	UserDataHash  string                         `json:"userDataHash"`
+	Data          *ec2.RequestLaunchTemplateData `json:"data"`
+}
+
 type DefaultProvider struct {
 	sync.Mutex
 	ec2api                ec2iface.EC2API
@@ -74,7 +96,10 @@ type DefaultProvider struct {
 	amiFamily             *amifamily.Resolver
 	securityGroupProvider securitygroup.Provider
 	subnetProvider        subnet.Provider
+	secretProvider        secret.Provider
+	snapshotProvider      snapshot.Provider
 	cache                 *cache.Cache
+	clusterCache          *cache.Cache
 	cm                    *pretty.ChangeMonitor
 	KubeDNSIP             net.IP
 	CABundle              *string
@@ -83,15 +108,19 @@ type DefaultProvider struct {
 }
 
 func NewDefaultProvider(ctx context.Context, cache *cache.Cache, ec2api ec2iface.EC2API, eksapi eksiface.EKSAPI, amiFamily *amifamily.Resolver,
-	securityGroupProvider securitygroup.Provider, subnetProvider subnet.Provider,
-	caBundle *string, startAsync <-chan struct{}, kubeDNSIP net.IP, clusterEndpoint string) *DefaultProvider {
+	securityGroupProvider securitygroup.Provider, subnetProvider subnet.Provider, secretProvider secret.Provider,
+	snapshotProvider snapshot.Provider, caBundle *string, startAsync <-chan struct{}, kubeDNSIP net.IP, clusterEndpoint string,
+	clusterCache *cache.Cache) *DefaultProvider {
 	l := &DefaultProvider{
 		ec2api:                ec2api,
 		eksapi:                eksapi,
 		amiFamily:             amiFamily,
 		securityGroupProvider: securityGroupProvider,
 		subnetProvider:        subnetProvider,
+		secretProvider:        secretProvider,
+		snapshotProvider:      snapshotProvider,
 		cache:                 cache,
+		clusterCache:          clusterCache,
 		CABundle:              caBundle,
 		cm:                    pretty.NewChangeMonitor(),
 		KubeDNSIP:             kubeDNSIP,
@@ -111,16 +140,14 @@ func NewDefaultProvider(ctx context.Context, cache *cache.Cache, ec2api ec2iface
 }
 
 func (p *DefaultProvider) EnsureAll(ctx context.Context, nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim,
-	instanceTypes []*cloudprovider.InstanceType, capacityType string, tags map[string]string) ([]*LaunchTemplate, error) {
+	instanceTypes []*cloudprovider.InstanceType, capacityType string, tags map[string]string) (_ []*LaunchTemplate, err error) {
+	ctx, span := tracing.Start(ctx, "LaunchTemplateProvider.EnsureAll", tracing.AttributeNodeClass.String(nodeClass.Name))
+	defer func() { tracing.End(span, err) }()
 
 	p.Lock()
 	defer p.Unlock()
 
-	options, err := p.createAMIOptions(ctx, nodeClass, lo.Assign(nodeClaim.Labels, map[string]string{karpv1.CapacityTypeLabelKey: capacityType}), tags)
-	if err != nil {
-		return nil, err
-	}
-	resolvedLaunchTemplates, err := p.amiFamily.Resolve(nodeClass, nodeClaim, instanceTypes, capacityType, options)
+	resolvedLaunchTemplates, err := p.resolve(ctx, nodeClass, nodeClaim, instanceTypes, capacityType, tags)
 	if err != nil {
 		return nil, err
 	}
@@ -136,6 +163,120 @@ func (p *DefaultProvider) EnsureAll(ctx context.Context, nodeClass *v1.EC2NodeCl
 	return launchTemplates, nil
 }
 
+// Render returns the data of the launch templates that EnsureAll would ensure for the launch, without creating them, so
+// that what would be launched can be inspected. The user data is replaced by its hash, since it contains the values of
+// the secrets that are referenced by the EC2NodeClass.
+func (p *DefaultProvider) Render(ctx context.Context, nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim,
+	instanceTypes []*cloudprovider.InstanceType, capacityType string, tags map[string]string) ([]*RenderedLaunchTemplate, error) {
+	resolvedLaunchTemplates, err := p.resolve(ctx, nodeClass, nodeClaim, instanceTypes, capacityType, tags)
+	if err != nil {
+		return nil, err
+	}
+	var launchTemplates []*RenderedLaunchTemplate
+	for _, resolvedLaunchTemplate := range resolvedLaunchTemplates {
+		data, err := p.launchTemplateData(resolvedLaunchTemplate)
+		if err != nil {
+			return nil, err
+		}
+		userDataHash := fmt.Sprintf("%x", sha256.Sum256([]byte(aws.StringValue(data.UserData))))
+		data.UserData = nil
+		launchTemplates = append(launchTemplates, &RenderedLaunchTemplate{
+			Name:          LaunchTemplateName(resolvedLaunchTemplate),
+			InstanceTypes: lo.Map(resolvedLaunchTemplate.InstanceTypes, func(it *cloudprovider.InstanceType, _ int) string { return it.Name }),
+			UserDataHash:  userDataHash,
+			Data:          data,
+		})
+	}
+	return launchTemplates, nil
+}
+
+// resolve resolves the launch templates of a launch, which differ by AMI and the requirements of the instance types
+func (p *DefaultProvider) resolve(ctx context.Context, nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim,
+	instanceTypes []*cloudprovider.InstanceType, capacityType string, tags map[string]string) ([]*amifamily.LaunchTemplate, error) {
+	options, err := p.createAMIOptions(ctx, nodeClass, lo.Assign(nodeClaim.Labels, map[string]string{karpv1.CapacityTypeLabelKey: capacityType}), tags)
+	if err != nil {
+		return nil, err
+	}
+	// References to secrets are only resolved here so that their values never have to be stored in the EC2NodeClass.
+	// Since the values are part of the launch template's hash, a rotated secret results in a new launch template.
+	if nodeClass, err = p.resolveSecretReferences(ctx, nodeClass); err != nil {
+		return nil, err
+	}
+	// Snapshots are resolved for the same reason, so that a new snapshot results in a new launch template without
+	// drifting the nodes that were launched from the previous one
+	if nodeClass, err = p.resolveSnapshotSelectorTerms(ctx, nodeClass); err != nil {
+		return nil, err
+	}
+	return p.amiFamily.Resolve(nodeClass, nodeClaim, instanceTypes, capacityType, options)
+}
+
+// resolveSecretReferences returns a copy of the EC2NodeClass with the references to secrets in its userData, container
+// registry credentials and SSM hybrid activation code replaced by their values
+func (p *DefaultProvider) resolveSecretReferences(ctx context.Context, nodeClass *v1.EC2NodeClass) (*v1.EC2NodeClass, error) {
+	if nodeClass.Spec.UserData == nil && len(nodeClass.Spec.ContainerRegistries) == 0 &&
+		(nodeClass.Spec.SSMAgent == nil || nodeClass.Spec.SSMAgent.HybridActivation == nil) {
+		return nodeClass, nil
+	}
+	nodeClass = nodeClass.DeepCopy()
+	if nodeClass.Spec.UserData != nil {
+		userData, err := p.secretProvider.Resolve(ctx, *nodeClass.Spec.UserData)
+		if err != nil {
+			return nil, fmt.Errorf("resolving userData references, %w", err)
+		}
+		nodeClass.Spec.UserData = lo.ToPtr(userData)
+	}
+	for _, registry := range nodeClass.Spec.ContainerRegistries {
+		if registry.Credentials == nil {
+			continue
+		}
+		username, err := p.secretProvider.Resolve(ctx, registry.Credentials.Username)
+		if err != nil {
+			return nil, fmt.Errorf("resolving container registry credentials of %s, %w", registry.Host, err)
+		}
+		password, err := p.secretProvider.Resolve(ctx, registry.Credentials.Password)
+		if err != nil {
+			return nil, fmt.Errorf("resolving container registry credentials of %s, %w", registry.Host, err)
+		}
+		registry.Credentials.Username, registry.Credentials.Password = username, password
+	}
+	if nodeClass.Spec.SSMAgent != nil && nodeClass.Spec.SSMAgent.HybridActivation != nil {
+		activation := nodeClass.Spec.SSMAgent.HybridActivation
+		code, err := p.secretProvider.Resolve(ctx, activation.Code)
+		if err != nil {
+			return nil, fmt.Errorf("resolving ssm hybrid activation code, %w", err)
+		}
+		// The code is rendered into the scripts of the user data, so it's restricted to the characters of activation codes
+		if !activationCodePattern.MatchString(code) {
+			return nil, fmt.Errorf("resolving ssm hybrid activation code, resolved value is not a valid activation code")
+		}
+		activation.Code = code
+	}
+	return nodeClass, nil
+}
+
+// resolveSnapshotSelectorTerms returns a copy of the EC2NodeClass with the snapshotSelectorTerms of its block devices
+// replaced by the ID of the newest snapshot that they select
+func (p *DefaultProvider) resolveSnapshotSelectorTerms(ctx context.Context, nodeClass *v1.EC2NodeClass) (*v1.EC2NodeClass, error) {
+	if !lo.ContainsBy(nodeClass.Spec.BlockDeviceMappings, func(bdm *v1.BlockDeviceMapping) bool {
+		return bdm.EBS != nil && len(bdm.EBS.SnapshotSelectorTerms) > 0
+	}) {
+		return nodeClass, nil
+	}
+	nodeClass = nodeClass.DeepCopy()
+	for _, blockDeviceMapping := range nodeClass.Spec.BlockDeviceMappings {
+		if blockDeviceMapping.EBS == nil || len(blockDeviceMapping.EBS.SnapshotSelectorTerms) == 0 {
+			continue
+		}
+		snapshotID, err := p.snapshotProvider.Get(ctx, blockDeviceMapping.EBS.SnapshotSelectorTerms)
+		if err != nil {
+			return nil, fmt.Errorf("resolving snapshot of block device %s, %w", lo.FromPtr(blockDeviceMapping.DeviceName), err)
+		}
+		blockDeviceMapping.EBS.SnapshotID = lo.ToPtr(snapshotID)
+		blockDeviceMapping.EBS.SnapshotSelectorTerms = nil
+	}
+	return nodeClass, nil
+}
+
 // InvalidateCache deletes a launch template from cache if it exists
 func (p *DefaultProvider) InvalidateCache(ctx context.Context, ltName string, ltID string) {
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("launch-template-name", ltName, "launch-template-id", ltID))
@@ -144,7 +285,7 @@ func (p *DefaultProvider) InvalidateCache(ctx context.Context, ltName string, lt
 	defer p.cache.OnEvicted(p.cachedEvictedFunc(ctx))
 	p.cache.OnEvicted(nil)
 	log.FromContext(ctx).V(1).Info("invalidating launch template in the cache because it no longer exists")
-	p.cache.Delete(ltName)
+	p.cache.Delete(assumerole.CacheKey(ctx, ltName))
 }
 
 func LaunchTemplateName(options *amifamily.LaunchTemplate) string {
@@ -186,6 +327,8 @@ func (p *DefaultProvider) createAMIOptions(ctx context.Context, nodeClass *v1.EC
 		CABundle:                 p.CABundle,
 		KubeDNSIP:                p.KubeDNSIP,
 		AssociatePublicIPAddress: nodeClass.Spec.AssociatePublicIPAddress,
+		PrivateDNSNameOptions:    nodeClass.Spec.PrivateDNSNameOptions,
+		PrimaryNetworkInterface:  nodeClass.Spec.PrimaryNetworkInterface,
 		NodeClassName:            nodeClass.Name,
 	}, nil
 }
@@ -194,9 +337,11 @@ func (p *DefaultProvider) ensureLaunchTemplate(ctx context.Context, options *ami
 	var launchTemplate *ec2.LaunchTemplate
 	name := LaunchTemplateName(options)
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("launch-template-name", name))
+	// Launch templates are cached per role since they are owned by the account of the role that created them
+	key := assumerole.CacheKey(ctx, name)
 	// Read from cache
-	if launchTemplate, ok := p.cache.Get(name); ok {
-		p.cache.SetDefault(name, launchTemplate)
+	if launchTemplate, ok := p.cache.Get(key); ok {
+		p.cache.SetDefault(key, launchTemplate)
 		return launchTemplate.(*ec2.LaunchTemplate), nil
 	}
 	// Attempt to find an existing LT.
@@ -219,46 +364,18 @@ func (p *DefaultProvider) ensureLaunchTemplate(ctx context.Context, options *ami
 		}
 		launchTemplate = output.LaunchTemplates[0]
 	}
-	p.cache.SetDefault(name, launchTemplate)
+	p.cache.SetDefault(key, launchTemplate)
 	return launchTemplate, nil
 }
 
 func (p *DefaultProvider) createLaunchTemplate(ctx context.Context, options *amifamily.LaunchTemplate) (*ec2.LaunchTemplate, error) {
-	userData, err := options.UserData.Script()
+	data, err := p.launchTemplateData(options)
 	if err != nil {
 		return nil, err
 	}
-	launchTemplateDataTags := []*ec2.LaunchTemplateTagSpecificationRequest{
-		{ResourceType: aws.String(ec2.ResourceTypeNetworkInterface), Tags: utils.MergeTags(options.Tags)},
-	}
-	// Add the spot-instances-request tag if trying to launch spot capacity
-	if options.CapacityType == karpv1.CapacityTypeSpot {
-		launchTemplateDataTags = append(launchTemplateDataTags, &ec2.LaunchTemplateTagSpecificationRequest{ResourceType: aws.String(ec2.ResourceTypeSpotInstancesRequest), Tags: utils.MergeTags(options.Tags)})
-	}
-	networkInterfaces := p.generateNetworkInterfaces(options)
 	output, err := p.ec2api.CreateLaunchTemplateWithContext(ctx, &ec2.CreateLaunchTemplateInput{
 		LaunchTemplateName: aws.String(LaunchTemplateName(options)),
-		LaunchTemplateData: &ec2.RequestLaunchTemplateData{
-			BlockDeviceMappings: p.blockDeviceMappings(options.BlockDeviceMappings),
-			IamInstanceProfile: &ec2.LaunchTemplateIamInstanceProfileSpecificationRequest{
-				Name: aws.String(options.InstanceProfile),
-			},
-			Monitoring: &ec2.LaunchTemplatesMonitoringRequest{
-				Enabled: aws.Bool(options.DetailedMonitoring),
-			},
-			// If the network interface is defined, the security groups are defined within it
-			SecurityGroupIds: lo.Ternary(networkInterfaces != nil, nil, lo.Map(options.SecurityGroups, func(s v1.SecurityGroup, _ int) *string { return aws.String(s.ID) })),
-			UserData:         aws.String(userData),
-			ImageId:          aws.String(options.AMIID),
-			MetadataOptions: &ec2.LaunchTemplateInstanceMetadataOptionsRequest{
-				HttpEndpoint:            options.MetadataOptions.HTTPEndpoint,
-				HttpProtocolIpv6:        options.MetadataOptions.HTTPProtocolIPv6,
-				HttpPutResponseHopLimit: options.MetadataOptions.HTTPPutResponseHopLimit,
-				HttpTokens:              options.MetadataOptions.HTTPTokens,
-			},
-			NetworkInterfaces: networkInterfaces,
-			TagSpecifications: launchTemplateDataTags,
-		},
+		LaunchTemplateData: data,
 		TagSpecifications: []*ec2.TagSpecification{
 			{
 				ResourceType: aws.String(ec2.ResourceTypeLaunchTemplate),
@@ -273,6 +390,78 @@ func (p *DefaultProvider) createLaunchTemplate(ctx context.Context, options *ami
 	return output.LaunchTemplate, nil
 }
 
+// launchTemplateData returns the data of the launch template of the resolved launch template options
+func (p *DefaultProvider) launchTemplateData(options *amifamily.LaunchTemplate) (*ec2.RequestLaunchTemplateData, error) {
+	userData, err := options.UserData.Script()
+	if err != nil {
+		return nil, err
+	}
+	launchTemplateDataTags := []*ec2.LaunchTemplateTagSpecificationRequest{
+		{ResourceType: aws.String(ec2.ResourceTypeNetworkInterface), Tags: utils.MergeTags(p.networkInterfaceTags(options), options.Tags)},
+	}
+	// Add the spot-instances-request tag if trying to launch spot capacity
+	if options.CapacityType == karpv1.CapacityTypeSpot {
+		launchTemplateDataTags = append(launchTemplateDataTags, &ec2.LaunchTemplateTagSpecificationRequest{ResourceType: aws.String(ec2.ResourceTypeSpotInstancesRequest), Tags: utils.MergeTags(options.Tags)})
+	}
+	networkInterfaces := p.generateNetworkInterfaces(options)
+	return &ec2.RequestLaunchTemplateData{
+		BlockDeviceMappings: p.blockDeviceMappings(options.BlockDeviceMappings),
+		IamInstanceProfile: &ec2.LaunchTemplateIamInstanceProfileSpecificationRequest{
+			Name: aws.String(options.InstanceProfile),
+		},
+		Monitoring: &ec2.LaunchTemplatesMonitoringRequest{
+			Enabled: aws.Bool(options.DetailedMonitoring),
+		},
+		CreditSpecification: p.creditSpecification(options),
+		EbsOptimized:        options.EBSOptimized,
+		KeyName:             options.KeyName,
+		// If the network interface is defined, the security groups are defined within it
+		SecurityGroupIds: lo.Ternary(networkInterfaces != nil, nil, lo.Map(options.SecurityGroups, func(s v1.SecurityGroup, _ int) *string { return aws.String(s.ID) })),
+		UserData:         aws.String(userData),
+		ImageId:          aws.String(options.AMIID),
+		MetadataOptions: &ec2.LaunchTemplateInstanceMetadataOptionsRequest{
+			HttpEndpoint:            options.MetadataOptions.HTTPEndpoint,
+			HttpProtocolIpv6:        options.MetadataOptions.HTTPProtocolIPv6,
+			HttpPutResponseHopLimit: options.MetadataOptions.HTTPPutResponseHopLimit,
+			HttpTokens:              options.MetadataOptions.HTTPTokens,
+		},
+		NetworkInterfaces:     networkInterfaces,
+		PrivateDnsNameOptions: p.privateDNSNameOptions(options),
+		TagSpecifications:     launchTemplateDataTags,
+	}, nil
+}
+
+// creditSpecification sets the credit option of the launch templates of burstable instance types, which is only
+// resolved when the EC2NodeClass sets it
+func (p *DefaultProvider) creditSpecification(options *amifamily.LaunchTemplate) *ec2.CreditSpecificationRequest {
+	if options.CreditSpecification == nil {
+		return nil
+	}
+	return &ec2.CreditSpecificationRequest{CpuCredits: aws.String(string(lo.FromPtr(options.CreditSpecification)))}
+}
+
+// privateDNSNameOptions sets the hostname type of the instances and the DNS records of their hostnames. Resource name
+// hostnames get a DNS A record unless it's disabled, so that they resolve like the IP name hostnames do.
+func (p *DefaultProvider) privateDNSNameOptions(options *amifamily.LaunchTemplate) *ec2.LaunchTemplatePrivateDnsNameOptionsRequest {
+	if options.PrivateDNSNameOptions == nil {
+		return nil
+	}
+	dnsNameOptions := &ec2.LaunchTemplatePrivateDnsNameOptionsRequest{
+		EnableResourceNameDnsARecord:    options.PrivateDNSNameOptions.EnableResourceNameDNSARecord,
+		EnableResourceNameDnsAAAARecord: options.PrivateDNSNameOptions.EnableResourceNameDNSAAAARecord,
+	}
+	switch lo.FromPtr(options.PrivateDNSNameOptions.HostnameType) {
+	case v1.HostnameTypeIPName:
+		dnsNameOptions.HostnameType = aws.String(ec2.HostnameTypeIpName)
+	case v1.HostnameTypeResourceName:
+		dnsNameOptions.HostnameType = aws.String(ec2.HostnameTypeResourceName)
+		if dnsNameOptions.EnableResourceNameDnsARecord == nil {
+			dnsNameOptions.EnableResourceNameDnsARecord = aws.Bool(true)
+		}
+	}
+	return dnsNameOptions
+}
+
 // generateNetworkInterfaces generates network interfaces for the launch template.
 func (p *DefaultProvider) generateNetworkInterfaces(options *amifamily.LaunchTemplate) []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest {
 	if options.EFACount != 0 {
@@ -286,22 +475,53 @@ func (p *DefaultProvider) generateNetworkInterfaces(options *amifamily.LaunchTem
 				// Instances launched with multiple pre-configured network interfaces cannot set AssociatePublicIPAddress to true. This is an EC2 limitation. However, this does not apply for instances
 				// with a single EFA network interface, and we should support those use cases. Launch failures with multiple enis should be considered user misconfiguration.
 				AssociatePublicIpAddress: options.AssociatePublicIPAddress,
+				// Only the primary network interface is assigned an IPv6 address for the node IP
+				Ipv6AddressCount: lo.Ternary(i == 0 && options.IPv6AddressCount != 0, lo.ToPtr(options.IPv6AddressCount), nil),
+				Description:      lo.Ternary(i == 0, p.networkInterfaceDescription(options), nil),
 			}
 		})
 	}
 
-	if options.AssociatePublicIPAddress != nil {
+	interfaceType := p.networkInterfaceType(options)
+	description := p.networkInterfaceDescription(options)
+	if options.AssociatePublicIPAddress != nil || options.IPv6AddressCount != 0 || interfaceType != nil || description != nil {
 		return []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{
 			{
 				AssociatePublicIpAddress: options.AssociatePublicIPAddress,
 				DeviceIndex:              aws.Int64(0),
 				Groups:                   lo.Map(options.SecurityGroups, func(s v1.SecurityGroup, _ int) *string { return aws.String(s.ID) }),
+				Ipv6AddressCount:         lo.Ternary(options.IPv6AddressCount != 0, lo.ToPtr(options.IPv6AddressCount), nil),
+				InterfaceType:            interfaceType,
+				Description:              description,
 			},
 		}
 	}
 	return nil
 }
 
+// networkInterfaceType returns the EC2 interface type of the primary network interface. Standard network interfaces
+// are left to the default of EC2.
+func (p *DefaultProvider) networkInterfaceType(options *amifamily.LaunchTemplate) *string {
+	if options.PrimaryNetworkInterface == nil || lo.FromPtr(options.PrimaryNetworkInterface.InterfaceType) != v1.NetworkInterfaceTypeEFA {
+		return nil
+	}
+	return aws.String(ec2.NetworkInterfaceTypeEfa)
+}
+
+func (p *DefaultProvider) networkInterfaceDescription(options *amifamily.LaunchTemplate) *string {
+	if options.PrimaryNetworkInterface == nil {
+		return nil
+	}
+	return options.PrimaryNetworkInterface.Description
+}
+
+func (p *DefaultProvider) networkInterfaceTags(options *amifamily.LaunchTemplate) map[string]string {
+	if options.PrimaryNetworkInterface == nil {
+		return nil
+	}
+	return options.PrimaryNetworkInterface.Tags
+}
+
 func (p *DefaultProvider) blockDeviceMappings(blockDeviceMappings []*v1.BlockDeviceMapping) []*ec2.LaunchTemplateBlockDeviceMappingRequest {
 	if len(blockDeviceMappings) == 0 {
 		// The EC2 API fails with empty slices and expects nil.
@@ -362,7 +582,9 @@ func (p *DefaultProvider) cachedEvictedFunc(ctx context.Context) func(string, in
 			return
 		}
 		launchTemplate := lt.(*ec2.LaunchTemplate)
-		if _, err := p.ec2api.DeleteLaunchTemplateWithContext(ctx, &ec2.DeleteLaunchTemplateInput{LaunchTemplateId: launchTemplate.LaunchTemplateId}); awserrors.IgnoreNotFound(err) != nil {
+		// Delete the launch template with the role that it was created with
+		_, roleARN := assumerole.FromCacheKey(key)
+		if _, err := p.ec2api.DeleteLaunchTemplateWithContext(assumerole.WithRole(ctx, roleARN), &ec2.DeleteLaunchTemplateInput{LaunchTemplateId: launchTemplate.LaunchTemplateId}); awserrors.IgnoreNotFound(err) != nil {
 			log.FromContext(ctx).WithValues("launch-template", launchTemplate.LaunchTemplateName).Error(err, "failed to delete launch template")
 			return
 		}
@@ -421,21 +643,79 @@ func (p *DefaultProvider) ResolveClusterCIDR(ctx context.Context) error {
 	if p.ClusterCIDR.Load() != nil {
 		return nil
 	}
-	out, err := p.eksapi.DescribeClusterWithContext(ctx, &eks.DescribeClusterInput{
-		Name: aws.String(options.FromContext(ctx).ClusterName),
-	})
+	cluster, err := p.describeCluster(ctx)
 	if err != nil {
 		return err
 	}
-	if ipv4CIDR := out.Cluster.KubernetesNetworkConfig.ServiceIpv4Cidr; ipv4CIDR != nil {
-		p.ClusterCIDR.Store(ipv4CIDR)
-		log.FromContext(ctx).WithValues("cluster-cidr", *ipv4CIDR).V(1).Info("discovered cluster CIDR")
-		return nil
-	}
-	if ipv6CIDR := out.Cluster.KubernetesNetworkConfig.ServiceIpv6Cidr; ipv6CIDR != nil {
-		p.ClusterCIDR.Store(ipv6CIDR)
-		log.FromContext(ctx).WithValues("cluster-cidr", *ipv6CIDR).V(1).Info("discovered cluster CIDR")
+	if cidr := serviceCIDR(cluster); cidr != "" {
+		p.ClusterCIDR.Store(lo.ToPtr(cidr))
+		log.FromContext(ctx).WithValues("cluster-cidr", cidr).V(1).Info("discovered cluster CIDR")
 		return nil
 	}
 	return fmt.Errorf("no CIDR found in DescribeCluster response")
 }
+
+// ResolveCluster returns the values of the cluster that nodes are bootstrapped with, and describes the fields whose
+// values don't match the values that EKS reports for the cluster. Clusters that EKS doesn't know, such as clusters
+// that aren't managed by EKS, and controllers that aren't allowed to describe the cluster, only return the values
+// that nodes are bootstrapped with.
+func (p *DefaultProvider) ResolveCluster(ctx context.Context) (*v1.Cluster, []string, error) {
+	resolved := &v1.Cluster{
+		Endpoint:    p.ClusterEndpoint,
+		ServiceCIDR: lo.FromPtr(p.ClusterCIDR.Load()),
+	}
+	caBundle, err := base64.StdEncoding.DecodeString(lo.FromPtr(p.CABundle))
+	if err != nil {
+		caBundle = []byte(lo.FromPtr(p.CABundle))
+	}
+	if len(caBundle) != 0 {
+		resolved.CertificateAuthorityHash = fmt.Sprintf("%x", sha256.Sum256(caBundle))
+	}
+	cluster, err := p.describeCluster(ctx)
+	if awserrors.IsNotFound(err) || awserrors.IsAccessDenied(err) {
+		return resolved, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	resolved.IPFamily = aws.StringValue(lo.FromPtr(cluster.KubernetesNetworkConfig).IpFamily)
+
+	var drifted []string
+	if endpoint := aws.StringValue(cluster.Endpoint); endpoint != "" && !strings.EqualFold(strings.TrimSuffix(endpoint, "/"), strings.TrimSuffix(resolved.Endpoint, "/")) {
+		drifted = append(drifted, fmt.Sprintf("endpoint %q doesn't match %q", resolved.Endpoint, endpoint))
+	}
+	if data := aws.StringValue(lo.FromPtr(cluster.CertificateAuthority).Data); data != "" && len(caBundle) != 0 {
+		if discovered, err := base64.StdEncoding.DecodeString(data); err == nil && strings.TrimSpace(string(discovered)) != strings.TrimSpace(string(caBundle)) {
+			drifted = append(drifted, "certificate authority doesn't match")
+		}
+	}
+	if cidr := serviceCIDR(cluster); cidr != "" && resolved.ServiceCIDR != "" && cidr != resolved.ServiceCIDR {
+		drifted = append(drifted, fmt.Sprintf("service CIDR %q doesn't match %q", resolved.ServiceCIDR, cidr))
+	}
+	return resolved, drifted, nil
+}
+
+// describeCluster describes the cluster, caching the response so that drift can be checked for every EC2NodeClass
+func (p *DefaultProvider) describeCluster(ctx context.Context) (*eks.Cluster, error) {
+	name := options.FromContext(ctx).ClusterName
+	if cluster, ok := p.clusterCache.Get(name); ok {
+		return cluster.(*eks.Cluster), nil
+	}
+	out, err := p.eksapi.DescribeClusterWithContext(ctx, &eks.DescribeClusterInput{
+		Name: aws.String(name),
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.clusterCache.SetDefault(name, out.Cluster)
+	return out.Cluster, nil
+}
+
+// serviceCIDR returns the CIDR of the services of the cluster, for the IP family of the cluster
+func serviceCIDR(cluster *eks.Cluster) string {
+	config := lo.FromPtr(cluster.KubernetesNetworkConfig)
+	if aws.StringValue(config.IpFamily) == eks.IpFamilyIpv6 {
+		return lo.CoalesceOrEmpty(aws.StringValue(config.ServiceIpv6Cidr), aws.StringValue(config.ServiceIpv4Cidr))
+	}
+	return lo.CoalesceOrEmpty(aws.StringValue(config.ServiceIpv4Cidr), aws.StringValue(config.ServiceIpv6Cidr))
+}
@@ -16,6 +16,7 @@ package launchtemplate_test
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -96,7 +97,7 @@ var _ = BeforeSuite(func() {
 
 	fakeClock = &clock.FakeClock{}
 	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
-		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
+		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider, awsEnv.AlertTracker, awsEnv.UnavailableOfferingsCache)
 	cluster = state.NewCluster(fakeClock, env.Client)
 	prov = provisioning.NewProvisioner(env.Client, events.NewRecorder(&record.FakeRecorder{}), cloudProvider, cluster)
 })
@@ -770,6 +771,94 @@ var _ = Describe("LaunchTemplate Provider", func() {
 				Expect(*ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.KmsKeyId).To(Equal("arn:aws:kms:us-west-2:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab"))
 			})
 		})
+		Context("Snapshot Selector Terms", func() {
+			var now time.Time
+			BeforeEach(func() {
+				now = time.Now()
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2023@latest"}}
+				awsEnv.LaunchTemplateProvider.CABundle = lo.ToPtr("Y2EtYnVuZGxlCg==")
+				awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(lo.ToPtr("10.100.0.0/16"))
+				nodeClass.Spec.BlockDeviceMappings = []*v1.BlockDeviceMapping{
+					{
+						DeviceName: aws.String("/dev/xvda"),
+						EBS:        &v1.BlockDevice{VolumeSize: lo.ToPtr(resource.MustParse("20Gi"))},
+						RootVolume: true,
+					},
+					{
+						DeviceName: aws.String("/dev/xvdb"),
+						EBS: &v1.BlockDevice{
+							VolumeSize:            lo.ToPtr(resource.MustParse("100Gi")),
+							SnapshotSelectorTerms: []v1.SnapshotSelectorTerm{{Tags: map[string]string{"image-cache": "ml"}}},
+						},
+						ImageFSVolume: true,
+					},
+				}
+				awsEnv.EC2API.DescribeSnapshotsOutput.Set(&ec2.DescribeSnapshotsOutput{
+					Snapshots: []*ec2.Snapshot{
+						{SnapshotId: aws.String("snap-old"), OwnerId: aws.String("111122223333"), State: aws.String(ec2.SnapshotStateCompleted), StartTime: aws.Time(now.Add(-2 * time.Hour)), Tags: []*ec2.Tag{{Key: aws.String("image-cache"), Value: aws.String("ml")}}},
+						{SnapshotId: aws.String("snap-new"), OwnerId: aws.String("111122223333"), State: aws.String(ec2.SnapshotStateCompleted), StartTime: aws.Time(now.Add(-time.Hour)), Tags: []*ec2.Tag{{Key: aws.String("image-cache"), Value: aws.String("ml")}}},
+						{SnapshotId: aws.String("snap-pending"), OwnerId: aws.String("111122223333"), State: aws.String(ec2.SnapshotStatePending), StartTime: aws.Time(now), Tags: []*ec2.Tag{{Key: aws.String("image-cache"), Value: aws.String("ml")}}},
+						{SnapshotId: aws.String("snap-other"), OwnerId: aws.String("444455556666"), State: aws.String(ec2.SnapshotStateCompleted), StartTime: aws.Time(now), Tags: []*ec2.Tag{{Key: aws.String("image-cache"), Value: aws.String("web")}}},
+					},
+				})
+			})
+			It("should use the newest completed snapshot that matches the terms", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+					Expect(ltInput.LaunchTemplateData.BlockDeviceMappings).To(HaveLen(2))
+					Expect(ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.SnapshotId).To(BeNil())
+					Expect(aws.StringValue(ltInput.LaunchTemplateData.BlockDeviceMappings[1].Ebs.SnapshotId)).To(Equal("snap-new"))
+				})
+			})
+			It("should only select snapshots of the owner of the term", func() {
+				nodeClass.Spec.BlockDeviceMappings[1].EBS.SnapshotSelectorTerms = []v1.SnapshotSelectorTerm{
+					{Tags: map[string]string{"image-cache": "*"}, Owner: "444455556666"},
+				}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+					Expect(aws.StringValue(ltInput.LaunchTemplateData.BlockDeviceMappings[1].Ebs.SnapshotId)).To(Equal("snap-other"))
+				})
+			})
+			It("should create a new launch template when a newer snapshot completes", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				names := sets.New[string]()
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+					names.Insert(aws.StringValue(ltInput.LaunchTemplateName))
+				})
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Reset()
+
+				output := awsEnv.EC2API.DescribeSnapshotsOutput.Clone()
+				output.Snapshots[2].State = aws.String(ec2.SnapshotStateCompleted)
+				awsEnv.EC2API.DescribeSnapshotsOutput.Set(output)
+				awsEnv.SnapshotCache.Flush()
+				pod = coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+					Expect(names.Has(aws.StringValue(ltInput.LaunchTemplateName))).To(BeFalse())
+					Expect(aws.StringValue(ltInput.LaunchTemplateData.BlockDeviceMappings[1].Ebs.SnapshotId)).To(Equal("snap-pending"))
+				})
+			})
+			It("should fail to launch when no completed snapshot matches the terms", func() {
+				nodeClass.Spec.BlockDeviceMappings[1].EBS.SnapshotSelectorTerms = []v1.SnapshotSelectorTerm{{Tags: map[string]string{"image-cache": "batch"}}}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectNotScheduled(ctx, env.Client, pod)
+			})
+		})
 	})
 	Context("Ephemeral Storage", func() {
 		It("should pack pods when a daemonset has an ephemeral-storage request", func() {
@@ -1012,12 +1101,16 @@ var _ = Describe("LaunchTemplate Provider", func() {
 				"",
 				nodeClass.Spec.BlockDeviceMappings,
 				nodeClass.Spec.InstanceStorePolicy,
+				nodeClass.Spec.Hugepages,
+				nodeClass.Spec.Neuron,
+				nodeClass.Spec.VPCCNI,
 				nodeClass.Spec.Kubelet.MaxPods,
 				nodeClass.Spec.Kubelet.PodsPerCore,
 				nodeClass.Spec.Kubelet.KubeReserved,
 				nodeClass.Spec.Kubelet.SystemReserved,
 				nodeClass.Spec.Kubelet.EvictionHard,
 				nodeClass.Spec.Kubelet.EvictionSoft,
+				nodeClass.Spec.Kubelet.ReservedSizing,
 				amiFamily,
 				nil,
 			)
@@ -1066,12 +1159,16 @@ var _ = Describe("LaunchTemplate Provider", func() {
 				"",
 				nodeClass.Spec.BlockDeviceMappings,
 				nodeClass.Spec.InstanceStorePolicy,
+				nodeClass.Spec.Hugepages,
+				nodeClass.Spec.Neuron,
+				nodeClass.Spec.VPCCNI,
 				nodeClass.Spec.Kubelet.MaxPods,
 				nodeClass.Spec.Kubelet.PodsPerCore,
 				nodeClass.Spec.Kubelet.KubeReserved,
 				nodeClass.Spec.Kubelet.SystemReserved,
 				nodeClass.Spec.Kubelet.EvictionHard,
 				nodeClass.Spec.Kubelet.EvictionSoft,
+				nodeClass.Spec.Kubelet.ReservedSizing,
 				amiFamily,
 				nil,
 			)
@@ -1092,12 +1189,16 @@ var _ = Describe("LaunchTemplate Provider", func() {
 				"",
 				nodeClass.Spec.BlockDeviceMappings,
 				nodeClass.Spec.InstanceStorePolicy,
+				nodeClass.Spec.Hugepages,
+				nodeClass.Spec.Neuron,
+				nodeClass.Spec.VPCCNI,
 				nodeClass.Spec.Kubelet.MaxPods,
 				nodeClass.Spec.Kubelet.PodsPerCore,
 				nodeClass.Spec.Kubelet.KubeReserved,
 				nodeClass.Spec.Kubelet.SystemReserved,
 				nodeClass.Spec.Kubelet.EvictionHard,
 				nodeClass.Spec.Kubelet.EvictionSoft,
+				nodeClass.Spec.Kubelet.ReservedSizing,
 				amiFamily,
 				nil,
 			)
@@ -1175,6 +1276,37 @@ var _ = Describe("LaunchTemplate Provider", func() {
 				}
 			})
 		})
+		It("should specify the --kube-reserved and --system-reserved of the instance type when reserved resources are sized", func() {
+			nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{
+				ReservedSizing: &v1.ReservedSizing{
+					SystemReserved: &v1.ReservedCurve{
+						CPU:          []v1.ReservedRange{{UpTo: lo.ToPtr("1"), Percentage: "10%"}, {Percentage: "5%"}},
+						MemoryPerPod: lo.ToPtr("10Mi"),
+					},
+				},
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{corev1.LabelInstanceTypeStable: "m5.large"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(Equal(1))
+			ltInput := awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Pop()
+			userData, err := base64.StdEncoding.DecodeString(*ltInput.LaunchTemplateData.UserData)
+			Expect(err).To(BeNil())
+			// m5.large has 2 cores and 29 pods
+			for arg, expected := range map[string][]string{
+				"--kube-reserved=":   {"cpu=70m", "memory=574Mi", "ephemeral-storage=1Gi"},
+				"--system-reserved=": {"cpu=150m", "memory=290Mi"},
+			} {
+				i := strings.Index(string(userData), arg)
+				Expect(i).To(BeNumerically(">=", 0))
+				rem := string(userData)[(i + len(arg)):]
+				i = strings.Index(rem, "'")
+				for _, v := range expected {
+					Expect(rem[:i]).To(ContainSubstring(v))
+				}
+			}
+		})
 		It("should pass eviction hard threshold values when specified", func() {
 			nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{
 				EvictionHard: map[string]string{
@@ -1313,6 +1445,35 @@ var _ = Describe("LaunchTemplate Provider", func() {
 			ExpectScheduled(ctx, env.Client, pod)
 			ExpectLaunchTemplatesCreatedWithUserDataContaining("--dns-cluster-ip '10.0.100.10'")
 		})
+		It("should specify --ip-family when the node IP family is IPv6 in an ipv4 cluster", func() {
+			nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{NodeIPFamily: lo.ToPtr(v1.NodeIPFamilyIPv6)}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			ExpectLaunchTemplatesCreatedWithUserDataContaining("--dns-cluster-ip '10.0.100.10'")
+			ExpectLaunchTemplatesCreatedWithUserDataContaining("--ip-family ipv6")
+		})
+		It("should not specify --ip-family when the node IP family is IPv4 in an ipv6 cluster", func() {
+			awsEnv.LaunchTemplateProvider.KubeDNSIP = net.ParseIP("fd4b:121b:812b::a")
+			nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{NodeIPFamily: lo.ToPtr(v1.NodeIPFamilyIPv4)}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			ExpectLaunchTemplatesCreatedWithUserDataContaining("--dns-cluster-ip 'fd4b:121b:812b::a'")
+			ExpectLaunchTemplatesCreatedWithUserDataNotContaining("--ip-family")
+		})
+		It("should pass ResolvConf when specified", func() {
+			nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{
+				ResolvConf: lo.ToPtr("/etc/kubernetes/resolv.conf"),
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			ExpectLaunchTemplatesCreatedWithUserDataContaining("--resolv-conf=/etc/kubernetes/resolv.conf")
+		})
 		It("should pass ImageGCHighThresholdPercent when specified", func() {
 			nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{
 				ImageGCHighThresholdPercent: aws.Int32(50),
@@ -1364,6 +1525,43 @@ var _ = Describe("LaunchTemplate Provider", func() {
 			ExpectScheduled(ctx, env.Client, pod)
 			ExpectLaunchTemplatesCreatedWithUserDataContaining("--local-disks raid0")
 		})
+		Context("Size", func() {
+			It("should compress user data that's larger than the limit of EC2", func() {
+				nodeClass.Spec.UserData = aws.String("#!/bin/bash\n" + strings.Repeat("echo 'configuring the node'\n", 1000))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(input *ec2.CreateLaunchTemplateInput) {
+					userData, err := base64.StdEncoding.DecodeString(*input.LaunchTemplateData.UserData)
+					Expect(err).To(BeNil())
+					Expect(len(userData)).To(BeNumerically("<=", 16*1024))
+					// gzip header
+					Expect(userData[:2]).To(Equal([]byte{0x1f, 0x8b}))
+				})
+			})
+			It("should not compress user data that's within the limit of EC2", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("#!/bin/bash")
+			})
+			It("should fail to provision when the compressed user data is larger than the limit of EC2", func() {
+				nodeClass.Spec.UserData = aws.String("#!/bin/bash\n# " + base64.StdEncoding.EncodeToString([]byte(strings.Join(lo.Times(2000, func(i int) string {
+					return fmt.Sprintf("%x", sha256.Sum256([]byte(strconv.Itoa(i))))
+				}), ""))))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectNotScheduled(ctx, env.Client, pod)
+			})
+			It("should name the size of the custom user data when it's too large", func() {
+				_, err := bootstrap.Windows{Options: bootstrap.Options{CustomUserData: aws.String(strings.Repeat("#", 20000))}}.Script()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("exceeding the 16384 byte limit of EC2, 20000 of the 20228 bytes of the rendered user data are from spec.userData"))
+			})
+		})
 		Context("Bottlerocket", func() {
 			BeforeEach(func() {
 				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "bottlerocket@latest"}}
@@ -1793,6 +1991,27 @@ var _ = Describe("LaunchTemplate Provider", func() {
 					Entry("cpuCFSQuota", "cpuCFSQuota", v1.KubeletConfiguration{
 						CPUCFSQuota: lo.ToPtr(false),
 					}),
+					Entry("resolvConf", "resolvConf", v1.KubeletConfiguration{
+						ResolvConf: lo.ToPtr("/etc/kubernetes/resolv.conf"),
+					}),
+				)
+				DescribeTable(
+					"should specify the node IP in the Kubelet flags when the node IP family is specified",
+					func(nodeIPFamily string, expectedFlag string) {
+						nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{NodeIPFamily: lo.ToPtr(nodeIPFamily)}
+						ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+						pod := coretest.UnschedulablePod()
+						ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+						ExpectScheduled(ctx, env.Client, pod)
+						for _, userData := range ExpectUserDataExistsFromCreatedLaunchTemplates() {
+							configs := ExpectUserDataCreatedWithNodeConfigs(userData)
+							Expect(len(configs)).To(Equal(1))
+							Expect(configs[0].Spec.Kubelet.Flags).To(ContainElement(expectedFlag))
+							Expect(configs[0].Spec.Kubelet.Config).ToNot(HaveKey("nodeIPFamily"))
+						}
+					},
+					Entry("IPv4", v1.NodeIPFamilyIPv4, "--node-ip=0.0.0.0"),
+					Entry("IPv6", v1.NodeIPFamilyIPv6, "--node-ip=::"),
 				)
 			})
 			It("should set LocalDiskStrategy to Raid0 when specified by the InstanceStorePolicy", func() {
@@ -1876,6 +2095,89 @@ var _ = Describe("LaunchTemplate Provider", func() {
 				ExpectScheduled(ctx, env.Client, pod)
 				ExpectLaunchTemplatesCreatedWithUserData("special user data")
 			})
+			It("should render the labels and taints of the node into userData that references them when AMIFamily is Custom", func() {
+				nodeClass.Spec.UserData = aws.String("#!/bin/bash\n/etc/kubelet.sh --node-labels='${KARPENTER_NODE_LABELS}' --register-with-taints='${KARPENTER_NODE_TAINTS}'")
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Tags: map[string]string{"*": "*"}}}
+				nodeClass.Status.AMIs = []v1.AMI{
+					{
+						ID: "ami-123",
+						Requirements: []corev1.NodeSelectorRequirement{
+							{Key: corev1.LabelArchStable, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.ArchitectureAmd64}},
+						},
+					},
+				}
+				nodePool.Spec.Template.Labels = map[string]string{"team": "billing"}
+				nodePool.Spec.Template.Spec.Taints = []corev1.Taint{{Key: "dedicated", Value: "billing", Effect: corev1.TaintEffectNoSchedule}}
+				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+				pod := coretest.UnschedulablePod(coretest.PodOptions{Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}}})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining(
+					"team=billing",
+					fmt.Sprintf("%s=%s", karpv1.NodePoolLabelKey, nodePool.Name),
+					"--register-with-taints='dedicated=billing:NoSchedule,karpenter.sh/unregistered:NoExecute'",
+				)
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("${KARPENTER_NODE_LABELS}", "${KARPENTER_NODE_TAINTS}")
+			})
+			Context("Secret References", func() {
+				BeforeEach(func() {
+					nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Tags: map[string]string{"*": "*"}}}
+					nodeClass.Status.AMIs = []v1.AMI{
+						{
+							ID: "ami-123",
+							Requirements: []corev1.NodeSelectorRequirement{
+								{Key: corev1.LabelArchStable, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.ArchitectureAmd64}},
+							},
+						},
+					}
+				})
+				It("should resolve references to SSM parameters and Secrets Manager secrets in userData", func() {
+					awsEnv.SSMAPI.Parameters = map[string]string{"/karpenter/bootstrap-token": "abcdef.0123456789abcdef"}
+					awsEnv.SecretsManagerAPI.Secrets["registry"] = `{"username":"karpenter","password":"hunter2"}`
+					nodeClass.Spec.UserData = aws.String("token={{resolve:ssm-secure:/karpenter/bootstrap-token}} password={{resolve:secretsmanager:registry:SecretString:password}}")
+					ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+					pod := coretest.UnschedulablePod()
+					ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+					ExpectScheduled(ctx, env.Client, pod)
+					ExpectLaunchTemplatesCreatedWithUserData("token=abcdef.0123456789abcdef password=hunter2")
+				})
+				It("should resolve the whole secret string when no JSON key is referenced", func() {
+					awsEnv.SecretsManagerAPI.Secrets["arn:aws:secretsmanager:us-west-2:123456789012:secret:registry-AbCdEf"] = "hunter2"
+					nodeClass.Spec.UserData = aws.String("password={{resolve:secretsmanager:arn:aws:secretsmanager:us-west-2:123456789012:secret:registry-AbCdEf}}")
+					ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+					pod := coretest.UnschedulablePod()
+					ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+					ExpectScheduled(ctx, env.Client, pod)
+					ExpectLaunchTemplatesCreatedWithUserData("password=hunter2")
+				})
+				It("should not launch when a referenced secret doesn't exist", func() {
+					nodeClass.Spec.UserData = aws.String("password={{resolve:secretsmanager:registry}}")
+					ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+					pod := coretest.UnschedulablePod()
+					ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+					ExpectNotScheduled(ctx, env.Client, pod)
+					Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(Equal(0))
+				})
+				It("should create a new launch template once a referenced secret is rotated", func() {
+					awsEnv.SecretsManagerAPI.Secrets["registry"] = "hunter2"
+					nodeClass.Spec.UserData = aws.String("password={{resolve:secretsmanager:registry}}")
+					ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+					instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+					Expect(err).ToNot(HaveOccurred())
+					nodeClaim := coretest.NodeClaim()
+					before, err := awsEnv.LaunchTemplateProvider.EnsureAll(ctx, nodeClass, nodeClaim, instanceTypes, karpv1.CapacityTypeOnDemand, nil)
+					Expect(err).ToNot(HaveOccurred())
+
+					awsEnv.SecretsManagerAPI.Secrets["registry"] = "hunter3"
+					awsEnv.SecretCache.Flush()
+					after, err := awsEnv.LaunchTemplateProvider.EnsureAll(ctx, nodeClass, nodeClaim, instanceTypes, karpv1.CapacityTypeOnDemand, nil)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(lo.Map(after, func(lt *launchtemplate.LaunchTemplate, _ int) string { return lt.Name })).ToNot(ContainElements(lo.Map(before, func(lt *launchtemplate.LaunchTemplate, _ int) string { return lt.Name })))
+					userData, err := base64.StdEncoding.DecodeString(aws.StringValue(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Pop().LaunchTemplateData.UserData))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(userData)).To(Equal("password=hunter3"))
+				})
+			})
 			It("should correctly use ami selector with specific IDs in EC2NodeClass", func() {
 				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{ID: "ami-123"}, {ID: "ami-456"}}
 				awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []*ec2.Image{
@@ -1962,7 +2264,7 @@ var _ = Describe("LaunchTemplate Provider", func() {
 				}})
 				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Tags: map[string]string{"*": "*"}}}
 				ExpectApplied(ctx, env.Client, nodeClass)
-				controller := status.NewController(env.Client, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider)
+				controller := status.NewController(env.Client, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider, awsEnv.ImpairedZonesCache, awsEnv.EC2API, awsEnv.EBSEncryptionCache, awsEnv.ServiceQuotasAPI, awsEnv.QuotaUsageCache)
 				ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
 				nodePool.Spec.Template.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
 					{
@@ -2056,79 +2358,1109 @@ var _ = Describe("LaunchTemplate Provider", func() {
 				Entry("AssociatePublicIPAddress is false (EFA)", false, false, true),
 			)
 		})
-		Context("Kubelet Args", func() {
-			It("should specify the --dns-cluster-ip flag when clusterDNSIP is set", func() {
-				nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{ClusterDNS: []string{"10.0.10.100"}}
+		Context("Primary Network Interface", func() {
+			It("should launch instances with the description and tags of the primary network interface", func() {
+				nodeClass.Spec.PrimaryNetworkInterface = &v1.PrimaryNetworkInterface{
+					Description: lo.ToPtr("router"),
+					Tags:        map[string]string{"team": "network"},
+				}
 				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
 				pod := coretest.UnschedulablePod()
 				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
 				ExpectScheduled(ctx, env.Client, pod)
-				ExpectLaunchTemplatesCreatedWithUserDataContaining("--dns-cluster-ip '10.0.10.100'")
+				input := awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Pop()
+				Expect(input.LaunchTemplateData.NetworkInterfaces).To(HaveLen(1))
+				Expect(aws.StringValue(input.LaunchTemplateData.NetworkInterfaces[0].Description)).To(Equal("router"))
+				Expect(input.LaunchTemplateData.NetworkInterfaces[0].InterfaceType).To(BeNil())
+				Expect(input.LaunchTemplateData.SecurityGroupIds).To(BeNil())
+				tagSpecification, ok := lo.Find(input.LaunchTemplateData.TagSpecifications, func(t *ec2.LaunchTemplateTagSpecificationRequest) bool {
+					return aws.StringValue(t.ResourceType) == ec2.ResourceTypeNetworkInterface
+				})
+				Expect(ok).To(BeTrue())
+				Expect(tagSpecification.Tags).To(ContainElement(&ec2.Tag{Key: aws.String("team"), Value: aws.String("network")}))
+			})
+			It("should launch instances with an EFA primary network interface on instance types that support EFA", func() {
+				nodeClass.Spec.PrimaryNetworkInterface = &v1.PrimaryNetworkInterface{InterfaceType: lo.ToPtr(v1.NetworkInterfaceTypeEFA)}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				node := ExpectScheduled(ctx, env.Client, pod)
+				input := awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Pop()
+				Expect(aws.StringValue(input.LaunchTemplateData.NetworkInterfaces[0].InterfaceType)).To(Equal(ec2.NetworkInterfaceTypeEfa))
+				Expect(node.Labels[corev1.LabelInstanceTypeStable]).To(BeElementOf("dl1.24xlarge", "g4dn.8xlarge", "m6idn.32xlarge"))
 			})
 		})
-		Context("Windows Custom UserData", func() {
-			BeforeEach(func() {
-				nodePool.Spec.Template.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelOSStable, Operator: corev1.NodeSelectorOpIn, Values: []string{string(corev1.Windows)}}}}
-				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "windows2022@latest"}}
-				nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{MaxPods: lo.ToPtr[int32](110)}
+		Context("Private DNS Name Options", func() {
+			It("should launch instances with resource name hostnames and DNS A records", func() {
+				nodeClass.Spec.PrivateDNSNameOptions = &v1.PrivateDNSNameOptions{HostnameType: lo.ToPtr(v1.HostnameTypeResourceName)}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				input := awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Pop()
+				Expect(input.LaunchTemplateData.PrivateDnsNameOptions).To(Equal(&ec2.LaunchTemplatePrivateDnsNameOptionsRequest{
+					HostnameType:                 aws.String(ec2.HostnameTypeResourceName),
+					EnableResourceNameDnsARecord: aws.Bool(true),
+				}))
 			})
-			It("should merge and bootstrap with custom user data", func() {
-				content, err := os.ReadFile("testdata/windows_userdata_input.golden")
-				Expect(err).To(BeNil())
-				nodeClass.Spec.UserData = aws.String(string(content))
-				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
-				Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(nodePool), nodePool)).To(Succeed())
-				pod := coretest.UnschedulablePod(coretest.PodOptions{
-					NodeSelector: map[string]string{
-						corev1.LabelOSStable:     string(corev1.Windows),
-						corev1.LabelWindowsBuild: "10.0.20348",
-					},
-				})
+			It("should launch instances with resource name hostnames and DNS AAAA records", func() {
+				nodeClass.Spec.PrivateDNSNameOptions = &v1.PrivateDNSNameOptions{
+					HostnameType:                    lo.ToPtr(v1.HostnameTypeResourceName),
+					EnableResourceNameDNSARecord:    lo.ToPtr(false),
+					EnableResourceNameDNSAAAARecord: lo.ToPtr(true),
+				}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
 				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
 				ExpectScheduled(ctx, env.Client, pod)
-				content, err = os.ReadFile("testdata/windows_userdata_merged.golden")
-				Expect(err).To(BeNil())
-				ExpectLaunchTemplatesCreatedWithUserData(fmt.Sprintf(string(content), karpv1.NodePoolLabelKey, nodePool.Name))
+				input := awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Pop()
+				Expect(input.LaunchTemplateData.PrivateDnsNameOptions).To(Equal(&ec2.LaunchTemplatePrivateDnsNameOptionsRequest{
+					HostnameType:                    aws.String(ec2.HostnameTypeResourceName),
+					EnableResourceNameDnsARecord:    aws.Bool(false),
+					EnableResourceNameDnsAAAARecord: aws.Bool(true),
+				}))
 			})
-			It("should bootstrap when custom user data is empty", func() {
-				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
-				Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(nodePool), nodePool)).To(Succeed())
-				pod := coretest.UnschedulablePod(coretest.PodOptions{
-					NodeSelector: map[string]string{
-						corev1.LabelOSStable:     string(corev1.Windows),
-						corev1.LabelWindowsBuild: "10.0.20348",
-					},
-				})
+			It("should launch instances with IP name hostnames", func() {
+				nodeClass.Spec.PrivateDNSNameOptions = &v1.PrivateDNSNameOptions{HostnameType: lo.ToPtr(v1.HostnameTypeIPName)}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
 				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
 				ExpectScheduled(ctx, env.Client, pod)
-				content, err := os.ReadFile("testdata/windows_userdata_unmerged.golden")
-				Expect(err).To(BeNil())
-				ExpectLaunchTemplatesCreatedWithUserData(fmt.Sprintf(string(content), karpv1.NodePoolLabelKey, nodePool.Name))
+				input := awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Pop()
+				Expect(input.LaunchTemplateData.PrivateDnsNameOptions).To(Equal(&ec2.LaunchTemplatePrivateDnsNameOptionsRequest{
+					HostnameType: aws.String(ec2.HostnameTypeIpName),
+				}))
 			})
-		})
-	})
-	Context("Detailed Monitoring", func() {
-		It("should default detailed monitoring to off", func() {
-			nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2@latest"}}
-			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
-			pod := coretest.UnschedulablePod()
-			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
-			ExpectScheduled(ctx, env.Client, pod)
-			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
-			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
-				Expect(aws.BoolValue(ltInput.LaunchTemplateData.Monitoring.Enabled)).To(BeFalse())
+			It("should use the private DNS name options of the subnet by default", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				input := awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Pop()
+				Expect(input.LaunchTemplateData.PrivateDnsNameOptions).To(BeNil())
 			})
 		})
-		It("should pass detailed monitoring setting to the launch template at creation", func() {
-			nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2@latest"}}
-			nodeClass.Spec.DetailedMonitoring = aws.Bool(true)
-			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
-			pod := coretest.UnschedulablePod()
-			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
-			ExpectScheduled(ctx, env.Client, pod)
-			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
-			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
-				Expect(aws.BoolValue(ltInput.LaunchTemplateData.Monitoring.Enabled)).To(BeTrue())
+		Context("Dual-Stack", func() {
+			DescribeTable(
+				"should assign an IPv6 address to the primary network interface when the node IP family includes IPv6",
+				func(nodeIPFamily string, isEFA bool) {
+					nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{NodeIPFamily: lo.ToPtr(nodeIPFamily)}
+					ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+					pod := coretest.UnschedulablePod(lo.Ternary(isEFA, coretest.PodOptions{
+						ResourceRequirements: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{v1.ResourceEFA: resource.MustParse("2")},
+							Limits:   corev1.ResourceList{v1.ResourceEFA: resource.MustParse("2")},
+						},
+					}, coretest.PodOptions{}))
+					ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+					ExpectScheduled(ctx, env.Client, pod)
+					input := awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Pop()
+					Expect(aws.Int64Value(input.LaunchTemplateData.NetworkInterfaces[0].Ipv6AddressCount)).To(BeNumerically("==", 1))
+					for _, networkInterface := range input.LaunchTemplateData.NetworkInterfaces[1:] {
+						Expect(networkInterface.Ipv6AddressCount).To(BeNil())
+					}
+				},
+				Entry("IPv6", v1.NodeIPFamilyIPv6, false),
+				Entry("DualStack", v1.NodeIPFamilyDualStack, false),
+				Entry("DualStack (EFA)", v1.NodeIPFamilyDualStack, true),
+			)
+			It("should not specify network interfaces when the node IP family is IPv4", func() {
+				nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{NodeIPFamily: lo.ToPtr(v1.NodeIPFamilyIPv4)}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				input := awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Pop()
+				Expect(input.LaunchTemplateData.NetworkInterfaces).To(BeNil())
+			})
+			It("should register the node IPs of both IP families on AL2", func() {
+				nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{NodeIPFamily: lo.ToPtr(v1.NodeIPFamilyDualStack)}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining(
+					"http://169.254.169.254/latest/meta-data/ipv6",
+					`NODE_IP="${IPV4_ADDRESS},${IPV6_ADDRESS}"`,
+					`" --node-ip=${NODE_IP}"`,
+				)
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("--ip-family")
+			})
+			It("should register the IPv6 node IP first on AL2 in an ipv6 cluster", func() {
+				awsEnv.LaunchTemplateProvider.KubeDNSIP = net.ParseIP("fd4b:121b:812b::a")
+				nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{NodeIPFamily: lo.ToPtr(v1.NodeIPFamilyDualStack)}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining(
+					`NODE_IP="${IPV6_ADDRESS},${IPV4_ADDRESS}"`,
+					"--ip-family ipv6",
+					`" --node-ip=${NODE_IP}"`,
+				)
+			})
+			It("should register the node IPs of both IP families on AL2023", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2023@latest"}}
+				nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{NodeIPFamily: lo.ToPtr(v1.NodeIPFamilyDualStack)}
+				awsEnv.LaunchTemplateProvider.CABundle = lo.ToPtr("Y2EtYnVuZGxlCg==")
+				awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(lo.ToPtr("10.100.0.0/16"))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				for _, userData := range ExpectUserDataExistsFromCreatedLaunchTemplates() {
+					configs := ExpectUserDataCreatedWithNodeConfigs(userData)
+					Expect(len(configs)).To(Equal(1))
+					Expect(configs[0].Spec.Kubelet.Flags).ToNot(ContainElement(HavePrefix("--node-ip")))
+					Expect(userData).To(ContainSubstring(`NODE_IP="${IPV4_ADDRESS},${IPV6_ADDRESS}"`))
+					Expect(userData).To(ContainSubstring("/etc/eks/kubelet/environment"))
+				}
+			})
+		})
+		Context("Image Filesystem Volume", func() {
+			BeforeEach(func() {
+				nodeClass.Spec.BlockDeviceMappings = []*v1.BlockDeviceMapping{
+					{
+						DeviceName: lo.ToPtr("/dev/xvda"),
+						EBS:        &v1.BlockDevice{VolumeSize: resource.NewScaledQuantity(20, resource.Giga)},
+						RootVolume: true,
+					},
+					{
+						DeviceName:    lo.ToPtr("/dev/xvdb"),
+						EBS:           &v1.BlockDevice{VolumeSize: resource.NewScaledQuantity(200, resource.Giga)},
+						ImageFSVolume: true,
+					},
+				}
+			})
+			It("should mount the image filesystem volume before bootstrapping on AL2", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining(
+					"IMAGE_FS_DEVICE='/dev/xvdb'",
+					`mkfs.xfs "${IMAGE_FS_DEVICE}"`,
+					"mount /var/lib/containerd",
+				)
+				for _, userData := range ExpectUserDataExistsFromCreatedLaunchTemplates() {
+					Expect(strings.Index(userData, "mount /var/lib/containerd")).To(BeNumerically("<", strings.Index(userData, "/etc/eks/bootstrap.sh")))
+				}
+			})
+			It("should mount the image filesystem volume in a shell script on AL2023", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2023@latest"}}
+				awsEnv.LaunchTemplateProvider.CABundle = lo.ToPtr("Y2EtYnVuZGxlCg==")
+				awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(lo.ToPtr("10.100.0.0/16"))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				for _, userData := range ExpectUserDataExistsFromCreatedLaunchTemplates() {
+					Expect(ExpectUserDataCreatedWithNodeConfigs(userData)).To(HaveLen(1))
+					Expect(userData).To(ContainSubstring("IMAGE_FS_DEVICE='/dev/xvdb'"))
+					Expect(userData).To(ContainSubstring("mount /var/lib/containerd"))
+				}
+			})
+			It("should not mount an image filesystem volume on Bottlerocket", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "bottlerocket@latest"}}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("IMAGE_FS_DEVICE")
+			})
+			It("should not count the image filesystem volume towards ephemeral-storage", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod(coretest.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
+					Requests: map[corev1.ResourceName]resource.Quantity{
+						corev1.ResourceEphemeralStorage: resource.MustParse("100G"),
+					}}})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectNotScheduled(ctx, env.Client, pod)
+			})
+		})
+		Context("Container Registries", func() {
+			BeforeEach(func() {
+				awsEnv.SecretsManagerAPI.Secrets["registry"] = `{"username":"karpenter","password":"hunter2"}`
+				nodeClass.Spec.ContainerRegistries = []v1.ContainerRegistry{
+					{
+						Host:    "docker.io",
+						Mirrors: []string{"https://111122223333.dkr.ecr.us-west-2.amazonaws.com/v2/docker-hub", "https://mirror.internal"},
+					},
+					{
+						Host: "mirror.internal",
+						Credentials: &v1.ContainerRegistryCredentials{
+							Username: "{{resolve:secretsmanager:registry:SecretString:username}}",
+							Password: "{{resolve:secretsmanager:registry:SecretString:password}}",
+						},
+					},
+				}
+			})
+			It("should write the hosts of the registries for containerd on AL2", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				authorization := "Basic " + base64.StdEncoding.EncodeToString([]byte("karpenter:hunter2"))
+				ExpectLaunchTemplatesCreatedWithUserDataContaining(
+					"cat > '/etc/containerd/certs.d/docker.io/hosts.toml' <<'EOF'",
+					`server = "https://registry-1.docker.io"`,
+					`[host."https://111122223333.dkr.ecr.us-west-2.amazonaws.com/v2/docker-hub"]`,
+					"override_path = true",
+					fmt.Sprintf("[host.%q.header]\n    Authorization = [%q]", "https://mirror.internal", authorization),
+					"cat > '/etc/containerd/certs.d/mirror.internal/hosts.toml' <<'EOF'",
+					fmt.Sprintf("[header]\n  Authorization = [%q]", authorization),
+				)
+			})
+			It("should write the hosts of the registries for containerd on AL2023", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2023@latest"}}
+				awsEnv.LaunchTemplateProvider.CABundle = lo.ToPtr("Y2EtYnVuZGxlCg==")
+				awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(lo.ToPtr("10.100.0.0/16"))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				for _, userData := range ExpectUserDataExistsFromCreatedLaunchTemplates() {
+					Expect(ExpectUserDataCreatedWithNodeConfigs(userData)).To(HaveLen(1))
+					Expect(userData).To(ContainSubstring("/etc/containerd/certs.d/docker.io/hosts.toml"))
+					Expect(userData).To(ContainSubstring("/etc/containerd/certs.d/mirror.internal/hosts.toml"))
+				}
+			})
+			It("should configure the container registry settings on Bottlerocket", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "bottlerocket@latest"}}
+				nodeClass.Spec.UserData = aws.String(`
+[[settings.container-registry.mirrors]]
+registry = "docker.io"
+endpoint = ["https://other.internal"]
+[[settings.container-registry.mirrors]]
+registry = "quay.io"
+endpoint = ["https://quay.internal"]
+`)
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+					userData, err := base64.StdEncoding.DecodeString(*ltInput.LaunchTemplateData.UserData)
+					Expect(err).To(BeNil())
+					config := &bootstrap.BottlerocketConfig{}
+					Expect(config.UnmarshalTOML(userData)).To(Succeed())
+					Expect(config.Settings.ContainerRegistry.Mirrors).To(ConsistOf(
+						bootstrap.BottlerocketRegistryMirror{Registry: lo.ToPtr("quay.io"), Endpoint: []string{"https://quay.internal"}},
+						bootstrap.BottlerocketRegistryMirror{Registry: lo.ToPtr("docker.io"), Endpoint: nodeClass.Spec.ContainerRegistries[0].Mirrors},
+					))
+					Expect(config.Settings.ContainerRegistry.Credentials).To(ConsistOf(
+						bootstrap.BottlerocketRegistryCredential{Registry: lo.ToPtr("mirror.internal"), Username: lo.ToPtr("karpenter"), Password: lo.ToPtr("hunter2")},
+					))
+				})
+			})
+			It("should resolve references to SSM parameters in the credentials", func() {
+				awsEnv.SSMAPI.Parameters = map[string]string{"/registry/username": "karpenter", "/registry/password": "hunter3"}
+				nodeClass.Spec.ContainerRegistries[1].Credentials = &v1.ContainerRegistryCredentials{
+					Username: "{{resolve:ssm:/registry/username}}",
+					Password: "{{resolve:ssm-secure:/registry/password}}",
+				}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining(base64.StdEncoding.EncodeToString([]byte("karpenter:hunter3")))
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("{{resolve:")
+			})
+		})
+		Context("Container Snapshotter", func() {
+			BeforeEach(func() {
+				nodeClass.Spec.ContainerSnapshotter = lo.ToPtr(v1.ContainerSnapshotterSOCI)
+			})
+			It("should configure containerd with the SOCI snapshotter on AL2023", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2023@latest"}}
+				awsEnv.LaunchTemplateProvider.CABundle = lo.ToPtr("Y2EtYnVuZGxlCg==")
+				awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(lo.ToPtr("10.100.0.0/16"))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				for _, userData := range ExpectUserDataExistsFromCreatedLaunchTemplates() {
+					configs := ExpectUserDataCreatedWithNodeConfigs(userData)
+					Expect(configs).To(HaveLen(1))
+					Expect(configs[0].Spec.Containerd.Config).To(ContainSubstring(`snapshotter = "soci"`))
+					Expect(userData).To(ContainSubstring("systemctl enable --now soci-snapshotter.service"))
+				}
+			})
+			It("should not configure containerd when the snapshotter is OverlayFS on AL2023", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2023@latest"}}
+				nodeClass.Spec.ContainerSnapshotter = lo.ToPtr(v1.ContainerSnapshotterOverlayFS)
+				awsEnv.LaunchTemplateProvider.CABundle = lo.ToPtr("Y2EtYnVuZGxlCg==")
+				awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(lo.ToPtr("10.100.0.0/16"))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				for _, userData := range ExpectUserDataExistsFromCreatedLaunchTemplates() {
+					configs := ExpectUserDataCreatedWithNodeConfigs(userData)
+					Expect(configs).To(HaveLen(1))
+					Expect(configs[0].Spec.Containerd.Config).To(BeEmpty())
+					Expect(userData).ToNot(ContainSubstring("soci-snapshotter"))
+				}
+			})
+			It("should set the snapshotter of the container runtime on Bottlerocket", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "bottlerocket@latest"}}
+				nodeClass.Spec.UserData = aws.String(`
+[settings.container-runtime]
+max-concurrent-downloads = 5
+`)
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+					userData, err := base64.StdEncoding.DecodeString(*ltInput.LaunchTemplateData.UserData)
+					Expect(err).To(BeNil())
+					config := &bootstrap.BottlerocketConfig{}
+					Expect(config.UnmarshalTOML(userData)).To(Succeed())
+					Expect(lo.FromPtr(config.Settings.ContainerRuntime.Snapshotter)).To(Equal("soci"))
+					Expect(lo.FromPtr(config.Settings.ContainerRuntime.MaxConcurrentDownloads)).To(Equal(5))
+				})
+			})
+			It("should not configure the snapshotter on AL2", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("soci")
+			})
+		})
+		Context("Swap", func() {
+			BeforeEach(func() {
+				nodeClass.Spec.Swap = &v1.Swap{Size: lo.ToPtr(resource.MustParse("4Gi")), Swappiness: lo.ToPtr[int32](10)}
+			})
+			It("should provision a swap file and allow kubelet to use it on AL2", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining(
+					"fallocate -l 4294967296 /swapfile",
+					"swapon /swapfile",
+					"vm.swappiness = 10",
+					`.failSwapOn = false | .memorySwap.swapBehavior = "LimitedSwap"`,
+				)
+			})
+			It("should provision a swap file and set the kubelet swap configuration on AL2023", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2023@latest"}}
+				nodeClass.Spec.Swap.SwapBehavior = lo.ToPtr("NoSwap")
+				awsEnv.LaunchTemplateProvider.CABundle = lo.ToPtr("Y2EtYnVuZGxlCg==")
+				awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(lo.ToPtr("10.100.0.0/16"))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				for _, userData := range ExpectUserDataExistsFromCreatedLaunchTemplates() {
+					configs := ExpectUserDataCreatedWithNodeConfigs(userData)
+					Expect(configs).To(HaveLen(1))
+					Expect(string(configs[0].Spec.Kubelet.Config["failSwapOn"].Raw)).To(Equal("false"))
+					Expect(string(configs[0].Spec.Kubelet.Config["memorySwap"].Raw)).To(Equal(`{"swapBehavior":"NoSwap"}`))
+					Expect(userData).To(ContainSubstring("swapon /swapfile"))
+				}
+			})
+			It("should provision swap on the instance store disks", func() {
+				nodeClass.Spec.Swap = &v1.Swap{Location: lo.ToPtr(v1.SwapLocationInstanceStore)}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("/dev/disk/by-id/nvme-Amazon_EC2_NVMe_Instance_Storage_*", `swapon "${SWAP_DEVICE}"`)
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("/swapfile")
+			})
+			It("should not provision swap on Bottlerocket", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "bottlerocket@latest"}}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("swap")
+			})
+		})
+		Context("Sysctls and Kernel Modules", func() {
+			BeforeEach(func() {
+				nodeClass.Spec.Sysctls = map[string]string{
+					"net.netfilter.nf_conntrack_max": "1048576",
+					"net.ipv4.conf.all.rp_filter":    "2",
+				}
+				nodeClass.Spec.KernelModules = []v1.KernelModule{"nf_conntrack", "br_netfilter"}
+			})
+			It("should load the kernel modules before setting the sysctls on AL2", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				for _, userData := range ExpectUserDataExistsFromCreatedLaunchTemplates() {
+					Expect(userData).To(ContainSubstring("modprobe -a nf_conntrack br_netfilter"))
+					Expect(userData).To(ContainSubstring("net.ipv4.conf.all.rp_filter = 2\nnet.netfilter.nf_conntrack_max = 1048576\n"))
+					Expect(strings.Index(userData, "modprobe")).To(BeNumerically("<", strings.Index(userData, "sysctl -p /etc/sysctl.d/99-karpenter.conf")))
+					Expect(strings.Index(userData, "sysctl -p /etc/sysctl.d/99-karpenter.conf")).To(BeNumerically("<", strings.Index(userData, "/etc/eks/bootstrap.sh")))
+				}
+			})
+			It("should set the sysctls before nodeadm runs on AL2023", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2023@latest"}}
+				awsEnv.LaunchTemplateProvider.CABundle = lo.ToPtr("Y2EtYnVuZGxlCg==")
+				awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(lo.ToPtr("10.100.0.0/16"))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("modprobe -a nf_conntrack br_netfilter", "net.netfilter.nf_conntrack_max = 1048576")
+			})
+			It("should merge the sysctls and kernel modules into the kernel settings on Bottlerocket", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "bottlerocket@latest"}}
+				nodeClass.Spec.UserData = aws.String(`
+[settings.kernel]
+lockdown = "integrity"
+[settings.kernel.sysctl]
+"net.ipv4.conf.all.rp_filter" = "1"
+"user.max_user_namespaces" = "16384"
+`)
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+					userData, err := base64.StdEncoding.DecodeString(*ltInput.LaunchTemplateData.UserData)
+					Expect(err).To(BeNil())
+					config := &bootstrap.BottlerocketConfig{}
+					Expect(config.UnmarshalTOML(userData)).To(Succeed())
+					Expect(lo.FromPtr(config.Settings.Kernel.Lockdown)).To(Equal("integrity"))
+					Expect(config.Settings.Kernel.Sysctl).To(Equal(map[string]string{
+						"net.netfilter.nf_conntrack_max": "1048576",
+						"net.ipv4.conf.all.rp_filter":    "2",
+						"user.max_user_namespaces":       "16384",
+					}))
+					Expect(config.Settings.Kernel.Modules).To(HaveLen(2))
+					Expect(lo.FromPtr(config.Settings.Kernel.Modules["br_netfilter"].Autoload)).To(BeTrue())
+				})
+			})
+			It("should not set the sysctls on Windows", func() {
+				nodePool.Spec.Template.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelOSStable, Operator: corev1.NodeSelectorOpIn, Values: []string{string(corev1.Windows)}}}}
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "windows2022@latest"}}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod(coretest.PodOptions{
+					NodeSelector: map[string]string{
+						corev1.LabelOSStable:     string(corev1.Windows),
+						corev1.LabelWindowsBuild: "10.0.20348",
+					},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("nf_conntrack")
+			})
+		})
+		Context("PTP Hardware Clock", func() {
+			BeforeEach(func() {
+				nodeClass.Spec.PTPHardwareClock = lo.ToPtr(true)
+			})
+			It("should configure chrony to prefer the PTP hardware clock on AL2", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("refclock PHC /dev/ptp_ena poll 0 delay 0.000010 prefer", "systemctl restart chronyd")
+			})
+			It("should configure chrony to prefer the PTP hardware clock on AL2023", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2023@latest"}}
+				awsEnv.LaunchTemplateProvider.CABundle = lo.ToPtr("Y2EtYnVuZGxlCg==")
+				awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(lo.ToPtr("10.100.0.0/16"))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("refclock PHC /dev/ptp_ena")
+			})
+			It("should not configure chrony when the PTP hardware clock is disabled", func() {
+				nodeClass.Spec.PTPHardwareClock = lo.ToPtr(false)
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("ptp_ena")
+			})
+		})
+		Context("Hugepages and Kernel Parameters", func() {
+			BeforeEach(func() {
+				nodeClass.Spec.Hugepages = []v1.Hugepages{{Size: "2Mi", Count: 512}, {Size: "1Gi", Count: 2}}
+				nodeClass.Spec.KernelParameters = &v1.KernelParameters{NUMABalancing: lo.ToPtr(false), IsolatedCPUs: lo.ToPtr("2-3")}
+			})
+			It("should allocate hugepages and set the NUMA balancing on AL2", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining(
+					"echo 512 > /sys/kernel/mm/hugepages/hugepages-2048kB/nr_hugepages",
+					"echo 2 > /sys/kernel/mm/hugepages/hugepages-1048576kB/nr_hugepages",
+					"kernel.numa_balancing = 0",
+				)
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("isolcpus")
+			})
+			It("should allocate hugepages before nodeadm runs on AL2023", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2023@latest"}}
+				awsEnv.LaunchTemplateProvider.CABundle = lo.ToPtr("Y2EtYnVuZGxlCg==")
+				awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(lo.ToPtr("10.100.0.0/16"))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining(
+					"echo 512 > /sys/kernel/mm/hugepages/hugepages-2048kB/nr_hugepages",
+					"kernel.numa_balancing = 0",
+				)
+			})
+			It("should set sysctls and the kernel command line on Bottlerocket", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "bottlerocket@latest"}}
+				nodeClass.Spec.UserData = aws.String(`
+[settings.kernel.sysctl]
+"user.max_user_namespaces" = "16384"
+`)
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+					userData, err := base64.StdEncoding.DecodeString(*ltInput.LaunchTemplateData.UserData)
+					Expect(err).To(BeNil())
+					config := &bootstrap.BottlerocketConfig{}
+					Expect(config.UnmarshalTOML(userData)).To(Succeed())
+					Expect(config.Settings.Kernel.Sysctl).To(Equal(map[string]string{
+						"user.max_user_namespaces": "16384",
+						"kernel.numa_balancing":    "0",
+					}))
+					Expect(config.Settings.Boot.KernelParameters).To(Equal(map[string][]string{
+						"default_hugepagesz": {"1G"},
+						"hugepages":          {"2"},
+						"isolcpus":           {"2-3"},
+						"nohz_full":          {"2-3"},
+						"rcu_nocbs":          {"2-3"},
+					}))
+					Expect(lo.FromPtr(config.Settings.Boot.RebootToReconcile)).To(BeTrue())
+				})
+			})
+			It("should allocate 2Mi hugepages through sysctl on Bottlerocket", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "bottlerocket@latest"}}
+				nodeClass.Spec.Hugepages = []v1.Hugepages{{Size: "2Mi", Count: 512}}
+				nodeClass.Spec.KernelParameters = nil
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+					userData, err := base64.StdEncoding.DecodeString(*ltInput.LaunchTemplateData.UserData)
+					Expect(err).To(BeNil())
+					config := &bootstrap.BottlerocketConfig{}
+					Expect(config.UnmarshalTOML(userData)).To(Succeed())
+					Expect(config.Settings.Kernel.Sysctl).To(Equal(map[string]string{"vm.nr_hugepages": "512"}))
+					Expect(config.Settings.Boot).To(BeNil())
+				})
+			})
+		})
+		Context("DCGM Exporter", func() {
+			var gpuPod *corev1.Pod
+			BeforeEach(func() {
+				nodeClass.Spec.DCGMExporter = &v1.DCGMExporter{Enabled: lo.ToPtr(true)}
+				gpuPod = coretest.UnschedulablePod(coretest.PodOptions{
+					ResourceRequirements: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{v1.ResourceNVIDIAGPU: resource.MustParse("1")},
+						Limits:   corev1.ResourceList{v1.ResourceNVIDIAGPU: resource.MustParse("1")},
+					},
+				})
+			})
+			It("should run the DCGM exporter on AL2 nodes with NVIDIA GPUs", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, gpuPod)
+				ExpectScheduled(ctx, env.Client, gpuPod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining(
+					"cat > /etc/systemd/system/dcgm-exporter.service",
+					"nvcr.io/nvidia/k8s/dcgm-exporter:",
+					"systemctl enable --now --no-block dcgm-exporter.service",
+					fmt.Sprintf("%s=true", v1.LabelDCGMExporter),
+				)
+			})
+			It("should run the DCGM exporter with a custom image on AL2023 nodes with NVIDIA GPUs", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2023@latest"}}
+				nodeClass.Spec.DCGMExporter.Image = lo.ToPtr("public.ecr.aws/test/dcgm-exporter:latest")
+				awsEnv.LaunchTemplateProvider.CABundle = lo.ToPtr("Y2EtYnVuZGxlCg==")
+				awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(lo.ToPtr("10.100.0.0/16"))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, gpuPod)
+				ExpectScheduled(ctx, env.Client, gpuPod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("ExecStartPre=/usr/bin/ctr --namespace dcgm images pull public.ecr.aws/test/dcgm-exporter:latest")
+			})
+			It("should run the DCGM exporter as a host container on Bottlerocket nodes with NVIDIA GPUs", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "bottlerocket@latest"}}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, gpuPod)
+				ExpectScheduled(ctx, env.Client, gpuPod)
+				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+					userData, err := base64.StdEncoding.DecodeString(*ltInput.LaunchTemplateData.UserData)
+					Expect(err).To(BeNil())
+					config := &bootstrap.BottlerocketConfig{}
+					Expect(config.UnmarshalTOML(userData)).To(Succeed())
+					Expect(config.Settings.HostContainers).To(HaveKey("dcgm-exporter"))
+					Expect(config.Settings.HostContainers["dcgm-exporter"].Source).To(HaveValue(HavePrefix("nvcr.io/nvidia/k8s/dcgm-exporter:")))
+					Expect(lo.FromPtr(config.Settings.HostContainers["dcgm-exporter"].Superpowered)).To(BeTrue())
+					Expect(config.Settings.Kubernetes.NodeLabels).To(HaveKeyWithValue(v1.LabelDCGMExporter, "true"))
+				})
+			})
+			It("should not run the DCGM exporter on nodes without NVIDIA GPUs", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{corev1.LabelInstanceTypeStable: "m5.large"}})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("dcgm-exporter")
+			})
+			It("should not run the DCGM exporter when it isn't enabled", func() {
+				nodeClass.Spec.DCGMExporter.Enabled = lo.ToPtr(false)
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, gpuPod)
+				ExpectScheduled(ctx, env.Client, gpuPod)
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("dcgm-exporter")
+			})
+		})
+		Context("Neuron", func() {
+			var neuronPod *corev1.Pod
+			BeforeEach(func() {
+				nodeClass.Spec.Neuron = &v1.NeuronConfiguration{LogicalNeuronCoreConfig: lo.ToPtr[int32](2)}
+				neuronPod = coretest.UnschedulablePod(coretest.PodOptions{
+					ResourceRequirements: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{v1.ResourceAWSNeuron: resource.MustParse("1")},
+						Limits:   corev1.ResourceList{v1.ResourceAWSNeuron: resource.MustParse("1")},
+					},
+				})
+			})
+			It("should configure the logical NeuronCores of AL2 nodes with Neuron devices", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, neuronPod)
+				ExpectScheduled(ctx, env.Client, neuronPod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining(
+					"echo 'NEURON_LOGICAL_NC_CONFIG=2' >> /etc/environment",
+					fmt.Sprintf("%s=2", v1.LabelNeuronLogicalCoreConfig),
+				)
+			})
+			It("should configure the logical NeuronCores of AL2023 nodes with Neuron devices", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2023@latest"}}
+				awsEnv.LaunchTemplateProvider.CABundle = lo.ToPtr("Y2EtYnVuZGxlCg==")
+				awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(lo.ToPtr("10.100.0.0/16"))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, neuronPod)
+				ExpectScheduled(ctx, env.Client, neuronPod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("NEURON_LOGICAL_NC_CONFIG=2")
+			})
+			It("should not configure the Neuron runtime of nodes without Neuron devices", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{corev1.LabelInstanceTypeStable: "m5.large"}})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("NEURON_LOGICAL_NC_CONFIG", v1.LabelNeuronLogicalCoreConfig)
+			})
+		})
+		Context("SSM Agent", func() {
+			var activation *v1.SSMHybridActivation
+			BeforeEach(func() {
+				awsEnv.SSMAPI.Parameters = map[string]string{"/karpenter/activation-code": "o1PsFFt8NRyEMBRKHqaP"}
+				activation = &v1.SSMHybridActivation{ID: "e488f2f6-e686-4afb-8a04-ef6dfe4f2a3b", Code: "{{resolve:ssm-secure:/karpenter/activation-code}}", Region: "us-west-2"}
+			})
+			It("should disable the SSM agent on AL2", func() {
+				nodeClass.Spec.SSMAgent = &v1.SSMAgent{Enabled: lo.ToPtr(false)}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("systemctl disable --now amazon-ssm-agent.service")
+			})
+			It("should register the SSM agent with a hybrid activation on AL2023", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2023@latest"}}
+				nodeClass.Spec.SSMAgent = &v1.SSMAgent{HybridActivation: activation}
+				awsEnv.LaunchTemplateProvider.CABundle = lo.ToPtr("Y2EtYnVuZGxlCg==")
+				awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(lo.ToPtr("10.100.0.0/16"))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining(`"${SSM_AGENT}" -register -id 'e488f2f6-e686-4afb-8a04-ef6dfe4f2a3b' -code 'o1PsFFt8NRyEMBRKHqaP' -region 'us-west-2' -y`)
+			})
+			It("should not launch when the resolved activation code is invalid", func() {
+				awsEnv.SSMAPI.Parameters = map[string]string{"/karpenter/activation-code": "o1PsFFt8NRyEMBRKHqaP'; reboot; '"}
+				nodeClass.Spec.SSMAgent = &v1.SSMAgent{HybridActivation: activation}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectNotScheduled(ctx, env.Client, pod)
+				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeZero())
+			})
+			It("should configure the control container on Bottlerocket", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "bottlerocket@latest"}}
+				nodeClass.Spec.SSMAgent = &v1.SSMAgent{HybridActivation: activation}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+					userData, err := base64.StdEncoding.DecodeString(*ltInput.LaunchTemplateData.UserData)
+					Expect(err).To(BeNil())
+					config := &bootstrap.BottlerocketConfig{}
+					Expect(config.UnmarshalTOML(userData)).To(Succeed())
+					control := config.Settings.HostContainers["control"]
+					Expect(lo.FromPtr(control.Enabled)).To(BeTrue())
+					controlUserData, err := base64.StdEncoding.DecodeString(lo.FromPtr(control.UserData))
+					Expect(err).To(BeNil())
+					Expect(string(controlUserData)).To(MatchJSON(`{"ssm":{"activation-id":"e488f2f6-e686-4afb-8a04-ef6dfe4f2a3b","activation-code":"o1PsFFt8NRyEMBRKHqaP","region":"us-west-2"}}`))
+				})
+			})
+			It("should enable the admin container on Bottlerocket for Session Manager", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "bottlerocket@latest"}}
+				nodeClass.Spec.SSMAgent = &v1.SSMAgent{SessionManager: lo.ToPtr(true)}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+					userData, err := base64.StdEncoding.DecodeString(*ltInput.LaunchTemplateData.UserData)
+					Expect(err).To(BeNil())
+					config := &bootstrap.BottlerocketConfig{}
+					Expect(config.UnmarshalTOML(userData)).To(Succeed())
+					Expect(lo.FromPtr(config.Settings.HostContainers["control"].Enabled)).To(BeTrue())
+					Expect(lo.FromPtr(config.Settings.HostContainers["admin"].Enabled)).To(BeTrue())
+				})
+			})
+			It("should keep the SSM agent of the AMI for Session Manager on AL2023", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2023@latest"}}
+				nodeClass.Spec.SSMAgent = &v1.SSMAgent{SessionManager: lo.ToPtr(true)}
+				awsEnv.LaunchTemplateProvider.CABundle = lo.ToPtr("Y2EtYnVuZGxlCg==")
+				awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(lo.ToPtr("10.100.0.0/16"))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("amazon-ssm-agent")
+			})
+			It("should disable the AmazonSSMAgent service on Windows", func() {
+				nodePool.Spec.Template.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelOSStable, Operator: corev1.NodeSelectorOpIn, Values: []string{string(corev1.Windows)}}}}
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "windows2022@latest"}}
+				nodeClass.Spec.SSMAgent = &v1.SSMAgent{Enabled: lo.ToPtr(false)}
+				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+				pod := coretest.UnschedulablePod(coretest.PodOptions{
+					NodeSelector: map[string]string{
+						corev1.LabelOSStable:     string(corev1.Windows),
+						corev1.LabelWindowsBuild: "10.0.20348",
+					},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("Set-Service -Name 'AmazonSSMAgent' -StartupType Disabled")
+			})
+			It("should keep the SSM agent of the AMI when it's enabled without a hybrid activation", func() {
+				nodeClass.Spec.SSMAgent = &v1.SSMAgent{Enabled: lo.ToPtr(true)}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("amazon-ssm-agent")
+			})
+		})
+		Context("CloudWatch Agent", func() {
+			BeforeEach(func() {
+				nodeClass.Spec.CloudWatchAgent = &v1.CloudWatchAgent{Enabled: lo.ToPtr(true), LogGroupName: lo.ToPtr("/test/nodes")}
+			})
+			It("should install and start the CloudWatch agent on AL2", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining(
+					"yum install -y amazon-cloudwatch-agent",
+					`"log_group_name": "/test/nodes"`,
+					`"log_stream_name": "{instance_id}/messages"`,
+					`"metrics_collected": {`,
+					"amazon-cloudwatch-agent-ctl -a fetch-config -m ec2 -s -c file:/opt/aws/amazon-cloudwatch-agent/etc/amazon-cloudwatch-agent.json",
+				)
+			})
+			It("should only ship logs when metrics are disabled on AL2023", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2023@latest"}}
+				nodeClass.Spec.CloudWatchAgent.Metrics = lo.ToPtr(false)
+				awsEnv.LaunchTemplateProvider.CABundle = lo.ToPtr("Y2EtYnVuZGxlCg==")
+				awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(lo.ToPtr("10.100.0.0/16"))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("dnf install -y amazon-cloudwatch-agent", `"log_group_name": "/test/nodes"`)
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("metrics_collected")
+			})
+			It("should not install the CloudWatch agent on Bottlerocket", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "bottlerocket@latest"}}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("cloudwatch")
+			})
+			It("should not install the CloudWatch agent when it isn't enabled", func() {
+				nodeClass.Spec.CloudWatchAgent.Enabled = lo.ToPtr(false)
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("amazon-cloudwatch-agent")
+			})
+		})
+		Context("Kubelet Args", func() {
+			It("should specify the --dns-cluster-ip flag when clusterDNSIP is set", func() {
+				nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{ClusterDNS: []string{"10.0.10.100"}}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("--dns-cluster-ip '10.0.10.100'")
+			})
+		})
+		Context("Windows Custom UserData", func() {
+			BeforeEach(func() {
+				nodePool.Spec.Template.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelOSStable, Operator: corev1.NodeSelectorOpIn, Values: []string{string(corev1.Windows)}}}}
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "windows2022@latest"}}
+				nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{MaxPods: lo.ToPtr[int32](110)}
+			})
+			It("should merge and bootstrap with custom user data", func() {
+				content, err := os.ReadFile("testdata/windows_userdata_input.golden")
+				Expect(err).To(BeNil())
+				nodeClass.Spec.UserData = aws.String(string(content))
+				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+				Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(nodePool), nodePool)).To(Succeed())
+				pod := coretest.UnschedulablePod(coretest.PodOptions{
+					NodeSelector: map[string]string{
+						corev1.LabelOSStable:     string(corev1.Windows),
+						corev1.LabelWindowsBuild: "10.0.20348",
+					},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				content, err = os.ReadFile("testdata/windows_userdata_merged.golden")
+				Expect(err).To(BeNil())
+				ExpectLaunchTemplatesCreatedWithUserData(fmt.Sprintf(string(content), karpv1.NodePoolLabelKey, nodePool.Name))
+			})
+			It("should bootstrap when custom user data is empty", func() {
+				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+				Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(nodePool), nodePool)).To(Succeed())
+				pod := coretest.UnschedulablePod(coretest.PodOptions{
+					NodeSelector: map[string]string{
+						corev1.LabelOSStable:     string(corev1.Windows),
+						corev1.LabelWindowsBuild: "10.0.20348",
+					},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				content, err := os.ReadFile("testdata/windows_userdata_unmerged.golden")
+				Expect(err).To(BeNil())
+				ExpectLaunchTemplatesCreatedWithUserData(fmt.Sprintf(string(content), karpv1.NodePoolLabelKey, nodePool.Name))
+			})
+		})
+		Context("Windows Components", func() {
+			var pod *corev1.Pod
+			BeforeEach(func() {
+				nodePool.Spec.Template.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelOSStable, Operator: corev1.NodeSelectorOpIn, Values: []string{string(corev1.Windows)}}}}
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "windows2022@latest"}}
+				pod = coretest.UnschedulablePod(coretest.PodOptions{
+					NodeSelector: map[string]string{
+						corev1.LabelOSStable:     string(corev1.Windows),
+						corev1.LabelWindowsBuild: "10.0.20348",
+					},
+				})
+			})
+			It("should register and start csi-proxy before bootstrapping", func() {
+				nodeClass.Spec.Windows = &v1.WindowsConfiguration{CSIProxy: lo.ToPtr(true)}
+				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				for _, userData := range ExpectUserDataExistsFromCreatedLaunchTemplates() {
+					Expect(userData).To(ContainSubstring("New-Service -Name 'csiproxy'"))
+					Expect(strings.Index(userData, "Start-Service -Name 'csiproxy'")).To(BeNumerically("<", strings.Index(userData, "& $EKSBootstrapScriptFile")))
+				}
+			})
+			It("should bootstrap with containerd when HostProcess containers are enabled", func() {
+				nodeClass.Spec.Windows = &v1.WindowsConfiguration{HostProcessContainers: lo.ToPtr(true)}
+				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("-ContainerRuntime 'containerd'")
+			})
+			It("should register the gMSA plugin", func() {
+				nodeClass.Spec.Windows = &v1.WindowsConfiguration{GMSA: lo.ToPtr(true)}
+				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining(`HKLM:\SYSTEM\CurrentControlSet\Control\CCG\COMClasses\{859E1386-BDB4-49E8-85C7-3070B13920E1}`)
+			})
+			It("should not set up any components when they're disabled", func() {
+				nodeClass.Spec.Windows = &v1.WindowsConfiguration{CSIProxy: lo.ToPtr(false), HostProcessContainers: lo.ToPtr(false), GMSA: lo.ToPtr(false)}
+				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("csiproxy", "-ContainerRuntime", "CCG")
+			})
+		})
+	})
+	Context("Detailed Monitoring", func() {
+		It("should default detailed monitoring to off", func() {
+			nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2@latest"}}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(aws.BoolValue(ltInput.LaunchTemplateData.Monitoring.Enabled)).To(BeFalse())
+			})
+		})
+		It("should pass detailed monitoring setting to the launch template at creation", func() {
+			nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2@latest"}}
+			nodeClass.Spec.DetailedMonitoring = aws.Bool(true)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(aws.BoolValue(ltInput.LaunchTemplateData.Monitoring.Enabled)).To(BeTrue())
+			})
+		})
+	})
+	Context("EBS Optimized", func() {
+		It("should not set the EBS optimization by default", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.EbsOptimized).To(BeNil())
+			})
+		})
+		It("should pass the EBS optimization to the launch template at creation", func() {
+			nodeClass.Spec.EBSOptimized = aws.Bool(true)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(aws.BoolValue(ltInput.LaunchTemplateData.EbsOptimized)).To(BeTrue())
+			})
+		})
+	})
+	Context("Credit Specification", func() {
+		It("should not set the credit specification by default", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{corev1.LabelInstanceTypeStable: "t3.large"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.CreditSpecification).To(BeNil())
+			})
+		})
+		It("should set the credit specification of the launch templates of burstable instance types", func() {
+			nodeClass.Spec.CreditSpecification = lo.ToPtr(v1.CreditSpecificationStandard)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{corev1.LabelInstanceTypeStable: "t3.large"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(aws.StringValue(ltInput.LaunchTemplateData.CreditSpecification.CpuCredits)).To(Equal("standard"))
+			})
+		})
+		It("should not set the credit specification of the launch templates of instance types that aren't burstable", func() {
+			nodeClass.Spec.CreditSpecification = lo.ToPtr(v1.CreditSpecificationUnlimited)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{corev1.LabelInstanceTypeStable: "m5.large"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.CreditSpecification).To(BeNil())
+			})
+		})
+	})
+	Context("Key Pair", func() {
+		It("should not set a key pair by default", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.KeyName).To(BeNil())
+			})
+		})
+		It("should pass the key pair to the launch template at creation", func() {
+			nodeClass.Spec.KeyName = aws.String("incident-response")
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(aws.StringValue(ltInput.LaunchTemplateData.KeyName)).To(Equal("incident-response"))
+			})
+		})
+	})
+	Context("Pod Metadata Access", func() {
+		BeforeEach(func() {
+			nodeClass.Spec.MetadataOptions = &v1.MetadataOptions{
+				HTTPEndpoint:            aws.String("enabled"),
+				HTTPPutResponseHopLimit: aws.Int64(1),
+				HTTPTokens:              aws.String("required"),
+			}
+		})
+		It("should raise the hop limit when pods are allowed to reach IMDS", func() {
+			nodeClass.Spec.PodMetadataAccess = lo.ToPtr(v1.PodMetadataAccessAllowed)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(aws.Int64Value(ltInput.LaunchTemplateData.MetadataOptions.HttpPutResponseHopLimit)).To(Equal(int64(2)))
+			})
+			// The metadata options of the EC2NodeClass aren't changed
+			Expect(aws.Int64Value(nodeClass.Spec.MetadataOptions.HTTPPutResponseHopLimit)).To(Equal(int64(1)))
+		})
+		It("should keep hop limits that already let pods reach IMDS", func() {
+			nodeClass.Spec.PodMetadataAccess = lo.ToPtr(v1.PodMetadataAccessAllowed)
+			nodeClass.Spec.MetadataOptions.HTTPPutResponseHopLimit = aws.Int64(3)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(aws.Int64Value(ltInput.LaunchTemplateData.MetadataOptions.HttpPutResponseHopLimit)).To(Equal(int64(3)))
+			})
+		})
+		It("should lower the hop limit when pods are blocked from reaching IMDS", func() {
+			nodeClass.Spec.PodMetadataAccess = lo.ToPtr(v1.PodMetadataAccessBlocked)
+			nodeClass.Spec.MetadataOptions.HTTPPutResponseHopLimit = aws.Int64(2)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(aws.Int64Value(ltInput.LaunchTemplateData.MetadataOptions.HttpPutResponseHopLimit)).To(Equal(int64(1)))
 			})
 		})
 	})
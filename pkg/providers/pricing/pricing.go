@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -28,8 +29,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
@@ -47,8 +50,12 @@ type Provider interface {
 	InstanceTypes() []string
 	OnDemandPrice(string) (float64, bool)
 	SpotPrice(string, string) (float64, bool)
+	LatestSpotPrice(string, string) (float64, bool)
 	UpdateOnDemandPricing(context.Context) error
 	UpdateSpotPricing(context.Context) error
+	UpdateSpotPricingForInstanceTypes(context.Context, []string) error
+	OnDemandLastUpdated() time.Time
+	SpotLastUpdated() time.Time
 }
 
 // DefaultProvider provides actual pricing data to the AWS cloud provider to allow it to make more informed decisions
@@ -63,12 +70,14 @@ type DefaultProvider struct {
 	region  string
 	cm      *pretty.ChangeMonitor
 
-	muOnDemand     sync.RWMutex
-	onDemandPrices map[string]float64
+	muOnDemand      sync.RWMutex
+	onDemandPrices  map[string]float64
+	onDemandUpdated time.Time
 
 	muSpot             sync.RWMutex
 	spotPrices         map[string]zonal
 	spotPricingUpdated bool
+	spotUpdated        time.Time
 }
 
 // zonalPricing is used to capture the per-zone price
@@ -78,11 +87,21 @@ type DefaultProvider struct {
 type zonal struct {
 	defaultPrice float64 // Used until we get the spot pricing data
 	prices       map[string]float64
+	// latest are the latest prices from the spot price history, which prices are adjusted from by their trend
+	latest map[string]float64
+}
+
+// spotPriceRecord is a price of an offering in the spot price history, which is in effect from its timestamp until the
+// timestamp of the next record
+type spotPriceRecord struct {
+	price     float64
+	timestamp time.Time
 }
 
 func newZonalPricing(defaultPrice float64) zonal {
 	z := zonal{
 		prices: map[string]float64{},
+		latest: map[string]float64{},
 	}
 	z.defaultPrice = defaultPrice
 	return z
@@ -101,6 +120,10 @@ func NewAPI(sess *session.Session, region string) pricingiface.PricingAPI {
 		pricingAPIRegion = "cn-northwest-1"
 	} else if strings.HasPrefix(region, "eu-") {
 		pricingAPIRegion = "eu-central-1"
+	} else if strings.HasPrefix(region, "us-iso-") {
+		pricingAPIRegion = "us-iso-east-1"
+	} else if strings.HasPrefix(region, "us-isob-") {
+		pricingAPIRegion = "us-isob-east-1"
 	}
 	return pricing.New(sess, &aws.Config{Region: aws.String(pricingAPIRegion)})
 }
@@ -127,6 +150,22 @@ func (p *DefaultProvider) InstanceTypes() []string {
 	return lo.Union(lo.Keys(p.onDemandPrices), lo.Keys(p.spotPrices))
 }
 
+// OnDemandLastUpdated returns the time of the last successful on-demand pricing update, or the zero time if the
+// static pricing data is still in use
+func (p *DefaultProvider) OnDemandLastUpdated() time.Time {
+	p.muOnDemand.RLock()
+	defer p.muOnDemand.RUnlock()
+	return p.onDemandUpdated
+}
+
+// SpotLastUpdated returns the time of the last successful spot pricing update, or the zero time if the static pricing
+// data is still in use
+func (p *DefaultProvider) SpotLastUpdated() time.Time {
+	p.muSpot.RLock()
+	defer p.muSpot.RUnlock()
+	return p.spotUpdated
+}
+
 // OnDemandPrice returns the last known on-demand price for a given instance type, returning an error if there is no
 // known on-demand pricing for the instance type.
 func (p *DefaultProvider) OnDemandPrice(instanceType string) (float64, bool) {
@@ -156,6 +195,22 @@ func (p *DefaultProvider) SpotPrice(instanceType string, zone string) (float64,
 	return 0.0, false
 }
 
+// LatestSpotPrice returns the latest spot price for a given instance type and zone. Unlike SpotPrice, it isn't adjusted
+// for the trend of the spot price, so it's the price that an instance is charged at.
+func (p *DefaultProvider) LatestSpotPrice(instanceType string, zone string) (float64, bool) {
+	p.muSpot.RLock()
+	defer p.muSpot.RUnlock()
+	if val, ok := p.spotPrices[instanceType]; ok {
+		if !p.spotPricingUpdated {
+			return val.defaultPrice, true
+		}
+		if price, ok := val.latest[zone]; ok {
+			return price, true
+		}
+	}
+	return 0.0, false
+}
+
 func (p *DefaultProvider) UpdateOnDemandPricing(ctx context.Context) error {
 	// standard on-demand instances
 	var wg sync.WaitGroup
@@ -219,6 +274,7 @@ func (p *DefaultProvider) UpdateOnDemandPricing(ctx context.Context) error {
 	}
 
 	p.onDemandPrices = lo.Assign(onDemandPrices, onDemandMetalPrices)
+	p.onDemandUpdated = time.Now()
 	if p.cm.HasChanged("on-demand-prices", p.onDemandPrices) {
 		log.FromContext(ctx).WithValues("instance-type-count", len(p.onDemandPrices)).V(1).Info("updated on-demand pricing")
 	}
@@ -275,7 +331,7 @@ func (p *DefaultProvider) fetchOnDemandPricing(ctx context.Context, additionalFi
 	return prices, nil
 }
 
-func (p *DefaultProvider) spotPage(ctx context.Context, prices map[string]map[string]float64) func(output *ec2.DescribeSpotPriceHistoryOutput, b bool) bool {
+func (p *DefaultProvider) spotPage(ctx context.Context, prices map[string]map[string][]spotPriceRecord) func(output *ec2.DescribeSpotPriceHistoryOutput, b bool) bool {
 	return func(output *ec2.DescribeSpotPriceHistoryOutput, b bool) bool {
 		for _, sph := range output.SpotPriceHistory {
 			spotPriceStr := aws.StringValue(sph.SpotPrice)
@@ -292,9 +348,9 @@ func (p *DefaultProvider) spotPage(ctx context.Context, prices map[string]map[st
 			az := aws.StringValue(sph.AvailabilityZone)
 			_, ok := prices[instanceType]
 			if !ok {
-				prices[instanceType] = map[string]float64{}
+				prices[instanceType] = map[string][]spotPriceRecord{}
 			}
-			prices[instanceType][az] = spotPrice
+			prices[instanceType][az] = append(prices[instanceType][az], spotPriceRecord{price: spotPrice, timestamp: aws.TimeValue(sph.Timestamp)})
 		}
 		return true
 	}
@@ -355,7 +411,9 @@ func (p *DefaultProvider) onDemandPage(ctx context.Context, prices map[string]fl
 
 // nolint: gocyclo
 func (p *DefaultProvider) UpdateSpotPricing(ctx context.Context) error {
-	prices := map[string]map[string]float64{}
+	prices := map[string]map[string][]spotPriceRecord{}
+	end := time.Now()
+	start := end.Add(-options.FromContext(ctx).SpotPriceTrendWindow)
 
 	p.muSpot.Lock()
 	defer p.muSpot.Unlock()
@@ -366,8 +424,8 @@ func (p *DefaultProvider) UpdateSpotPricing(ctx context.Context) error {
 				aws.String("Linux/UNIX"),
 				aws.String("Linux/UNIX (Amazon VPC)"),
 			},
-			// get the latest spot price for each instance type
-			StartTime: aws.Time(time.Now()),
+			// get the latest spot price for each instance type, along with the price history of the trend window
+			StartTime: aws.Time(start),
 		},
 		p.spotPage(ctx, prices),
 	)
@@ -379,24 +437,103 @@ func (p *DefaultProvider) UpdateSpotPricing(ctx context.Context) error {
 		return fmt.Errorf("no spot pricing found")
 	}
 
+	totalOfferings := p.setSpotPrices(prices, start, end)
+	p.spotPricingUpdated = true
+	p.spotUpdated = time.Now()
+	if p.cm.HasChanged("spot-prices", p.spotPrices) {
+		log.FromContext(ctx).WithValues(
+			"instance-type-count", len(p.onDemandPrices),
+			"offering-count", totalOfferings).V(1).Info("updated spot pricing with instance types and offerings")
+	}
+	return nil
+}
+
+// UpdateSpotPricingForInstanceTypes refreshes the spot prices of the instance types in between the updates of all spot
+// prices, since the prices of the pools that are in use lag behind their market price until the next update. The
+// history is only described for the instance types, so it's much cheaper than updating all spot prices.
+func (p *DefaultProvider) UpdateSpotPricingForInstanceTypes(ctx context.Context, instanceTypes []string) error {
+	if len(instanceTypes) == 0 {
+		return nil
+	}
+	prices := map[string]map[string][]spotPriceRecord{}
+	end := time.Now()
+	start := end.Add(-options.FromContext(ctx).SpotPriceTrendWindow)
+
+	p.muSpot.Lock()
+	defer p.muSpot.Unlock()
+	if err := p.ec2.DescribeSpotPriceHistoryPagesWithContext(
+		ctx,
+		&ec2.DescribeSpotPriceHistoryInput{
+			ProductDescriptions: []*string{
+				aws.String("Linux/UNIX"),
+				aws.String("Linux/UNIX (Amazon VPC)"),
+			},
+			InstanceTypes: aws.StringSlice(instanceTypes),
+			StartTime:     aws.Time(start),
+		},
+		p.spotPage(ctx, prices),
+	); err != nil {
+		return fmt.Errorf("retrieving spot pricing data, %w", err)
+	}
+	totalOfferings := p.setSpotPrices(prices, start, end)
+	if p.cm.HasChanged("spot-prices", p.spotPrices) {
+		log.FromContext(ctx).WithValues(
+			"instance-type-count", len(prices),
+			"offering-count", totalOfferings).V(1).Info("refreshed spot pricing of instance types in use")
+	}
+	return nil
+}
+
+// setSpotPrices sets the spot prices of the offerings from their price history, returning the number of offerings
+func (p *DefaultProvider) setSpotPrices(prices map[string]map[string][]spotPriceRecord, start, end time.Time) int {
 	totalOfferings := 0
 	for it, zoneData := range prices {
 		if _, ok := p.spotPrices[it]; !ok {
 			p.spotPrices[it] = newZonalPricing(0)
 		}
-		for zone, price := range zoneData {
-			p.spotPrices[it].prices[zone] = price
+		for zone, records := range zoneData {
+			p.spotPrices[it].prices[zone] = trendPrice(records, start, end)
+			p.spotPrices[it].latest[zone] = lo.MaxBy(records, func(a, b spotPriceRecord) bool { return a.timestamp.After(b.timestamp) }).price
 		}
 		totalOfferings += len(zoneData)
 	}
+	return totalOfferings
+}
 
-	p.spotPricingUpdated = true
-	if p.cm.HasChanged("spot-prices", p.spotPrices) {
-		log.FromContext(ctx).WithValues(
-			"instance-type-count", len(p.onDemandPrices),
-			"offering-count", totalOfferings).V(1).Info("updated spot pricing with instance types and offerings")
+// trendPrice returns the price of an offering from its price history between start and end. Without a window, this is
+// its latest price. Otherwise, it's the average price over the window, weighted by how long each price was in effect,
+// so that replacements are compared by their trailing average rather than a momentary dip. If the latest price is above
+// the average, the offering is trending upward and it's priced at its latest price projected by its rise over the
+// average instead, so that consolidation avoids moving onto it.
+func trendPrice(records []spotPriceRecord, start, end time.Time) float64 {
+	sort.Slice(records, func(i, j int) bool { return records[i].timestamp.Before(records[j].timestamp) })
+	latest := records[len(records)-1].price
+	if !end.After(start) {
+		return latest
 	}
-	return nil
+	var total float64
+	var covered time.Duration
+	for i, record := range records {
+		// the history includes the price that was in effect at the start of the window
+		from := lo.Ternary(record.timestamp.Before(start), start, record.timestamp)
+		to := end
+		if i+1 < len(records) {
+			to = records[i+1].timestamp
+		}
+		if !to.After(from) {
+			continue
+		}
+		total += record.price * to.Sub(from).Hours()
+		covered += to.Sub(from)
+	}
+	if covered <= 0 {
+		return latest
+	}
+	average := total / covered.Hours()
+	if latest > average {
+		return latest + (latest - average)
+	}
+	return average
 }
 
 func (p *DefaultProvider) LivenessProbe(_ *http.Request) error {
@@ -421,12 +558,28 @@ func (p *DefaultProvider) Reset() {
 	// see if we've got region specific pricing data
 	staticPricing, ok := initialOnDemandPrices[p.region]
 	if !ok {
-		// and if not, fall back to the always available us-east-1
-		staticPricing = initialOnDemandPrices["us-east-1"]
+		staticPricing = fallbackOnDemandPrices(p.region)
 	}
 
 	p.onDemandPrices = staticPricing
 	// default our spot pricing to the same as the on-demand pricing until a price update
 	p.spotPrices = populateInitialSpotPricing(staticPricing)
 	p.spotPricingUpdated = false
+	p.onDemandUpdated = time.Time{}
+	p.spotUpdated = time.Time{}
+}
+
+// fallbackOnDemandPrices returns the static pricing data of another region in the partition of the region, since the
+// instance types and currencies differ across partitions, and falls back to the always available us-east-1 for
+// partitions without static pricing data, like aws-iso and aws-iso-b
+func fallbackOnDemandPrices(region string) map[string]float64 {
+	partition := utils.Partition(region).ID()
+	if partition != endpoints.AwsPartitionID {
+		regions := lo.Keys(initialOnDemandPrices)
+		sort.Strings(regions)
+		if r, ok := lo.Find(regions, func(r string) bool { return utils.Partition(r).ID() == partition }); ok {
+			return initialOnDemandPrices[r]
+		}
+	}
+	return initialOnDemandPrices["us-east-1"]
 }
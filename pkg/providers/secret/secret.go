@@ -0,0 +1,149 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/patrickmn/go-cache"
+
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
+)
+
+const (
+	sourceSSM            = "ssm"
+	sourceSSMSecure      = "ssm-secure"
+	sourceSecretsManager = "secretsmanager"
+
+	// secretStringKey separates the id of a Secrets Manager secret from the key of a value in its JSON secret string
+	secretStringKey = ":SecretString:"
+)
+
+// referencePattern matches the dynamic references of CloudFormation, e.g. {{resolve:ssm-secure:/path/to/parameter}}
+var referencePattern = regexp.MustCompile(`{{resolve:(ssm|ssm-secure|secretsmanager):([^}]+)}}`)
+
+type Provider interface {
+	Resolve(context.Context, string) (string, error)
+}
+
+type DefaultProvider struct {
+	sync.Mutex
+	cache             *cache.Cache
+	ssmapi            ssmiface.SSMAPI
+	secretsmanagerapi secretsmanageriface.SecretsManagerAPI
+}
+
+func NewDefaultProvider(ssmapi ssmiface.SSMAPI, secretsmanagerapi secretsmanageriface.SecretsManagerAPI, cache *cache.Cache) *DefaultProvider {
+	return &DefaultProvider{
+		ssmapi:            ssmapi,
+		secretsmanagerapi: secretsmanagerapi,
+		cache:             cache,
+	}
+}
+
+// Resolve replaces every reference to an SSM parameter or a Secrets Manager secret in the passed string with the
+// current value of the parameter or secret. Values are cached so that each launch doesn't have to get them again.
+func (p *DefaultProvider) Resolve(ctx context.Context, s string) (string, error) {
+	matches := referencePattern.FindAllStringSubmatch(s, -1)
+	if len(matches) == 0 {
+		return s, nil
+	}
+	p.Lock()
+	defer p.Unlock()
+	var replacements []string
+	for _, match := range matches {
+		value, err := p.get(ctx, match[1], match[2])
+		if err != nil {
+			return "", err
+		}
+		replacements = append(replacements, match[0], value)
+	}
+	return strings.NewReplacer(replacements...).Replace(s), nil
+}
+
+func (p *DefaultProvider) get(ctx context.Context, source, id string) (string, error) {
+	key := assumerole.CacheKey(ctx, fmt.Sprintf("%s:%s", source, id))
+	if value, ok := p.cache.Get(key); ok {
+		return value.(string), nil
+	}
+	var value string
+	var err error
+	switch source {
+	case sourceSSM, sourceSSMSecure:
+		value, err = p.getParameter(ctx, id)
+	case sourceSecretsManager:
+		value, err = p.getSecret(ctx, id)
+	}
+	if err != nil {
+		return "", err
+	}
+	p.cache.SetDefault(key, value)
+	return value, nil
+}
+
+func (p *DefaultProvider) getParameter(ctx context.Context, name string) (string, error) {
+	out, err := p.ssmapi.GetParameterWithContext(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", fmt.Errorf("getting ssm parameter %q, %w", name, err)
+	}
+	return aws.StringValue(out.Parameter.Value), nil
+}
+
+// getSecret gets the secret string of a secret, or a single value of it if the reference has the form
+// <secret-id>:SecretString:<json-key>. The secret id can be an ARN, which is why the reference isn't split on colons.
+func (p *DefaultProvider) getSecret(ctx context.Context, ref string) (string, error) {
+	id, jsonKey, hasJSONKey := strings.Cut(ref, secretStringKey)
+	out, err := p.secretsmanagerapi.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(id),
+	})
+	if err != nil {
+		return "", fmt.Errorf("getting secret %q, %w", id, err)
+	}
+	if out.SecretString == nil {
+		return "", fmt.Errorf("secret %q has no secret string", id)
+	}
+	if !hasJSONKey {
+		return aws.StringValue(out.SecretString), nil
+	}
+	values := map[string]any{}
+	if err = json.Unmarshal([]byte(aws.StringValue(out.SecretString)), &values); err != nil {
+		return "", fmt.Errorf("secret %q is not a JSON object, %w", id, err)
+	}
+	value, ok := values[jsonKey]
+	if !ok {
+		return "", fmt.Errorf("secret %q has no key %q", id, jsonKey)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("marshaling key %q of secret %q, %w", jsonKey, id, err)
+	}
+	return string(b), nil
+}
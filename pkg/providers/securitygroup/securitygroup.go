@@ -30,10 +30,13 @@ import (
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 )
 
 type Provider interface {
 	List(context.Context, *v1.EC2NodeClass) ([]*ec2.SecurityGroup, error)
+	Invalidate()
 }
 
 type DefaultProvider struct {
@@ -71,12 +74,17 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass)
 	return securityGroups, nil
 }
 
+// Invalidate drops the discovered security groups so that they're described again the next time they're listed
+func (p *DefaultProvider) Invalidate() {
+	p.cache.Flush()
+}
+
 func (p *DefaultProvider) getSecurityGroups(ctx context.Context, filterSets [][]*ec2.Filter) ([]*ec2.SecurityGroup, error) {
 	hash, err := hashstructure.Hash(filterSets, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
 	if err != nil {
 		return nil, err
 	}
-	if sg, ok := p.cache.Get(fmt.Sprint(hash)); ok {
+	if sg, ok := p.cache.Get(assumerole.CacheKey(ctx, fmt.Sprint(hash))); ok {
 		// Ensure what's returned from this function is a shallow-copy of the slice (not a deep-copy of the data itself)
 		// so that modifications to the ordering of the data don't affect the original
 		return append([]*ec2.SecurityGroup{}, sg.([]*ec2.SecurityGroup)...), nil
@@ -91,7 +99,7 @@ func (p *DefaultProvider) getSecurityGroups(ctx context.Context, filterSets [][]
 			securityGroups[lo.FromPtr(output.SecurityGroups[i].GroupId)] = output.SecurityGroups[i]
 		}
 	}
-	p.cache.SetDefault(fmt.Sprint(hash), lo.Values(securityGroups))
+	p.cache.Set(assumerole.CacheKey(ctx, fmt.Sprint(hash)), lo.Values(securityGroups), options.FromContext(ctx).SecurityGroupCacheTTL)
 	return lo.Values(securityGroups), nil
 }
 
@@ -100,26 +108,18 @@ func getFilterSets(terms []v1.SecurityGroupSelectorTerm) (res [][]*ec2.Filter) {
 	nameFilter := &ec2.Filter{Name: aws.String("group-name")}
 	for _, term := range terms {
 		switch {
+		case term.VPCID != "":
+			// Terms that are scoped to a VPC can't be batched with the ids and names of other terms
+			res = append(res, append(getTermFilters(term), &ec2.Filter{
+				Name:   aws.String("vpc-id"),
+				Values: []*string{aws.String(term.VPCID)},
+			}))
 		case term.ID != "":
 			idFilter.Values = append(idFilter.Values, aws.String(term.ID))
 		case term.Name != "":
 			nameFilter.Values = append(nameFilter.Values, aws.String(term.Name))
 		default:
-			var filters []*ec2.Filter
-			for k, v := range term.Tags {
-				if v == "*" {
-					filters = append(filters, &ec2.Filter{
-						Name:   aws.String("tag-key"),
-						Values: []*string{aws.String(k)},
-					})
-				} else {
-					filters = append(filters, &ec2.Filter{
-						Name:   aws.String(fmt.Sprintf("tag:%s", k)),
-						Values: []*string{aws.String(v)},
-					})
-				}
-			}
-			res = append(res, filters)
+			res = append(res, getTermFilters(term))
 		}
 	}
 	if len(idFilter.Values) > 0 {
@@ -130,3 +130,26 @@ func getFilterSets(terms []v1.SecurityGroupSelectorTerm) (res [][]*ec2.Filter) {
 	}
 	return res
 }
+
+func getTermFilters(term v1.SecurityGroupSelectorTerm) (filters []*ec2.Filter) {
+	switch {
+	case term.ID != "":
+		return []*ec2.Filter{{Name: aws.String("group-id"), Values: []*string{aws.String(term.ID)}}}
+	case term.Name != "":
+		return []*ec2.Filter{{Name: aws.String("group-name"), Values: []*string{aws.String(term.Name)}}}
+	}
+	for k, v := range term.Tags {
+		if v == "*" {
+			filters = append(filters, &ec2.Filter{
+				Name:   aws.String("tag-key"),
+				Values: []*string{aws.String(k)},
+			})
+		} else {
+			filters = append(filters, &ec2.Filter{
+				Name:   aws.String(fmt.Sprintf("tag:%s", k)),
+				Values: []*string{aws.String(v)},
+			})
+		}
+	}
+	return filters
+}
@@ -254,6 +254,79 @@ var _ = Describe("SecurityGroupProvider", func() {
 			},
 		}, securityGroups)
 	})
+	It("should discover security groups by name wildcards", func() {
+		nodeClass.Spec.SecurityGroupSelectorTerms = []v1.SecurityGroupSelectorTerm{
+			{
+				Name: "*-test1",
+			},
+			{
+				Name: "securityGroup-tes?3",
+			},
+		}
+		securityGroups, err := awsEnv.SecurityGroupProvider.List(ctx, nodeClass)
+		Expect(err).To(BeNil())
+		ExpectConsistsOfSecurityGroups([]*ec2.SecurityGroup{
+			{
+				GroupId:   aws.String("sg-test1"),
+				GroupName: aws.String("securityGroup-test1"),
+			},
+			{
+				GroupId:   aws.String("sg-test3"),
+				GroupName: aws.String("securityGroup-test3"),
+			},
+		}, securityGroups)
+	})
+	It("should discover security groups by tags scoped to a VPC", func() {
+		awsEnv.EC2API.DescribeSecurityGroupsOutput.Set(&ec2.DescribeSecurityGroupsOutput{SecurityGroups: []*ec2.SecurityGroup{
+			{
+				GroupId:   aws.String("sg-test1"),
+				GroupName: aws.String("securityGroup-test1"),
+				VpcId:     aws.String("vpc-test1"),
+				Tags:      []*ec2.Tag{{Key: aws.String("foo"), Value: aws.String("bar")}},
+			},
+			{
+				GroupId:   aws.String("sg-test2"),
+				GroupName: aws.String("securityGroup-test2"),
+				VpcId:     aws.String("vpc-test2"),
+				Tags:      []*ec2.Tag{{Key: aws.String("foo"), Value: aws.String("bar")}},
+			},
+		}})
+		nodeClass.Spec.SecurityGroupSelectorTerms = []v1.SecurityGroupSelectorTerm{
+			{
+				Tags:  map[string]string{"foo": "bar"},
+				VPCID: "vpc-test2",
+			},
+		}
+		securityGroups, err := awsEnv.SecurityGroupProvider.List(ctx, nodeClass)
+		Expect(err).To(BeNil())
+		ExpectConsistsOfSecurityGroups([]*ec2.SecurityGroup{
+			{
+				GroupId:   aws.String("sg-test2"),
+				GroupName: aws.String("securityGroup-test2"),
+				VpcId:     aws.String("vpc-test2"),
+				Tags:      []*ec2.Tag{{Key: aws.String("foo"), Value: aws.String("bar")}},
+			},
+		}, securityGroups)
+	})
+	It("should not batch the names of terms that are scoped to a VPC", func() {
+		nodeClass.Spec.SecurityGroupSelectorTerms = []v1.SecurityGroupSelectorTerm{
+			{
+				Name: "securityGroup-test1",
+			},
+			{
+				Name:  "securityGroup-test2",
+				VPCID: "vpc-test1",
+			},
+		}
+		securityGroups, err := awsEnv.SecurityGroupProvider.List(ctx, nodeClass)
+		Expect(err).To(BeNil())
+		ExpectConsistsOfSecurityGroups([]*ec2.SecurityGroup{
+			{
+				GroupId:   aws.String("sg-test1"),
+				GroupName: aws.String("securityGroup-test1"),
+			},
+		}, securityGroups)
+	})
 	It("should discover security groups by names intersected with tags", func() {
 		nodeClass.Spec.SecurityGroupSelectorTerms = []v1.SecurityGroupSelectorTerm{
 			{
@@ -0,0 +1,113 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/mitchellh/hashstructure/v2"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/karpenter/pkg/utils/pretty"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
+)
+
+type Provider interface {
+	Get(context.Context, []v1.SnapshotSelectorTerm) (string, error)
+}
+
+type DefaultProvider struct {
+	sync.Mutex
+	ec2api ec2iface.EC2API
+	cache  *cache.Cache
+	cm     *pretty.ChangeMonitor
+}
+
+func NewDefaultProvider(ec2api ec2iface.EC2API, cache *cache.Cache) *DefaultProvider {
+	return &DefaultProvider{
+		ec2api: ec2api,
+		cache:  cache,
+		cm:     pretty.NewChangeMonitor(),
+	}
+}
+
+// Get returns the ID of the newest completed snapshot that matches any of the selector terms. Snapshots are cached so
+// that launches don't have to describe them again, which also bounds how long a new snapshot takes to be picked up.
+func (p *DefaultProvider) Get(ctx context.Context, terms []v1.SnapshotSelectorTerm) (string, error) {
+	p.Lock()
+	defer p.Unlock()
+	hash, err := hashstructure.Hash(terms, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+	if err != nil {
+		return "", err
+	}
+	key := assumerole.CacheKey(ctx, fmt.Sprint(hash))
+	if id, ok := p.cache.Get(key); ok {
+		return id.(string), nil
+	}
+	var newest *ec2.Snapshot
+	for _, term := range terms {
+		input := &ec2.DescribeSnapshotsInput{
+			Filters: append(tagFilters(term.Tags), &ec2.Filter{
+				Name:   aws.String("status"),
+				Values: aws.StringSlice([]string{ec2.SnapshotStateCompleted}),
+			}),
+		}
+		if term.Owner != "" {
+			input.OwnerIds = aws.StringSlice([]string{term.Owner})
+		}
+		if err := p.ec2api.DescribeSnapshotsPagesWithContext(ctx, input, func(page *ec2.DescribeSnapshotsOutput, _ bool) bool {
+			for _, snapshot := range page.Snapshots {
+				if newest == nil || aws.TimeValue(snapshot.StartTime).After(aws.TimeValue(newest.StartTime)) {
+					newest = snapshot
+				}
+			}
+			return true
+		}); err != nil {
+			return "", fmt.Errorf("describing snapshots %s, %w", pretty.Concise(input.Filters), err)
+		}
+	}
+	if newest == nil {
+		return "", fmt.Errorf("no completed snapshot matches the snapshotSelectorTerms %s", pretty.Concise(terms))
+	}
+	id := aws.StringValue(newest.SnapshotId)
+	p.cache.SetDefault(key, id)
+	if p.cm.HasChanged(fmt.Sprintf("snapshot/%s", key), id) {
+		log.FromContext(ctx).WithValues("snapshot-id", id, "start-time", aws.TimeValue(newest.StartTime)).V(1).Info("discovered snapshot")
+	}
+	return id, nil
+}
+
+func tagFilters(tags map[string]string) []*ec2.Filter {
+	return lo.MapToSlice(tags, func(k, v string) *ec2.Filter {
+		if v == "*" {
+			return &ec2.Filter{
+				Name:   aws.String("tag-key"),
+				Values: []*string{aws.String(k)},
+			}
+		}
+		return &ec2.Filter{
+			Name:   aws.String(fmt.Sprintf("tag:%s", k)),
+			Values: []*string{aws.String(v)},
+		}
+	})
+}
@@ -23,6 +23,8 @@ import (
 	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
 	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
+
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
 )
 
 type Provider interface {
@@ -47,7 +49,7 @@ func NewDefaultProvider(ssmapi ssmiface.SSMAPI, cache *cache.Cache) *DefaultProv
 func (p *DefaultProvider) List(ctx context.Context, path string) (map[string]string, error) {
 	p.Lock()
 	defer p.Unlock()
-	if paths, ok := p.cache.Get(path); ok {
+	if paths, ok := p.cache.Get(assumerole.CacheKey(ctx, path)); ok {
 		return paths.(map[string]string), nil
 	}
 	values := map[string]string{}
@@ -65,6 +67,6 @@ func (p *DefaultProvider) List(ctx context.Context, path string) (map[string]str
 	}); err != nil {
 		return nil, fmt.Errorf("getting ssm parameters for path %q, %w", path, err)
 	}
-	p.cache.SetDefault(path, values)
+	p.cache.SetDefault(assumerole.CacheKey(ctx, path), values)
 	return values, nil
 }
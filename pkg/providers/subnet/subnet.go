@@ -18,6 +18,9 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/netip"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -30,6 +33,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
@@ -42,6 +47,8 @@ type Provider interface {
 	List(context.Context, *v1.EC2NodeClass) ([]*ec2.Subnet, error)
 	ZonalSubnetsForLaunch(context.Context, *v1.EC2NodeClass, []*cloudprovider.InstanceType, string) (map[string]*Subnet, error)
 	UpdateInflightIPs(*ec2.CreateFleetInput, *ec2.CreateFleetOutput, []*cloudprovider.InstanceType, []*Subnet, string)
+	ListPublic(context.Context, []*ec2.Subnet) ([]string, error)
+	Invalidate()
 }
 
 type DefaultProvider struct {
@@ -52,6 +59,7 @@ type DefaultProvider struct {
 	associatePublicIPAddressCache *cache.Cache
 	cm                            *pretty.ChangeMonitor
 	inflightIPs                   map[string]int64
+	roundRobin                    map[string]int
 }
 
 type Subnet struct {
@@ -72,6 +80,8 @@ func NewDefaultProvider(ec2api ec2iface.EC2API, cache *cache.Cache, availableIPA
 		associatePublicIPAddressCache: associatePublicIPAddressCache,
 		// inflightIPs is used to track IPs from known launched instances
 		inflightIPs: map[string]int64{},
+		// roundRobin tracks the next subnet of each EC2NodeClass and zone for the RoundRobin SubnetSelectionPolicy
+		roundRobin: map[string]int{},
 	}
 }
 
@@ -86,7 +96,7 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass)
 	if err != nil {
 		return nil, err
 	}
-	if subnets, ok := p.cache.Get(fmt.Sprint(hash)); ok {
+	if subnets, ok := p.cache.Get(assumerole.CacheKey(ctx, fmt.Sprint(hash))); ok {
 		// Ensure what's returned from this function is a shallow-copy of the slice (not a deep-copy of the data itself)
 		// so that modifications to the ordering of the data don't affect the original
 		return append([]*ec2.Subnet{}, subnets.([]*ec2.Subnet)...), nil
@@ -94,10 +104,14 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass)
 
 	// Ensure that all the subnets that are returned here are unique
 	subnets := map[string]*ec2.Subnet{}
-	for _, filters := range filterSets {
-		output, err := p.ec2api.DescribeSubnetsWithContext(ctx, &ec2.DescribeSubnetsInput{Filters: filters})
+	for _, filterSet := range filterSets {
+		output, err := p.ec2api.DescribeSubnetsWithContext(ctx, &ec2.DescribeSubnetsInput{Filters: filterSet.Filters})
 		if err != nil {
-			return nil, fmt.Errorf("describing subnets %s, %w", pretty.Concise(filters), err)
+			return nil, fmt.Errorf("describing subnets %s, %w", pretty.Concise(filterSet.Filters), err)
+		}
+		output.Subnets, err = filterSet.matchingCIDR(output.Subnets)
+		if err != nil {
+			return nil, err
 		}
 		for i := range output.Subnets {
 			subnets[lo.FromPtr(output.Subnets[i].SubnetId)] = output.Subnets[i]
@@ -108,7 +122,7 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass)
 			delete(p.inflightIPs, lo.FromPtr(output.Subnets[i].SubnetId)) // remove any previously tracked IP addresses since we just refreshed from EC2
 		}
 	}
-	p.cache.SetDefault(fmt.Sprint(hash), lo.Values(subnets))
+	p.cache.Set(assumerole.CacheKey(ctx, fmt.Sprint(hash)), lo.Values(subnets), options.FromContext(ctx).SubnetCacheTTL)
 	if p.cm.HasChanged(fmt.Sprintf("subnets/%s", nodeClass.Name), lo.Keys(subnets)) {
 		log.FromContext(ctx).
 			WithValues("subnets", lo.Map(lo.Values(subnets), func(s *ec2.Subnet, _ int) v1.Subnet {
@@ -116,13 +130,19 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass)
 					ID:     lo.FromPtr(s.SubnetId),
 					Zone:   lo.FromPtr(s.AvailabilityZone),
 					ZoneID: lo.FromPtr(s.AvailabilityZoneId),
+					CIDR:   lo.FromPtr(s.CidrBlock),
 				}
 			})).V(1).Info("discovered subnets")
 	}
 	return lo.Values(subnets), nil
 }
 
-// ZonalSubnetsForLaunch returns a mapping of zone to the subnet with the most available IP addresses and deducts the passed ips from the available count
+// Invalidate drops the discovered subnets so that they're described again the next time they're listed
+func (p *DefaultProvider) Invalidate() {
+	p.cache.Flush()
+}
+
+// ZonalSubnetsForLaunch returns a mapping of zone to the subnet chosen by the SubnetSelectionPolicy of the EC2NodeClass and deducts the passed ips from the available count
 func (p *DefaultProvider) ZonalSubnetsForLaunch(ctx context.Context, nodeClass *v1.EC2NodeClass, instanceTypes []*cloudprovider.InstanceType, capacityType string) (map[string]*Subnet, error) {
 	if len(nodeClass.Status.Subnets) == 0 {
 		return nil, fmt.Errorf("no subnets matched selector %v", nodeClass.Spec.SubnetSelectorTerms)
@@ -139,22 +159,10 @@ func (p *DefaultProvider) ZonalSubnetsForLaunch(ctx context.Context, nodeClass *
 		}
 	}
 
-	for _, subnet := range nodeClass.Status.Subnets {
-		if v, ok := zonalSubnets[subnet.Zone]; ok {
-			currentZonalSubnetIPAddressCount := v.AvailableIPAddressCount
-			newZonalSubnetIPAddressCount := availableIPAddressCount[subnet.ID]
-			if ips, ok := p.inflightIPs[v.ID]; ok {
-				currentZonalSubnetIPAddressCount = ips
-			}
-			if ips, ok := p.inflightIPs[subnet.ID]; ok {
-				newZonalSubnetIPAddressCount = ips
-			}
-
-			if currentZonalSubnetIPAddressCount >= newZonalSubnetIPAddressCount {
-				continue
-			}
-		}
-		zonalSubnets[subnet.Zone] = &Subnet{ID: subnet.ID, Zone: subnet.Zone, ZoneID: subnet.ZoneID, AvailableIPAddressCount: availableIPAddressCount[subnet.ID]}
+	policy := lo.FromPtrOr(nodeClass.Spec.SubnetSelectionPolicy, v1.SubnetSelectionPolicyMostAvailableIPs)
+	for zone, subnets := range lo.GroupBy(nodeClass.Status.Subnets, func(subnet v1.Subnet) string { return subnet.Zone }) {
+		subnet := p.selectSubnet(nodeClass, policy, subnets, availableIPAddressCount)
+		zonalSubnets[zone] = &Subnet{ID: subnet.ID, Zone: subnet.Zone, ZoneID: subnet.ZoneID, AvailableIPAddressCount: availableIPAddressCount[subnet.ID]}
 	}
 
 	for _, subnet := range zonalSubnets {
@@ -171,6 +179,35 @@ func (p *DefaultProvider) ZonalSubnetsForLaunch(ctx context.Context, nodeClass *
 	return zonalSubnets, nil
 }
 
+// selectSubnet chooses one of the subnets of a zone based on the SubnetSelectionPolicy. Subnets are compared by their
+// inflight IP addresses when they're tracked, falling back to the available IP addresses of the subnet.
+func (p *DefaultProvider) selectSubnet(nodeClass *v1.EC2NodeClass, policy v1.SubnetSelectionPolicy, subnets []v1.Subnet, availableIPAddressCount map[string]int64) v1.Subnet {
+	availableIPs := func(subnet v1.Subnet) int64 {
+		if ips, ok := p.inflightIPs[subnet.ID]; ok {
+			return ips
+		}
+		return availableIPAddressCount[subnet.ID]
+	}
+	switch policy {
+	case v1.SubnetSelectionPolicyRoundRobin:
+		sorted := append([]v1.Subnet{}, subnets...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+		key := fmt.Sprintf("%s/%s", nodeClass.Name, sorted[0].Zone)
+		subnet := sorted[p.roundRobin[key]%len(sorted)]
+		p.roundRobin[key]++
+		return subnet
+	case v1.SubnetSelectionPolicyPriority:
+		return lo.MaxBy(subnets, func(a, b v1.Subnet) bool {
+			if a.Priority != b.Priority {
+				return a.Priority > b.Priority
+			}
+			return availableIPs(a) > availableIPs(b)
+		})
+	default:
+		return lo.MaxBy(subnets, func(a, b v1.Subnet) bool { return availableIPs(a) > availableIPs(b) })
+	}
+}
+
 // UpdateInflightIPs is used to refresh the in-memory IP usage by adding back unused IPs after a CreateFleet response is returned
 func (p *DefaultProvider) UpdateInflightIPs(createFleetInput *ec2.CreateFleetInput, createFleetOutput *ec2.CreateFleetOutput, instanceTypes []*cloudprovider.InstanceType,
 	subnets []*Subnet, capacityType string) {
@@ -233,6 +270,56 @@ func (p *DefaultProvider) UpdateInflightIPs(createFleetInput *ec2.CreateFleetInp
 	}
 }
 
+// ListPublic returns the IDs of the passed subnets whose route table has a route to an internet gateway. Subnets
+// without an explicitly associated route table use the main route table of their VPC.
+func (p *DefaultProvider) ListPublic(ctx context.Context, subnets []*ec2.Subnet) ([]string, error) {
+	var public []string
+	for vpcID, vpcSubnets := range lo.GroupBy(subnets, func(s *ec2.Subnet) string { return lo.FromPtr(s.VpcId) }) {
+		if vpcID == "" {
+			continue
+		}
+		routeTables, err := p.routeTables(ctx, vpcID)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range vpcSubnets {
+			routeTable, ok := lo.Find(routeTables, func(rt *ec2.RouteTable) bool {
+				return lo.ContainsBy(rt.Associations, func(a *ec2.RouteTableAssociation) bool { return lo.FromPtr(a.SubnetId) == lo.FromPtr(s.SubnetId) })
+			})
+			if !ok {
+				routeTable, ok = lo.Find(routeTables, func(rt *ec2.RouteTable) bool {
+					return lo.ContainsBy(rt.Associations, func(a *ec2.RouteTableAssociation) bool { return lo.FromPtr(a.Main) })
+				})
+			}
+			if ok && lo.ContainsBy(routeTable.Routes, func(r *ec2.Route) bool {
+				return strings.HasPrefix(lo.FromPtr(r.GatewayId), "igw-") && lo.FromPtr(r.State) == ec2.RouteStateActive
+			}) {
+				public = append(public, lo.FromPtr(s.SubnetId))
+			}
+		}
+	}
+	sort.Strings(public)
+	return public, nil
+}
+
+func (p *DefaultProvider) routeTables(ctx context.Context, vpcID string) ([]*ec2.RouteTable, error) {
+	key := assumerole.CacheKey(ctx, fmt.Sprintf("routetables/%s", vpcID))
+	if routeTables, ok := p.cache.Get(key); ok {
+		return routeTables.([]*ec2.RouteTable), nil
+	}
+	var routeTables []*ec2.RouteTable
+	if err := p.ec2api.DescribeRouteTablesPagesWithContext(ctx, &ec2.DescribeRouteTablesInput{
+		Filters: []*ec2.Filter{{Name: aws.String("vpc-id"), Values: aws.StringSlice([]string{vpcID})}},
+	}, func(output *ec2.DescribeRouteTablesOutput, _ bool) bool {
+		routeTables = append(routeTables, output.RouteTables...)
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("describing route tables of %s, %w", vpcID, err)
+	}
+	p.cache.SetDefault(key, routeTables)
+	return routeTables, nil
+}
+
 func (p *DefaultProvider) LivenessProbe(_ *http.Request) error {
 	p.Lock()
 	//nolint: staticcheck
@@ -255,7 +342,30 @@ func (p *DefaultProvider) minPods(instanceTypes []*cloudprovider.InstanceType, r
 	return pods
 }
 
-func getFilterSets(terms []v1.SubnetSelectorTerm) (res [][]*ec2.Filter) {
+// filterSet is a set of filters that subnets are described with. EC2 only filters subnets by exact CIDR blocks, so
+// subnets that are selected by a CIDR range are described with the other filters of their term and matched afterward.
+type filterSet struct {
+	Filters []*ec2.Filter
+	CIDR    string
+}
+
+// matchingCIDR returns the subnets with a CIDR block that is contained in the CIDR range of the filter set
+func (f filterSet) matchingCIDR(subnets []*ec2.Subnet) ([]*ec2.Subnet, error) {
+	if f.CIDR == "" {
+		return subnets, nil
+	}
+	cidr, err := netip.ParsePrefix(f.CIDR)
+	if err != nil {
+		return nil, fmt.Errorf("parsing cidr %q, %w", f.CIDR, err)
+	}
+	cidr = cidr.Masked()
+	return lo.Filter(subnets, func(s *ec2.Subnet, _ int) bool {
+		block, err := netip.ParsePrefix(lo.FromPtr(s.CidrBlock))
+		return err == nil && block.Bits() >= cidr.Bits() && cidr.Contains(block.Addr())
+	}), nil
+}
+
+func getFilterSets(terms []v1.SubnetSelectorTerm) (res []filterSet) {
 	idFilter := &ec2.Filter{Name: aws.String("subnet-id")}
 	for _, term := range terms {
 		switch {
@@ -276,11 +386,18 @@ func getFilterSets(terms []v1.SubnetSelectorTerm) (res [][]*ec2.Filter) {
 					})
 				}
 			}
-			res = append(res, filters)
+			// Terms that only select a CIDR range are still scoped to the subnets that can be launched into
+			if len(filters) == 0 {
+				filters = append(filters, &ec2.Filter{
+					Name:   aws.String("state"),
+					Values: []*string{aws.String(ec2.SubnetStateAvailable)},
+				})
+			}
+			res = append(res, filterSet{Filters: filters, CIDR: term.CIDR})
 		}
 	}
 	if len(idFilter.Values) > 0 {
-		res = append(res, []*ec2.Filter{idFilter})
+		res = append(res, filterSet{Filters: []*ec2.Filter{idFilter}})
 	}
 	return res
 }
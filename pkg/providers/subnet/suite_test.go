@@ -16,6 +16,7 @@ package subnet_test
 
 import (
 	"context"
+	"fmt"
 	"sort"
 	"sync"
 	"testing"
@@ -27,9 +28,11 @@ import (
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/assumerole"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/test"
 
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
 	coretest "sigs.k8s.io/karpenter/pkg/test"
 
@@ -229,6 +232,84 @@ var _ = Describe("SubnetProvider", func() {
 				},
 			}, subnets)
 		})
+		Context("CIDR", func() {
+			BeforeEach(func() {
+				awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{
+					{
+						SubnetId:                lo.ToPtr("subnet-primary"),
+						AvailabilityZone:        lo.ToPtr("test-zone-1a"),
+						AvailabilityZoneId:      lo.ToPtr("tstz1-1a"),
+						AvailableIpAddressCount: lo.ToPtr[int64](100),
+						CidrBlock:               lo.ToPtr("10.0.0.0/20"),
+						Tags:                    []*ec2.Tag{{Key: lo.ToPtr("foo"), Value: lo.ToPtr("bar")}},
+					},
+					{
+						SubnetId:                lo.ToPtr("subnet-secondary"),
+						AvailabilityZone:        lo.ToPtr("test-zone-1b"),
+						AvailabilityZoneId:      lo.ToPtr("tstz1-1b"),
+						AvailableIpAddressCount: lo.ToPtr[int64](8000),
+						CidrBlock:               lo.ToPtr("10.0.128.0/19"),
+						Tags:                    []*ec2.Tag{{Key: lo.ToPtr("foo"), Value: lo.ToPtr("bar")}},
+					},
+					{
+						SubnetId:                lo.ToPtr("subnet-secondary-pending"),
+						AvailabilityZone:        lo.ToPtr("test-zone-1c"),
+						AvailabilityZoneId:      lo.ToPtr("tstz1-1c"),
+						AvailableIpAddressCount: lo.ToPtr[int64](8000),
+						CidrBlock:               lo.ToPtr("10.0.160.0/19"),
+						State:                   lo.ToPtr(ec2.SubnetStatePending),
+					},
+					{
+						SubnetId:                lo.ToPtr("subnet-wide"),
+						AvailabilityZone:        lo.ToPtr("test-zone-1c"),
+						AvailabilityZoneId:      lo.ToPtr("tstz1-1c"),
+						AvailableIpAddressCount: lo.ToPtr[int64](100),
+						CidrBlock:               lo.ToPtr("10.0.0.0/16"),
+					},
+				}})
+			})
+			It("should discover available subnets that are contained in a CIDR range", func() {
+				nodeClass.Spec.SubnetSelectorTerms = []v1.SubnetSelectorTerm{
+					{
+						CIDR: "10.0.128.0/17",
+					},
+				}
+				subnets, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
+				Expect(err).To(BeNil())
+				ExpectConsistsOfSubnets([]*ec2.Subnet{
+					{
+						SubnetId:                lo.ToPtr("subnet-secondary"),
+						AvailabilityZone:        lo.ToPtr("test-zone-1b"),
+						AvailabilityZoneId:      lo.ToPtr("tstz1-1b"),
+						AvailableIpAddressCount: lo.ToPtr[int64](8000),
+					},
+				}, subnets)
+			})
+			It("should discover subnets by CIDR range intersected with tags", func() {
+				nodeClass.Spec.SubnetSelectorTerms = []v1.SubnetSelectorTerm{
+					{
+						CIDR: "10.0.0.0/16",
+						Tags: map[string]string{"foo": "bar"},
+					},
+				}
+				subnets, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
+				Expect(err).To(BeNil())
+				ExpectConsistsOfSubnets([]*ec2.Subnet{
+					{
+						SubnetId:                lo.ToPtr("subnet-primary"),
+						AvailabilityZone:        lo.ToPtr("test-zone-1a"),
+						AvailabilityZoneId:      lo.ToPtr("tstz1-1a"),
+						AvailableIpAddressCount: lo.ToPtr[int64](100),
+					},
+					{
+						SubnetId:                lo.ToPtr("subnet-secondary"),
+						AvailabilityZone:        lo.ToPtr("test-zone-1b"),
+						AvailabilityZoneId:      lo.ToPtr("tstz1-1b"),
+						AvailableIpAddressCount: lo.ToPtr[int64](8000),
+					},
+				}, subnets)
+			})
+		})
 	})
 	Context("Provider Cache", func() {
 		It("should resolve subnets from cache that are filtered by id", func() {
@@ -275,6 +356,60 @@ var _ = Describe("SubnetProvider", func() {
 				lo.Contains(expectedSubnets, cachedSubnet[0])
 			}
 		})
+		It("should cache subnets separately for each assumed role", func() {
+			for _, roleARN := range []string{"", "arn:aws:iam::123456789012:role/team-a", "arn:aws:iam::123456789012:role/team-b"} {
+				_, err := awsEnv.SubnetProvider.List(assumerole.WithRole(ctx, roleARN), nodeClass)
+				Expect(err).To(BeNil())
+			}
+			Expect(awsEnv.SubnetCache.Items()).To(HaveLen(3))
+		})
+	})
+	Context("ZonalSubnetsForLaunch", func() {
+		var subnets []v1.Subnet
+		BeforeEach(func() {
+			subnets = []v1.Subnet{
+				{ID: fmt.Sprintf("subnet-%s-a", nodeClass.Name), Zone: "test-zone-1a", ZoneID: "tstz1-1a"},
+				{ID: fmt.Sprintf("subnet-%s-b", nodeClass.Name), Zone: "test-zone-1a", ZoneID: "tstz1-1a", Priority: 10},
+				{ID: fmt.Sprintf("subnet-%s-c", nodeClass.Name), Zone: "test-zone-1a", ZoneID: "tstz1-1a"},
+				{ID: fmt.Sprintf("subnet-%s-d", nodeClass.Name), Zone: "test-zone-1b", ZoneID: "tstz1-1b"},
+			}
+			for i, ips := range []int64{100, 50, 200, 10} {
+				awsEnv.AvailableIPAdressCache.SetDefault(subnets[i].ID, ips)
+			}
+			nodeClass.Status.Subnets = subnets
+		})
+		It("should choose the subnet with the most available IP addresses by default", func() {
+			zonalSubnets, err := awsEnv.SubnetProvider.ZonalSubnetsForLaunch(ctx, nodeClass, nil, karpv1.CapacityTypeOnDemand)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(zonalSubnets).To(HaveLen(2))
+			Expect(zonalSubnets["test-zone-1a"].ID).To(Equal(subnets[2].ID))
+			Expect(zonalSubnets["test-zone-1b"].ID).To(Equal(subnets[3].ID))
+		})
+		It("should choose the subnet with the highest priority", func() {
+			nodeClass.Spec.SubnetSelectionPolicy = lo.ToPtr(v1.SubnetSelectionPolicyPriority)
+			zonalSubnets, err := awsEnv.SubnetProvider.ZonalSubnetsForLaunch(ctx, nodeClass, nil, karpv1.CapacityTypeOnDemand)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(zonalSubnets["test-zone-1a"].ID).To(Equal(subnets[1].ID))
+			Expect(zonalSubnets["test-zone-1b"].ID).To(Equal(subnets[3].ID))
+		})
+		It("should choose the subnet with the most available IP addresses when priorities are equal", func() {
+			nodeClass.Spec.SubnetSelectionPolicy = lo.ToPtr(v1.SubnetSelectionPolicyPriority)
+			nodeClass.Status.Subnets[1].Priority = 0
+			zonalSubnets, err := awsEnv.SubnetProvider.ZonalSubnetsForLaunch(ctx, nodeClass, nil, karpv1.CapacityTypeOnDemand)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(zonalSubnets["test-zone-1a"].ID).To(Equal(subnets[2].ID))
+		})
+		It("should rotate through the subnets of a zone", func() {
+			nodeClass.Spec.SubnetSelectionPolicy = lo.ToPtr(v1.SubnetSelectionPolicyRoundRobin)
+			var chosen []string
+			for i := 0; i < 4; i++ {
+				zonalSubnets, err := awsEnv.SubnetProvider.ZonalSubnetsForLaunch(ctx, nodeClass, nil, karpv1.CapacityTypeOnDemand)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(zonalSubnets["test-zone-1b"].ID).To(Equal(subnets[3].ID))
+				chosen = append(chosen, zonalSubnets["test-zone-1a"].ID)
+			}
+			Expect(chosen).To(Equal([]string{subnets[0].ID, subnets[1].ID, subnets[2].ID, subnets[0].ID}))
+		})
 	})
 	It("should not cause data races when calling List() simultaneously", func() {
 		wg := sync.WaitGroup{}
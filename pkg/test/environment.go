@@ -21,10 +21,12 @@ import (
 	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/clock"
 
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	karpv1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 
+	"github.com/aws/karpenter-provider-aws/pkg/alerting"
 	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	"github.com/aws/karpenter-provider-aws/pkg/fake"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
@@ -33,7 +35,9 @@ import (
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/secret"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/snapshot"
 	ssmp "github.com/aws/karpenter-provider-aws/pkg/providers/ssm"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/version"
@@ -50,17 +54,23 @@ func init() {
 
 type Environment struct {
 	// API
-	EC2API     *fake.EC2API
-	EKSAPI     *fake.EKSAPI
-	SSMAPI     *fake.SSMAPI
-	IAMAPI     *fake.IAMAPI
-	PricingAPI *fake.PricingAPI
+	EC2API            *fake.EC2API
+	EKSAPI            *fake.EKSAPI
+	SSMAPI            *fake.SSMAPI
+	SecretsManagerAPI *fake.SecretsManagerAPI
+	KMSAPI            *fake.KMSAPI
+	IAMAPI            *fake.IAMAPI
+	PricingAPI        *fake.PricingAPI
+	SNSAPI            *fake.SNSAPI
+	ServiceQuotasAPI  *fake.ServiceQuotasAPI
 
 	// Cache
 	EC2Cache                      *cache.Cache
 	KubernetesVersionCache        *cache.Cache
 	InstanceTypeCache             *cache.Cache
 	UnavailableOfferingsCache     *awscache.UnavailableOfferings
+	ImpairedZonesCache            *awscache.ImpairedZones
+	SpotInterruptionHistory       *awscache.SpotInterruptionHistory
 	LaunchTemplateCache           *cache.Cache
 	SubnetCache                   *cache.Cache
 	AvailableIPAdressCache        *cache.Cache
@@ -68,6 +78,11 @@ type Environment struct {
 	SecurityGroupCache            *cache.Cache
 	InstanceProfileCache          *cache.Cache
 	SSMCache                      *cache.Cache
+	SecretCache                   *cache.Cache
+	SnapshotCache                 *cache.Cache
+	EBSEncryptionCache            *cache.Cache
+	QuotaUsageCache               *cache.Cache
+	ClusterCache                  *cache.Cache
 
 	// Providers
 	InstanceTypesProvider   *instancetype.DefaultProvider
@@ -80,6 +95,11 @@ type Environment struct {
 	AMIResolver             *amifamily.Resolver
 	VersionProvider         *version.DefaultProvider
 	LaunchTemplateProvider  *launchtemplate.DefaultProvider
+	SecretProvider          *secret.DefaultProvider
+	SnapshotProvider        *snapshot.DefaultProvider
+
+	LaunchJournal *fake.LaunchJournal
+	AlertTracker  *alerting.Tracker
 }
 
 func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment {
@@ -87,13 +107,19 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 	ec2api := fake.NewEC2API()
 	eksapi := fake.NewEKSAPI()
 	ssmapi := fake.NewSSMAPI()
+	secretsmanagerapi := fake.NewSecretsManagerAPI()
+	kmsapi := fake.NewKMSAPI()
 	iamapi := fake.NewIAMAPI()
+	snsapi := &fake.SNSAPI{}
+	servicequotasapi := fake.NewServiceQuotasAPI()
 
 	// cache
 	ec2Cache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
 	kubernetesVersionCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
 	instanceTypeCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
 	unavailableOfferingsCache := awscache.NewUnavailableOfferings()
+	impairedZonesCache := awscache.NewImpairedZones()
+	spotInterruptionHistory := awscache.NewSpotInterruptionHistory(clock.RealClock{})
 	launchTemplateCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
 	subnetCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
 	availableIPAdressCache := cache.New(awscache.AvailableIPAddressTTL, awscache.DefaultCleanupInterval)
@@ -101,6 +127,11 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 	securityGroupCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
 	instanceProfileCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
 	ssmCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
+	secretCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
+	snapshotCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
+	ebsEncryptionCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
+	quotaUsageCache := cache.New(awscache.QuotaUsageTTL, awscache.DefaultCleanupInterval)
+	clusterCache := cache.New(awscache.ClusterTTL, awscache.DefaultCleanupInterval)
 	fakePricingAPI := &fake.PricingAPI{}
 
 	// Providers
@@ -110,8 +141,10 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 	versionProvider := version.NewDefaultProvider(env.KubernetesInterface, kubernetesVersionCache)
 	instanceProfileProvider := instanceprofile.NewDefaultProvider(fake.DefaultRegion, iamapi, instanceProfileCache)
 	ssmProvider := ssmp.NewDefaultProvider(ssmapi, ssmCache)
-	amiProvider := amifamily.NewDefaultProvider(versionProvider, ssmProvider, ec2api, ec2Cache)
+	amiProvider := amifamily.NewDefaultProvider(versionProvider, ssmProvider, ec2api, kmsapi, ec2Cache)
 	amiResolver := amifamily.NewResolver(amiProvider)
+	secretProvider := secret.NewDefaultProvider(ssmapi, secretsmanagerapi, secretCache)
+	snapshotProvider := snapshot.NewDefaultProvider(ec2api, snapshotCache)
 	instanceTypesProvider := instancetype.NewDefaultProvider(fake.DefaultRegion, instanceTypeCache, ec2api, subnetProvider, unavailableOfferingsCache, pricingProvider)
 	launchTemplateProvider :=
 		launchtemplate.NewDefaultProvider(
@@ -122,30 +155,43 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 			amiResolver,
 			securityGroupProvider,
 			subnetProvider,
+			secretProvider,
+			snapshotProvider,
 			lo.ToPtr("ca-bundle"),
 			make(chan struct{}),
 			net.ParseIP("10.0.100.10"),
 			"https://test-cluster",
+			clusterCache,
 		)
+	launchJournal := &fake.LaunchJournal{}
 	instanceProvider :=
 		instance.NewDefaultProvider(ctx,
 			"",
 			ec2api,
 			unavailableOfferingsCache,
+			impairedZonesCache,
+			spotInterruptionHistory,
 			instanceTypesProvider,
 			subnetProvider,
 			launchTemplateProvider,
+			launchJournal,
 		)
+	alertTracker := alerting.NewTracker(clock.RealClock{}, Options().AlertThreshold, alerting.NewSNSNotifier(snsapi, fake.DefaultAlertTopicARN))
 
 	return &Environment{
-		EC2API:     ec2api,
-		EKSAPI:     eksapi,
-		SSMAPI:     ssmapi,
-		IAMAPI:     iamapi,
-		PricingAPI: fakePricingAPI,
+		EC2API:            ec2api,
+		EKSAPI:            eksapi,
+		SSMAPI:            ssmapi,
+		SecretsManagerAPI: secretsmanagerapi,
+		KMSAPI:            kmsapi,
+		IAMAPI:            iamapi,
+		PricingAPI:        fakePricingAPI,
+		SNSAPI:            snsapi,
+		ServiceQuotasAPI:  servicequotasapi,
 
 		EC2Cache:                      ec2Cache,
 		KubernetesVersionCache:        kubernetesVersionCache,
+		InstanceTypeCache:             instanceTypeCache,
 		LaunchTemplateCache:           launchTemplateCache,
 		SubnetCache:                   subnetCache,
 		AvailableIPAdressCache:        availableIPAdressCache,
@@ -153,18 +199,30 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 		SecurityGroupCache:            securityGroupCache,
 		InstanceProfileCache:          instanceProfileCache,
 		UnavailableOfferingsCache:     unavailableOfferingsCache,
+		ImpairedZonesCache:            impairedZonesCache,
+		SpotInterruptionHistory:       spotInterruptionHistory,
 		SSMCache:                      ssmCache,
+		SecretCache:                   secretCache,
+		SnapshotCache:                 snapshotCache,
+		EBSEncryptionCache:            ebsEncryptionCache,
+		QuotaUsageCache:               quotaUsageCache,
+		ClusterCache:                  clusterCache,
 
 		InstanceTypesProvider:   instanceTypesProvider,
 		InstanceProvider:        instanceProvider,
 		SubnetProvider:          subnetProvider,
 		SecurityGroupProvider:   securityGroupProvider,
 		LaunchTemplateProvider:  launchTemplateProvider,
+		SecretProvider:          secretProvider,
+		SnapshotProvider:        snapshotProvider,
 		InstanceProfileProvider: instanceProfileProvider,
 		PricingProvider:         pricingProvider,
 		AMIProvider:             amiProvider,
 		AMIResolver:             amiResolver,
 		VersionProvider:         versionProvider,
+
+		LaunchJournal: launchJournal,
+		AlertTracker:  alertTracker,
 	}
 }
 
@@ -172,14 +230,22 @@ func (env *Environment) Reset() {
 	env.EC2API.Reset()
 	env.EKSAPI.Reset()
 	env.SSMAPI.Reset()
+	env.SecretsManagerAPI.Reset()
+	env.KMSAPI.Reset()
 	env.IAMAPI.Reset()
 	env.PricingAPI.Reset()
+	env.SNSAPI.Reset()
+	env.ServiceQuotasAPI.Reset()
 	env.PricingProvider.Reset()
 	env.InstanceTypesProvider.Reset()
+	env.LaunchJournal.Reset()
+	env.AlertTracker.Reset()
 
 	env.EC2Cache.Flush()
 	env.KubernetesVersionCache.Flush()
 	env.UnavailableOfferingsCache.Flush()
+	env.ImpairedZonesCache.Flush()
+	env.SpotInterruptionHistory.Flush()
 	env.LaunchTemplateCache.Flush()
 	env.SubnetCache.Flush()
 	env.AssociatePublicIPAddressCache.Flush()
@@ -187,6 +253,11 @@ func (env *Environment) Reset() {
 	env.SecurityGroupCache.Flush()
 	env.InstanceProfileCache.Flush()
 	env.SSMCache.Flush()
+	env.SecretCache.Flush()
+	env.SnapshotCache.Flush()
+	env.EBSEncryptionCache.Flush()
+	env.QuotaUsageCache.Flush()
+	env.ClusterCache.Flush()
 	mfs, err := crmetrics.Registry.Gather()
 	if err != nil {
 		for _, mf := range mfs {
@@ -27,6 +27,8 @@ import (
 type OptionsFields struct {
 	AssumeRoleARN           *string
 	AssumeRoleDuration      *time.Duration
+	AssumeRoleSessionTags   *string
+	AssumeRoleAttribution   *bool
 	ClusterCABundle         *string
 	ClusterName             *string
 	ClusterEndpoint         *string
@@ -34,6 +36,49 @@ type OptionsFields struct {
 	VMMemoryOverheadPercent *float64
 	InterruptionQueue       *string
 	ReservedENIs            *int
+	SecurityGroupsForPods   *bool
+
+	AWSRequestTimeout               *time.Duration
+	AWSMaxRetries                   *int
+	AWSMaxConcurrentRequests        *int
+	AWSUseFIPSEndpoint              *bool
+	AWSUseDualStackEndpoint         *bool
+	AWSEndpointOverrides            *string
+	AWSProxyURL                     *string
+	AWSNoProxy                      *string
+	AWSCABundleFile                 *string
+	VPCEndpointsPreflight           *bool
+	AMICacheTTL                     *time.Duration
+	SubnetCacheTTL                  *time.Duration
+	SecurityGroupCacheTTL           *time.Duration
+	InstanceProfileCacheTTL         *time.Duration
+	InstanceProfilePropagationDelay *time.Duration
+	PricingUpdatePeriod             *time.Duration
+	SpotPriceTrendWindow            *time.Duration
+	SpotPriceRefreshPeriod          *time.Duration
+	SpotDiversificationThreshold    *int
+	TracingEndpoint                 *string
+	DecisionAuditLog                *bool
+	DryRun                          *bool
+	EMFNamespace                    *string
+	EMFExportPeriod                 *time.Duration
+	DebugEndpointsPort              *int
+	DebugEndpointsAddress           *string
+	AlertWebhookURL                 *string
+	AlertSNSTopicARN                *string
+	AlertThreshold                  *int
+	PermissionsCheckPeriod          *time.Duration
+	PublicIPGuardrail               *string
+	IdentityAgentSelector           *string
+	NodeMetadataAnnotations         *string
+
+	NodeRolePermissionsBoundary *string
+	NodeRoleRequiredPolicies    *string
+	EBSEncryptionPolicy         *string
+	QuotaHeadroomPercent        *int
+	ZonalShift                  *string
+	DisabledControllers         *string
+	SettingsConfigMap           *string
 }
 
 func Options(overrides ...OptionsFields) *options.Options {
@@ -46,6 +91,8 @@ func Options(overrides ...OptionsFields) *options.Options {
 	return &options.Options{
 		AssumeRoleARN:           lo.FromPtrOr(opts.AssumeRoleARN, ""),
 		AssumeRoleDuration:      lo.FromPtrOr(opts.AssumeRoleDuration, 15*time.Minute),
+		AssumeRoleSessionTags:   lo.FromPtrOr(opts.AssumeRoleSessionTags, ""),
+		AssumeRoleAttribution:   lo.FromPtrOr(opts.AssumeRoleAttribution, false),
 		ClusterCABundle:         lo.FromPtrOr(opts.ClusterCABundle, ""),
 		ClusterName:             lo.FromPtrOr(opts.ClusterName, "test-cluster"),
 		ClusterEndpoint:         lo.FromPtrOr(opts.ClusterEndpoint, "https://test-cluster"),
@@ -53,5 +100,48 @@ func Options(overrides ...OptionsFields) *options.Options {
 		VMMemoryOverheadPercent: lo.FromPtrOr(opts.VMMemoryOverheadPercent, 0.075),
 		InterruptionQueue:       lo.FromPtrOr(opts.InterruptionQueue, ""),
 		ReservedENIs:            lo.FromPtrOr(opts.ReservedENIs, 0),
+		SecurityGroupsForPods:   lo.FromPtrOr(opts.SecurityGroupsForPods, false),
+
+		AWSRequestTimeout:               lo.FromPtrOr(opts.AWSRequestTimeout, 0),
+		AWSMaxRetries:                   lo.FromPtrOr(opts.AWSMaxRetries, 3),
+		AWSMaxConcurrentRequests:        lo.FromPtrOr(opts.AWSMaxConcurrentRequests, 0),
+		AWSUseFIPSEndpoint:              lo.FromPtrOr(opts.AWSUseFIPSEndpoint, false),
+		AWSUseDualStackEndpoint:         lo.FromPtrOr(opts.AWSUseDualStackEndpoint, false),
+		AWSEndpointOverrides:            lo.FromPtrOr(opts.AWSEndpointOverrides, ""),
+		AWSProxyURL:                     lo.FromPtrOr(opts.AWSProxyURL, ""),
+		AWSNoProxy:                      lo.FromPtrOr(opts.AWSNoProxy, ""),
+		AWSCABundleFile:                 lo.FromPtrOr(opts.AWSCABundleFile, ""),
+		VPCEndpointsPreflight:           lo.FromPtrOr(opts.VPCEndpointsPreflight, false),
+		AMICacheTTL:                     lo.FromPtrOr(opts.AMICacheTTL, time.Minute),
+		SubnetCacheTTL:                  lo.FromPtrOr(opts.SubnetCacheTTL, time.Minute),
+		SecurityGroupCacheTTL:           lo.FromPtrOr(opts.SecurityGroupCacheTTL, time.Minute),
+		InstanceProfileCacheTTL:         lo.FromPtrOr(opts.InstanceProfileCacheTTL, 15*time.Minute),
+		InstanceProfilePropagationDelay: lo.FromPtrOr(opts.InstanceProfilePropagationDelay, 0),
+		PricingUpdatePeriod:             lo.FromPtrOr(opts.PricingUpdatePeriod, 12*time.Hour),
+		SpotPriceTrendWindow:            lo.FromPtrOr(opts.SpotPriceTrendWindow, 0),
+		SpotPriceRefreshPeriod:          lo.FromPtrOr(opts.SpotPriceRefreshPeriod, 0),
+		SpotDiversificationThreshold:    lo.FromPtrOr(opts.SpotDiversificationThreshold, 0),
+		TracingEndpoint:                 lo.FromPtrOr(opts.TracingEndpoint, ""),
+		DecisionAuditLog:                lo.FromPtrOr(opts.DecisionAuditLog, false),
+		DryRun:                          lo.FromPtrOr(opts.DryRun, false),
+		EMFNamespace:                    lo.FromPtrOr(opts.EMFNamespace, ""),
+		EMFExportPeriod:                 lo.FromPtrOr(opts.EMFExportPeriod, time.Minute),
+		DebugEndpointsPort:              lo.FromPtrOr(opts.DebugEndpointsPort, 0),
+		DebugEndpointsAddress:           lo.FromPtrOr(opts.DebugEndpointsAddress, "127.0.0.1"),
+		AlertWebhookURL:                 lo.FromPtrOr(opts.AlertWebhookURL, ""),
+		AlertSNSTopicARN:                lo.FromPtrOr(opts.AlertSNSTopicARN, ""),
+		AlertThreshold:                  lo.FromPtrOr(opts.AlertThreshold, 5),
+		PermissionsCheckPeriod:          lo.FromPtrOr(opts.PermissionsCheckPeriod, time.Hour),
+		PublicIPGuardrail:               lo.FromPtrOr(opts.PublicIPGuardrail, options.PublicIPGuardrailDisabled),
+		IdentityAgentSelector:           lo.FromPtrOr(opts.IdentityAgentSelector, "app.kubernetes.io/name=eks-pod-identity-agent"),
+		NodeMetadataAnnotations:         lo.FromPtrOr(opts.NodeMetadataAnnotations, ""),
+
+		NodeRolePermissionsBoundary: lo.FromPtrOr(opts.NodeRolePermissionsBoundary, ""),
+		NodeRoleRequiredPolicies:    lo.FromPtrOr(opts.NodeRoleRequiredPolicies, ""),
+		EBSEncryptionPolicy:         lo.FromPtrOr(opts.EBSEncryptionPolicy, options.EBSEncryptionPolicyDisabled),
+		QuotaHeadroomPercent:        lo.FromPtrOr(opts.QuotaHeadroomPercent, 0),
+		ZonalShift:                  lo.FromPtrOr(opts.ZonalShift, options.ZonalShiftDisabled),
+		DisabledControllers:         lo.FromPtrOr(opts.DisabledControllers, ""),
+		SettingsConfigMap:           lo.FromPtrOr(opts.SettingsConfigMap, ""),
 	}
 }
@@ -0,0 +1,103 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/samber/lo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/aws/karpenter-provider-aws/pkg/tracing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var ctx context.Context
+var recorder *tracetest.SpanRecorder
+var sess *session.Session
+
+func TestTracing(t *testing.T) {
+	ctx = context.Background()
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Tracing")
+}
+
+var _ = BeforeSuite(func() {
+	sess = session.Must(session.NewSession())
+	sess.Handlers.Clear()
+	sess = tracing.WithTracing(sess)
+})
+
+var _ = BeforeEach(func() {
+	recorder = tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+})
+
+func send(ctx context.Context, operation string, requestID string, err error) {
+	r := request.New(*sess.Config, metadata.ClientInfo{ServiceID: "EC2"}, sess.Handlers.Copy(), nil, &request.Operation{Name: operation}, nil, nil)
+	r.SetContext(ctx)
+	r.Handlers.Validate.Run(r)
+	r.RequestID = requestID
+	r.Error = err
+	r.Handlers.Complete.Run(r)
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	return lo.SliceToMap(span.Attributes(), func(kv attribute.KeyValue) (attribute.Key, attribute.Value) { return kv.Key, kv.Value })
+}
+
+var _ = Describe("Tracing", func() {
+	It("should record a span with the request ID for each AWS request", func() {
+		send(ctx, "CreateFleet", "request-id", nil)
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Name()).To(Equal("EC2.CreateFleet"))
+		Expect(spans[0].Status().Code).To(Equal(codes.Unset))
+		attrs := attributes(spans[0])
+		Expect(attrs[tracing.AttributeAWSService].AsString()).To(Equal("EC2"))
+		Expect(attrs[tracing.AttributeAWSOperation].AsString()).To(Equal("CreateFleet"))
+		Expect(attrs[tracing.AttributeAWSRequestID].AsString()).To(Equal("request-id"))
+	})
+	It("should record the error code of failed AWS requests", func() {
+		send(ctx, "RunInstances", "request-id", awserr.New("UnauthorizedOperation", "", nil))
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Status().Code).To(Equal(codes.Error))
+		Expect(attributes(spans[0])[tracing.AttributeAWSErrorCode].AsString()).To(Equal("UnauthorizedOperation"))
+	})
+	It("should parent AWS request spans to the span in the caller's context", func() {
+		parentCtx, parent := tracing.Start(ctx, "CloudProvider.Create")
+		send(parentCtx, "DescribeLaunchTemplates", "request-id", nil)
+		tracing.End(parent, fmt.Errorf("creating instance"))
+
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(2))
+		Expect(spans[0].Parent().SpanID()).To(Equal(spans[1].SpanContext().SpanID()))
+		Expect(spans[1].Status().Code).To(Equal(codes.Error))
+		Expect(spans[1].Status().Description).To(Equal("creating instance"))
+	})
+})
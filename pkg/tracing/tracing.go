@@ -0,0 +1,114 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"sigs.k8s.io/karpenter/pkg/operator"
+)
+
+const (
+	tracerName = "karpenter.k8s.aws"
+
+	AttributeNodeClaim         = attribute.Key("karpenter.sh.nodeclaim")
+	AttributeNodeClass         = attribute.Key("karpenter.k8s.aws.ec2nodeclass")
+	AttributeInstanceID        = attribute.Key("aws.ec2.instance_id")
+	AttributeCapacityType      = attribute.Key("karpenter.sh.capacity_type")
+	AttributeInstanceTypeCount = attribute.Key("karpenter.instance_type_count")
+	AttributeAWSService        = attribute.Key("aws.service")
+	AttributeAWSOperation      = attribute.Key("aws.operation")
+	AttributeAWSRequestID      = attribute.Key("aws.request_id")
+	AttributeAWSErrorCode      = attribute.Key("aws.error_code")
+	AttributeAWSRetries        = attribute.Key("aws.retries")
+)
+
+// Start starts a span for the passed operation. Until a TracerProvider is registered by NewTracerProvider, the
+// global no-op provider is used and spans are not recorded.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records the error on the span, if any, and ends the span
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// NewTracerProvider registers a global TracerProvider which exports spans over OTLP gRPC to the passed endpoint.
+// Additional exporter configuration (e.g. OTEL_EXPORTER_OTLP_INSECURE) is read from the standard OTEL environment variables.
+func NewTracerProvider(ctx context.Context, endpoint string) (*sdktrace.TracerProvider, error) {
+	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("creating otlp trace exporter, %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName("karpenter"),
+			semconv.ServiceVersion(operator.Version),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	return provider, nil
+}
+
+// WithTracing adds a span around every request made by clients created from the AWS session. Spans include the AWS
+// request ID so that slow calls can be correlated with CloudTrail and AWS support cases.
+func WithTracing(sess *session.Session) *session.Session {
+	sess.Handlers.Validate.PushFrontNamed(startSpanHandler)
+	sess.Handlers.Complete.PushBackNamed(endSpanHandler)
+	return sess
+}
+
+var startSpanHandler = request.NamedHandler{Name: "karpenter.StartSpanHandler", Fn: func(r *request.Request) {
+	operation := ""
+	if r.Operation != nil {
+		operation = r.Operation.Name
+	}
+	ctx, _ := Start(r.Context(), fmt.Sprintf("%s.%s", r.ClientInfo.ServiceID, operation),
+		AttributeAWSService.String(r.ClientInfo.ServiceID),
+		AttributeAWSOperation.String(operation),
+	)
+	r.SetContext(ctx)
+}}
+
+var endSpanHandler = request.NamedHandler{Name: "karpenter.EndSpanHandler", Fn: func(r *request.Request) {
+	span := trace.SpanFromContext(r.Context())
+	span.SetAttributes(
+		AttributeAWSRequestID.String(r.RequestID),
+		AttributeAWSRetries.Int(r.RetryCount),
+	)
+	if awsErr, ok := r.Error.(awserr.Error); ok {
+		span.SetAttributes(AttributeAWSErrorCode.String(awsErr.Code()))
+	}
+	End(span, r.Error)
+}}
@@ -24,6 +24,7 @@ import (
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/mitchellh/hashstructure/v2"
 	"github.com/samber/lo"
@@ -54,6 +55,21 @@ func ParseInstanceID(providerID string) (string, error) {
 	return "", fmt.Errorf("parsing instance id %s", providerID)
 }
 
+// Partition returns the partition that the region belongs to, e.g. aws-iso for us-iso-east-1 or aws-iso-b for
+// us-isob-east-1. Regions that don't match any partition are assumed to be in the aws partition.
+func Partition(region string) endpoints.Partition {
+	if partition, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), region); ok {
+		return partition
+	}
+	return endpoints.AwsPartition()
+}
+
+// ServicePrincipal returns the principal of the AWS service in the partition of the region, e.g. ec2.amazonaws.com.cn
+// in aws-cn or ec2.c2s.ic.gov in aws-iso
+func ServicePrincipal(service, region string) string {
+	return fmt.Sprintf("%s.%s", service, Partition(region).DNSSuffix())
+}
+
 // MergeTags takes a variadic list of maps and merges them together into a list of
 // EC2 tags to be passed into EC2 API calls
 func MergeTags(tags ...map[string]string) []*ec2.Tag {
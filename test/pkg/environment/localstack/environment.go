@@ -0,0 +1,95 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localstack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/pricing/pricingiface"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/samber/lo"
+	"k8s.io/utils/env"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+)
+
+// EndpointEnvVar is the environment variable with the URL of the LocalStack (or moto server) edge endpoint. The suites
+// that use this environment are skipped when it isn't set, so that they don't run without an emulator.
+const EndpointEnvVar = "LOCALSTACK_ENDPOINT"
+
+// Environment is a set of AWS clients that are pointed at LocalStack with the endpoint overrides of Karpenter, so that
+// the providers are tested against the same API calls that they make in a cluster, without AWS credentials
+type Environment struct {
+	Context     context.Context
+	Endpoint    string
+	Region      string
+	ClusterName string
+
+	EC2API     *ec2.EC2
+	SSMAPI     *ssm.SSM
+	SQSAPI     *sqs.SQS
+	PricingAPI pricingiface.PricingAPI
+}
+
+// Enabled returns whether the endpoint of an emulator is configured
+func Enabled() bool {
+	return env.GetString(EndpointEnvVar, "") != ""
+}
+
+func NewEnvironment(t *testing.T) *Environment {
+	endpoint := env.GetString(EndpointEnvVar, "")
+	region := env.GetString("AWS_REGION", "us-west-2")
+	clusterName := env.GetString("CLUSTER_NAME", "localstack")
+
+	ctx := TestContextWithLogger(t)
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+		ClusterName: lo.ToPtr(clusterName),
+		AWSEndpointOverrides: lo.ToPtr(strings.Join(lo.Map(options.AWSServices, func(service string, _ int) string {
+			return fmt.Sprintf("%s=%s", service, endpoint)
+		}), ",")),
+	}))
+	// LocalStack accepts any credentials, so static ones keep the SDK from looking for a profile or instance role
+	sess := session.Must(session.NewSession(operator.WithEndpoints(ctx, &aws.Config{
+		Region:      aws.String(region),
+		Credentials: credentials.NewStaticCredentials("test", "test", ""),
+		MaxRetries:  aws.Int(3),
+	})))
+	return &Environment{
+		Context:     ctx,
+		Endpoint:    endpoint,
+		Region:      region,
+		ClusterName: clusterName,
+
+		EC2API:     ec2.New(sess),
+		SSMAPI:     ssm.New(sess),
+		SQSAPI:     sqs.New(sess),
+		PricingAPI: pricing.NewAPI(sess, region),
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localstack
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+)
+
+const DiscoveryTagKey = "karpenter.sh/discovery"
+
+// Fixtures are the resources that a cluster's nodes are launched into: a VPC with a tagged subnet per availability
+// zone, a tagged security group for the nodes, the EKS optimized AMI parameters and an interruption queue
+type Fixtures struct {
+	VPCID             string
+	SubnetIDs         []string
+	Zones             []string
+	SecurityGroupID   string
+	ImageID           string
+	ImageParameter    string
+	KubernetesVersion string
+	QueueName         string
+	QueueURL          string
+}
+
+func (env *Environment) ExpectFixturesCreated() *Fixtures {
+	f := &Fixtures{
+		KubernetesVersion: "1.30",
+		QueueName:         env.ClusterName,
+	}
+	vpc, err := env.EC2API.CreateVpcWithContext(env.Context, &ec2.CreateVpcInput{
+		CidrBlock:         aws.String("10.0.0.0/16"),
+		TagSpecifications: env.tagSpecifications(ec2.ResourceTypeVpc, env.ClusterName),
+	})
+	Expect(err).ToNot(HaveOccurred())
+	f.VPCID = lo.FromPtr(vpc.Vpc.VpcId)
+
+	zones, err := env.EC2API.DescribeAvailabilityZonesWithContext(env.Context, &ec2.DescribeAvailabilityZonesInput{})
+	Expect(err).ToNot(HaveOccurred())
+	for i, zone := range lo.Slice(zones.AvailabilityZones, 0, 3) {
+		subnet, err := env.EC2API.CreateSubnetWithContext(env.Context, &ec2.CreateSubnetInput{
+			VpcId:             vpc.Vpc.VpcId,
+			AvailabilityZone:  zone.ZoneName,
+			CidrBlock:         aws.String(fmt.Sprintf("10.0.%d.0/20", i*16)),
+			TagSpecifications: env.tagSpecifications(ec2.ResourceTypeSubnet, fmt.Sprintf("%s-private-%s", env.ClusterName, lo.FromPtr(zone.ZoneName))),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		f.SubnetIDs = append(f.SubnetIDs, lo.FromPtr(subnet.Subnet.SubnetId))
+		f.Zones = append(f.Zones, lo.FromPtr(zone.ZoneName))
+	}
+
+	sg, err := env.EC2API.CreateSecurityGroupWithContext(env.Context, &ec2.CreateSecurityGroupInput{
+		GroupName:         aws.String(fmt.Sprintf("%s-node", env.ClusterName)),
+		Description:       aws.String("Security group for the nodes of the cluster"),
+		VpcId:             vpc.Vpc.VpcId,
+		TagSpecifications: env.tagSpecifications(ec2.ResourceTypeSecurityGroup, fmt.Sprintf("%s-node", env.ClusterName)),
+	})
+	Expect(err).ToNot(HaveOccurred())
+	f.SecurityGroupID = lo.FromPtr(sg.GroupId)
+
+	// The emulators are seeded with AMIs, so the parameter of the EKS optimized AMI points at one of them
+	images, err := env.EC2API.DescribeImagesWithContext(env.Context, &ec2.DescribeImagesInput{
+		Filters: []*ec2.Filter{{Name: aws.String("architecture"), Values: []*string{aws.String(ec2.ArchitectureValuesX8664)}}},
+	})
+	Expect(err).ToNot(HaveOccurred())
+	Expect(images.Images).ToNot(BeEmpty())
+	f.ImageID = lo.FromPtr(images.Images[0].ImageId)
+	f.ImageParameter = fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2023/x86_64/standard/recommended/image_id", f.KubernetesVersion)
+	_, err = env.SSMAPI.PutParameterWithContext(env.Context, &ssm.PutParameterInput{
+		Name:      aws.String(f.ImageParameter),
+		Value:     aws.String(f.ImageID),
+		Type:      aws.String(ssm.ParameterTypeString),
+		Overwrite: aws.Bool(true),
+	})
+	Expect(err).ToNot(HaveOccurred())
+
+	queue, err := env.SQSAPI.CreateQueueWithContext(env.Context, &sqs.CreateQueueInput{
+		QueueName: aws.String(f.QueueName),
+		Attributes: map[string]*string{
+			sqs.QueueAttributeNameMessageRetentionPeriod: aws.String("300"),
+		},
+	})
+	Expect(err).ToNot(HaveOccurred())
+	f.QueueURL = lo.FromPtr(queue.QueueUrl)
+	return f
+}
+
+// ExpectFixturesDeleted deletes the fixtures, so that the suite can be rerun against the same emulator
+func (env *Environment) ExpectFixturesDeleted(f *Fixtures) {
+	_, err := env.SQSAPI.DeleteQueueWithContext(env.Context, &sqs.DeleteQueueInput{QueueUrl: aws.String(f.QueueURL)})
+	Expect(err).ToNot(HaveOccurred())
+	_, err = env.SSMAPI.DeleteParameterWithContext(env.Context, &ssm.DeleteParameterInput{Name: aws.String(f.ImageParameter)})
+	Expect(err).ToNot(HaveOccurred())
+	_, err = env.EC2API.DeleteSecurityGroupWithContext(env.Context, &ec2.DeleteSecurityGroupInput{GroupId: aws.String(f.SecurityGroupID)})
+	Expect(err).ToNot(HaveOccurred())
+	for _, id := range f.SubnetIDs {
+		_, err = env.EC2API.DeleteSubnetWithContext(env.Context, &ec2.DeleteSubnetInput{SubnetId: aws.String(id)})
+		Expect(err).ToNot(HaveOccurred())
+	}
+	_, err = env.EC2API.DeleteVpcWithContext(env.Context, &ec2.DeleteVpcInput{VpcId: aws.String(f.VPCID)})
+	Expect(err).ToNot(HaveOccurred())
+}
+
+func (env *Environment) tagSpecifications(resourceType string, name string) []*ec2.TagSpecification {
+	return []*ec2.TagSpecification{{
+		ResourceType: aws.String(resourceType),
+		Tags: []*ec2.Tag{
+			{Key: aws.String("Name"), Value: aws.String(name)},
+			{Key: aws.String(DiscoveryTagKey), Value: aws.String(env.ClusterName)},
+		},
+	}}
+}
@@ -0,0 +1,88 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localstack_test
+
+import (
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+	"github.com/aws/karpenter-provider-aws/test/pkg/environment/localstack"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EC2", func() {
+	var nodeClass *v1.EC2NodeClass
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass(v1.EC2NodeClass{
+			Spec: v1.EC2NodeClassSpec{
+				SubnetSelectorTerms:        []v1.SubnetSelectorTerm{{Tags: map[string]string{localstack.DiscoveryTagKey: env.ClusterName}}},
+				SecurityGroupSelectorTerms: []v1.SecurityGroupSelectorTerm{{Tags: map[string]string{localstack.DiscoveryTagKey: env.ClusterName}}},
+			},
+		})
+	})
+	Context("Subnets", func() {
+		var subnetProvider *subnet.DefaultProvider
+		BeforeEach(func() {
+			subnetProvider = subnet.NewDefaultProvider(env.EC2API, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AvailableIPAddressTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AssociatePublicIPAddressTTL, awscache.DefaultCleanupInterval))
+		})
+		It("should discover the subnets of the cluster by tags", func() {
+			subnets, err := subnetProvider.List(env.Context, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lo.Map(subnets, func(s *ec2.Subnet, _ int) string { return lo.FromPtr(s.SubnetId) })).To(ConsistOf(fixtures.SubnetIDs))
+			Expect(lo.Map(subnets, func(s *ec2.Subnet, _ int) string { return lo.FromPtr(s.AvailabilityZone) })).To(ConsistOf(fixtures.Zones))
+		})
+		It("should discover a subnet by id", func() {
+			nodeClass.Spec.SubnetSelectorTerms = []v1.SubnetSelectorTerm{{ID: fixtures.SubnetIDs[0]}}
+			subnets, err := subnetProvider.List(env.Context, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(subnets).To(HaveLen(1))
+			Expect(lo.FromPtr(subnets[0].SubnetId)).To(Equal(fixtures.SubnetIDs[0]))
+		})
+		It("should report that the subnets of the cluster are private", func() {
+			subnets, err := subnetProvider.List(env.Context, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			public, err := subnetProvider.ListPublic(env.Context, subnets)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(public).To(BeEmpty())
+		})
+	})
+	Context("Security Groups", func() {
+		var securityGroupProvider *securitygroup.DefaultProvider
+		BeforeEach(func() {
+			securityGroupProvider = securitygroup.NewDefaultProvider(env.EC2API, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+		})
+		It("should discover the security group of the cluster by tags", func() {
+			securityGroups, err := securityGroupProvider.List(env.Context, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(securityGroups).To(HaveLen(1))
+			Expect(lo.FromPtr(securityGroups[0].GroupId)).To(Equal(fixtures.SecurityGroupID))
+		})
+		It("should discover the security group of the cluster by name", func() {
+			nodeClass.Spec.SecurityGroupSelectorTerms = []v1.SecurityGroupSelectorTerm{{Name: env.ClusterName + "-node"}}
+			securityGroups, err := securityGroupProvider.List(env.Context, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(securityGroups).To(HaveLen(1))
+			Expect(lo.FromPtr(securityGroups[0].GroupId)).To(Equal(fixtures.SecurityGroupID))
+		})
+	})
+})
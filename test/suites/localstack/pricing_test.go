@@ -0,0 +1,57 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localstack_test
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/samber/lo"
+
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pricing", func() {
+	var pricingProvider *pricing.DefaultProvider
+	BeforeEach(func() {
+		pricingProvider = pricing.NewDefaultProvider(env.Context, env.PricingAPI, env.EC2API, env.Region)
+	})
+	It("should update the spot prices from the spot price history", func() {
+		Expect(pricingProvider.UpdateSpotPricing(env.Context)).To(Succeed())
+		Expect(pricingProvider.SpotLastUpdated()).ToNot(BeZero())
+		Expect(lo.SomeBy(pricingProvider.InstanceTypes(), func(instanceType string) bool {
+			return lo.SomeBy(fixtures.Zones, func(zone string) bool {
+				price, ok := pricingProvider.SpotPrice(instanceType, zone)
+				return ok && price > 0
+			})
+		})).To(BeTrue())
+	})
+	It("should update the on-demand prices from the price list", func() {
+		err := pricingProvider.UpdateOnDemandPricing(env.Context)
+		// The Pricing API is only emulated by LocalStack Pro, so the community image answers with an error
+		var aerr awserr.Error
+		if errors.As(err, &aerr) && lo.Contains([]string{"InternalFailure", "NotImplemented", "UnknownOperationException"}, aerr.Code()) {
+			Skip("the emulator doesn't implement the Pricing API")
+		}
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pricingProvider.OnDemandLastUpdated()).ToNot(BeZero())
+		price, ok := pricingProvider.OnDemandPrice("m5.large")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically(">", 0))
+	})
+})
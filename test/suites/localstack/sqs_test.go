@@ -0,0 +1,75 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localstack_test
+
+import (
+	"time"
+
+	servicesqs "github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/samber/lo"
+
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/spotinterruption"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/sqs"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SQS", func() {
+	var sqsProvider *sqs.DefaultProvider
+	BeforeEach(func() {
+		var err error
+		sqsProvider, err = sqs.NewDefaultProvider(env.SQSAPI, fixtures.QueueURL)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = env.SQSAPI.PurgeQueueWithContext(env.Context, &servicesqs.PurgeQueueInput{QueueUrl: lo.ToPtr(fixtures.QueueURL)})
+		Expect(err).ToNot(HaveOccurred())
+	})
+	It("should receive, parse and delete a spot interruption warning", func() {
+		// The message has the shape of the events that EventBridge delivers to the interruption queue
+		_, err := sqsProvider.SendMessage(env.Context, spotinterruption.Message{
+			Metadata: messages.Metadata{
+				Version:    "0",
+				Account:    "000000000000",
+				DetailType: "EC2 Spot Instance Interruption Warning",
+				ID:         "1e5527d7-bb36-4607-3370-4164db56a40e",
+				Region:     env.Region,
+				Resources:  []string{"arn:aws:ec2:" + env.Region + ":000000000000:instance/i-0b2b6a5f9d0c5e4b1"},
+				Source:     "aws.ec2",
+				Time:       time.Now(),
+			},
+			Detail: spotinterruption.Detail{
+				InstanceID:     "i-0b2b6a5f9d0c5e4b1",
+				InstanceAction: "terminate",
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		var received []*servicesqs.Message
+		Eventually(func(g Gomega) {
+			received, err = sqsProvider.GetSQSMessages(env.Context)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(received).To(HaveLen(1))
+		}).WithTimeout(time.Minute).Should(Succeed())
+
+		msg, err := interruption.NewEventParser(interruption.DefaultParsers...).Parse(lo.FromPtr(received[0].Body))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(msg.Kind()).To(Equal(messages.SpotInterruptionKind))
+		Expect(msg.EC2InstanceIDs()).To(ConsistOf("i-0b2b6a5f9d0c5e4b1"))
+
+		Expect(sqsProvider.DeleteSQSMessage(env.Context, received[0])).To(Succeed())
+	})
+})
@@ -0,0 +1,44 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localstack_test
+
+import (
+	"fmt"
+
+	"github.com/patrickmn/go-cache"
+
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/ssm"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SSM", func() {
+	var ssmProvider *ssm.DefaultProvider
+	BeforeEach(func() {
+		ssmProvider = ssm.NewDefaultProvider(env.SSMAPI, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+	})
+	It("should list the EKS optimized AMI parameters of a Kubernetes version", func() {
+		parameters, err := ssmProvider.List(env.Context, fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2023", fixtures.KubernetesVersion))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parameters).To(HaveKeyWithValue(fixtures.ImageParameter, fixtures.ImageID))
+	})
+	It("should return no parameters for a path that doesn't exist", func() {
+		parameters, err := ssmProvider.List(env.Context, "/aws/service/eks/optimized-ami/0.0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parameters).To(BeEmpty())
+	})
+})
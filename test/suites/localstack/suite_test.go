@@ -0,0 +1,42 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localstack_test
+
+import (
+	"testing"
+
+	"github.com/aws/karpenter-provider-aws/test/pkg/environment/localstack"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var env *localstack.Environment
+var fixtures *localstack.Fixtures
+
+func TestLocalStack(t *testing.T) {
+	if !localstack.Enabled() {
+		t.Skipf("%s isn't set, run \"make localstack-test\" to run the suite against LocalStack", localstack.EndpointEnvVar)
+	}
+	RegisterFailHandler(Fail)
+	BeforeSuite(func() {
+		env = localstack.NewEnvironment(t)
+		fixtures = env.ExpectFixturesCreated()
+	})
+	AfterSuite(func() {
+		env.ExpectFixturesDeleted(fixtures)
+	})
+	RunSpecs(t, "LocalStack")
+}